@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+
+	"team-assistant/internal/config"
+	"team-assistant/internal/model"
+	"team-assistant/internal/service"
+)
+
+// 导出工作量统计 CSV（与 GET /api/stats.csv 相同），如:
+// go run ./cmd/exportstats -start 2024-01-01 -end 2024-01-31 -messages -o workload.csv
+func main() {
+	configFile := flag.String("f", "etc/config.yaml", "config file path")
+	start := flag.String("start", "", "start date (2006-01-02), default 7 days ago")
+	end := flag.String("end", "", "end date (2006-01-02), default now")
+	messages := flag.Bool("messages", false, "include message counts per person")
+	output := flag.String("o", "", "output file (default stdout)")
+	flag.Parse()
+
+	startTime := time.Now().AddDate(0, 0, -7)
+	endTime := time.Now()
+	var err error
+	if *start != "" {
+		if startTime, err = time.Parse("2006-01-02", *start); err != nil {
+			log.Fatalf("Invalid -start: %v", err)
+		}
+	}
+	if *end != "" {
+		if endTime, err = time.Parse("2006-01-02", *end); err != nil {
+			log.Fatalf("Invalid -end: %v", err)
+		}
+	}
+
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	dsn, err := cfg.MySQL.DSN()
+	if err != nil {
+		log.Fatalf("Invalid MySQL config: %v", err)
+	}
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		log.Fatalf("Failed to connect to MySQL: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	stats, err := model.NewGitCommitModel(db).GetAllStats(ctx, startTime, endTime)
+	if err != nil {
+		log.Fatalf("Failed to get stats: %v", err)
+	}
+	var messageCounts map[string]int
+	if *messages {
+		if messageCounts, err = model.NewChatMessageModel(db).CountBySender(ctx, startTime, endTime); err != nil {
+			log.Fatalf("Failed to count messages: %v", err)
+		}
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			log.Fatalf("Failed to create %s: %v", *output, err)
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := service.WriteWorkloadCSV(w, stats, messageCounts); err != nil {
+		log.Fatalf("Failed to write CSV: %v", err)
+	}
+	log.Printf("Exported %d authors (%s ~ %s)", len(stats), startTime.Format("2006-01-02"), endTime.Format("2006-01-02"))
+}
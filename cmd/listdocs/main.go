@@ -5,19 +5,19 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
-	"os"
 
-	"gopkg.in/yaml.v3"
 	"team-assistant/internal/config"
 	"team-assistant/pkg/lark"
 )
 
 func main() {
 	// 加载配置
-	data, _ := os.ReadFile("etc/config.yaml")
-	var cfg config.Config
-	yaml.Unmarshal(data, &cfg)
+	cfg, err := config.Load("etc/config.yaml")
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
 
 	client := lark.NewClient(cfg.Lark.Domain, cfg.Lark.AppID, cfg.Lark.AppSecret)
 	ctx := context.Background()
@@ -28,7 +28,7 @@ func main() {
 		return
 	}
 
-	fmt.Println("=== 查询应用可访问的文档 ===\n")
+	fmt.Print("=== 查询应用可访问的文档 ===\n\n")
 
 	// 1. 查询云盘根目录文件列表
 	fmt.Println("【1. 云盘文件列表】")
@@ -97,7 +97,7 @@ func listDriveFiles(ctx context.Context, domain, token, folderToken string) {
 			fmt.Printf("   URL: %s\n", file.URL)
 		}
 	}
-	fmt.Printf("\n共 %d 个文件, 还有更多: %v\n", len(result.Data.Files), result.HasMore)
+	fmt.Printf("\n共 %d 个文件, 还有更多: %v\n", len(result.Data.Files), result.Data.HasMore)
 }
 
 // listWikiSpaces 列出知识库空间
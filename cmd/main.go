@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
@@ -14,43 +15,73 @@ import (
 	"team-assistant/internal/config"
 	"team-assistant/internal/handler"
 	"team-assistant/internal/svc"
-
-	"gopkg.in/yaml.v3"
 )
 
-var configFile = flag.String("f", "etc/config.yaml", "the config file")
+var (
+	configFile = flag.String("f", "etc/config.yaml", "the config file")
+	offline    = flag.Bool("offline", false, "safe mode: disable all external LLM/embedding/Dify calls (same as SafeMode: true)")
+	migrateFTS = flag.Bool("migrate-fulltext", false, "create the ngram FULLTEXT index on chat_messages.content if missing, then exit")
+)
 
 func main() {
 	flag.Parse()
 
 	// 加载配置
-	data, err := os.ReadFile(*configFile)
+	cfg, err := config.Load(*configFile)
 	if err != nil {
-		log.Fatalf("Failed to read config file: %v", err)
+		log.Fatalf("Failed to load config: %v", err)
 	}
-
-	var cfg config.Config
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
-		log.Fatalf("Failed to parse config: %v", err)
+	if *offline {
+		cfg.SafeMode = true
+	}
+	if cfg.SafeMode {
+		log.Println("Safe mode enabled: LLM returns canned responses, vector search and Dify disabled")
 	}
 
 	// 初始化服务上下文
-	svcCtx, err := svc.NewServiceContext(cfg)
+	svcCtx, err := svc.NewServiceContext(*cfg)
 	if err != nil {
 		log.Fatalf("Failed to initialize service context: %v", err)
 	}
 	defer svcCtx.Close()
 
+	// 全文索引迁移（大表上耗时较长，单独执行后退出）
+	if *migrateFTS {
+		created, err := svcCtx.MessageModel.EnsureFullTextIndex(context.Background())
+		if err != nil {
+			log.Fatalf("Failed to create full-text index: %v", err)
+		}
+		if created {
+			log.Println("Full-text index created on chat_messages.content")
+		} else {
+			log.Println("Full-text index already exists on chat_messages.content")
+		}
+		return
+	}
+
 	// 启动 GitHub 定时采集器（每小时采集一次）
 	var githubCollector *collector.GitHubCollector
 	if cfg.GitHub.Token != "" {
 		githubCollector = collector.NewGitHubCollector(svcCtx, 1*time.Hour)
-		go githubCollector.Start()
+		svcCtx.Lifecycle.GoService("github_collector", githubCollector.Start, githubCollector.Stop)
 		log.Println("GitHub collector enabled")
 	} else {
 		log.Println("GitHub collector disabled (no token configured)")
 	}
 
+	// Ollama 保活：定期发送极短的 embedding 请求，避免模型空闲卸载后首次检索超时
+	if interval := cfg.VectorDB.KeepAliveInterval; interval > 0 && svcCtx.Services.RAG.IsEnabled() {
+		svcCtx.Lifecycle.Go("embedding_keepalive", func(ctx context.Context) {
+			svcCtx.Services.RAG.KeepEmbeddingAlive(ctx, time.Duration(interval)*time.Second)
+		})
+		log.Printf("Embedding keep-alive enabled (every %ds)", interval)
+	}
+
+	// 定期刷新向量集合统计缓存（状态查询直接读取缓存）
+	if svcCtx.Services.RAG.IsEnabled() {
+		svcCtx.Lifecycle.Go("rag_stats_refresh", svcCtx.Services.RAG.RefreshStatsPeriodically)
+	}
+
 	// 消息同步器（仅用于创建任务，不再自动运行同步）
 	// 同步任务由独立的 syncworker 进程处理
 	msgSyncer := collector.NewMessageSyncer(svcCtx)
@@ -61,8 +92,7 @@ func main() {
 	if cfg.Dify.Enabled && cfg.Dify.DatasetID != "" {
 		difySyncer = collector.NewDifySyncer(svcCtx)
 		if difySyncer != nil {
-			go difySyncer.Start()
-			log.Println("Dify syncer started")
+			svcCtx.Lifecycle.GoService("dify_syncer", difySyncer.Start, difySyncer.Stop)
 		}
 	} else {
 		log.Println("Dify syncer disabled (not enabled or no dataset ID)")
@@ -74,21 +104,33 @@ func main() {
 	// 飞书Webhook
 	larkHandler := handler.NewLarkWebhookHandler(svcCtx)
 	larkHandler.SetMessageSyncer(msgSyncer)
+	if difySyncer != nil && cfg.Dify.ExportReports {
+		larkHandler.SetReportExporter(difySyncer)
+		log.Println("Report export to Dify enabled")
+	}
 	mux.HandleFunc("/webhook/lark", larkHandler.Handle)
 
 	// GitHub Webhook
 	githubHandler := handler.NewGitHubWebhookHandler(svcCtx)
 	mux.HandleFunc("/webhook/github", githubHandler.Handle)
 
+	// 外部系统消息接入（Prometheus/Grafana 告警等）
+	mux.HandleFunc("/webhook/ingest", handler.NewIngestWebhookHandler(svcCtx).Handle)
+
 	// 健康检查
 	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("ok"))
 	})
+	// 就绪检查（后台任务状态）
+	mux.HandleFunc("/readyz", handler.NewReadyHandler(svcCtx).Handle)
 
 	// API路由
-	mux.HandleFunc("/api/stats", handler.NewStatsHandler(svcCtx).Handle)
+	statsHandler := handler.NewStatsHandler(svcCtx)
+	mux.HandleFunc("/api/stats", statsHandler.Handle)
+	mux.HandleFunc("/api/stats.csv", statsHandler.HandleCSV)
 	mux.HandleFunc("/api/members", handler.NewMemberHandler(svcCtx).Handle)
+	mux.HandleFunc("/api/search", handler.NewSearchHandler(svcCtx).Handle)
 
 	// 手动触发采集
 	mux.HandleFunc("/api/collect", func(w http.ResponseWriter, r *http.Request) {
@@ -113,21 +155,21 @@ func main() {
 		signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 		<-sigChan
 		log.Println("Shutting down server...")
-		if githubCollector != nil {
-			githubCollector.Stop()
+		timeout := time.Duration(cfg.Server.ShutdownTimeout) * time.Second
+		if err := svcCtx.Lifecycle.Stop(timeout); err != nil {
+			log.Printf("Background tasks did not stop cleanly: %v", err)
 		}
 		msgSyncer.Stop()
-		if difySyncer != nil {
-			difySyncer.Stop()
-		}
 		server.Close()
 	}()
 
 	log.Printf("Team Assistant starting on %s", addr)
 	log.Printf("Lark webhook: http://localhost%s/webhook/lark", addr)
 	log.Printf("GitHub webhook: http://localhost%s/webhook/github", addr)
+	log.Printf("Readiness: http://localhost%s/readyz", addr)
 	log.Printf("API endpoints:")
 	log.Printf("  - GET  /api/stats?start=2024-01-01&end=2024-01-31")
+	log.Printf("  - GET  /api/stats.csv?start=2024-01-01&end=2024-01-31&messages=1")
 	log.Printf("  - GET  /api/members")
 	log.Printf("  - POST /api/members")
 	log.Printf("  - POST /api/collect (trigger GitHub collection)")
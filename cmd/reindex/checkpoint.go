@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// checkpoint 重建索引的断点信息
+// 消息按写入顺序（chat_messages.id）正序处理，Offset 之前的消息都已处理完成，
+// MessageID 是第 Offset 条（即最后一条已完成）的消息ID；Indexed/Failed 为截至断点的累计数
+type checkpoint struct {
+	MessageID string    `json:"message_id"`
+	Offset    int       `json:"offset"`
+	Total     int       `json:"total"`
+	Indexed   int64     `json:"indexed"`
+	Failed    int64     `json:"failed"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// loadCheckpoint 读取断点文件，文件不存在时返回 nil
+func loadCheckpoint(path string) (*checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+
+	var cp checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint: %w", err)
+	}
+	return &cp, nil
+}
+
+// saveCheckpoint 写入断点文件（先写临时文件再重命名，避免进程被杀时写出半个文件）
+func saveCheckpoint(path string, cp *checkpoint) error {
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal checkpoint: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to create checkpoint: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	return nil
+}
+
+// resumeOffset 计算续跑时应跳过的消息数
+// 优先按断点中的消息ID定位（-limit 取最新消息时，期间有新消息写入会把最早的消息挤出，偏移量会变化），
+// 找不到时退回到记录的偏移量；新写入的消息总排在断点之后
+func resumeOffset(messageIDs []string, cp *checkpoint) int {
+	if cp == nil || cp.Offset <= 0 {
+		return 0
+	}
+
+	if cp.MessageID != "" {
+		for i, id := range messageIDs {
+			if id == cp.MessageID {
+				return i + 1
+			}
+		}
+	}
+
+	if cp.Offset > len(messageIDs) {
+		return len(messageIDs)
+	}
+	return cp.Offset
+}
+
+// progressTracker 记录并发 worker 的完成情况，计算连续完成的位置（水位线）
+// 只有水位线之前的消息全部完成，才能安全地作为断点
+type progressTracker struct {
+	mu        sync.Mutex
+	base      int
+	done      map[int]bool
+	watermark int
+}
+
+// newProgressTracker 创建进度跟踪器，base 为续跑时跳过的消息数
+func newProgressTracker(base int) *progressTracker {
+	return &progressTracker{
+		base:      base,
+		done:      make(map[int]bool),
+		watermark: base,
+	}
+}
+
+// MarkDone 标记第 index 条消息（按查询顺序，从 0 开始）已处理完成
+func (t *progressTracker) MarkDone(index int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.done[index] = true
+	for t.done[t.watermark] {
+		delete(t.done, t.watermark)
+		t.watermark++
+	}
+}
+
+// Watermark 返回连续完成的消息数
+func (t *progressTracker) Watermark() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.watermark
+}
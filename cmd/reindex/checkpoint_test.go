@@ -0,0 +1,106 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckpointReadWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reindex.checkpoint.json")
+
+	cp, err := loadCheckpoint(path)
+	if err != nil {
+		t.Fatalf("Missing checkpoint should not be an error: %v", err)
+	}
+	if cp != nil {
+		t.Fatalf("Expected nil checkpoint, got %+v", cp)
+	}
+
+	want := &checkpoint{MessageID: "om_100", Offset: 100, Total: 500, Indexed: 98, Failed: 2}
+	if err := saveCheckpoint(path, want); err != nil {
+		t.Fatalf("saveCheckpoint failed: %v", err)
+	}
+
+	got, err := loadCheckpoint(path)
+	if err != nil {
+		t.Fatalf("loadCheckpoint failed: %v", err)
+	}
+	if got.MessageID != want.MessageID || got.Offset != want.Offset || got.Total != want.Total ||
+		got.Indexed != want.Indexed || got.Failed != want.Failed {
+		t.Errorf("Checkpoint mismatch: got %+v, want %+v", got, want)
+	}
+
+	// 覆盖写入
+	want.Offset = 200
+	want.MessageID = "om_200"
+	if err := saveCheckpoint(path, want); err != nil {
+		t.Fatalf("saveCheckpoint failed: %v", err)
+	}
+	got, _ = loadCheckpoint(path)
+	if got.Offset != 200 || got.MessageID != "om_200" {
+		t.Errorf("Expected overwritten checkpoint, got %+v", got)
+	}
+
+	// 不应残留临时文件
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("Expected only checkpoint file, got %d entries", len(entries))
+	}
+}
+
+func TestLoadCheckpointInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reindex.checkpoint.json")
+	os.WriteFile(path, []byte("not json"), 0644)
+
+	if _, err := loadCheckpoint(path); err == nil {
+		t.Error("Expected error for invalid checkpoint")
+	}
+}
+
+func TestResumeOffset(t *testing.T) {
+	// 按写入顺序正序
+	ids := []string{"om_1", "om_2", "om_3", "om_4", "om_5"}
+
+	tests := []struct {
+		name string
+		ids  []string
+		cp   *checkpoint
+		want int
+	}{
+		{"无断点", ids, nil, 0},
+		{"按消息ID定位", ids, &checkpoint{MessageID: "om_3", Offset: 3}, 3},
+		{"新写入的消息排在断点之后不被跳过", append(append([]string{}, ids...), "om_6", "om_7"), &checkpoint{MessageID: "om_3", Offset: 3}, 3},
+		{"最早的消息被 limit 挤出时按消息ID定位", []string{"om_2", "om_3", "om_4", "om_5", "om_6"}, &checkpoint{MessageID: "om_3", Offset: 3}, 2},
+		{"消息ID找不到时使用偏移量", ids, &checkpoint{MessageID: "om_x", Offset: 2}, 2},
+		{"偏移量超出范围", ids, &checkpoint{Offset: 10}, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resumeOffset(tt.ids, tt.cp); got != tt.want {
+				t.Errorf("resumeOffset() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProgressTrackerWatermark(t *testing.T) {
+	tracker := newProgressTracker(10)
+
+	tracker.MarkDone(11)
+	tracker.MarkDone(12)
+	if w := tracker.Watermark(); w != 10 {
+		t.Errorf("Watermark should wait for index 10, got %d", w)
+	}
+
+	tracker.MarkDone(10)
+	if w := tracker.Watermark(); w != 13 {
+		t.Errorf("Expected watermark 13, got %d", w)
+	}
+
+	tracker.MarkDone(14)
+	if w := tracker.Watermark(); w != 13 {
+		t.Errorf("Expected watermark 13, got %d", w)
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+// existsBatchSize 每次向 Qdrant 查询是否存在的 ID 数
+const existsBatchSize = 256
+
+// pointChecker 查询数据点是否已存在（*vectordb.QdrantClient 实现）
+type pointChecker interface {
+	PointsExist(ctx context.Context, collection string, ids []string) (map[string]bool, error)
+}
+
+// existingPoints 按集合分批查询已存在的数据点 ID，idsByCollection 为集合名 -> 数据点 ID
+func existingPoints(ctx context.Context, checker pointChecker, idsByCollection map[string][]string) (map[string]bool, error) {
+	existing := make(map[string]bool)
+	for collection, ids := range idsByCollection {
+		for start := 0; start < len(ids); start += existsBatchSize {
+			end := start + existsBatchSize
+			if end > len(ids) {
+				end = len(ids)
+			}
+			found, err := checker.PointsExist(ctx, collection, ids[start:end])
+			if err != nil {
+				return nil, fmt.Errorf("check existing points in %s: %w", collection, err)
+			}
+			for id := range found {
+				existing[id] = true
+			}
+		}
+	}
+	return existing, nil
+}
+
+// messagePointIDs 消息可能对应的数据点 ID：整条消息索引，或长消息分块后的第一块
+// 与 RAGService.PointExists 的判断保持一致
+func messagePointIDs(messageID string) []string {
+	return []string{messageIDToUUID(messageID), messageIDToUUID(messageID + "_chunk_0")}
+}
+
+// messageIndexed 消息是否已在 Qdrant 中（任一可能的数据点 ID 已存在）
+func messageIndexed(existing map[string]bool, messageID string) bool {
+	for _, id := range messagePointIDs(messageID) {
+		if existing[id] {
+			return true
+		}
+	}
+	return false
+}
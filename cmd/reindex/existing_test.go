@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// fakeChecker 记录每次查询的集合和 ID 数，existing 中的 ID 视为已存在
+type fakeChecker struct {
+	existing map[string]bool
+	calls    []string
+	err      error
+}
+
+func (f *fakeChecker) PointsExist(_ context.Context, collection string, ids []string) (map[string]bool, error) {
+	f.calls = append(f.calls, fmt.Sprintf("%s:%d", collection, len(ids)))
+	if f.err != nil {
+		return nil, f.err
+	}
+	found := make(map[string]bool)
+	for _, id := range ids {
+		if f.existing[id] {
+			found[id] = true
+		}
+	}
+	return found, nil
+}
+
+func TestExistingPoints(t *testing.T) {
+	ids := make([]string, existsBatchSize+10)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("id-%d", i)
+	}
+	checker := &fakeChecker{existing: map[string]bool{"id-0": true, ids[len(ids)-1]: true, "other-1": true}}
+
+	got, err := existingPoints(context.Background(), checker, map[string][]string{
+		"messages_oc_a": ids,
+		"messages_oc_b": {"other-1", "other-2"},
+	})
+	if err != nil {
+		t.Fatalf("existingPoints() error: %v", err)
+	}
+	if len(got) != 3 || !got["id-0"] || !got[ids[len(ids)-1]] || !got["other-1"] {
+		t.Errorf("existingPoints() = %v", got)
+	}
+	// 超过一批的集合分两次查询
+	if len(checker.calls) != 3 {
+		t.Errorf("PointsExist calls = %v, want 2 batches for oc_a and 1 for oc_b", checker.calls)
+	}
+}
+
+func TestExistingPointsError(t *testing.T) {
+	checker := &fakeChecker{err: errors.New("connection refused")}
+	if _, err := existingPoints(context.Background(), checker, map[string][]string{"messages": {"id-1"}}); err == nil {
+		t.Error("Expected error when Qdrant is unavailable")
+	}
+}
+
+func TestMessageIndexedChunked(t *testing.T) {
+	existing := map[string]bool{
+		messageIDToUUID("om_whole"):        true,
+		messageIDToUUID("om_long_chunk_0"): true,
+	}
+	if !messageIndexed(existing, "om_whole") {
+		t.Error("Expected whole message to be indexed")
+	}
+	// 长消息分块索引，只有 <id>_chunk_0 存在
+	if !messageIndexed(existing, "om_long") {
+		t.Error("Expected chunked message to be indexed")
+	}
+	if messageIndexed(existing, "om_new") {
+		t.Error("Expected new message not to be indexed")
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/redis/go-redis/v9"
+
+	"team-assistant/internal/config"
+	"team-assistant/internal/repository"
+)
+
+// bumpIndexVersion 递增 Redis 中的索引版本，通知服务进程刷新集合统计缓存
+// 未配置 Redis 时跳过，服务进程的统计缓存会在定期刷新时更新
+func bumpIndexVersion(ctx context.Context, cfg config.RedisConfig) {
+	if cfg.Host == "" {
+		return
+	}
+	rdb := redis.NewClient(&redis.Options{
+		Addr:     cfg.Host,
+		Password: cfg.Password,
+		DB:       cfg.DB,
+	})
+	defer rdb.Close()
+
+	if err := repository.NewIndexVersionRepository(rdb).Bump(ctx); err != nil {
+		log.Printf("Failed to bump index version, service stats may stay stale until next refresh: %v", err)
+	}
+}
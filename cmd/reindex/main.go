@@ -9,15 +9,20 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
 	"sync"
 	"sync/atomic"
+	"syscall"
 	"time"
 
 	_ "github.com/go-sql-driver/mysql"
-	"gopkg.in/yaml.v3"
 
+	"team-assistant/internal/backoff"
 	"team-assistant/internal/config"
-	"team-assistant/pkg/embedding"
+	"team-assistant/internal/model"
+	"team-assistant/internal/service"
+	"team-assistant/internal/svc"
+	"team-assistant/pkg/llm"
 	"team-assistant/pkg/vectordb"
 )
 
@@ -34,17 +39,36 @@ func main() {
 	limit := flag.Int("limit", 0, "Max messages to index (0 = all)")
 	workers := flag.Int("workers", 5, "Number of concurrent workers")
 	recreate := flag.Bool("recreate", false, "Recreate collection (required when changing embedding model)")
+	resume := flag.Bool("resume", false, "Resume from checkpoint file, skipping already processed messages")
+	checkpointPath := flag.String("checkpoint", "reindex.checkpoint.json", "Checkpoint file path")
+	retries := flag.Int("retries", 3, "Max retries per message on embedding/upsert errors")
+	skipExisting := flag.Bool("skip-existing", false, "Skip messages whose point already exists in Qdrant")
+	offline := flag.Bool("offline", false, "safe mode: refuse to run, reindexing needs embedding calls (same as SafeMode: true)")
 	flag.Parse()
 
+	if *resume && *recreate {
+		log.Fatal("-resume cannot be used with -recreate")
+	}
+	if *skipExisting && *recreate {
+		log.Fatal("-skip-existing cannot be used with -recreate")
+	}
+	// 断点按消息在列表中的位置续跑，和跳过已存在的消息一起用会错位；-skip-existing 本身即可续跑
+	if *skipExisting && *resume {
+		log.Fatal("-skip-existing cannot be used with -resume")
+	}
+
 	// 加载配置
-	data, err := os.ReadFile("etc/config.yaml")
+	cfg, err := config.Load("etc/config.yaml")
 	if err != nil {
-		log.Fatalf("Failed to read config: %v", err)
+		log.Fatalf("Failed to load config: %v", err)
 	}
 
-	var cfg config.Config
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
-		log.Fatalf("Failed to parse config: %v", err)
+	if *offline {
+		cfg.SafeMode = true
+	}
+	// 安全模式禁止所有外部 LLM/embedding 调用，重建索引无法进行
+	if cfg.SafeMode {
+		log.Fatal("Safe mode is enabled: reindex needs embedding calls, which safe mode disables")
 	}
 
 	// 检查 VectorDB 是否启用
@@ -53,12 +77,10 @@ func main() {
 	}
 
 	// 连接数据库
-	dsn := fmt.Sprintf("%s:%s@tcp(%s)/%s?parseTime=true&tls=skip-verify",
-		cfg.MySQL.User,
-		cfg.MySQL.Password,
-		cfg.MySQL.Host,
-		cfg.MySQL.Database,
-	)
+	dsn, err := cfg.MySQL.DSN()
+	if err != nil {
+		log.Fatalf("Invalid MySQL config: %v", err)
+	}
 	db, err := sql.Open("mysql", dsn)
 	if err != nil {
 		log.Fatalf("Failed to connect to MySQL: %v", err)
@@ -71,13 +93,24 @@ func main() {
 		dimension = 768 // 默认维度
 	}
 
-	embClient := embedding.NewOllamaClientWithDimension(cfg.VectorDB.OllamaEndpoint, cfg.VectorDB.EmbeddingModel, dimension)
 	vectorClient := vectordb.NewQdrantClient(cfg.VectorDB.QdrantEndpoint)
 
 	ctx := context.Background()
 
+	// 每群一个集合时按 chat_id 写入 <CollectionName>_<chat_id>，集合在读取消息后按群创建
+	// 从 single 切换到 per_chat 时运行一次即可把历史消息迁移到各群集合
+	perChat := cfg.VectorDB.CollectionStrategy == service.CollectionStrategyPerChat
+	collectionFor := func(chatID string) string {
+		if perChat {
+			return service.PerChatCollectionName(cfg.VectorDB.CollectionName, chatID)
+		}
+		return cfg.VectorDB.CollectionName
+	}
+
 	// 重建或创建集合
-	if *recreate {
+	if perChat {
+		log.Printf("Using per-chat collections with prefix %s_", cfg.VectorDB.CollectionName)
+	} else if *recreate {
 		log.Printf("Recreating collection %s with dimension %d...", cfg.VectorDB.CollectionName, dimension)
 		if err := vectorClient.RecreateCollection(ctx, cfg.VectorDB.CollectionName, dimension); err != nil {
 			log.Fatalf("Failed to recreate collection: %v", err)
@@ -90,15 +123,23 @@ func main() {
 		}
 	}
 
-	// 查询消息
+	// 与 webhook/同步共用 RAG 服务的索引流程（翻译、回复上下文、分块、数字处理、归一化），避免重建后索引方式不一致
+	var llmClient *llm.Client
+	if len(cfg.VectorDB.TranslateChats) > 0 {
+		llmClient = svc.NewLLMClient(cfg.LLM)
+	}
+	senderFilter := service.NewSenderFilter(cfg.Index.ExcludedSenders, false)
+	ragService := svc.NewRAGService(*cfg, llmClient, model.NewChatMessageModel(db), senderFilter, nil)
+
+	// 查询消息：按写入顺序倒序取（-limit 时取最新的消息），读完后翻转为正序处理
 	query := `
 		SELECT m.message_id, m.chat_id, COALESCE(g.chat_name, '') as chat_name,
 			   COALESCE(m.sender_id, '') as sender_id, COALESCE(m.sender_name, '') as sender_name,
-			   m.content, m.created_at
+			   m.content, COALESCE(m.reply_to_id, '') as reply_to_id, m.created_at
 		FROM chat_messages m
 		LEFT JOIN chat_groups g ON m.chat_id = g.chat_id
 		WHERE m.content IS NOT NULL AND m.content != ''
-		ORDER BY m.created_at DESC
+		ORDER BY m.id DESC
 	`
 	if *limit > 0 {
 		query += fmt.Sprintf(" LIMIT %d", *limit)
@@ -118,90 +159,252 @@ func main() {
 		SenderID   string
 		SenderName string
 		Content    string
+		ReplyToID  string // 被回复消息ID
 		CreatedAt  time.Time
 	}
 
+	// 排除的发言人（监控机器人等）和只有@提及的消息不参与索引
+	excluded, mentionOnly := 0, 0
+
 	var messages []Message
 	for rows.Next() {
 		var msg Message
 		if err := rows.Scan(&msg.MessageID, &msg.ChatID, &msg.ChatName,
-			&msg.SenderID, &msg.SenderName, &msg.Content, &msg.CreatedAt); err != nil {
+			&msg.SenderID, &msg.SenderName, &msg.Content, &msg.ReplyToID, &msg.CreatedAt); err != nil {
 			log.Printf("Failed to scan row: %v", err)
 			continue
 		}
+		if senderFilter.Excludes(msg.SenderID, msg.SenderName) {
+			excluded++
+			continue
+		}
+		if !cfg.Index.IndexMentionOnly && service.IsMentionOnly(msg.Content) {
+			mentionOnly++
+			continue
+		}
 		messages = append(messages, msg)
 	}
+	// 按写入顺序正序处理：中断期间新同步的消息排在断点之后，续跑时不会被跳过
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+	if excluded > 0 {
+		log.Printf("Skipped %d messages from excluded senders", excluded)
+	}
+	if mentionOnly > 0 {
+		log.Printf("Skipped %d mention-only messages", mentionOnly)
+	}
 
 	total := len(messages)
 	log.Printf("Found %d messages to index (workers: %d)", total, *workers)
 
+	if perChat {
+		created := make(map[string]bool)
+		for _, msg := range messages {
+			name := collectionFor(msg.ChatID)
+			if created[name] {
+				continue
+			}
+			created[name] = true
+			if *recreate {
+				err = vectorClient.RecreateCollection(ctx, name, dimension)
+			} else {
+				err = vectorClient.CreateCollection(ctx, name, dimension)
+			}
+			if err != nil {
+				log.Fatalf("Failed to prepare collection %s: %v", name, err)
+			}
+		}
+		log.Printf("Prepared %d per-chat collections", len(created))
+	}
+
+	// 跳过 Qdrant 中已存在的消息，重复运行时不再重新生成 embedding
+	alreadyIndexed := 0
+	if *skipExisting && total > 0 {
+		idsByCollection := make(map[string][]string)
+		for _, msg := range messages {
+			name := collectionFor(msg.ChatID)
+			idsByCollection[name] = append(idsByCollection[name], messagePointIDs(msg.MessageID)...)
+		}
+		existing, err := existingPoints(ctx, vectorClient, idsByCollection)
+		if err != nil {
+			log.Fatalf("Failed to check existing points: %v", err)
+		}
+		remaining := messages[:0]
+		for _, msg := range messages {
+			if messageIndexed(existing, msg.MessageID) {
+				alreadyIndexed++
+				continue
+			}
+			remaining = append(remaining, msg)
+		}
+		messages = remaining
+		total = len(messages)
+		log.Printf("Skipping %d messages already in Qdrant, %d left to index", alreadyIndexed, total)
+	}
+
 	if total == 0 {
+		if alreadyIndexed > 0 {
+			log.Printf("Done! All %d messages already indexed", alreadyIndexed)
+		}
+		if *recreate {
+			bumpIndexVersion(ctx, cfg.Redis)
+		}
 		return
 	}
 
-	// 并发处理
+	// 断点续跑：跳过已处理的消息，计数从断点恢复
+	skip := 0
 	var indexed int64
 	var failed int64
-	var wg sync.WaitGroup
-	msgChan := make(chan Message, 100)
+	if *resume {
+		cp, err := loadCheckpoint(*checkpointPath)
+		if err != nil {
+			log.Fatalf("Failed to load checkpoint: %v", err)
+		}
+		if cp == nil {
+			log.Printf("No checkpoint found at %s, starting from scratch", *checkpointPath)
+		} else {
+			messageIDs := make([]string, len(messages))
+			for i, msg := range messages {
+				messageIDs[i] = msg.MessageID
+			}
+			skip = resumeOffset(messageIDs, cp)
+			indexed, failed = cp.Indexed, cp.Failed
+			log.Printf("Resuming from checkpoint (message_id: %s, updated: %s), skipping %d/%d messages",
+				cp.MessageID, cp.UpdatedAt.Format(time.RFC3339), skip, total)
+		}
+	}
 
-	// 启动 worker
-	for i := 0; i < *workers; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for msg := range msgChan {
-				log.Printf("Processing msg %s (len=%d)", msg.MessageID, len(msg.Content))
-				vec, err := embClient.GetEmbedding(ctx, msg.Content)
-				if err != nil {
-					f := atomic.AddInt64(&failed, 1)
-					log.Printf("Embedding error [%d]: %v", f, err)
-					continue
-				}
+	if skip >= total {
+		log.Printf("All messages already processed")
+		os.Remove(*checkpointPath)
+		return
+	}
 
-				log.Printf("Got vector with %d dimensions", len(vec))
+	// 并发处理
+	var wg sync.WaitGroup
+	type job struct {
+		index int
+		msg   Message
+	}
+	jobChan := make(chan job, 100)
+	tracker := newProgressTracker(skip)
+	retryBackoff := backoff.New(time.Second, 10*time.Second)
 
-				if len(vec) == 0 {
-					log.Printf("Empty vector for msg %s", msg.MessageID)
-					atomic.AddInt64(&failed, 1)
-					continue
+	// withRetry 失败时按退避重试，避免 Ollama/Qdrant 偶发抖动导致消息漏索引
+	withRetry := func(op func() error) error {
+		var err error
+		for attempt := 0; attempt <= *retries; attempt++ {
+			if attempt > 0 {
+				if sleepErr := retryBackoff.Sleep(ctx, attempt); sleepErr != nil {
+					return sleepErr
 				}
+			}
+			if err = op(); err == nil {
+				return nil
+			}
+		}
+		return err
+	}
 
-				point := vectordb.Point{
-					ID:     messageIDToUUID(msg.MessageID),
-					Vector: vec,
-					Payload: map[string]interface{}{
-						"message_id":  msg.MessageID,
-						"chat_id":     msg.ChatID,
-						"chat_name":   msg.ChatName,
-						"sender_id":   msg.SenderID,
-						"sender_name": msg.SenderName,
-						"content":     msg.Content,
-						"created_at":  msg.CreatedAt.Format(time.RFC3339),
-					},
-				}
+	// writeCheckpoint 将连续完成的位置写入断点文件
+	writeCheckpoint := func() {
+		w := tracker.Watermark()
+		if w <= 0 {
+			return
+		}
+		cp := &checkpoint{
+			MessageID: messages[w-1].MessageID,
+			Offset:    w,
+			Total:     total,
+			Indexed:   atomic.LoadInt64(&indexed),
+			Failed:    atomic.LoadInt64(&failed),
+			UpdatedAt: time.Now(),
+		}
+		if err := saveCheckpoint(*checkpointPath, cp); err != nil {
+			log.Printf("Failed to save checkpoint: %v", err)
+		}
+	}
 
-				if err := vectorClient.Upsert(ctx, cfg.VectorDB.CollectionName, []vectordb.Point{point}); err != nil {
-					log.Printf("Upsert error: %v", err)
-					atomic.AddInt64(&failed, 1)
-					continue
-				}
+	// processMessage 生成 embedding 并写入 Qdrant（重试耗尽后计为失败）
+	processMessage := func(msg Message) {
+		mv := service.MessageVector{
+			MessageID:  msg.MessageID,
+			ChatID:     msg.ChatID,
+			ChatName:   msg.ChatName,
+			SenderID:   msg.SenderID,
+			SenderName: msg.SenderName,
+			Content:    msg.Content,
+			ReplyToID:  msg.ReplyToID,
+			CreatedAt:  msg.CreatedAt,
+		}
+		if err := withRetry(func() error {
+			return ragService.IndexMessage(ctx, mv)
+		}); err != nil {
+			f := atomic.AddInt64(&failed, 1)
+			log.Printf("Index error for msg %s [%d]: %v", msg.MessageID, f, err)
+			return
+		}
 
-				n := atomic.AddInt64(&indexed, 1)
-				if n%50 == 0 {
-					log.Printf("Progress: %d/%d (%.1f%%)", n, total, float64(n)/float64(total)*100)
-				}
+		n := atomic.AddInt64(&indexed, 1)
+		if n%50 == 0 {
+			log.Printf("Progress: %d/%d (%.1f%%)", n, total, float64(n)/float64(total)*100)
+		}
+	}
+
+	// 启动 worker
+	for i := 0; i < *workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobChan {
+				processMessage(j.msg)
+				tracker.MarkDone(j.index)
 			}
 		}()
 	}
 
+	// 定期写入断点
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	stopCheckpoint := make(chan struct{})
+	checkpointDone := make(chan struct{})
+	go func() {
+		defer close(checkpointDone)
+		ticker := time.NewTicker(10 * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopCheckpoint:
+				return
+			case <-ticker.C:
+				writeCheckpoint()
+			case sig := <-sigChan:
+				// 被中断时写入断点后退出，下次使用 -resume 续跑
+				writeCheckpoint()
+				log.Printf("Received %v, checkpoint saved to %s (offset %d/%d)", sig, *checkpointPath, tracker.Watermark(), total)
+				os.Exit(1)
+			}
+		}
+	}()
+
 	// 发送消息
 	start := time.Now()
-	for _, msg := range messages {
-		msgChan <- msg
+	for i := skip; i < total; i++ {
+		jobChan <- job{index: i, msg: messages[i]}
 	}
-	close(msgChan)
+	close(jobChan)
 	wg.Wait()
+	close(stopCheckpoint)
+	<-checkpointDone
+
+	// 全部完成后删除断点文件，下次 -resume 会从头开始
+	if err := os.Remove(*checkpointPath); err != nil && !os.IsNotExist(err) {
+		log.Printf("Failed to remove checkpoint: %v", err)
+	}
+
+	bumpIndexVersion(ctx, cfg.Redis)
 
-	log.Printf("Done! Indexed: %d, Failed: %d, Time: %v", indexed, failed, time.Since(start))
+	log.Printf("Done! Indexed: %d, Failed: %d, Skipped: %d, Already indexed: %d, Time: %v", indexed, failed, skip, alreadyIndexed, time.Since(start))
 }
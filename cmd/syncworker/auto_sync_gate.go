@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+
+	"team-assistant/internal/config"
+	"team-assistant/pkg/ratelimit"
+)
+
+// defaultAutoSyncConcurrency 默认同时同步的群数量
+const defaultAutoSyncConcurrency = 2
+
+// autoSyncGate 所有自动同步群共享的并发槽和限流器
+// 无论配置多少个群，同一时刻最多 concurrency 个群在拉取；飞书接口的总速率由客户端的令牌桶（Lark.QPS）统一限制，
+// 配置了 RequestsPerSecond 时 limiter 在此之内进一步限制自动同步的拉取速率
+type autoSyncGate struct {
+	slots   chan struct{}
+	limiter *ratelimit.Limiter // nil 表示只受客户端限流
+}
+
+// newAutoSyncGate 根据配置创建共享闸门，未配置速率时只依赖飞书客户端的限流
+func newAutoSyncGate(cfg config.AutoSyncConfig) *autoSyncGate {
+	concurrency := cfg.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultAutoSyncConcurrency
+	}
+
+	var limiter *ratelimit.Limiter
+	if cfg.RequestsPerSecond > 0 {
+		burst := cfg.Burst
+		if burst <= 0 {
+			burst = int(cfg.RequestsPerSecond)
+		}
+		if burst < 1 {
+			burst = 1
+		}
+		limiter = ratelimit.NewLimiter(cfg.RequestsPerSecond, burst)
+	}
+
+	return &autoSyncGate{
+		slots:   make(chan struct{}, concurrency),
+		limiter: limiter,
+	}
+}
+
+// acquire 占用一个并发槽，ctx 取消时返回错误
+func (g *autoSyncGate) acquire(ctx context.Context) error {
+	select {
+	case g.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release 释放并发槽
+func (g *autoSyncGate) release() {
+	<-g.slots
+}
+
+// wait 在每次调用飞书 API 前等待令牌，未单独配置速率时只检查 ctx
+func (g *autoSyncGate) wait(ctx context.Context) error {
+	if g.limiter == nil {
+		return ctx.Err()
+	}
+	return g.limiter.Wait(ctx)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"team-assistant/internal/config"
+)
+
+func TestAutoSyncGateBoundsCombinedRate(t *testing.T) {
+	const (
+		chats    = 8
+		rate     = 20.0
+		burst    = 2
+		duration = 600 * time.Millisecond
+	)
+	gate := newAutoSyncGate(config.AutoSyncConfig{Concurrency: chats, RequestsPerSecond: rate, Burst: burst})
+
+	ctx, cancel := context.WithTimeout(context.Background(), duration)
+	defer cancel()
+
+	// 模拟多个群同时拉取，统计总请求数
+	var calls int64
+	var wg sync.WaitGroup
+	for i := 0; i < chats; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := gate.acquire(ctx); err != nil {
+				return
+			}
+			defer gate.release()
+			for gate.wait(ctx) == nil {
+				atomic.AddInt64(&calls, 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	limit := int64(rate*duration.Seconds()) + burst
+	if calls > limit {
+		t.Errorf("Expected combined calls <= %d across %d chats, got %d", limit, chats, calls)
+	}
+	if calls == 0 {
+		t.Errorf("Expected some calls to pass the limiter")
+	}
+}
+
+func TestAutoSyncGateConcurrency(t *testing.T) {
+	gate := newAutoSyncGate(config.AutoSyncConfig{Concurrency: 2, RequestsPerSecond: 100})
+
+	var running, maxRunning int64
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := gate.acquire(context.Background()); err != nil {
+				return
+			}
+			defer gate.release()
+			n := atomic.AddInt64(&running, 1)
+			for {
+				m := atomic.LoadInt64(&maxRunning)
+				if n <= m || atomic.CompareAndSwapInt64(&maxRunning, m, n) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+			atomic.AddInt64(&running, -1)
+		}()
+	}
+	wg.Wait()
+
+	if maxRunning > 2 {
+		t.Errorf("Expected at most 2 chats syncing at once, got %d", maxRunning)
+	}
+}
+
+func TestNewAutoSyncGateDefaults(t *testing.T) {
+	gate := newAutoSyncGate(config.AutoSyncConfig{})
+	if cap(gate.slots) != defaultAutoSyncConcurrency {
+		t.Errorf("Expected default concurrency %d, got %d", defaultAutoSyncConcurrency, cap(gate.slots))
+	}
+	// 未配置速率时只受飞书客户端的令牌桶限制，不再叠加第二个限流器
+	if gate.limiter != nil {
+		t.Errorf("Expected no separate limiter when rate not configured")
+	}
+	if err := gate.wait(context.Background()); err != nil {
+		t.Errorf("wait() without limiter = %v", err)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+
+	"team-assistant/internal/model"
+)
+
+// chatGroupFinder 查询群记录（*model.ChatGroupModel 实现）
+type chatGroupFinder interface {
+	FindByChatID(ctx context.Context, chatID string) (*model.ChatGroup, error)
+}
+
+// inactivePause 单个群的停用暂停状态
+// 群解散或机器人被移出后（chat_groups.status = 0）跳过定时同步，重新拉机器人进群后自动恢复
+type inactivePause struct {
+	finder chatGroupFinder // nil 表示不检查群状态
+	chatID string
+	paused bool
+}
+
+// newInactivePause 创建群的停用暂停状态，服务上下文没有群模型时不检查群状态
+func (s *AutoSyncScheduler) newInactivePause(chatID string) *inactivePause {
+	p := &inactivePause{chatID: chatID}
+	if s.svcCtx.GroupModel != nil {
+		p.finder = s.svcCtx.GroupModel
+	}
+	return p
+}
+
+// skip 返回本次是否跳过同步，changed 表示停用状态刚发生变化（只在变化时记录日志，避免每次同步都刷屏）
+// 群记录不存在或查询失败时照常同步
+func (p *inactivePause) skip(ctx context.Context) (skip, changed bool) {
+	if p.finder == nil {
+		return false, false
+	}
+	inactive := false
+	group, err := p.finder.FindByChatID(ctx, p.chatID)
+	switch {
+	case err == nil:
+		inactive = group.Status == model.ChatGroupStatusInactive
+	case !errors.Is(err, sql.ErrNoRows):
+		log.Printf("AutoSync: failed to check status of chat %s: %v", p.chatID, err)
+		inactive = p.paused
+	}
+	changed = inactive != p.paused
+	p.paused = inactive
+	return inactive, changed
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"team-assistant/internal/config"
+	"team-assistant/internal/model"
+)
+
+// fakeGroupFinder 返回设定的群状态，status < 0 表示群记录不存在
+type fakeGroupFinder struct {
+	status int
+	err    error
+}
+
+func (f *fakeGroupFinder) FindByChatID(ctx context.Context, chatID string) (*model.ChatGroup, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if f.status < 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &model.ChatGroup{ChatID: chatID, Status: f.status}, nil
+}
+
+func TestInactivePauseSkip(t *testing.T) {
+	finder := &fakeGroupFinder{status: -1}
+	p := &inactivePause{finder: finder, chatID: "oc_a"}
+	steps := []struct {
+		name        string
+		status      int
+		err         error
+		wantSkip    bool
+		wantChanged bool
+	}{
+		{"群记录不存在照常同步", -1, nil, false, false},
+		{"启用的群", model.ChatGroupStatusActive, nil, false, false},
+		{"群解散后跳过", model.ChatGroupStatusInactive, nil, true, true},
+		{"持续跳过不重复记录", model.ChatGroupStatusInactive, nil, true, false},
+		{"查询失败保持跳过", 0, errors.New("connection refused"), true, false},
+		{"重新进群后恢复", model.ChatGroupStatusActive, nil, false, true},
+		{"恢复后查询失败照常同步", 0, errors.New("connection refused"), false, false},
+	}
+	for _, step := range steps {
+		finder.status, finder.err = step.status, step.err
+		skip, changed := p.skip(context.Background())
+		if skip != step.wantSkip || changed != step.wantChanged {
+			t.Errorf("%s: skip() = %v, %v, want %v, %v", step.name, skip, changed, step.wantSkip, step.wantChanged)
+		}
+	}
+}
+
+func TestInactivePauseWithoutFinder(t *testing.T) {
+	p := &inactivePause{chatID: "oc_a"}
+	if skip, _ := p.skip(context.Background()); skip {
+		t.Error("Expected sync without group status checker")
+	}
+}
+
+func TestAutoSyncChecksGroupStatusWithWorkerContext(t *testing.T) {
+	// sql.Open 不会建立连接，这里只检查同步进程的服务上下文装配
+	db, err := sql.Open("mysql", "user:pass@tcp(127.0.0.1:3306)/team_assistant")
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	defer db.Close()
+
+	svcCtx := newServiceContext(&config.Config{}, db, nil, nil)
+	s := NewAutoSyncScheduler(svcCtx, config.AutoSyncConfig{})
+	if p := s.newInactivePause("oc_a"); p.finder == nil {
+		t.Error("Expected syncworker auto-sync to check chat group status")
+	}
+}
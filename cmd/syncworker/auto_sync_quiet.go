@@ -0,0 +1,32 @@
+package main
+
+import (
+	"math"
+	"time"
+
+	"team-assistant/internal/config"
+)
+
+// quietPause 单个群定时同步的免打扰暂停状态
+// 时段内跳过同步，结束后第一次同步补拉暂停期间的消息
+type quietPause struct {
+	window      *config.QuietWindow // nil 表示不暂停
+	pausedSince time.Time
+}
+
+// lookback 返回本次同步应回溯的分钟数，处于免打扰时段时返回 0 表示跳过本次同步
+func (p *quietPause) lookback(now time.Time, base int) int {
+	if p.window.Contains(now) {
+		if p.pausedSince.IsZero() {
+			p.pausedSince = now
+		}
+		return 0
+	}
+	if p.pausedSince.IsZero() {
+		return base
+	}
+
+	paused := now.Sub(p.pausedSince)
+	p.pausedSince = time.Time{}
+	return base + int(math.Ceil(paused.Minutes()))
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"team-assistant/internal/config"
+)
+
+func TestQuietPauseLookback(t *testing.T) {
+	window, err := config.QuietHoursConfig{Enabled: true, Start: "22:00", End: "08:00"}.Window()
+	if err != nil {
+		t.Fatalf("Window() error: %v", err)
+	}
+	at := func(day, hour, minute int) time.Time { return time.Date(2024, 3, day, hour, minute, 0, 0, time.Local) }
+
+	p := &quietPause{window: window}
+	steps := []struct {
+		name string
+		now  time.Time
+		want int
+	}{
+		{"白天正常同步", at(4, 21, 50), 10},
+		{"进入免打扰", at(4, 22, 0), 0},
+		{"跨午夜仍暂停", at(5, 3, 0), 0},
+		{"结束后补拉暂停期间", at(5, 8, 0), 10 + 600},
+		{"之后恢复正常", at(5, 8, 1), 10},
+	}
+	for _, step := range steps {
+		if got := p.lookback(step.now, 10); got != step.want {
+			t.Errorf("%s: lookback = %d, want %d", step.name, got, step.want)
+		}
+	}
+
+	// 未配置暂停
+	p = &quietPause{}
+	if got := p.lookback(at(4, 23, 0), 10); got != 10 {
+		t.Errorf("Without window lookback = %d, want 10", got)
+	}
+}
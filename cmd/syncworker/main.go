@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"flag"
 	"log"
 	"os"
@@ -13,7 +14,7 @@ import (
 	"time"
 
 	_ "github.com/go-sql-driver/mysql"
-	"gopkg.in/yaml.v3"
+	"github.com/redis/go-redis/v9"
 
 	"team-assistant/internal/collector"
 	"team-assistant/internal/config"
@@ -25,28 +26,31 @@ import (
 )
 
 var (
-	configFile  = flag.String("f", "etc/config.yaml", "config file path")
-	workers     = flag.Int("w", 3, "number of parallel workers")
-	interval    = flag.Duration("i", 2*time.Second, "check interval")
+	configFile = flag.String("f", "etc/config.yaml", "config file path")
+	workers    = flag.Int("w", 3, "number of parallel workers")
+	interval   = flag.Duration("i", 2*time.Second, "check interval")
+	offline    = flag.Bool("offline", false, "safe mode: disable all external LLM/embedding calls (same as SafeMode: true)")
 )
 
 func main() {
 	flag.Parse()
 
 	// 加载配置
-	data, err := os.ReadFile(*configFile)
+	cfg, err := config.Load(*configFile)
 	if err != nil {
-		log.Fatalf("Failed to read config: %v", err)
+		log.Fatalf("Failed to load config: %v", err)
 	}
-	var cfg config.Config
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
-		log.Fatalf("Failed to parse config: %v", err)
+	if *offline {
+		cfg.SafeMode = true
+	}
+	if cfg.SafeMode {
+		log.Println("Safe mode enabled: image analysis, translation and vector indexing disabled")
 	}
 
 	// 连接数据库
-	dsn := cfg.MySQL.User + ":" + cfg.MySQL.Password + "@tcp(" + cfg.MySQL.Host + ")/" + cfg.MySQL.Database + "?charset=utf8mb4&parseTime=True&loc=Local"
-	if cfg.MySQL.SkipSSL {
-		dsn += "&tls=skip-verify"
+	dsn, err := cfg.MySQL.DSN()
+	if err != nil {
+		log.Fatalf("Invalid MySQL config: %v", err)
 	}
 	db, err := sql.Open("mysql", dsn)
 	if err != nil {
@@ -54,46 +58,30 @@ func main() {
 	}
 	defer db.Close()
 
-	// 创建 LLM 客户端（支持代理）
-	var proxyConfig *llm.ProxyConfig
-	if cfg.LLM.ProxyHost != "" && cfg.LLM.ProxyPort > 0 {
-		proxyConfig = &llm.ProxyConfig{
-			Host:     cfg.LLM.ProxyHost,
-			Port:     cfg.LLM.ProxyPort,
-			User:     cfg.LLM.ProxyUser,
-			Password: cfg.LLM.ProxyPassword,
-		}
+	// 创建 LLM 客户端（图片分析、索引翻译）；安全模式下不调用外部模型，图片只记录占位文本
+	var llmClient *llm.Client
+	if !cfg.SafeMode {
+		llmClient = svc.NewLLMClient(cfg.LLM)
 	}
-	llmClient := llm.NewClientWithProxy(cfg.LLM.APIKey, cfg.LLM.Endpoint, cfg.LLM.Model, proxyConfig)
-	// 设置视觉模型配置
-	if cfg.LLM.VisionModel != "" {
-		llmClient.SetVisionConfig(cfg.LLM.VisionModel, cfg.LLM.VisionEndpoint, cfg.LLM.VisionAPIKey)
+
+	// 连接 Redis（可选），用于通知服务进程刷新索引统计
+	var rdb *redis.Client
+	if cfg.Redis.Host != "" {
+		rdb = redis.NewClient(&redis.Options{
+			Addr:     cfg.Redis.Host,
+			Password: cfg.Redis.Password,
+			DB:       cfg.Redis.DB,
+		})
+		defer rdb.Close()
 	}
 
 	// 初始化服务上下文
-	svcCtx := &svc.ServiceContext{
-		Config:        cfg,
-		DB:            db,
-		LarkClient:    lark.NewClient(cfg.Lark.Domain, cfg.Lark.AppID, cfg.Lark.AppSecret),
-		MessageModel:  model.NewChatMessageModel(db),
-		SyncTaskModel: model.NewMessageSyncTaskModel(db),
-		LLMClient:     llmClient,
-		Services:      &svc.Services{},
-	}
-
-	// 初始化 RAG 服务（如果启用）
-	if cfg.VectorDB.Enabled {
-		ragService := service.NewRAGService(
-			cfg.VectorDB.QdrantEndpoint,
-			cfg.VectorDB.OllamaEndpoint,
-			cfg.VectorDB.EmbeddingModel,
-			cfg.VectorDB.CollectionName,
-			cfg.VectorDB.EmbeddingDimension,
-			true,
-		)
-		svcCtx.Services.RAG = ragService
-		log.Println("RAG service initialized")
+	svcCtx := newServiceContext(cfg, db, rdb, llmClient)
+	notifier, err := svc.NewNotifier(cfg.QuietHours, svcCtx.LarkClient)
+	if err != nil {
+		log.Fatalf("Failed to create notifier: %v", err)
 	}
+	svcCtx.Notifier = notifier
 
 	// 创建同步器池（处理手动创建的同步任务）
 	pool := NewSyncPool(svcCtx, *workers, *interval)
@@ -101,7 +89,7 @@ func main() {
 	// 创建定时增量同步调度器（处理配置的群自动同步）
 	var autoSyncer *AutoSyncScheduler
 	if cfg.AutoSync.Enabled && len(cfg.AutoSync.Chats) > 0 {
-		autoSyncer = NewAutoSyncScheduler(svcCtx, cfg.AutoSync.Chats)
+		autoSyncer = NewAutoSyncScheduler(svcCtx, cfg.AutoSync)
 		autoSyncer.Start()
 		log.Printf("AutoSync enabled for %d chats", len(cfg.AutoSync.Chats))
 	} else {
@@ -124,11 +112,41 @@ func main() {
 	log.Println("Sync worker stopped")
 }
 
+// newServiceContext 创建同步进程使用的服务上下文（只包含同步需要的模型和客户端）
+// 启用向量检索时按与主服务相同的配置创建 RAG 服务，同步的消息与 webhook 消息索引方式一致；安全模式下不索引
+func newServiceContext(cfg *config.Config, db *sql.DB, rdb *redis.Client, llmClient *llm.Client) *svc.ServiceContext {
+	messageModel := model.NewChatMessageModel(db)
+	messageModel.SetKeepRawContent(cfg.Storage.KeepsRawContent())
+	messageModel.SetFullTextSearch(cfg.Storage.FullTextSearch)
+	senderFilter := service.NewSenderFilter(cfg.Index.ExcludedSenders, cfg.Index.SkipStoreExcluded)
+
+	services := &svc.Services{}
+	if cfg.VectorDB.Enabled && !cfg.SafeMode {
+		services.RAG = svc.NewRAGService(*cfg, llmClient, messageModel, senderFilter, rdb)
+		log.Println("RAG service initialized")
+	}
+
+	return &svc.ServiceContext{
+		Config:        *cfg,
+		DB:            db,
+		Redis:         rdb,
+		LarkClient:    svc.NewLarkClient(cfg.Lark),
+		MessageModel:  messageModel,
+		SyncTaskModel: model.NewMessageSyncTaskModel(db),
+		GroupModel:    model.NewChatGroupModel(db),
+		AlertModel:    model.NewAlertModel(db),
+		LLMClient:     llmClient,
+		Services:      services,
+		SenderFilter:  senderFilter,
+	}
+}
+
 // SyncPool 同步器池，支持并行处理
 type SyncPool struct {
 	svcCtx   *svc.ServiceContext
 	workers  int
 	interval time.Duration
+	retry    batchRetry
 	stopChan chan struct{}
 	wg       sync.WaitGroup
 }
@@ -138,6 +156,7 @@ func NewSyncPool(svcCtx *svc.ServiceContext, workers int, interval time.Duration
 		svcCtx:   svcCtx,
 		workers:  workers,
 		interval: interval,
+		retry:    newBatchRetry(svcCtx.Config.SyncTask),
 		stopChan: make(chan struct{}),
 	}
 }
@@ -198,8 +217,17 @@ func (p *SyncPool) processOne(syncer *collector.MessageSyncer, workerID int) {
 		default:
 		}
 
-		// 执行一批同步
-		if err := syncer.SyncTask(ctx, task); err != nil {
+		// 执行一批同步，临时错误先重试，重试用完或遇到永久错误才标记失败
+		err := p.retry.run(p.stopChan, func(attempt int, err error) {
+			log.Printf("Worker %d: task %d batch failed (attempt %d/%d), retrying: %v", workerID, task.ID, attempt, p.retry.retries+1, err)
+		}, func() error {
+			return syncer.SyncTask(ctx, task)
+		})
+		if errors.Is(err, errSyncStopped) {
+			log.Printf("Worker %d: stopping, task %d will resume later", workerID, task.ID)
+			return
+		}
+		if err != nil {
 			log.Printf("Worker %d: task %d failed: %v", workerID, task.ID, err)
 			p.svcCtx.SyncTaskModel.MarkFailed(ctx, task.ID, err.Error())
 			return
@@ -228,13 +256,13 @@ func (p *SyncPool) processOne(syncer *collector.MessageSyncer, workerID int) {
 // getTaskStatus 获取任务当前状态
 func (p *SyncPool) getTaskStatus(ctx context.Context, taskID int64) (*model.MessageSyncTask, error) {
 	query := `SELECT id, chat_id, chat_name, status, total_messages, synced_messages,
-              page_token, start_time, end_time, error_msg, requested_by,
+              page_token, resume_time, start_time, end_time, error_msg, requested_by,
               started_at, finished_at, created_at, updated_at
               FROM message_sync_tasks WHERE id = ?`
 	var task model.MessageSyncTask
 	err := p.svcCtx.DB.QueryRowContext(ctx, query, taskID).Scan(
 		&task.ID, &task.ChatID, &task.ChatName, &task.Status, &task.TotalMessages, &task.SyncedMessages,
-		&task.PageToken, &task.StartTime, &task.EndTime, &task.ErrorMsg, &task.RequestedBy,
+		&task.PageToken, &task.ResumeTime, &task.StartTime, &task.EndTime, &task.ErrorMsg, &task.RequestedBy,
 		&task.StartedAt, &task.FinishedAt, &task.CreatedAt, &task.UpdatedAt)
 	return &task, err
 }
@@ -249,7 +277,7 @@ func (p *SyncPool) claimTask(ctx context.Context) (*model.MessageSyncTask, error
 	}
 
 	query := `SELECT id, chat_id, chat_name, status, total_messages, synced_messages,
-              page_token, start_time, end_time, error_msg, requested_by,
+              page_token, resume_time, start_time, end_time, error_msg, requested_by,
               started_at, finished_at, created_at, updated_at
               FROM message_sync_tasks
               WHERE status = 'pending'
@@ -260,7 +288,7 @@ func (p *SyncPool) claimTask(ctx context.Context) (*model.MessageSyncTask, error
 	var task model.MessageSyncTask
 	err = tx.QueryRowContext(ctx, query).Scan(
 		&task.ID, &task.ChatID, &task.ChatName, &task.Status, &task.TotalMessages, &task.SyncedMessages,
-		&task.PageToken, &task.StartTime, &task.EndTime, &task.ErrorMsg, &task.RequestedBy,
+		&task.PageToken, &task.ResumeTime, &task.StartTime, &task.EndTime, &task.ErrorMsg, &task.RequestedBy,
 		&task.StartedAt, &task.FinishedAt, &task.CreatedAt, &task.UpdatedAt)
 	if err != nil {
 		tx.Rollback()
@@ -284,26 +312,47 @@ func (p *SyncPool) claimTask(ctx context.Context) (*model.MessageSyncTask, error
 // ==================== AutoSyncScheduler ====================
 
 // AutoSyncScheduler 定时增量同步调度器
+// 所有群共享同一个 syncer 和限流闸门，总 API 速率不随群数量增长
 type AutoSyncScheduler struct {
 	svcCtx   *svc.ServiceContext
 	chats    []config.AutoSyncChatConfig
 	indexer  *service.MessageIndexer
+	syncer   *collector.MessageSyncer
+	gate     *autoSyncGate
+	quiet    *config.QuietWindow // 免打扰时段内暂停同步（未配置 PauseAutoSync 时为 nil）
+	ctx      context.Context
+	cancel   context.CancelFunc
 	stopChan chan struct{}
 	wg       sync.WaitGroup
 }
 
 // NewAutoSyncScheduler 创建定时增量同步调度器
-func NewAutoSyncScheduler(svcCtx *svc.ServiceContext, chats []config.AutoSyncChatConfig) *AutoSyncScheduler {
+func NewAutoSyncScheduler(svcCtx *svc.ServiceContext, cfg config.AutoSyncConfig) *AutoSyncScheduler {
 	// 创建索引器
 	var indexer *service.MessageIndexer
 	if svcCtx.Services != nil && svcCtx.Services.RAG != nil {
 		indexer = service.NewMessageIndexer(svcCtx.Services.RAG)
 	}
 
+	var quiet *config.QuietWindow
+	if svcCtx.Config.QuietHours.PauseAutoSync {
+		window, err := svcCtx.Config.QuietHours.Window()
+		if err != nil {
+			log.Printf("AutoSync: invalid quiet hours, not pausing: %v", err)
+		}
+		quiet = window
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
 	return &AutoSyncScheduler{
 		svcCtx:   svcCtx,
-		chats:    chats,
+		chats:    cfg.Chats,
 		indexer:  indexer,
+		syncer:   collector.NewMessageSyncer(svcCtx),
+		gate:     newAutoSyncGate(cfg),
+		quiet:    quiet,
+		ctx:      ctx,
+		cancel:   cancel,
 		stopChan: make(chan struct{}),
 	}
 }
@@ -319,6 +368,7 @@ func (s *AutoSyncScheduler) Start() {
 // Stop 停止调度器
 func (s *AutoSyncScheduler) Stop() {
 	close(s.stopChan)
+	s.cancel()
 	s.wg.Wait()
 	log.Println("AutoSyncScheduler stopped")
 }
@@ -349,8 +399,33 @@ func (s *AutoSyncScheduler) runChatSync(cfg config.AutoSyncChatConfig) {
 
 	log.Printf("AutoSync [%s]: started, interval=%ds, lookback=%dm", chatName, interval, lookback)
 
+	pause := &quietPause{window: s.quiet}
+	inactive := s.newInactivePause(cfg.ChatID)
+	syncOnce := func() {
+		if skip, changed := inactive.skip(s.ctx); skip {
+			if changed {
+				log.Printf("AutoSync [%s]: chat disbanded or bot removed, paused", chatName)
+			}
+			return
+		} else if changed {
+			log.Printf("AutoSync [%s]: chat active again, resuming", chatName)
+		}
+		wasPaused := !pause.pausedSince.IsZero()
+		minutes := pause.lookback(time.Now(), lookback)
+		switch {
+		case minutes == 0:
+			if !wasPaused {
+				log.Printf("AutoSync [%s]: quiet hours, paused until %s", chatName, s.quiet.End(time.Now()).Format("15:04"))
+			}
+			return
+		case wasPaused:
+			log.Printf("AutoSync [%s]: quiet hours ended, catching up %d minutes", chatName, minutes)
+		}
+		s.syncChatIncremental(cfg, chatName, minutes)
+	}
+
 	// 立即执行一次
-	s.syncChatIncremental(cfg, chatName, lookback)
+	syncOnce()
 
 	for {
 		select {
@@ -358,14 +433,20 @@ func (s *AutoSyncScheduler) runChatSync(cfg config.AutoSyncChatConfig) {
 			log.Printf("AutoSync [%s]: stopping", chatName)
 			return
 		case <-ticker.C:
-			s.syncChatIncremental(cfg, chatName, lookback)
+			syncOnce()
 		}
 	}
 }
 
 // syncChatIncremental 增量同步单个群的消息
 func (s *AutoSyncScheduler) syncChatIncremental(cfg config.AutoSyncChatConfig, chatName string, lookbackMinutes int) {
-	ctx := context.Background()
+	ctx := s.ctx
+
+	// 等待共享并发槽，避免所有群同时拉取
+	if err := s.gate.acquire(ctx); err != nil {
+		return
+	}
+	defer s.gate.release()
 
 	// 计算时间范围
 	endTime := time.Now()
@@ -379,13 +460,22 @@ func (s *AutoSyncScheduler) syncChatIncremental(cfg config.AutoSyncChatConfig, c
 	totalSynced := 0
 	maxPages := 10 // 最多翻页 10 次，防止卡死
 
-	syncer := collector.NewMessageSyncer(s.svcCtx)
-
 	log.Printf("AutoSync [%s]: fetching messages from %s to %s", chatName, startTime.Format("15:04:05"), endTime.Format("15:04:05"))
 
 	for page := 0; page < maxPages; page++ {
+		// 共享限流：所有群的拉取请求总速率受限
+		if err := s.gate.wait(ctx); err != nil {
+			return
+		}
+
 		// 拉取消息
 		resp, err := s.svcCtx.LarkClient.GetChatHistory(ctx, cfg.ChatID, startTimeStr, endTimeStr, 50, pageToken)
+		if err != nil && pageToken != "" && lark.IsPageTokenExpired(err) {
+			// 分页 Token 失效：从时间窗口开头重新分页，已存在的消息会被跳过
+			log.Printf("AutoSync [%s]: page token expired, restarting window", chatName)
+			pageToken = ""
+			continue
+		}
 		if err != nil {
 			log.Printf("AutoSync [%s]: failed to get history: %v", chatName, err)
 			return
@@ -398,7 +488,7 @@ func (s *AutoSyncScheduler) syncChatIncremental(cfg config.AutoSyncChatConfig, c
 		}
 
 		// 处理消息
-		newCount := s.processMessages(ctx, syncer, resp.Data.Items, cfg.ChatID, chatName)
+		newCount := s.processMessages(ctx, s.syncer, resp.Data.Items, cfg.ChatID, chatName)
 		totalSynced += newCount
 
 		// 检查是否有更多
@@ -420,6 +510,12 @@ func (s *AutoSyncScheduler) processMessages(ctx context.Context, syncer *collect
 
 	for _, item := range items {
 		if item.Deleted {
+			// 飞书中已删除的消息：之前索引过的从向量库移除
+			if s.indexer != nil {
+				if err := s.indexer.DeleteMessage(ctx, chatID, item.MessageID); err != nil {
+					log.Printf("AutoSync: failed to remove deleted message %s from index: %v", item.MessageID, err)
+				}
+			}
 			continue
 		}
 
@@ -435,14 +531,24 @@ func (s *AutoSyncScheduler) processMessages(ctx context.Context, syncer *collect
 
 		// 转换并存储消息
 		msg := syncer.ConvertToMessage(ctx, item)
-		if err := s.svcCtx.MessageModel.Insert(ctx, msg); err != nil {
+		if s.svcCtx.SenderFilter.SkipStore(msg.SenderID.String, msg.SenderName.String) {
+			continue
+		}
+		stored, err := s.svcCtx.MessageModel.Upsert(ctx, msg)
+		if err != nil {
 			// INSERT IGNORE 不会报重复键错误，这里可能是其他错误
 			log.Printf("AutoSync: failed to insert message %s: %v", item.MessageID, err)
 			continue
 		}
 
 		newCount++
-		convertedMsgs = append(convertedMsgs, msg)
+		syncer.RecordAlert(ctx, msg)
+		// 检查存在后实时回调可能已写入同一条消息：以合并后的行为准，没有变化且已索引时不再重复索引
+		if s.indexer != nil {
+			if indexed := service.MessageForIndex(ctx, s.svcCtx.MessageModel, s.svcCtx.Services.RAG, msg, stored); indexed != nil {
+				convertedMsgs = append(convertedMsgs, indexed)
+			}
+		}
 	}
 
 	// 批量索引到向量数据库
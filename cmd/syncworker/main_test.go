@@ -0,0 +1,48 @@
+package main
+
+import (
+	"database/sql"
+	"testing"
+
+	"team-assistant/internal/config"
+)
+
+func TestWorkerContextRecordsAlerts(t *testing.T) {
+	// sql.Open 不会建立连接，这里只检查服务上下文装配
+	db, err := sql.Open("mysql", "user:pass@tcp(127.0.0.1:3306)/team_assistant")
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	defer db.Close()
+
+	if svcCtx := newServiceContext(&config.Config{}, db, nil, nil); svcCtx.AlertModel == nil {
+		t.Error("Expected syncworker syncs to record alerts")
+	}
+}
+
+func TestWorkerContextEmbedsReplyContext(t *testing.T) {
+	db, err := sql.Open("mysql", "user:pass@tcp(127.0.0.1:3306)/team_assistant")
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	defer db.Close()
+
+	cfg := &config.Config{VectorDB: config.VectorDBConfig{Enabled: true, ReplyContext: true}}
+	svcCtx := newServiceContext(cfg, db, nil, nil)
+	if svcCtx.Services.RAG == nil || !svcCtx.Services.RAG.ReplyContextEnabled() {
+		t.Error("Expected synced replies to be embedded with their parent message")
+	}
+}
+
+func TestWorkerContextSafeModeSkipsIndexing(t *testing.T) {
+	db, err := sql.Open("mysql", "user:pass@tcp(127.0.0.1:3306)/team_assistant")
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	defer db.Close()
+
+	cfg := &config.Config{SafeMode: true, VectorDB: config.VectorDBConfig{Enabled: true}}
+	if svcCtx := newServiceContext(cfg, db, nil, nil); svcCtx.Services.RAG != nil {
+		t.Error("Safe mode should not create a RAG service that calls Ollama/Qdrant")
+	}
+}
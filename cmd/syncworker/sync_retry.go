@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"team-assistant/internal/backoff"
+	"team-assistant/internal/config"
+	"team-assistant/pkg/lark"
+)
+
+const (
+	defaultSyncTaskRetries      = 3               // 一批同步的默认重试次数
+	defaultSyncTaskRetryBackoff = 2 * time.Second // 首次重试的默认等待时间
+	maxSyncTaskRetryBackoff     = 60 * time.Second
+)
+
+// errSyncStopped 等待重试期间 worker 收到停止信号，任务保持原状态，下次启动后继续
+var errSyncStopped = errors.New("sync worker stopping")
+
+// permanentSyncCodes 重试也无法恢复的飞书错误码：机器人不在群里、未开启机器人能力、缺少权限、群已解散
+var permanentSyncCodes = map[int]bool{
+	230002: true,
+	230006: true,
+	230027: true,
+	232009: true,
+	232011: true,
+}
+
+// isPermanentSyncError 是否是永久错误（群不存在、机器人不在群里等），这类错误不重试直接标记任务失败
+// 超时、限流、网络错误等其他错误视为临时错误
+func isPermanentSyncError(err error) bool {
+	var apiErr *lark.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	if permanentSyncCodes[apiErr.Code] {
+		return true
+	}
+	msg := strings.ToLower(apiErr.Msg)
+	for _, word := range []string{"not found", "not exist", "disbanded", "dissolved"} {
+		if strings.Contains(msg, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// batchRetry 一批同步的重试策略
+type batchRetry struct {
+	retries int
+	backoff *backoff.Backoff
+}
+
+// newBatchRetry 根据配置创建重试策略
+func newBatchRetry(cfg config.SyncTaskConfig) batchRetry {
+	retries := cfg.Retries
+	if retries == 0 {
+		retries = defaultSyncTaskRetries
+	}
+	if retries < 0 {
+		retries = 0
+	}
+	initial := defaultSyncTaskRetryBackoff
+	if cfg.RetryBackoff > 0 {
+		initial = time.Duration(cfg.RetryBackoff) * time.Second
+	}
+	return batchRetry{retries: retries, backoff: backoff.New(initial, maxSyncTaskRetryBackoff)}
+}
+
+// run 执行一批同步，临时错误按退避重试；任务的 page_token 只在一批成功后才更新，重试从上次成功的位置继续
+// 永久错误或用完重试次数后返回最后一次的错误，等待期间收到 stop 时返回 errSyncStopped
+func (r batchRetry) run(stop <-chan struct{}, onRetry func(attempt int, err error), batch func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := batch()
+		if err == nil {
+			return nil
+		}
+		if attempt > r.retries || isPermanentSyncError(err) {
+			return err
+		}
+		if onRetry != nil {
+			onRetry(attempt, err)
+		}
+
+		timer := time.NewTimer(r.backoff.Duration(attempt))
+		select {
+		case <-stop:
+			timer.Stop()
+			return errSyncStopped
+		case <-timer.C:
+		}
+	}
+}
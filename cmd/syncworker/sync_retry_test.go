@@ -0,0 +1,110 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"team-assistant/internal/backoff"
+	"team-assistant/internal/config"
+	"team-assistant/pkg/lark"
+)
+
+func newTestBatchRetry(retries int) batchRetry {
+	return batchRetry{retries: retries, backoff: backoff.New(time.Millisecond, time.Millisecond)}
+}
+
+func TestBatchRetry(t *testing.T) {
+	timeout := errors.New("context deadline exceeded")
+	chatNotFound := &lark.APIError{Op: "get chat history", Code: 232011, Msg: "operator can NOT be out of the chat"}
+
+	tests := []struct {
+		name      string
+		errs      []error // 每次执行的结果，用完后返回 nil
+		retries   int
+		wantErr   error
+		wantCalls int
+	}{
+		{"一次成功", nil, 3, nil, 1},
+		{"重试后成功", []error{timeout, timeout}, 3, nil, 3},
+		{"重试用完后失败", []error{timeout, timeout, timeout, timeout, timeout}, 3, timeout, 4},
+		{"永久错误不重试", []error{chatNotFound}, 3, chatNotFound, 1},
+		{"不重试", []error{timeout}, 0, timeout, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls, retried := 0, 0
+			err := newTestBatchRetry(tt.retries).run(make(chan struct{}), func(int, error) { retried++ }, func() error {
+				calls++
+				if calls <= len(tt.errs) {
+					return tt.errs[calls-1]
+				}
+				return nil
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("run() error = %v, want %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls || retried != tt.wantCalls-1 {
+				t.Errorf("run() calls = %d, retries logged = %d, want %d calls", calls, retried, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestBatchRetryStop(t *testing.T) {
+	stop := make(chan struct{})
+	close(stop)
+	r := batchRetry{retries: 3, backoff: backoff.New(time.Hour, time.Hour)}
+
+	calls := 0
+	err := r.run(stop, nil, func() error {
+		calls++
+		return errors.New("timeout")
+	})
+	if !errors.Is(err, errSyncStopped) || calls != 1 {
+		t.Errorf("run() = %v after %d calls, want errSyncStopped without waiting", err, calls)
+	}
+}
+
+func TestIsPermanentSyncError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"机器人不在群里", &lark.APIError{Code: 230002, Msg: "Bot can NOT be outside the group"}, true},
+		{"群不存在", fmt.Errorf("sync: %w", &lark.APIError{Code: 230001, Msg: "chat not found"}), true},
+		{"限流", &lark.APIError{Code: 99991400, Msg: "request trigger frequency limit"}, false},
+		{"网络超时", errors.New("dial tcp: i/o timeout"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isPermanentSyncError(tt.err); got != tt.want {
+				t.Errorf("isPermanentSyncError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewBatchRetry(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         config.SyncTaskConfig
+		wantRetries int
+		wantInitial time.Duration
+	}{
+		{"默认值", config.SyncTaskConfig{}, 3, 2 * time.Second},
+		{"自定义", config.SyncTaskConfig{Retries: 5, RetryBackoff: 10}, 5, 10 * time.Second},
+		{"负数不重试", config.SyncTaskConfig{Retries: -1}, 0, 2 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newBatchRetry(tt.cfg)
+			if r.retries != tt.wantRetries || r.backoff.Initial != tt.wantInitial {
+				t.Errorf("newBatchRetry() = %d retries, %v initial", r.retries, r.backoff.Initial)
+			}
+		})
+	}
+}
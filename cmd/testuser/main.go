@@ -4,19 +4,18 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
-	"os"
-
-	"gopkg.in/yaml.v3"
 
 	"team-assistant/internal/config"
 	"team-assistant/pkg/lark"
 )
 
 func main() {
-	data, _ := os.ReadFile("etc/config.yaml")
-	var cfg config.Config
-	yaml.Unmarshal(data, &cfg)
+	cfg, err := config.Load("etc/config.yaml")
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
 
 	client := lark.NewClient(cfg.Lark.Domain, cfg.Lark.AppID, cfg.Lark.AppSecret)
 	ctx := context.Background()
@@ -35,6 +35,7 @@ CREATE TABLE IF NOT EXISTS git_commits (
     branch VARCHAR(100) COMMENT '分支',
     commit_sha VARCHAR(40) NOT NULL COMMENT 'Commit SHA',
     commit_message TEXT COMMENT '提交信息',
+    file_list TEXT COMMENT '变更文件列表（逗号分隔）',
     files_changed INT DEFAULT 0 COMMENT '变更文件数',
     additions INT DEFAULT 0 COMMENT '新增行数',
     deletions INT DEFAULT 0 COMMENT '删除行数',
@@ -58,6 +59,8 @@ CREATE TABLE IF NOT EXISTS chat_groups (
     owner_id VARCHAR(100) COMMENT '群主ID',
     member_count INT DEFAULT 0 COMMENT '成员数',
     status TINYINT DEFAULT 1 COMMENT '状态：1启用 0禁用',
+    project VARCHAR(100) DEFAULT NULL COMMENT '所属项目名称',
+    keywords VARCHAR(500) DEFAULT NULL COMMENT '群主题关键词，逗号分隔',
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
 
@@ -79,6 +82,8 @@ CREATE TABLE IF NOT EXISTS chat_messages (
     mentions JSON COMMENT '@的人员列表',
     reply_to_id VARCHAR(100) COMMENT '回复的消息ID',
     is_at_bot TINYINT DEFAULT 0 COMMENT '是否@了机器人',
+    is_forwarded TINYINT DEFAULT 0 COMMENT '是否是转发消息',
+    reaction_count INT NOT NULL DEFAULT 0 COMMENT '表情回复数',
     created_at TIMESTAMP NOT NULL COMMENT '消息时间',
     indexed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 
@@ -89,6 +94,7 @@ CREATE TABLE IF NOT EXISTS chat_messages (
     KEY idx_time (created_at),
     KEY idx_chat_time (chat_id, created_at),
     KEY idx_at_bot (is_at_bot, created_at),
+    KEY idx_chat_reaction (chat_id, reaction_count),
     FULLTEXT KEY ft_content (content) WITH PARSER ngram
 ) ENGINE=InnoDB COMMENT='聊天消息';
 
@@ -167,6 +173,7 @@ CREATE TABLE IF NOT EXISTS message_sync_tasks (
     total_messages INT DEFAULT 0 COMMENT '总消息数',
     synced_messages INT DEFAULT 0 COMMENT '已同步消息数',
     page_token VARCHAR(500) COMMENT '分页Token（用于续传）',
+    resume_time VARCHAR(20) COMMENT '已同步的最后一条消息时间戳（秒），分页Token失效时从这里续传',
     start_time VARCHAR(20) COMMENT '开始时间戳（毫秒）',
     end_time VARCHAR(20) COMMENT '结束时间戳（毫秒）',
     error_msg TEXT COMMENT '错误信息',
@@ -181,6 +188,98 @@ CREATE TABLE IF NOT EXISTS message_sync_tasks (
     KEY idx_time (created_at)
 ) ENGINE=InnoDB COMMENT='消息同步任务';
 
+-- 9. 用户已读状态表（用于"我不在的时候发生了什么"）
+CREATE TABLE IF NOT EXISTS user_read_state (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    user_id VARCHAR(100) NOT NULL COMMENT '用户 open_id',
+    chat_id VARCHAR(100) NOT NULL COMMENT '群ID',
+    last_read_at DATETIME NOT NULL COMMENT '上次查看/提问时间',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    UNIQUE KEY uk_user_chat (user_id, chat_id)
+) ENGINE=InnoDB COMMENT='用户已读状态';
+
+-- 10. Dify 同步状态表（记录已推送文档的内容哈希，内容不变时跳过）
+CREATE TABLE IF NOT EXISTS dify_sync_state (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    source_id VARCHAR(255) NOT NULL COMMENT '来源标识（如 messages:2024-03-01）',
+    document_id VARCHAR(100) NOT NULL COMMENT 'Dify 文档ID',
+    content_hash CHAR(64) NOT NULL COMMENT '内容 SHA-256',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    UNIQUE KEY uk_source (source_id)
+) ENGINE=InnoDB COMMENT='Dify 同步状态';
+
+-- 11. 告警表（同步时从告警消息中解析，用于告警趋势/排行查询）
+CREATE TABLE IF NOT EXISTS chat_alerts (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    message_id VARCHAR(100) NOT NULL COMMENT '飞书消息ID',
+    chat_id VARCHAR(100) NOT NULL COMMENT '群ID',
+    site VARCHAR(50) NOT NULL DEFAULT '' COMMENT '站点前缀或站点ID，解析不到时为空',
+    alert_type VARCHAR(50) NOT NULL COMMENT '告警类型',
+    alert_time DATETIME NOT NULL COMMENT '告警消息发送时间',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE KEY uk_message (message_id),
+    INDEX idx_time_site (alert_time, site),
+    INDEX idx_site_time (site, alert_time)
+) ENGINE=InnoDB COMMENT='告警记录';
+
+-- 12. 消息表情回复（按用户记录，chat_messages.reaction_count 为汇总数）
+CREATE TABLE IF NOT EXISTS chat_message_reactions (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    message_id VARCHAR(100) NOT NULL COMMENT '飞书消息ID',
+    operator_id VARCHAR(100) NOT NULL COMMENT '回复者 open_id',
+    emoji_type VARCHAR(50) NOT NULL COMMENT '表情类型，如 THUMBSUP',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE KEY uk_reaction (message_id, operator_id, emoji_type)
+) ENGINE=InnoDB COMMENT='消息表情回复';
+
+-- 13. 群周总结（群历程生成，"最近的决议/里程碑"查询直接读取）
+CREATE TABLE IF NOT EXISTS chat_weekly_summaries (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    chat_id VARCHAR(100) NOT NULL COMMENT '群ID',
+    week_start DATETIME NOT NULL COMMENT '周开始时间（周一）',
+    week_end DATETIME NOT NULL COMMENT '周结束时间',
+    summary TEXT COMMENT '本周概述',
+    main_topics JSON COMMENT '主要话题',
+    decisions JSON COMMENT '决议',
+    milestones JSON COMMENT '里程碑',
+    participants JSON COMMENT '参与者',
+    message_count INT NOT NULL DEFAULT 0 COMMENT '本周消息数',
+    complete TINYINT(1) NOT NULL DEFAULT 0 COMMENT '周是否已结束，未结束的周下次查询时重新生成',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    UNIQUE KEY uk_chat_week (chat_id, week_start)
+) ENGINE=InnoDB COMMENT='群周总结（群历程/最近决议查询复用）';
+
+-- 14. GitHub 采集状态（每个仓库最后一次采集成功的时间，用于展示工作量数据的新鲜度）
+CREATE TABLE IF NOT EXISTS github_collect_state (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    repo_full_name VARCHAR(255) NOT NULL COMMENT '仓库全名（owner/repo）',
+    last_success_at DATETIME NOT NULL COMMENT '最后一次采集成功时覆盖到的时间',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    UNIQUE KEY uk_repo (repo_full_name)
+) ENGINE=InnoDB COMMENT='GitHub 采集状态';
+
+-- 15. 用户默认群（私聊提问时默认检索的群）
+CREATE TABLE IF NOT EXISTS user_default_chat (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    user_id VARCHAR(100) NOT NULL COMMENT '用户 open_id',
+    chat_id VARCHAR(100) NOT NULL COMMENT '默认群ID',
+    chat_name VARCHAR(255) COMMENT '默认群名称',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    UNIQUE KEY uk_user (user_id)
+) ENGINE=InnoDB COMMENT='用户默认群';
+
 -- 初始化一些测试数据
 INSERT INTO team_members (name, github_username, role) VALUES
     ('测试用户', 'test-user', 'backend')
@@ -0,0 +1,7 @@
+-- 群项目信息：所属项目和主题关键词
+-- 已有数据库执行: mysql -u root -p team_assistant < deploy/sql/migrations/001_chat_group_metadata.sql
+USE team_assistant;
+
+ALTER TABLE chat_groups
+    ADD COLUMN project VARCHAR(100) DEFAULT NULL COMMENT '所属项目名称' AFTER status,
+    ADD COLUMN keywords VARCHAR(500) DEFAULT NULL COMMENT '群主题关键词，逗号分隔' AFTER project;
@@ -0,0 +1,6 @@
+-- 聊天消息：标记转发消息（合并转发、分享群名片等）
+-- 已有数据库执行: mysql -u root -p team_assistant < deploy/sql/migrations/002_chat_message_forwarded.sql
+USE team_assistant;
+
+ALTER TABLE chat_messages
+    ADD COLUMN is_forwarded TINYINT DEFAULT 0 COMMENT '是否是转发消息' AFTER is_at_bot;
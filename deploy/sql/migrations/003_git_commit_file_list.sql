@@ -0,0 +1,6 @@
+-- Git 提交记录：保存变更文件列表，用于工作量问答展示
+-- 已有数据库执行: mysql -u root -p team_assistant < deploy/sql/migrations/003_git_commit_file_list.sql
+USE team_assistant;
+
+ALTER TABLE git_commits
+    ADD COLUMN file_list TEXT COMMENT '变更文件列表（逗号分隔）' AFTER commit_message;
@@ -0,0 +1,17 @@
+-- 告警表：同步时从告警消息中解析站点和告警类型，用于告警趋势/排行查询
+-- 已有数据库执行: mysql -u root -p team_assistant < deploy/sql/migrations/004_chat_alerts.sql
+USE team_assistant;
+
+CREATE TABLE IF NOT EXISTS chat_alerts (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    message_id VARCHAR(100) NOT NULL COMMENT '飞书消息ID',
+    chat_id VARCHAR(100) NOT NULL COMMENT '群ID',
+    site VARCHAR(50) NOT NULL DEFAULT '' COMMENT '站点前缀或站点ID，解析不到时为空',
+    alert_type VARCHAR(50) NOT NULL COMMENT '告警类型',
+    alert_time DATETIME NOT NULL COMMENT '告警消息发送时间',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE KEY uk_message (message_id),
+    INDEX idx_time_site (alert_time, site),
+    INDEX idx_site_time (site, alert_time)
+) ENGINE=InnoDB COMMENT='告警记录';
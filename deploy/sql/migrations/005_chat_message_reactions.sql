@@ -0,0 +1,17 @@
+-- 表情回复：按用户记录消息的表情回复，并在消息上维护回复数，用于"最火的消息"查询
+-- 已有数据库执行: mysql -u root -p team_assistant < deploy/sql/migrations/005_chat_message_reactions.sql
+USE team_assistant;
+
+ALTER TABLE chat_messages
+    ADD COLUMN reaction_count INT NOT NULL DEFAULT 0 COMMENT '表情回复数' AFTER is_forwarded,
+    ADD KEY idx_chat_reaction (chat_id, reaction_count);
+
+CREATE TABLE IF NOT EXISTS chat_message_reactions (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    message_id VARCHAR(100) NOT NULL COMMENT '飞书消息ID',
+    operator_id VARCHAR(100) NOT NULL COMMENT '回复者 open_id',
+    emoji_type VARCHAR(50) NOT NULL COMMENT '表情类型，如 THUMBSUP',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE KEY uk_reaction (message_id, operator_id, emoji_type)
+) ENGINE=InnoDB COMMENT='消息表情回复';
@@ -0,0 +1,21 @@
+-- 群周总结：持久化群历程生成的每周总结（决议、里程碑等），"最近有什么决议"直接读取，已结束的周不再重复生成
+-- 已有数据库执行: mysql -u root -p team_assistant < deploy/sql/migrations/006_chat_weekly_summaries.sql
+USE team_assistant;
+
+CREATE TABLE IF NOT EXISTS chat_weekly_summaries (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    chat_id VARCHAR(100) NOT NULL COMMENT '群ID',
+    week_start DATETIME NOT NULL COMMENT '周开始时间（周一）',
+    week_end DATETIME NOT NULL COMMENT '周结束时间',
+    summary TEXT COMMENT '本周概述',
+    main_topics JSON COMMENT '主要话题',
+    decisions JSON COMMENT '决议',
+    milestones JSON COMMENT '里程碑',
+    participants JSON COMMENT '参与者',
+    message_count INT NOT NULL DEFAULT 0 COMMENT '本周消息数',
+    complete TINYINT(1) NOT NULL DEFAULT 0 COMMENT '周是否已结束，未结束的周下次查询时重新生成',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    UNIQUE KEY uk_chat_week (chat_id, week_start)
+) ENGINE=InnoDB COMMENT='群周总结（群历程/最近决议查询复用）';
@@ -0,0 +1,6 @@
+-- 同步任务：记录已同步的最后一条消息时间，分页 Token 失效时从这里续传而不是整个任务失败
+-- 已有数据库执行: mysql -u root -p team_assistant < deploy/sql/migrations/007_sync_task_resume_time.sql
+USE team_assistant;
+
+ALTER TABLE message_sync_tasks
+    ADD COLUMN resume_time VARCHAR(20) COMMENT '已同步的最后一条消息时间戳（秒），分页Token失效时从这里续传' AFTER page_token;
@@ -0,0 +1,13 @@
+-- GitHub 采集状态：记录每个仓库最后一次采集成功的时间，工作量回答附带 "数据截至"，/api/stats 返回各仓库的新鲜度
+-- 已有数据库执行: mysql -u root -p team_assistant < deploy/sql/migrations/008_github_collect_state.sql
+USE team_assistant;
+
+CREATE TABLE IF NOT EXISTS github_collect_state (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    repo_full_name VARCHAR(255) NOT NULL COMMENT '仓库全名（owner/repo）',
+    last_success_at DATETIME NOT NULL COMMENT '最后一次采集成功时覆盖到的时间',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    UNIQUE KEY uk_repo (repo_full_name)
+) ENGINE=InnoDB COMMENT='GitHub 采集状态';
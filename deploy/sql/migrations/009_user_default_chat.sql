@@ -0,0 +1,14 @@
+-- 用户默认群：私聊提问时默认只检索该群，明确指定其他群或 "所有群" 时不生效
+-- 已有数据库执行: mysql -u root -p team_assistant < deploy/sql/migrations/009_user_default_chat.sql
+USE team_assistant;
+
+CREATE TABLE IF NOT EXISTS user_default_chat (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    user_id VARCHAR(100) NOT NULL COMMENT '用户 open_id',
+    chat_id VARCHAR(100) NOT NULL COMMENT '默认群ID',
+    chat_name VARCHAR(255) COMMENT '默认群名称',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    UNIQUE KEY uk_user (user_id)
+) ENGINE=InnoDB COMMENT='用户默认群';
@@ -0,0 +1,7 @@
+-- 聊天消息：content 全文索引（ngram 分词支持中文），配合 Storage.FullTextSearch 使用，避免 LIKE 全表扫描
+-- 已有数据库执行: mysql -u root -p team_assistant < deploy/sql/migrations/010_chat_message_fulltext.sql
+-- 或: ./team-assistant -migrate-fulltext（已存在时跳过）
+USE team_assistant;
+
+ALTER TABLE chat_messages
+    ADD FULLTEXT INDEX ft_content (content) WITH PARSER ngram;
@@ -0,0 +1,14 @@
+-- Dify 同步状态：记录已推送文档的内容哈希，内容不变时跳过，内容变化时更新原文档
+-- 已有数据库执行: mysql -u root -p team_assistant < deploy/sql/migrations/011_dify_sync_state.sql
+USE team_assistant;
+
+CREATE TABLE IF NOT EXISTS dify_sync_state (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    source_id VARCHAR(255) NOT NULL COMMENT '来源标识（如 messages:2024-03-01）',
+    document_id VARCHAR(100) NOT NULL COMMENT 'Dify 文档ID',
+    content_hash CHAR(64) NOT NULL COMMENT '内容 SHA-256',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    UNIQUE KEY uk_source (source_id)
+) ENGINE=InnoDB COMMENT='Dify 同步状态';
@@ -0,0 +1,14 @@
+-- 用户已读状态：记录用户在每个群最后一次查看/提问的时间，用于"我不在的时候发生了什么"
+-- 已有数据库执行: mysql -u root -p team_assistant < deploy/sql/migrations/012_user_read_state.sql
+USE team_assistant;
+
+CREATE TABLE IF NOT EXISTS user_read_state (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    user_id VARCHAR(100) NOT NULL COMMENT '用户 open_id',
+    chat_id VARCHAR(100) NOT NULL COMMENT '群ID',
+    last_read_at DATETIME NOT NULL COMMENT '上次查看/提问时间',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    UNIQUE KEY uk_user_chat (user_id, chat_id)
+) ENGINE=InnoDB COMMENT='用户已读状态';
@@ -0,0 +1,81 @@
+package backoff
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Backoff 带抖动、有上限的指数退避
+// 第 n 次重试的基准等待时间为 Initial * Multiplier^(n-1)，不超过 Max；
+// 再在 [基准*(1-Jitter), 基准] 区间内随机，避免多个客户端同时重连
+type Backoff struct {
+	Initial    time.Duration // 首次重试等待时间
+	Max        time.Duration // 最大等待时间
+	Multiplier float64       // 增长倍数
+	Jitter     float64       // 抖动比例（0-1），0 表示不抖动
+}
+
+// New 创建退避策略（倍数 2，抖动 20%）
+func New(initial, max time.Duration) *Backoff {
+	return &Backoff{
+		Initial:    initial,
+		Max:        max,
+		Multiplier: 2,
+		Jitter:     0.2,
+	}
+}
+
+// Default 默认退避策略：1s 起步，最多 30s
+func Default() *Backoff {
+	return New(1*time.Second, 30*time.Second)
+}
+
+// Base 第 attempt 次重试（从 1 开始）的基准等待时间（不含抖动）
+func (b *Backoff) Base(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	multiplier := b.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	d := float64(b.Initial) * math.Pow(multiplier, float64(attempt-1))
+	if b.Max > 0 && d > float64(b.Max) {
+		return b.Max
+	}
+	return time.Duration(d)
+}
+
+// Duration 第 attempt 次重试（从 1 开始）的实际等待时间（含抖动）
+func (b *Backoff) Duration(attempt int) time.Duration {
+	base := b.Base(attempt)
+
+	jitter := b.Jitter
+	if jitter <= 0 {
+		return base
+	}
+	if jitter > 1 {
+		jitter = 1
+	}
+
+	// 在 [base*(1-jitter), base] 之间随机
+	delta := float64(base) * jitter * rand.Float64()
+	return base - time.Duration(delta)
+}
+
+// Sleep 等待第 attempt 次重试的时间，ctx 取消时提前返回
+func (b *Backoff) Sleep(ctx context.Context, attempt int) error {
+	timer := time.NewTimer(b.Duration(attempt))
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
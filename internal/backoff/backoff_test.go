@@ -0,0 +1,70 @@
+package backoff
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBaseSequence(t *testing.T) {
+	b := &Backoff{Initial: 100 * time.Millisecond, Max: time.Second, Multiplier: 2}
+
+	expected := []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+		time.Second, // 达到上限
+		time.Second,
+	}
+
+	for i, want := range expected {
+		if got := b.Base(i + 1); got != want {
+			t.Errorf("Base(%d) = %v, want %v", i+1, got, want)
+		}
+	}
+
+	// attempt < 1 按第一次处理
+	if got := b.Base(0); got != 100*time.Millisecond {
+		t.Errorf("Base(0) = %v, want 100ms", got)
+	}
+}
+
+func TestDurationWithinJitterBounds(t *testing.T) {
+	b := New(100*time.Millisecond, 2*time.Second)
+
+	for attempt := 1; attempt <= 10; attempt++ {
+		base := b.Base(attempt)
+		lower := time.Duration(float64(base) * (1 - b.Jitter))
+		for i := 0; i < 100; i++ {
+			d := b.Duration(attempt)
+			if d < lower || d > base {
+				t.Fatalf("Duration(%d) = %v, want in [%v, %v]", attempt, d, lower, base)
+			}
+		}
+		if base > b.Max {
+			t.Errorf("Base(%d) = %v exceeds max %v", attempt, base, b.Max)
+		}
+	}
+}
+
+func TestDurationWithoutJitter(t *testing.T) {
+	b := &Backoff{Initial: 50 * time.Millisecond, Max: time.Second, Multiplier: 3}
+	if got := b.Duration(2); got != 150*time.Millisecond {
+		t.Errorf("Duration(2) = %v, want 150ms", got)
+	}
+}
+
+func TestSleepCanceled(t *testing.T) {
+	b := New(time.Hour, time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+	if err := b.Sleep(ctx, 1); err == nil {
+		t.Error("Expected context error")
+	}
+	if time.Since(start) > time.Second {
+		t.Error("Sleep should return immediately when context is canceled")
+	}
+}
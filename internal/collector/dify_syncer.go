@@ -2,20 +2,47 @@ package collector
 
 import (
 	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
 	"sync"
 	"time"
 
+	"team-assistant/internal/model"
 	"team-assistant/internal/svc"
 	"team-assistant/pkg/dify"
 )
 
+// difyDocumentClient DifySyncer 用到的 Dify 文档接口
+type difyDocumentClient interface {
+	CreateDocumentByText(ctx context.Context, datasetID, name, text string) (*dify.DocumentCreateResponse, error)
+	UpdateDocumentByText(ctx context.Context, datasetID, documentID, name, text string) error
+}
+
+// difySyncStateStore 已推送文档的状态存储
+type difySyncStateStore interface {
+	Get(ctx context.Context, sourceID string) (*model.DifySyncState, error)
+	Upsert(ctx context.Context, sourceID, documentID, contentHash string) error
+}
+
+// difySyncAction 文档推送动作
+type difySyncAction string
+
+const (
+	difySyncCreate difySyncAction = "create" // 首次推送，创建文档
+	difySyncUpdate difySyncAction = "update" // 内容有变化，更新文档
+	difySyncSkip   difySyncAction = "skip"   // 内容未变化，跳过
+)
+
 // DifySyncer 将消息同步到 Dify 知识库
 type DifySyncer struct {
 	svcCtx     *svc.ServiceContext
-	client     *dify.Client
+	client     difyDocumentClient
+	state      difySyncStateStore
 	datasetID  string
 	batchSize  int
 	interval   time.Duration
@@ -32,7 +59,7 @@ func NewDifySyncer(svcCtx *svc.ServiceContext) *DifySyncer {
 		return nil
 	}
 
-	return &DifySyncer{
+	syncer := &DifySyncer{
 		svcCtx:    svcCtx,
 		client:    dify.NewClient(svcCtx.Config.Dify.BaseURL, svcCtx.Config.Dify.APIKey),
 		datasetID: svcCtx.Config.Dify.DatasetID,
@@ -40,6 +67,10 @@ func NewDifySyncer(svcCtx *svc.ServiceContext) *DifySyncer {
 		interval:  5 * time.Minute, // 每 5 分钟同步一次
 		stopChan:  make(chan struct{}),
 	}
+	if svcCtx.DifySyncStateModel != nil {
+		syncer.state = svcCtx.DifySyncStateModel
+	}
+	return syncer
 }
 
 // Start 启动同步器
@@ -141,17 +172,16 @@ func (s *DifySyncer) syncMessages() {
 		messagesByDate[dateKey] = append(messagesByDate[dateKey], msgText)
 	}
 
-	// 为每天创建/更新文档
+	// 为每天创建/更新文档（内容未变化的跳过）
 	for date, msgs := range messagesByDate {
 		docName := fmt.Sprintf("群聊消息-%s", date)
 		content := strings.Join(msgs, "\n")
 
-		// 尝试创建文档（如果已存在会更新）
-		_, err := s.client.CreateDocumentByText(ctx, s.datasetID, docName, content)
+		action, err := s.pushDocument(ctx, "messages:"+date, docName, content)
 		if err != nil {
-			log.Printf("Dify syncer: failed to create document %s: %v", docName, err)
-		} else {
-			log.Printf("Dify syncer: synced %d messages for %s", len(msgs), date)
+			log.Printf("Dify syncer: failed to sync document %s: %v", docName, err)
+		} else if action != difySyncSkip {
+			log.Printf("Dify syncer: synced %d messages for %s (%s)", len(msgs), date, action)
 		}
 	}
 
@@ -211,11 +241,92 @@ func (s *DifySyncer) SyncChatHistory(ctx context.Context, chatID string, days in
 		docName := fmt.Sprintf("群聊消息-%s-%s", chatID[:8], date)
 		content := strings.Join(msgs, "\n")
 
-		_, err := s.client.CreateDocumentByText(ctx, s.datasetID, docName, content)
-		if err != nil {
-			log.Printf("Dify syncer: failed to create document %s: %v", docName, err)
+		if _, err := s.pushDocument(ctx, fmt.Sprintf("chat:%s:%s", chatID, date), docName, content); err != nil {
+			log.Printf("Dify syncer: failed to sync document %s: %v", docName, err)
 		}
 	}
 
 	return nil
 }
+
+// ExportReport 将生成的报告（群总结、群历程等）推送到知识库
+// sourceID 相同且内容未变化时跳过，返回是否实际写入
+func (s *DifySyncer) ExportReport(ctx context.Context, sourceID, title, content string) (bool, error) {
+	action, err := s.pushDocument(ctx, sourceID, title, content)
+	if err != nil {
+		return false, err
+	}
+	return action != difySyncSkip, nil
+}
+
+// contentHash 计算文档内容哈希
+func contentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// decideDifySync 根据已记录的状态和当前内容哈希决定推送动作
+func decideDifySync(state *model.DifySyncState, hash string) difySyncAction {
+	if state == nil || state.DocumentID == "" {
+		return difySyncCreate
+	}
+	if state.ContentHash == hash {
+		return difySyncSkip
+	}
+	return difySyncUpdate
+}
+
+// pushDocument 推送文档到 Dify：首次创建，内容变化时更新，内容未变化时跳过
+// 未配置状态存储或读取状态失败（如未执行迁移、数据库异常）时直接创建，不中断同步
+func (s *DifySyncer) pushDocument(ctx context.Context, sourceID, name, content string) (difySyncAction, error) {
+	hash := contentHash(content)
+
+	var state *model.DifySyncState
+	if s.state != nil {
+		st, err := s.state.Get(ctx, sourceID)
+		switch {
+		case err == nil:
+			state = st
+		case !errors.Is(err, sql.ErrNoRows):
+			log.Printf("Dify syncer: failed to get sync state for %s, creating document: %v", sourceID, err)
+		}
+	}
+
+	action := decideDifySync(state, hash)
+	documentID := ""
+
+	switch action {
+	case difySyncSkip:
+		return action, nil
+
+	case difySyncUpdate:
+		documentID = state.DocumentID
+		err := s.client.UpdateDocumentByText(ctx, s.datasetID, documentID, name, content)
+		switch {
+		case err == nil:
+		case errors.Is(err, dify.ErrDocumentNotFound):
+			// 文档已在 Dify 中被删除，重新创建
+			log.Printf("Dify syncer: document %s (%s) not found, recreating", name, documentID)
+			action = difySyncCreate
+		default:
+			// 超时、5xx 等错误不重新创建，避免知识库中出现重复文档；保留原状态，下次同步时重试
+			return "", fmt.Errorf("update document: %w", err)
+		}
+	}
+
+	if action == difySyncCreate {
+		resp, err := s.client.CreateDocumentByText(ctx, s.datasetID, name, content)
+		if err != nil {
+			return "", fmt.Errorf("create document: %w", err)
+		}
+		documentID = resp.Document.ID
+	}
+
+	if s.state != nil && documentID != "" {
+		if err := s.state.Upsert(ctx, sourceID, documentID, hash); err != nil {
+			log.Printf("Dify syncer: failed to save sync state for %s: %v", sourceID, err)
+		}
+	}
+
+	return action, nil
+}
@@ -0,0 +1,206 @@
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"team-assistant/internal/model"
+	"team-assistant/pkg/dify"
+)
+
+// fakeDifyDocuments 记录文档创建/更新调用
+type fakeDifyDocuments struct {
+	created   []string
+	updated   []string
+	nextID    int
+	updateErr error
+}
+
+func (f *fakeDifyDocuments) CreateDocumentByText(ctx context.Context, datasetID, name, text string) (*dify.DocumentCreateResponse, error) {
+	f.nextID++
+	f.created = append(f.created, name)
+	resp := &dify.DocumentCreateResponse{}
+	resp.Document.ID = fmt.Sprintf("doc-%d", f.nextID)
+	return resp, nil
+}
+
+func (f *fakeDifyDocuments) UpdateDocumentByText(ctx context.Context, datasetID, documentID, name, text string) error {
+	if f.updateErr != nil {
+		return f.updateErr
+	}
+	f.updated = append(f.updated, documentID)
+	return nil
+}
+
+// fakeSyncStateStore 内存版同步状态存储
+type fakeSyncStateStore struct {
+	states map[string]*model.DifySyncState
+	getErr error
+}
+
+func (f *fakeSyncStateStore) Get(ctx context.Context, sourceID string) (*model.DifySyncState, error) {
+	if f.getErr != nil {
+		return nil, f.getErr
+	}
+	if st, ok := f.states[sourceID]; ok {
+		return st, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *fakeSyncStateStore) Upsert(ctx context.Context, sourceID, documentID, contentHash string) error {
+	f.states[sourceID] = &model.DifySyncState{SourceID: sourceID, DocumentID: documentID, ContentHash: contentHash}
+	return nil
+}
+
+func TestDecideDifySync(t *testing.T) {
+	hash := contentHash("[10:00] 张三: 今天发版")
+
+	tests := []struct {
+		name  string
+		state *model.DifySyncState
+		want  difySyncAction
+	}{
+		{"首次同步", nil, difySyncCreate},
+		{"内容未变化", &model.DifySyncState{DocumentID: "doc-1", ContentHash: hash}, difySyncSkip},
+		{"内容有变化", &model.DifySyncState{DocumentID: "doc-1", ContentHash: contentHash("旧内容")}, difySyncUpdate},
+		{"缺少文档ID", &model.DifySyncState{ContentHash: hash}, difySyncCreate},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := decideDifySync(tt.state, hash); got != tt.want {
+				t.Errorf("decideDifySync() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPushDocumentSkipsUnchanged(t *testing.T) {
+	client := &fakeDifyDocuments{}
+	store := &fakeSyncStateStore{states: map[string]*model.DifySyncState{}}
+	syncer := &DifySyncer{client: client, state: store, datasetID: "ds1"}
+	ctx := context.Background()
+
+	action, err := syncer.pushDocument(ctx, "messages:2024-03-01", "群聊消息-2024-03-01", "内容A")
+	if err != nil || action != difySyncCreate {
+		t.Fatalf("First push should create, got %s, %v", action, err)
+	}
+
+	action, err = syncer.pushDocument(ctx, "messages:2024-03-01", "群聊消息-2024-03-01", "内容A")
+	if err != nil || action != difySyncSkip {
+		t.Fatalf("Unchanged push should skip, got %s, %v", action, err)
+	}
+
+	action, err = syncer.pushDocument(ctx, "messages:2024-03-01", "群聊消息-2024-03-01", "内容A\n内容B")
+	if err != nil || action != difySyncUpdate {
+		t.Fatalf("Changed push should update, got %s, %v", action, err)
+	}
+
+	if len(client.created) != 1 {
+		t.Errorf("Expected 1 create, got %v", client.created)
+	}
+	if len(client.updated) != 1 || client.updated[0] != "doc-1" {
+		t.Errorf("Expected update of doc-1, got %v", client.updated)
+	}
+	if store.states["messages:2024-03-01"].ContentHash != contentHash("内容A\n内容B") {
+		t.Errorf("Stored hash should be updated")
+	}
+}
+
+func TestPushDocumentRecreatesWhenUpdateFails(t *testing.T) {
+	client := &fakeDifyDocuments{updateErr: fmt.Errorf("update document doc-deleted: %w", dify.ErrDocumentNotFound)}
+	store := &fakeSyncStateStore{states: map[string]*model.DifySyncState{
+		"messages:2024-03-01": {DocumentID: "doc-deleted", ContentHash: contentHash("旧内容")},
+	}}
+	syncer := &DifySyncer{client: client, state: store, datasetID: "ds1"}
+
+	action, err := syncer.pushDocument(context.Background(), "messages:2024-03-01", "群聊消息-2024-03-01", "新内容")
+	if err != nil || action != difySyncCreate {
+		t.Fatalf("Expected recreate, got %s, %v", action, err)
+	}
+	if got := store.states["messages:2024-03-01"].DocumentID; got != "doc-1" {
+		t.Errorf("Expected state to point to new document, got %s", got)
+	}
+}
+
+func TestPushDocumentKeepsStateWhenUpdateErrors(t *testing.T) {
+	client := &fakeDifyDocuments{updateErr: errors.New("dify api error: 500 Internal Server Error, body: ")}
+	oldHash := contentHash("旧内容")
+	store := &fakeSyncStateStore{states: map[string]*model.DifySyncState{
+		"messages:2024-03-01": {DocumentID: "doc-1", ContentHash: oldHash},
+	}}
+	syncer := &DifySyncer{client: client, state: store, datasetID: "ds1"}
+
+	if _, err := syncer.pushDocument(context.Background(), "messages:2024-03-01", "群聊消息-2024-03-01", "新内容"); err == nil {
+		t.Fatal("Expected error when update fails with 500")
+	}
+	if len(client.created) != 0 {
+		t.Errorf("Transient update failure should not create a duplicate document, got %v", client.created)
+	}
+	if st := store.states["messages:2024-03-01"]; st.DocumentID != "doc-1" || st.ContentHash != oldHash {
+		t.Errorf("Sync state should be unchanged, got %+v", st)
+	}
+}
+
+func TestPushDocumentCreatesWhenStateLookupFails(t *testing.T) {
+	client := &fakeDifyDocuments{}
+	store := &fakeSyncStateStore{
+		states: map[string]*model.DifySyncState{},
+		getErr: errors.New("Table 'team_assistant.dify_sync_state' doesn't exist"),
+	}
+	syncer := &DifySyncer{client: client, state: store, datasetID: "ds1"}
+
+	action, err := syncer.pushDocument(context.Background(), "messages:2024-03-01", "群聊消息-2024-03-01", "内容")
+	if err != nil || action != difySyncCreate {
+		t.Fatalf("Expected create on state lookup error, got %s, %v", action, err)
+	}
+	if len(client.created) != 1 {
+		t.Errorf("Expected 1 create, got %v", client.created)
+	}
+}
+
+func TestPushDocumentWithoutStateStore(t *testing.T) {
+	client := &fakeDifyDocuments{}
+	syncer := &DifySyncer{client: client, datasetID: "ds1"}
+
+	for i := 0; i < 2; i++ {
+		if _, err := syncer.pushDocument(context.Background(), "messages:2024-03-01", "群聊消息", "内容"); err != nil {
+			t.Fatalf("pushDocument failed: %v", err)
+		}
+	}
+	if len(client.created) != 2 {
+		t.Errorf("Without state store every push should create, got %d", len(client.created))
+	}
+}
+
+func TestExportReportDedupesByContent(t *testing.T) {
+	client := &fakeDifyDocuments{}
+	store := &fakeSyncStateStore{states: map[string]*model.DifySyncState{}}
+	syncer := &DifySyncer{client: client, state: store, datasetID: "ds1"}
+	ctx := context.Background()
+	sourceID := "report:timeline:oc_dev:2025-01-05"
+
+	written, err := syncer.ExportReport(ctx, sourceID, "群历程-研发群-2025-01-05", "报告 v1")
+	if err != nil || !written {
+		t.Fatalf("First export should write, got %v, %v", written, err)
+	}
+	written, err = syncer.ExportReport(ctx, sourceID, "群历程-研发群-2025-01-05", "报告 v1")
+	if err != nil || written {
+		t.Fatalf("Unchanged export should be skipped, got %v, %v", written, err)
+	}
+	written, err = syncer.ExportReport(ctx, sourceID, "群历程-研发群-2025-01-05", "报告 v2")
+	if err != nil || !written {
+		t.Fatalf("Changed export should write, got %v, %v", written, err)
+	}
+
+	if len(client.created) != 1 || client.created[0] != "群历程-研发群-2025-01-05" {
+		t.Errorf("Expected one document created with report title, got %v", client.created)
+	}
+	if len(client.updated) != 1 {
+		t.Errorf("Expected one update, got %v", client.updated)
+	}
+}
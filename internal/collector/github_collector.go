@@ -12,22 +12,32 @@ import (
 	"team-assistant/pkg/github"
 )
 
+// githubCollectStateStore 记录仓库采集成功的时间（默认使用 svcCtx.GitHubCollectStateModel）
+type githubCollectStateStore interface {
+	MarkSuccess(ctx context.Context, repoFullName string, at time.Time) error
+}
+
 // GitHubCollector GitHub数据采集器
 type GitHubCollector struct {
 	svcCtx       *svc.ServiceContext
 	githubClient *github.Client
+	state        githubCollectStateStore
 	interval     time.Duration
 	stopChan     chan struct{}
 }
 
 // NewGitHubCollector 创建GitHub采集器
 func NewGitHubCollector(svcCtx *svc.ServiceContext, interval time.Duration) *GitHubCollector {
-	return &GitHubCollector{
+	c := &GitHubCollector{
 		svcCtx:       svcCtx,
 		githubClient: github.NewClient(svcCtx.Config.GitHub.Token),
 		interval:     interval,
 		stopChan:     make(chan struct{}),
 	}
+	if svcCtx.GitHubCollectStateModel != nil {
+		c.state = svcCtx.GitHubCollectStateModel
+	}
+	return c
 }
 
 // Start 启动定时采集
@@ -91,11 +101,14 @@ func (c *GitHubCollector) collect() {
 			}
 
 			if len(commits) == 0 {
+				c.markCollected(ctx, repo.FullName, until)
 				continue
 			}
 
 			log.Printf("Found %d commits in %s/%s", len(commits), org, repo.Name)
 
+			saveFailed := false
+
 			for _, commit := range commits {
 				gitCommit := &model.GitCommit{
 					CommitSHA:     commit.SHA,
@@ -109,6 +122,13 @@ func (c *GitHubCollector) collect() {
 					Deletions:     commit.Stats.Deletions,
 					FilesChanged:  len(commit.Files),
 				}
+				var files []string
+				for _, f := range commit.Files {
+					files = append(files, f.Filename)
+				}
+				if fileList := model.JoinCommitFiles(files); fileList != "" {
+					gitCommit.FileList = sql.NullString{String: fileList, Valid: true}
+				}
 
 				// 尝试关联成员
 				if commit.Author != nil && commit.Author.Login != "" {
@@ -120,16 +140,32 @@ func (c *GitHubCollector) collect() {
 
 				if err := c.svcCtx.CommitModel.Insert(ctx, gitCommit); err != nil {
 					log.Printf("Failed to save commit %s: %v", commit.SHA[:7], err)
+					saveFailed = true
 				} else {
 					totalCommits++
 				}
 			}
+
+			// 有提交保存失败时不更新，下次采集成功后再记录
+			if !saveFailed {
+				c.markCollected(ctx, repo.FullName, until)
+			}
 		}
 	}
 
 	log.Printf("GitHub collection completed, saved %d commits", totalCommits)
 }
 
+// markCollected 记录仓库采集成功，数据覆盖到 until
+func (c *GitHubCollector) markCollected(ctx context.Context, repoFullName string, until time.Time) {
+	if c.state == nil {
+		return
+	}
+	if err := c.state.MarkSuccess(ctx, repoFullName, until); err != nil {
+		log.Printf("Failed to record GitHub collect time for %s: %v", repoFullName, err)
+	}
+}
+
 // CollectOnce 手动触发一次采集
 func (c *GitHubCollector) CollectOnce() {
 	c.collect()
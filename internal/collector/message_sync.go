@@ -5,8 +5,8 @@ import (
 	"database/sql"
 	"encoding/base64"
 	"encoding/json"
+	"fmt"
 	"log"
-	"net/http"
 	"strconv"
 	"strings"
 	"sync"
@@ -16,11 +16,23 @@ import (
 	"team-assistant/internal/service"
 	"team-assistant/internal/svc"
 	"team-assistant/pkg/lark"
+	"team-assistant/pkg/llm"
 )
 
+// syncTaskStore 同步任务存储（*model.MessageSyncTaskModel 实现，测试中可替换）
+type syncTaskStore interface {
+	GetPendingTask(ctx context.Context) (*model.MessageSyncTask, error)
+	MarkStarted(ctx context.Context, id int64) error
+	MarkCompleted(ctx context.Context, id int64, totalMessages int) error
+	MarkFailed(ctx context.Context, id int64, errMsg string) error
+	UpdateProgress(ctx context.Context, id int64, syncedMessages int, pageToken, resumeTime string) error
+	RestartFrom(ctx context.Context, id int64, startTime string) error
+}
+
 // MessageSyncer 消息同步器
 type MessageSyncer struct {
 	svcCtx    *svc.ServiceContext
+	tasks     syncTaskStore
 	batchSize int
 	stopChan  chan struct{}
 	wg        sync.WaitGroup
@@ -44,7 +56,7 @@ func NewMessageSyncer(svcCtx *svc.ServiceContext) *MessageSyncer {
 		indexer = service.NewMessageIndexer(svcCtx.Services.RAG)
 	}
 
-	return &MessageSyncer{
+	s := &MessageSyncer{
 		svcCtx:    svcCtx,
 		batchSize: 50, // 飞书API限制最大50条/次
 		stopChan:  make(chan struct{}),
@@ -52,6 +64,10 @@ func NewMessageSyncer(svcCtx *svc.ServiceContext) *MessageSyncer {
 		indexer:   indexer,
 		userCache: make(map[string]string),
 	}
+	if svcCtx.SyncTaskModel != nil {
+		s.tasks = svcCtx.SyncTaskModel
+	}
+	return s
 }
 
 // Start 启动同步器（后台运行）
@@ -106,7 +122,7 @@ func (s *MessageSyncer) processNextTask() {
 	ctx := context.Background()
 
 	// 获取待处理任务
-	task, err := s.svcCtx.SyncTaskModel.GetPendingTask(ctx)
+	task, err := s.tasks.GetPendingTask(ctx)
 	if err != nil {
 		if err != sql.ErrNoRows {
 			log.Printf("Failed to get pending task: %v", err)
@@ -118,7 +134,7 @@ func (s *MessageSyncer) processNextTask() {
 
 	// 标记任务开始
 	if task.Status == "pending" {
-		if err := s.svcCtx.SyncTaskModel.MarkStarted(ctx, task.ID); err != nil {
+		if err := s.tasks.MarkStarted(ctx, task.ID); err != nil {
 			log.Printf("Failed to mark task started: %v", err)
 			return
 		}
@@ -127,7 +143,7 @@ func (s *MessageSyncer) processNextTask() {
 	// 执行同步
 	if err := s.syncMessages(ctx, task); err != nil {
 		log.Printf("Failed to sync messages: %v", err)
-		s.svcCtx.SyncTaskModel.MarkFailed(ctx, task.ID, err.Error())
+		s.tasks.MarkFailed(ctx, task.ID, err.Error())
 		return
 	}
 }
@@ -162,6 +178,9 @@ func (s *MessageSyncer) syncMessages(ctx context.Context, task *model.MessageSyn
 	// 拉取一批消息
 	resp, err := s.svcCtx.LarkClient.GetChatHistory(ctx, task.ChatID, startTime, endTime, s.batchSize, pageToken)
 	if err != nil {
+		if pageToken != "" && lark.IsPageTokenExpired(err) {
+			return s.restartExpiredTask(ctx, task, startTime, err)
+		}
 		return err
 	}
 
@@ -178,22 +197,31 @@ func (s *MessageSyncer) syncMessages(ctx context.Context, task *model.MessageSyn
 		}
 
 		msg := s.ConvertToMessage(ctx, item)
-		if err := s.svcCtx.MessageModel.Insert(ctx, msg); err != nil {
+		if s.svcCtx.SenderFilter.SkipStore(msg.SenderID.String, msg.SenderName.String) {
+			continue
+		}
+		stored, err := s.svcCtx.MessageModel.Upsert(ctx, msg)
+		if err != nil {
 			log.Printf("Failed to insert message %s: %v", item.MessageID, err)
 		} else {
 			totalSynced++
-
-			// 收集向量数据
-			if msg.Content.Valid && msg.Content.String != "" {
-				vectorMsgs = append(vectorMsgs, service.MessageVector{
-					MessageID:  msg.MessageID,
-					ChatID:     msg.ChatID,
-					ChatName:   chatName,
-					SenderID:   msg.SenderID.String,
-					SenderName: msg.SenderName.String,
-					Content:    msg.Content.String,
-					CreatedAt:  msg.CreatedAt,
-				})
+			s.RecordAlert(ctx, msg)
+
+			// 收集向量数据：实时回调已写入时以合并后的行为准，没有变化且已索引的消息不再重复索引
+			if rag := s.svcCtx.Services.RAG; rag != nil && rag.IsEnabled() {
+				indexed := service.MessageForIndex(ctx, s.svcCtx.MessageModel, rag, msg, stored)
+				if indexed != nil && indexed.Content.Valid && indexed.Content.String != "" {
+					vectorMsgs = append(vectorMsgs, service.MessageVector{
+						MessageID:  indexed.MessageID,
+						ChatID:     indexed.ChatID,
+						ChatName:   chatName,
+						SenderID:   indexed.SenderID.String,
+						SenderName: indexed.SenderName.String,
+						Content:    indexed.Content.String,
+						ReplyToID:  indexed.ReplyToID.String,
+						CreatedAt:  indexed.CreatedAt,
+					})
+				}
 			}
 		}
 	}
@@ -212,7 +240,7 @@ func (s *MessageSyncer) syncMessages(ctx context.Context, task *model.MessageSyn
 	// 修复：如果返回空数据或没有更多数据，标记为完成
 	if !resp.Data.HasMore || len(resp.Data.Items) == 0 {
 		// 完成
-		s.svcCtx.SyncTaskModel.MarkCompleted(ctx, task.ID, totalSynced)
+		s.tasks.MarkCompleted(ctx, task.ID, totalSynced)
 		log.Printf("Task %d completed, total messages: %d", task.ID, totalSynced)
 
 		// 发送完成通知
@@ -220,12 +248,41 @@ func (s *MessageSyncer) syncMessages(ctx context.Context, task *model.MessageSyn
 			s.notifyCompletion(ctx, task.RequestedBy.String, task, totalSynced)
 		}
 	} else {
-		s.svcCtx.SyncTaskModel.UpdateProgress(ctx, task.ID, totalSynced, resp.Data.PageToken)
+		s.tasks.UpdateProgress(ctx, task.ID, totalSynced, resp.Data.PageToken, lastItemTime(resp.Data.Items))
 	}
 
 	return nil
 }
 
+// restartExpiredTask 分页 Token 失效时不让任务失败，而是清空 Token 从最后同步的消息时间重新分页
+// 没有记录续传时间（如升级前创建的任务）时从任务原来的开始时间重新拉取，已存在的消息按 message_id 覆盖写入
+func (s *MessageSyncer) restartExpiredTask(ctx context.Context, task *model.MessageSyncTask, startTime string, cause error) error {
+	resumeTime := startTime
+	if task.ResumeTime.Valid && task.ResumeTime.String != "" {
+		resumeTime = task.ResumeTime.String
+	}
+	log.Printf("Task %d: page token expired (%v), restarting chat %s from %q", task.ID, cause, task.ChatID, resumeTime)
+	if err := s.tasks.RestartFrom(ctx, task.ID, resumeTime); err != nil {
+		return fmt.Errorf("restart task after page token expired: %w", err)
+	}
+	return nil
+}
+
+// lastItemTime 本页最后一条消息的发送时间（秒级时间戳，飞书 start_time 参数格式），没有消息时返回空
+// 按秒取整会重复拉取同一秒内的消息，写入时按 message_id 去重
+func lastItemTime(items []*lark.MessageItem) string {
+	var latest int64
+	for _, item := range items {
+		if ms, err := strconv.ParseInt(item.CreateTime, 10, 64); err == nil && ms > latest {
+			latest = ms
+		}
+	}
+	if latest == 0 {
+		return ""
+	}
+	return strconv.FormatInt(latest/1000, 10)
+}
+
 // preloadChatMembers 预加载群成员名称到缓存
 func (s *MessageSyncer) preloadChatMembers(ctx context.Context, chatID string) {
 	members, err := s.svcCtx.LarkClient.GetChatMembers(ctx, chatID)
@@ -283,6 +340,16 @@ func (s *MessageSyncer) ConvertToMessage(ctx context.Context, item *lark.Message
 	)
 }
 
+// RecordAlert 解析告警消息并写入告警表，供告警趋势/排行查询使用
+func (s *MessageSyncer) RecordAlert(ctx context.Context, msg *model.ChatMessage) {
+	if s.svcCtx.AlertModel == nil {
+		return
+	}
+	if _, err := s.svcCtx.AlertModel.RecordMessage(ctx, msg); err != nil {
+		log.Printf("Failed to record alert for message %s: %v", msg.MessageID, err)
+	}
+}
+
 // AnalyzeImage 实现 service.ImageAnalyzer 接口
 func (s *MessageSyncer) AnalyzeImage(ctx context.Context, messageID, rawContent string) string {
 	return s.analyzeImageMessage(ctx, messageID, rawContent)
@@ -310,10 +377,11 @@ func (s *MessageSyncer) analyzeImageMessage(ctx context.Context, messageID, rawC
 		return "[图片]"
 	}
 
-	// 检测图片类型
-	mimeType := http.DetectContentType(imageData)
-	if !strings.HasPrefix(mimeType, "image/") {
-		mimeType = "image/png" // 默认
+	// 检测图片类型（HEIC 等视觉模型不支持的格式不发送）
+	mimeType, err := llm.DetectImageMimeType(imageData)
+	if err != nil {
+		log.Printf("Skip analyzing image %s: %v", imageContent.ImageKey, err)
+		return "[图片]"
 	}
 
 	// 转换为 base64
@@ -356,20 +424,36 @@ func (s *MessageSyncer) notifyCompletion(ctx context.Context, userID string, tas
 	msg += "同步消息数: " + strconv.Itoa(total) + "\n"
 	msg += "状态: 已完成 ✅"
 
-	// 发送私聊消息给请求者
+	// 发送私聊消息给请求者（免打扰时段内会延后发送）
 	// 注意：这里需要使用 open_id 发送消息
-	if err := s.svcCtx.LarkClient.SendMessageToUser(ctx, userID, "text", msg); err != nil {
+	var err error
+	if s.svcCtx.Notifier != nil {
+		err = s.svcCtx.Notifier.SendToUser(ctx, userID, "text", msg)
+	} else {
+		err = s.svcCtx.LarkClient.SendMessageToUser(ctx, userID, "text", msg)
+	}
+	if err != nil {
 		log.Printf("Failed to notify user %s: %v", userID, err)
 	}
 }
 
-// CreateSyncTask 创建同步任务
-func (s *MessageSyncer) CreateSyncTask(ctx context.Context, chatID, chatName, requestedBy string) (int64, error) {
+// CreateSyncTask 创建同步任务，start/end 限定同步的时间范围（零值表示不限），同步时作为飞书 start_time/end_time 参数
+func (s *MessageSyncer) CreateSyncTask(ctx context.Context, chatID, chatName, requestedBy string, start, end time.Time) (int64, error) {
 	task := &model.MessageSyncTask{
 		ChatID:      chatID,
 		ChatName:    sql.NullString{String: chatName, Valid: chatName != ""},
 		Status:      "pending",
 		RequestedBy: sql.NullString{String: requestedBy, Valid: requestedBy != ""},
+		StartTime:   larkTimeParam(start),
+		EndTime:     larkTimeParam(end),
 	}
 	return s.svcCtx.SyncTaskModel.Create(ctx, task)
 }
+
+// larkTimeParam 转为飞书 start_time/end_time 参数（秒级时间戳），零值返回 NULL
+func larkTimeParam(t time.Time) sql.NullString {
+	if t.IsZero() {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: strconv.FormatInt(t.Unix(), 10), Valid: true}
+}
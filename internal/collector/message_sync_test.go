@@ -0,0 +1,149 @@
+package collector
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"team-assistant/internal/model"
+	"team-assistant/internal/svc"
+	"team-assistant/pkg/lark"
+)
+
+func TestLastItemTime(t *testing.T) {
+	tests := []struct {
+		name  string
+		times []string
+		want  string
+	}{
+		{"取最后一条的秒级时间", []string{"1700000000123", "1700000100999"}, "1700000100"},
+		{"乱序时取最大值", []string{"1700000100999", "1700000000123"}, "1700000100"},
+		{"跳过无法解析的时间", []string{"", "bad", "1700000000500"}, "1700000000"},
+		{"没有消息", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items := make([]*lark.MessageItem, len(tt.times))
+			for i, ts := range tt.times {
+				items[i] = &lark.MessageItem{CreateTime: ts}
+			}
+			if got := lastItemTime(items); got != tt.want {
+				t.Errorf("lastItemTime() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLarkTimeParam(t *testing.T) {
+	if got := larkTimeParam(time.Time{}); got.Valid {
+		t.Errorf("larkTimeParam(zero) = %+v, want NULL", got)
+	}
+	got := larkTimeParam(time.Unix(1704038400, 0))
+	if want := (sql.NullString{String: "1704038400", Valid: true}); got != want {
+		t.Errorf("larkTimeParam() = %+v, want %+v", got, want)
+	}
+}
+
+// fakeSyncTaskStore 内存版同步任务存储，只保存一个任务
+type fakeSyncTaskStore struct {
+	task      *model.MessageSyncTask
+	failed    []string
+	completed bool
+}
+
+func (f *fakeSyncTaskStore) GetPendingTask(ctx context.Context) (*model.MessageSyncTask, error) {
+	if f.task == nil || f.task.Status == "completed" || f.task.Status == "failed" {
+		return nil, sql.ErrNoRows
+	}
+	task := *f.task
+	return &task, nil
+}
+
+func (f *fakeSyncTaskStore) MarkStarted(ctx context.Context, id int64) error {
+	f.task.Status = "running"
+	return nil
+}
+
+func (f *fakeSyncTaskStore) MarkCompleted(ctx context.Context, id int64, totalMessages int) error {
+	f.task.Status = "completed"
+	f.completed = true
+	return nil
+}
+
+func (f *fakeSyncTaskStore) MarkFailed(ctx context.Context, id int64, errMsg string) error {
+	f.task.Status = "failed"
+	f.failed = append(f.failed, errMsg)
+	return nil
+}
+
+func (f *fakeSyncTaskStore) UpdateProgress(ctx context.Context, id int64, syncedMessages int, pageToken, resumeTime string) error {
+	f.task.SyncedMessages = syncedMessages
+	f.task.PageToken = sql.NullString{String: pageToken, Valid: true}
+	return nil
+}
+
+func (f *fakeSyncTaskStore) RestartFrom(ctx context.Context, id int64, startTime string) error {
+	f.task.StartTime = sql.NullString{String: startTime, Valid: true}
+	f.task.PageToken = sql.NullString{}
+	return nil
+}
+
+func TestSyncRestartsWhenPageTokenExpires(t *testing.T) {
+	var requests []string // 依次收到的 start_time/page_token
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/tenant_access_token/internal"):
+			fmt.Fprint(w, `{"code":0,"tenant_access_token":"t-test","expire":7200}`)
+		case strings.HasSuffix(r.URL.Path, "/im/v1/messages"):
+			q := r.URL.Query()
+			requests = append(requests, q.Get("start_time")+"/"+q.Get("page_token"))
+			if q.Get("page_token") != "" {
+				fmt.Fprint(w, `{"code":230001,"msg":"invalid request"}`)
+				return
+			}
+			fmt.Fprint(w, `{"code":0,"data":{"has_more":false,"items":[]}}`)
+		default:
+			fmt.Fprint(w, `{"code":0,"data":{"has_more":false,"items":[]}}`)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	store := &fakeSyncTaskStore{task: &model.MessageSyncTask{
+		ID:             1,
+		ChatID:         "oc_1",
+		Status:         "running",
+		SyncedMessages: 120,
+		PageToken:      sql.NullString{String: "stale-token", Valid: true},
+		ResumeTime:     sql.NullString{String: "1700000000", Valid: true},
+		StartTime:      sql.NullString{String: "1699990000", Valid: true},
+	}}
+	s := NewMessageSyncer(&svc.ServiceContext{LarkClient: lark.NewClient(server.URL, "app", "secret")})
+	s.tasks = store
+
+	// Token 过期：任务不失败，从续传时间重新分页
+	s.processNextTask()
+	if len(store.failed) > 0 {
+		t.Fatalf("Task should not be marked failed, got %v", store.failed)
+	}
+	if store.task.StartTime.String != "1700000000" || store.task.PageToken.Valid {
+		t.Errorf("Expected start_time reset to resume time and page_token cleared, got %q / %+v",
+			store.task.StartTime.String, store.task.PageToken)
+	}
+	if store.task.SyncedMessages != 120 {
+		t.Errorf("Synced count should be kept, got %d", store.task.SyncedMessages)
+	}
+
+	// 下一轮不带 Token 拉取成功，任务完成
+	s.processNextTask()
+	if len(store.failed) > 0 || !store.completed {
+		t.Errorf("Expected task completed without failure, failed=%v completed=%v", store.failed, store.completed)
+	}
+	if got := strings.Join(requests, ","); got != "1699990000/stale-token,1700000000/" {
+		t.Errorf("Unexpected requests: %s", got)
+	}
+}
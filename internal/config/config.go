@@ -10,15 +10,33 @@ type Config struct {
 	LLM         LLMConfig         `yaml:"LLM"`
 	Dify        DifyConfig        `yaml:"Dify"`
 	VectorDB    VectorDBConfig    `yaml:"VectorDB"`
+	Index       IndexConfig       `yaml:"Index"`
+	Storage     StorageConfig     `yaml:"Storage"`
+	Search      SearchConfig      `yaml:"Search"`
 	Bitable     BitableConfig     `yaml:"Bitable"`
 	AutoSync    AutoSyncConfig    `yaml:"AutoSync"`
+	SyncTask    SyncTaskConfig    `yaml:"SyncTask"`
 	Permissions PermissionsConfig `yaml:"Permissions"`
+	Ingest      IngestConfig      `yaml:"Ingest"`
+	QuietHours  QuietHoursConfig  `yaml:"QuietHours"`
+	// 安全模式（预发/CI）：不调用外部 LLM、Embedding 和 Dify，LLM 返回固定内容，检索只使用数据库关键词搜索
+	// 启动参数 -offline 等价于 SafeMode: true
+	SafeMode bool `yaml:"SafeMode"`
 }
 
 // ServerConfig 服务器配置
 type ServerConfig struct {
 	Port int    `yaml:"Port"`
 	Mode string `yaml:"Mode"` // debug, release
+	// 优雅关闭时等待后台任务（采集器、同步器、缓存清理）退出的超时（秒），0 使用默认值 30 秒
+	ShutdownTimeout int `yaml:"ShutdownTimeout"`
+	// 查询接口令牌（/api/search），请求需携带 Authorization: Bearer <APIToken>；为空时关闭接口
+	APIToken string `yaml:"APIToken"`
+}
+
+// IngestConfig 外部消息接入配置（POST /webhook/ingest）
+type IngestConfig struct {
+	Token string `yaml:"Token"` // 接入令牌，请求需携带 Authorization: Bearer <Token>；为空时关闭接入
 }
 
 // MySQLConfig MySQL配置
@@ -27,7 +45,9 @@ type MySQLConfig struct {
 	User     string `yaml:"User"`
 	Password string `yaml:"Password"`
 	Database string `yaml:"Database"`
-	SkipSSL  bool   `yaml:"SkipSSL"` // 跳过 SSL 验证
+	SkipSSL  bool   `yaml:"SkipSSL"` // 跳过 SSL 验证（兼容旧配置，等价于 TLS: skip-verify）
+	TLS      string `yaml:"TLS"`     // TLS 模式：true、false、skip-verify、preferred，留空时按 SkipSSL
+	CACert   string `yaml:"CACert"`  // 自定义 CA 证书路径，设置后使用该 CA 校验服务端证书（优先于 TLS）
 }
 
 // RedisConfig Redis配置
@@ -45,6 +65,21 @@ type LarkConfig struct {
 	VerificationToken string `yaml:"VerificationToken"` // 事件验证Token
 	EncryptKey        string `yaml:"EncryptKey"`        // 加密密钥（可选）
 	BotOpenID         string `yaml:"BotOpenID"`         // 机器人的open_id
+	// 机器人的昵称/别名，群消息以这些名称开头时即使没有正式 @ 也视为在问机器人，如 ["助手", "小助手"]
+	BotAliases []string `yaml:"BotAliases"`
+	// 下载消息图片/文件遇到 5xx 或超时时的重试次数，0 使用默认值 3，负数不重试
+	ResourceRetries int `yaml:"ResourceRetries"`
+	// 调用飞书接口的每秒请求数上限（进程内所有接口共享），触发频率限制时自动等待重试，0 使用默认值 20，负数不限流
+	QPS float64 `yaml:"QPS"`
+	// 向同一群/用户（回复时为同一条消息）发送消息的每秒上限，超出的消息排队依次发送，0 使用默认值 5，负数不限制
+	SendPerChatQPS float64 `yaml:"SendPerChatQPS"`
+	// 调用飞书接口的超时（秒），0 使用默认值 30
+	Timeout int `yaml:"Timeout"`
+	// 访问飞书接口的 HTTP 代理（可选）
+	ProxyHost     string `yaml:"ProxyHost"`
+	ProxyPort     int    `yaml:"ProxyPort"`
+	ProxyUser     string `yaml:"ProxyUser"`
+	ProxyPassword string `yaml:"ProxyPassword"`
 }
 
 // GitHubConfig GitHub配置
@@ -64,6 +99,7 @@ type LLMConfig struct {
 	VisionModel    string `yaml:"VisionModel"`    // 视觉模型名称，如 meta/llama-3.2-90b-vision-instruct
 	VisionEndpoint string `yaml:"VisionEndpoint"` // 视觉模型端点（如果和主模型不同）
 	VisionAPIKey   string `yaml:"VisionAPIKey"`   // 视觉模型 API Key（如果和主模型不同）
+	MaxImageSizeMB int    `yaml:"MaxImageSizeMB"` // 私聊图片问答允许的最大图片（MB），超过时提示压缩，0 使用默认值 10
 	// 代理配置（用于香港等受限地区访问 Claude API）
 	ProxyHost     string `yaml:"ProxyHost"`     // 代理主机，如 52.41.128.82
 	ProxyPort     int    `yaml:"ProxyPort"`     // 代理端口，如 9662
@@ -71,6 +107,50 @@ type LLMConfig struct {
 	ProxyPassword string `yaml:"ProxyPassword"` // 代理密码
 	// 备选模型配置（按优先级排列，主模型失败时自动切换）
 	FallbackModels []FallbackModelConfig `yaml:"FallbackModels"`
+	// 总结时转发消息的处理方式：label（默认，标注为转发内容单独总结）、exclude（不参与总结）、keep（与本群消息同等对待）
+	SummaryForwardMode string `yaml:"SummaryForwardMode"`
+	// 意图处理超时（秒），0 使用默认值 60 秒；群历程默认 300 秒，需通过 IntentTimeouts 单独调整
+	IntentTimeout  int            `yaml:"IntentTimeout"`
+	IntentTimeouts map[string]int `yaml:"IntentTimeouts"` // 按意图覆盖超时（秒），如 group_timeline: 600
+	// 启用的意图，为空全部启用；未启用的意图改按问答（qa）处理，qa 也未启用时回复 "该功能未启用"，帮助始终可用
+	// 可选：search_message、summarize、qa、query_workload、query_commits、site_query、group_timeline、member_groups、top_ranking
+	EnabledIntents []string `yaml:"EnabledIntents"`
+	// 按调用场景覆盖 max_tokens：parse(500)、generate(1024)、summarize(1500)、translate(1024)、analyze_image(1024)、vision(2048)
+	// 小窗口模型总结被截断时可调小，输出偏短时可调大
+	CallMaxTokens map[string]int      `yaml:"CallMaxTokens"`
+	CallStop      map[string][]string `yaml:"CallStop"` // 按调用场景设置 stop 序列（可选），如 summarize: ["</summary>"]
+	// 问答回复末尾附加"参考消息"（回答所依据的消息片段），便于用户核对
+	ShowAnswerSources bool `yaml:"ShowAnswerSources"`
+	AnswerSourceLimit int  `yaml:"AnswerSourceLimit"` // 参考消息条数上限，默认 5
+	// 群内回复超过 LongReportThreshold 字时的投递方式：group（默认，直接回复到群）、dm（群内简短提示，完整报告私信提问者）
+	LongReportDelivery  string `yaml:"LongReportDelivery"`
+	LongReportThreshold int    `yaml:"LongReportThreshold"` // 长报告字数阈值，0 使用默认值 1500
+	// 发言人名称缺失时的处理：label（默认，显示为 UnknownSenderLabel）、resolve（按 open_id 调用飞书接口查询姓名）
+	UnknownSenderMode  string `yaml:"UnknownSenderMode"`
+	UnknownSenderLabel string `yaml:"UnknownSenderLabel"` // 默认 "未知成员"
+	// 私聊跨群问答时先挑选最相关的 N 个群再检索，0 使用默认值 5，负数关闭（直接搜索所有群）
+	CrossGroupTopK int `yaml:"CrossGroupTopK"`
+	// 角色类问题（如"后端是谁"）附带的群活跃成员名单上限（按近 90 天发言数排序），0 使用默认值 30，负数关闭
+	ActiveMemberLimit int `yaml:"ActiveMemberLimit"`
+	// 回复模板文件（text/template），用 {{define "summary"}}...{{end}} 覆盖内置模板，为空使用内置模板
+	AnswerTemplatesFile string `yaml:"AnswerTemplatesFile"`
+	// 搜索结果 "相关度" 的展示方式：raw（默认，原始融合分数）、minmax（在本次结果中归一化，最相关为 100%）、
+	// sigmoid（按 ScoreSigmoidMidpoint/ScoreSigmoidSteepness 映射，不同问题之间可比）
+	ScoreDisplay          string  `yaml:"ScoreDisplay"`
+	ScoreSigmoidMidpoint  float64 `yaml:"ScoreSigmoidMidpoint"`  // 映射为 50% 的原始分数，0 使用默认值 0.5
+	ScoreSigmoidSteepness float64 `yaml:"ScoreSigmoidSteepness"` // 曲线陡峭程度，0 使用默认值 10
+	// 未配置 AI（Dify/LLM）时对 AI 查询的回复，为空使用默认提示；同步、列出群聊等命令不受影响
+	DisabledMessage string `yaml:"DisabledMessage"`
+	// 问答直接列出原始消息（不经 LLM 总结）：auto（默认，"列出..."、精确查找报错码等问题直接返回消息原文）、off（始终由 LLM 回答）
+	ListAnswerMode string `yaml:"ListAnswerMode"`
+	// 问答传给 LLM 的聊天记录最大长度（字节），按相关度加入完整消息直到用完，0 使用默认值 8000；统计类问题至少 15000
+	QAContextLength int `yaml:"QAContextLength"`
+	// 传给 LLM 的上下文（问答聊天记录、成员工作总结、群历程）最大 token 数，按 1 token ≈ 1.5 个汉字估算，0 使用默认值 6000；单周总结最多 2000
+	ContextMaxTokens int `yaml:"ContextMaxTokens"`
+	// 问答指定时间范围内没有找到消息时的处理：widen（默认，去掉时间条件重新搜索，回答中注明不在该时间范围内）、off（直接回复没有找到）
+	QATimeFallback string `yaml:"QATimeFallback"`
+	// 问答、总结上下文中发言人前加上成员档案（team_members）中的角色，没有角色时用部门，如 "[产品经理] 李四"
+	SenderRoles bool `yaml:"SenderRoles"`
 }
 
 // FallbackModelConfig 备选模型配置
@@ -87,29 +167,147 @@ type DifyConfig struct {
 	BaseURL   string `yaml:"BaseURL"`   // Dify API 地址，如 http://localhost/v1
 	APIKey    string `yaml:"APIKey"`    // Dify 应用 API Key
 	DatasetID string `yaml:"DatasetID"` // 知识库 ID（可选）
+	// 将生成的群总结/群历程报告推送到知识库（需配置 DatasetID），内容不变时不重复推送
+	ExportReports bool `yaml:"ExportReports"`
+	// 传给 Dify inputs 的对话历史最大字符数，超出时只保留最近的对话；0 使用默认值 2000
+	// 需不大于 Dify 应用中 conversation_history 变量的最大长度
+	MaxHistoryLength int `yaml:"MaxHistoryLength"`
 }
 
 // VectorDBConfig 向量数据库配置
 type VectorDBConfig struct {
-	Enabled            bool   `yaml:"Enabled"`            // 是否启用向量搜索
-	QdrantEndpoint     string `yaml:"QdrantEndpoint"`     // Qdrant 地址，如 http://localhost:6333
-	OllamaEndpoint     string `yaml:"OllamaEndpoint"`     // Ollama 地址，如 http://localhost:11434
-	EmbeddingModel     string `yaml:"EmbeddingModel"`     // Embedding 模型，默认 nomic-embed-text
-	EmbeddingDimension int    `yaml:"EmbeddingDimension"` // Embedding 维度，默认 768（nomic-embed-text）
-	CollectionName     string `yaml:"CollectionName"`     // 集合名称，默认 messages
+	Enabled             bool   `yaml:"Enabled"`             // 是否启用向量搜索
+	QdrantEndpoint      string `yaml:"QdrantEndpoint"`      // Qdrant 地址，如 http://localhost:6333
+	OllamaEndpoint      string `yaml:"OllamaEndpoint"`      // Ollama 地址，如 http://localhost:11434
+	EmbeddingModel      string `yaml:"EmbeddingModel"`      // Embedding 模型，默认 nomic-embed-text
+	EmbeddingDimension  int    `yaml:"EmbeddingDimension"`  // Embedding 维度，默认 768（nomic-embed-text）
+	CollectionName      string `yaml:"CollectionName"`      // 集合名称，默认 messages
+	CollectionStrategy  string `yaml:"CollectionStrategy"`  // 集合划分：single（默认，所有群共用一个集合）或 per_chat（每个群一个集合 <CollectionName>_<chat_id>）
+	NormalizeEmbeddings bool   `yaml:"NormalizeEmbeddings"` // 写入/查询前做 L2 归一化；集合为 Cosine 距离时 Qdrant 会自动归一化，Dot 距离时需开启
+	FitDimension        bool   `yaml:"FitDimension"`        // 向量维度与集合不一致时补零/截断并记录警告（迁移模型时使用），默认关闭直接报错
+	// 索引前翻译成中文的群ID列表（如印尼群），同时保存原文和译文，用译文生成 embedding 以支持中文跨语言检索
+	TranslateChats []string `yaml:"TranslateChats"`
+	// 回复消息生成 embedding 时拼接被回复的消息内容（"是的，改好了" 这类短回复更容易检索到），展示仍使用原文
+	ReplyContext bool `yaml:"ReplyContext"`
+	// 生成 embedding 前如何处理时间戳、耗时、请求ID 等易变数值：keep（默认，保留）、mask（替换为占位符）、strip（去掉）
+	// 数值多的告警消息开启后同类告警更容易聚在一起；只影响向量，展示和关键词检索仍使用原文；修改后需要重建索引
+	EmbedNumbers string `yaml:"EmbedNumbers"`
+	// Ollama 保活间隔（秒）：定期发送极短的 embedding 请求，避免空闲后模型被卸载导致首次检索超时；0 关闭
+	KeepAliveInterval int `yaml:"KeepAliveInterval"`
+}
+
+// IndexConfig 消息入库/索引配置
+type IndexConfig struct {
+	// 排除的发言人（open_id 或名称，不区分大小写），如监控机器人：消息仍存储，但不参与向量索引
+	ExcludedSenders []string `yaml:"ExcludedSenders"`
+	// 排除的发言人消息也不存储（告警统计、总结同样不再包含这些消息）
+	SkipStoreExcluded bool `yaml:"SkipStoreExcluded"`
+	// 只有@提及的消息（如 "@张三"）也参与向量索引；默认只存储不索引，避免检索噪音
+	IndexMentionOnly bool `yaml:"IndexMentionOnly"`
+}
+
+// StorageConfig 消息存储配置
+type StorageConfig struct {
+	// 是否保存飞书原始消息 JSON（raw_content），默认保存；解析后的 content 已足够时关闭可减少约一半存储
+	KeepRawContent *bool `yaml:"KeepRawContent"`
+	// 按内容搜索时使用 content 的全文索引（MATCH ... AGAINST），需要先创建 ngram 全文索引；MATCH 查询出错时自动回退到 LIKE
+	FullTextSearch bool `yaml:"FullTextSearch"`
+}
+
+// SearchConfig 搜索结果条数配置（"搜索 XXX"、语义搜索），调大召回更全，调小回复更短
+type SearchConfig struct {
+	// 每次搜索从数据库/向量库取回的消息条数，0 使用默认值 20
+	FetchLimit int `yaml:"FetchLimit"`
+	// 回复中最多展示的消息条数，其余只显示条数（LLM 不可用时问答的本地回答同样适用），0 使用默认值 10
+	DisplayLimit int `yaml:"DisplayLimit"`
+}
+
+// KeepsRawContent 是否保存 raw_content（未配置时为 true）
+func (c StorageConfig) KeepsRawContent() bool {
+	return c.KeepRawContent == nil || *c.KeepRawContent
 }
 
 // BitableConfig 多维表格配置
 type BitableConfig struct {
 	Enabled  bool   `yaml:"Enabled"`  // 是否启用 Bitable 查询
-	AppToken string `yaml:"AppToken"` // 多维表格 App Token
-	TableID  string `yaml:"TableID"`  // 表格 ID
+	AppToken string `yaml:"AppToken"` // 多维表格 App Token（只有一张表时使用，配置了 Tables 时忽略）
+	TableID  string `yaml:"TableID"`  // 表格 ID（同上）
+	// 站点查询依次查找的表格（如站点表、域名表、商户表），返回第一张查到记录的表
+	Tables []BitableTableConfig `yaml:"Tables"`
+	// 展示的字段（按顺序），为空则展示所有非空字段
+	Fields []BitableFieldConfig `yaml:"Fields"`
+}
+
+// BitableTableConfig 站点查询使用的一张多维表格
+type BitableTableConfig struct {
+	Name        string `yaml:"Name"`        // 表格名称，回复中标注来源，为空使用 TableID
+	AppToken    string `yaml:"AppToken"`    // 多维表格 App Token
+	TableID     string `yaml:"TableID"`     // 表格 ID
+	PrefixField string `yaml:"PrefixField"` // 站点前缀所在的列，默认 "站点前缀"
+	IDField     string `yaml:"IDField"`     // 站点ID所在的列，默认 "站点ID"
+}
+
+// 站点表格的默认列名
+const (
+	DefaultBitablePrefixField = "站点前缀"
+	DefaultBitableIDField     = "站点ID"
+)
+
+// SiteTables 站点查询依次查找的表格（已补齐默认列名）；没有配置 Tables 时使用 AppToken/TableID 这一张表
+func (c BitableConfig) SiteTables() []BitableTableConfig {
+	tables := c.Tables
+	if len(tables) == 0 {
+		if c.AppToken == "" && c.TableID == "" {
+			return nil
+		}
+		tables = []BitableTableConfig{{AppToken: c.AppToken, TableID: c.TableID}}
+	}
+
+	result := make([]BitableTableConfig, len(tables))
+	for i, t := range tables {
+		if t.Name == "" {
+			t.Name = t.TableID
+		}
+		if t.PrefixField == "" {
+			t.PrefixField = DefaultBitablePrefixField
+		}
+		if t.IDField == "" {
+			t.IDField = DefaultBitableIDField
+		}
+		result[i] = t
+	}
+	return result
+}
+
+// BitableFieldConfig 展示的 Bitable 字段
+type BitableFieldConfig struct {
+	Column  string   `yaml:"Column"`  // 表格列名
+	Columns []string `yaml:"Columns"` // 多列合并为一行展示（如 前台域名1~6），与 Column 二选一
+	Label   string   `yaml:"Label"`   // 展示名称，为空则使用列名
 }
 
 // AutoSyncConfig 定时增量同步配置
 type AutoSyncConfig struct {
-	Enabled bool                 `yaml:"Enabled"` // 是否启用定时同步
-	Chats   []AutoSyncChatConfig `yaml:"Chats"`   // 需要同步的群列表
+	Enabled           bool                 `yaml:"Enabled"`           // 是否启用定时同步
+	Chats             []AutoSyncChatConfig `yaml:"Chats"`             // 需要同步的群列表
+	Concurrency       int                  `yaml:"Concurrency"`       // 同时同步的群数量上限，默认 2
+	RequestsPerSecond float64              `yaml:"RequestsPerSecond"` // 所有群共享的拉取消息速率（次/秒），在 Lark.QPS 之内进一步限制，为 0 时只受 Lark.QPS 限制
+	Burst             int                  `yaml:"Burst"`             // 突发请求数，默认与速率相同
+}
+
+// SyncTaskConfig 手动同步任务配置（syncworker 使用）
+type SyncTaskConfig struct {
+	Retries      int `yaml:"Retries"`      // 一批同步遇到临时错误（超时、限流等）时的重试次数，0 使用默认值 3，负数不重试
+	RetryBackoff int `yaml:"RetryBackoff"` // 首次重试前等待的秒数，之后按指数增长（最多 60 秒），0 使用默认值 2
+}
+
+// QuietHoursConfig 免打扰时段：时段内不主动发送通知（结束后补发），可选暂停定时同步
+type QuietHoursConfig struct {
+	Enabled       bool   `yaml:"Enabled"`
+	Start         string `yaml:"Start"`         // 开始时间（HH:MM），如 "22:00"
+	End           string `yaml:"End"`           // 结束时间（HH:MM），早于开始时间表示跨午夜，如 "08:00"
+	Timezone      string `yaml:"Timezone"`      // 时区，如 "Asia/Shanghai"，为空使用服务器本地时区
+	PauseAutoSync bool   `yaml:"PauseAutoSync"` // 时段内暂停定时同步，结束后补拉暂停期间的消息
 }
 
 // AutoSyncChatConfig 单个群的同步配置
@@ -128,4 +326,8 @@ type PermissionsConfig struct {
 	GroupChatAllowedUsers []string `yaml:"GroupChatAllowedUsers"`
 	// 群聊最小成员数：只有成员数 >= 此值的群才能使用机器人
 	GroupMinMembers int `yaml:"GroupMinMembers"`
+	// 同一用户重复发送相同同步命令的最小间隔（秒），窗口内的重复命令只提示"正在处理"；0 使用默认 10 秒，负数关闭
+	SyncCommandInterval int `yaml:"SyncCommandInterval"`
+	// 群聊中 @机器人 "列出群聊" 是否返回机器人加入的所有群，默认关闭（避免向群成员暴露其他群），关闭时提示私聊查看
+	GroupListChats bool `yaml:"GroupListChats"`
 }
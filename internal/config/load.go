@@ -0,0 +1,222 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 默认配置
+const (
+	DefaultServerPort         = 8090
+	DefaultServerMode         = "release"
+	DefaultShutdownTimeout    = 30 // 秒
+	DefaultLarkDomain         = "https://open.feishu.cn"
+	DefaultEmbeddingModel     = "nomic-embed-text"
+	DefaultEmbeddingDimension = 768
+)
+
+// Load 读取并解析配置文件，补齐默认值后校验，所有 cmd 入口统一使用
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+
+	cfg.applyDefaults()
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// applyDefaults 补齐未配置的默认值
+func (c *Config) applyDefaults() {
+	if c.Server.Port == 0 {
+		c.Server.Port = DefaultServerPort
+	}
+	if c.Server.Mode == "" {
+		c.Server.Mode = DefaultServerMode
+	}
+	if c.Server.ShutdownTimeout == 0 {
+		c.Server.ShutdownTimeout = DefaultShutdownTimeout
+	}
+	if c.Lark.Domain == "" {
+		c.Lark.Domain = DefaultLarkDomain
+	}
+	c.Lark.Domain = strings.TrimRight(c.Lark.Domain, "/")
+	if c.VectorDB.EmbeddingModel == "" {
+		c.VectorDB.EmbeddingModel = DefaultEmbeddingModel
+	}
+	if c.VectorDB.EmbeddingDimension == 0 {
+		c.VectorDB.EmbeddingDimension = DefaultEmbeddingDimension
+	}
+}
+
+// Validate 校验配置取值，返回所有问题（而不是遇到第一个就停止）
+func (c *Config) Validate() error {
+	var errs []error
+
+	if c.Server.Port < 0 || c.Server.Port > 65535 {
+		errs = append(errs, fmt.Errorf("Server.Port %d out of range", c.Server.Port))
+	}
+	if c.Server.ShutdownTimeout < 0 {
+		errs = append(errs, fmt.Errorf("Server.ShutdownTimeout %d must not be negative", c.Server.ShutdownTimeout))
+	}
+	if !strings.HasPrefix(c.Lark.Domain, "http://") && !strings.HasPrefix(c.Lark.Domain, "https://") {
+		errs = append(errs, fmt.Errorf("Lark.Domain %q must start with http:// or https://", c.Lark.Domain))
+	}
+
+	if c.Dify.MaxHistoryLength < 0 {
+		errs = append(errs, fmt.Errorf("Dify.MaxHistoryLength %d must not be negative", c.Dify.MaxHistoryLength))
+	}
+
+	switch c.MySQL.TLSMode() {
+	case MySQLTLSDisabled, MySQLTLSVerify, MySQLTLSSkipVerify, MySQLTLSPreferred:
+	default:
+		errs = append(errs, fmt.Errorf("MySQL.TLS %q must be one of true, false, skip-verify, preferred", c.MySQL.TLS))
+	}
+
+	switch c.LLM.SummaryForwardMode {
+	case "", "label", "exclude", "keep":
+	default:
+		errs = append(errs, fmt.Errorf("LLM.SummaryForwardMode %q must be one of label, exclude, keep", c.LLM.SummaryForwardMode))
+	}
+	switch c.LLM.LongReportDelivery {
+	case "", "group", "dm":
+	default:
+		errs = append(errs, fmt.Errorf("LLM.LongReportDelivery %q must be one of group, dm", c.LLM.LongReportDelivery))
+	}
+	switch c.LLM.ScoreDisplay {
+	case "", "raw", "minmax", "sigmoid":
+	default:
+		errs = append(errs, fmt.Errorf("LLM.ScoreDisplay %q must be one of raw, minmax, sigmoid", c.LLM.ScoreDisplay))
+	}
+	if c.LLM.ScoreSigmoidSteepness < 0 {
+		errs = append(errs, fmt.Errorf("LLM.ScoreSigmoidSteepness %v must not be negative", c.LLM.ScoreSigmoidSteepness))
+	}
+	for call, n := range c.LLM.CallMaxTokens {
+		if !isLLMCallSite(call) {
+			errs = append(errs, fmt.Errorf("LLM.CallMaxTokens has unknown call %q", call))
+		} else if n < 0 {
+			errs = append(errs, fmt.Errorf("LLM.CallMaxTokens[%s] %d must not be negative", call, n))
+		}
+	}
+	for call := range c.LLM.CallStop {
+		if !isLLMCallSite(call) {
+			errs = append(errs, fmt.Errorf("LLM.CallStop has unknown call %q", call))
+		}
+	}
+	for _, intent := range c.LLM.EnabledIntents {
+		if !isIntent(intent) {
+			errs = append(errs, fmt.Errorf("LLM.EnabledIntents has unknown intent %q", intent))
+		}
+	}
+	switch c.LLM.ListAnswerMode {
+	case "", "auto", "off":
+	default:
+		errs = append(errs, fmt.Errorf("LLM.ListAnswerMode %q must be one of auto, off", c.LLM.ListAnswerMode))
+	}
+	switch c.LLM.QATimeFallback {
+	case "", "widen", "off":
+	default:
+		errs = append(errs, fmt.Errorf("LLM.QATimeFallback %q must be one of widen, off", c.LLM.QATimeFallback))
+	}
+	if c.Lark.Timeout < 0 {
+		errs = append(errs, fmt.Errorf("Lark.Timeout %d must not be negative", c.Lark.Timeout))
+	}
+	if c.LLM.MaxImageSizeMB < 0 {
+		errs = append(errs, fmt.Errorf("LLM.MaxImageSizeMB %d must not be negative", c.LLM.MaxImageSizeMB))
+	}
+	if c.LLM.QAContextLength < 0 {
+		errs = append(errs, fmt.Errorf("LLM.QAContextLength %d must not be negative", c.LLM.QAContextLength))
+	}
+	if c.LLM.ContextMaxTokens < 0 {
+		errs = append(errs, fmt.Errorf("LLM.ContextMaxTokens %d must not be negative", c.LLM.ContextMaxTokens))
+	}
+	if c.Search.FetchLimit < 0 {
+		errs = append(errs, fmt.Errorf("Search.FetchLimit %d must not be negative", c.Search.FetchLimit))
+	}
+	if c.Search.DisplayLimit < 0 {
+		errs = append(errs, fmt.Errorf("Search.DisplayLimit %d must not be negative", c.Search.DisplayLimit))
+	}
+	switch c.LLM.UnknownSenderMode {
+	case "", "label", "resolve":
+	default:
+		errs = append(errs, fmt.Errorf("LLM.UnknownSenderMode %q must be one of label, resolve", c.LLM.UnknownSenderMode))
+	}
+
+	if c.VectorDB.Enabled {
+		if c.VectorDB.QdrantEndpoint == "" || c.VectorDB.OllamaEndpoint == "" {
+			errs = append(errs, errors.New("VectorDB.QdrantEndpoint and VectorDB.OllamaEndpoint are required when VectorDB is enabled"))
+		}
+		if c.VectorDB.EmbeddingDimension < 0 {
+			errs = append(errs, fmt.Errorf("VectorDB.EmbeddingDimension %d must be positive", c.VectorDB.EmbeddingDimension))
+		}
+		switch c.VectorDB.CollectionStrategy {
+		case "", "single", "per_chat":
+		default:
+			errs = append(errs, fmt.Errorf("VectorDB.CollectionStrategy %q must be one of single, per_chat", c.VectorDB.CollectionStrategy))
+		}
+		switch c.VectorDB.EmbedNumbers {
+		case "", "keep", "mask", "strip":
+		default:
+			errs = append(errs, fmt.Errorf("VectorDB.EmbedNumbers %q must be one of keep, mask, strip", c.VectorDB.EmbedNumbers))
+		}
+		if c.VectorDB.KeepAliveInterval < 0 {
+			errs = append(errs, fmt.Errorf("VectorDB.KeepAliveInterval %d must not be negative", c.VectorDB.KeepAliveInterval))
+		}
+	}
+	if c.Bitable.Enabled {
+		tables := c.Bitable.SiteTables()
+		if len(tables) == 0 {
+			errs = append(errs, errors.New("Bitable.Tables (or Bitable.AppToken and Bitable.TableID) are required when Bitable is enabled"))
+		}
+		for i, t := range tables {
+			if t.AppToken == "" || t.TableID == "" {
+				errs = append(errs, fmt.Errorf("Bitable.Tables[%d].AppToken and TableID are required", i))
+			}
+		}
+	}
+	for i, chat := range c.AutoSync.Chats {
+		if chat.ChatID == "" {
+			errs = append(errs, fmt.Errorf("AutoSync.Chats[%d].ChatID is required", i))
+		}
+	}
+	if c.SyncTask.RetryBackoff < 0 {
+		errs = append(errs, fmt.Errorf("SyncTask.RetryBackoff %d must not be negative", c.SyncTask.RetryBackoff))
+	}
+
+	if _, err := c.QuietHours.Window(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// isIntent 是否是意图解析会返回的意图（与 llm.Intent* 常量一致）
+func isIntent(intent string) bool {
+	switch intent {
+	case "query_workload", "query_commits", "search_message", "summarize", "query_requirement",
+		"qa", "site_query", "group_timeline", "member_groups", "top_ranking", "help":
+		return true
+	}
+	return false
+}
+
+// isLLMCallSite 是否是 LLM 客户端支持按场景配置的调用（与 llm.Call* 常量一致）
+func isLLMCallSite(call string) bool {
+	switch call {
+	case "parse", "generate", "summarize", "translate", "analyze_image", "vision":
+		return true
+	}
+	return false
+}
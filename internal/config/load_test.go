@@ -0,0 +1,176 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeConfig 写入临时配置文件
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	path := writeConfig(t, `
+Server:
+  Port: 9000
+MySQL:
+  Host: "127.0.0.1:3306"
+  TLS: "true"
+Lark:
+  Domain: "https://open.larksuite.com/"
+  AppID: "cli_test"
+VectorDB:
+  Enabled: true
+  QdrantEndpoint: "http://localhost:6333"
+  OllamaEndpoint: "http://localhost:11434"
+`)
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if cfg.Server.Port != 9000 || cfg.Lark.AppID != "cli_test" || cfg.MySQL.TLS != "true" {
+		t.Errorf("Configured values not loaded: %+v", cfg)
+	}
+	if cfg.Lark.Domain != "https://open.larksuite.com" {
+		t.Errorf("Lark.Domain should be trimmed, got %q", cfg.Lark.Domain)
+	}
+	if cfg.Server.Mode != DefaultServerMode {
+		t.Errorf("Server.Mode = %q, want default %q", cfg.Server.Mode, DefaultServerMode)
+	}
+	if cfg.VectorDB.EmbeddingModel != DefaultEmbeddingModel || cfg.VectorDB.EmbeddingDimension != DefaultEmbeddingDimension {
+		t.Errorf("VectorDB defaults not applied: %+v", cfg.VectorDB)
+	}
+}
+
+func TestLoadDefaults(t *testing.T) {
+	cfg, err := Load(writeConfig(t, "Lark:\n  AppID: cli_test\n"))
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if cfg.Server.Port != DefaultServerPort {
+		t.Errorf("Server.Port = %d, want %d", cfg.Server.Port, DefaultServerPort)
+	}
+	if cfg.Lark.Domain != DefaultLarkDomain {
+		t.Errorf("Lark.Domain = %q, want %q", cfg.Lark.Domain, DefaultLarkDomain)
+	}
+}
+
+func TestStorageKeepsRawContent(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want bool
+	}{
+		{"未配置默认保存", "Lark:\n  AppID: cli_test\n", true},
+		{"显式开启", "Storage:\n  KeepRawContent: true\n", true},
+		{"关闭", "Storage:\n  KeepRawContent: false\n", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Load(writeConfig(t, tt.yaml))
+			if err != nil {
+				t.Fatalf("Load() error: %v", err)
+			}
+			if got := cfg.Storage.KeepsRawContent(); got != tt.want {
+				t.Errorf("KeepsRawContent() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"YAML 格式错误", "Server: [", "failed to parse config"},
+		{"TLS 模式非法", "MySQL:\n  TLS: required\n", "MySQL.TLS"},
+		{"端口越界", "Server:\n  Port: 70000\n", "Server.Port"},
+		{"域名缺少协议", "Lark:\n  Domain: open.feishu.cn\n", "Lark.Domain"},
+		{"转发模式非法", "LLM:\n  SummaryForwardMode: drop\n", "SummaryForwardMode"},
+		{"长报告投递方式非法", "LLM:\n  LongReportDelivery: email\n", "LongReportDelivery"},
+		{"向量库缺少地址", "VectorDB:\n  Enabled: true\n", "QdrantEndpoint"},
+		{"自动同步缺少群ID", "AutoSync:\n  Chats:\n    - Name: 研发群\n", "AutoSync.Chats[0].ChatID"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writeConfig(t, tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("Expected error for missing config file")
+	}
+}
+
+func TestValidateReportsAllErrors(t *testing.T) {
+	cfg := Config{
+		Server: ServerConfig{Port: -1},
+		Lark:   LarkConfig{Domain: "feishu", Timeout: -1},
+		LLM: LLMConfig{UnknownSenderMode: "guess", ScoreDisplay: "percentile", ListAnswerMode: "always", QAContextLength: -1, ContextMaxTokens: -1, QATimeFallback: "never", MaxImageSizeMB: -1, EnabledIntents: []string{"timeline"},
+			CallMaxTokens: map[string]int{"summarise": 800, "vision": -1}, CallStop: map[string][]string{"chat": {"END"}}},
+		Dify: DifyConfig{MaxHistoryLength: -1},
+		VectorDB: VectorDBConfig{Enabled: true, QdrantEndpoint: "http://localhost:6333", OllamaEndpoint: "http://localhost:11434",
+			CollectionStrategy: "per_tenant", KeepAliveInterval: -60, EmbedNumbers: "drop"},
+		SyncTask: SyncTaskConfig{RetryBackoff: -1},
+		Search:   SearchConfig{FetchLimit: -1, DisplayLimit: -5},
+		Bitable:  BitableConfig{Enabled: true, Tables: []BitableTableConfig{{Name: "站点", AppToken: "app", TableID: "tbl"}, {Name: "域名", AppToken: "app"}}},
+	}
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Expected validation error")
+	}
+	for _, want := range []string{"Server.Port", "Lark.Domain", "Lark.Timeout", "UnknownSenderMode", "LLM.ScoreDisplay", "LLM.ListAnswerMode", "LLM.QAContextLength", "LLM.ContextMaxTokens", "LLM.QATimeFallback", "LLM.MaxImageSizeMB", `unknown intent "timeline"`, `unknown call "summarise"`, "LLM.CallMaxTokens[vision]", `LLM.CallStop has unknown call "chat"`, "Dify.MaxHistoryLength", "VectorDB.CollectionStrategy", "VectorDB.KeepAliveInterval", "VectorDB.EmbedNumbers", "SyncTask.RetryBackoff", "Search.FetchLimit", "Search.DisplayLimit", "Bitable.Tables[1]"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Error should mention %s, got: %v", want, err)
+		}
+	}
+}
+
+func TestBitableSiteTables(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  BitableConfig
+		want []BitableTableConfig
+	}{
+		{"未配置", BitableConfig{}, nil},
+		{"只配置单表", BitableConfig{AppToken: "app", TableID: "tbl"}, []BitableTableConfig{
+			{Name: "tbl", AppToken: "app", TableID: "tbl", PrefixField: "站点前缀", IDField: "站点ID"},
+		}},
+		{"多表优先于单表配置", BitableConfig{AppToken: "app", TableID: "tbl", Tables: []BitableTableConfig{
+			{Name: "站点表", AppToken: "app", TableID: "tbl_sites"},
+			{Name: "域名表", AppToken: "app", TableID: "tbl_domains", PrefixField: "前缀", IDField: "域名ID"},
+		}}, []BitableTableConfig{
+			{Name: "站点表", AppToken: "app", TableID: "tbl_sites", PrefixField: "站点前缀", IDField: "站点ID"},
+			{Name: "域名表", AppToken: "app", TableID: "tbl_domains", PrefixField: "前缀", IDField: "域名ID"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.cfg.SiteTables()
+			if len(got) != len(tt.want) {
+				t.Fatalf("SiteTables() = %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("SiteTables()[%d] = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// MySQL TLS 模式
+const (
+	MySQLTLSDisabled   = "false"       // 不使用 TLS
+	MySQLTLSVerify     = "true"        // 使用 TLS 并校验服务端证书（系统 CA）
+	MySQLTLSSkipVerify = "skip-verify" // 使用 TLS 但不校验证书
+	MySQLTLSPreferred  = "preferred"   // 服务端支持时使用 TLS（不校验证书），否则明文
+)
+
+// mysqlCustomTLSName 自定义 CA 时注册到驱动的 TLS 配置名
+const mysqlCustomTLSName = "team-assistant-ca"
+
+// TLSMode 返回实际生效的 TLS 模式：未配置 TLS 时兼容旧的 SkipSSL 开关
+func (c MySQLConfig) TLSMode() string {
+	mode := strings.ToLower(strings.TrimSpace(c.TLS))
+	if mode == "" {
+		if c.SkipSSL {
+			return MySQLTLSSkipVerify
+		}
+		return MySQLTLSDisabled
+	}
+	return mode
+}
+
+// DSN 构建 MySQL 连接串，所有入口（服务、syncworker、reindex）统一使用
+// 配置了 CACert 时读取证书并注册自定义 TLS 配置，使用该 CA 校验服务端证书
+func (c MySQLConfig) DSN() (string, error) {
+	dsn := fmt.Sprintf("%s:%s@tcp(%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+		c.User, c.Password, c.Host, c.Database)
+
+	if c.CACert != "" {
+		if err := registerMySQLCA(c.CACert, c.Host); err != nil {
+			return "", err
+		}
+		return dsn + "&tls=" + mysqlCustomTLSName, nil
+	}
+
+	switch mode := c.TLSMode(); mode {
+	case MySQLTLSDisabled:
+		return dsn, nil
+	case MySQLTLSVerify, MySQLTLSSkipVerify, MySQLTLSPreferred:
+		return dsn + "&tls=" + mode, nil
+	default:
+		return "", fmt.Errorf("invalid MySQL TLS mode %q: expect true, false, skip-verify or preferred", mode)
+	}
+}
+
+// registerMySQLCA 读取 CA 证书并注册到 MySQL 驱动
+func registerMySQLCA(caFile, host string) error {
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return fmt.Errorf("failed to read MySQL CA cert: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return fmt.Errorf("no valid certificate found in MySQL CA cert %s", caFile)
+	}
+
+	serverName := host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		serverName = h
+	}
+	if err := mysql.RegisterTLSConfig(mysqlCustomTLSName, &tls.Config{
+		RootCAs:    pool,
+		ServerName: serverName,
+		MinVersion: tls.VersionTLS12,
+	}); err != nil {
+		return fmt.Errorf("failed to register MySQL TLS config: %w", err)
+	}
+	return nil
+}
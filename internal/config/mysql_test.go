@@ -0,0 +1,115 @@
+package config
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestMySQLDSN(t *testing.T) {
+	base := "root:pass@tcp(127.0.0.1:3306)/team_assistant?charset=utf8mb4&parseTime=True&loc=Local"
+
+	tests := []struct {
+		name    string
+		cfg     MySQLConfig
+		want    string
+		wantErr bool
+	}{
+		{"默认不使用 TLS", MySQLConfig{}, base, false},
+		{"兼容 SkipSSL", MySQLConfig{SkipSSL: true}, base + "&tls=skip-verify", false},
+		{"校验证书", MySQLConfig{TLS: "true"}, base + "&tls=true", false},
+		{"跳过校验", MySQLConfig{TLS: "skip-verify"}, base + "&tls=skip-verify", false},
+		{"优先 TLS", MySQLConfig{TLS: " Preferred "}, base + "&tls=preferred", false},
+		{"显式关闭优先于 SkipSSL", MySQLConfig{TLS: "false", SkipSSL: true}, base, false},
+		{"非法模式", MySQLConfig{TLS: "required"}, "", true},
+		{"CA 文件不存在", MySQLConfig{CACert: "/nonexistent/ca.pem"}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			cfg.Host, cfg.User, cfg.Password, cfg.Database = "127.0.0.1:3306", "root", "pass", "team_assistant"
+
+			got, err := cfg.DSN()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DSN() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("DSN() = %q, want %q", got, tt.want)
+			}
+			if err == nil {
+				if _, err := mysql.ParseDSN(got); err != nil {
+					t.Errorf("DSN should be accepted by driver: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestMySQLDSNWithCACert(t *testing.T) {
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, selfSignedCertPEM(t), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := MySQLConfig{Host: "db.internal:3306", User: "root", Database: "team_assistant", TLS: "skip-verify", CACert: caFile}
+	dsn, err := cfg.DSN()
+	if err != nil {
+		t.Fatalf("DSN() error: %v", err)
+	}
+	if !strings.HasSuffix(dsn, "&tls="+mysqlCustomTLSName) {
+		t.Errorf("CA cert should take precedence over TLS mode, got %q", dsn)
+	}
+
+	parsed, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		t.Fatalf("DSN should be accepted by driver: %v", err)
+	}
+	if parsed.TLS == nil || parsed.TLS.ServerName != "db.internal" || parsed.TLS.InsecureSkipVerify {
+		t.Errorf("Unexpected TLS config: %+v", parsed.TLS)
+	}
+}
+
+func TestMySQLDSNInvalidCACert(t *testing.T) {
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := (MySQLConfig{Host: "127.0.0.1:3306", CACert: caFile}).DSN(); err == nil {
+		t.Error("Expected error for invalid CA cert")
+	}
+}
+
+// selfSignedCertPEM 生成测试用自签名证书
+func selfSignedCertPEM(t *testing.T) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// QuietWindow 解析后的免打扰时段，nil 表示未启用
+type QuietWindow struct {
+	start int // 开始时间，距零点的分钟数
+	end   int // 结束时间，距零点的分钟数
+	loc   *time.Location
+}
+
+// Window 解析免打扰时段，未启用时返回 nil
+func (c QuietHoursConfig) Window() (*QuietWindow, error) {
+	if !c.Enabled {
+		return nil, nil
+	}
+
+	start, err := parseClock(c.Start)
+	if err != nil {
+		return nil, fmt.Errorf("QuietHours.Start %q: %w", c.Start, err)
+	}
+	end, err := parseClock(c.End)
+	if err != nil {
+		return nil, fmt.Errorf("QuietHours.End %q: %w", c.End, err)
+	}
+	if start == end {
+		return nil, fmt.Errorf("QuietHours.Start and QuietHours.End must differ, both are %q", c.Start)
+	}
+
+	loc := time.Local
+	if c.Timezone != "" {
+		if loc, err = time.LoadLocation(c.Timezone); err != nil {
+			return nil, fmt.Errorf("QuietHours.Timezone %q: %w", c.Timezone, err)
+		}
+	}
+	return &QuietWindow{start: start, end: end, loc: loc}, nil
+}
+
+// parseClock 解析 HH:MM，返回距零点的分钟数
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, errors.New("must be HH:MM")
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Contains 时间是否处于免打扰时段内
+func (w *QuietWindow) Contains(t time.Time) bool {
+	if w == nil {
+		return false
+	}
+	local := t.In(w.loc)
+	minute := local.Hour()*60 + local.Minute()
+	if w.start < w.end {
+		return minute >= w.start && minute < w.end
+	}
+	// 跨午夜，如 22:00 ~ 08:00
+	return minute >= w.start || minute < w.end
+}
+
+// End 返回 t 所在免打扰时段的结束时间，不在时段内时返回 t
+func (w *QuietWindow) End(t time.Time) time.Time {
+	if !w.Contains(t) {
+		return t
+	}
+	local := t.In(w.loc)
+	end := time.Date(local.Year(), local.Month(), local.Day(), w.end/60, w.end%60, 0, 0, w.loc)
+	if !end.After(local) {
+		// 跨午夜时段的前半段（如 23:00），结束时间在第二天
+		end = end.AddDate(0, 0, 1)
+	}
+	return end
+}
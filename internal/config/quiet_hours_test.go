@@ -0,0 +1,112 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQuietWindowContains(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	at := func(hour, minute int) time.Time { return time.Date(2024, 3, 4, hour, minute, 0, 0, shanghai) }
+
+	tests := []struct {
+		name       string
+		start, end string
+		t          time.Time
+		want       bool
+	}{
+		{"跨午夜-开始时刻", "22:00", "08:00", at(22, 0), true},
+		{"跨午夜-午夜前", "22:00", "08:00", at(23, 30), true},
+		{"跨午夜-午夜后", "22:00", "08:00", at(3, 15), true},
+		{"跨午夜-结束时刻", "22:00", "08:00", at(8, 0), false},
+		{"跨午夜-白天", "22:00", "08:00", at(14, 0), false},
+		{"当天-时段内", "12:00", "13:30", at(13, 0), true},
+		{"当天-时段前", "12:00", "13:30", at(11, 59), false},
+		{"当天-时段后", "12:00", "13:30", at(13, 30), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := QuietHoursConfig{Enabled: true, Start: tt.start, End: tt.end, Timezone: "Asia/Shanghai"}.Window()
+			if err != nil {
+				t.Fatalf("Window() error: %v", err)
+			}
+			if got := w.Contains(tt.t); got != tt.want {
+				t.Errorf("Contains(%s) = %v, want %v", tt.t.Format("15:04"), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuietWindowEnd(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	w, err := QuietHoursConfig{Enabled: true, Start: "22:00", End: "08:00", Timezone: "Asia/Shanghai"}.Window()
+	if err != nil {
+		t.Fatalf("Window() error: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		t    time.Time
+		want time.Time
+	}{
+		{"午夜前结束于次日", time.Date(2024, 3, 4, 23, 10, 0, 0, shanghai), time.Date(2024, 3, 5, 8, 0, 0, 0, shanghai)},
+		{"午夜后结束于当天", time.Date(2024, 3, 5, 2, 0, 0, 0, shanghai), time.Date(2024, 3, 5, 8, 0, 0, 0, shanghai)},
+		{"跨月", time.Date(2024, 3, 31, 22, 30, 0, 0, shanghai), time.Date(2024, 4, 1, 8, 0, 0, 0, shanghai)},
+		{"不在时段内", time.Date(2024, 3, 5, 9, 0, 0, 0, shanghai), time.Date(2024, 3, 5, 9, 0, 0, 0, shanghai)},
+		// 其他时区的时间按配置的时区计算
+		{"UTC 时间", time.Date(2024, 3, 4, 15, 0, 0, 0, time.UTC), time.Date(2024, 3, 5, 8, 0, 0, 0, shanghai)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := w.End(tt.t); !got.Equal(tt.want) {
+				t.Errorf("End(%s) = %s, want %s", tt.t, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuietHoursWindowDisabled(t *testing.T) {
+	w, err := QuietHoursConfig{Start: "bad"}.Window()
+	if err != nil || w != nil {
+		t.Fatalf("Disabled quiet hours should return nil window, got %v, %v", w, err)
+	}
+	now := time.Now()
+	if w.Contains(now) || !w.End(now).Equal(now) {
+		t.Error("Nil window should never be quiet")
+	}
+}
+
+func TestQuietHoursWindowErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  QuietHoursConfig
+		want string
+	}{
+		{"开始时间格式错误", QuietHoursConfig{Enabled: true, Start: "10pm", End: "08:00"}, "QuietHours.Start"},
+		{"结束时间缺失", QuietHoursConfig{Enabled: true, Start: "22:00"}, "QuietHours.End"},
+		{"开始等于结束", QuietHoursConfig{Enabled: true, Start: "22:00", End: "22:00"}, "must differ"},
+		{"未知时区", QuietHoursConfig{Enabled: true, Start: "22:00", End: "08:00", Timezone: "Mars/Base"}, "QuietHours.Timezone"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.cfg.Window()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Window() error = %v, want containing %q", err, tt.want)
+			}
+			cfg := Config{Lark: LarkConfig{Domain: DefaultLarkDomain}, QuietHours: tt.cfg}
+			if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() error = %v, want containing %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"team-assistant/internal/config"
+)
+
+// defaultAIDisabledMessage AI 未配置时的默认回复
+const defaultAIDisabledMessage = "⚠️ AI 功能未配置，请联系管理员设置 Dify 或 LLM API Key。\n\n当前支持的命令：\n• 输入 \"帮助\" 查看使用指南"
+
+// aiEnabled 是否配置了 AI 功能（Dify 或 LLM）；安全模式下使用离线 LLM，也视为可用
+func aiEnabled(cfg config.Config) bool {
+	return cfg.SafeMode || cfg.Dify.Enabled && cfg.Dify.APIKey != "" || cfg.LLM.APIKey != ""
+}
+
+// aiDisabledMessage AI 未配置时对 AI 查询的回复，优先使用配置的 LLM.DisabledMessage
+func aiDisabledMessage(cfg config.Config) string {
+	if msg := strings.TrimSpace(cfg.LLM.DisabledMessage); msg != "" {
+		return msg
+	}
+	return defaultAIDisabledMessage
+}
+
+// replyIfAIDisabled AI 未配置时回复提示并返回 true；确定性命令不应经过这里
+func (h *LarkWebhookHandler) replyIfAIDisabled(ctx context.Context, messageID string) bool {
+	if aiEnabled(h.svcCtx.Config) {
+		return false
+	}
+	if err := h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", aiDisabledMessage(h.svcCtx.Config)); err != nil {
+		log.Printf("Failed to reply message: %v", err)
+	}
+	return true
+}
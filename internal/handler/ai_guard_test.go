@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"team-assistant/internal/config"
+	"team-assistant/internal/svc"
+	"team-assistant/pkg/lark"
+)
+
+// fakeLarkServer 模拟飞书 token、群列表、回复和私信接口，记录回复与私信内容
+type fakeLarkServer struct {
+	mu      sync.Mutex
+	replies []string
+	dms     []string
+	failDM  bool   // 私信接口返回错误
+	image   []byte // 消息资源下载接口返回的图片数据
+}
+
+// messageText 解析请求体中的文本消息内容
+func messageText(r *http.Request) string {
+	body, _ := io.ReadAll(r.Body)
+	var req struct {
+		Content string `json:"content"`
+	}
+	json.Unmarshal(body, &req)
+	var content struct {
+		Text string `json:"text"`
+	}
+	json.Unmarshal([]byte(req.Content), &content)
+	return content.Text
+}
+
+func (s *fakeLarkServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/open-apis/auth/v3/tenant_access_token/internal":
+		w.Write([]byte(`{"code": 0, "tenant_access_token": "t-test", "expire": 7200}`))
+	case r.URL.Path == "/open-apis/im/v1/chats":
+		w.Write([]byte(`{"code": 0, "data": {"items": [{"chat_id": "oc_dev", "name": "研发群"}]}}`))
+	case strings.Contains(r.URL.Path, "/resources/"):
+		w.Write(s.image)
+	case strings.HasSuffix(r.URL.Path, "/reply"):
+		text := messageText(r)
+		s.mu.Lock()
+		s.replies = append(s.replies, text)
+		s.mu.Unlock()
+		w.Write([]byte(`{"code": 0}`))
+	case r.URL.Path == "/open-apis/im/v1/messages" && r.URL.Query().Get("receive_id_type") == "open_id":
+		if s.failDM {
+			w.Write([]byte(`{"code": 230013, "msg": "bot has no availability to this user"}`))
+			return
+		}
+		text := messageText(r)
+		s.mu.Lock()
+		s.dms = append(s.dms, text)
+		s.mu.Unlock()
+		w.Write([]byte(`{"code": 0}`))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// newAIDisabledHandler 创建未配置 AI 的处理器（processor 为空，误入 AI 查询会直接 panic）
+func newAIDisabledHandler(t *testing.T, cfg config.Config) (*LarkWebhookHandler, *fakeLarkServer) {
+	t.Helper()
+	larkServer := &fakeLarkServer{}
+	server := httptest.NewServer(larkServer)
+	t.Cleanup(server.Close)
+
+	h := &LarkWebhookHandler{
+		svcCtx: &svc.ServiceContext{
+			Config:     cfg,
+			LarkClient: lark.NewClient(server.URL, "app", "secret"),
+		},
+		userCache:    make(map[string]map[string]string),
+		imageCache:   make(map[string]*ImageContext),
+		syncDebounce: newCommandDebouncer(syncCommandInterval(0)),
+	}
+	return h, larkServer
+}
+
+func privateEvent(content string) *lark.MessageReceiveEvent {
+	event := &lark.MessageReceiveEvent{}
+	event.Sender.SenderID.OpenID = "ou_user"
+	event.Message.MessageID = "om_1"
+	event.Message.ChatType = "p2p"
+	event.Message.Content = content
+	return event
+}
+
+func TestAIEnabled(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.Config
+		want bool
+	}{
+		{"未配置", config.Config{}, false},
+		{"配置 LLM", config.Config{LLM: config.LLMConfig{APIKey: "sk"}}, true},
+		{"启用 Dify", config.Config{Dify: config.DifyConfig{Enabled: true, APIKey: "app-key"}}, true},
+		{"Dify 未启用", config.Config{Dify: config.DifyConfig{APIKey: "app-key"}}, false},
+		{"安全模式", config.Config{SafeMode: true}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := aiEnabled(tt.cfg); got != tt.want {
+				t.Errorf("aiEnabled() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAIDisabledMessage(t *testing.T) {
+	if got := aiDisabledMessage(config.Config{}); got != defaultAIDisabledMessage {
+		t.Errorf("Expected default message, got %q", got)
+	}
+	cfg := config.Config{LLM: config.LLMConfig{DisabledMessage: " AI 暂未开放 "}}
+	if got := aiDisabledMessage(cfg); got != "AI 暂未开放" {
+		t.Errorf("Expected configured message, got %q", got)
+	}
+}
+
+func TestPrivateCommandsWorkWithAIDisabled(t *testing.T) {
+	cfg := config.Config{LLM: config.LLMConfig{DisabledMessage: "AI 暂未开放"}}
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"帮助", "帮助", "团队助手"},
+		{"列出群聊", "列出群聊", "研发群"},
+		{"AI 查询", "这周大家都在忙什么", "AI 暂未开放"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, larkServer := newAIDisabledHandler(t, cfg)
+			h.handlePrivateCommand(privateEvent(tt.content), tt.content)
+
+			if len(larkServer.replies) != 1 {
+				t.Fatalf("Expected 1 reply, got %v", larkServer.replies)
+			}
+			if !strings.Contains(larkServer.replies[0], tt.want) {
+				t.Errorf("Reply should contain %q, got:\n%s", tt.want, larkServer.replies[0])
+			}
+		})
+	}
+}
+
+func TestGroupQueryWithAIDisabled(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"帮助", "帮助", "团队助手使用指南"},
+		{"AI 查询", "总结一下今天的讨论", "AI 功能未配置"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, larkServer := newAIDisabledHandler(t, config.Config{})
+			h.processQuery("oc_dev", "group", "ou_user", "om_1", "", tt.query)
+
+			if len(larkServer.replies) != 1 {
+				t.Fatalf("Expected 1 reply, got %v", larkServer.replies)
+			}
+			if !strings.Contains(larkServer.replies[0], tt.want) {
+				t.Errorf("Reply should contain %q, got:\n%s", tt.want, larkServer.replies[0])
+			}
+		})
+	}
+}
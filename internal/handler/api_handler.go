@@ -4,10 +4,15 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"net/url"
 	"time"
 
 	"team-assistant/internal/model"
+	"team-assistant/internal/service"
 	"team-assistant/internal/svc"
 )
 
@@ -59,32 +64,16 @@ func (h *StatsHandler) Handle(w http.ResponseWriter, r *http.Request) {
 
 	ctx := context.Background()
 
-	// 解析时间范围
-	startStr := r.URL.Query().Get("start")
-	endStr := r.URL.Query().Get("end")
-
-	var startTime, endTime time.Time
-	var err error
-
-	if startStr != "" {
-		startTime, err = time.Parse("2006-01-02", startStr)
-		if err != nil {
-			writeError(w, http.StatusBadRequest, "Invalid start date format")
-			return
-		}
-	} else {
-		// 默认7天前
-		startTime = time.Now().AddDate(0, 0, -7)
+	page, err := parsePageParams(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
 	}
 
-	if endStr != "" {
-		endTime, err = time.Parse("2006-01-02", endStr)
-		if err != nil {
-			writeError(w, http.StatusBadRequest, "Invalid end date format")
-			return
-		}
-	} else {
-		endTime = time.Now()
+	startTime, endTime, err := parseStatsRange(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
 	}
 
 	// 获取统计数据
@@ -94,13 +83,115 @@ func (h *StatsHandler) Handle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	start, end := page.bounds(len(stats))
+	items := stats[start:end]
+	if items == nil {
+		items = []*model.CommitStats{}
+	}
+	result := page.envelope(items, len(stats))
+	setNextPageLink(w, r, result)
+
 	writeSuccess(w, map[string]interface{}{
-		"start_time": startTime.Format("2006-01-02"),
-		"end_time":   endTime.Format("2006-01-02"),
-		"stats":      stats,
+		"start_time":     startTime.Format("2006-01-02"),
+		"end_time":       endTime.Format("2006-01-02"),
+		"items":          result.Items,
+		"total":          result.Total,
+		"page":           result.Page,
+		"page_size":      result.PageSize,
+		"has_more":       result.HasMore,
+		"runtime":        h.svcCtx.Metrics.Snapshot(),
+		"github_collect": h.githubCollectFreshness(ctx),
 	})
 }
 
+// HandleCSV 以 CSV 导出工作量统计：GET /api/stats.csv?start=&end=，messages=1 时附带每人发言数
+func (h *StatsHandler) HandleCSV(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	ctx := context.Background()
+	startTime, endTime, err := parseStatsRange(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	stats, err := h.svcCtx.CommitModel.GetAllStats(ctx, startTime, endTime)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to get stats")
+		return
+	}
+
+	var messageCounts map[string]int
+	if r.URL.Query().Get("messages") == "1" {
+		if messageCounts, err = h.svcCtx.MessageModel.CountBySender(ctx, startTime, endTime); err != nil {
+			writeError(w, http.StatusInternalServerError, "Failed to count messages")
+			return
+		}
+	}
+
+	filename := fmt.Sprintf("workload_%s_%s.csv", startTime.Format("20060102"), endTime.Format("20060102"))
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	if err := service.WriteWorkloadCSV(w, stats, messageCounts); err != nil {
+		log.Printf("Failed to write workload CSV: %v", err)
+	}
+}
+
+// parseStatsRange 解析 start、end 查询参数（2006-01-02），默认最近 7 天
+func parseStatsRange(query url.Values) (time.Time, time.Time, error) {
+	startTime := time.Now().AddDate(0, 0, -7)
+	endTime := time.Now()
+	var err error
+
+	if s := query.Get("start"); s != "" {
+		if startTime, err = time.Parse("2006-01-02", s); err != nil {
+			return startTime, endTime, errors.New("Invalid start date format")
+		}
+	}
+	if s := query.Get("end"); s != "" {
+		if endTime, err = time.Parse("2006-01-02", s); err != nil {
+			return startTime, endTime, errors.New("Invalid end date format")
+		}
+	}
+	return startTime, endTime, nil
+}
+
+// githubCollectFreshness GitHub 采集新鲜度：整体最后成功时间和各仓库的采集时间，查询失败时返回 nil
+func (h *StatsHandler) githubCollectFreshness(ctx context.Context) map[string]interface{} {
+	if h.svcCtx.GitHubCollectStateModel == nil {
+		return nil
+	}
+	states, err := h.svcCtx.GitHubCollectStateModel.ListAll(ctx)
+	if err != nil {
+		log.Printf("Failed to load GitHub collect state: %v", err)
+		return nil
+	}
+	return githubCollectSnapshot(states)
+}
+
+// githubCollectSnapshot 采集状态快照，没有记录时 last_success_at 为空
+func githubCollectSnapshot(states []*model.GitHubCollectState) map[string]interface{} {
+	repos := make([]map[string]interface{}, 0, len(states))
+	for _, s := range states {
+		repos = append(repos, map[string]interface{}{
+			"repo":            s.RepoFullName,
+			"last_success_at": s.LastSuccessAt.Format(time.RFC3339),
+		})
+	}
+
+	lastSuccess := ""
+	if latest := model.LatestGitHubCollect(states); !latest.IsZero() {
+		lastSuccess = latest.Format(time.RFC3339)
+	}
+	return map[string]interface{}{
+		"last_success_at": lastSuccess,
+		"repos":           repos,
+	}
+}
+
 // MemberHandler 成员管理处理器
 type MemberHandler struct {
 	svcCtx *svc.ServiceContext
@@ -124,6 +215,12 @@ func (h *MemberHandler) Handle(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *MemberHandler) listMembers(w http.ResponseWriter, r *http.Request) {
+	page, err := parsePageParams(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	ctx := context.Background()
 	members, err := h.svcCtx.MemberModel.ListAll(ctx)
 	if err != nil {
@@ -131,7 +228,14 @@ func (h *MemberHandler) listMembers(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	writeSuccess(w, members)
+	start, end := page.bounds(len(members))
+	items := members[start:end]
+	if items == nil {
+		items = []*model.TeamMember{}
+	}
+	result := page.envelope(items, len(members))
+	setNextPageLink(w, r, result)
+	writeSuccess(w, result)
 }
 
 func (h *MemberHandler) addMember(w http.ResponseWriter, r *http.Request) {
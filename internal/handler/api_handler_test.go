@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"team-assistant/internal/model"
+)
+
+func TestGitHubCollectSnapshot(t *testing.T) {
+	t1 := time.Date(2024, 3, 8, 9, 0, 0, 0, time.UTC)
+	t2 := t1.Add(time.Hour)
+
+	tests := []struct {
+		name   string
+		states []*model.GitHubCollectState
+		want   map[string]interface{}
+	}{
+		{"没有采集记录", nil, map[string]interface{}{"last_success_at": "", "repos": []map[string]interface{}{}}},
+		{"多个仓库", []*model.GitHubCollectState{
+			{RepoFullName: "org/api", LastSuccessAt: t1},
+			{RepoFullName: "org/web", LastSuccessAt: t2},
+		}, map[string]interface{}{
+			"last_success_at": "2024-03-08T10:00:00Z",
+			"repos": []map[string]interface{}{
+				{"repo": "org/api", "last_success_at": "2024-03-08T09:00:00Z"},
+				{"repo": "org/web", "last_success_at": "2024-03-08T10:00:00Z"},
+			},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := githubCollectSnapshot(tt.states); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("githubCollectSnapshot() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePageParams(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    pageParams
+		wantErr bool
+	}{
+		{"默认第一页", "", pageParams{Page: 1, PageSize: 50}, false},
+		{"指定分页", "page=3&page_size=20", pageParams{Page: 3, PageSize: 20}, false},
+		{"超过上限按 200", "page_size=1000", pageParams{Page: 1, PageSize: 200}, false},
+		{"页码不能为 0", "page=0", pageParams{}, true},
+		{"每页条数不是数字", "page_size=abc", pageParams{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, _ := url.ParseQuery(tt.query)
+			got, err := parsePageParams(query)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePageParams(%q) error = %v, wantErr %v", tt.query, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("parsePageParams(%q) = %+v, want %+v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPageEnvelope(t *testing.T) {
+	tests := []struct {
+		name               string
+		page               pageParams
+		total              int
+		wantStart, wantEnd int
+		wantMore           bool
+	}{
+		{"第一页还有更多", pageParams{Page: 1, PageSize: 50}, 120, 0, 50, true},
+		{"最后一页不满", pageParams{Page: 3, PageSize: 50}, 120, 100, 120, false},
+		{"刚好整页", pageParams{Page: 2, PageSize: 60}, 120, 60, 120, false},
+		{"超出最后一页为空", pageParams{Page: 5, PageSize: 50}, 120, 120, 120, false},
+		{"没有数据", pageParams{Page: 1, PageSize: 50}, 0, 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := tt.page.bounds(tt.total)
+			if start != tt.wantStart || end != tt.wantEnd {
+				t.Errorf("bounds(%d) = [%d, %d), want [%d, %d)", tt.total, start, end, tt.wantStart, tt.wantEnd)
+			}
+			env := tt.page.envelope(nil, tt.total)
+			if env.HasMore != tt.wantMore || env.Total != tt.total || env.Page != tt.page.Page || env.PageSize != tt.page.PageSize {
+				t.Errorf("envelope() = %+v, want has_more %v", env, tt.wantMore)
+			}
+		})
+	}
+}
+
+func TestSetNextPageLink(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/api/stats?start=2024-01-01&page=1&page_size=2", nil)
+
+	w := httptest.NewRecorder()
+	setNextPageLink(w, r, pageEnvelope{Page: 1, PageSize: 2, Total: 5, HasMore: true})
+	want := `</api/stats?page=2&page_size=2&start=2024-01-01>; rel="next"`
+	if got := w.Header().Get("Link"); got != want {
+		t.Errorf("Link = %q, want %q", got, want)
+	}
+
+	w = httptest.NewRecorder()
+	setNextPageLink(w, r, pageEnvelope{Page: 3, PageSize: 2, Total: 5})
+	if got := w.Header().Get("Link"); got != "" {
+		t.Errorf("Last page should not set Link, got %q", got)
+	}
+}
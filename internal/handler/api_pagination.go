@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+const (
+	defaultPageSize = 50  // 未指定 page_size 时每页条数
+	maxPageSize     = 200 // page_size 上限，超过时按上限返回
+)
+
+// pageParams 列表接口的分页参数，Page 从 1 开始
+type pageParams struct {
+	Page     int
+	PageSize int
+}
+
+// pageEnvelope 分页响应：items 为当前页数据，has_more 表示还有下一页
+type pageEnvelope struct {
+	Items    interface{} `json:"items"`
+	Total    int         `json:"total"`
+	Page     int         `json:"page"`
+	PageSize int         `json:"page_size"`
+	HasMore  bool        `json:"has_more"`
+}
+
+// parsePageParams 解析 page、page_size 查询参数，未指定时为第 1 页、每页 50 条，page_size 超过 200 时按 200
+func parsePageParams(query url.Values) (pageParams, error) {
+	p := pageParams{Page: 1, PageSize: defaultPageSize}
+	if s := query.Get("page"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			return p, fmt.Errorf("invalid page %q", s)
+		}
+		p.Page = n
+	}
+	if s := query.Get("page_size"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			return p, fmt.Errorf("invalid page_size %q", s)
+		}
+		p.PageSize = min(n, maxPageSize)
+	}
+	return p, nil
+}
+
+// bounds 当前页在 total 条数据中的下标范围 [start, end)，超出最后一页时为空
+func (p pageParams) bounds(total int) (start, end int) {
+	start = min((p.Page-1)*p.PageSize, total)
+	end = min(start+p.PageSize, total)
+	return start, end
+}
+
+// envelope 构建分页响应，items 为已截取的当前页数据
+func (p pageParams) envelope(items interface{}, total int) pageEnvelope {
+	_, end := p.bounds(total)
+	return pageEnvelope{Items: items, Total: total, Page: p.Page, PageSize: p.PageSize, HasMore: end < total}
+}
+
+// setNextPageLink 还有下一页时设置 Link 头（rel="next"），保留其余查询参数，便于客户端逐页获取
+func setNextPageLink(w http.ResponseWriter, r *http.Request, page pageEnvelope) {
+	if !page.HasMore {
+		return
+	}
+	query := r.URL.Query()
+	query.Set("page", strconv.Itoa(page.Page+1))
+	query.Set("page_size", strconv.Itoa(page.PageSize))
+	next := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+	w.Header().Set("Link", fmt.Sprintf("<%s>; rel=\"next\"", next.String()))
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// botAliasSeparators 别名之后可以跟的分隔符
+const botAliasSeparators = " \t\n，,：:、。.!！?？~～"
+
+// matchBotAlias 判断群消息是否以机器人别名开头（可带 @ 前缀），返回去掉别名和分隔符后的内容
+// 英文别名后必须是分隔符或消息结尾，避免 "bot" 匹配到 "bottle"；中文别名可以直接接正文，如 "助手帮我总结"
+func matchBotAlias(content string, aliases []string) (string, bool) {
+	text := strings.TrimLeft(strings.TrimSpace(content), "@")
+
+	matched := ""
+	for _, alias := range aliases {
+		alias = strings.TrimSpace(alias)
+		if alias == "" || len(alias) <= len(matched) || len(text) < len(alias) || !strings.EqualFold(text[:len(alias)], alias) {
+			continue
+		}
+		rest := text[len(alias):]
+		if next, _ := utf8.DecodeRuneInString(rest); rest != "" && isASCIIWordRune(next) {
+			last, _ := utf8.DecodeLastRuneInString(alias)
+			if isASCIIWordRune(last) {
+				continue
+			}
+		}
+		// 取最长的别名，"小助手" 优先于 "小助"
+		matched = alias
+	}
+	if matched == "" {
+		return "", false
+	}
+	return strings.TrimSpace(strings.TrimLeft(text[len(matched):], botAliasSeparators)), true
+}
+
+// isASCIIWordRune 是否是英文字母或数字
+func isASCIIWordRune(r rune) bool {
+	return r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
@@ -0,0 +1,38 @@
+package handler
+
+import "testing"
+
+func TestMatchBotAlias(t *testing.T) {
+	aliases := []string{"助手", "小助手", "Bot", " "}
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantOK  bool
+	}{
+		{"空格分隔", "助手 帮我总结一下今天的讨论", "帮我总结一下今天的讨论", true},
+		{"中文逗号", "助手，上周谁提交最多", "上周谁提交最多", true},
+		{"直接接正文", "助手帮我查下 by4 的告警", "帮我查下 by4 的告警", true},
+		{"最长别名优先", "小助手：今天有什么决议", "今天有什么决议", true},
+		{"文本@前缀", "@助手 帮助", "帮助", true},
+		{"英文不区分大小写", "bot, summarize today", "summarize today", true},
+		{"英文单词边界", "bottle 放在哪了", "", false},
+		{"只有别名", "助手", "", true},
+		{"不在开头", "问下助手今天的总结", "", false},
+		{"普通消息", "今天下午三点开会", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := matchBotAlias(tt.content, aliases)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("matchBotAlias(%q) = (%q, %v), want (%q, %v)", tt.content, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+
+	if _, ok := matchBotAlias("助手 帮助", nil); ok {
+		t.Error("No aliases configured should never match")
+	}
+}
@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"team-assistant/internal/model"
+	"team-assistant/pkg/lark"
+)
+
+// 群生命周期事件类型
+const (
+	eventChatDisbanded = "im.chat.disbanded_v1"
+	eventBotAdded      = "im.chat.member.bot.added_v1"
+	eventBotRemoved    = "im.chat.member.bot.deleted_v1"
+)
+
+// chatStatusStore 群状态存储
+type chatStatusStore interface {
+	SetStatus(ctx context.Context, chatID string, status int) error
+}
+
+// chatStatusForEvent 群生命周期事件对应的群状态：解散或机器人被移出后停止同步，重新拉机器人进群后恢复
+func chatStatusForEvent(eventType string) (int, bool) {
+	switch eventType {
+	case eventChatDisbanded, eventBotRemoved:
+		return model.ChatGroupStatusInactive, true
+	case eventBotAdded:
+		return model.ChatGroupStatusActive, true
+	}
+	return 0, false
+}
+
+// parseChatLifecycleEvent 解析群生命周期事件，缺少群ID时返回 false
+func parseChatLifecycleEvent(eventData json.RawMessage) (*lark.ChatLifecycleEvent, bool) {
+	var event lark.ChatLifecycleEvent
+	if err := json.Unmarshal(eventData, &event); err != nil {
+		log.Printf("Failed to parse chat event: %v", err)
+		return nil, false
+	}
+	if event.ChatID == "" {
+		return nil, false
+	}
+	return &event, true
+}
+
+// applyChatLifecycle 按事件更新群状态
+func applyChatLifecycle(ctx context.Context, store chatStatusStore, eventType string, event *lark.ChatLifecycleEvent) error {
+	status, ok := chatStatusForEvent(eventType)
+	if !ok {
+		return nil
+	}
+	log.Printf("Chat %s (%s) %s, marking status=%d", event.ChatID, event.Name, eventType, status)
+	return store.SetStatus(ctx, event.ChatID, status)
+}
+
+// handleChatLifecycle 处理群解散、机器人进出群事件，不可访问的群标记为停用，定时同步随之跳过
+func (h *LarkWebhookHandler) handleChatLifecycle(eventData json.RawMessage, eventType string) {
+	if h.svcCtx.GroupModel == nil {
+		return
+	}
+	event, ok := parseChatLifecycleEvent(eventData)
+	if !ok {
+		return
+	}
+	safeGo(func() {
+		if err := applyChatLifecycle(context.Background(), h.svcCtx.GroupModel, eventType, event); err != nil {
+			log.Printf("Failed to update status of chat %s: %v", event.ChatID, err)
+		}
+	})
+}
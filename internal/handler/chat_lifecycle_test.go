@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"team-assistant/internal/model"
+)
+
+// fakeChatStatusStore 记录群状态变更
+type fakeChatStatusStore struct {
+	statuses map[string]int
+}
+
+func (s *fakeChatStatusStore) SetStatus(ctx context.Context, chatID string, status int) error {
+	s.statuses[chatID] = status
+	return nil
+}
+
+func TestParseChatLifecycleEvent(t *testing.T) {
+	tests := []struct {
+		name  string
+		event string
+		want  bool
+	}{
+		{"群解散", `{"chat_id": "oc_a", "operator_id": {"open_id": "ou_1"}, "external": false}`, true},
+		{"机器人被移出", `{"chat_id": "oc_a", "name": "研发群", "operator_id": {"open_id": "ou_1"}}`, true},
+		{"缺少群ID", `{"operator_id": {"open_id": "ou_1"}}`, false},
+		{"格式错误", `[]`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := parseChatLifecycleEvent(json.RawMessage(tt.event)); ok != tt.want {
+				t.Errorf("parseChatLifecycleEvent() ok = %v, want %v", ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyChatLifecycle(t *testing.T) {
+	event, ok := parseChatLifecycleEvent(json.RawMessage(`{"chat_id": "oc_a", "name": "研发群"}`))
+	if !ok {
+		t.Fatal("Expected valid event")
+	}
+
+	tests := []struct {
+		eventType  string
+		wantStatus int
+		wantSet    bool
+	}{
+		{eventChatDisbanded, model.ChatGroupStatusInactive, true},
+		{eventBotRemoved, model.ChatGroupStatusInactive, true},
+		{eventBotAdded, model.ChatGroupStatusActive, true},
+		{"im.chat.updated_v1", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.eventType, func(t *testing.T) {
+			store := &fakeChatStatusStore{statuses: make(map[string]int)}
+			if err := applyChatLifecycle(context.Background(), store, tt.eventType, event); err != nil {
+				t.Fatal(err)
+			}
+			status, set := store.statuses["oc_a"]
+			if set != tt.wantSet || status != tt.wantStatus {
+				t.Errorf("status = %d (set %v), want %d (set %v)", status, set, tt.wantStatus, tt.wantSet)
+			}
+		})
+	}
+}
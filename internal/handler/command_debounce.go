@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultSyncCommandInterval 同一用户重复发送相同同步命令的默认最小间隔
+const defaultSyncCommandInterval = 10 * time.Second
+
+// commandDebouncer 按用户对相同命令去抖（内存实现），防止连点产生重复回复
+type commandDebouncer struct {
+	window time.Duration
+	now    func() time.Time
+
+	mu   sync.Mutex
+	seen map[string]time.Time // user|command -> 首次执行时间
+}
+
+// newCommandDebouncer 创建命令去抖器，window <= 0 时不去抖
+func newCommandDebouncer(window time.Duration) *commandDebouncer {
+	return &commandDebouncer{
+		window: window,
+		now:    time.Now,
+		seen:   make(map[string]time.Time),
+	}
+}
+
+// allow 判断该用户的命令是否可以执行；窗口内的重复命令返回 false
+// 重复命令不会刷新时间，避免连续发送导致永远无法执行
+func (d *commandDebouncer) allow(userID, command string) bool {
+	if d == nil || d.window <= 0 {
+		return true
+	}
+
+	now := d.now()
+	key := userID + "|" + strings.TrimSpace(command)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// 顺带清理过期记录
+	for k, t := range d.seen {
+		if now.Sub(t) >= d.window {
+			delete(d.seen, k)
+		}
+	}
+
+	if _, ok := d.seen[key]; ok {
+		return false
+	}
+	d.seen[key] = now
+	return true
+}
+
+// syncCommandInterval 解析配置的同步命令最小间隔（秒），0 使用默认值，负数关闭
+func syncCommandInterval(seconds int) time.Duration {
+	switch {
+	case seconds < 0:
+		return 0
+	case seconds == 0:
+		return defaultSyncCommandInterval
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strings"
+
+	"team-assistant/internal/config"
+)
+
+// isConfigStatusCommand 判断是否是 "配置"/"状态" 命令
+func isConfigStatusCommand(content string) bool {
+	switch content {
+	case "配置", "状态", "配置状态", "运行状态":
+		return true
+	}
+	return false
+}
+
+// handleConfigStatus 展示已启用的功能和模型（仅白名单用户可用），密钥等敏感信息脱敏
+func (h *LarkWebhookHandler) handleConfigStatus(ctx context.Context, messageID, senderOpenID string) {
+	if !h.isAllowedUser(senderOpenID) {
+		h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", "抱歉，该命令仅管理员可用。")
+		return
+	}
+
+	if err := h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", formatConfigStatus(h.svcCtx.Config)); err != nil {
+		log.Printf("Failed to reply config status: %v", err)
+	}
+}
+
+// formatConfigStatus 格式化配置概览，只展示功能开关、模型和地址，密钥只显示是否已配置
+func formatConfigStatus(cfg config.Config) string {
+	var sb strings.Builder
+	sb.WriteString("⚙️ 当前配置\n")
+	if cfg.SafeMode {
+		sb.WriteString("🧪 安全模式：已启用（不调用外部 LLM/Embedding/Dify，LLM 返回固定内容）\n")
+	}
+
+	sb.WriteString("\n🤖 AI\n")
+	sb.WriteString(fmt.Sprintf("• AI 问答：%s\n", enabledLabel(aiEnabled(cfg))))
+	sb.WriteString(fmt.Sprintf("• 主模型：%s / %s\n", valueOrUnset(cfg.LLM.Provider), valueOrUnset(cfg.LLM.Model)))
+	sb.WriteString(fmt.Sprintf("   端点：%s | API Key：%s\n", redactURL(cfg.LLM.Endpoint), redactSecret(cfg.LLM.APIKey)))
+	if len(cfg.LLM.FallbackModels) > 0 {
+		models := make([]string, len(cfg.LLM.FallbackModels))
+		for i, m := range cfg.LLM.FallbackModels {
+			models[i] = fmt.Sprintf("%s / %s", valueOrUnset(m.Provider), valueOrUnset(m.Model))
+		}
+		sb.WriteString(fmt.Sprintf("• 备选模型：%s\n", strings.Join(models, "、")))
+	}
+	if cfg.LLM.VisionModel != "" {
+		sb.WriteString(fmt.Sprintf("• 视觉模型：%s\n", cfg.LLM.VisionModel))
+	} else {
+		sb.WriteString("• 视觉模型：未配置\n")
+	}
+	if cfg.LLM.ProxyHost != "" {
+		sb.WriteString(fmt.Sprintf("• 代理：%s:%d | 密码：%s\n", cfg.LLM.ProxyHost, cfg.LLM.ProxyPort, redactSecret(cfg.LLM.ProxyPassword)))
+	}
+	sb.WriteString(fmt.Sprintf("• Dify：%s", enabledLabel(cfg.Dify.Enabled)))
+	if cfg.Dify.Enabled {
+		sb.WriteString(fmt.Sprintf("（%s | API Key：%s）", redactURL(cfg.Dify.BaseURL), redactSecret(cfg.Dify.APIKey)))
+	}
+	sb.WriteString("\n")
+
+	sb.WriteString("\n🔍 检索\n")
+	sb.WriteString(fmt.Sprintf("• 向量检索（RAG）：%s\n", enabledLabel(cfg.VectorDB.Enabled)))
+	if cfg.VectorDB.Enabled {
+		collection := valueOrUnset(cfg.VectorDB.CollectionName)
+		if cfg.VectorDB.CollectionStrategy == "per_chat" {
+			collection += "（每群一个集合）"
+		}
+		sb.WriteString(fmt.Sprintf("   Embedding：%s | 集合：%s\n", valueOrUnset(cfg.VectorDB.EmbeddingModel), collection))
+		sb.WriteString(fmt.Sprintf("   Qdrant：%s | Ollama：%s\n", redactURL(cfg.VectorDB.QdrantEndpoint), redactURL(cfg.VectorDB.OllamaEndpoint)))
+	}
+	sb.WriteString(fmt.Sprintf("• 多维表格：%s\n", enabledLabel(cfg.Bitable.Enabled)))
+
+	sb.WriteString("\n🔄 同步与通知\n")
+	if cfg.AutoSync.Enabled {
+		sb.WriteString(fmt.Sprintf("• 定时同步：已启用（%d 个群）\n", len(cfg.AutoSync.Chats)))
+	} else {
+		sb.WriteString("• 定时同步：未启用\n")
+	}
+	if cfg.QuietHours.Enabled {
+		sb.WriteString(fmt.Sprintf("• 免打扰：%s ~ %s\n", cfg.QuietHours.Start, cfg.QuietHours.End))
+	} else {
+		sb.WriteString("• 免打扰：未启用\n")
+	}
+	sb.WriteString(fmt.Sprintf("• GitHub：Token %s | Webhook Secret %s\n", redactSecret(cfg.GitHub.Token), redactSecret(cfg.GitHub.WebhookSecret)))
+	sb.WriteString(fmt.Sprintf("• 外部消息接入：%s", enabledLabel(cfg.Ingest.Token != "")))
+	return sb.String()
+}
+
+// enabledLabel 功能开关的展示文字
+func enabledLabel(enabled bool) string {
+	if enabled {
+		return "已启用"
+	}
+	return "未启用"
+}
+
+// valueOrUnset 为空时显示 "未配置"
+func valueOrUnset(value string) string {
+	if value == "" {
+		return "未配置"
+	}
+	return value
+}
+
+// redactSecret 密钥脱敏：只显示是否已配置，足够长时保留末 4 位便于核对是哪一个 Key
+func redactSecret(secret string) string {
+	runes := []rune(secret)
+	switch {
+	case len(runes) == 0:
+		return "未配置"
+	case len(runes) < 12:
+		return "已配置"
+	}
+	return "已配置（****" + string(runes[len(runes)-4:]) + "）"
+}
+
+// redactURL 地址脱敏：隐藏 URL 中的密码和查询参数值（部分服务把 Key 放在查询参数里）
+func redactURL(raw string) string {
+	if raw == "" {
+		return "未配置"
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "已配置"
+	}
+	if u.RawQuery != "" {
+		query := u.Query()
+		keys := make([]string, 0, len(query))
+		for key := range query {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for i, key := range keys {
+			keys[i] = url.QueryEscape(key) + "=****"
+		}
+		u.RawQuery = strings.Join(keys, "&")
+	}
+	return u.Redacted()
+}
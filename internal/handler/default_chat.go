@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// 默认群命令的操作
+const (
+	defaultChatShow  = "show"
+	defaultChatSet   = "set"
+	defaultChatClear = "clear"
+)
+
+// parseDefaultChatCommand 解析默认群命令：
+// "默认群" 查看，"设置默认群 研发群"/"默认群 研发群" 设置，"清除默认群"/"默认群 所有群" 清除
+func parseDefaultChatCommand(content string) (action, target string, ok bool) {
+	s := strings.TrimSpace(content)
+	switch s {
+	case "清除默认群", "取消默认群", "删除默认群":
+		return defaultChatClear, "", true
+	}
+
+	for _, cmd := range []string{"设置默认群", "默认群"} {
+		if !isDebugCommand(s, cmd) {
+			continue
+		}
+		target = extractDebugQuery(s, cmd)
+		switch target {
+		case "":
+			if cmd == "默认群" {
+				return defaultChatShow, "", true
+			}
+			return "", "", false
+		case "所有群", "全部群", "无", "清除":
+			return defaultChatClear, "", true
+		}
+		return defaultChatSet, target, true
+	}
+	return "", "", false
+}
+
+// isDefaultChatCommand 判断是否是默认群命令
+func isDefaultChatCommand(content string) bool {
+	_, _, ok := parseDefaultChatCommand(content)
+	return ok
+}
+
+// handleDefaultChat 查看、设置或清除用户的默认群，私聊提问时默认只检索该群
+func (h *LarkWebhookHandler) handleDefaultChat(ctx context.Context, messageID, senderOpenID, content string) {
+	store := h.svcCtx.UserDefaultChatModel
+	if store == nil {
+		h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", "默认群功能未启用")
+		return
+	}
+
+	action, target, _ := parseDefaultChatCommand(content)
+	var reply string
+	switch action {
+	case defaultChatShow:
+		current, err := store.Get(ctx, senderOpenID)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			reply = "📭 尚未设置默认群，私聊提问会搜索所有群\n\n💡 发送 \"设置默认群 群名\" 设置"
+		case err != nil:
+			log.Printf("Failed to get default chat for %s: %v", senderOpenID, err)
+			reply = "获取默认群失败"
+		default:
+			reply = fmt.Sprintf("📌 当前默认群：%s\n\n私聊提问默认只搜索该群，问题中指定其他群或 \"所有群\" 时不受影响。发送 \"清除默认群\" 取消", current.ChatName)
+		}
+
+	case defaultChatSet:
+		chatID, chatName, err := h.findChat(ctx, target)
+		if err != nil {
+			h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", fmt.Sprintf("未找到群聊「%s」，可发送 \"列出群聊\" 查看群名", target))
+			return
+		}
+		if err := store.Set(ctx, senderOpenID, chatID, chatName); err != nil {
+			log.Printf("Failed to set default chat for %s: %v", senderOpenID, err)
+			reply = "设置默认群失败"
+			break
+		}
+		reply = fmt.Sprintf("✅ 已将默认群设置为：%s\n\n私聊提问默认只搜索该群，需要搜索全部时请在问题中带上 \"所有群\"", chatName)
+
+	case defaultChatClear:
+		removed, err := store.Clear(ctx, senderOpenID)
+		switch {
+		case err != nil:
+			log.Printf("Failed to clear default chat for %s: %v", senderOpenID, err)
+			reply = "清除默认群失败"
+		case !removed:
+			reply = "📭 尚未设置默认群"
+		default:
+			reply = "✅ 已清除默认群，私聊提问将搜索所有群"
+		}
+	}
+
+	if err := h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", reply); err != nil {
+		log.Printf("Failed to reply default chat command: %v", err)
+	}
+}
@@ -0,0 +1,33 @@
+package handler
+
+import "testing"
+
+func TestParseDefaultChatCommand(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		wantAction string
+		wantTarget string
+		wantOK     bool
+	}{
+		{"查看", "默认群", defaultChatShow, "", true},
+		{"设置", "设置默认群 研发群", defaultChatSet, "研发群", true},
+		{"简写设置", "默认群 研发群", defaultChatSet, "研发群", true},
+		{"冒号分隔", "设置默认群：研发群", defaultChatSet, "研发群", true},
+		{"清除", "清除默认群", defaultChatClear, "", true},
+		{"设为所有群即清除", "默认群 所有群", defaultChatClear, "", true},
+		{"设置缺少群名", "设置默认群", "", "", false},
+		{"普通提问", "默认群是哪个群开的", "", "", false},
+		{"无关命令", "同步任务", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			action, target, ok := parseDefaultChatCommand(tt.content)
+			if action != tt.wantAction || target != tt.wantTarget || ok != tt.wantOK {
+				t.Errorf("parseDefaultChatCommand(%q) = (%q, %q, %v), want (%q, %q, %v)",
+					tt.content, action, target, ok, tt.wantAction, tt.wantTarget, tt.wantOK)
+			}
+		})
+	}
+}
@@ -122,6 +122,13 @@ func (h *GitHubWebhookHandler) handlePush(payload []byte) {
 			Deletions:     0,
 			FilesChanged:  len(commit.Added) + len(commit.Modified) + len(commit.Removed),
 		}
+		var files []string
+		files = append(files, commit.Added...)
+		files = append(files, commit.Modified...)
+		files = append(files, commit.Removed...)
+		if fileList := model.JoinCommitFiles(files); fileList != "" {
+			gitCommit.FileList = sql.NullString{String: fileList, Valid: true}
+		}
 
 		if err := h.svcCtx.CommitModel.Insert(ctx, gitCommit); err != nil {
 			log.Printf("Failed to save commit %s: %v", commit.ID, err)
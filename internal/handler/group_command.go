@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"context"
+	"log"
+)
+
+// 群聊中不经过 AI 的固定命令
+const (
+	groupCommandHelp      = "help"
+	groupCommandListChats = "list_chats"
+)
+
+// groupCommandWords 群聊固定命令的说法，与私聊命令一致
+var groupCommandWords = map[string]string{
+	"帮助": groupCommandHelp, "help": groupCommandHelp, "菜单": groupCommandHelp,
+	"列出群聊": groupCommandListChats, "群列表": groupCommandListChats, "我的群": groupCommandListChats,
+}
+
+// groupChatListDisabledReply 群聊中未开启列出群聊时的回复
+const groupChatListDisabledReply = "🔒 为避免向群成员暴露其他群，请私聊我发送 \"列出群聊\" 查看机器人加入的群"
+
+// parseGroupCommand 识别群聊中 @机器人 的固定命令（整句匹配，避免误识别普通提问）
+func parseGroupCommand(query string) (string, bool) {
+	cmd, ok := groupCommandWords[query]
+	return cmd, ok
+}
+
+// handleGroupCommand 处理群聊固定命令，直接返回确定的回复而不调用 LLM，返回是否已处理
+func (h *LarkWebhookHandler) handleGroupCommand(ctx context.Context, messageID, query string) bool {
+	cmd, ok := parseGroupCommand(query)
+	if !ok {
+		return false
+	}
+
+	switch cmd {
+	case groupCommandHelp:
+		if err := h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", h.getHelpMessage()); err != nil {
+			log.Printf("Failed to reply help: %v", err)
+		}
+	case groupCommandListChats:
+		if !h.svcCtx.Config.Permissions.GroupListChats {
+			if err := h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", groupChatListDisabledReply); err != nil {
+				log.Printf("Failed to reply chat list hint: %v", err)
+			}
+			return true
+		}
+		h.listChats(ctx, messageID)
+	}
+	return true
+}
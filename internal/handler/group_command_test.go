@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"strings"
+	"testing"
+
+	"team-assistant/pkg/llm"
+)
+
+func TestParseGroupCommand(t *testing.T) {
+	tests := []struct {
+		query  string
+		want   string
+		wantOK bool
+	}{
+		{"帮助", groupCommandHelp, true},
+		{"help", groupCommandHelp, true},
+		{"菜单", groupCommandHelp, true},
+		{"列出群聊", groupCommandListChats, true},
+		{"群列表", groupCommandListChats, true},
+		{"帮助我总结一下今天的讨论", "", false},
+		{"列出群聊里最活跃的人", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got, ok := parseGroupCommand(tt.query)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("parseGroupCommand(%q) = (%q, %v), want (%q, %v)", tt.query, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestGroupCommandShortCircuit(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		listChats bool
+		want      string
+	}{
+		{"帮助不调用 AI", "帮助", false, "团队助手使用指南"},
+		{"菜单不调用 AI", "菜单", false, "团队助手使用指南"},
+		{"默认不在群里列出群聊", "列出群聊", false, "请私聊我"},
+		{"开启后列出群聊", "列出群聊", true, "研发群"},
+		{"普通提问仍走 AI", "帮助我看看登录超时修好了吗", false, llm.OfflineResponse},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, larkServer := newSafeModeHandler(t)
+			h.svcCtx.Config.Permissions.GroupListChats = tt.listChats
+			h.processQuery("oc_dev", "group", "ou_user", "om_1", "", tt.query)
+
+			if len(larkServer.replies) != 1 {
+				t.Fatalf("Expected 1 reply, got %v", larkServer.replies)
+			}
+			reply := larkServer.replies[0]
+			if !strings.Contains(reply, tt.want) {
+				t.Errorf("Expected reply containing %q, got:\n%s", tt.want, reply)
+			}
+			if tt.want != llm.OfflineResponse && strings.Contains(reply, llm.OfflineResponse) {
+				t.Errorf("Command should not go through the LLM, got:\n%s", reply)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package handler
+
+import (
+	"fmt"
+
+	"team-assistant/internal/config"
+)
+
+// defaultMaxImageSizeMB 私聊图片问答默认允许的最大图片（MB）
+const defaultMaxImageSizeMB = 10
+
+// maxImageBytes 私聊图片问答允许的最大字节数
+func maxImageBytes(cfg config.Config) int64 {
+	mb := cfg.LLM.MaxImageSizeMB
+	if mb <= 0 {
+		mb = defaultMaxImageSizeMB
+	}
+	return int64(mb) << 20
+}
+
+// oversizedImageReply 图片超过大小限制时的回复
+func oversizedImageReply(limit int64) string {
+	return fmt.Sprintf("⚠️ 图片过大，请压缩后重试（最大支持 %dMB）", limit>>20)
+}
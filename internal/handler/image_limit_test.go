@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"team-assistant/internal/config"
+)
+
+func TestMaxImageBytes(t *testing.T) {
+	if got := maxImageBytes(config.Config{}); got != defaultMaxImageSizeMB<<20 {
+		t.Errorf("maxImageBytes(default) = %d", got)
+	}
+	cfg := config.Config{LLM: config.LLMConfig{MaxImageSizeMB: 4}}
+	if got := maxImageBytes(cfg); got != 4<<20 {
+		t.Errorf("maxImageBytes(4MB) = %d", got)
+	}
+}
+
+func TestPrivateImageOversized(t *testing.T) {
+	h, larkServer := newSafeModeHandler(t)
+	h.svcCtx.Config.LLM.MaxImageSizeMB = 1
+	larkServer.image = bytes.Repeat([]byte{0xff}, 1<<20+1)
+
+	h.handlePrivateImageMessage(privateEvent("[IMAGE:img_big]"), "[IMAGE:img_big]")
+
+	if len(larkServer.replies) != 1 || !strings.Contains(larkServer.replies[0], "图片过大，请压缩后重试") {
+		t.Fatalf("Expected oversized image reply, got %v", larkServer.replies)
+	}
+	if !strings.Contains(larkServer.replies[0], "1MB") {
+		t.Errorf("Expected reply to mention the limit, got %q", larkServer.replies[0])
+	}
+	if len(h.imageCache) != 0 {
+		t.Errorf("Oversized image should not be cached for follow-ups, got %d entries", len(h.imageCache))
+	}
+}
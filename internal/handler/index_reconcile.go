@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"team-assistant/internal/service"
+)
+
+const indexReconcileShowLimit = 20 // 对账结果最多展示的群数
+
+// isIndexReconcileCommand 判断是否是 "索引对账 [补齐]" 命令
+func isIndexReconcileCommand(content string) bool {
+	return isDebugCommand(content, "索引对账")
+}
+
+// handleIndexReconcile 对比各群 MySQL 消息数和向量库数据点数（仅白名单用户可用）
+// "索引对账 补齐" 时在后台重新索引缺失的消息，完成后回复结果
+func (h *LarkWebhookHandler) handleIndexReconcile(ctx context.Context, messageID, senderOpenID, content string) {
+	if !h.isAllowedUser(senderOpenID) {
+		h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", "抱歉，该命令仅管理员可用。")
+		return
+	}
+	rag := h.svcCtx.Services.RAG
+	if rag == nil || !rag.IsEnabled() {
+		h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", "向量知识库未启用")
+		return
+	}
+
+	reconciler := service.NewIndexReconciler(h.svcCtx.MessageModel, rag)
+	gaps, checked, err := reconciler.Reconcile(ctx)
+	if err != nil {
+		log.Printf("Failed to reconcile index: %v", err)
+		h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", "索引对账失败: "+err.Error())
+		return
+	}
+
+	names := h.chatNames(ctx)
+	reply := formatIndexReconcile(gaps, checked, names)
+	fix := extractDebugQuery(content, "索引对账") == "补齐"
+	var toFix []service.IndexGap
+	for _, g := range gaps {
+		if g.Missing() > 0 {
+			toFix = append(toFix, g)
+		}
+	}
+	if fix && len(toFix) > 0 {
+		reply += fmt.Sprintf("\n\n🔄 已开始补齐 %d 个群的缺失索引，完成后通知", len(toFix))
+	} else if len(toFix) > 0 {
+		reply += "\n\n💡 发送 \"索引对账 补齐\" 重新索引缺失的消息"
+	}
+	if err := h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", reply); err != nil {
+		log.Printf("Failed to reply index reconcile: %v", err)
+	}
+
+	if !fix || len(toFix) == 0 {
+		return
+	}
+	safeGo(func() {
+		bgCtx := context.WithoutCancel(ctx)
+		var sb strings.Builder
+		sb.WriteString("✅ **索引补齐完成**\n\n")
+		for _, g := range toFix {
+			name := chatDisplayName(g.ChatID, names)
+			n, err := reconciler.ReindexMissing(bgCtx, g.ChatID, names[g.ChatID], 0)
+			if err != nil {
+				log.Printf("Failed to reindex missing messages for chat %s: %v", g.ChatID, err)
+				sb.WriteString(fmt.Sprintf("• %s: ❌ %v\n", name, err))
+				continue
+			}
+			sb.WriteString(fmt.Sprintf("• %s: 重新索引 %d 条\n", name, n))
+		}
+		if err := h.svcCtx.LarkClient.ReplyMessage(bgCtx, messageID, "text", strings.TrimRight(sb.String(), "\n")); err != nil {
+			log.Printf("Failed to reply index reindex result: %v", err)
+		}
+	})
+}
+
+// chatNames 获取群ID到群名的映射，查询失败时返回空映射（展示群ID）
+func (h *LarkWebhookHandler) chatNames(ctx context.Context) map[string]string {
+	names := make(map[string]string)
+	groups, err := h.svcCtx.GroupModel.ListAll(ctx)
+	if err != nil {
+		log.Printf("Failed to list groups for chat names: %v", err)
+		return names
+	}
+	for _, g := range groups {
+		if g.ChatName.Valid && g.ChatName.String != "" {
+			names[g.ChatID] = g.ChatName.String
+		}
+	}
+	return names
+}
+
+// chatDisplayName 群名缺失时展示群ID
+func chatDisplayName(chatID string, names map[string]string) string {
+	if name := names[chatID]; name != "" {
+		return name
+	}
+	return chatID
+}
+
+// formatIndexReconcile 格式化对账结果，列出消息数和向量库数据点数不一致的群
+func formatIndexReconcile(gaps []service.IndexGap, checked int, names map[string]string) string {
+	if len(gaps) == 0 {
+		return fmt.Sprintf("✅ **索引对账**\n\n共检查 %d 个群，MySQL 消息数与向量库一致", checked)
+	}
+
+	var missing int64
+	for _, g := range gaps {
+		if g.Missing() > 0 {
+			missing += g.Missing()
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("📊 **索引对账**\n\n")
+	sb.WriteString(fmt.Sprintf("共检查 %d 个群，%d 个群不一致，向量库共缺少 %d 条\n\n", checked, len(gaps), missing))
+	for i, g := range gaps {
+		if i >= indexReconcileShowLimit {
+			sb.WriteString(fmt.Sprintf("... 还有 %d 个群\n", len(gaps)-indexReconcileShowLimit))
+			break
+		}
+		name := chatDisplayName(g.ChatID, names)
+		if g.Missing() > 0 {
+			sb.WriteString(fmt.Sprintf("• %s: MySQL %d / 向量库 %d，缺少 %d\n", name, g.MySQLCount, g.VectorCount, g.Missing()))
+		} else {
+			sb.WriteString(fmt.Sprintf("• %s: MySQL %d / 向量库 %d，多出 %d\n", name, g.MySQLCount, g.VectorCount, -g.Missing()))
+		}
+	}
+	sb.WriteString("\n说明：排除的发言人和只有@提及的消息不参与索引，也会计入缺少的数量")
+	return sb.String()
+}
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"strings"
+	"testing"
+
+	"team-assistant/internal/service"
+)
+
+func TestFormatIndexReconcile(t *testing.T) {
+	names := map[string]string{"oc_dev": "研发群"}
+
+	got := formatIndexReconcile(nil, 3, names)
+	if !strings.Contains(got, "共检查 3 个群") || !strings.Contains(got, "一致") {
+		t.Errorf("Expected consistent summary, got %q", got)
+	}
+
+	gaps := []service.IndexGap{
+		{ChatID: "oc_dev", MySQLCount: 120, VectorCount: 100},
+		{ChatID: "oc_gone", VectorCount: 4},
+	}
+	got = formatIndexReconcile(gaps, 5, names)
+	for _, want := range []string{
+		"2 个群不一致，向量库共缺少 20 条",
+		"• 研发群: MySQL 120 / 向量库 100，缺少 20",
+		"• oc_gone: MySQL 0 / 向量库 4，多出 4",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected %q in %q", want, got)
+		}
+	}
+}
+
+func TestIsIndexReconcileCommand(t *testing.T) {
+	for content, want := range map[string]bool{
+		"索引对账":    true,
+		"索引对账 补齐": true,
+		"索引对账怎么做": false,
+		"知识库状态":   false,
+	} {
+		if got := isIndexReconcileCommand(content); got != want {
+			t.Errorf("isIndexReconcileCommand(%q) = %v, want %v", content, got, want)
+		}
+	}
+}
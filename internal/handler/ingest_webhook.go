@@ -0,0 +1,236 @@
+package handler
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"team-assistant/internal/model"
+	"team-assistant/internal/service"
+	"team-assistant/internal/svc"
+)
+
+// 外部消息接入限制
+const (
+	ingestMaxBodyBytes  = 256 << 10 // 请求体上限 256KB
+	ingestMaxContentLen = 64 << 10  // 单条消息内容上限 64KB
+	ingestMsgType       = "external"
+)
+
+// ingestSourcePattern 来源标识：字母、数字、下划线、中划线，最长 32 位
+var ingestSourcePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+
+// IngestRequest 外部系统推送的消息
+type IngestRequest struct {
+	Source    string          `json:"source"`    // 来源，如 prometheus、grafana
+	ChatID    string          `json:"chat_id"`   // 归属的群ID（可用已有飞书群ID，也可用自定义ID）
+	Sender    string          `json:"sender"`    // 发送者名称，为空时使用 source
+	Content   string          `json:"content"`   // 消息内容
+	Timestamp json.RawMessage `json:"timestamp"` // Unix 秒/毫秒或 RFC3339 字符串，为空时使用当前时间
+}
+
+// messageInserter 消息存储
+type messageInserter interface {
+	Insert(ctx context.Context, msg *model.ChatMessage) error
+}
+
+// messageIndexer 消息向量索引
+type messageIndexer interface {
+	IndexMessage(ctx context.Context, msg *model.ChatMessage, chatName string) error
+}
+
+// IngestWebhookHandler 接收外部系统（Prometheus/Grafana 告警等）推送的消息，存储并索引，便于统一检索
+type IngestWebhookHandler struct {
+	svcCtx  *svc.ServiceContext
+	store   messageInserter
+	indexer messageIndexer
+	now     func() time.Time
+}
+
+// NewIngestWebhookHandler 创建外部消息接入处理器
+func NewIngestWebhookHandler(svcCtx *svc.ServiceContext) *IngestWebhookHandler {
+	h := &IngestWebhookHandler{svcCtx: svcCtx, now: time.Now}
+	if svcCtx.MessageModel != nil {
+		h.store = svcCtx.MessageModel
+	}
+	if svcCtx.Services != nil && svcCtx.Services.RAG != nil {
+		h.indexer = service.NewMessageIndexer(svcCtx.Services.RAG)
+	}
+	return h
+}
+
+// Handle 处理 POST /webhook/ingest
+func (h *IngestWebhookHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	token := h.svcCtx.Config.Ingest.Token
+	if token == "" || h.store == nil {
+		writeError(w, http.StatusServiceUnavailable, "Ingest is not configured")
+		return
+	}
+	if !validIngestToken(r, token) {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, ingestMaxBodyBytes+1))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Failed to read body")
+		return
+	}
+	defer r.Body.Close()
+	if len(body) > ingestMaxBodyBytes {
+		writeError(w, http.StatusRequestEntityTooLarge, "Request body too large")
+		return
+	}
+
+	var req IngestRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid JSON")
+		return
+	}
+
+	msg, err := buildIngestMessage(&req, body, h.now())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// 排除的发言人（监控机器人等）按配置不存储
+	if h.svcCtx.SenderFilter.SkipStore(msg.SenderID.String, msg.SenderName.String) {
+		log.Printf("Skipped ingested message %s from excluded sender %s", msg.MessageID, msg.SenderName.String)
+		writeSuccess(w, map[string]string{"message_id": msg.MessageID, "skipped": "excluded_sender"})
+		return
+	}
+
+	ctx := r.Context()
+	if err := h.store.Insert(ctx, msg); err != nil {
+		log.Printf("Failed to store ingested message from %s: %v", req.Source, err)
+		writeError(w, http.StatusInternalServerError, "Failed to store message")
+		return
+	}
+	log.Printf("Ingested message %s from %s into %s", msg.MessageID, req.Source, msg.ChatID)
+	h.svcCtx.Metrics.RecordMessageIngested()
+
+	// 告警消息解析入库，支持告警趋势/排行查询
+	if h.svcCtx.AlertModel != nil {
+		if _, err := h.svcCtx.AlertModel.RecordMessage(ctx, msg); err != nil {
+			log.Printf("Failed to record alert: %v", err)
+		}
+	}
+
+	if h.indexer != nil {
+		if err := h.indexer.IndexMessage(ctx, msg, h.chatName(ctx, msg.ChatID, req.Source)); err != nil {
+			// 已入库，索引失败不影响接入结果，可通过 reindex 补齐
+			log.Printf("Failed to index ingested message %s: %v", msg.MessageID, err)
+		}
+	}
+
+	writeSuccess(w, map[string]string{"message_id": msg.MessageID})
+}
+
+// chatName 获取群名称，非飞书群时使用来源作为名称
+func (h *IngestWebhookHandler) chatName(ctx context.Context, chatID, source string) string {
+	if h.svcCtx.GroupModel != nil {
+		if group, err := h.svcCtx.GroupModel.FindByChatID(ctx, chatID); err == nil && group.ChatName.Valid {
+			return group.ChatName.String
+		}
+	}
+	return source
+}
+
+// validIngestToken 校验 Authorization: Bearer <token> 或 X-Ingest-Token
+func validIngestToken(r *http.Request, token string) bool {
+	got := r.Header.Get("X-Ingest-Token")
+	if auth := r.Header.Get("Authorization"); got == "" && strings.HasPrefix(auth, "Bearer ") {
+		got = strings.TrimPrefix(auth, "Bearer ")
+	}
+	return got != "" && subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1
+}
+
+// buildIngestMessage 校验请求并转换为消息
+// 消息ID由来源、群、时间和内容计算，携带 timestamp 时外部系统重试不会重复入库
+func buildIngestMessage(req *IngestRequest, raw []byte, now time.Time) (*model.ChatMessage, error) {
+	req.Source = strings.TrimSpace(req.Source)
+	req.ChatID = strings.TrimSpace(req.ChatID)
+	req.Sender = strings.TrimSpace(req.Sender)
+	req.Content = strings.TrimSpace(req.Content)
+
+	switch {
+	case !ingestSourcePattern.MatchString(req.Source):
+		return nil, fmt.Errorf("invalid source: must be 1-32 letters, digits, '_' or '-'")
+	case req.ChatID == "" || len(req.ChatID) > 100:
+		return nil, fmt.Errorf("invalid chat_id: must be 1-100 characters")
+	case req.Content == "":
+		return nil, fmt.Errorf("content is required")
+	case len(req.Content) > ingestMaxContentLen:
+		return nil, fmt.Errorf("content too long: max %d bytes", ingestMaxContentLen)
+	}
+
+	createdAt, err := parseIngestTimestamp(req.Timestamp, now)
+	if err != nil {
+		return nil, err
+	}
+
+	sender := req.Sender
+	if sender == "" {
+		sender = req.Source
+	}
+
+	hash := sha256.Sum256([]byte(req.Source + "\n" + req.ChatID + "\n" + strconv.FormatInt(createdAt.UnixMilli(), 10) + "\n" + req.Content))
+	return &model.ChatMessage{
+		MessageID:   "ext_" + hex.EncodeToString(hash[:16]),
+		ChatID:      req.ChatID,
+		SenderID:    sql.NullString{String: req.Source, Valid: true},
+		SenderName:  sql.NullString{String: sender, Valid: true},
+		MsgType:     sql.NullString{String: ingestMsgType, Valid: true},
+		Content:     sql.NullString{String: req.Content, Valid: true},
+		RawContent:  sql.NullString{String: string(raw), Valid: true},
+		CreatedAt:   createdAt,
+		CreatedAtTs: sql.NullInt64{Int64: createdAt.UnixMilli(), Valid: true},
+	}, nil
+}
+
+// parseIngestTimestamp 解析时间戳：Unix 秒、Unix 毫秒或 RFC3339 字符串，为空时使用 now
+func parseIngestTimestamp(raw json.RawMessage, now time.Time) (time.Time, error) {
+	s := strings.TrimSpace(string(raw))
+	if s == "" || s == "null" || s == `""` {
+		return now, nil
+	}
+
+	if strings.HasPrefix(s, `"`) {
+		var str string
+		if err := json.Unmarshal(raw, &str); err != nil {
+			return time.Time{}, fmt.Errorf("invalid timestamp")
+		}
+		t, err := time.Parse(time.RFC3339, str)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid timestamp: expect unix seconds/milliseconds or RFC3339")
+		}
+		return t.In(now.Location()), nil
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n <= 0 {
+		return time.Time{}, fmt.Errorf("invalid timestamp: expect unix seconds/milliseconds or RFC3339")
+	}
+	// 13 位按毫秒处理
+	if n >= 1e12 {
+		return time.UnixMilli(n).In(now.Location()), nil
+	}
+	return time.Unix(n, 0).In(now.Location()), nil
+}
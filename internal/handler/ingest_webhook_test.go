@@ -0,0 +1,224 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"team-assistant/internal/config"
+	"team-assistant/internal/model"
+	"team-assistant/internal/service"
+	"team-assistant/internal/svc"
+)
+
+// fakeMessageStore 内存版消息存储
+type fakeMessageStore struct {
+	messages []*model.ChatMessage
+	err      error
+}
+
+func (s *fakeMessageStore) Insert(ctx context.Context, msg *model.ChatMessage) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.messages = append(s.messages, msg)
+	return nil
+}
+
+// fakeMessageIndexer 记录被索引的消息
+type fakeMessageIndexer struct {
+	indexed   []*model.ChatMessage
+	chatNames []string
+}
+
+func (i *fakeMessageIndexer) IndexMessage(ctx context.Context, msg *model.ChatMessage, chatName string) error {
+	i.indexed = append(i.indexed, msg)
+	i.chatNames = append(i.chatNames, chatName)
+	return nil
+}
+
+func newTestIngestHandler(token string) (*IngestWebhookHandler, *fakeMessageStore, *fakeMessageIndexer) {
+	store := &fakeMessageStore{}
+	indexer := &fakeMessageIndexer{}
+	h := &IngestWebhookHandler{
+		svcCtx:  &svc.ServiceContext{Config: config.Config{Ingest: config.IngestConfig{Token: token}}},
+		store:   store,
+		indexer: indexer,
+		now:     func() time.Time { return time.Date(2024, 3, 1, 12, 0, 0, 0, time.Local) },
+	}
+	return h, store, indexer
+}
+
+func doIngest(h *IngestWebhookHandler, method, auth, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/webhook/ingest", strings.NewReader(body))
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	w := httptest.NewRecorder()
+	h.Handle(w, req)
+	return w
+}
+
+func TestIngestWebhookStoresAndIndexes(t *testing.T) {
+	h, store, indexer := newTestIngestHandler("secret")
+
+	body := `{"source": "prometheus", "chat_id": "oc_ops", "sender": "Alertmanager", "content": "by4 支付慢请求告警", "timestamp": 1709251200}`
+	w := doIngest(h, http.MethodPost, "Bearer secret", body)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if len(store.messages) != 1 {
+		t.Fatalf("Expected 1 stored message, got %d", len(store.messages))
+	}
+	msg := store.messages[0]
+	if msg.ChatID != "oc_ops" || msg.SenderName.String != "Alertmanager" || msg.Content.String != "by4 支付慢请求告警" {
+		t.Errorf("Unexpected stored message: %+v", msg)
+	}
+	if msg.MsgType.String != ingestMsgType || msg.SenderID.String != "prometheus" {
+		t.Errorf("Expected external message from prometheus, got type=%q sender_id=%q", msg.MsgType.String, msg.SenderID.String)
+	}
+	if !msg.CreatedAt.Equal(time.Unix(1709251200, 0)) {
+		t.Errorf("Unexpected created_at: %v", msg.CreatedAt)
+	}
+
+	if len(indexer.indexed) != 1 || indexer.indexed[0] != msg || indexer.chatNames[0] != "prometheus" {
+		t.Errorf("Expected message indexed with source as chat name, got %v %v", indexer.indexed, indexer.chatNames)
+	}
+
+	var resp Response
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	data, _ := resp.Data.(map[string]interface{})
+	if resp.Code != 0 || data["message_id"] != msg.MessageID {
+		t.Errorf("Unexpected response: %s", w.Body.String())
+	}
+
+	// 相同请求重试时消息ID不变（INSERT IGNORE/ON DUPLICATE 去重）
+	doIngest(h, http.MethodPost, "Bearer secret", body)
+	if len(store.messages) != 2 || store.messages[1].MessageID != msg.MessageID {
+		t.Errorf("Expected stable message ID on retry")
+	}
+}
+
+func TestIngestWebhookRejects(t *testing.T) {
+	valid := `{"source": "grafana", "chat_id": "oc_ops", "content": "CPU 告警"}`
+
+	tests := []struct {
+		name     string
+		token    string
+		method   string
+		auth     string
+		body     string
+		wantCode int
+	}{
+		{"未配置令牌", "", http.MethodPost, "Bearer ", valid, http.StatusServiceUnavailable},
+		{"缺少令牌", "secret", http.MethodPost, "", valid, http.StatusUnauthorized},
+		{"令牌错误", "secret", http.MethodPost, "Bearer wrong", valid, http.StatusUnauthorized},
+		{"方法错误", "secret", http.MethodGet, "Bearer secret", "", http.StatusMethodNotAllowed},
+		{"非法 JSON", "secret", http.MethodPost, "Bearer secret", `{`, http.StatusBadRequest},
+		{"缺少内容", "secret", http.MethodPost, "Bearer secret", `{"source": "grafana", "chat_id": "oc_ops", "content": "  "}`, http.StatusBadRequest},
+		{"缺少群ID", "secret", http.MethodPost, "Bearer secret", `{"source": "grafana", "content": "告警"}`, http.StatusBadRequest},
+		{"非法来源", "secret", http.MethodPost, "Bearer secret", `{"source": "graf ana", "chat_id": "oc_ops", "content": "告警"}`, http.StatusBadRequest},
+		{"非法时间", "secret", http.MethodPost, "Bearer secret", `{"source": "grafana", "chat_id": "oc_ops", "content": "告警", "timestamp": "昨天"}`, http.StatusBadRequest},
+		{"请求体过大", "secret", http.MethodPost, "Bearer secret", `{"source": "grafana", "chat_id": "oc_ops", "content": "` + strings.Repeat("a", ingestMaxBodyBytes) + `"}`, http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, store, _ := newTestIngestHandler(tt.token)
+			w := doIngest(h, tt.method, tt.auth, tt.body)
+			if w.Code != tt.wantCode {
+				t.Errorf("Expected %d, got %d: %s", tt.wantCode, w.Code, w.Body.String())
+			}
+			if len(store.messages) != 0 {
+				t.Errorf("Expected nothing stored, got %d", len(store.messages))
+			}
+		})
+	}
+}
+
+func TestIngestWebhookStoreError(t *testing.T) {
+	h, store, indexer := newTestIngestHandler("secret")
+	store.err = errors.New("db down")
+
+	w := doIngest(h, http.MethodPost, "Bearer secret", `{"source": "grafana", "chat_id": "oc_ops", "content": "告警"}`)
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected 500, got %d", w.Code)
+	}
+	if len(indexer.indexed) != 0 {
+		t.Errorf("Should not index when storing fails")
+	}
+}
+
+func TestParseIngestTimestamp(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name    string
+		raw     string
+		want    time.Time
+		wantErr bool
+	}{
+		{"为空", ``, now, false},
+		{"null", `null`, now, false},
+		{"秒", `1709251200`, time.Unix(1709251200, 0), false},
+		{"毫秒", `1709251200123`, time.UnixMilli(1709251200123), false},
+		{"RFC3339", `"2024-03-01T08:00:00Z"`, time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC), false},
+		{"负数", `-1`, time.Time{}, true},
+		{"非法字符串", `"03-01"`, time.Time{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseIngestTimestamp(json.RawMessage(tt.raw), now)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseIngestTimestamp failed: %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("parseIngestTimestamp(%s) = %v, want %v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidIngestToken(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/webhook/ingest", nil)
+	req.Header.Set("X-Ingest-Token", "secret")
+	if !validIngestToken(req, "secret") {
+		t.Error("Expected X-Ingest-Token to be accepted")
+	}
+	req.Header.Set("X-Ingest-Token", "secret2")
+	if validIngestToken(req, "secret") {
+		t.Error("Expected mismatched token to be rejected")
+	}
+}
+
+func TestIngestWebhookSkipsExcludedSender(t *testing.T) {
+	h, store, indexer := newTestIngestHandler("secret")
+	h.svcCtx.SenderFilter = service.NewSenderFilter([]string{"Alertmanager"}, true)
+
+	w := doIngest(h, http.MethodPost, "Bearer secret", `{"source": "prometheus", "chat_id": "oc_ops", "sender": "Alertmanager", "content": "CPU 告警"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(store.messages) != 0 || len(indexer.indexed) != 0 {
+		t.Errorf("Excluded sender should be neither stored nor indexed, got stored=%d indexed=%d", len(store.messages), len(indexer.indexed))
+	}
+
+	// 其他发言人照常入库
+	doIngest(h, http.MethodPost, "Bearer secret", `{"source": "prometheus", "chat_id": "oc_ops", "sender": "值班同学", "content": "已处理"}`)
+	if len(store.messages) != 1 {
+		t.Errorf("Expected other senders stored, got %d", len(store.messages))
+	}
+}
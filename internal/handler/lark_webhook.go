@@ -3,23 +3,27 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"team-assistant/internal/logic/ai"
+	"team-assistant/internal/model"
 	"team-assistant/internal/service"
 	"team-assistant/internal/svc"
 	"team-assistant/pkg/lark"
+	"team-assistant/pkg/llm"
 )
 
 // MessageSyncer 简化的消息同步器接口
 type MessageSyncer interface {
-	CreateSyncTask(ctx context.Context, chatID, chatName, requestedBy string) (int64, error)
+	CreateSyncTask(ctx context.Context, chatID, chatName, requestedBy string, start, end time.Time) (int64, error)
 }
 
 // ChatTurn 单轮对话
@@ -51,6 +55,8 @@ type LarkWebhookHandler struct {
 	// 图片会话缓存 (messageID -> ImageContext)
 	imageCache   map[string]*ImageContext
 	imageCacheMu sync.RWMutex
+	// 同步命令去抖（同一用户短时间内重复发送相同命令）
+	syncDebounce *commandDebouncer
 }
 
 // NewLarkWebhookHandler 创建飞书Webhook处理器
@@ -62,31 +68,41 @@ func NewLarkWebhookHandler(svcCtx *svc.ServiceContext) *LarkWebhookHandler {
 	}
 
 	h := &LarkWebhookHandler{
-		svcCtx:     svcCtx,
-		processor:  ai.NewHybridProcessor(svcCtx),
-		converter:  service.NewMessageConverter(),
-		indexer:    indexer,
-		userCache:  make(map[string]map[string]string),
-		imageCache: make(map[string]*ImageContext),
+		svcCtx:       svcCtx,
+		processor:    ai.NewHybridProcessor(svcCtx),
+		converter:    service.NewMessageConverter(),
+		indexer:      indexer,
+		userCache:    make(map[string]map[string]string),
+		imageCache:   make(map[string]*ImageContext),
+		syncDebounce: newCommandDebouncer(syncCommandInterval(svcCtx.Config.Permissions.SyncCommandInterval)),
 	}
 	// 启动图片缓存清理协程
-	go h.cleanImageCache()
+	svcCtx.Lifecycle.Go("image_cache_cleaner", h.cleanImageCache)
 	return h
 }
 
-// cleanImageCache 定期清理过期的图片缓存（保留10分钟）
-func (h *LarkWebhookHandler) cleanImageCache() {
+// cleanImageCache 定期清理过期的图片缓存（保留10分钟），ctx 取消时退出
+func (h *LarkWebhookHandler) cleanImageCache(ctx context.Context) {
 	ticker := time.NewTicker(5 * time.Minute)
 	defer ticker.Stop()
-	for range ticker.C {
-		h.imageCacheMu.Lock()
-		now := time.Now()
-		for msgID, ctx := range h.imageCache {
-			if now.Sub(ctx.CreatedAt) > 10*time.Minute {
-				delete(h.imageCache, msgID)
-			}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.removeExpiredImages(time.Now())
+		}
+	}
+}
+
+// removeExpiredImages 删除超过 10 分钟的图片缓存
+func (h *LarkWebhookHandler) removeExpiredImages(now time.Time) {
+	h.imageCacheMu.Lock()
+	defer h.imageCacheMu.Unlock()
+	for msgID, imgCtx := range h.imageCache {
+		if now.Sub(imgCtx.CreatedAt) > 10*time.Minute {
+			delete(h.imageCache, msgID)
 		}
-		h.imageCacheMu.Unlock()
 	}
 }
 
@@ -131,6 +147,11 @@ func (h *LarkWebhookHandler) SetMessageSyncer(syncer MessageSyncer) {
 	h.msgSyncer = syncer
 }
 
+// SetReportExporter 设置报告导出器（生成的群总结/群历程推送到知识库）
+func (h *LarkWebhookHandler) SetReportExporter(exporter ai.ReportExporter) {
+	h.processor.SetReportExporter(exporter)
+}
+
 // safeGo 安全地启动一个 goroutine，捕获 panic 防止程序崩溃
 func safeGo(fn func()) {
 	go func() {
@@ -211,6 +232,10 @@ func (h *LarkWebhookHandler) Handle(w http.ResponseWriter, r *http.Request) {
 	switch eventType {
 	case "im.message.receive_v1":
 		h.handleMessageReceive(callback.Event)
+	case eventReactionCreated, eventReactionDeleted:
+		h.handleMessageReaction(callback.Event, eventType == eventReactionCreated)
+	case eventChatDisbanded, eventBotAdded, eventBotRemoved:
+		h.handleChatLifecycle(callback.Event, eventType)
 	default:
 		log.Printf("Unknown event type: %s", eventType)
 	}
@@ -231,8 +256,8 @@ func (h *LarkWebhookHandler) handleMessageReceive(eventData json.RawMessage) {
 	// 解析消息内容
 	content := lark.ParseMessageContent(event.Message.MessageType, event.Message.Content)
 
-	// 存储所有消息用于后续搜索（仅群聊消息）
-	if event.Message.ChatType == "group" {
+	// 存储所有消息用于后续搜索（仅群聊消息，包括话题群）
+	if event.Message.ChatType != "p2p" {
 		safeGo(func() { h.storeMessage(&event, content) })
 	}
 
@@ -268,7 +293,12 @@ func (h *LarkWebhookHandler) handleMessageReceive(eventData json.RawMessage) {
 	// 群聊消息需要检查是否@机器人
 	isAtBot := lark.IsAtBot(&event, h.svcCtx.Config.Lark.BotOpenID)
 	if !isAtBot {
-		return
+		// 以机器人别名开头的消息（如 "助手 帮我总结一下"）同样视为在问机器人，去掉别名后处理
+		stripped, ok := matchBotAlias(content, h.svcCtx.Config.Lark.BotAliases)
+		if !ok {
+			return
+		}
+		content = stripped
 	}
 
 	if content == "" {
@@ -304,7 +334,9 @@ func (h *LarkWebhookHandler) handleMessageReceive(eventData json.RawMessage) {
 	log.Printf("Received bot message: %s, rootID: %s", content, event.Message.RootID)
 
 	// 处理用户查询（传递 rootID 用于判断是否是回复追问）
-	safeGo(func() { h.processQuery(event.Message.ChatID, event.Message.MessageID, event.Message.RootID, content) })
+	safeGo(func() {
+		h.processQuery(event.Message.ChatID, event.Message.ChatType, event.Sender.SenderID.OpenID, event.Message.MessageID, event.Message.RootID, content)
+	})
 }
 
 // GetUserName 获取用户名（带缓存），实现 service.UserNameFetcher 接口
@@ -323,10 +355,12 @@ func (h *LarkWebhookHandler) GetUserName(ctx context.Context, chatID, openID str
 	if chatCache, ok := h.userCache[chatID]; ok {
 		if name, ok := chatCache[openID]; ok {
 			h.userCacheMu.RUnlock()
+			h.svcCtx.Metrics.RecordCacheLookup("user_name", true)
 			return name
 		}
 	}
 	h.userCacheMu.RUnlock()
+	h.svcCtx.Metrics.RecordCacheLookup("user_name", false)
 
 	// 缓存未命中，加载群成员
 	members, err := h.svcCtx.LarkClient.GetChatMembers(ctx, chatID)
@@ -363,18 +397,34 @@ func (h *LarkWebhookHandler) storeMessage(event *lark.MessageReceiveEvent, conte
 		service.WithUserNameFetcher(h),
 	)
 
+	// 排除的发言人（监控机器人等）按配置不存储
+	if h.svcCtx.SenderFilter.SkipStore(msg.SenderID.String, msg.SenderName.String) {
+		return
+	}
+
 	// 存储到数据库
-	if err := h.svcCtx.MessageModel.Insert(ctx, msg); err != nil {
+	stored, err := h.svcCtx.MessageModel.Upsert(ctx, msg)
+	if err != nil {
 		log.Printf("Failed to store message: %v", err)
 		return
 	}
 
 	log.Printf("Stored message: %s from %s (%s)", event.Message.MessageID, msg.SenderName.String, event.Sender.SenderID.OpenID)
+	h.svcCtx.Metrics.RecordMessageStored()
+
+	// 告警消息解析入库（自动同步会跳过已存在的消息，实时消息需在这里记录）
+	if h.svcCtx.AlertModel != nil {
+		if _, err := h.svcCtx.AlertModel.RecordMessage(ctx, msg); err != nil {
+			log.Printf("Failed to record alert: %v", err)
+		}
+	}
 
-	// 索引到向量数据库
+	// 索引到向量数据库：同步已写入时以合并后的行为准，没有变化且已索引的消息不再重复索引
 	if h.indexer != nil {
-		chatName := h.getChatName(ctx, event.Message.ChatID)
-		h.indexer.IndexMessage(ctx, msg, chatName)
+		if indexed := service.MessageForIndex(ctx, h.svcCtx.MessageModel, h.svcCtx.Services.RAG, msg, stored); indexed != nil {
+			chatName := h.getChatName(ctx, event.Message.ChatID)
+			h.indexer.IndexMessage(ctx, indexed, chatName)
+		}
 	}
 }
 
@@ -389,33 +439,45 @@ func (h *LarkWebhookHandler) getChatName(ctx context.Context, chatID string) str
 }
 
 // processQuery 处理用户查询
-func (h *LarkWebhookHandler) processQuery(chatID, messageID, rootID, query string) {
-	ctx := context.Background()
+func (h *LarkWebhookHandler) processQuery(chatID, chatType, senderOpenID, messageID, rootID, query string) {
+	// 回复按所在群排队，同一群的连续回复不超过飞书的频率限制
+	ctx := lark.WithReplyChat(context.Background(), chatID)
 
 	log.Printf("Processing query: %s", query)
 
-	// 检查是否配置了 AI 功能（Dify 或 LLM）
-	hasAI := h.svcCtx.Config.Dify.Enabled && h.svcCtx.Config.Dify.APIKey != "" ||
-		h.svcCtx.Config.LLM.APIKey != ""
+	// 群项目信息命令不依赖 AI
+	if h.handleGroupMetadataCommand(ctx, chatID, senderOpenID, messageID, query) {
+		return
+	}
 
-	if !hasAI {
-		reply := "⚠️ AI 功能未配置，请联系管理员设置 Dify 或 LLM API Key。\n\n当前支持的命令：\n• 输入 \"帮助\" 查看使用指南"
-		if query == "帮助" || query == "help" {
-			reply = h.getHelpMessage()
-		}
-		if err := h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", reply); err != nil {
-			log.Printf("Failed to reply message: %v", err)
-		}
+	// 帮助、列出群聊等固定命令不依赖 AI
+	if h.handleGroupCommand(ctx, messageID, query) {
+		return
+	}
+
+	// 以下均为 AI 查询，未配置 AI 时直接提示
+	if h.replyIfAIDisabled(ctx, messageID) {
 		return
 	}
 
-	// 使用混合处理器处理查询
-	// 传递 rootID，用于判断是否是回复追问（只有有 rootID 的才视为追问）
-	isReplyFollowUp := rootID != ""
-	reply, err := h.processor.ProcessQuery(ctx, chatID, query, isReplyFollowUp)
+	var reply string
+	var err error
+	if ai.IsCatchUpQuery(query) && h.svcCtx.Services.ReadState != nil {
+		// "我不在的时候发生了什么"：总结该用户上次查看以来的消息
+		reply, err = h.catchUp(ctx, chatID, senderOpenID)
+	} else {
+		// 使用混合处理器处理查询
+		// 传递 rootID，用于判断是否是回复追问（只有有 rootID 的才视为追问）
+		isReplyFollowUp := rootID != ""
+		reply, err = h.processor.ProcessQuery(ctx, chatID, chatType, query, isReplyFollowUp)
+	}
+	h.svcCtx.Metrics.RecordQuery(err)
 	if err != nil {
 		log.Printf("Query processing error: %v", err)
 		reply = "处理请求时出错，请稍后重试。"
+	} else {
+		// 记录用户在该群的最后查看时间；处理失败时不更新，避免用户丢失未读的消息范围
+		h.markRead(ctx, senderOpenID, chatID)
 	}
 
 	// 添加模型来源标识
@@ -424,9 +486,126 @@ func (h *LarkWebhookHandler) processQuery(chatID, messageID, rootID, query strin
 		reply = reply + "\n\n---\n_🤖 Powered by " + modelName + "_"
 	}
 
-	if err := h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", reply); err != nil {
-		log.Printf("Failed to reply message: %v", err)
+	h.deliverReply(ctx, chatType, senderOpenID, messageID, reply)
+}
+
+// catchUp 生成用户在群内的补课摘要（上次查看/提问以来的消息）
+func (h *LarkWebhookHandler) catchUp(ctx context.Context, chatID, senderOpenID string) (string, error) {
+	start, end, hasState, err := h.svcCtx.Services.ReadState.DigestRange(ctx, senderOpenID, chatID, time.Now())
+	if err != nil {
+		log.Printf("Failed to get read state: %v", err)
+		return "", err
+	}
+	return h.processor.SummarizeSince(ctx, chatID, start, end, hasState)
+}
+
+// markRead 更新用户在群内的已读时间
+func (h *LarkWebhookHandler) markRead(ctx context.Context, senderOpenID, chatID string) {
+	if h.svcCtx.Services.ReadState == nil {
+		return
+	}
+	if err := h.svcCtx.Services.ReadState.MarkRead(ctx, senderOpenID, chatID, time.Now()); err != nil {
+		log.Printf("Failed to update read state: %v", err)
+	}
+}
+
+// 群项目信息命令
+const (
+	groupMetaShow        = "项目信息"
+	groupMetaSetProject  = "设置项目"
+	groupMetaSetKeywords = "设置关键词"
+	groupMetaClear       = "清除项目"
+)
+
+// parseGroupMetadataCommand 解析群项目信息命令，返回命令名和参数
+// 要求命令后紧跟空白或冒号，避免误伤 "设置项目进度提醒" 之类的普通提问
+func parseGroupMetadataCommand(query string) (cmd, arg string, ok bool) {
+	query = strings.TrimSpace(query)
+	switch query {
+	case groupMetaShow, groupMetaClear:
+		return query, "", true
+	}
+	for _, prefix := range []string{groupMetaSetKeywords, groupMetaSetProject} {
+		if !strings.HasPrefix(query, prefix) {
+			continue
+		}
+		rest := strings.TrimPrefix(query, prefix)
+		if rest != "" && !strings.HasPrefix(rest, " ") && !strings.HasPrefix(rest, ":") && !strings.HasPrefix(rest, "：") {
+			continue
+		}
+		return prefix, strings.TrimSpace(strings.TrimLeft(rest, ":： ")), true
 	}
+	return "", "", false
+}
+
+// formatGroupMetadata 格式化群项目信息
+func formatGroupMetadata(group *model.ChatGroup) string {
+	project := "未设置"
+	if group.Project.Valid && group.Project.String != "" {
+		project = group.Project.String
+	}
+	keywords := "未设置"
+	if list := group.KeywordList(); len(list) > 0 {
+		keywords = strings.Join(list, "、")
+	}
+	return fmt.Sprintf("🏷️ **群项目信息**\n\n项目：%s\n关键词：%s\n\n💡 管理员可发送「设置项目 名称」「设置关键词 a,b,c」「清除项目」进行修改", project, keywords)
+}
+
+// handleGroupMetadataCommand 处理群项目信息命令，返回是否已处理
+// 查看所有人可用，修改仅白名单用户可用
+func (h *LarkWebhookHandler) handleGroupMetadataCommand(ctx context.Context, chatID, senderOpenID, messageID, query string) bool {
+	cmd, arg, ok := parseGroupMetadataCommand(query)
+	if !ok {
+		return false
+	}
+
+	reply := func(text string) {
+		if err := h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", text); err != nil {
+			log.Printf("Failed to reply message: %v", err)
+		}
+	}
+
+	if cmd != groupMetaShow && !h.isAllowedUser(senderOpenID) {
+		reply("抱歉，该命令仅管理员可用。")
+		return true
+	}
+
+	chatService := h.svcCtx.Services.Chat
+	var err error
+	switch cmd {
+	case groupMetaShow:
+		var group *model.ChatGroup
+		if group, err = chatService.GetGroupMetadata(ctx, chatID); err == nil {
+			reply(formatGroupMetadata(group))
+		}
+	case groupMetaSetProject:
+		if arg == "" {
+			reply("请指定项目名称，例如：设置项目 支付中台")
+			return true
+		}
+		if err = chatService.SetGroupProject(ctx, chatID, arg); err == nil {
+			reply(fmt.Sprintf("✅ 已将本群标记为「%s」项目群", arg))
+		}
+	case groupMetaSetKeywords:
+		var keywords string
+		if keywords, err = chatService.SetGroupKeywords(ctx, chatID, arg); err == nil {
+			if keywords == "" {
+				reply("✅ 已清除本群关键词")
+			} else {
+				reply("✅ 已设置本群关键词：" + strings.ReplaceAll(keywords, ",", "、"))
+			}
+		}
+	case groupMetaClear:
+		if err = chatService.ClearGroupMetadata(ctx, chatID); err == nil {
+			reply("✅ 已清除本群的项目和关键词")
+		}
+	}
+
+	if err != nil {
+		log.Printf("Group metadata command %s failed: %v", cmd, err)
+		reply("❌ 操作失败，请稍后重试。")
+	}
+	return true
 }
 
 func (h *LarkWebhookHandler) getHelpMessage() string {
@@ -440,10 +619,16 @@ func (h *LarkWebhookHandler) getHelpMessage() string {
 🔍 **消息搜索**
 • "张三说过什么关于登录的？"
 • "搜索关于支付的讨论"
+• "有没有人问过登录超时？"
 
 📋 **消息总结**
 • "总结一下今天的讨论"
 • "本周群消息摘要"
+• "我不在的时候群里发生了啥"
+
+🏷️ **群项目信息**
+• "项目信息" 查看本群所属项目和关键词
+• "设置项目 支付中台"、"设置关键词 支付,退款"（管理员）
 
 💡 **提示**
 • 支持自然语言提问
@@ -453,7 +638,7 @@ func (h *LarkWebhookHandler) getHelpMessage() string {
 
 // handlePrivateCommand 处理私聊命令
 func (h *LarkWebhookHandler) handlePrivateCommand(event *lark.MessageReceiveEvent, content string) {
-	ctx := context.Background()
+	ctx := lark.WithReplyChat(context.Background(), event.Message.ChatID)
 	senderOpenID := event.Sender.SenderID.OpenID
 	messageID := event.Message.MessageID
 
@@ -470,10 +655,53 @@ func (h *LarkWebhookHandler) handlePrivateCommand(event *lark.MessageReceiveEven
 	case content == "同步状态" || content == "任务状态" || content == "同步进度":
 		h.showSyncStatus(ctx, messageID, senderOpenID)
 
-	case strings.HasPrefix(content, "同步") || strings.HasPrefix(content, "下载"):
+	case isSyncTaskListCommand(content):
+		h.listSyncTasks(ctx, messageID, content)
+
+	case isSyncHistoryCommand(content):
+		h.handleSyncHistoryCommand(ctx, messageID, content)
+
+	case isDefaultChatCommand(content):
+		h.handleDefaultChat(ctx, messageID, senderOpenID, content)
+
+	case content == "知识库状态" || content == "索引状态":
+		h.showKnowledgeBaseStatus(ctx, messageID)
+
+	case isConfigStatusCommand(content):
+		h.handleConfigStatus(ctx, messageID, senderOpenID)
+
+	case isLLMProbeCommand(content):
+		h.handleLLMProbe(ctx, messageID, senderOpenID)
+
+	case isParseDebugCommand(content):
+		h.handleParseDebug(ctx, messageID, senderOpenID, content)
+
+	case isRetrievalPreviewCommand(content):
+		h.handleRetrievalPreview(ctx, messageID, senderOpenID, content)
+
+	case isMessageStateCommand(content):
+		h.handleMessageState(ctx, messageID, senderOpenID, content)
+
+	case isIndexReconcileCommand(content):
+		h.handleIndexReconcile(ctx, messageID, senderOpenID, content)
+
+	case isMemberMergeCommand(content):
+		h.handleMemberMerge(ctx, messageID, senderOpenID, content)
+
+	case content == "知识库文档" || strings.HasPrefix(content, "知识库文档 "):
+		h.listDifyDocuments(ctx, messageID, senderOpenID, content)
+
+	case strings.HasPrefix(content, "删除文档"):
+		h.deleteDifyDocument(ctx, messageID, senderOpenID, content)
+
+	case strings.HasPrefix(content, "同步") || strings.HasPrefix(content, "重新同步") || strings.HasPrefix(content, "下载"):
 		h.handleSyncCommand(ctx, messageID, senderOpenID, content)
 
 	default:
+		// "研发群同步过吗" 查询同步历史
+		if h.trySyncHistoryQuestion(ctx, messageID, content) {
+			return
+		}
 		// 尝试作为群名匹配
 		if h.trySyncByName(ctx, messageID, senderOpenID, content) {
 			return
@@ -487,8 +715,13 @@ func (h *LarkWebhookHandler) handlePrivateCommand(event *lark.MessageReceiveEven
 func (h *LarkWebhookHandler) handleAIQuery(ctx context.Context, messageID, userID, query string) {
 	log.Printf("Processing AI query from %s: %s", userID, query)
 
+	if h.replyIfAIDisabled(ctx, messageID) {
+		return
+	}
+
 	// 私聊场景下不使用 root_id 追问逻辑，默认不视为追问
-	response, err := h.processor.ProcessQuery(ctx, userID, query, false)
+	response, err := h.processor.ProcessQuery(ctx, userID, "p2p", query, false)
+	h.svcCtx.Metrics.RecordQuery(err)
 	if err != nil {
 		log.Printf("AI query error: %v", err)
 		h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", "处理请求时出错，请稍后重试")
@@ -512,7 +745,7 @@ func (h *LarkWebhookHandler) handleAIQuery(ctx context.Context, messageID, userI
 
 // handlePrivateImageMessage 处理私聊中的图片消息（支持纯图片和富文本图片）
 func (h *LarkWebhookHandler) handlePrivateImageMessage(event *lark.MessageReceiveEvent, content string) {
-	ctx := context.Background()
+	ctx := lark.WithReplyChat(context.Background(), event.Message.ChatID)
 	messageID := event.Message.MessageID
 	senderOpenID := event.Sender.SenderID.OpenID
 
@@ -540,8 +773,14 @@ func (h *LarkWebhookHandler) handlePrivateImageMessage(event *lark.MessageReceiv
 
 	log.Printf("Processing private image from %s, image_key: %s, query: %s", senderOpenID, imageKey, query)
 
-	// 下载图片
-	imageData, err := h.svcCtx.LarkClient.DownloadImage(ctx, messageID, imageKey)
+	// 下载图片（限制大小，超大图片既占内存也会被视觉模型拒绝）
+	imageData, err := h.svcCtx.LarkClient.DownloadImageWithLimit(ctx, messageID, imageKey, maxImageBytes(h.svcCtx.Config))
+	var tooLarge *lark.ResourceTooLargeError
+	if errors.As(err, &tooLarge) {
+		log.Printf("Private image %s exceeds %d bytes, skip analysis", imageKey, tooLarge.Limit)
+		h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", oversizedImageReply(tooLarge.Limit))
+		return
+	}
 	if err != nil {
 		log.Printf("Failed to download image: %v", err)
 		h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", "无法下载图片，请重试")
@@ -581,7 +820,7 @@ func (h *LarkWebhookHandler) handlePrivateImageMessage(event *lark.MessageReceiv
 
 // handleImageFollowUp 处理图片话题的追问
 func (h *LarkWebhookHandler) handleImageFollowUp(event *lark.MessageReceiveEvent, query string, imgCtx *ImageContext) {
-	ctx := context.Background()
+	ctx := lark.WithReplyChat(context.Background(), event.Message.ChatID)
 	messageID := event.Message.MessageID
 	rootID := event.Message.RootID // 原始图片消息的 ID
 	senderOpenID := event.Sender.SenderID.OpenID
@@ -631,13 +870,27 @@ func (h *LarkWebhookHandler) replyPrivateHelp(ctx context.Context, messageID str
 **消息同步：**
 • "列出群聊" - 查看机器人加入的所有群
 • "同步 [群名/群ID]" - 同步指定群的历史消息
+• "重新同步 [群名] 2024-01-01 到 2024-01-31" - 只同步指定日期范围内的消息
 • "同步状态" - 查看当前同步任务进度
+• "同步任务 [失败/群名]" - 按状态或群列出同步任务及失败原因
+• "同步历史 [群名]" - 查看该群的同步记录和是否已开启定时同步（也可问 "研发群同步过吗"）
+• "知识库状态" - 查看向量索引数量和状态
+• "设置默认群 [群名]" - 私聊提问默认只搜索该群，"默认群" 查看，"清除默认群" 取消
+• "知识库文档" - 查看 Dify 知识库文档（管理员）
+• "删除文档 [文档ID]" - 删除 Dify 知识库文档（管理员）
+• "检索 [问题]" - 预览问答会检索到的消息及分数（管理员）
+• "消息状态 [消息ID/片段]" - 查看消息是否已同步和索引（管理员）
+• "索引对账 [补齐]" - 对比各群消息数和向量库数据点数，补齐缺失的索引（管理员）
+• "合并成员 [主成员] [重复成员]" - 合并重复的成员记录，工作量合并统计（管理员）
+• "配置" - 查看已启用的功能和模型，密钥已脱敏（管理员）
+• "测试AI" - 用固定问题测试当前模型，返回耗时和 token 用量（管理员）
 
 **AI 查询（自然语言）：**
 • "搜索关于登录的讨论"
 • "总结今天的消息"
 • "本周群消息摘要"
 • "谁提到过支付？"
+• "小王在哪些群活跃？"
 
 **示例：**
 • 同步 研发群
@@ -687,7 +940,9 @@ func (h *LarkWebhookHandler) handleSyncCommand(ctx context.Context, messageID, s
 
 	// 解析目标群
 	target := ""
-	if strings.HasPrefix(content, "同步") {
+	if strings.HasPrefix(content, "重新同步") {
+		target = strings.TrimSpace(strings.TrimPrefix(content, "重新同步"))
+	} else if strings.HasPrefix(content, "同步") {
 		target = strings.TrimSpace(strings.TrimPrefix(content, "同步"))
 	} else if strings.HasPrefix(content, "下载") {
 		target = strings.TrimSpace(strings.TrimPrefix(content, "下载"))
@@ -698,6 +953,23 @@ func (h *LarkWebhookHandler) handleSyncCommand(ctx context.Context, messageID, s
 		return
 	}
 
+	// 末尾可带日期范围，只同步该范围内的消息
+	debounceKey := target
+	target, dateRange, err := parseSyncTarget(target, time.Now())
+	if err != nil {
+		h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", "❌ "+err.Error()+"\n\n"+syncRangeUsage)
+		return
+	}
+	if target == "" {
+		h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", "请指定要同步的群，"+syncRangeUsage)
+		return
+	}
+
+	if !h.syncDebounce.allow(senderOpenID, debounceKey) {
+		h.replySyncInProgress(ctx, messageID, target)
+		return
+	}
+
 	// 查找群
 	chatID, chatName, err := h.findChat(ctx, target)
 	if err != nil {
@@ -706,19 +978,257 @@ func (h *LarkWebhookHandler) handleSyncCommand(ctx context.Context, messageID, s
 	}
 
 	// 创建同步任务
-	taskID, err := h.msgSyncer.CreateSyncTask(ctx, chatID, chatName, senderOpenID)
+	taskID, err := h.msgSyncer.CreateSyncTask(ctx, chatID, chatName, senderOpenID, dateRange.start, dateRange.end)
 	if err != nil {
 		log.Printf("Failed to create sync task: %v", err)
 		h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", "创建同步任务失败: "+err.Error())
 		return
 	}
 
-	reply := fmt.Sprintf("✅ **同步任务已创建**\n\n任务ID: %d\n群聊: %s\n状态: 等待处理\n\n消息同步将在后台进行，完成后会通知您。", taskID, chatName)
+	rangeLine := ""
+	if !dateRange.isZero() {
+		rangeLine = "时间范围: " + dateRange.String() + "\n"
+	}
+	reply := fmt.Sprintf("✅ **同步任务已创建**\n\n任务ID: %d\n群聊: %s\n%s状态: 等待处理\n\n消息同步将在后台进行，完成后会通知您。", taskID, chatName, rangeLine)
 	if err := h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", reply); err != nil {
 		log.Printf("Failed to reply sync task created: %v", err)
 	}
 }
 
+// replySyncInProgress 回复重复的同步命令
+func (h *LarkWebhookHandler) replySyncInProgress(ctx context.Context, messageID, target string) {
+	reply := fmt.Sprintf("⏳ 「%s」的同步请求已在处理中，请勿重复发送。\n\n发送 \"同步状态\" 查看进度", target)
+	if err := h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", reply); err != nil {
+		log.Printf("Failed to reply sync in progress: %v", err)
+	}
+}
+
+// isDebugCommand 判断是否是 "<命令> <问题>" 形式的调试命令
+// 要求命令后紧跟空白或冒号，避免误伤 "解析一下这个报错" 之类的普通提问
+func isDebugCommand(content, cmd string) bool {
+	if !strings.HasPrefix(content, cmd) {
+		return false
+	}
+	rest := strings.TrimPrefix(content, cmd)
+	return rest == "" || strings.HasPrefix(rest, " ") || strings.HasPrefix(rest, ":") || strings.HasPrefix(rest, "：")
+}
+
+// extractDebugQuery 提取 "<命令> <问题>" 中的问题部分
+func extractDebugQuery(content, cmd string) string {
+	query := strings.TrimPrefix(content, cmd)
+	query = strings.TrimLeft(query, ":： ")
+	return strings.TrimSpace(query)
+}
+
+// isParseDebugCommand 判断是否是 "解析 <问题>" 调试命令
+func isParseDebugCommand(content string) bool {
+	return isDebugCommand(content, "解析")
+}
+
+// extractParseDebugQuery 提取 "解析 <问题>" 中的问题部分
+func extractParseDebugQuery(content string) string {
+	return extractDebugQuery(content, "解析")
+}
+
+// isRetrievalPreviewCommand 判断是否是 "检索 <问题>" 调试命令
+func isRetrievalPreviewCommand(content string) bool {
+	return isDebugCommand(content, "检索")
+}
+
+// formatParseDebugResult 格式化意图解析结果（原始 ParsedQuery JSON）
+func formatParseDebugResult(parsed *llm.ParsedQuery) string {
+	data, err := json.MarshalIndent(parsed, "", "  ")
+	if err != nil {
+		return "序列化解析结果失败: " + err.Error()
+	}
+	return "🔎 **意图解析结果**\n\n" + string(data)
+}
+
+// handleParseDebug 重新执行意图解析并返回原始结果（不执行后续处理，仅白名单用户可用）
+func (h *LarkWebhookHandler) handleParseDebug(ctx context.Context, messageID, senderOpenID, content string) {
+	if !h.isAllowedUser(senderOpenID) {
+		h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", "抱歉，该命令仅管理员可用。")
+		return
+	}
+
+	query := extractParseDebugQuery(content)
+	if query == "" {
+		h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", "请指定要解析的问题，例如：解析 张三这周干了什么")
+		return
+	}
+
+	if h.svcCtx.LLMClient == nil {
+		h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", "LLM 未配置，无法解析意图")
+		return
+	}
+
+	parsed, err := h.svcCtx.LLMClient.ParseUserQuery(ctx, query)
+	if err != nil {
+		log.Printf("Failed to parse query for debug: %v", err)
+		h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", "意图解析失败: "+err.Error())
+		return
+	}
+
+	if err := h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", formatParseDebugResult(parsed)); err != nil {
+		log.Printf("Failed to reply parse result: %v", err)
+	}
+}
+
+// handleRetrievalPreview 按问答流程执行检索并返回带分数的结果（不调用生成模型，仅白名单用户可用）
+// 用于区分检索质量和生成质量问题
+func (h *LarkWebhookHandler) handleRetrievalPreview(ctx context.Context, messageID, senderOpenID, content string) {
+	if !h.isAllowedUser(senderOpenID) {
+		h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", "抱歉，该命令仅管理员可用。")
+		return
+	}
+
+	query := extractDebugQuery(content, "检索")
+	if query == "" {
+		h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", "请指定要检索的问题，例如：检索 登录超时是谁在跟进")
+		return
+	}
+
+	if h.processor == nil {
+		h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", aiDisabledMessage(h.svcCtx.Config))
+		return
+	}
+
+	preview, err := h.processor.PreviewRetrieval(ctx, "", query)
+	if err != nil {
+		log.Printf("Failed to preview retrieval: %v", err)
+		h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", "检索失败: "+err.Error())
+		return
+	}
+
+	if err := h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", preview); err != nil {
+		log.Printf("Failed to reply retrieval preview: %v", err)
+	}
+}
+
+// difyDocumentPageSize 知识库文档列表每页数量
+const difyDocumentPageSize = 20
+
+// listDifyDocuments 列出 Dify 知识库中的文档（仅白名单用户可用）
+// 用法：知识库文档 [页码]
+func (h *LarkWebhookHandler) listDifyDocuments(ctx context.Context, messageID, senderOpenID, content string) {
+	if !h.isAllowedUser(senderOpenID) {
+		h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", "抱歉，该命令仅管理员可用。")
+		return
+	}
+
+	if h.svcCtx.DifyClient == nil || h.svcCtx.Config.Dify.DatasetID == "" {
+		h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", "Dify 知识库未配置")
+		return
+	}
+
+	page := 1
+	if arg := strings.TrimSpace(strings.TrimPrefix(content, "知识库文档")); arg != "" {
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 1 {
+			h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", "页码格式错误，例如：知识库文档 2")
+			return
+		}
+		page = n
+	}
+
+	docs, err := h.svcCtx.DifyClient.ListDocuments(ctx, h.svcCtx.Config.Dify.DatasetID, page, difyDocumentPageSize)
+	if err != nil {
+		log.Printf("Failed to list dify documents: %v", err)
+		h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", "获取知识库文档失败: "+err.Error())
+		return
+	}
+
+	if err := h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", formatDifyDocuments(docs, page)); err != nil {
+		log.Printf("Failed to reply dify documents: %v", err)
+	}
+}
+
+// deleteDifyDocument 从 Dify 知识库删除文档（仅白名单用户可用）
+// 用法：删除文档 <文档ID>
+func (h *LarkWebhookHandler) deleteDifyDocument(ctx context.Context, messageID, senderOpenID, content string) {
+	if !h.isAllowedUser(senderOpenID) {
+		h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", "抱歉，该命令仅管理员可用。")
+		return
+	}
+
+	if h.svcCtx.DifyClient == nil || h.svcCtx.Config.Dify.DatasetID == "" {
+		h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", "Dify 知识库未配置")
+		return
+	}
+
+	documentID := strings.TrimSpace(strings.TrimPrefix(content, "删除文档"))
+	if documentID == "" {
+		h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", "请指定文档ID，例如：删除文档 a1b2c3\n发送「知识库文档」查看文档列表")
+		return
+	}
+
+	if err := h.svcCtx.DifyClient.DeleteDocument(ctx, h.svcCtx.Config.Dify.DatasetID, documentID); err != nil {
+		log.Printf("Failed to delete dify document %s: %v", documentID, err)
+		h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", "删除文档失败: "+err.Error())
+		return
+	}
+
+	log.Printf("Dify document %s deleted by %s", documentID, senderOpenID)
+	h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", fmt.Sprintf("🗑️ 已删除文档 %s", documentID))
+}
+
+// difyIndexingStatusText Dify 文档索引状态的展示文本
+func difyIndexingStatusText(status string) string {
+	switch status {
+	case "completed":
+		return "✅ 已完成"
+	case "error":
+		return "❌ 失败"
+	case "paused":
+		return "⏸️ 已暂停"
+	case "waiting", "parsing", "cleaning", "splitting", "indexing":
+		return "⏳ 索引中"
+	case "":
+		return "未知"
+	default:
+		return status
+	}
+}
+
+// formatDifyDocuments 格式化 Dify 知识库文档列表
+func formatDifyDocuments(docs []map[string]interface{}, page int) string {
+	if len(docs) == 0 {
+		if page > 1 {
+			return fmt.Sprintf("📄 第 %d 页没有文档", page)
+		}
+		return "📄 知识库中暂无文档"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📄 **知识库文档**（第 %d 页，%d 个）\n\n", page, len(docs)))
+
+	for i, doc := range docs {
+		id, _ := doc["id"].(string)
+		name, _ := doc["name"].(string)
+		status, _ := doc["indexing_status"].(string)
+
+		sb.WriteString(fmt.Sprintf("%d. %s\n", (page-1)*difyDocumentPageSize+i+1, name))
+		sb.WriteString(fmt.Sprintf("   ID: %s\n", id))
+		sb.WriteString(fmt.Sprintf("   状态: %s", difyIndexingStatusText(status)))
+		if enabled, ok := doc["enabled"].(bool); ok && !enabled {
+			sb.WriteString("（已禁用）")
+		}
+		if words, ok := doc["word_count"].(float64); ok {
+			sb.WriteString(fmt.Sprintf(" | 字数: %.0f", words))
+		}
+		if created, ok := doc["created_at"].(float64); ok && created > 0 {
+			sb.WriteString(fmt.Sprintf(" | 创建: %s", time.Unix(int64(created), 0).Format("2006-01-02 15:04")))
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(docs) == difyDocumentPageSize {
+		sb.WriteString(fmt.Sprintf("\n💡 发送「知识库文档 %d」查看下一页", page+1))
+	}
+	sb.WriteString("\n💡 发送「删除文档 <ID>」删除文档")
+	return sb.String()
+}
+
 // findChat 根据名称或ID查找群
 func (h *LarkWebhookHandler) findChat(ctx context.Context, target string) (chatID, chatName string, err error) {
 	// 如果是 chat_id 格式
@@ -753,8 +1263,13 @@ func (h *LarkWebhookHandler) trySyncByName(ctx context.Context, messageID, sende
 		return false
 	}
 
+	if !h.syncDebounce.allow(senderOpenID, content) {
+		h.replySyncInProgress(ctx, messageID, chatName)
+		return true
+	}
+
 	// 找到了，创建同步任务
-	taskID, err := h.msgSyncer.CreateSyncTask(ctx, chatID, chatName, senderOpenID)
+	taskID, err := h.msgSyncer.CreateSyncTask(ctx, chatID, chatName, senderOpenID, time.Time{}, time.Time{})
 	if err != nil {
 		log.Printf("Failed to create sync task: %v", err)
 		h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", "创建同步任务失败: "+err.Error())
@@ -800,7 +1315,7 @@ func (h *LarkWebhookHandler) checkPrivateChatPermission(event *lark.MessageRecei
 
 // replyNoPrivateChatPermission 回复无私聊权限
 func (h *LarkWebhookHandler) replyNoPrivateChatPermission(event *lark.MessageReceiveEvent) {
-	ctx := context.Background()
+	ctx := lark.WithReplyChat(context.Background(), event.Message.ChatID)
 	reply := "抱歉，您没有私聊机器人的权限。"
 	if err := h.svcCtx.LarkClient.ReplyMessage(ctx, event.Message.MessageID, "text", reply); err != nil {
 		log.Printf("Failed to reply no permission: %v", err)
@@ -866,7 +1381,7 @@ func (h *LarkWebhookHandler) checkGroupPermission(event *lark.MessageReceiveEven
 
 // replyNoGroupPermission 回复群成员数不足
 func (h *LarkWebhookHandler) replyNoGroupPermission(event *lark.MessageReceiveEvent) {
-	ctx := context.Background()
+	ctx := lark.WithReplyChat(context.Background(), event.Message.ChatID)
 	minMembers := h.svcCtx.Config.Permissions.GroupMinMembers
 	reply := fmt.Sprintf("抱歉，机器人仅在成员数 >= %d 人的群聊中提供服务。", minMembers)
 	if err := h.svcCtx.LarkClient.ReplyMessage(ctx, event.Message.MessageID, "text", reply); err != nil {
@@ -909,13 +1424,43 @@ func (h *LarkWebhookHandler) checkGroupChatUserPermission(event *lark.MessageRec
 
 // replyNoGroupChatUserPermission 回复无群聊权限
 func (h *LarkWebhookHandler) replyNoGroupChatUserPermission(event *lark.MessageReceiveEvent) {
-	ctx := context.Background()
+	ctx := lark.WithReplyChat(context.Background(), event.Message.ChatID)
 	reply := "抱歉，您没有在群聊中使用机器人的权限。"
 	if err := h.svcCtx.LarkClient.ReplyMessage(ctx, event.Message.MessageID, "text", reply); err != nil {
 		log.Printf("Failed to reply no group chat user permission: %v", err)
 	}
 }
 
+// showKnowledgeBaseStatus 显示向量知识库状态（读取缓存的集合统计）
+func (h *LarkWebhookHandler) showKnowledgeBaseStatus(ctx context.Context, messageID string) {
+	rag := h.svcCtx.Services.RAG
+	if rag == nil || !rag.IsEnabled() {
+		h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", "向量知识库未启用")
+		return
+	}
+
+	stats, err := rag.GetCollectionStats(ctx)
+	if err != nil {
+		log.Printf("Failed to get collection stats: %v", err)
+		h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", "获取知识库状态失败: "+err.Error())
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString("📚 **知识库状态**\n\n")
+	sb.WriteString(fmt.Sprintf("状态: %s\n", stats.Status))
+	sb.WriteString(fmt.Sprintf("向量数: %d\n", stats.PointsCount))
+	sb.WriteString(fmt.Sprintf("维度: %d\n", stats.Dimension))
+	if stats.Distance != "" {
+		sb.WriteString(fmt.Sprintf("距离度量: %s\n", stats.Distance))
+	}
+	sb.WriteString(fmt.Sprintf("更新时间: %s", stats.UpdatedAt.Format("2006-01-02 15:04:05")))
+
+	if err := h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", sb.String()); err != nil {
+		log.Printf("Failed to reply knowledge base status: %v", err)
+	}
+}
+
 // showSyncStatus 显示同步状态
 func (h *LarkWebhookHandler) showSyncStatus(ctx context.Context, messageID, senderOpenID string) {
 	tasks, err := h.svcCtx.SyncTaskModel.GetRecentTasks(ctx, 10)
@@ -0,0 +1,293 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"team-assistant/internal/config"
+	"team-assistant/pkg/dify"
+	"team-assistant/pkg/llm"
+)
+
+func TestIsParseDebugCommand(t *testing.T) {
+	tests := []struct {
+		content string
+		want    bool
+	}{
+		{"解析 张三这周干了什么", true},
+		{"解析：本周总结", true},
+		{"解析:本周总结", true},
+		{"解析", true},
+		{"解析一下这个报错", false},
+		{"帮我解析 一下", false},
+		{"同步 研发群", false},
+	}
+
+	for _, tt := range tests {
+		if got := isParseDebugCommand(tt.content); got != tt.want {
+			t.Errorf("isParseDebugCommand(%q) = %v, want %v", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestExtractParseDebugQuery(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"解析 张三这周干了什么", "张三这周干了什么"},
+		{"解析：  本周总结 ", "本周总结"},
+		{"解析", ""},
+	}
+
+	for _, tt := range tests {
+		if got := extractParseDebugQuery(tt.content); got != tt.want {
+			t.Errorf("extractParseDebugQuery(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestIsRetrievalPreviewCommand(t *testing.T) {
+	tests := []struct {
+		content string
+		want    bool
+	}{
+		{"检索 登录超时", true},
+		{"检索：登录超时", true},
+		{"检索", true},
+		{"检索一下登录的讨论", false},
+		{"帮我检索 登录", false},
+	}
+
+	for _, tt := range tests {
+		if got := isRetrievalPreviewCommand(tt.content); got != tt.want {
+			t.Errorf("isRetrievalPreviewCommand(%q) = %v, want %v", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestRetrievalPreviewRequiresAdmin(t *testing.T) {
+	h, larkServer := newAIDisabledHandler(t, config.Config{})
+	h.handlePrivateCommand(privateEvent("检索 登录超时"), "检索 登录超时")
+
+	if len(larkServer.replies) != 1 || !strings.Contains(larkServer.replies[0], "仅管理员可用") {
+		t.Errorf("Expected admin-only reply, got %v", larkServer.replies)
+	}
+}
+
+func TestFormatParseDebugResult(t *testing.T) {
+	parsed := &llm.ParsedQuery{
+		Intent:      llm.IntentSearchMessage,
+		TimeRange:   llm.TimeRangeThisWeek,
+		Keywords:    []string{"登录"},
+		TargetGroup: "研发群",
+		SitePrefix:  "l08",
+		RawQuery:    "研发群本周关于登录的讨论",
+	}
+
+	result := formatParseDebugResult(parsed)
+
+	idx := strings.Index(result, "{")
+	if idx < 0 {
+		t.Fatalf("Expected JSON in result, got %q", result)
+	}
+
+	var decoded llm.ParsedQuery
+	if err := json.Unmarshal([]byte(result[idx:]), &decoded); err != nil {
+		t.Fatalf("Result should contain valid JSON: %v", err)
+	}
+
+	if decoded.Intent != parsed.Intent || decoded.TimeRange != parsed.TimeRange {
+		t.Errorf("Intent/TimeRange mismatch: %+v", decoded)
+	}
+	if decoded.TargetGroup != "研发群" || decoded.SitePrefix != "l08" {
+		t.Errorf("TargetGroup/SitePrefix mismatch: %+v", decoded)
+	}
+	if len(decoded.Keywords) != 1 || decoded.Keywords[0] != "登录" {
+		t.Errorf("Keywords mismatch: %v", decoded.Keywords)
+	}
+}
+
+// newFakeDifyServer 模拟 Dify 文档列表和删除接口
+func newFakeDifyServer(t *testing.T, deleted *[]string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/datasets/ds1/documents":
+			w.Write([]byte(`{"data": [
+				{"id": "doc-1", "name": "研发群-2024-03-01", "indexing_status": "completed", "enabled": true, "word_count": 1200, "created_at": 1709251200},
+				{"id": "doc-2", "name": "研发群-2024-03-02", "indexing_status": "indexing", "enabled": true, "word_count": 0, "created_at": 1709337600},
+				{"id": "doc-3", "name": "运营群-2024-03-02", "indexing_status": "error", "enabled": false}
+			], "has_more": false, "page": 1, "limit": 20, "total": 3}`))
+		case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/v1/datasets/ds1/documents/"):
+			id := strings.TrimPrefix(r.URL.Path, "/v1/datasets/ds1/documents/")
+			if id == "missing" {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"code": "document_not_found"}`))
+				return
+			}
+			*deleted = append(*deleted, id)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestFormatDifyDocumentsFromMockedDify(t *testing.T) {
+	var deleted []string
+	server := newFakeDifyServer(t, &deleted)
+	client := dify.NewClient(server.URL, "test-key")
+
+	docs, err := client.ListDocuments(context.Background(), "ds1", 1, difyDocumentPageSize)
+	if err != nil {
+		t.Fatalf("ListDocuments failed: %v", err)
+	}
+
+	result := formatDifyDocuments(docs, 1)
+	for _, want := range []string{
+		"第 1 页，3 个",
+		"1. 研发群-2024-03-01", "ID: doc-1", "✅ 已完成", "字数: 1200",
+		"2. 研发群-2024-03-02", "⏳ 索引中",
+		"3. 运营群-2024-03-02", "❌ 失败（已禁用）",
+		"删除文档 <ID>",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("Result should contain %q, got:\n%s", want, result)
+		}
+	}
+	if strings.Contains(result, "下一页") {
+		t.Errorf("Should not suggest next page for a partial page:\n%s", result)
+	}
+}
+
+func TestDeleteDifyDocumentMocked(t *testing.T) {
+	var deleted []string
+	server := newFakeDifyServer(t, &deleted)
+	client := dify.NewClient(server.URL, "test-key")
+
+	if err := client.DeleteDocument(context.Background(), "ds1", "doc-2"); err != nil {
+		t.Fatalf("DeleteDocument failed: %v", err)
+	}
+	if len(deleted) != 1 || deleted[0] != "doc-2" {
+		t.Errorf("Expected doc-2 to be deleted, got %v", deleted)
+	}
+
+	if err := client.DeleteDocument(context.Background(), "ds1", "missing"); err == nil {
+		t.Error("Expected error when deleting missing document")
+	}
+}
+
+func TestFormatDifyDocumentsEmpty(t *testing.T) {
+	if got := formatDifyDocuments(nil, 1); got != "📄 知识库中暂无文档" {
+		t.Errorf("Unexpected empty result: %q", got)
+	}
+	if got := formatDifyDocuments(nil, 3); got != "📄 第 3 页没有文档" {
+		t.Errorf("Unexpected empty page result: %q", got)
+	}
+}
+
+func TestFormatDifyDocumentsFullPage(t *testing.T) {
+	docs := make([]map[string]interface{}, difyDocumentPageSize)
+	for i := range docs {
+		docs[i] = map[string]interface{}{"id": "doc", "name": "文档", "indexing_status": "completed"}
+	}
+
+	result := formatDifyDocuments(docs, 2)
+	if !strings.Contains(result, "21. 文档") {
+		t.Errorf("Numbering should continue across pages:\n%s", result)
+	}
+	if !strings.Contains(result, "知识库文档 3") {
+		t.Errorf("Should suggest next page:\n%s", result)
+	}
+}
+
+func TestParseGroupMetadataCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		wantCmd string
+		wantArg string
+		wantOK  bool
+	}{
+		{"查看", "项目信息", groupMetaShow, "", true},
+		{"设置项目", "设置项目 支付中台", groupMetaSetProject, "支付中台", true},
+		{"冒号分隔", "设置项目：支付中台 ", groupMetaSetProject, "支付中台", true},
+		{"设置关键词", "设置关键词 支付,退款", groupMetaSetKeywords, "支付,退款", true},
+		{"清除", "清除项目", groupMetaClear, "", true},
+		{"普通提问", "设置项目进度提醒怎么做", "", "", false},
+		{"无关查询", "总结一下今天的讨论", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, arg, ok := parseGroupMetadataCommand(tt.query)
+			if cmd != tt.wantCmd || arg != tt.wantArg || ok != tt.wantOK {
+				t.Errorf("parseGroupMetadataCommand(%q) = (%q, %q, %v), want (%q, %q, %v)",
+					tt.query, cmd, arg, ok, tt.wantCmd, tt.wantArg, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestCommandDebouncer(t *testing.T) {
+	now := time.Date(2025, 1, 5, 10, 0, 0, 0, time.Local)
+	d := newCommandDebouncer(10 * time.Second)
+	d.now = func() time.Time { return now }
+
+	if !d.allow("ou_a", "研发群") {
+		t.Fatal("First command should be allowed")
+	}
+	if d.allow("ou_a", "研发群 ") {
+		t.Error("Identical command within window should be debounced")
+	}
+	if !d.allow("ou_b", "研发群") {
+		t.Error("Other users should not be affected")
+	}
+	if !d.allow("ou_a", "支付群") {
+		t.Error("Different command should be allowed")
+	}
+
+	// 窗口内重复发送不刷新时间
+	now = now.Add(9 * time.Second)
+	if d.allow("ou_a", "研发群") {
+		t.Error("Command should still be debounced before window ends")
+	}
+	now = now.Add(time.Second)
+	if !d.allow("ou_a", "研发群") {
+		t.Error("Command should be allowed again after window")
+	}
+
+	// 过期记录被清理
+	now = now.Add(time.Minute)
+	d.allow("ou_c", "产品群")
+	if len(d.seen) != 1 {
+		t.Errorf("Expected expired entries cleaned up, got %d entries", len(d.seen))
+	}
+}
+
+func TestCommandDebouncerDisabled(t *testing.T) {
+	d := newCommandDebouncer(syncCommandInterval(-1))
+	for i := 0; i < 3; i++ {
+		if !d.allow("ou_a", "研发群") {
+			t.Fatal("Debounce disabled should always allow")
+		}
+	}
+	if got := syncCommandInterval(0); got != defaultSyncCommandInterval {
+		t.Errorf("Expected default interval, got %v", got)
+	}
+	if got := syncCommandInterval(30); got != 30*time.Second {
+		t.Errorf("Expected 30s, got %v", got)
+	}
+}
@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"team-assistant/pkg/llm"
+)
+
+const (
+	// llmProbePrompt 测试 AI 使用的固定问题，回答应包含 llmProbeExpected
+	llmProbePrompt = "这是一条连通性测试，请只回复：OK"
+	// llmProbeExpected 期望回答中包含的内容，不包含时提示回答异常（模型可用但可能配置了不合适的模型）
+	llmProbeExpected = "OK"
+	// llmProbeTimeout 测试请求的超时（包含重试和备选模型切换）
+	llmProbeTimeout = 60 * time.Second
+	// llmProbeMaxReplyRunes 回复中最多展示的回答字数
+	llmProbeMaxReplyRunes = 200
+)
+
+// llmProber 测试 AI 使用的 LLM 接口（由 llm.Client 实现）
+type llmProber interface {
+	GenerateResponseWithUsage(ctx context.Context, prompt string, data interface{}) (string, *llm.Usage, error)
+	GetCurrentModelName() string
+}
+
+// llmProbeResult 一次测试的结果
+type llmProbeResult struct {
+	model   string
+	answer  string
+	usage   *llm.Usage
+	latency time.Duration
+	err     error
+}
+
+// isLLMProbeCommand 判断是否是 "测试AI" 命令
+func isLLMProbeCommand(content string) bool {
+	switch strings.ToLower(content) {
+	case "测试ai", "测试 ai", "测试模型":
+		return true
+	}
+	return false
+}
+
+// handleLLMProbe 用固定问题调用当前 LLM，返回回答、耗时和 token 用量（仅白名单用户可用）
+func (h *LarkWebhookHandler) handleLLMProbe(ctx context.Context, messageID, senderOpenID string) {
+	if !h.isAllowedUser(senderOpenID) {
+		h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", "抱歉，该命令仅管理员可用。")
+		return
+	}
+
+	if h.svcCtx.LLMClient == nil {
+		h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", "LLM 未配置，请检查 LLM.APIKey、LLM.Endpoint 和 LLM.Model")
+		return
+	}
+
+	result := runLLMProbe(ctx, h.svcCtx.LLMClient)
+	if result.err != nil {
+		log.Printf("LLM probe failed: %v", result.err)
+	}
+	reply := formatLLMProbeResult(result, h.svcCtx.Config.LLM.Provider)
+	if h.svcCtx.Config.SafeMode {
+		reply += "\n\n🧪 当前为安全模式，LLM 返回固定内容，未请求外部接口"
+	}
+	if err := h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", reply); err != nil {
+		log.Printf("Failed to reply LLM probe result: %v", err)
+	}
+}
+
+// runLLMProbe 发送固定问题并计时
+func runLLMProbe(ctx context.Context, prober llmProber) llmProbeResult {
+	ctx, cancel := context.WithTimeout(ctx, llmProbeTimeout)
+	defer cancel()
+
+	start := time.Now()
+	answer, usage, err := prober.GenerateResponseWithUsage(ctx, llmProbePrompt, nil)
+	return llmProbeResult{
+		model:   prober.GetCurrentModelName(),
+		answer:  strings.TrimSpace(answer),
+		usage:   usage,
+		latency: time.Since(start),
+		err:     err,
+	}
+}
+
+// formatLLMProbeResult 格式化测试结果
+func formatLLMProbeResult(r llmProbeResult, provider string) string {
+	var sb strings.Builder
+	if r.err != nil {
+		sb.WriteString("❌ AI 测试失败\n\n")
+		sb.WriteString(fmt.Sprintf("• 模型：%s / %s\n", valueOrUnset(provider), valueOrUnset(r.model)))
+		sb.WriteString(fmt.Sprintf("• 耗时：%s\n", formatProbeLatency(r.latency)))
+		sb.WriteString(fmt.Sprintf("• 原因：%s\n", describeLLMError(r.err)))
+		sb.WriteString(fmt.Sprintf("• 错误：%s", truncateRunes(r.err.Error(), llmProbeMaxReplyRunes)))
+		return sb.String()
+	}
+
+	if strings.Contains(strings.ToUpper(r.answer), llmProbeExpected) {
+		sb.WriteString("✅ AI 测试通过\n\n")
+	} else {
+		sb.WriteString("⚠️ AI 可以访问，但回答与预期不符，请确认模型是否合适\n\n")
+	}
+	sb.WriteString(fmt.Sprintf("• 模型：%s / %s\n", valueOrUnset(provider), valueOrUnset(r.model)))
+	sb.WriteString(fmt.Sprintf("• 耗时：%s\n", formatProbeLatency(r.latency)))
+	if r.usage != nil {
+		sb.WriteString(fmt.Sprintf("• Token：输入 %d / 输出 %d / 合计 %d\n", r.usage.PromptTokens, r.usage.CompletionTokens, r.usage.TotalTokens))
+	} else {
+		sb.WriteString("• Token：接口未返回用量\n")
+	}
+	sb.WriteString(fmt.Sprintf("• 回答：%s", truncateRunes(r.answer, llmProbeMaxReplyRunes)))
+	return sb.String()
+}
+
+// formatProbeLatency 耗时展示，精确到毫秒
+func formatProbeLatency(d time.Duration) string {
+	return d.Round(time.Millisecond).String()
+}
+
+// describeLLMError 把常见的 LLM 调用错误转为可操作的提示（认证、端点、模型、网络）
+func describeLLMError(err error) string {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "请求超时，请检查网络、代理或端点是否可达"
+	}
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "HTTP 401") || strings.Contains(msg, "HTTP 403"):
+		return "认证失败，请检查 LLM.APIKey 是否正确、是否有权限使用该模型"
+	case strings.Contains(msg, "HTTP 404"):
+		return "端点或模型不存在，请检查 LLM.Endpoint（需包含完整路径，如 /v1/chat/completions）和 LLM.Model"
+	case strings.Contains(msg, "HTTP 429"):
+		return "请求被限流或额度不足，请稍后重试或检查账户余额"
+	case strings.Contains(msg, "HTTP 5"):
+		return "模型服务端错误，请稍后重试"
+	case strings.Contains(msg, "no such host"), strings.Contains(msg, "connection refused"),
+		strings.Contains(msg, "unsupported protocol scheme"):
+		return "无法连接端点，请检查 LLM.Endpoint 地址和代理配置"
+	case strings.Contains(msg, "timeout") || strings.Contains(msg, "Timeout"):
+		return "请求超时，请检查网络、代理或端点是否可达"
+	case strings.Contains(msg, "invalid character"), strings.Contains(msg, "cannot unmarshal"):
+		return "返回内容不是预期的 JSON，请检查 LLM.Endpoint 和 LLM.Provider 是否匹配"
+	}
+	return "调用失败，请查看下方错误信息"
+}
+
+// truncateRunes 按字符截断，超出时追加 "..."
+func truncateRunes(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max]) + "..."
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"team-assistant/pkg/llm"
+)
+
+func TestIsLLMProbeCommand(t *testing.T) {
+	for _, content := range []string{"测试AI", "测试ai", "测试 AI", "测试模型"} {
+		if !isLLMProbeCommand(content) {
+			t.Errorf("isLLMProbeCommand(%q) = false, want true", content)
+		}
+	}
+	for _, content := range []string{"测试", "AI 是什么", "测试AI 为什么慢"} {
+		if isLLMProbeCommand(content) {
+			t.Errorf("isLLMProbeCommand(%q) = true, want false", content)
+		}
+	}
+}
+
+func TestRunLLMProbe(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   []string
+	}{
+		{"正常回答", http.StatusOK,
+			`{"choices":[{"message":{"content":"OK"}}],"usage":{"prompt_tokens":180,"completion_tokens":1,"total_tokens":181}}`,
+			[]string{"✅ AI 测试通过", "openai / mock-model", "输入 180 / 输出 1 / 合计 181", "回答：OK"}},
+		{"回答不符合预期", http.StatusOK, `{"choices":[{"message":{"content":"你好，我是助手"}}]}`,
+			[]string{"⚠️ AI 可以访问，但回答与预期不符", "接口未返回用量"}},
+		{"API Key 错误", http.StatusUnauthorized, `{"error":{"message":"Incorrect API key provided"}}`,
+			[]string{"❌ AI 测试失败", "认证失败，请检查 LLM.APIKey", "HTTP 401"}},
+		{"端点路径错误", http.StatusNotFound, `404 page not found`,
+			[]string{"❌ AI 测试失败", "端点或模型不存在", "HTTP 404"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var prompts []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				prompts = append(prompts, string(body))
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			result := runLLMProbe(context.Background(), llm.NewClient("sk-test", server.URL, "mock-model"))
+			got := formatLLMProbeResult(result, "openai")
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("Probe result missing %q:\n%s", want, got)
+				}
+			}
+			if len(prompts) == 0 || !strings.Contains(prompts[0], llmProbePrompt) {
+				t.Errorf("Expected fixed prompt sent to LLM, got %v", prompts)
+			}
+		})
+	}
+}
+
+func TestDescribeLLMError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{errors.New("all LLM models failed, last error: LLM error: HTTP 403 - forbidden"), "认证失败"},
+		{errors.New("Anthropic error: HTTP 429 - rate limited"), "限流"},
+		{errors.New(`Post "http://llm.invalid/v1": dial tcp: lookup llm.invalid: no such host`), "无法连接端点"},
+		{context.DeadlineExceeded, "请求超时"},
+		{errors.New("invalid character '<' looking for beginning of value"), "不是预期的 JSON"},
+	}
+	for _, tt := range tests {
+		if got := describeLLMError(tt.err); !strings.Contains(got, tt.want) {
+			t.Errorf("describeLLMError(%v) = %q, want containing %q", tt.err, got, tt.want)
+		}
+	}
+}
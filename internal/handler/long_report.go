@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"unicode/utf8"
+
+	"team-assistant/internal/config"
+)
+
+const (
+	defaultLongReportThreshold = 1500 // 长报告字数阈值
+	longReportDMNotice         = "📬 已私信你详细报告"
+)
+
+// longReportThreshold 长报告字数阈值
+func longReportThreshold(cfg config.LLMConfig) int {
+	if cfg.LongReportThreshold > 0 {
+		return cfg.LongReportThreshold
+	}
+	return defaultLongReportThreshold
+}
+
+// shouldDMLongReport 群聊中回复超过阈值且配置为 dm 时，完整报告改为私信提问者
+func shouldDMLongReport(cfg config.LLMConfig, chatType, senderOpenID, reply string) bool {
+	if cfg.LongReportDelivery != "dm" || chatType == "p2p" || senderOpenID == "" {
+		return false
+	}
+	return utf8.RuneCountInString(reply) > longReportThreshold(cfg)
+}
+
+// deliverReply 回复查询结果：长报告按配置私信提问者并在群内简短提示，私信失败时仍回复到群
+func (h *LarkWebhookHandler) deliverReply(ctx context.Context, chatType, senderOpenID, messageID, reply string) {
+	if shouldDMLongReport(h.svcCtx.Config.LLM, chatType, senderOpenID, reply) {
+		err := h.svcCtx.LarkClient.SendMessageToUser(ctx, senderOpenID, "text", reply)
+		if err == nil {
+			if err := h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", longReportDMNotice); err != nil {
+				log.Printf("Failed to reply message: %v", err)
+			}
+			return
+		}
+		log.Printf("Failed to send long report to %s, replying in group: %v", senderOpenID, err)
+	}
+
+	if err := h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", reply); err != nil {
+		log.Printf("Failed to reply message: %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"team-assistant/internal/config"
+)
+
+func TestShouldDMLongReport(t *testing.T) {
+	long := strings.Repeat("进展", 800) // 1600 字
+	dm := config.LLMConfig{LongReportDelivery: "dm"}
+
+	tests := []struct {
+		name     string
+		cfg      config.LLMConfig
+		chatType string
+		sender   string
+		reply    string
+		want     bool
+	}{
+		{"群聊长报告", dm, "group", "ou_user", long, true},
+		{"群聊短回复", dm, "group", "ou_user", "本周共 3 个告警", false},
+		{"未开启私信", config.LLMConfig{}, "group", "ou_user", long, false},
+		{"配置为群内回复", config.LLMConfig{LongReportDelivery: "group"}, "group", "ou_user", long, false},
+		{"私聊不转私信", dm, "p2p", "ou_user", long, false},
+		{"缺少提问者", dm, "group", "", long, false},
+		{"自定义阈值", config.LLMConfig{LongReportDelivery: "dm", LongReportThreshold: 10}, "group", "ou_user", "这是一条超过十个字的回复内容", true},
+		{"刚好等于阈值", config.LLMConfig{LongReportDelivery: "dm", LongReportThreshold: 4}, "group", "ou_user", "四个汉字", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shouldDMLongReport(tt.cfg, tt.chatType, tt.sender, tt.reply); got != tt.want {
+				t.Errorf("shouldDMLongReport() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeliverReply(t *testing.T) {
+	long := strings.Repeat("进展", 800)
+	cfg := config.Config{LLM: config.LLMConfig{LongReportDelivery: "dm"}}
+
+	t.Run("长报告私信提问者", func(t *testing.T) {
+		h, larkServer := newAIDisabledHandler(t, cfg)
+		h.deliverReply(context.Background(), "group", "ou_user", "om_1", long)
+
+		if len(larkServer.dms) != 1 || larkServer.dms[0] != long {
+			t.Fatalf("Expected full report sent by DM, got %d DMs", len(larkServer.dms))
+		}
+		if len(larkServer.replies) != 1 || larkServer.replies[0] != longReportDMNotice {
+			t.Errorf("Expected brief notice in group, got %v", larkServer.replies)
+		}
+	})
+
+	t.Run("短回复直接回复到群", func(t *testing.T) {
+		h, larkServer := newAIDisabledHandler(t, cfg)
+		h.deliverReply(context.Background(), "group", "ou_user", "om_1", "本周共 3 个告警")
+
+		if len(larkServer.dms) != 0 || len(larkServer.replies) != 1 || larkServer.replies[0] != "本周共 3 个告警" {
+			t.Errorf("Unexpected delivery: dms=%d replies=%v", len(larkServer.dms), larkServer.replies)
+		}
+	})
+
+	t.Run("私信失败回落到群", func(t *testing.T) {
+		h, larkServer := newAIDisabledHandler(t, cfg)
+		larkServer.failDM = true
+		h.deliverReply(context.Background(), "group", "ou_user", "om_1", long)
+
+		if len(larkServer.replies) != 1 || larkServer.replies[0] != long {
+			t.Errorf("Expected full report in group after DM failure, got %d replies", len(larkServer.replies))
+		}
+	})
+}
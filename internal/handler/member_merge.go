@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"team-assistant/internal/model"
+)
+
+const memberMergeUsage = "用法：合并成员 <主成员> <重复成员>，成员可以是ID或名称，例如：合并成员 12 35"
+
+// isMemberMergeCommand 判断是否是 "合并成员 <主成员> <重复成员>" 命令
+func isMemberMergeCommand(content string) bool {
+	return isDebugCommand(content, "合并成员")
+}
+
+// parseMemberMergeArgs 解析主成员和重复成员（ID 或名称）
+func parseMemberMergeArgs(arg string) (primary, duplicate string, ok bool) {
+	fields := strings.Fields(arg)
+	if len(fields) != 2 || fields[0] == fields[1] {
+		return "", "", false
+	}
+	return fields[0], fields[1], true
+}
+
+// pickMember 从按名称模糊匹配的候选中选出唯一成员，名称完全相同的优先
+func pickMember(ref string, candidates []*model.TeamMember) (*model.TeamMember, error) {
+	var exact []*model.TeamMember
+	for _, m := range candidates {
+		if m.Name == ref {
+			exact = append(exact, m)
+		}
+	}
+	if len(exact) == 0 {
+		exact = candidates
+	}
+
+	switch len(exact) {
+	case 0:
+		return nil, fmt.Errorf("未找到成员「%s」", ref)
+	case 1:
+		return exact[0], nil
+	}
+
+	names := make([]string, len(exact))
+	for i, m := range exact {
+		names[i] = fmt.Sprintf("%s(ID %d)", m.Name, m.ID)
+	}
+	return nil, fmt.Errorf("「%s」匹配到多个成员：%s，请使用成员ID", ref, strings.Join(names, "、"))
+}
+
+// resolveMember 按 ID 或名称查找成员
+func (h *LarkWebhookHandler) resolveMember(ctx context.Context, ref string) (*model.TeamMember, error) {
+	if id, err := strconv.ParseInt(ref, 10, 64); err == nil {
+		member, err := h.svcCtx.MemberModel.FindByID(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("未找到ID为 %d 的成员", id)
+		}
+		return member, nil
+	}
+
+	candidates, err := h.svcCtx.MemberModel.FindByName(ctx, ref)
+	if err != nil {
+		return nil, fmt.Errorf("查询成员「%s」失败：%v", ref, err)
+	}
+	return pickMember(ref, candidates)
+}
+
+// handleMemberMerge 合并重复的成员记录（仅白名单用户可用），避免同一个人的工作量被拆分统计
+func (h *LarkWebhookHandler) handleMemberMerge(ctx context.Context, messageID, senderOpenID, content string) {
+	if !h.isAllowedUser(senderOpenID) {
+		h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", "抱歉，该命令仅管理员可用。")
+		return
+	}
+
+	primaryRef, duplicateRef, ok := parseMemberMergeArgs(extractDebugQuery(content, "合并成员"))
+	if !ok {
+		h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", memberMergeUsage)
+		return
+	}
+
+	var refErrs []error
+	primary, err := h.resolveMember(ctx, primaryRef)
+	refErrs = append(refErrs, err)
+	duplicate, err := h.resolveMember(ctx, duplicateRef)
+	refErrs = append(refErrs, err)
+	if err := errors.Join(refErrs...); err != nil {
+		h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", "❌ "+strings.ReplaceAll(err.Error(), "\n", "\n❌ "))
+		return
+	}
+	if primary.ID == duplicate.ID {
+		h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", "主成员和重复成员是同一个人，无需合并。")
+		return
+	}
+
+	result, err := h.svcCtx.MemberModel.Merge(ctx, primary.ID, duplicate.ID)
+	if err != nil {
+		log.Printf("Failed to merge member %d into %d: %v", duplicate.ID, primary.ID, err)
+		h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", "合并成员失败: "+err.Error())
+		return
+	}
+	log.Printf("Member %d (%s) merged into %d (%s) by %s", duplicate.ID, duplicate.Name, primary.ID, primary.Name, senderOpenID)
+
+	if err := h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", formatMemberMergeResult(result)); err != nil {
+		log.Printf("Failed to reply member merge result: %v", err)
+	}
+}
+
+// formatMemberMergeResult 格式化合并结果
+func formatMemberMergeResult(r *model.MemberMergeResult) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("✅ 已将「%s」(ID %d) 合并到「%s」(ID %d)\n", r.Duplicate.Name, r.Duplicate.ID, r.Primary.Name, r.Primary.ID))
+	sb.WriteString(fmt.Sprintf("• 迁移提交：%d 条\n", r.Commits))
+	sb.WriteString(fmt.Sprintf("• 迁移消息：%d 条\n", r.Messages))
+	if r.Primary.GitHubUsername.Valid && r.Primary.GitHubUsername.String != "" {
+		sb.WriteString(fmt.Sprintf("• GitHub：%s\n", r.Primary.GitHubUsername.String))
+	}
+	sb.WriteString("重复成员已删除，工作量统计将合并计算")
+	return sb.String()
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+
+	"team-assistant/internal/config"
+	"team-assistant/internal/model"
+)
+
+func TestParseMemberMergeArgs(t *testing.T) {
+	tests := []struct {
+		arg           string
+		wantPrimary   string
+		wantDuplicate string
+		wantOK        bool
+	}{
+		{"12 35", "12", "35", true},
+		{"张三  张叁", "张三", "张叁", true},
+		{"12", "", "", false},
+		{"12 35 40", "", "", false},
+		{"12 12", "", "", false},
+		{"", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.arg, func(t *testing.T) {
+			primary, duplicate, ok := parseMemberMergeArgs(tt.arg)
+			if ok != tt.wantOK || primary != tt.wantPrimary || duplicate != tt.wantDuplicate {
+				t.Errorf("parseMemberMergeArgs(%q) = (%q, %q, %v)", tt.arg, primary, duplicate, ok)
+			}
+		})
+	}
+}
+
+func TestPickMember(t *testing.T) {
+	zhang := &model.TeamMember{ID: 1, Name: "张三"}
+	zhangJr := &model.TeamMember{ID: 2, Name: "张三丰"}
+	li := &model.TeamMember{ID: 3, Name: "李四"}
+
+	tests := []struct {
+		name       string
+		ref        string
+		candidates []*model.TeamMember
+		wantID     int64
+		wantErr    string
+	}{
+		{"完全匹配优先", "张三", []*model.TeamMember{zhangJr, zhang}, 1, ""},
+		{"唯一模糊匹配", "李", []*model.TeamMember{li}, 3, ""},
+		{"多个模糊匹配", "张", []*model.TeamMember{zhang, zhangJr}, 0, "张三(ID 1)、张三丰(ID 2)"},
+		{"未找到", "王五", nil, 0, "未找到成员「王五」"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := pickMember(tt.ref, tt.candidates)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("pickMember() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got.ID != tt.wantID {
+				t.Errorf("pickMember() = %v, %v, want ID %d", got, err, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestFormatMemberMergeResult(t *testing.T) {
+	got := formatMemberMergeResult(&model.MemberMergeResult{
+		Primary:   &model.TeamMember{ID: 1, Name: "张三", GitHubUsername: sql.NullString{String: "zhangsan", Valid: true}},
+		Duplicate: &model.TeamMember{ID: 2, Name: "张叁"},
+		Commits:   12,
+		Messages:  30,
+	})
+	for _, want := range []string{"已将「张叁」(ID 2) 合并到「张三」(ID 1)", "迁移提交：12 条", "迁移消息：30 条", "GitHub：zhangsan"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Result should contain %q, got:\n%s", want, got)
+		}
+	}
+}
+
+func TestMemberMergeRequiresAdmin(t *testing.T) {
+	h, larkServer := newAIDisabledHandler(t, config.Config{})
+	h.handlePrivateCommand(privateEvent("合并成员 1 2"), "合并成员 1 2")
+
+	if len(larkServer.replies) != 1 || !strings.Contains(larkServer.replies[0], "仅管理员可用") {
+		t.Errorf("Expected admin-only reply, got %v", larkServer.replies)
+	}
+}
@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"team-assistant/internal/model"
+	"team-assistant/internal/service"
+)
+
+const (
+	messageStateSnippetLimit   = 3   // 按片段查询时最多展示的消息数
+	messageStateContentPreview = 200 // 展示的存储内容字数
+)
+
+// messageIndexState 单条消息的同步/索引状态
+type messageIndexState struct {
+	Message  *model.ChatMessage
+	Indexed  bool
+	IndexErr error // 查询向量库失败
+	// 只有@提及，按配置不参与索引
+	MentionOnly bool
+}
+
+// isMessageStateCommand 判断是否是 "消息状态 <消息ID/片段>" 调试命令
+func isMessageStateCommand(content string) bool {
+	return isDebugCommand(content, "消息状态")
+}
+
+// parseMessageStateTarget 解析查询目标：om_ 开头视为消息ID，否则视为引用的消息片段（去掉引号）
+func parseMessageStateTarget(arg string) (messageID, snippet string) {
+	arg = strings.TrimSpace(arg)
+	if strings.HasPrefix(arg, "om_") && !strings.ContainsAny(arg, " \t") {
+		return arg, ""
+	}
+	return "", strings.TrimSpace(strings.Trim(arg, "\"'“”‘’「」『』"))
+}
+
+// handleMessageState 查询消息的同步/索引状态（仅白名单用户可用）
+// 用于区分"没同步到""同步了但没索引""embedding 失败"等问题
+func (h *LarkWebhookHandler) handleMessageState(ctx context.Context, messageID, senderOpenID, content string) {
+	if !h.isAllowedUser(senderOpenID) {
+		h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", "抱歉，该命令仅管理员可用。")
+		return
+	}
+
+	target := extractDebugQuery(content, "消息状态")
+	targetID, snippet := parseMessageStateTarget(target)
+	if targetID == "" && snippet == "" {
+		h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", "请指定消息ID或消息片段，例如：消息状态 om_xxx 或 消息状态 \"登录超时\"")
+		return
+	}
+
+	var messages []*model.ChatMessage
+	if targetID != "" {
+		msg, err := h.svcCtx.MessageModel.GetByMessageID(ctx, targetID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Failed to get message %s: %v", targetID, err)
+			h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", "查询消息失败: "+err.Error())
+			return
+		}
+		if msg != nil {
+			messages = append(messages, msg)
+		}
+	} else {
+		found, err := h.svcCtx.MessageModel.SearchByContent(ctx, "", snippet, messageStateSnippetLimit)
+		if err != nil {
+			log.Printf("Failed to search message snippet %q: %v", snippet, err)
+			h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", "查询消息失败: "+err.Error())
+			return
+		}
+		messages = found
+	}
+
+	rag := h.svcCtx.Services.RAG
+	ragEnabled := rag != nil && rag.IsEnabled()
+	states := make([]messageIndexState, 0, len(messages))
+	for _, msg := range messages {
+		state := messageIndexState{Message: msg}
+		state.MentionOnly = !h.svcCtx.Config.Index.IndexMentionOnly && service.IsMentionOnly(msg.Content.String)
+		if ragEnabled {
+			state.Indexed, state.IndexErr = rag.PointExists(ctx, msg.ChatID, msg.MessageID)
+		}
+		states = append(states, state)
+	}
+
+	reply := formatMessageStates(target, states, ragEnabled, h.svcCtx.SenderFilter.Excludes)
+	if err := h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", reply); err != nil {
+		log.Printf("Failed to reply message state: %v", err)
+	}
+}
+
+// formatMessageStates 格式化消息状态，excluded 判断发言人是否配置为不索引
+func formatMessageStates(target string, states []messageIndexState, ragEnabled bool, excluded func(senderID, senderName string) bool) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🔎 消息状态：%s\n", target))
+
+	if len(states) == 0 {
+		sb.WriteString("\n❌ MySQL：未找到该消息\n")
+		sb.WriteString("👉 消息未同步，检查事件订阅是否正常，或私聊发送 \"同步 群名\" 补齐历史消息")
+		return sb.String()
+	}
+
+	for i, state := range states {
+		msg := state.Message
+		sb.WriteString(fmt.Sprintf("\n%d. %s\n", i+1, msg.MessageID))
+		sb.WriteString(fmt.Sprintf("群：%s｜发言人：%s｜发送时间：%s\n", msg.ChatID, msg.SenderName.String, msg.CreatedAt.Format("2006-01-02 15:04:05")))
+		sb.WriteString(fmt.Sprintf("✅ MySQL：已存储（indexed_at %s）\n", msg.IndexedAt.Format("2006-01-02 15:04:05")))
+
+		content := strings.TrimSpace(msg.Content.String)
+		switch {
+		case !ragEnabled:
+			sb.WriteString("⚪ 向量库：未启用向量检索\n")
+		case content == "":
+			sb.WriteString("⚠️ 向量库：消息内容为空，不会被索引\n")
+		case state.IndexErr != nil:
+			sb.WriteString(fmt.Sprintf("⚠️ 向量库：查询失败 %v\n", state.IndexErr))
+		case state.Indexed:
+			sb.WriteString("✅ 向量库：已索引\n")
+		case excluded(msg.SenderID.String, msg.SenderName.String):
+			sb.WriteString("⏭️ 向量库：发言人已配置为不索引（Index.ExcludedSenders）\n")
+		case state.MentionOnly:
+			sb.WriteString("⏭️ 向量库：只有@提及，不参与索引（Index.IndexMentionOnly）\n")
+		default:
+			sb.WriteString("❌ 向量库：无数据点，索引或 embedding 失败，可运行 reindex 补齐\n")
+		}
+
+		if content == "" {
+			sb.WriteString("内容：（空）\n")
+		} else {
+			sb.WriteString(fmt.Sprintf("内容：%s\n", previewRunes(content, messageStateContentPreview)))
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// previewRunes 按字符截断文本
+func previewRunes(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	return string(runes[:limit]) + "..."
+}
@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"team-assistant/internal/config"
+	"team-assistant/internal/model"
+)
+
+func TestParseMessageStateTarget(t *testing.T) {
+	tests := []struct {
+		name        string
+		arg         string
+		wantID      string
+		wantSnippet string
+	}{
+		{"消息ID", "om_abc123", "om_abc123", ""},
+		{"带空格的消息ID", "  om_abc123 ", "om_abc123", ""},
+		{"双引号片段", `"登录超时"`, "", "登录超时"},
+		{"中文引号片段", "“登录超时怎么处理”", "", "登录超时怎么处理"},
+		{"直角引号片段", "「支付回调」", "", "支付回调"},
+		{"未加引号的片段", "om 开头的不是ID", "", "om 开头的不是ID"},
+		{"空", "  ", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, snippet := parseMessageStateTarget(tt.arg)
+			if id != tt.wantID || snippet != tt.wantSnippet {
+				t.Errorf("parseMessageStateTarget(%q) = (%q, %q), want (%q, %q)", tt.arg, id, snippet, tt.wantID, tt.wantSnippet)
+			}
+		})
+	}
+}
+
+func TestIsMessageStateCommand(t *testing.T) {
+	tests := []struct {
+		content string
+		want    bool
+	}{
+		{"消息状态 om_abc", true},
+		{"消息状态：登录超时", true},
+		{"消息状态", true},
+		{"消息状态怎么看", false},
+	}
+
+	for _, tt := range tests {
+		if got := isMessageStateCommand(tt.content); got != tt.want {
+			t.Errorf("isMessageStateCommand(%q) = %v, want %v", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestFormatMessageStates(t *testing.T) {
+	stored := func(id, sender, content string) *model.ChatMessage {
+		return &model.ChatMessage{
+			MessageID:  id,
+			ChatID:     "oc_dev",
+			SenderID:   sql.NullString{String: "ou_" + id, Valid: true},
+			SenderName: sql.NullString{String: sender, Valid: true},
+			Content:    sql.NullString{String: content, Valid: content != ""},
+			CreatedAt:  time.Date(2024, 3, 1, 10, 0, 0, 0, time.Local),
+			IndexedAt:  time.Date(2024, 3, 1, 10, 0, 5, 0, time.Local),
+		}
+	}
+	noneExcluded := func(senderID, senderName string) bool { return false }
+
+	tests := []struct {
+		name       string
+		states     []messageIndexState
+		ragEnabled bool
+		excluded   func(senderID, senderName string) bool
+		want       []string
+	}{
+		{"未同步", nil, true, noneExcluded, []string{"未找到该消息", "同步 群名"}},
+		{"已索引", []messageIndexState{{Message: stored("om_1", "张三", "登录超时已修复"), Indexed: true}}, true, noneExcluded,
+			[]string{"om_1", "已存储（indexed_at 2024-03-01 10:00:05）", "✅ 向量库：已索引", "内容：登录超时已修复"}},
+		{"未索引", []messageIndexState{{Message: stored("om_1", "张三", "登录超时已修复")}}, true, noneExcluded,
+			[]string{"无数据点", "reindex"}},
+		{"查询向量库失败", []messageIndexState{{Message: stored("om_1", "张三", "登录超时"), IndexErr: errors.New("connection refused")}}, true, noneExcluded,
+			[]string{"查询失败 connection refused"}},
+		{"内容为空", []messageIndexState{{Message: stored("om_1", "张三", "")}}, true, noneExcluded,
+			[]string{"内容为空，不会被索引", "内容：（空）"}},
+		{"排除的发言人", []messageIndexState{{Message: stored("om_1", "监控机器人", "CPU 95%")}}, true,
+			func(senderID, senderName string) bool { return senderName == "监控机器人" },
+			[]string{"Index.ExcludedSenders"}},
+		{"只有@提及", []messageIndexState{{Message: stored("om_1", "张三", "@李四"), MentionOnly: true}}, true, noneExcluded,
+			[]string{"Index.IndexMentionOnly"}},
+		{"未启用向量检索", []messageIndexState{{Message: stored("om_1", "张三", "登录超时")}}, false, noneExcluded,
+			[]string{"未启用向量检索"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := formatMessageStates("om_1", tt.states, tt.ragEnabled, tt.excluded)
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("Output should contain %q, got:\n%s", want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatMessageStatesTruncatesContent(t *testing.T) {
+	msg := &model.ChatMessage{MessageID: "om_1", Content: sql.NullString{String: strings.Repeat("长", 300), Valid: true}}
+	got := formatMessageStates("om_1", []messageIndexState{{Message: msg, Indexed: true}}, true, func(string, string) bool { return false })
+	if !strings.Contains(got, strings.Repeat("长", messageStateContentPreview)+"...") || strings.Contains(got, strings.Repeat("长", messageStateContentPreview+1)) {
+		t.Errorf("Content should be truncated to %d runes", messageStateContentPreview)
+	}
+}
+
+func TestMessageStateRequiresAdmin(t *testing.T) {
+	h, larkServer := newAIDisabledHandler(t, config.Config{})
+	h.handlePrivateCommand(privateEvent("消息状态 om_abc"), "消息状态 om_abc")
+
+	if len(larkServer.replies) != 1 || !strings.Contains(larkServer.replies[0], "仅管理员可用") {
+		t.Errorf("Expected admin-only reply, got %v", larkServer.replies)
+	}
+}
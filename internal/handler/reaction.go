@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"team-assistant/pkg/lark"
+)
+
+// 表情回复事件类型
+const (
+	eventReactionCreated = "im.message.reaction.created_v1"
+	eventReactionDeleted = "im.message.reaction.deleted_v1"
+)
+
+// reactionStore 表情回复存储
+type reactionStore interface {
+	AddReaction(ctx context.Context, messageID, operatorID, emojiType string) error
+	RemoveReaction(ctx context.Context, messageID, operatorID, emojiType string) error
+}
+
+// parseReactionEvent 解析表情回复事件，只统计用户的回复（忽略机器人自己添加的表情）
+func parseReactionEvent(eventData json.RawMessage) (*lark.MessageReactionEvent, bool) {
+	var event lark.MessageReactionEvent
+	if err := json.Unmarshal(eventData, &event); err != nil {
+		log.Printf("Failed to parse reaction event: %v", err)
+		return nil, false
+	}
+	if event.OperatorType != "" && event.OperatorType != "user" {
+		return nil, false
+	}
+	if event.MessageID == "" || event.UserID.OpenID == "" || event.ReactionType.EmojiType == "" {
+		return nil, false
+	}
+	return &event, true
+}
+
+// applyReaction 按事件增加或删除表情回复
+func applyReaction(ctx context.Context, store reactionStore, event *lark.MessageReactionEvent, created bool) error {
+	if created {
+		return store.AddReaction(ctx, event.MessageID, event.UserID.OpenID, event.ReactionType.EmojiType)
+	}
+	return store.RemoveReaction(ctx, event.MessageID, event.UserID.OpenID, event.ReactionType.EmojiType)
+}
+
+// handleMessageReaction 处理表情回复事件，维护消息的表情回复数
+func (h *LarkWebhookHandler) handleMessageReaction(eventData json.RawMessage, created bool) {
+	if h.svcCtx.MessageModel == nil {
+		return
+	}
+	event, ok := parseReactionEvent(eventData)
+	if !ok {
+		return
+	}
+	safeGo(func() {
+		if err := applyReaction(context.Background(), h.svcCtx.MessageModel, event, created); err != nil {
+			log.Printf("Failed to record reaction on %s: %v", event.MessageID, err)
+		}
+	})
+}
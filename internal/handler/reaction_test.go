@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+// fakeReactionStore 记录表情回复增删
+type fakeReactionStore struct {
+	added, removed []string
+}
+
+func (s *fakeReactionStore) AddReaction(ctx context.Context, messageID, operatorID, emojiType string) error {
+	s.added = append(s.added, messageID+"/"+operatorID+"/"+emojiType)
+	return nil
+}
+
+func (s *fakeReactionStore) RemoveReaction(ctx context.Context, messageID, operatorID, emojiType string) error {
+	s.removed = append(s.removed, messageID+"/"+operatorID+"/"+emojiType)
+	return nil
+}
+
+func TestParseReactionEvent(t *testing.T) {
+	tests := []struct {
+		name  string
+		event string
+		want  bool
+	}{
+		{"用户回复", `{"message_id": "om_1", "reaction_type": {"emoji_type": "THUMBSUP"}, "operator_type": "user", "user_id": {"open_id": "ou_1"}}`, true},
+		{"机器人回复", `{"message_id": "om_1", "reaction_type": {"emoji_type": "THUMBSUP"}, "operator_type": "app", "app_id": "cli_1"}`, false},
+		{"缺少表情", `{"message_id": "om_1", "operator_type": "user", "user_id": {"open_id": "ou_1"}}`, false},
+		{"缺少消息ID", `{"reaction_type": {"emoji_type": "OK"}, "operator_type": "user", "user_id": {"open_id": "ou_1"}}`, false},
+		{"格式错误", `[]`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := parseReactionEvent(json.RawMessage(tt.event)); ok != tt.want {
+				t.Errorf("parseReactionEvent() ok = %v, want %v", ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyReaction(t *testing.T) {
+	event, ok := parseReactionEvent(json.RawMessage(`{"message_id": "om_1", "reaction_type": {"emoji_type": "THUMBSUP"}, "operator_type": "user", "user_id": {"open_id": "ou_1"}}`))
+	if !ok {
+		t.Fatal("Expected valid event")
+	}
+
+	store := &fakeReactionStore{}
+	if err := applyReaction(context.Background(), store, event, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyReaction(context.Background(), store, event, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(store.added) != 1 || store.added[0] != "om_1/ou_1/THUMBSUP" {
+		t.Errorf("Unexpected added reactions: %v", store.added)
+	}
+	if len(store.removed) != 1 || store.removed[0] != "om_1/ou_1/THUMBSUP" {
+		t.Errorf("Unexpected removed reactions: %v", store.removed)
+	}
+}
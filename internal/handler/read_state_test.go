@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"team-assistant/internal/model"
+	"team-assistant/internal/service"
+)
+
+// failingReadStateRepo 读取已读状态失败，记录写入次数
+type failingReadStateRepo struct {
+	upserts int
+}
+
+func (r *failingReadStateRepo) Get(ctx context.Context, userID, chatID string) (*model.UserReadState, error) {
+	return nil, errors.New("connection refused")
+}
+
+func (r *failingReadStateRepo) Upsert(ctx context.Context, userID, chatID string, lastReadAt time.Time) error {
+	r.upserts++
+	return nil
+}
+
+func TestCatchUpFailureKeepsReadState(t *testing.T) {
+	h, larkServer := newSafeModeHandler(t)
+	repo := &failingReadStateRepo{}
+	h.svcCtx.Services.ReadState = service.NewReadStateService(repo)
+
+	h.processQuery("oc_dev", "group", "ou_user", "om_1", "", "我不在的时候发生了什么")
+
+	if len(larkServer.replies) != 1 || !strings.Contains(larkServer.replies[0], "处理请求时出错") {
+		t.Fatalf("Expected error reply, got %v", larkServer.replies)
+	}
+	if repo.upserts != 0 {
+		t.Errorf("Failed catch-up should not move last_read_at, got %d upserts", repo.upserts)
+	}
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"team-assistant/internal/svc"
+	"team-assistant/pkg/lifecycle"
+)
+
+// ReadyResponse /readyz 返回内容
+type ReadyResponse struct {
+	Ready bool                   `json:"ready"`
+	Tasks []lifecycle.TaskStatus `json:"tasks"`
+}
+
+// ReadyHandler 就绪检查：所有后台任务都在运行时返回 200，否则返回 503
+type ReadyHandler struct {
+	svcCtx *svc.ServiceContext
+}
+
+// NewReadyHandler 创建就绪检查处理器
+func NewReadyHandler(svcCtx *svc.ServiceContext) *ReadyHandler {
+	return &ReadyHandler{svcCtx: svcCtx}
+}
+
+// Handle 处理 GET /readyz
+func (h *ReadyHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	resp := ReadyResponse{
+		Ready: h.svcCtx.Lifecycle.Ready(),
+		Tasks: h.svcCtx.Lifecycle.Status(),
+	}
+	if resp.Tasks == nil {
+		resp.Tasks = []lifecycle.TaskStatus{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if !resp.Ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(resp)
+}
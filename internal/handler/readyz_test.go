@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"team-assistant/internal/svc"
+	"team-assistant/pkg/lifecycle"
+)
+
+func TestReadyHandler(t *testing.T) {
+	manager := lifecycle.NewManager()
+	manager.Go("image_cache_cleaner", func(ctx context.Context) { <-ctx.Done() })
+	h := NewReadyHandler(&svc.ServiceContext{Lifecycle: manager})
+
+	tests := []struct {
+		name      string
+		stop      bool
+		wantCode  int
+		wantReady bool
+		wantState string
+	}{
+		{"运行中", false, http.StatusOK, true, lifecycle.StateRunning},
+		{"已停止", true, http.StatusServiceUnavailable, false, lifecycle.StateStopped},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.stop {
+				if err := manager.Stop(time.Second); err != nil {
+					t.Fatal(err)
+				}
+			}
+			rec := httptest.NewRecorder()
+			h.Handle(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("Status code = %d, want %d", rec.Code, tt.wantCode)
+			}
+			var resp ReadyResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Ready != tt.wantReady || len(resp.Tasks) != 1 || resp.Tasks[0].State != tt.wantState {
+				t.Errorf("Unexpected response: %+v", resp)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"team-assistant/internal/config"
+	"team-assistant/internal/logic/ai"
+	"team-assistant/internal/model"
+	"team-assistant/internal/service"
+	"team-assistant/internal/svc"
+	"team-assistant/pkg/lark"
+	"team-assistant/pkg/lifecycle"
+	"team-assistant/pkg/llm"
+)
+
+// messageColumns chat_messages 标准查询的列
+var messageColumns = []string{"id", "message_id", "chat_id", "sender_id", "sender_name", "member_id", "msg_type",
+	"content", "raw_content", "mentions", "reply_to_id", "thread_id", "root_id", "is_at_bot", "is_forwarded",
+	"created_at", "created_at_ts", "indexed_at"}
+
+// fakeMessageDB 只读的假数据库：消息查询返回固定的一条消息，其余查询返回空结果
+type fakeMessageDB struct{}
+
+func (fakeMessageDB) Open(string) (driver.Conn, error) { return fakeMessageConn{}, nil }
+
+type fakeMessageConn struct{}
+
+func (fakeMessageConn) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (fakeMessageConn) Close() error                        { return nil }
+func (fakeMessageConn) Begin() (driver.Tx, error)           { return nil, driver.ErrSkip }
+
+func (fakeMessageConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	if strings.Contains(query, "FROM chat_messages") && strings.Contains(query, "raw_content, mentions") {
+		created := time.Date(2025, 3, 5, 10, 30, 0, 0, time.Local)
+		return &fakeRows{columns: messageColumns, rows: [][]driver.Value{{
+			int64(1), "om_fixed", "oc_dev", "ou_zhang", "张三-后端", nil, "text",
+			"登录超时已修复，今晚发布", nil, []byte("[]"), nil, nil, nil, int64(0), int64(0),
+			created, created.UnixMilli(), created,
+		}}}, nil
+	}
+	return &fakeRows{columns: []string{"value"}}, nil
+}
+
+func (fakeMessageConn) ExecContext(context.Context, string, []driver.NamedValue) (driver.Result, error) {
+	return driver.RowsAffected(0), nil
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+var registerFakeDB sync.Once
+
+// newSafeModeHandler 创建安全模式下的完整处理器：离线 LLM、关闭向量检索，消息来自假数据库
+func newSafeModeHandler(t *testing.T) (*LarkWebhookHandler, *fakeLarkServer) {
+	t.Helper()
+	registerFakeDB.Do(func() { sql.Register("fake_messages", fakeMessageDB{}) })
+	db, err := sql.Open("fake_messages", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	larkServer := &fakeLarkServer{}
+	server := httptest.NewServer(larkServer)
+	t.Cleanup(server.Close)
+
+	cfg := config.Config{SafeMode: true, LLM: config.LLMConfig{Model: "offline-model"}}
+	svcCtx := &svc.ServiceContext{
+		Config:       cfg,
+		DB:           db,
+		LarkClient:   lark.NewClient(server.URL, "app", "secret"),
+		MemberModel:  model.NewTeamMemberModel(db),
+		MessageModel: model.NewChatMessageModel(db),
+		GroupModel:   model.NewChatGroupModel(db),
+		Services: &svc.Services{
+			RAG: service.NewRAGService("", "", "", "", 0, false),
+		},
+		Lifecycle: lifecycle.NewManager(),
+	}
+	h := &LarkWebhookHandler{
+		svcCtx:       svcCtx,
+		processor:    ai.NewHybridProcessor(svcCtx),
+		userCache:    make(map[string]map[string]string),
+		imageCache:   make(map[string]*ImageContext),
+		syncDebounce: newCommandDebouncer(syncCommandInterval(0)),
+	}
+	return h, larkServer
+}
+
+func TestSafeModePrivateQuery(t *testing.T) {
+	h, larkServer := newSafeModeHandler(t)
+	h.handlePrivateCommand(privateEvent("登录超时修好了吗"), "登录超时修好了吗")
+
+	if len(larkServer.replies) != 1 {
+		t.Fatalf("Expected 1 reply, got %v", larkServer.replies)
+	}
+	reply := larkServer.replies[0]
+	if !strings.Contains(reply, llm.OfflineResponse) {
+		t.Errorf("Reply should be the offline canned answer, got:\n%s", reply)
+	}
+	if !strings.Contains(reply, "offline-model") {
+		t.Errorf("Reply should credit the configured model, got:\n%s", reply)
+	}
+}
+
+func TestSafeModeGroupQuery(t *testing.T) {
+	h, larkServer := newSafeModeHandler(t)
+	h.processQuery("oc_dev", "group", "ou_user", "om_1", "", "登录超时修好了吗")
+
+	if len(larkServer.replies) != 1 {
+		t.Fatalf("Expected 1 reply, got %v", larkServer.replies)
+	}
+	if !strings.Contains(larkServer.replies[0], llm.OfflineResponse) {
+		t.Errorf("Reply should be the offline canned answer, got:\n%s", larkServer.replies[0])
+	}
+}
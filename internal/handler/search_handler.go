@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"team-assistant/internal/service"
+	"team-assistant/internal/svc"
+)
+
+const (
+	// searchDefaultLimit /api/search 默认返回条数
+	searchDefaultLimit = 20
+	// searchMaxLimit /api/search 最多返回条数
+	searchMaxLimit = 100
+)
+
+// hybridSearcher 混合搜索（由 service.RAGService 实现，测试中可替换）
+type hybridSearcher interface {
+	IsEnabled() bool
+	HybridSearch(ctx context.Context, query string, keywords []string, limit int, opts service.HybridSearchOptions) ([]service.SearchResult, error)
+}
+
+// SearchResponse /api/search 返回内容
+type SearchResponse struct {
+	Query string                 `json:"query"`
+	Items []service.SearchResult `json:"items"`
+	Total int                    `json:"total"`
+}
+
+// SearchHandler 消息搜索接口，供外部看板不经过飞书直接检索历史消息
+// 接口返回任意群的原始消息内容，必须配置 Server.APIToken 并在请求中携带
+type SearchHandler struct {
+	searcher hybridSearcher
+	token    string
+}
+
+// NewSearchHandler 创建消息搜索处理器
+func NewSearchHandler(svcCtx *svc.ServiceContext) *SearchHandler {
+	h := &SearchHandler{token: svcCtx.Config.Server.APIToken}
+	if svcCtx.Services != nil && svcCtx.Services.RAG != nil {
+		h.searcher = svcCtx.Services.RAG
+	}
+	return h
+}
+
+// Handle 处理 GET /api/search?q=...&chat_id=...&sender=...&start=...&end=...&limit=...
+func (h *SearchHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if h.token == "" {
+		writeError(w, http.StatusServiceUnavailable, "Search API is not configured")
+		return
+	}
+	if !validIngestToken(r, h.token) {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	if h.searcher == nil || !h.searcher.IsEnabled() {
+		writeError(w, http.StatusServiceUnavailable, "Vector search is not enabled")
+		return
+	}
+
+	query := r.URL.Query()
+	q := strings.TrimSpace(query.Get("q"))
+	if q == "" {
+		writeError(w, http.StatusBadRequest, "q is required")
+		return
+	}
+	limit, err := parseSearchLimit(query.Get("limit"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	opts, err := parseSearchOptions(query)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	results, err := h.searcher.HybridSearch(r.Context(), q, strings.Fields(q), limit, opts)
+	if err != nil {
+		log.Printf("Failed to search messages: %v", err)
+		writeError(w, http.StatusInternalServerError, "Failed to search messages")
+		return
+	}
+	if results == nil {
+		results = []service.SearchResult{}
+	}
+	writeSuccess(w, SearchResponse{Query: q, Items: results, Total: len(results)})
+}
+
+// parseSearchLimit 解析 limit 参数，默认 searchDefaultLimit，超过 searchMaxLimit 时取上限
+func parseSearchLimit(s string) (int, error) {
+	if s == "" {
+		return searchDefaultLimit, nil
+	}
+	limit, err := strconv.Atoi(s)
+	if err != nil || limit <= 0 {
+		return 0, errors.New("Invalid limit")
+	}
+	if limit > searchMaxLimit {
+		limit = searchMaxLimit
+	}
+	return limit, nil
+}
+
+// parseSearchOptions 在默认混合搜索选项上设置群、发送者和时间过滤
+func parseSearchOptions(query url.Values) (service.HybridSearchOptions, error) {
+	opts := service.DefaultHybridSearchOptions()
+	opts.ChatID = strings.TrimSpace(query.Get("chat_id"))
+	opts.SenderName = strings.TrimSpace(query.Get("sender"))
+
+	if s := query.Get("start"); s != "" {
+		start, _, err := parseSearchTime(s)
+		if err != nil {
+			return opts, errors.New("Invalid start time format")
+		}
+		opts.StartTime = &start
+	}
+	if s := query.Get("end"); s != "" {
+		end, dateOnly, err := parseSearchTime(s)
+		if err != nil {
+			return opts, errors.New("Invalid end time format")
+		}
+		if dateOnly {
+			// end=2024-01-31 包含当天全天
+			end = end.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		}
+		opts.EndTime = &end
+	}
+	if opts.StartTime != nil && opts.EndTime != nil && opts.EndTime.Before(*opts.StartTime) {
+		return opts, errors.New("end must not be before start")
+	}
+	return opts, nil
+}
+
+// parseSearchTime 解析日期（2006-01-02，按本地时区）或 RFC3339 时间，dateOnly 表示只给了日期
+func parseSearchTime(s string) (t time.Time, dateOnly bool, err error) {
+	if t, err = time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, true, nil
+	}
+	t, err = time.Parse(time.RFC3339, s)
+	return t, false, err
+}
@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"team-assistant/internal/service"
+)
+
+// fakeSearcher 记录搜索参数并返回固定结果
+type fakeSearcher struct {
+	enabled  bool
+	results  []service.SearchResult
+	err      error
+	query    string
+	keywords []string
+	limit    int
+	opts     service.HybridSearchOptions
+}
+
+func (f *fakeSearcher) IsEnabled() bool { return f.enabled }
+
+func (f *fakeSearcher) HybridSearch(ctx context.Context, query string, keywords []string, limit int, opts service.HybridSearchOptions) ([]service.SearchResult, error) {
+	f.query, f.keywords, f.limit, f.opts = query, keywords, limit, opts
+	return f.results, f.err
+}
+
+func TestSearchHandler(t *testing.T) {
+	results := []service.SearchResult{
+		{MessageID: "om_1", ChatID: "oc_1", SenderName: "张三", Content: "支付回调超时", Score: 0.82},
+	}
+
+	tests := []struct {
+		name      string
+		searcher  *fakeSearcher
+		method    string
+		url       string
+		wantCode  int
+		wantTotal int
+	}{
+		{"正常搜索", &fakeSearcher{enabled: true, results: results}, http.MethodGet, "/api/search?q=支付+超时", http.StatusOK, 1},
+		{"没有结果返回空列表", &fakeSearcher{enabled: true}, http.MethodGet, "/api/search?q=提现", http.StatusOK, 0},
+		{"RAG 未启用", &fakeSearcher{enabled: false}, http.MethodGet, "/api/search?q=支付", http.StatusServiceUnavailable, 0},
+		{"缺少 q", &fakeSearcher{enabled: true}, http.MethodGet, "/api/search?chat_id=oc_1", http.StatusBadRequest, 0},
+		{"limit 非法", &fakeSearcher{enabled: true}, http.MethodGet, "/api/search?q=支付&limit=abc", http.StatusBadRequest, 0},
+		{"时间格式错误", &fakeSearcher{enabled: true}, http.MethodGet, "/api/search?q=支付&start=2024/01/01", http.StatusBadRequest, 0},
+		{"结束早于开始", &fakeSearcher{enabled: true}, http.MethodGet, "/api/search?q=支付&start=2024-02-01&end=2024-01-01", http.StatusBadRequest, 0},
+		{"搜索失败", &fakeSearcher{enabled: true, err: errors.New("qdrant down")}, http.MethodGet, "/api/search?q=支付", http.StatusInternalServerError, 0},
+		{"不支持 POST", &fakeSearcher{enabled: true}, http.MethodPost, "/api/search?q=支付", http.StatusMethodNotAllowed, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &SearchHandler{searcher: tt.searcher, token: "secret"}
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, tt.url, nil)
+			req.Header.Set("Authorization", "Bearer secret")
+			h.Handle(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("Status code = %d, want %d: %s", rec.Code, tt.wantCode, rec.Body.String())
+			}
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+			var resp struct {
+				Data struct {
+					Items []map[string]interface{} `json:"items"`
+					Total int                      `json:"total"`
+				} `json:"data"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Data.Items == nil || resp.Data.Total != tt.wantTotal || len(resp.Data.Items) != tt.wantTotal {
+				t.Errorf("Unexpected response: %s", rec.Body.String())
+			}
+			if tt.wantTotal > 0 && resp.Data.Items[0]["score"] == nil {
+				t.Errorf("Expected score in response: %s", rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestSearchHandlerFilters(t *testing.T) {
+	searcher := &fakeSearcher{enabled: true}
+	h := &SearchHandler{searcher: searcher, token: "secret"}
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet,
+		"/api/search?q=支付+超时&chat_id=oc_1&sender=张三&start=2024-01-01&end=2024-01-31&limit=500", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	h.Handle(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Status code = %d: %s", rec.Code, rec.Body.String())
+	}
+	if searcher.query != "支付 超时" || len(searcher.keywords) != 2 || searcher.limit != searchMaxLimit {
+		t.Errorf("Unexpected search args: query=%q keywords=%v limit=%d", searcher.query, searcher.keywords, searcher.limit)
+	}
+	opts := searcher.opts
+	if opts.ChatID != "oc_1" || opts.SenderName != "张三" || !opts.ExpandSynonyms || opts.SemanticWeight != 0.6 {
+		t.Errorf("Unexpected options: %+v", opts)
+	}
+	wantStart := time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)
+	wantEnd := time.Date(2024, 2, 1, 0, 0, 0, 0, time.Local).Add(-time.Nanosecond)
+	if opts.StartTime == nil || !opts.StartTime.Equal(wantStart) || opts.EndTime == nil || !opts.EndTime.Equal(wantEnd) {
+		t.Errorf("Unexpected time range: %v ~ %v", opts.StartTime, opts.EndTime)
+	}
+}
+
+func TestSearchHandlerAuth(t *testing.T) {
+	tests := []struct {
+		name     string
+		token    string
+		auth     string
+		wantCode int
+	}{
+		{"未配置令牌", "", "Bearer secret", http.StatusServiceUnavailable},
+		{"缺少令牌", "secret", "", http.StatusUnauthorized},
+		{"令牌错误", "secret", "Bearer wrong", http.StatusUnauthorized},
+		{"令牌正确", "secret", "Bearer secret", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			searcher := &fakeSearcher{enabled: true}
+			h := &SearchHandler{searcher: searcher, token: tt.token}
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/api/search?q=支付", nil)
+			if tt.auth != "" {
+				req.Header.Set("Authorization", tt.auth)
+			}
+			h.Handle(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("Status code = %d, want %d: %s", rec.Code, tt.wantCode, rec.Body.String())
+			}
+			if tt.wantCode != http.StatusOK && searcher.query != "" {
+				t.Error("Search should not run without a valid token")
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"team-assistant/internal/config"
+	"team-assistant/internal/model"
+)
+
+// syncHistoryTaskLimit 同步历史最多展示的任务数
+const syncHistoryTaskLimit = 5
+
+// syncHistorySuffixes "研发群同步过吗" 形式的提问后缀
+var syncHistorySuffixes = []string{"同步过吗", "同步过没有", "同步过没", "有没有同步过", "有没有同步", "同步到哪了", "同步到哪里了", "同步到哪儿了", "同步到什么时候了"}
+
+// parseSyncHistoryCommand 解析 "同步历史 研发群"、"同步记录 研发群"，返回群名
+func parseSyncHistoryCommand(content string) (string, bool) {
+	s := strings.TrimSpace(content)
+	for _, prefix := range []string{"同步历史", "同步记录"} {
+		if strings.HasPrefix(s, prefix) {
+			chat := strings.TrimSpace(strings.TrimPrefix(s, prefix))
+			return chat, chat != ""
+		}
+	}
+	return "", false
+}
+
+// isSyncHistoryCommand 判断是否是群同步历史命令
+func isSyncHistoryCommand(content string) bool {
+	_, ok := parseSyncHistoryCommand(content)
+	return ok
+}
+
+// parseSyncHistoryQuestion 解析 "研发群同步过吗"、"研发群同步到哪了？" 形式的提问，返回群名
+func parseSyncHistoryQuestion(content string) (string, bool) {
+	s := strings.TrimRight(strings.TrimSpace(content), "?？ ")
+	for _, suffix := range syncHistorySuffixes {
+		if !strings.HasSuffix(s, suffix) {
+			continue
+		}
+		chat := strings.TrimSpace(strings.TrimSuffix(strings.TrimSuffix(s, suffix), "的"))
+		return chat, chat != ""
+	}
+	return "", false
+}
+
+// handleSyncHistoryCommand 处理 "同步历史 [群名]"
+func (h *LarkWebhookHandler) handleSyncHistoryCommand(ctx context.Context, messageID, content string) {
+	target, _ := parseSyncHistoryCommand(content)
+	chatID, chatName, err := h.findChat(ctx, target)
+	if err != nil {
+		h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", fmt.Sprintf("未找到群聊「%s」，可发送 \"列出群聊\" 查看群名", target))
+		return
+	}
+	h.replyChatSyncHistory(ctx, messageID, chatID, chatName)
+}
+
+// trySyncHistoryQuestion 尝试把 "研发群同步过吗" 作为同步历史查询，群名匹配不到时返回 false 交给 AI 处理
+func (h *LarkWebhookHandler) trySyncHistoryQuestion(ctx context.Context, messageID, content string) bool {
+	target, ok := parseSyncHistoryQuestion(content)
+	if !ok {
+		return false
+	}
+	chatID, chatName, err := h.findChat(ctx, target)
+	if err != nil {
+		return false
+	}
+	h.replyChatSyncHistory(ctx, messageID, chatID, chatName)
+	return true
+}
+
+// replyChatSyncHistory 回复某个群的同步历史和定时同步配置，便于提问前了解数据覆盖情况
+func (h *LarkWebhookHandler) replyChatSyncHistory(ctx context.Context, messageID, chatID, chatName string) {
+	stats, err := h.svcCtx.SyncTaskModel.GetChatStats(ctx, chatID)
+	if err != nil {
+		log.Printf("Failed to get sync stats for %s: %v", chatID, err)
+		h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", "获取同步历史失败")
+		return
+	}
+	tasks, err := h.svcCtx.SyncTaskModel.ListByChat(ctx, chatID, syncHistoryTaskLimit)
+	if err != nil {
+		log.Printf("Failed to list sync tasks for %s: %v", chatID, err)
+		h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", "获取同步历史失败")
+		return
+	}
+
+	reply := formatChatSyncHistory(chatName, stats, tasks, findAutoSyncChat(h.svcCtx.Config.AutoSync, chatID))
+	if err := h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", reply); err != nil {
+		log.Printf("Failed to reply chat sync history: %v", err)
+	}
+}
+
+// findAutoSyncChat 查找群的定时同步配置，未启用定时同步或群不在列表中时返回 nil
+func findAutoSyncChat(cfg config.AutoSyncConfig, chatID string) *config.AutoSyncChatConfig {
+	if !cfg.Enabled {
+		return nil
+	}
+	for i := range cfg.Chats {
+		if cfg.Chats[i].ChatID == chatID {
+			return &cfg.Chats[i]
+		}
+	}
+	return nil
+}
+
+// formatChatSyncHistory 格式化群的同步历史：任务统计、最近完成时间、定时同步状态和最近的任务
+func formatChatSyncHistory(chatName string, stats *model.ChatSyncStats, tasks []*model.MessageSyncTask, autoSync *config.AutoSyncChatConfig) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📚 **「%s」的同步历史**\n\n", chatName))
+
+	if stats.TotalTasks == 0 {
+		sb.WriteString("• 尚未手动同步过历史消息\n")
+	} else {
+		sb.WriteString(fmt.Sprintf("• 同步任务：共 %d 个（完成 %d / 失败 %d / 进行中 %d）\n",
+			stats.TotalTasks, stats.CompletedTasks, stats.FailedTasks, stats.ActiveTasks))
+		if stats.LastCompletedAt.Valid {
+			sb.WriteString(fmt.Sprintf("• 最近完成：%s\n", stats.LastCompletedAt.Time.Format("2006-01-02 15:04")))
+		} else {
+			sb.WriteString("• 最近完成：暂无完成的任务\n")
+		}
+	}
+
+	if autoSync != nil {
+		sb.WriteString(fmt.Sprintf("• 定时同步：✅ 已启用（每 %d 秒拉取最近 %d 分钟的消息）\n",
+			autoSync.Interval, autoSync.LookbackMinutes))
+	} else {
+		sb.WriteString("• 定时同步：未启用，仅包含手动同步和机器人实时收到的消息\n")
+	}
+
+	if len(tasks) > 0 {
+		sb.WriteString("\n**最近的任务：**\n")
+		for _, task := range tasks {
+			sb.WriteString(formatSyncHistoryTask(task))
+		}
+	}
+
+	if stats.TotalTasks == 0 || !stats.LastCompletedAt.Valid {
+		sb.WriteString(fmt.Sprintf("\n发送 \"同步 %s\" 同步历史消息", chatName))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// formatSyncHistoryTask 格式化单个任务：状态、条数、创建和完成时间
+func formatSyncHistoryTask(task *model.MessageSyncTask) string {
+	status := task.Status
+	if name, ok := syncTaskStatusNames[task.Status]; ok {
+		status = syncTaskStatusIcons[task.Status] + " " + name
+	}
+
+	line := fmt.Sprintf("• #%d %s (%d条) %s", task.ID, status, task.SyncedMessages, task.CreatedAt.Format("01-02 15:04"))
+	if task.FinishedAt.Valid && task.Status == "completed" {
+		line += " 完成于 " + task.FinishedAt.Time.Format("01-02 15:04")
+	}
+	return line + "\n"
+}
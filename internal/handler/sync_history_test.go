@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"team-assistant/internal/config"
+	"team-assistant/internal/model"
+)
+
+func TestParseSyncHistory(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		question bool
+		want     string
+		wantOK   bool
+	}{
+		{"同步历史", "同步历史 研发群", false, "研发群", true},
+		{"同步记录", "同步记录 oc_abc", false, "oc_abc", true},
+		{"缺少群名", "同步历史", false, "", false},
+		{"同步命令", "同步 研发群", false, "", false},
+		{"同步过吗", "研发群同步过吗", true, "研发群", true},
+		{"带问号和的", "研发群的同步到哪了？", true, "研发群", true},
+		{"有没有同步", "产品 讨论群有没有同步", true, "产品 讨论群", true},
+		{"只有后缀", "同步过吗", true, "", false},
+		{"普通提问", "登录超时的问题修复了吗", true, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parse := parseSyncHistoryCommand
+			if tt.question {
+				parse = parseSyncHistoryQuestion
+			}
+			got, ok := parse(tt.content)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("parse(%q) = %q, %v, want %q, %v", tt.content, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestFindAutoSyncChat(t *testing.T) {
+	cfg := config.AutoSyncConfig{Enabled: true, Chats: []config.AutoSyncChatConfig{
+		{ChatID: "oc_a", Interval: 60, LookbackMinutes: 10},
+		{ChatID: "oc_b", Interval: 300, LookbackMinutes: 30},
+	}}
+	if got := findAutoSyncChat(cfg, "oc_b"); got == nil || got.Interval != 300 {
+		t.Errorf("findAutoSyncChat(oc_b) = %+v, want the oc_b config", got)
+	}
+	if got := findAutoSyncChat(cfg, "oc_c"); got != nil {
+		t.Errorf("findAutoSyncChat(oc_c) = %+v, want nil", got)
+	}
+	cfg.Enabled = false
+	if got := findAutoSyncChat(cfg, "oc_a"); got != nil {
+		t.Errorf("Expected nil when auto sync is disabled, got %+v", got)
+	}
+}
+
+func TestFormatChatSyncHistory(t *testing.T) {
+	created := time.Date(2024, 3, 5, 9, 30, 0, 0, time.Local)
+	finished := time.Date(2024, 3, 5, 9, 42, 0, 0, time.Local)
+	tasks := []*model.MessageSyncTask{
+		{ID: 12, ChatID: "oc_a", Status: "completed", SyncedMessages: 1200, CreatedAt: created,
+			FinishedAt: sql.NullTime{Time: finished, Valid: true}},
+		{ID: 9, ChatID: "oc_a", Status: "failed", SyncedMessages: 40, CreatedAt: created,
+			FinishedAt: sql.NullTime{Time: finished, Valid: true}},
+	}
+	stats := &model.ChatSyncStats{TotalTasks: 3, CompletedTasks: 1, FailedTasks: 2,
+		LastCompletedAt: sql.NullTime{Time: finished, Valid: true}}
+
+	got := formatChatSyncHistory("研发群", stats, tasks, &config.AutoSyncChatConfig{ChatID: "oc_a", Interval: 60, LookbackMinutes: 10})
+	want := "📚 **「研发群」的同步历史**\n\n" +
+		"• 同步任务：共 3 个（完成 1 / 失败 2 / 进行中 0）\n" +
+		"• 最近完成：2024-03-05 09:42\n" +
+		"• 定时同步：✅ 已启用（每 60 秒拉取最近 10 分钟的消息）\n\n" +
+		"**最近的任务：**\n" +
+		"• #12 ✅ 已完成 (1200条) 03-05 09:30 完成于 03-05 09:42\n" +
+		"• #9 ❌ 失败 (40条) 03-05 09:30"
+	if got != want {
+		t.Errorf("formatChatSyncHistory() =\n%s\nwant\n%s", got, want)
+	}
+
+	never := formatChatSyncHistory("产品群", &model.ChatSyncStats{}, nil, nil)
+	for _, want := range []string{"尚未手动同步过历史消息", "定时同步：未启用", "发送 \"同步 产品群\""} {
+		if !strings.Contains(never, want) {
+			t.Errorf("Never-synced reply missing %q:\n%s", want, never)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// syncRangeUsage 同步时间范围的用法提示
+const syncRangeUsage = "用法：重新同步 研发群 2024-01-01 到 2024-01-31"
+
+// syncRangePattern 命令末尾的日期范围："2024-01-01 到 2024-01-31"、"2024/1/1~2024/1/31"
+var syncRangePattern = regexp.MustCompile(`\s+(\d{4}[-/]\d{1,2}[-/]\d{1,2})\s*(?:到|至|~|～)\s*(\d{4}[-/]\d{1,2}[-/]\d{1,2})\s*$`)
+
+// syncDateLikePattern 末尾像是日期但格式不对的写法（如 "2024.01.01"、"2024-01"、只写了一个日期），用于提示格式错误而不是当作群名
+var syncDateLikePattern = regexp.MustCompile(`\s+\d{4}[-/.年]\d{1,2}\S*(?:\s*(?:到|至|~|～)\s*\S*)?\s*$`)
+
+// syncRange 同步的时间范围，零值表示不限
+type syncRange struct {
+	start time.Time // 开始日期 00:00:00
+	end   time.Time // 结束日期 23:59:59
+}
+
+// isZero 是否未指定时间范围
+func (r syncRange) isZero() bool {
+	return r.start.IsZero() && r.end.IsZero()
+}
+
+// String 范围描述，如 "2024-01-01 ~ 2024-01-31"
+func (r syncRange) String() string {
+	return r.start.Format("2006-01-02") + " ~ " + r.end.Format("2006-01-02")
+}
+
+// parseSyncTarget 拆分同步命令中的群名和末尾的日期范围（按本地时区，包含结束日当天）
+// 没有日期范围时返回零值；日期格式错误、日期不存在或开始晚于结束时返回错误
+func parseSyncTarget(target string, now time.Time) (string, syncRange, error) {
+	m := syncRangePattern.FindStringSubmatchIndex(target)
+	if m == nil {
+		if syncDateLikePattern.MatchString(target) {
+			return "", syncRange{}, errors.New("日期范围格式不正确")
+		}
+		return target, syncRange{}, nil
+	}
+
+	name := strings.TrimSpace(target[:m[0]])
+	start, ok := parseSyncDate(target[m[2]:m[3]])
+	if !ok {
+		return "", syncRange{}, errors.New("开始日期无效：" + target[m[2]:m[3]])
+	}
+	end, ok := parseSyncDate(target[m[4]:m[5]])
+	if !ok {
+		return "", syncRange{}, errors.New("结束日期无效：" + target[m[4]:m[5]])
+	}
+	if end.Before(start) {
+		return "", syncRange{}, errors.New("开始日期不能晚于结束日期")
+	}
+	if start.After(now) {
+		return "", syncRange{}, errors.New("开始日期不能晚于今天")
+	}
+	return name, syncRange{start: start, end: end.AddDate(0, 0, 1).Add(-time.Second)}, nil
+}
+
+// parseSyncDate 解析 2024-01-01、2024/1/1 形式的日期，日期不存在（如 2024-02-30）时返回 false
+func parseSyncDate(s string) (time.Time, bool) {
+	parts := strings.FieldsFunc(s, func(r rune) bool { return r == '-' || r == '/' })
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+	year, _ := strconv.Atoi(parts[0])
+	month, _ := strconv.Atoi(parts[1])
+	day, _ := strconv.Atoi(parts[2])
+	t := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.Local)
+	if t.Year() != year || int(t.Month()) != month || t.Day() != day {
+		return time.Time{}, false
+	}
+	return t, true
+}
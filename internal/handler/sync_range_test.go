@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSyncTarget(t *testing.T) {
+	now := time.Date(2024, 3, 5, 10, 0, 0, 0, time.Local)
+	tests := []struct {
+		name      string
+		target    string
+		wantName  string
+		wantStart time.Time
+		wantEnd   time.Time
+		wantErr   bool
+	}{
+		{"不带日期", "研发群", "研发群", time.Time{}, time.Time{}, false},
+		{"群名中的数字不当作日期", "2024 年会筹备群", "2024 年会筹备群", time.Time{}, time.Time{}, false},
+		{"到", "研发群 2024-01-01 到 2024-01-31", "研发群",
+			time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local), time.Date(2024, 1, 31, 23, 59, 59, 0, time.Local), false},
+		{"斜杠和波浪线", "研发 测试群 2024/1/1~2024/1/1", "研发 测试群",
+			time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local), time.Date(2024, 1, 1, 23, 59, 59, 0, time.Local), false},
+		{"至", "oc_abc 2023-12-25 至 2024-01-02", "oc_abc",
+			time.Date(2023, 12, 25, 0, 0, 0, 0, time.Local), time.Date(2024, 1, 2, 23, 59, 59, 0, time.Local), false},
+		{"日期不存在", "研发群 2024-02-30 到 2024-03-01", "", time.Time{}, time.Time{}, true},
+		{"月份越界", "研发群 2024-01-01 到 2024-13-01", "", time.Time{}, time.Time{}, true},
+		{"开始晚于结束", "研发群 2024-02-01 到 2024-01-01", "", time.Time{}, time.Time{}, true},
+		{"开始日期在未来", "研发群 2024-04-01 到 2024-04-30", "", time.Time{}, time.Time{}, true},
+		{"只写了一个日期", "研发群 2024-01-01", "", time.Time{}, time.Time{}, true},
+		{"点分隔", "研发群 2024.01.01 到 2024.01.31", "", time.Time{}, time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, r, err := parseSyncTarget(tt.target, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSyncTarget(%q) error = %v, wantErr %v", tt.target, err, tt.wantErr)
+			}
+			if name != tt.wantName || !r.start.Equal(tt.wantStart) || !r.end.Equal(tt.wantEnd) {
+				t.Errorf("parseSyncTarget(%q) = %q, %v ~ %v, want %q, %v ~ %v",
+					tt.target, name, r.start, r.end, tt.wantName, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
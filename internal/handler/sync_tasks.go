@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"team-assistant/internal/model"
+)
+
+const (
+	syncTaskListLimit   = 10  // 任务列表最多展示的任务数
+	syncTaskErrorLength = 100 // 失败原因展示的字数
+)
+
+// syncTaskStatusWords 命令中的状态词
+var syncTaskStatusWords = map[string]string{
+	"失败": "failed", "出错": "failed",
+	"进行中": "running", "同步中": "running", "运行中": "running",
+	"等待": "pending", "等待中": "pending", "排队": "pending",
+	"完成": "completed", "已完成": "completed", "成功": "completed",
+}
+
+// syncTaskStatusNames 任务状态的中文名称
+var syncTaskStatusNames = map[string]string{
+	"pending":   "等待中",
+	"running":   "同步中",
+	"completed": "已完成",
+	"failed":    "失败",
+}
+
+// syncTaskStatusIcons 任务状态的图标
+var syncTaskStatusIcons = map[string]string{
+	"pending":   "⏳",
+	"running":   "🔄",
+	"completed": "✅",
+	"failed":    "❌",
+}
+
+// syncTaskFilter 任务列表过滤条件，都为空时列出最近的任务
+type syncTaskFilter struct {
+	Status string // pending/running/completed/failed
+	Chat   string // 群名或群ID
+}
+
+// parseSyncTaskFilter 解析任务列表命令：
+// "同步任务"、"同步任务 失败"、"同步任务 研发群"、"显示失败的任务"
+func parseSyncTaskFilter(content string) (syncTaskFilter, bool) {
+	s := strings.TrimSpace(content)
+	for _, prefix := range []string{"显示", "查看", "列出"} {
+		s = strings.TrimPrefix(s, prefix)
+	}
+
+	if strings.HasPrefix(s, "同步任务") {
+		arg := strings.TrimSpace(strings.TrimPrefix(s, "同步任务"))
+		if arg == "" {
+			return syncTaskFilter{}, true
+		}
+		if status, ok := syncTaskStatusWords[strings.TrimSuffix(arg, "的")]; ok {
+			return syncTaskFilter{Status: status}, true
+		}
+		return syncTaskFilter{Chat: arg}, true
+	}
+
+	// "失败的任务"、"进行中的同步任务" 只接受状态词，避免误识别普通提问
+	for _, suffix := range []string{"的同步任务", "的任务", "任务"} {
+		if !strings.HasSuffix(s, suffix) {
+			continue
+		}
+		if status, ok := syncTaskStatusWords[strings.TrimSuffix(s, suffix)]; ok {
+			return syncTaskFilter{Status: status}, true
+		}
+	}
+	return syncTaskFilter{}, false
+}
+
+// isSyncTaskListCommand 判断是否是任务列表命令
+func isSyncTaskListCommand(content string) bool {
+	_, ok := parseSyncTaskFilter(content)
+	return ok
+}
+
+// listSyncTasks 按状态或群列出同步任务，便于排查失败的同步
+func (h *LarkWebhookHandler) listSyncTasks(ctx context.Context, messageID, content string) {
+	filter, _ := parseSyncTaskFilter(content)
+
+	var tasks []*model.MessageSyncTask
+	var err error
+	title := "最近的同步任务"
+	switch {
+	case filter.Status != "":
+		title = syncTaskStatusNames[filter.Status] + "的同步任务"
+		tasks, err = h.svcCtx.SyncTaskModel.ListByStatus(ctx, filter.Status, syncTaskListLimit)
+	case filter.Chat != "":
+		chatID, chatName, findErr := h.findChat(ctx, filter.Chat)
+		if findErr != nil {
+			h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", fmt.Sprintf("未找到群聊「%s」，可发送 \"列出群聊\" 查看群名", filter.Chat))
+			return
+		}
+		title = fmt.Sprintf("「%s」的同步任务", chatName)
+		tasks, err = h.svcCtx.SyncTaskModel.ListByChat(ctx, chatID, syncTaskListLimit)
+	default:
+		tasks, err = h.svcCtx.SyncTaskModel.GetRecentTasks(ctx, syncTaskListLimit)
+	}
+	if err != nil {
+		log.Printf("Failed to list sync tasks (status=%q, chat=%q): %v", filter.Status, filter.Chat, err)
+		h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", "获取任务列表失败")
+		return
+	}
+
+	if err := h.svcCtx.LarkClient.ReplyMessage(ctx, messageID, "text", formatSyncTaskList(title, tasks)); err != nil {
+		log.Printf("Failed to reply sync task list: %v", err)
+	}
+}
+
+// formatSyncTaskList 格式化任务列表，失败的任务附带失败原因
+func formatSyncTaskList(title string, tasks []*model.MessageSyncTask) string {
+	if len(tasks) == 0 {
+		return fmt.Sprintf("📭 暂无%s", title)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📋 **%s**（最近 %d 个）\n\n", title, len(tasks)))
+	for _, task := range tasks {
+		chatName := task.ChatID
+		if task.ChatName.Valid && task.ChatName.String != "" {
+			chatName = task.ChatName.String
+		}
+		status := task.Status
+		if name, ok := syncTaskStatusNames[task.Status]; ok {
+			status = syncTaskStatusIcons[task.Status] + " " + name
+		}
+
+		sb.WriteString(fmt.Sprintf("• #%d %s %s (%d条) %s\n", task.ID, status, chatName,
+			task.SyncedMessages, task.CreatedAt.Format("01-02 15:04")))
+		if task.Status == "failed" && task.ErrorMsg.Valid && task.ErrorMsg.String != "" {
+			errMsg := []rune(task.ErrorMsg.String)
+			if len(errMsg) > syncTaskErrorLength {
+				errMsg = append(errMsg[:syncTaskErrorLength], []rune("...")...)
+			}
+			sb.WriteString(fmt.Sprintf("   原因: %s\n", string(errMsg)))
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
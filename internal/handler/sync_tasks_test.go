@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"team-assistant/internal/model"
+)
+
+func TestParseSyncTaskFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    syncTaskFilter
+		wantOK  bool
+	}{
+		{"最近任务", "同步任务", syncTaskFilter{}, true},
+		{"按状态", "同步任务 失败", syncTaskFilter{Status: "failed"}, true},
+		{"状态带的", "同步任务 进行中的", syncTaskFilter{Status: "running"}, true},
+		{"按群名", "同步任务 研发群", syncTaskFilter{Chat: "研发群"}, true},
+		{"按群ID", "同步任务 oc_abc", syncTaskFilter{Chat: "oc_abc"}, true},
+		{"显示失败的任务", "显示失败的任务", syncTaskFilter{Status: "failed"}, true},
+		{"查看等待中的同步任务", "查看等待中的同步任务", syncTaskFilter{Status: "pending"}, true},
+		{"同步中的任务", "同步中的任务", syncTaskFilter{Status: "running"}, true},
+		{"普通提问", "今天完成的任务有哪些", syncTaskFilter{}, false},
+		{"非状态词", "小王的任务", syncTaskFilter{}, false},
+		{"同步命令", "同步 研发群", syncTaskFilter{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseSyncTaskFilter(tt.content)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("parseSyncTaskFilter(%q) = %+v, %v, want %+v, %v", tt.content, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestFormatSyncTaskList(t *testing.T) {
+	created := time.Date(2024, 3, 5, 9, 30, 0, 0, time.Local)
+	tasks := []*model.MessageSyncTask{
+		{ID: 12, ChatID: "oc_a", ChatName: sql.NullString{String: "研发群", Valid: true}, Status: "failed",
+			SyncedMessages: 40, ErrorMsg: sql.NullString{String: strings.Repeat("超", 120), Valid: true}, CreatedAt: created},
+		{ID: 11, ChatID: "oc_b", Status: "completed", SyncedMessages: 300, CreatedAt: created},
+	}
+
+	got := formatSyncTaskList("失败的同步任务", tasks)
+	want := "📋 **失败的同步任务**（最近 2 个）\n\n" +
+		"• #12 ❌ 失败 研发群 (40条) 03-05 09:30\n" +
+		"   原因: " + strings.Repeat("超", syncTaskErrorLength) + "...\n" +
+		"• #11 ✅ 已完成 oc_b (300条) 03-05 09:30"
+	if got != want {
+		t.Errorf("formatSyncTaskList() =\n%s\nwant\n%s", got, want)
+	}
+
+	if got := formatSyncTaskList("失败的同步任务", nil); got != "📭 暂无失败的同步任务" {
+		t.Errorf("Unexpected empty reply: %q", got)
+	}
+}
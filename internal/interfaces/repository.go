@@ -23,7 +23,7 @@ type CommitRepository interface {
 	GetStatsByMember(ctx context.Context, memberID int64, start, end time.Time) (*model.CommitStats, error)
 	GetStatsByAuthorName(ctx context.Context, authorName string, start, end time.Time) (*model.CommitStats, error)
 	GetAllStats(ctx context.Context, start, end time.Time) ([]*model.CommitStats, error)
-	GetRecentCommits(ctx context.Context, memberID int64, limit int) ([]*model.GitCommit, error)
+	GetRecentCommits(ctx context.Context, memberID int64, start, end time.Time, limit int) ([]*model.GitCommit, error)
 	GetCommitsByDateRange(ctx context.Context, authorName string, start, end time.Time, limit int) ([]*model.GitCommit, error)
 }
 
@@ -42,6 +42,7 @@ type GroupRepository interface {
 	Upsert(ctx context.Context, group *model.ChatGroup) error
 	FindByChatID(ctx context.Context, chatID string) (*model.ChatGroup, error)
 	ListAll(ctx context.Context) ([]*model.ChatGroup, error)
+	UpdateMetadata(ctx context.Context, chatID, project, keywords string) error
 }
 
 // SyncTaskRepository 同步任务数据访问接口
@@ -50,8 +51,10 @@ type SyncTaskRepository interface {
 	GetByID(ctx context.Context, id int64) (*model.MessageSyncTask, error)
 	GetPendingTask(ctx context.Context) (*model.MessageSyncTask, error)
 	GetRecentTasks(ctx context.Context, limit int) ([]*model.MessageSyncTask, error)
+	ListByStatus(ctx context.Context, status string, limit int) ([]*model.MessageSyncTask, error)
+	ListByChat(ctx context.Context, chatID string, limit int) ([]*model.MessageSyncTask, error)
 	UpdateStatus(ctx context.Context, id int64, status string) error
-	UpdateProgress(ctx context.Context, id int64, syncedMessages int, pageToken string) error
+	UpdateProgress(ctx context.Context, id int64, syncedMessages int, pageToken, resumeTime string) error
 	MarkStarted(ctx context.Context, id int64) error
 	MarkCompleted(ctx context.Context, id int64, totalMessages int) error
 	MarkFailed(ctx context.Context, id int64, errMsg string) error
@@ -63,3 +66,9 @@ type ConversationRepository interface {
 	SaveConversationID(ctx context.Context, userID, conversationID string, ttl time.Duration) error
 	DeleteConversation(ctx context.Context, userID string) error
 }
+
+// ReadStateRepository 用户已读状态数据访问接口
+type ReadStateRepository interface {
+	Get(ctx context.Context, userID, chatID string) (*model.UserReadState, error)
+	Upsert(ctx context.Context, userID, chatID string, lastReadAt time.Time) error
+}
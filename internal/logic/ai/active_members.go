@@ -0,0 +1,66 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"team-assistant/internal/model"
+)
+
+// ======================== 活跃成员名单（角色类问题） ========================
+
+const (
+	defaultActiveMemberLimit = 30 // 默认名单上限
+	activeMemberDays         = 90 // 统计最近多少天的发言
+)
+
+// roleQueryPatterns 角色/人员类问题的关键词
+var roleQueryPatterns = []string{"是谁", "谁负责", "谁是", "负责人", "有哪些人", "有谁", "哪位"}
+
+// isRoleQuery 判断是否是询问角色/负责人的问题（如 "后端是谁"、"支付谁负责"）
+func isRoleQuery(query string) bool {
+	return containsAny(query, roleQueryPatterns)
+}
+
+// activeMemberLimit 名单上限，0 使用默认值，负数关闭
+func (hp *HybridProcessor) activeMemberLimit() int {
+	limit := hp.svcCtx.Config.LLM.ActiveMemberLimit
+	if limit == 0 {
+		return defaultActiveMemberLimit
+	}
+	return limit
+}
+
+// activeMembersContext 返回群内活跃成员名单（发言人名称通常带角色，如 "张三-后端"），便于 LLM 推断角色
+// 大群只列出最活跃的成员；跨群查询或未开启时返回空字符串
+func (hp *HybridProcessor) activeMembersContext(ctx context.Context, chatID string) string {
+	if chatID == "" || hp.svcCtx == nil || hp.svcCtx.MessageModel == nil {
+		return ""
+	}
+	limit := hp.activeMemberLimit()
+	if limit < 0 {
+		return ""
+	}
+
+	senders, err := hp.svcCtx.MessageModel.GetActiveSenders(ctx, chatID, time.Now().AddDate(0, 0, -activeMemberDays), limit)
+	if err != nil {
+		log.Printf("Failed to get active senders for %s: %v", chatID, err)
+		return ""
+	}
+	return formatActiveMembers(senders)
+}
+
+// formatActiveMembers 格式化活跃成员名单（保持按发言数降序）
+func formatActiveMembers(senders []*model.SenderActivity) string {
+	if len(senders) == 0 {
+		return ""
+	}
+	names := make([]string, len(senders))
+	for i, s := range senders {
+		names[i] = fmt.Sprintf("%s(%d)", s.Name, s.MessageCount)
+	}
+	return fmt.Sprintf("【群内活跃成员】近 %d 天按发言数排序：%s", activeMemberDays, strings.Join(names, "、"))
+}
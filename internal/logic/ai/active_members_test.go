@@ -0,0 +1,43 @@
+package ai
+
+import (
+	"testing"
+
+	"team-assistant/internal/model"
+)
+
+func TestIsRoleQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"代理模式的后端是谁", true},
+		{"支付谁负责", true},
+		{"这个需求的负责人", true},
+		{"今天讨论了什么", false},
+		{"登录超时修好了吗", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			if got := isRoleQuery(tt.query); got != tt.want {
+				t.Errorf("isRoleQuery(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatActiveMembers(t *testing.T) {
+	senders := []*model.SenderActivity{
+		{Name: "Joy-产品", MessageCount: 120},
+		{Name: "张三-后端", MessageCount: 80},
+		{Name: "李四-测试", MessageCount: 3},
+	}
+	want := "【群内活跃成员】近 90 天按发言数排序：Joy-产品(120)、张三-后端(80)、李四-测试(3)"
+	if got := formatActiveMembers(senders); got != want {
+		t.Errorf("formatActiveMembers() = %q, want %q", got, want)
+	}
+	if got := formatActiveMembers(nil); got != "" {
+		t.Errorf("Expected empty roster, got %q", got)
+	}
+}
@@ -0,0 +1,259 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"team-assistant/internal/model"
+	"team-assistant/pkg/llm"
+)
+
+// ======================== 告警统计（直接查告警表，不经过 LLM） ========================
+
+// 告警统计查询类型
+const (
+	alertStatsTrend   = "trend"   // 站点告警趋势，如 "by4 这周告警趋势"
+	alertStatsRanking = "ranking" // 站点告警排行，如 "哪个站点告警最多"
+)
+
+// alertStatsQuery 解析后的告警统计查询
+type alertStatsQuery struct {
+	kind      string
+	site      string        // 趋势查询的站点，为空表示所有站点
+	timeRange llm.TimeRange // 为空表示最近 7 天
+	label     string        // 时间范围描述，如 "本周"
+}
+
+// statsTimeRanges 统计类查询的时间关键词（按匹配优先级排列）
+var statsTimeRanges = []struct {
+	keyword string
+	tr      llm.TimeRange
+	label   string
+}{
+	{"今天", llm.TimeRangeToday, "今天"},
+	{"昨天", llm.TimeRangeYesterday, "昨天"},
+	{"上周", llm.TimeRangeLastWeek, "上周"},
+	{"本周", llm.TimeRangeThisWeek, "本周"},
+	{"这周", llm.TimeRangeThisWeek, "本周"},
+	{"上个月", llm.TimeRangeLastMonth, "上个月"},
+	{"上月", llm.TimeRangeLastMonth, "上个月"},
+	{"本月", llm.TimeRangeThisMonth, "本月"},
+	{"这个月", llm.TimeRangeThisMonth, "本月"},
+	{"最近一个月", llm.TimeRangeRecentMonth, "最近 30 天"},
+	{"近30天", llm.TimeRangeRecentMonth, "最近 30 天"},
+}
+
+// alertSitePattern 查询中的站点前缀（如 by4、l08）或站点ID（3-5位数字）
+var alertSitePattern = regexp.MustCompile(`(?i)\b[a-z]{1,3}\d{1,3}\b|\b\d{3,5}\b`)
+
+// parseAlertStatsQuery 识别可以直接由告警表回答的统计问题
+func parseAlertStatsQuery(query string) (alertStatsQuery, bool) {
+	if !strings.Contains(query, "告警") && !strings.Contains(query, "报警") {
+		return alertStatsQuery{}, false
+	}
+
+	q := alertStatsQuery{}
+	q.timeRange, q.label = parseStatsTimeRange(query)
+
+	isRanking := strings.Contains(query, "站点") &&
+		(strings.Contains(query, "最多") || strings.Contains(query, "排行") || strings.Contains(query, "排名"))
+	switch {
+	case isRanking:
+		q.kind = alertStatsRanking
+	case strings.Contains(query, "趋势") || strings.Contains(query, "每天"):
+		q.kind = alertStatsTrend
+		q.site = strings.ToLower(alertSitePattern.FindString(query))
+	default:
+		return alertStatsQuery{}, false
+	}
+	return q, true
+}
+
+// parseStatsTimeRange 识别查询中的时间关键词，未指定时为最近 7 天（返回空 TimeRange）
+func parseStatsTimeRange(query string) (llm.TimeRange, string) {
+	for _, r := range statsTimeRanges {
+		if strings.Contains(query, r.keyword) {
+			return r.tr, r.label
+		}
+	}
+	return "", "最近 7 天"
+}
+
+// statsRange 计算统计类查询的时间范围，未指定时为最近 7 天
+func (hp *HybridProcessor) statsRange(tr llm.TimeRange, now time.Time) (time.Time, time.Time) {
+	if tr == "" {
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		return today.AddDate(0, 0, -6), now
+	}
+	return hp.getTimeRange(tr)
+}
+
+// tryAlertStats 尝试直接从告警表回答告警趋势/排行问题，无法回答时返回 false 交给常规流程
+func (hp *HybridProcessor) tryAlertStats(ctx context.Context, query string) (string, bool) {
+	if hp.svcCtx == nil || hp.svcCtx.AlertModel == nil {
+		return "", false
+	}
+	q, ok := parseAlertStatsQuery(query)
+	if !ok {
+		return "", false
+	}
+
+	start, end := hp.statsRange(q.timeRange, time.Now())
+	counts, err := hp.svcCtx.AlertModel.CountByTypeAndSite(ctx, start, end)
+	if err != nil {
+		log.Printf("Failed to count alerts: %v, falling back to normal processing", err)
+		return "", false
+	}
+
+	if q.kind == alertStatsRanking {
+		return formatAlertRanking(counts, q.label), true
+	}
+
+	days, err := hp.svcCtx.AlertModel.TrendByDay(ctx, q.site, start, end)
+	if err != nil {
+		log.Printf("Failed to get alert trend: %v, falling back to normal processing", err)
+		return "", false
+	}
+	return formatAlertTrend(q.site, q.label, days, counts, start, end), true
+}
+
+// alertSiteName 站点展示名
+func alertSiteName(site string) string {
+	if site == "" {
+		return "未识别站点"
+	}
+	return site
+}
+
+// alertRankingLimit 告警排行最多展示的站点数
+const alertRankingLimit = 10
+
+// formatAlertRanking 格式化站点告警排行
+func formatAlertRanking(counts []*model.AlertCount, label string) string {
+	return formatAlertRankingTop(counts, label, alertRankingLimit)
+}
+
+// formatAlertRankingTop 格式化站点告警排行，最多展示 limit 个站点（"告警最多的三个站点" 只展示前三）
+func formatAlertRankingTop(counts []*model.AlertCount, label string, limit int) string {
+	if len(counts) == 0 {
+		return fmt.Sprintf("📭 %s没有告警记录", label)
+	}
+
+	type siteTotal struct {
+		site  string
+		total int
+		types []*model.AlertCount
+	}
+	bySite := make(map[string]*siteTotal)
+	var sites []*siteTotal
+	total := 0
+	for _, c := range counts {
+		st, ok := bySite[c.Site]
+		if !ok {
+			st = &siteTotal{site: c.Site}
+			bySite[c.Site] = st
+			sites = append(sites, st)
+		}
+		st.total += c.Count
+		st.types = append(st.types, c)
+		total += c.Count
+	}
+	sort.SliceStable(sites, func(i, j int) bool { return sites[i].total > sites[j].total })
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📊 %s站点告警排行（共 %d 条，%d 个站点）\n", label, total, len(sites)))
+	for i, st := range sites {
+		if i >= limit {
+			sb.WriteString(fmt.Sprintf("\n…其余 %d 个站点未展示", len(sites)-limit))
+			break
+		}
+		var parts []string
+		for _, t := range st.types {
+			parts = append(parts, fmt.Sprintf("%s %d", t.AlertType, t.Count))
+		}
+		sb.WriteString(fmt.Sprintf("\n%d. 📍%s — %d 条（%s）", i+1, alertSiteName(st.site), st.total, strings.Join(parts, "、")))
+	}
+	return sb.String()
+}
+
+// alertTrendMaxBar 趋势图最长的柱长度
+const alertTrendMaxBar = 20
+
+// formatAlertTrend 格式化告警按天趋势，范围不超过一个月时补齐没有告警的日期
+func formatAlertTrend(site, label string, days []*model.AlertDayCount, counts []*model.AlertCount, start, end time.Time) string {
+	target := "所有站点"
+	if site != "" {
+		target = site
+	}
+
+	byDay := make(map[string]int)
+	total, maxCount := 0, 0
+	for _, d := range days {
+		byDay[d.Day] = d.Count
+		total += d.Count
+		if d.Count > maxCount {
+			maxCount = d.Count
+		}
+	}
+	if total == 0 {
+		return fmt.Sprintf("📭 %s %s没有告警记录", target, label)
+	}
+
+	var keys []string
+	if end.Sub(start) <= 31*24*time.Hour {
+		for d := start; d.Before(end); d = d.AddDate(0, 0, 1) {
+			keys = append(keys, d.Format("2006-01-02"))
+		}
+	} else {
+		for _, d := range days {
+			keys = append(keys, d.Day)
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📈 %s %s告警趋势（共 %d 条）\n", target, label, total))
+	for _, day := range keys {
+		n := byDay[day]
+		bar := strings.Repeat("▇", (n*alertTrendMaxBar+maxCount-1)/maxCount)
+		sb.WriteString(fmt.Sprintf("\n%s %s %d", day[5:], bar, n))
+	}
+
+	var parts []string
+	if site == "" {
+		parts = mergeAlertTypeCounts(counts)
+	} else {
+		for _, c := range counts {
+			if c.Site == site {
+				parts = append(parts, fmt.Sprintf("%s %d", c.AlertType, c.Count))
+			}
+		}
+	}
+	if len(parts) > 0 {
+		sb.WriteString("\n\n📋 类型分布：" + strings.Join(parts, "、"))
+	}
+	return sb.String()
+}
+
+// mergeAlertTypeCounts 合并各站点的同类告警数，按数量倒序
+func mergeAlertTypeCounts(counts []*model.AlertCount) []string {
+	totals := make(map[string]int)
+	var types []string
+	for _, c := range counts {
+		if _, ok := totals[c.AlertType]; !ok {
+			types = append(types, c.AlertType)
+		}
+		totals[c.AlertType] += c.Count
+	}
+	sort.SliceStable(types, func(i, j int) bool { return totals[types[i]] > totals[types[j]] })
+
+	parts := make([]string, 0, len(types))
+	for _, t := range types {
+		parts = append(parts, fmt.Sprintf("%s %d", t, totals[t]))
+	}
+	return parts
+}
@@ -0,0 +1,105 @@
+package ai
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"team-assistant/internal/model"
+	"team-assistant/pkg/llm"
+)
+
+func TestParseAlertStatsQuery(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		wantOK    bool
+		wantKind  string
+		wantSite  string
+		wantRange llm.TimeRange
+	}{
+		{"站点趋势", "by4 这周告警趋势", true, alertStatsTrend, "by4", llm.TimeRangeThisWeek},
+		{"大写站点", "BY4上周每天的告警", true, alertStatsTrend, "by4", llm.TimeRangeLastWeek},
+		{"所有站点趋势", "告警趋势", true, alertStatsTrend, "", ""},
+		{"站点排行", "哪个站点告警最多", true, alertStatsRanking, "", ""},
+		{"本月排行", "本月站点告警排行", true, alertStatsRanking, "", llm.TimeRangeThisMonth},
+		{"普通告警查询", "by4 有什么告警", false, "", "", ""},
+		{"非告警问题", "这周谁最忙", false, "", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, ok := parseAlertStatsQuery(tt.query)
+			if ok != tt.wantOK {
+				t.Fatalf("parseAlertStatsQuery(%q) ok = %v, want %v", tt.query, ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if q.kind != tt.wantKind || q.site != tt.wantSite || q.timeRange != tt.wantRange {
+				t.Errorf("parseAlertStatsQuery(%q) = %+v, want kind=%s site=%q range=%q",
+					tt.query, q, tt.wantKind, tt.wantSite, tt.wantRange)
+			}
+		})
+	}
+}
+
+func TestFormatAlertRanking(t *testing.T) {
+	counts := []*model.AlertCount{
+		{Site: "by4", AlertType: "慢请求", Count: 5},
+		{Site: "l08", AlertType: "支付失败", Count: 4},
+		{Site: "by4", AlertType: "支付失败", Count: 2},
+		{Site: "", AlertType: "其他告警", Count: 1},
+	}
+
+	result := formatAlertRanking(counts, "本周")
+	for _, want := range []string{
+		"本周站点告警排行（共 12 条，3 个站点）",
+		"1. 📍by4 — 7 条（慢请求 5、支付失败 2）",
+		"2. 📍l08 — 4 条（支付失败 4）",
+		"3. 📍未识别站点 — 1 条",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("Result should contain %q, got:\n%s", want, result)
+		}
+	}
+
+	if got := formatAlertRanking(nil, "今天"); got != "📭 今天没有告警记录" {
+		t.Errorf("Unexpected empty result: %q", got)
+	}
+}
+
+func TestFormatAlertTrend(t *testing.T) {
+	start := time.Date(2024, 3, 4, 0, 0, 0, 0, time.Local)
+	end := start.AddDate(0, 0, 3)
+	days := []*model.AlertDayCount{{Day: "2024-03-04", Count: 4}, {Day: "2024-03-06", Count: 2}}
+	counts := []*model.AlertCount{
+		{Site: "by4", AlertType: "慢请求", Count: 6},
+		{Site: "l08", AlertType: "支付失败", Count: 3},
+	}
+
+	result := formatAlertTrend("by4", "本周", days, counts, start, end)
+	for _, want := range []string{
+		"📈 by4 本周告警趋势（共 6 条）",
+		"03-04 " + strings.Repeat("▇", alertTrendMaxBar) + " 4",
+		"03-05  0",
+		"03-06 " + strings.Repeat("▇", alertTrendMaxBar/2) + " 2",
+		"类型分布：慢请求 6",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("Result should contain %q, got:\n%s", want, result)
+		}
+	}
+	if strings.Contains(result, "支付失败") {
+		t.Errorf("Should only list alert types of the site:\n%s", result)
+	}
+
+	all := formatAlertTrend("", "本周", days, counts, start, end)
+	if !strings.Contains(all, "所有站点") || !strings.Contains(all, "慢请求 6、支付失败 3") {
+		t.Errorf("Unexpected all-site trend:\n%s", all)
+	}
+
+	if got := formatAlertTrend("by4", "本周", nil, nil, start, end); got != "📭 by4 本周没有告警记录" {
+		t.Errorf("Unexpected empty result: %q", got)
+	}
+}
@@ -0,0 +1,64 @@
+package ai
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultAnswerSourceLimit 默认附加的参考消息条数
+	defaultAnswerSourceLimit = 5
+	// answerSourceSnippetLen 参考消息内容截断长度（字符）
+	answerSourceSnippetLen = 60
+)
+
+// answerSource 回答所依据的一条消息
+type answerSource struct {
+	sender    string
+	content   string
+	timestamp time.Time
+}
+
+// formatAnswerSources 格式化"参考消息"段落，按传入顺序（相关度）取前 limit 条
+func formatAnswerSources(sources []answerSource, limit int) string {
+	if len(sources) == 0 {
+		return ""
+	}
+	if limit <= 0 {
+		limit = defaultAnswerSourceLimit
+	}
+	if len(sources) > limit {
+		sources = sources[:limit]
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📎 参考消息（%d 条）\n", len(sources)))
+	for i, src := range sources {
+		sb.WriteString(fmt.Sprintf("%d. [%s] %s: %s\n", i+1,
+			src.timestamp.Format("01-02 15:04"), src.sender, trimSnippet(src.content, answerSourceSnippetLen)))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// trimSnippet 合并空白并按字符截断，避免截断多字节字符
+func trimSnippet(s string, maxRunes int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	runes := []rune(s)
+	if len(runes) <= maxRunes {
+		return s
+	}
+	return string(runes[:maxRunes]) + "..."
+}
+
+// appendAnswerSources 在回答末尾附加参考消息（未开启时原样返回）
+func (hp *HybridProcessor) appendAnswerSources(answer string, sources []answerSource) string {
+	if !hp.svcCtx.Config.LLM.ShowAnswerSources {
+		return answer
+	}
+	section := formatAnswerSources(sources, hp.svcCtx.Config.LLM.AnswerSourceLimit)
+	if section == "" {
+		return answer
+	}
+	return answer + "\n\n---\n" + section
+}
@@ -0,0 +1,151 @@
+package ai
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"text/template"
+	"time"
+)
+
+// ======================== 回复模板 ========================
+
+// 模板名称（模板文件中用 {{define "<名称>"}}...{{end}} 覆盖）
+const (
+	tmplWorkload       = "workload"       // 工作量统计（LLM 不可用时的降级展示）
+	tmplSemanticSearch = "search"         // 混合搜索结果
+	tmplKeywordSearch  = "keyword_search" // 关键词搜索结果
+	tmplSummary        = "summary"        // 消息总结
+)
+
+// defaultAnswerTemplates 内置回复模板，未配置模板文件或模板文件中没有覆盖的部分使用这里的定义
+const defaultAnswerTemplates = `
+{{- define "workload"}}📊 工作量统计 ({{.Start.Format "01-02"}} ~ {{.End.Format "01-02"}})
+
+{{range .Workloads}}👤 {{.Name}}
+   提交: {{.CommitCount}} 次
+   新增: {{.Additions}} 行 | 删除: {{.Deletions}} 行
+   涉及仓库: {{.RepoCount}} 个
+{{.RecentCommits}}
+{{end}}{{end}}
+
+{{- define "search"}}🔍 混合搜索找到 {{.Total}} 条相关消息:
+
+{{range .Results}}[{{.Time.Format "01-02 15:04"}}] {{.Sender}} 在「{{.Chat}}」:
+{{.Content}}
+(相关度: {{printf "%.0f" .Percent}}%)
+
+{{end}}{{if .More}}...(还有 {{.More}} 条消息)
+{{end}}{{end}}
+
+{{- define "keyword_search"}}🔍 找到 {{.Total}} 条相关消息:
+
+{{range .Messages}}[{{.Time.Format "01-02 15:04"}}] {{.Sender}}: {{.Content}}
+{{end}}{{if .More}}...(还有 {{.More}} 条消息)
+{{end}}{{end}}
+
+{{- define "summary"}}📋 {{if .GroupName}}「{{.GroupName}}」{{end}}消息总结 ({{.Start.Format "01-02 15:04"}} ~ {{.End.Format "01-02 15:04"}})
+
+{{.Summary}}{{end}}
+`
+
+// builtinTemplates 解析后的内置模板
+var builtinTemplates = template.Must(template.New("answers").Parse(defaultAnswerTemplates))
+
+// AnswerTemplates 回复模板集合，nil 时使用内置模板
+type AnswerTemplates struct {
+	tmpl *template.Template
+}
+
+// LoadAnswerTemplates 加载回复模板：在内置模板基础上解析模板文件，文件中定义的同名模板覆盖内置模板
+// path 为空时只使用内置模板；加载失败时返回内置模板和错误
+func LoadAnswerTemplates(path string) (*AnswerTemplates, error) {
+	defaults := &AnswerTemplates{tmpl: builtinTemplates}
+	if path == "" {
+		return defaults, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return defaults, fmt.Errorf("read answer templates: %w", err)
+	}
+
+	tmpl, err := template.Must(builtinTemplates.Clone()).Parse(string(data))
+	if err != nil {
+		return defaults, fmt.Errorf("parse answer templates %s: %w", path, err)
+	}
+	return &AnswerTemplates{tmpl: tmpl}, nil
+}
+
+// Render 渲染指定模板，自定义模板渲染失败时回退到内置模板
+func (t *AnswerTemplates) Render(name string, data interface{}) string {
+	if t != nil && t.tmpl != builtinTemplates {
+		var buf bytes.Buffer
+		err := t.tmpl.ExecuteTemplate(&buf, name, data)
+		if err == nil {
+			return buf.String()
+		}
+		log.Printf("Failed to render answer template %s, using default: %v", name, err)
+	}
+
+	var buf bytes.Buffer
+	if err := builtinTemplates.ExecuteTemplate(&buf, name, data); err != nil {
+		log.Printf("Failed to render default answer template %s: %v", name, err)
+	}
+	return buf.String()
+}
+
+// workloadTemplateData 工作量模板数据
+type workloadTemplateData struct {
+	Start, End time.Time
+	Workloads  []workloadTemplateItem
+}
+
+// workloadTemplateItem 单个成员的工作量
+type workloadTemplateItem struct {
+	Name        string
+	CommitCount int
+	Additions   int
+	Deletions   int
+	RepoCount   int
+	// 最近提交（已格式化，没有提交时为空）
+	RecentCommits string
+}
+
+// searchTemplateData 混合搜索模板数据
+type searchTemplateData struct {
+	Total   int                  // 找到的消息总数
+	Results []searchTemplateItem // 展示的消息
+	More    int                  // 未展示的消息数
+}
+
+// searchTemplateItem 单条搜索结果
+type searchTemplateItem struct {
+	Time    time.Time
+	Sender  string
+	Chat    string
+	Content string  // 已截断
+	Percent float32 // 相关度百分比
+}
+
+// keywordSearchTemplateData 关键词搜索模板数据
+type keywordSearchTemplateData struct {
+	Total    int
+	Messages []keywordSearchTemplateItem
+	More     int
+}
+
+// keywordSearchTemplateItem 单条关键词搜索结果
+type keywordSearchTemplateItem struct {
+	Time    time.Time
+	Sender  string
+	Content string // 已截断
+}
+
+// summaryTemplateData 消息总结模板数据
+type summaryTemplateData struct {
+	GroupName  string // 指定群或"所有群"，当前群时为空
+	Start, End time.Time
+	Summary    string
+}
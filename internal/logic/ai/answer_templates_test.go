@@ -0,0 +1,144 @@
+package ai
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	sampleStart = time.Date(2024, 3, 4, 9, 0, 0, 0, time.Local)
+	sampleEnd   = time.Date(2024, 3, 8, 18, 30, 0, 0, time.Local)
+)
+
+// sampleTemplateData 各模板的示例数据
+func sampleTemplateData() map[string]interface{} {
+	searchResults := make([]searchTemplateItem, 10)
+	for i := range searchResults {
+		searchResults[i] = searchTemplateItem{Time: sampleStart, Sender: "张三", Chat: "研发群", Content: "登录超时已修复", Percent: 87.4}
+	}
+	return map[string]interface{}{
+		tmplWorkload: workloadTemplateData{Start: sampleStart, End: sampleEnd, Workloads: []workloadTemplateItem{
+			{Name: "张三", CommitCount: 12, Additions: 340, Deletions: 56, RepoCount: 2, RecentCommits: "   最近提交:\n   • [api] 修复登录超时 (03-05)\n"},
+			{Name: "李四", CommitCount: 3, Additions: 20, Deletions: 1, RepoCount: 1},
+		}},
+		tmplSemanticSearch: searchTemplateData{Total: 12, Results: searchResults, More: 2},
+		tmplKeywordSearch: keywordSearchTemplateData{Total: 1, Messages: []keywordSearchTemplateItem{
+			{Time: sampleEnd, Sender: "李四", Content: "支付回调已上线"},
+		}},
+		tmplSummary: summaryTemplateData{GroupName: "研发群", Start: sampleStart, End: sampleEnd, Summary: "1. 登录超时已修复"},
+	}
+}
+
+func TestDefaultAnswerTemplates(t *testing.T) {
+	data := sampleTemplateData()
+	searchItem := "[03-04 09:00] 张三 在「研发群」:\n登录超时已修复\n(相关度: 87%)\n\n"
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{tmplWorkload, "📊 工作量统计 (03-04 ~ 03-08)\n\n" +
+			"👤 张三\n   提交: 12 次\n   新增: 340 行 | 删除: 56 行\n   涉及仓库: 2 个\n   最近提交:\n   • [api] 修复登录超时 (03-05)\n\n" +
+			"👤 李四\n   提交: 3 次\n   新增: 20 行 | 删除: 1 行\n   涉及仓库: 1 个\n\n"},
+		{tmplSemanticSearch, "🔍 混合搜索找到 12 条相关消息:\n\n" + strings.Repeat(searchItem, 10) + "...(还有 2 条消息)\n"},
+		{tmplKeywordSearch, "🔍 找到 1 条相关消息:\n\n[03-08 18:30] 李四: 支付回调已上线\n"},
+		{tmplSummary, "📋 「研发群」消息总结 (03-04 09:00 ~ 03-08 18:30)\n\n1. 登录超时已修复"},
+	}
+
+	var templates *AnswerTemplates // nil 使用内置模板
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := templates.Render(tt.name, data[tt.name]); got != tt.want {
+				t.Errorf("Render(%s) =\n%q\nwant\n%q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSummaryTemplateWithoutGroup(t *testing.T) {
+	var templates *AnswerTemplates
+	got := templates.Render(tmplSummary, summaryTemplateData{Start: sampleStart, End: sampleEnd, Summary: "无"})
+	if got != "📋 消息总结 (03-04 09:00 ~ 03-08 18:30)\n\n无" {
+		t.Errorf("Unexpected summary header: %q", got)
+	}
+}
+
+func writeTemplateFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "answers.tmpl")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadAnswerTemplatesOverride(t *testing.T) {
+	path := writeTemplateFile(t, `{{define "summary"}}## {{.GroupName}} 周报
+{{.Summary}}{{end}}`)
+	templates, err := LoadAnswerTemplates(path)
+	if err != nil {
+		t.Fatalf("LoadAnswerTemplates() error: %v", err)
+	}
+
+	data := sampleTemplateData()
+	if got := templates.Render(tmplSummary, data[tmplSummary]); got != "## 研发群 周报\n1. 登录超时已修复" {
+		t.Errorf("Override not applied: %q", got)
+	}
+	// 未覆盖的模板继续使用内置样式
+	var defaults *AnswerTemplates
+	if got, want := templates.Render(tmplKeywordSearch, data[tmplKeywordSearch]), defaults.Render(tmplKeywordSearch, data[tmplKeywordSearch]); got != want {
+		t.Errorf("Non-overridden template changed: %q", got)
+	}
+}
+
+func TestLoadAnswerTemplatesErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+	}{
+		{"文件不存在", filepath.Join(t.TempDir(), "missing.tmpl")},
+		{"语法错误", writeTemplateFile(t, `{{define "summary"}}{{.Summary}`)},
+	}
+
+	data := sampleTemplateData()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			templates, err := LoadAnswerTemplates(tt.path)
+			if err == nil {
+				t.Fatal("Expected error")
+			}
+			// 加载失败时仍可使用内置模板
+			if got := templates.Render(tmplSummary, data[tmplSummary]); !strings.HasPrefix(got, "📋 「研发群」消息总结") {
+				t.Errorf("Expected default template after load error, got %q", got)
+			}
+		})
+	}
+}
+
+func TestRenderFallsBackOnExecError(t *testing.T) {
+	templates, err := LoadAnswerTemplates(writeTemplateFile(t, `{{define "summary"}}{{.Missing}}{{end}}`))
+	if err != nil {
+		t.Fatalf("LoadAnswerTemplates() error: %v", err)
+	}
+	data := sampleTemplateData()
+	if got := templates.Render(tmplSummary, data[tmplSummary]); !strings.HasPrefix(got, "📋 「研发群」消息总结") {
+		t.Errorf("Expected fallback to default template, got %q", got)
+	}
+}
+
+func TestExampleAnswerTemplatesMatchDefaults(t *testing.T) {
+	templates, err := LoadAnswerTemplates("../../../etc/answer_templates.example.tmpl")
+	if err != nil {
+		t.Fatalf("Example templates should parse: %v", err)
+	}
+
+	var defaults *AnswerTemplates
+	for name, data := range sampleTemplateData() {
+		if got, want := templates.Render(name, data), defaults.Render(name, data); got != want {
+			t.Errorf("Example template %s differs from default:\n%q\nwant\n%q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package ai
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"team-assistant/internal/config"
+)
+
+// renderBitableFields 按配置的字段列表渲染 Bitable 记录
+// specs 为空时按列名排序展示所有非空字段，便于接入新表格时先看到全部内容再决定展示哪些
+func renderBitableFields(fields map[string]interface{}, specs []config.BitableFieldConfig) string {
+	var sb strings.Builder
+
+	if len(specs) == 0 {
+		keys := make([]string, 0, len(fields))
+		for k := range fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			if v := getFieldString(fields, k); v != "" {
+				sb.WriteString(fmt.Sprintf("• %s: %s\n", k, v))
+			}
+		}
+		return sb.String()
+	}
+
+	for _, spec := range specs {
+		columns := spec.Columns
+		if spec.Column != "" {
+			columns = append([]string{spec.Column}, columns...)
+		}
+		if len(columns) == 0 {
+			continue
+		}
+
+		var values []string
+		for _, col := range columns {
+			if v := getFieldString(fields, col); v != "" {
+				values = append(values, v)
+			}
+		}
+		if len(values) == 0 {
+			continue
+		}
+
+		label := spec.Label
+		if label == "" {
+			label = columns[0]
+		}
+		sb.WriteString(fmt.Sprintf("• %s: %s\n", label, strings.Join(values, ", ")))
+	}
+	return sb.String()
+}
+
+// 站点字段提问中字段名之前/之后的修饰词
+var (
+	siteFieldLeading = []string{"对应的", "对应什么", "对应", "站点的", "的"}
+	siteFieldEnds    = []string{"是什么", "是多少", "是啥", "是", "多少", "在哪", "呢", "吗", "?", "？", "。", "，", ","}
+	// 泛指整个站点的词，不算具体字段
+	siteGenericFields = map[string]bool{"": true, "信息": true, "详情": true, "资料": true, "站点": true, "站点信息": true, "哪个站点": true, "什么站点": true}
+)
+
+// extractSiteField 从问题中提取询问的站点字段（如 "by4 的后台地址" → "后台地址"），询问整体信息时返回空字符串
+// key 为问题中的站点前缀或站点ID，字段名取 key 之后的部分
+func extractSiteField(query, key string) string {
+	if key == "" {
+		return ""
+	}
+	idx := strings.Index(strings.ToLower(query), strings.ToLower(key))
+	if idx < 0 {
+		return ""
+	}
+	rest := strings.TrimSpace(query[idx+len(key):])
+	for _, p := range siteFieldLeading {
+		if strings.HasPrefix(rest, p) {
+			rest = strings.TrimSpace(strings.TrimPrefix(rest, p))
+			break
+		}
+	}
+
+	end := len(rest)
+	for _, e := range siteFieldEnds {
+		if i := strings.Index(rest, e); i >= 0 && i < end {
+			end = i
+		}
+	}
+	field := strings.TrimSpace(rest[:end])
+	if siteGenericFields[field] {
+		return ""
+	}
+	return field
+}
+
+// findBitableField 在记录中查找询问的字段：先按配置的展示名/列名，再按表格列名；先完全匹配，再包含匹配
+// 返回展示名和值（多列合并），找不到字段时 ok 为 false
+func findBitableField(fields map[string]interface{}, specs []config.BitableFieldConfig, name string) (label, value string, ok bool) {
+	type candidate struct {
+		label   string
+		columns []string
+	}
+	var candidates []candidate
+	for _, spec := range specs {
+		columns := spec.Columns
+		if spec.Column != "" {
+			columns = append([]string{spec.Column}, columns...)
+		}
+		if len(columns) == 0 {
+			continue
+		}
+		l := spec.Label
+		if l == "" {
+			l = columns[0]
+		}
+		candidates = append(candidates, candidate{label: l, columns: columns})
+	}
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		candidates = append(candidates, candidate{label: k, columns: []string{k}})
+	}
+
+	normalize := func(s string) string { return strings.ToLower(strings.ReplaceAll(s, " ", "")) }
+	want := normalize(name)
+	if want == "" {
+		return "", "", false
+	}
+	matches := func(c candidate, match func(n string) bool) bool {
+		if match(normalize(c.label)) {
+			return true
+		}
+		for _, col := range c.columns {
+			if match(normalize(col)) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, match := range []func(n string) bool{
+		func(n string) bool { return n == want },
+		func(n string) bool { return n != "" && (strings.Contains(n, want) || strings.Contains(want, n)) },
+	} {
+		for _, c := range candidates {
+			if !matches(c, match) {
+				continue
+			}
+			var values []string
+			for _, col := range c.columns {
+				if v := getFieldString(fields, col); v != "" {
+					values = append(values, v)
+				}
+			}
+			return c.label, strings.Join(values, ", "), true
+		}
+	}
+	return "", "", false
+}
+
+// formatSiteRecord 格式化站点记录：field 为空时展示完整信息，否则只回答该字段；记录中没有该字段时展示完整信息
+func formatSiteRecord(fields map[string]interface{}, specs []config.BitableFieldConfig, prefix, field string) string {
+	site := strings.ToUpper(prefix)
+	var sb strings.Builder
+	if field != "" {
+		label, value, ok := findBitableField(fields, specs, field)
+		switch {
+		case ok && value == "":
+			return fmt.Sprintf("📍 站点「%s」的%s未填写。", site, label)
+		case ok:
+			return fmt.Sprintf("📍 站点「%s」的%s：%s", site, label, value)
+		}
+		sb.WriteString(fmt.Sprintf("未找到字段「%s」，以下是站点的完整信息。\n", field))
+	}
+	sb.WriteString(fmt.Sprintf("📍 站点「%s」信息：\n\n", site))
+	sb.WriteString(renderBitableFields(fields, specs))
+	return sb.String()
+}
@@ -0,0 +1,159 @@
+package ai
+
+import (
+	"testing"
+
+	"team-assistant/internal/config"
+)
+
+func TestRenderBitableFields(t *testing.T) {
+	fields := map[string]interface{}{
+		"站点ID":  float64(1024),
+		"站点前缀":  "l08",
+		"国家":    []interface{}{"印尼"},
+		"状态":    "运营中",
+		"前台域名1": map[string]interface{}{"text": "a.example.com", "link": "https://a.example.com"},
+		"前台域名2": "b.example.com",
+		"多域名":   "",
+		"内部备注":  "不展示",
+		"注意":    "",
+	}
+
+	tests := []struct {
+		name  string
+		specs []config.BitableFieldConfig
+		want  string
+	}{
+		{
+			name: "按配置顺序和标签展示",
+			specs: []config.BitableFieldConfig{
+				{Column: "站点前缀", Label: "前缀"},
+				{Column: "站点ID"},
+				{Column: "国家"},
+			},
+			want: "• 前缀: l08\n• 站点ID: 1024\n• 国家: 印尼\n",
+		},
+		{
+			name: "多列合并并跳过空值",
+			specs: []config.BitableFieldConfig{
+				{Columns: []string{"前台域名1", "前台域名2", "前台域名3", "多域名"}, Label: "前台域名"},
+			},
+			want: "• 前台域名: a.example.com, b.example.com\n",
+		},
+		{
+			name: "空字段不展示",
+			specs: []config.BitableFieldConfig{
+				{Column: "注意"},
+				{Column: "不存在的列"},
+				{Label: "无列名"},
+			},
+			want: "",
+		},
+		{
+			name:  "未配置时展示所有非空字段",
+			specs: nil,
+			want: "• 内部备注: 不展示\n• 前台域名1: a.example.com\n• 前台域名2: b.example.com\n" +
+				"• 国家: 印尼\n• 状态: 运营中\n• 站点ID: 1024\n• 站点前缀: l08\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderBitableFields(fields, tt.specs); got != tt.want {
+				t.Errorf("renderBitableFields() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractSiteField(t *testing.T) {
+	tests := []struct {
+		query string
+		key   string
+		want  string
+	}{
+		{"by4 的后台地址", "by4", "后台地址"},
+		{"BY4的后台地址是什么？", "by4", "后台地址"},
+		{"l08的状态", "l08", "状态"},
+		{"3040的站点前缀是？", "3040", "站点前缀"},
+		{"站点ID 2888对应什么前缀？", "2888", "前缀"},
+		{"l08是什么站点？", "l08", ""},
+		{"查一下p03站点", "p03", ""},
+		{"by4的信息", "by4", ""},
+		{"3040是哪个站点？", "3040", ""},
+		{"没有站点", "l08", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			if got := extractSiteField(tt.query, tt.key); got != tt.want {
+				t.Errorf("extractSiteField(%q, %q) = %q, want %q", tt.query, tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindBitableField(t *testing.T) {
+	fields := map[string]interface{}{
+		"站点前缀":  "by4",
+		"后台地址":  map[string]interface{}{"text": "https://admin.by4.example.com"},
+		"前台域名1": "a.example.com",
+		"前台域名2": "b.example.com",
+		"状态":    "运营中",
+		"备注":    "",
+	}
+	specs := []config.BitableFieldConfig{
+		{Columns: []string{"前台域名1", "前台域名2"}, Label: "前台域名"},
+		{Column: "站点前缀", Label: "前缀"},
+	}
+
+	tests := []struct {
+		name      string
+		field     string
+		wantLabel string
+		wantValue string
+		wantOK    bool
+	}{
+		{"表格列名完全匹配", "后台地址", "后台地址", "https://admin.by4.example.com", true},
+		{"配置的展示名合并多列", "前台域名", "前台域名", "a.example.com, b.example.com", true},
+		{"按配置的列名匹配", "站点前缀", "前缀", "by4", true},
+		{"包含匹配", "域名", "前台域名", "a.example.com, b.example.com", true},
+		{"忽略空格和大小写", "后台 地址", "后台地址", "https://admin.by4.example.com", true},
+		{"字段为空", "备注", "备注", "", true},
+		{"没有该字段", "负责人", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			label, value, ok := findBitableField(fields, specs, tt.field)
+			if label != tt.wantLabel || value != tt.wantValue || ok != tt.wantOK {
+				t.Errorf("findBitableField(%q) = (%q, %q, %v), want (%q, %q, %v)",
+					tt.field, label, value, ok, tt.wantLabel, tt.wantValue, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestFormatSiteRecord(t *testing.T) {
+	fields := map[string]interface{}{"后台地址": "https://admin.by4.example.com", "状态": "运营中", "备注": ""}
+	full := "📍 站点「BY4」信息：\n\n• 后台地址: https://admin.by4.example.com\n• 状态: 运营中\n"
+
+	tests := []struct {
+		name  string
+		field string
+		want  string
+	}{
+		{"只返回询问的字段", "后台地址", "📍 站点「BY4」的后台地址：https://admin.by4.example.com"},
+		{"字段未填写", "备注", "📍 站点「BY4」的备注未填写。"},
+		{"未指定字段返回完整信息", "", full},
+		{"字段不存在时返回完整信息", "负责人", "未找到字段「负责人」，以下是站点的完整信息。\n" + full},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatSiteRecord(fields, nil, "by4", tt.field); got != tt.want {
+				t.Errorf("formatSiteRecord(%q) =\n%q\nwant\n%q", tt.field, got, tt.want)
+			}
+		})
+	}
+}
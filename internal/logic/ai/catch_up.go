@@ -0,0 +1,99 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"team-assistant/internal/model"
+)
+
+// catchUpMaxMessages 补课摘要最多总结的消息数，超出时只总结最近的消息并在回复中说明
+const catchUpMaxMessages = 200
+
+// ======================== 个人补课摘要 ========================
+
+// catchUpKeywords "我不在的时候发生了什么" 类问题的关键词
+var catchUpKeywords = []string{"我不在的时候", "我不在时", "我错过了什么", "我错过了啥", "错过了哪些", "我离开后"}
+
+// IsCatchUpQuery 判断是否是"我不在的时候群里发生了啥"类问题
+func IsCatchUpQuery(query string) bool {
+	query = strings.TrimSpace(query)
+	if query == "补课" {
+		return true
+	}
+	for _, kw := range catchUpKeywords {
+		if strings.Contains(query, kw) {
+			return true
+		}
+	}
+	return false
+}
+
+// SummarizeSince 总结群内从 start 到 end 的消息（用于个人补课摘要）
+// hasState 为 false 表示用户没有已读记录，回复中会说明使用了默认时间范围
+func (hp *HybridProcessor) SummarizeSince(ctx context.Context, chatID string, start, end time.Time, hasState bool) (string, error) {
+	if hp.llmClient == nil {
+		return "", fmt.Errorf("LLM client not initialized")
+	}
+
+	header := fmt.Sprintf("📬 你上次查看是 %s，此后群里的动态：", start.Format("01-02 15:04"))
+	if !hasState {
+		header = fmt.Sprintf("📬 没有找到你的查看记录，以下是 %s 以来群里的动态：", start.Format("01-02 15:04"))
+	}
+
+	if !start.Before(end) {
+		return header + "\n\n暂时没有新消息。", nil
+	}
+
+	// 多取一条判断是否超出上限
+	messages, err := hp.svcCtx.MessageModel.GetMessagesByDateRange(ctx, chatID, start, end, catchUpMaxMessages+1)
+	if err != nil {
+		log.Printf("Failed to get messages for catch-up: %v", err)
+		return "获取消息失败，请稍后重试。", err
+	}
+	messages, truncated := trimCatchUpMessages(messages, catchUpMaxMessages)
+	if truncated {
+		header += fmt.Sprintf("\n（消息较多，只总结了 %s 以来最近的 %d 条，更早的消息可以指定时间范围提问）",
+			messages[len(messages)-1].CreatedAt.Format("01-02 15:04"), len(messages))
+	}
+
+	if len(messages) == 0 {
+		return header + "\n\n暂时没有新消息。", nil
+	}
+
+	// 按时间正序排列，方便 LLM 理解上下文
+	forwardMode := hp.svcCtx.Config.LLM.SummaryForwardMode
+	var msgTexts []string
+	for i := len(messages) - 1; i >= 0; i-- {
+		msg := messages[i]
+		if !msg.Content.Valid || msg.Content.String == "" {
+			continue
+		}
+		if line, ok := hp.summaryLine(ctx, msg, "01-02 15:04", forwardMode); ok {
+			msgTexts = append(msgTexts, line)
+		}
+	}
+
+	if len(msgTexts) == 0 {
+		return header + "\n\n暂时没有新消息。", nil
+	}
+
+	summary, err := hp.llmClient.SummarizeMessages(ctx, msgTexts)
+	if err != nil {
+		log.Printf("LLM catch-up summarize error: %v", err)
+		return "总结消息失败，请稍后重试。", err
+	}
+
+	return fmt.Sprintf("%s（共 %d 条消息）\n\n%s", header, len(msgTexts), summary), nil
+}
+
+// trimCatchUpMessages 保留最近的 max 条消息（messages 按时间倒序），truncated 表示丢弃了更早的消息
+func trimCatchUpMessages(messages []*model.ChatMessage, max int) (kept []*model.ChatMessage, truncated bool) {
+	if len(messages) <= max {
+		return messages, false
+	}
+	return messages[:max], true
+}
@@ -0,0 +1,134 @@
+package ai
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"team-assistant/pkg/llm"
+)
+
+// ======================== 问答上下文组装（按相关度填充长度预算） ========================
+
+// 问答上下文默认长度（字节）
+const (
+	defaultQAContextLength    = 8000
+	statisticalContextLength  = 15000 // 统计类查询需要更多消息，至少使用该长度
+	contextOmittedNotePattern = "...(还有 %d 条相关度较低的消息未列出)"
+)
+
+// 传给 LLM 的上下文 token 上限（估算值，见 llm.TruncateToTokens）
+const (
+	defaultContextMaxTokens = 6000
+	weeklySummaryMaxTokens  = 2000 // 群历程逐周调用总结，单周的上下文更短
+	contextTruncatedNote    = "\n...(内容已截断)"
+)
+
+// scoredMessage 问答检索到的候选消息
+type scoredMessage struct {
+	content   string
+	formatted string
+	sender    string
+	score     int     // 匹配的关键词数量，越多越相关
+	relevance float32 // 混合检索分数（仅语义检索到的消息有），关键词匹配数相同时参考
+	timestamp time.Time
+}
+
+// sortScoredMessages 按相关度排序：关键词匹配数 > 混合检索分数 > 时间（新的在前）
+func sortScoredMessages(msgs []*scoredMessage) {
+	sort.SliceStable(msgs, func(i, j int) bool {
+		if msgs[i].score != msgs[j].score {
+			return msgs[i].score > msgs[j].score
+		}
+		if msgs[i].relevance != msgs[j].relevance {
+			return msgs[i].relevance > msgs[j].relevance
+		}
+		return msgs[i].timestamp.After(msgs[j].timestamp)
+	})
+}
+
+// qaContextLength 问答上下文的长度预算，未配置时使用默认值；统计类查询至少使用 statisticalContextLength
+func (hp *HybridProcessor) qaContextLength(statistical bool) int {
+	budget := defaultQAContextLength
+	if hp.svcCtx != nil && hp.svcCtx.Config.LLM.QAContextLength > 0 {
+		budget = hp.svcCtx.Config.LLM.QAContextLength
+	}
+	if statistical && budget < statisticalContextLength {
+		budget = statisticalContextLength
+	}
+	return budget
+}
+
+// contextMaxTokens 传给 LLM 的上下文 token 上限，未配置时使用默认值
+func (hp *HybridProcessor) contextMaxTokens() int {
+	if hp.svcCtx != nil && hp.svcCtx.Config.LLM.ContextMaxTokens > 0 {
+		return hp.svcCtx.Config.LLM.ContextMaxTokens
+	}
+	return defaultContextMaxTokens
+}
+
+// truncateContext 按 token 上限截断上下文（不会截断在多字节字符中间），截断时在末尾注明
+func truncateContext(context string, maxTokens int) string {
+	truncated := llm.TruncateToTokens(context, maxTokens)
+	if len(truncated) < len(context) {
+		return truncated + contextTruncatedNote
+	}
+	return context
+}
+
+// fillContextBudget 按传入顺序（相关度从高到低）逐条加入完整消息，直到长度预算用完
+// 放不下的消息跳过（后面更短的消息仍可加入），被截断的只会是相关度最低的消息；
+// 第一条消息本身超过预算时截取其开头，保证至少有一条证据。返回加入的消息和未加入的条数
+func fillContextBudget(msgs []*scoredMessage, budget int) (included []*scoredMessage, dropped int) {
+	used := 0
+	for _, m := range msgs {
+		n := len(m.formatted)
+		if used > 0 {
+			n++ // 换行
+		}
+		if used+n > budget {
+			if len(included) == 0 {
+				truncated := *m
+				truncated.formatted = truncateBytes(m.formatted, budget)
+				included = append(included, &truncated)
+				used = budget
+				continue
+			}
+			dropped++
+			continue
+		}
+		included = append(included, m)
+		used += n
+	}
+	return included, dropped
+}
+
+// truncateBytes 截取前 maxBytes 字节，不截断多字节字符
+func truncateBytes(s string, maxBytes int) string {
+	if len(s) <= maxBytes {
+		return s
+	}
+	cut := 0
+	for i, r := range s {
+		if i+utf8.RuneLen(r) > maxBytes {
+			break
+		}
+		cut = i + utf8.RuneLen(r)
+	}
+	return s[:cut]
+}
+
+// buildQAContext 拼接加入预算的消息，有未加入的消息时在末尾注明条数
+func buildQAContext(included []*scoredMessage, dropped int) string {
+	lines := make([]string, len(included))
+	for i, m := range included {
+		lines[i] = m.formatted
+	}
+	context := strings.Join(lines, "\n")
+	if dropped > 0 {
+		context += "\n" + fmt.Sprintf(contextOmittedNotePattern, dropped)
+	}
+	return context
+}
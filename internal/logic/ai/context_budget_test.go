@@ -0,0 +1,230 @@
+package ai
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"team-assistant/internal/model"
+	"team-assistant/internal/svc"
+	"team-assistant/pkg/llm"
+)
+
+func scored(formatted string, score int, relevance float32, minutesAgo int) *scoredMessage {
+	return &scoredMessage{
+		content:   formatted,
+		formatted: formatted,
+		score:     score,
+		relevance: relevance,
+		timestamp: time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC).Add(-time.Duration(minutesAgo) * time.Minute),
+	}
+}
+
+func formattedOf(msgs []*scoredMessage) []string {
+	out := make([]string, len(msgs))
+	for i, m := range msgs {
+		out[i] = m.formatted
+	}
+	return out
+}
+
+func TestSortScoredMessages(t *testing.T) {
+	msgs := []*scoredMessage{
+		scored("旧的单关键词", 1, 0, 60),
+		scored("语义低分双关键词", 2, 0.3, 30),
+		scored("新的单关键词", 1, 0, 5),
+		scored("语义高分双关键词", 2, 0.9, 90),
+		scored("单关键词有语义分", 1, 0.5, 120),
+	}
+	sortScoredMessages(msgs)
+
+	want := []string{"语义高分双关键词", "语义低分双关键词", "单关键词有语义分", "新的单关键词", "旧的单关键词"}
+	if got := formattedOf(msgs); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("sortScoredMessages() = %v, want %v", got, want)
+	}
+}
+
+func TestFillContextBudget(t *testing.T) {
+	a := scored("aaaaaaaaaa", 3, 0, 0)           // 10 字节
+	b := scored("bbbbbbbbbbbbbbbbbbbb", 2, 0, 0) // 20 字节
+	c := scored("cccc", 1, 0, 0)                 // 4 字节
+
+	tests := []struct {
+		name        string
+		msgs        []*scoredMessage
+		budget      int
+		want        []string
+		wantDropped int
+	}{
+		{"全部放得下", []*scoredMessage{a, b, c}, 100, []string{a.formatted, b.formatted, c.formatted}, 0},
+		{"刚好用完预算", []*scoredMessage{a, b, c}, 10 + 1 + 20 + 1 + 4, []string{a.formatted, b.formatted, c.formatted}, 0},
+		{"放不下的跳过后面短的仍加入", []*scoredMessage{a, b, c}, 20, []string{a.formatted, c.formatted}, 1},
+		{"只放得下最相关的", []*scoredMessage{a, b, c}, 12, []string{a.formatted}, 2},
+		{"第一条超长截取开头", []*scoredMessage{b, a}, 8, []string{"bbbbbbbb"}, 1},
+		{"没有消息", nil, 100, nil, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			included, dropped := fillContextBudget(tt.msgs, tt.budget)
+			if got := formattedOf(included); strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("fillContextBudget() included = %v, want %v", got, tt.want)
+			}
+			if dropped != tt.wantDropped {
+				t.Errorf("fillContextBudget() dropped = %d, want %d", dropped, tt.wantDropped)
+			}
+		})
+	}
+}
+
+func TestFillContextBudgetDoesNotMutateOversized(t *testing.T) {
+	long := scored("很长的第一条消息", 1, 0, 0)
+	included, _ := fillContextBudget([]*scoredMessage{long}, 7)
+	if len(included) != 1 || included[0].formatted != "很长" {
+		t.Fatalf("fillContextBudget() = %v, want truncated to whole runes", formattedOf(included))
+	}
+	if long.formatted != "很长的第一条消息" {
+		t.Errorf("Original message was modified: %q", long.formatted)
+	}
+}
+
+func TestBuildQAContext(t *testing.T) {
+	msgs := []*scoredMessage{scored("第一条", 2, 0, 0), scored("第二条", 1, 0, 0)}
+
+	if got := buildQAContext(msgs, 0); got != "第一条\n第二条" {
+		t.Errorf("buildQAContext(dropped=0) = %q", got)
+	}
+	if got := buildQAContext(msgs, 3); got != "第一条\n第二条\n...(还有 3 条相关度较低的消息未列出)" {
+		t.Errorf("buildQAContext(dropped=3) = %q", got)
+	}
+}
+
+func TestQAContextLength(t *testing.T) {
+	hp := &HybridProcessor{svcCtx: &svc.ServiceContext{}}
+	if got := hp.qaContextLength(false); got != defaultQAContextLength {
+		t.Errorf("qaContextLength(false) = %d, want default %d", got, defaultQAContextLength)
+	}
+	hp.svcCtx.Config.LLM.QAContextLength = 4000
+	if got := hp.qaContextLength(false); got != 4000 {
+		t.Errorf("qaContextLength(false) = %d, want 4000", got)
+	}
+	if got := hp.qaContextLength(true); got != statisticalContextLength {
+		t.Errorf("qaContextLength(true) = %d, want %d", got, statisticalContextLength)
+	}
+	hp.svcCtx.Config.LLM.QAContextLength = 20000
+	if got := hp.qaContextLength(true); got != 20000 {
+		t.Errorf("qaContextLength(true) = %d, want 20000", got)
+	}
+}
+
+func TestTruncateContext(t *testing.T) {
+	long := strings.Repeat("支付回调超时，张三已修复。", 500)
+
+	if got := truncateContext("登录超时", 100); got != "登录超时" {
+		t.Errorf("truncateContext() should keep short context, got %q", got)
+	}
+	got := truncateContext(long, 300)
+	if !utf8.ValidString(got) {
+		t.Fatalf("truncateContext() produced invalid UTF-8")
+	}
+	if !strings.HasSuffix(got, contextTruncatedNote) {
+		t.Errorf("Expected truncation note, got ...%q", got[len(got)-30:])
+	}
+	if tokens := llm.EstimateTokens(strings.TrimSuffix(got, contextTruncatedNote)); tokens > 300 {
+		t.Errorf("Truncated context has %d tokens, want <= 300", tokens)
+	}
+}
+
+func TestContextMaxTokens(t *testing.T) {
+	hp := &HybridProcessor{svcCtx: &svc.ServiceContext{}}
+	if got := hp.contextMaxTokens(); got != defaultContextMaxTokens {
+		t.Errorf("contextMaxTokens() = %d, want default %d", got, defaultContextMaxTokens)
+	}
+	hp.svcCtx.Config.LLM.ContextMaxTokens = 1000
+	if got := hp.contextMaxTokens(); got != 1000 {
+		t.Errorf("contextMaxTokens() = %d, want 1000", got)
+	}
+}
+
+// newPromptRecorder 模拟 LLM，记录每次请求的完整 prompt
+func newPromptRecorder(t *testing.T, reply string) (*llm.Client, *[]string) {
+	t.Helper()
+	var prompts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		prompt := ""
+		for _, m := range req.Messages {
+			prompt += m.Content
+		}
+		prompts = append(prompts, prompt)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]string{"role": "assistant", "content": reply}}},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return llm.NewClient("test-key", server.URL, "test-model"), &prompts
+}
+
+func TestContextTruncationKeepsValidUTF8(t *testing.T) {
+	// 内容超过 token 上限，且以英文字符结尾使汉字不与字节边界对齐（按字节截断会切在汉字中间）
+	content := strings.Repeat("登录超时的问题已经定位，", 40) + "x"
+	weekStart := time.Date(2024, 3, 4, 0, 0, 0, 0, time.Local)
+	messages := make([]*model.ChatMessage, 30)
+	for i := range messages {
+		messages[i] = &model.ChatMessage{
+			SenderName: sql.NullString{String: "张三", Valid: true},
+			Content:    sql.NullString{String: content, Valid: true},
+			CreatedAt:  weekStart.Add(time.Duration(i) * time.Minute),
+		}
+	}
+
+	tests := []struct {
+		name string
+		run  func(hp *HybridProcessor) error
+	}{
+		{"问答", func(hp *HybridProcessor) error {
+			_, err := hp.answerWithContext(context.Background(), "登录超时修好了吗", strings.Repeat(content+"\n", 60))
+			return err
+		}},
+		{"周总结", func(hp *HybridProcessor) error {
+			_, err := hp.summarizeWeekMessages(context.Background(), messages, weekStart, weekStart.AddDate(0, 0, 7), 100)
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, prompts := newPromptRecorder(t, `{"summary": "已定位"}`)
+			hp := &HybridProcessor{svcCtx: &svc.ServiceContext{}, llmClient: client}
+			hp.svcCtx.Config.LLM.ContextMaxTokens = 1500
+			if err := tt.run(hp); err != nil {
+				t.Fatal(err)
+			}
+			if len(*prompts) != 1 {
+				t.Fatalf("Expected 1 LLM request, got %d", len(*prompts))
+			}
+			prompt := (*prompts)[0]
+			// 无效的 UTF-8 在 JSON 编码时会变成 U+FFFD
+			if strings.ContainsRune(prompt, utf8.RuneError) {
+				t.Errorf("Prompt contains a broken character")
+			}
+			if !strings.Contains(prompt, "(内容已截断)") {
+				t.Errorf("Expected truncated context in prompt")
+			}
+			if tokens := llm.EstimateTokens(prompt); tokens > 1500+1000 {
+				t.Errorf("Prompt has %d estimated tokens, context should be capped to 1500", tokens)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+)
+
+// ===== 对话上下文持久化 =====
+
+const (
+	// conversationContextTTL 追问上下文的有效期
+	conversationContextTTL = 5 * time.Minute
+	// conversationStoreTimeout 单次读写 Redis 的超时，Redis 不可用时不拖慢回答
+	conversationStoreTimeout = 500 * time.Millisecond
+)
+
+// conversationStore 对话状态的持久化存储（Redis），进程重启后仍能继续追问和 Dify 多轮对话
+// 配置了存储时每次都从 Redis 读取（多实例部署时其他实例可能已更新），内存只在 Redis 不可用时兜底
+type conversationStore interface {
+	GetConversationID(ctx context.Context, userID string) (string, error)
+	SaveConversationID(ctx context.Context, userID, conversationID string, ttl time.Duration) error
+	DeleteConversation(ctx context.Context, userID string) error
+	GetContext(ctx context.Context, userID string) ([]byte, error)
+	SaveContext(ctx context.Context, userID string, data []byte, ttl time.Duration) error
+	DeleteContext(ctx context.Context, userID string) error
+}
+
+// loadContext 读取用户的追问上下文：有 Redis 时以 Redis 为准并刷新内存，Redis 读取失败时使用内存；过期返回 nil
+func (hp *HybridProcessor) loadContext(ctx context.Context, userID string) *ConversationContext {
+	var convCtx *ConversationContext
+	fromStore := false
+	if hp.conversations != nil {
+		convCtx, fromStore = hp.restoreContext(ctx, userID)
+	}
+	if !fromStore {
+		hp.mu.RLock()
+		convCtx = hp.contextMap[userID]
+		hp.mu.RUnlock()
+	}
+
+	if convCtx == nil || time.Since(convCtx.LastTimestamp) > conversationContextTTL {
+		hp.mu.Lock()
+		delete(hp.contextMap, userID)
+		hp.mu.Unlock()
+		return nil
+	}
+
+	if fromStore {
+		hp.mu.Lock()
+		hp.contextMap[userID] = convCtx
+		hp.mu.Unlock()
+	}
+	return convCtx
+}
+
+// restoreContext 从 Redis 读取追问上下文，ok 为 false 表示读取失败（此时调用方使用内存中的上下文）
+// 不存在或无法解析时返回 nil, true
+func (hp *HybridProcessor) restoreContext(ctx context.Context, userID string) (convCtx *ConversationContext, ok bool) {
+	ctx, cancel := context.WithTimeout(ctx, conversationStoreTimeout)
+	defer cancel()
+
+	data, err := hp.conversations.GetContext(ctx, userID)
+	if err != nil {
+		log.Printf("Failed to load conversation context for %s: %v", userID, err)
+		return nil, false
+	}
+	if data == nil {
+		return nil, true
+	}
+
+	if err := json.Unmarshal(data, &convCtx); err != nil {
+		log.Printf("Failed to decode conversation context for %s: %v", userID, err)
+		return nil, true
+	}
+	return convCtx, true
+}
+
+// storeContext 保存用户的追问上下文到内存和 Redis
+func (hp *HybridProcessor) storeContext(ctx context.Context, userID string, convCtx *ConversationContext) {
+	hp.mu.Lock()
+	hp.contextMap[userID] = convCtx
+	hp.mu.Unlock()
+
+	if hp.conversations == nil {
+		return
+	}
+	data, err := json.Marshal(convCtx)
+	if err != nil {
+		log.Printf("Failed to encode conversation context for %s: %v", userID, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, conversationStoreTimeout)
+	defer cancel()
+	if err := hp.conversations.SaveContext(ctx, userID, data, conversationContextTTL); err != nil {
+		log.Printf("Failed to save conversation context for %s: %v", userID, err)
+	}
+}
+
+// loadConversationID 读取用户的 Dify 对话 ID：有 Redis 时以 Redis 为准并刷新内存，Redis 读取失败时使用内存
+func (hp *HybridProcessor) loadConversationID(ctx context.Context, userID string) string {
+	if hp.conversations != nil {
+		storeCtx, cancel := context.WithTimeout(ctx, conversationStoreTimeout)
+		conversationID, err := hp.conversations.GetConversationID(storeCtx, userID)
+		cancel()
+		if err == nil {
+			hp.mu.Lock()
+			if conversationID != "" {
+				hp.conversationMap[userID] = conversationID
+			} else {
+				delete(hp.conversationMap, userID)
+			}
+			hp.mu.Unlock()
+			return conversationID
+		}
+		log.Printf("Failed to load Dify conversation ID for %s: %v", userID, err)
+	}
+
+	hp.mu.RLock()
+	defer hp.mu.RUnlock()
+	return hp.conversationMap[userID]
+}
+
+// storeConversationID 保存用户的 Dify 对话 ID 到内存和 Redis（使用 Redis 的默认保留时间）
+func (hp *HybridProcessor) storeConversationID(ctx context.Context, userID, conversationID string) {
+	hp.mu.Lock()
+	hp.conversationMap[userID] = conversationID
+	hp.mu.Unlock()
+
+	if hp.conversations == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, conversationStoreTimeout)
+	defer cancel()
+	if err := hp.conversations.SaveConversationID(ctx, userID, conversationID, 0); err != nil {
+		log.Printf("Failed to save Dify conversation ID for %s: %v", userID, err)
+	}
+}
+
+// clearStoredConversation 删除 Redis 中用户的对话 ID 和追问上下文
+func (hp *HybridProcessor) clearStoredConversation(userID string) {
+	if hp.conversations == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), conversationStoreTimeout)
+	defer cancel()
+	if err := hp.conversations.DeleteConversation(ctx, userID); err != nil {
+		log.Printf("Failed to delete Dify conversation ID for %s: %v", userID, err)
+	}
+	if err := hp.conversations.DeleteContext(ctx, userID); err != nil {
+		log.Printf("Failed to delete conversation context for %s: %v", userID, err)
+	}
+}
@@ -0,0 +1,190 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeConversationStore 内存实现的 conversationStore，模拟 Redis
+type fakeConversationStore struct {
+	conversationIDs map[string]string
+	contexts        map[string][]byte
+	ttls            map[string]time.Duration
+	err             error
+}
+
+func newFakeConversationStore() *fakeConversationStore {
+	return &fakeConversationStore{
+		conversationIDs: make(map[string]string),
+		contexts:        make(map[string][]byte),
+		ttls:            make(map[string]time.Duration),
+	}
+}
+
+func (s *fakeConversationStore) GetConversationID(ctx context.Context, userID string) (string, error) {
+	return s.conversationIDs[userID], s.err
+}
+
+func (s *fakeConversationStore) SaveConversationID(ctx context.Context, userID, conversationID string, ttl time.Duration) error {
+	s.conversationIDs[userID] = conversationID
+	return s.err
+}
+
+func (s *fakeConversationStore) DeleteConversation(ctx context.Context, userID string) error {
+	delete(s.conversationIDs, userID)
+	return s.err
+}
+
+func (s *fakeConversationStore) GetContext(ctx context.Context, userID string) ([]byte, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.contexts[userID], nil
+}
+
+func (s *fakeConversationStore) SaveContext(ctx context.Context, userID string, data []byte, ttl time.Duration) error {
+	s.contexts[userID] = data
+	s.ttls[userID] = ttl
+	return s.err
+}
+
+func (s *fakeConversationStore) DeleteContext(ctx context.Context, userID string) error {
+	delete(s.contexts, userID)
+	return s.err
+}
+
+// newStoreProcessor 模拟一次进程启动：内存缓存为空，共用同一个持久化存储
+func newStoreProcessor(store conversationStore) *HybridProcessor {
+	return &HybridProcessor{
+		conversationMap: make(map[string]string),
+		contextMap:      make(map[string]*ConversationContext),
+		conversations:   store,
+	}
+}
+
+func TestConversationContextSurvivesRestart(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeConversationStore()
+
+	before := newStoreProcessor(store)
+	before.saveContextWithAnswer(ctx, "ou_1", "登录超时谁在跟", "张三在跟进", nil, "oc_dev")
+	if store.ttls["ou_1"] != conversationContextTTL {
+		t.Errorf("Expected context saved with TTL %v, got %v", conversationContextTTL, store.ttls["ou_1"])
+	}
+
+	after := newStoreProcessor(store)
+	_, prev := after.getOrRestoreContext(ctx, "ou_1", "还有吗")
+	if prev == nil || prev.LastAnswer != "张三在跟进" || prev.LastChatID != "oc_dev" {
+		t.Fatalf("Expected context restored from store, got %+v", prev)
+	}
+	if _, ok := after.contextMap["ou_1"]; !ok {
+		t.Error("Expected restored context cached in memory")
+	}
+}
+
+func TestLoadContext(t *testing.T) {
+	tests := []struct {
+		name     string
+		saved    time.Time
+		storeErr error
+		wantNil  bool
+	}{
+		{"有效期内恢复", time.Now().Add(-time.Minute), nil, false},
+		{"超过5分钟视为过期", time.Now().Add(-10 * time.Minute), nil, true},
+		{"存储不可用时没有上下文", time.Now(), errors.New("redis: connection refused"), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFakeConversationStore()
+			newStoreProcessor(store).storeContext(context.Background(), "ou_1", &ConversationContext{
+				LastQuery:     "问题",
+				LastAnswer:    "回答",
+				LastTimestamp: tt.saved,
+			})
+			store.err = tt.storeErr
+
+			got := newStoreProcessor(store).loadContext(context.Background(), "ou_1")
+			if (got == nil) != tt.wantNil {
+				t.Errorf("loadContext() = %+v, wantNil %v", got, tt.wantNil)
+			}
+		})
+	}
+}
+
+func TestConversationIDSurvivesRestartAndClear(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeConversationStore()
+
+	newStoreProcessor(store).storeConversationID(ctx, "ou_1", "conv-123")
+
+	after := newStoreProcessor(store)
+	if got := after.loadConversationID(ctx, "ou_1"); got != "conv-123" {
+		t.Fatalf("loadConversationID() = %q, want conv-123", got)
+	}
+
+	after.saveContextWithAnswer(ctx, "ou_1", "问题", "回答", nil, "")
+	after.ClearConversation("ou_1")
+	if len(store.conversationIDs) != 0 || len(store.contexts) != 0 {
+		t.Errorf("Expected stored conversation cleared, got ids %v, contexts %d", store.conversationIDs, len(store.contexts))
+	}
+	if got := newStoreProcessor(store).loadContext(ctx, "ou_1"); got != nil {
+		t.Errorf("Expected no context after clear, got %+v", got)
+	}
+}
+
+func TestConversationStateSharedAcrossInstances(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeConversationStore()
+	a, b := newStoreProcessor(store), newStoreProcessor(store)
+
+	a.storeConversationID(ctx, "ou_1", "conv-1")
+	a.storeContext(ctx, "ou_1", &ConversationContext{LastAnswer: "旧回答", LastTimestamp: time.Now()})
+	if got := a.loadConversationID(ctx, "ou_1"); got != "conv-1" {
+		t.Fatalf("loadConversationID() = %q, want conv-1", got)
+	}
+
+	// 另一个实例写入了新的状态，本实例不应返回内存中的旧值
+	b.storeConversationID(ctx, "ou_1", "conv-2")
+	b.storeContext(ctx, "ou_1", &ConversationContext{LastAnswer: "新回答", LastTimestamp: time.Now()})
+	if got := a.loadConversationID(ctx, "ou_1"); got != "conv-2" {
+		t.Errorf("loadConversationID() = %q, want conv-2", got)
+	}
+	if got := a.loadContext(ctx, "ou_1"); got == nil || got.LastAnswer != "新回答" {
+		t.Errorf("Expected newer context from store, got %+v", got)
+	}
+
+	// 另一个实例清空了对话
+	b.ClearConversation("ou_1")
+	if got := a.loadConversationID(ctx, "ou_1"); got != "" {
+		t.Errorf("loadConversationID() = %q after clear, want empty", got)
+	}
+	if got := a.loadContext(ctx, "ou_1"); got != nil {
+		t.Errorf("Expected no context after clear, got %+v", got)
+	}
+
+	// Redis 不可用时使用内存中的状态
+	a.storeConversationID(ctx, "ou_1", "conv-3")
+	a.storeContext(ctx, "ou_1", &ConversationContext{LastAnswer: "本地回答", LastTimestamp: time.Now()})
+	store.err = errors.New("redis: connection refused")
+	if got := a.loadConversationID(ctx, "ou_1"); got != "conv-3" {
+		t.Errorf("loadConversationID() = %q with store down, want conv-3", got)
+	}
+	if got := a.loadContext(ctx, "ou_1"); got == nil || got.LastAnswer != "本地回答" {
+		t.Errorf("Expected in-memory context with store down, got %+v", got)
+	}
+}
+
+func TestConversationWithoutStore(t *testing.T) {
+	ctx := context.Background()
+	hp := newStoreProcessor(nil)
+	hp.saveContextWithAnswer(ctx, "ou_1", "问题", "回答", nil, "")
+	if got := hp.loadContext(ctx, "ou_1"); got == nil || got.LastAnswer != "回答" {
+		t.Errorf("Expected in-memory context without store, got %+v", got)
+	}
+	if got := hp.loadConversationID(ctx, "ou_1"); got != "" {
+		t.Errorf("loadConversationID() = %q, want empty", got)
+	}
+}
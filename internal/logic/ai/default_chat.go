@@ -0,0 +1,77 @@
+package ai
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+
+	"team-assistant/internal/model"
+)
+
+// ===== 私聊默认群 =====
+
+// defaultChatStore 用户默认群的读取接口
+type defaultChatStore interface {
+	Get(ctx context.Context, userID string) (*model.UserDefaultChat, error)
+}
+
+// allGroupsWords 明确要求搜索所有群的说法，出现时不使用默认群
+var allGroupsWords = []string{"所有群", "全部群", "每个群", "各个群", "各群"}
+
+// isAllGroupsScope 判断是否明确要求搜索所有群
+func isAllGroupsScope(s string) bool {
+	for _, w := range allGroupsWords {
+		if strings.Contains(s, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultChatKey context 中保存私聊默认群的 key
+type defaultChatKey struct{}
+
+// withDefaultChat 在 context 中记录本次私聊查询使用的默认群
+func withDefaultChat(ctx context.Context, c *model.UserDefaultChat) context.Context {
+	if c == nil || c.ChatID == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, defaultChatKey{}, c)
+}
+
+// defaultChatFrom 获取本次查询使用的默认群，未设置时返回 nil
+func defaultChatFrom(ctx context.Context) *model.UserDefaultChat {
+	c, _ := ctx.Value(defaultChatKey{}).(*model.UserDefaultChat)
+	return c
+}
+
+// applyDefaultChat 私聊时加载用户的默认群，查询中明确要求所有群时不加载
+func (hp *HybridProcessor) applyDefaultChat(ctx context.Context, userID, query string) context.Context {
+	if hp.defaultChats == nil || !isPrivateChat(ctx, userID) || isAllGroupsScope(query) {
+		return ctx
+	}
+	c, err := hp.defaultChats.Get(ctx, userID)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Failed to load default chat for %s: %v", userID, err)
+		}
+		return ctx
+	}
+	return withDefaultChat(ctx, c)
+}
+
+// privateScopeChatID 私聊检索范围：明确指定的群 > 默认群 > 所有群（返回空字符串）
+// targetID 为按 targetGroup 找到的群ID；指定了 "所有群" 或找不到的群时不使用默认群
+func privateScopeChatID(targetGroup, targetID string, defaultChat *model.UserDefaultChat) string {
+	switch {
+	case targetID != "":
+		return targetID
+	case targetGroup != "":
+		return ""
+	case defaultChat != nil:
+		return defaultChat.ChatID
+	}
+	return ""
+}
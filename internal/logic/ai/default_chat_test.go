@@ -0,0 +1,86 @@
+package ai
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"team-assistant/internal/model"
+)
+
+// fakeDefaultChatStore 按用户返回固定的默认群
+type fakeDefaultChatStore map[string]*model.UserDefaultChat
+
+func (f fakeDefaultChatStore) Get(ctx context.Context, userID string) (*model.UserDefaultChat, error) {
+	if c, ok := f[userID]; ok {
+		return c, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func TestPrivateScopeChatID(t *testing.T) {
+	def := &model.UserDefaultChat{ChatID: "oc_default", ChatName: "研发群"}
+
+	tests := []struct {
+		name        string
+		targetGroup string
+		targetID    string
+		defaultChat *model.UserDefaultChat
+		want        string
+	}{
+		{"指定群优先于默认群", "印尼群", "oc_id", def, "oc_id"},
+		{"未指定群使用默认群", "", "", def, "oc_default"},
+		{"指定所有群不使用默认群", "所有群", "", def, ""},
+		{"指定的群找不到时不使用默认群", "不存在的群", "", def, ""},
+		{"未设置默认群搜索所有群", "", "", nil, ""},
+		{"未设置默认群时指定群", "印尼群", "oc_id", nil, "oc_id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := privateScopeChatID(tt.targetGroup, tt.targetID, tt.defaultChat); got != tt.want {
+				t.Errorf("privateScopeChatID(%q, %q) = %q, want %q", tt.targetGroup, tt.targetID, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyDefaultChat(t *testing.T) {
+	hp := &HybridProcessor{defaultChats: fakeDefaultChatStore{
+		"ou_alice": {UserID: "ou_alice", ChatID: "oc_dev", ChatName: "研发群"},
+	}}
+
+	tests := []struct {
+		name     string
+		chatID   string
+		chatType string
+		query    string
+		want     string
+	}{
+		{"私聊使用默认群", "ou_alice", "p2p", "今天讨论了什么", "oc_dev"},
+		{"提到所有群时不使用默认群", "ou_alice", "p2p", "所有群今天讨论了什么", ""},
+		{"未设置默认群", "ou_bob", "p2p", "今天讨论了什么", ""},
+		{"群聊不使用默认群", "ou_alice", "group", "今天讨论了什么", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := hp.applyDefaultChat(withChatType(context.Background(), tt.chatType), tt.chatID, tt.query)
+			got := ""
+			if c := defaultChatFrom(ctx); c != nil {
+				got = c.ChatID
+			}
+			if got != tt.want {
+				t.Errorf("default chat = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyDefaultChatWithoutStore(t *testing.T) {
+	hp := &HybridProcessor{}
+	ctx := hp.applyDefaultChat(withChatType(context.Background(), "p2p"), "ou_alice", "今天讨论了什么")
+	if c := defaultChatFrom(ctx); c != nil {
+		t.Errorf("Expected no default chat without store, got %+v", c)
+	}
+}
@@ -0,0 +1,240 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"team-assistant/internal/service"
+)
+
+// ======================== 重复问题检测（"有没有人问过X"，不经过 LLM） ========================
+
+// 重复问题检测的展示限制
+const (
+	priorDiscussionLimit = 5  // 最多展示的历史讨论数
+	priorAnswerLimit     = 3  // 最多展示的 AI 历史回答数
+	priorSnippet         = 80 // 每条结果展示的内容长度（字符）
+)
+
+// priorQuestionPrefixes "有没有人问过X" 之类的问法前缀
+var priorQuestionPrefixes = []string{
+	"之前有没有人问过", "以前有没有人问过", "有没有人问过", "有人问过",
+	"之前有没有人讨论过", "以前有没有人讨论过", "有没有人讨论过", "有人讨论过",
+	"有没有人提过", "有人提过", "之前讨论过", "以前讨论过",
+}
+
+// priorQuestionSuffixes "X有人问过吗" 之类的问法后缀
+var priorQuestionSuffixes = []string{
+	"有没有人问过", "有人问过吗", "之前有人问过吗", "有人讨论过吗", "之前讨论过吗", "讨论过吗", "有人提过吗",
+}
+
+// parseDuplicateQuestion 识别 "有没有人问过登录超时"、"登录超时有人问过吗"，返回要查找的话题
+func parseDuplicateQuestion(query string) (string, bool) {
+	q := strings.TrimSpace(query)
+	q = strings.TrimRight(q, "？?。.！! ")
+
+	topic := ""
+	for _, prefix := range priorQuestionPrefixes {
+		if strings.HasPrefix(q, prefix) {
+			topic = strings.TrimPrefix(q, prefix)
+			break
+		}
+	}
+	if topic == "" {
+		for _, suffix := range priorQuestionSuffixes {
+			if strings.HasSuffix(q, suffix) {
+				topic = strings.TrimSuffix(q, suffix)
+				break
+			}
+		}
+	}
+
+	topic = strings.TrimSuffix(topic, "吗")
+	topic = strings.TrimSuffix(topic, "的问题")
+	topic = strings.TrimPrefix(topic, "关于")
+	topic = strings.Trim(topic, " ，,：:\"'“”「」")
+	if topic == "" {
+		return "", false
+	}
+	return topic, true
+}
+
+// priorHit 一条历史讨论或 AI 历史回答
+type priorHit struct {
+	ChatName  string
+	Sender    string
+	Content   string
+	CreatedAt time.Time
+	Score     float32
+}
+
+// priorSearcher 按话题检索历史记录的来源
+type priorSearcher interface {
+	SearchPrior(ctx context.Context, topic, chatID string, limit int) ([]priorHit, error)
+}
+
+// priorResults 各来源的检索结果
+type priorResults struct {
+	Answers     []priorHit // AI 历史回答
+	Discussions []priorHit // 群消息中的历史讨论
+}
+
+// searchPrior 同时检索群消息和 AI 历史回答，单个来源失败不影响另一个来源
+// answers 为 nil 时（尚未存储 AI 回答）只检索群消息
+func searchPrior(ctx context.Context, discussions, answers priorSearcher, topic, chatID string) (priorResults, error) {
+	var res priorResults
+	var errs []error
+	if discussions != nil {
+		hits, err := discussions.SearchPrior(ctx, topic, chatID, priorDiscussionLimit)
+		if err != nil {
+			log.Printf("Failed to search prior discussions for %q: %v", topic, err)
+			errs = append(errs, err)
+		}
+		res.Discussions = topPriorHits(hits, priorDiscussionLimit)
+	}
+	if answers != nil {
+		hits, err := answers.SearchPrior(ctx, topic, chatID, priorAnswerLimit)
+		if err != nil {
+			log.Printf("Failed to search prior AI answers for %q: %v", topic, err)
+			errs = append(errs, err)
+		}
+		res.Answers = topPriorHits(hits, priorAnswerLimit)
+	}
+
+	// 所有来源都失败时交给常规流程
+	sources := 0
+	if discussions != nil {
+		sources++
+	}
+	if answers != nil {
+		sources++
+	}
+	if sources > 0 && len(errs) == sources {
+		return res, fmt.Errorf("all prior sources failed: %w", errs[0])
+	}
+	return res, nil
+}
+
+// topPriorHits 按分数降序（分数相同时较新的在前）取前 limit 条，去掉内容重复的结果
+func topPriorHits(hits []priorHit, limit int) []priorHit {
+	sorted := append([]priorHit(nil), hits...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Score != sorted[j].Score {
+			return sorted[i].Score > sorted[j].Score
+		}
+		return sorted[i].CreatedAt.After(sorted[j].CreatedAt)
+	})
+
+	seen := make(map[string]bool, len(sorted))
+	var top []priorHit
+	for _, h := range sorted {
+		key := strings.Join(strings.Fields(h.Content), " ")
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		top = append(top, h)
+		if len(top) == limit {
+			break
+		}
+	}
+	return top
+}
+
+// messagePriorSearcher 在群消息中检索历史讨论：开启向量检索时使用混合检索，否则使用关键词搜索
+type messagePriorSearcher struct {
+	hp *HybridProcessor
+}
+
+// SearchPrior 检索与话题相关的历史群消息
+func (s messagePriorSearcher) SearchPrior(ctx context.Context, topic, chatID string, limit int) ([]priorHit, error) {
+	hp := s.hp
+	if hp.svcCtx.Services != nil && hp.svcCtx.Services.RAG != nil && hp.svcCtx.Services.RAG.IsEnabled() {
+		keywords := hp.extractSearchKeywords(topic, nil)
+		results, err := hp.qaHybridSearch(ctx, topic, keywords, chatID, nil, nil, nil, limit*2)
+		if err != nil {
+			return nil, fmt.Errorf("hybrid search: %w", err)
+		}
+		return searchResultsToPriorHits(results), nil
+	}
+
+	messages, err := hp.svcCtx.MessageModel.SearchByContent(ctx, chatID, topic, limit*2)
+	if err != nil {
+		return nil, fmt.Errorf("search messages: %w", err)
+	}
+	hits := make([]priorHit, 0, len(messages))
+	for _, msg := range messages {
+		hits = append(hits, priorHit{
+			Sender:    hp.senderName(ctx, msg),
+			Content:   msg.Content.String,
+			CreatedAt: msg.CreatedAt,
+		})
+	}
+	return hits, nil
+}
+
+// searchResultsToPriorHits 将向量检索结果转换为历史讨论
+func searchResultsToPriorHits(results []service.SearchResult) []priorHit {
+	hits := make([]priorHit, 0, len(results))
+	for _, r := range results {
+		hits = append(hits, priorHit{
+			ChatName:  r.ChatName,
+			Sender:    r.SenderName,
+			Content:   r.Content,
+			CreatedAt: r.CreatedAt,
+			Score:     r.Score,
+		})
+	}
+	return hits
+}
+
+// tryDuplicateQuestion 回答 "有没有人问过X"：列出该话题的历史讨论和 AI 历史回答，帮助用户避免重复提问
+// 检索范围与问答一致（群聊限定当前群，私聊使用默认群或所有群），无法回答时返回 false 交给常规流程
+func (hp *HybridProcessor) tryDuplicateQuestion(ctx context.Context, chatID, query string) (string, bool) {
+	if hp.svcCtx == nil || hp.svcCtx.MessageModel == nil {
+		return "", false
+	}
+	topic, ok := parseDuplicateQuestion(query)
+	if !ok {
+		return "", false
+	}
+
+	searchChatID := hp.getSearchChatID(chatID, "", ctx)
+	res, err := searchPrior(ctx, messagePriorSearcher{hp: hp}, hp.priorAnswers, topic, searchChatID)
+	if err != nil {
+		return "", false
+	}
+	return formatPriorResults(topic, res, searchChatID == ""), true
+}
+
+// formatPriorResults 格式化历史记录，crossChat 为 true 时标注消息所在的群
+func formatPriorResults(topic string, res priorResults, crossChat bool) string {
+	if len(res.Answers) == 0 && len(res.Discussions) == 0 {
+		return fmt.Sprintf("📭 没有找到关于「%s」的历史讨论，可以直接提问。", topic)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🔎 关于「%s」的历史记录\n", topic))
+	if len(res.Answers) > 0 {
+		sb.WriteString("\n🤖 **AI 回答过**\n")
+		for i, h := range res.Answers {
+			sb.WriteString(fmt.Sprintf("%d. [%s] %s\n", i+1, h.CreatedAt.Format("01-02 15:04"), trimSnippet(h.Content, priorSnippet)))
+		}
+	}
+	if len(res.Discussions) > 0 {
+		sb.WriteString("\n💬 **相关讨论**\n")
+		for i, h := range res.Discussions {
+			chat := ""
+			if crossChat && h.ChatName != "" {
+				chat = " @" + h.ChatName
+			}
+			sb.WriteString(fmt.Sprintf("%d. [%s] %s%s：%s\n", i+1, h.CreatedAt.Format("01-02 15:04"), h.Sender, chat, trimSnippet(h.Content, priorSnippet)))
+		}
+	}
+	sb.WriteString("\n💡 以上没有解决问题的话，可以直接提问。")
+	return sb.String()
+}
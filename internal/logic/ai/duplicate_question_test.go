@@ -0,0 +1,129 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseDuplicateQuestion(t *testing.T) {
+	tests := []struct {
+		query  string
+		want   string
+		wantOK bool
+	}{
+		{"有没有人问过登录超时", "登录超时", true},
+		{"有没有人问过登录超时的问题？", "登录超时", true},
+		{"之前有没有人讨论过支付回调", "支付回调", true},
+		{"有人问过关于发版流程吗", "发版流程", true},
+		{"登录超时有人问过吗？", "登录超时", true},
+		{"灰度发布之前讨论过吗", "灰度发布", true},
+		{"有没有人问过", "", false},
+		{"登录超时怎么处理", "", false},
+		{"有没有人在线", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got, ok := parseDuplicateQuestion(tt.query)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("parseDuplicateQuestion(%q) = (%q, %v), want (%q, %v)", tt.query, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+// fakePriorSearcher 返回固定结果，记录检索参数
+type fakePriorSearcher struct {
+	hits   []priorHit
+	err    error
+	topic  string
+	chatID string
+}
+
+func (f *fakePriorSearcher) SearchPrior(ctx context.Context, topic, chatID string, limit int) ([]priorHit, error) {
+	f.topic, f.chatID = topic, chatID
+	return f.hits, f.err
+}
+
+func TestSearchPriorCrossSource(t *testing.T) {
+	day := time.Date(2025, 3, 5, 10, 0, 0, 0, time.Local)
+	discussions := &fakePriorSearcher{hits: []priorHit{
+		{Sender: "张三", Content: "登录超时是网关配置问题", CreatedAt: day, Score: 0.6},
+		{Sender: "李四", Content: "登录超时已修复，今晚发布", CreatedAt: day.Add(time.Hour), Score: 0.9},
+		{Sender: "王五", Content: "登录超时是网关配置问题", CreatedAt: day.Add(2 * time.Hour), Score: 0.5},
+	}}
+	answers := &fakePriorSearcher{hits: []priorHit{
+		{Content: "登录超时通常是 token 过期导致", CreatedAt: day, Score: 0.8},
+	}}
+
+	res, err := searchPrior(context.Background(), discussions, answers, "登录超时", "oc_dev")
+	if err != nil {
+		t.Fatalf("searchPrior() error: %v", err)
+	}
+	if discussions.topic != "登录超时" || discussions.chatID != "oc_dev" || answers.chatID != "oc_dev" {
+		t.Errorf("Expected both sources searched with topic and chat, got %+v %+v", discussions, answers)
+	}
+	if len(res.Answers) != 1 || res.Answers[0].Content != "登录超时通常是 token 过期导致" {
+		t.Errorf("Answers = %+v", res.Answers)
+	}
+	if len(res.Discussions) != 2 {
+		t.Fatalf("Expected duplicate discussions removed, got %+v", res.Discussions)
+	}
+	if res.Discussions[0].Sender != "李四" {
+		t.Errorf("Expected highest score first, got %+v", res.Discussions)
+	}
+}
+
+func TestSearchPriorSourceFailure(t *testing.T) {
+	ok := &fakePriorSearcher{hits: []priorHit{{Content: "讨论过", Score: 1}}}
+	failing := &fakePriorSearcher{err: errors.New("qdrant down")}
+
+	tests := []struct {
+		name        string
+		discussions priorSearcher
+		answers     priorSearcher
+		wantErr     bool
+		wantHits    int
+	}{
+		{"回答来源失败仍返回讨论", ok, failing, false, 1},
+		{"讨论来源失败仍返回回答", failing, ok, false, 1},
+		{"全部失败", failing, failing, true, 0},
+		{"未存储 AI 回答只检索讨论", ok, nil, false, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := searchPrior(context.Background(), tt.discussions, tt.answers, "登录", "")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("searchPrior() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := len(res.Answers) + len(res.Discussions); got != tt.wantHits {
+				t.Errorf("Got %d hits, want %d", got, tt.wantHits)
+			}
+		})
+	}
+}
+
+func TestFormatPriorResults(t *testing.T) {
+	if got := formatPriorResults("登录超时", priorResults{}, false); !strings.Contains(got, "没有找到关于「登录超时」") {
+		t.Errorf("Expected empty hint, got %q", got)
+	}
+
+	day := time.Date(2025, 3, 5, 10, 0, 0, 0, time.Local)
+	res := priorResults{
+		Answers:     []priorHit{{Content: "token 过期导致", CreatedAt: day}},
+		Discussions: []priorHit{{ChatName: "研发群", Sender: "李四", Content: "已修复", CreatedAt: day}},
+	}
+	got := formatPriorResults("登录超时", res, true)
+	for _, want := range []string{"🤖 **AI 回答过**", "1. [03-05 10:00] token 过期导致", "💬 **相关讨论**", "1. [03-05 10:00] 李四 @研发群：已修复"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected %q in:\n%s", want, got)
+		}
+	}
+	if strings.Contains(formatPriorResults("登录超时", res, false), "@研发群") {
+		t.Error("Group name should be omitted when searching a single chat")
+	}
+}
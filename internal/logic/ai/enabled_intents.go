@@ -0,0 +1,42 @@
+package ai
+
+import (
+	"log"
+
+	"team-assistant/pkg/llm"
+)
+
+// ======================== 意图开关（LLM.EnabledIntents） ========================
+
+// intentDisabledMessage 意图未启用且无法改按问答处理时的回复
+const intentDisabledMessage = "⚠️ 该功能未启用，可发送 \"帮助\" 查看可用功能"
+
+// intentEnabled 意图是否启用：未配置时全部启用，帮助始终可用，未知意图按问答判断
+func (hp *HybridProcessor) intentEnabled(intent llm.Intent) bool {
+	enabled := hp.svcCtx.Config.LLM.EnabledIntents
+	if len(enabled) == 0 || intent == llm.IntentHelp {
+		return true
+	}
+	if intent == llm.IntentUnknown || intent == "" {
+		intent = llm.IntentQA
+	}
+	for _, name := range enabled {
+		if llm.Intent(name) == intent {
+			return true
+		}
+	}
+	return false
+}
+
+// routeIntent 返回实际处理的意图：未启用的意图改按问答处理，问答也未启用时返回 false
+func (hp *HybridProcessor) routeIntent(intent llm.Intent) (llm.Intent, bool) {
+	if hp.intentEnabled(intent) {
+		return intent, true
+	}
+	if hp.intentEnabled(llm.IntentQA) {
+		log.Printf("Intent %s is disabled, falling back to qa", intent)
+		return llm.IntentQA, true
+	}
+	log.Printf("Intent %s is disabled", intent)
+	return "", false
+}
@@ -0,0 +1,63 @@
+package ai
+
+import (
+	"context"
+	"testing"
+
+	"team-assistant/internal/config"
+	"team-assistant/internal/svc"
+	"team-assistant/pkg/llm"
+)
+
+func newIntentProcessor(enabled ...string) *HybridProcessor {
+	return &HybridProcessor{svcCtx: &svc.ServiceContext{Config: config.Config{LLM: config.LLMConfig{EnabledIntents: enabled}}}}
+}
+
+func TestRouteIntent(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled []string
+		intent  llm.Intent
+		want    llm.Intent
+		wantOK  bool
+	}{
+		{"未配置全部启用", nil, llm.IntentGroupTimeline, llm.IntentGroupTimeline, true},
+		{"已启用", []string{"summarize", "qa"}, llm.IntentSummarize, llm.IntentSummarize, true},
+		{"未启用改按问答", []string{"search_message", "qa"}, llm.IntentSiteQuery, llm.IntentQA, true},
+		{"问答也未启用", []string{"search_message", "summarize"}, llm.IntentGroupTimeline, "", false},
+		{"帮助始终可用", []string{"summarize"}, llm.IntentHelp, llm.IntentHelp, true},
+		{"未知意图按问答判断", []string{"qa"}, llm.IntentUnknown, llm.IntentUnknown, true},
+		{"未知意图且问答未启用", []string{"summarize"}, llm.IntentUnknown, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := newIntentProcessor(tt.enabled...).routeIntent(tt.intent)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("routeIntent(%s) = (%q, %v), want (%q, %v)", tt.intent, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestProcessWithNativeLLMDisabledIntent(t *testing.T) {
+	// 离线客户端的意图解析固定为 qa
+	hp := newIntentProcessor("search_message", "summarize")
+	hp.llmClient = llm.NewOfflineClient("offline-model")
+
+	answer, err := hp.processWithNativeLLM(context.Background(), "ou_user", "登录超时怎么处理", false)
+	if err != nil {
+		t.Fatalf("processWithNativeLLM failed: %v", err)
+	}
+	if answer != intentDisabledMessage {
+		t.Errorf("processWithNativeLLM() = %q, want disabled message", answer)
+	}
+}
+
+func TestTopRankingShortcutRespectsEnabledIntents(t *testing.T) {
+	// 未启用 top_ranking 时直接交给常规流程，不会查询数据库（这里没有配置 MessageModel）
+	hp := newIntentProcessor("qa")
+	if answer, ok := hp.tryTopRanking(context.Background(), "oc_1", "发言最多的五个人"); ok {
+		t.Errorf("Expected top ranking shortcut disabled, got %q", answer)
+	}
+}
@@ -0,0 +1,20 @@
+package ai
+
+import (
+	"context"
+)
+
+// groupContext 返回群的项目背景描述（如"这是「X」项目群"），未设置或跨群查询时返回空字符串
+func (hp *HybridProcessor) groupContext(ctx context.Context, chatID string) string {
+	if chatID == "" || hp.svcCtx == nil || hp.svcCtx.GroupModel == nil {
+		return ""
+	}
+	group, err := hp.svcCtx.GroupModel.FindByChatID(ctx, chatID)
+	if err != nil || group == nil {
+		return ""
+	}
+	if gc := group.MetadataContext(); gc != "" {
+		return "【群背景】" + gc
+	}
+	return ""
+}
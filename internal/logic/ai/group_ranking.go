@@ -0,0 +1,150 @@
+package ai
+
+import (
+	"context"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"team-assistant/internal/service"
+)
+
+const (
+	// defaultCrossGroupTopK 跨群问答默认检索的群数量
+	defaultCrossGroupTopK = 5
+	// groupRankingLookback 统计关键词命中的时间窗口
+	groupRankingLookback = 90 * 24 * time.Hour
+	// groupRankingSemanticLimit 用于聚合语义相似度的候选消息数
+	groupRankingSemanticLimit = 50
+)
+
+// groupRelevance 群与问题的相关度
+type groupRelevance struct {
+	chatID   string
+	keyword  float64 // 关键词命中得分（按最大值归一化）
+	semantic float64 // 语义相似度聚合得分（按最大值归一化）
+	score    float64
+}
+
+// rankRelevantGroups 综合关键词命中数和语义检索结果，返回最相关的 topK 个群
+// 关键词得分：每个关键词 log(1+命中数) 求和，匹配到的关键词越多、越集中得分越高
+// 语义得分：无过滤语义检索结果按群累加相似度
+func rankRelevantGroups(keywordHits map[string][]int, semantic []service.SearchResult, topK int) []groupRelevance {
+	byChat := make(map[string]*groupRelevance)
+	get := func(chatID string) *groupRelevance {
+		g, ok := byChat[chatID]
+		if !ok {
+			g = &groupRelevance{chatID: chatID}
+			byChat[chatID] = g
+		}
+		return g
+	}
+
+	for chatID, counts := range keywordHits {
+		if chatID == "" {
+			continue
+		}
+		var score float64
+		for _, c := range counts {
+			if c > 0 {
+				score += math.Log1p(float64(c))
+			}
+		}
+		if score > 0 {
+			get(chatID).keyword = score
+		}
+	}
+	for _, r := range semantic {
+		if r.ChatID == "" || r.Score <= 0 {
+			continue
+		}
+		get(r.ChatID).semantic += float64(r.Score)
+	}
+
+	var maxKeyword, maxSemantic float64
+	for _, g := range byChat {
+		maxKeyword = math.Max(maxKeyword, g.keyword)
+		maxSemantic = math.Max(maxSemantic, g.semantic)
+	}
+
+	ranked := make([]groupRelevance, 0, len(byChat))
+	for _, g := range byChat {
+		if maxKeyword > 0 {
+			g.keyword /= maxKeyword
+		}
+		if maxSemantic > 0 {
+			g.semantic /= maxSemantic
+		}
+		// 只有一种信号时按该信号排序
+		switch {
+		case maxKeyword > 0 && maxSemantic > 0:
+			g.score = 0.5*g.keyword + 0.5*g.semantic
+		case maxKeyword > 0:
+			g.score = g.keyword
+		default:
+			g.score = g.semantic
+		}
+		ranked = append(ranked, *g)
+	}
+
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].score != ranked[j].score {
+			return ranked[i].score > ranked[j].score
+		}
+		return ranked[i].chatID < ranked[j].chatID
+	})
+	if topK > 0 && len(ranked) > topK {
+		ranked = ranked[:topK]
+	}
+	return ranked
+}
+
+// crossGroupTopK 跨群问答挑选的群数量，0 表示不限定
+func (hp *HybridProcessor) crossGroupTopK() int {
+	k := hp.svcCtx.Config.LLM.CrossGroupTopK
+	switch {
+	case k < 0:
+		return 0
+	case k == 0:
+		return defaultCrossGroupTopK
+	}
+	return k
+}
+
+// findRelevantGroups 跨群问答前挑选最可能包含答案的群，找不到信号时返回 nil（搜索所有群）
+func (hp *HybridProcessor) findRelevantGroups(ctx context.Context, query string, keywords []string) []string {
+	topK := hp.crossGroupTopK()
+	if topK == 0 {
+		return nil
+	}
+
+	var keywordHits map[string][]int
+	if hp.svcCtx.MessageModel != nil && len(keywords) > 0 {
+		hits, err := hp.svcCtx.MessageModel.CountKeywordHitsByChat(ctx, keywords, time.Now().Add(-groupRankingLookback))
+		if err != nil {
+			log.Printf("Failed to count keyword hits by chat: %v", err)
+		}
+		keywordHits = hits
+	}
+
+	var semantic []service.SearchResult
+	if svcs := hp.svcCtx.Services; svcs != nil && svcs.RAG != nil && svcs.RAG.IsEnabled() {
+		results, err := svcs.RAG.Search(ctx, query, groupRankingSemanticLimit, "")
+		if err != nil {
+			log.Printf("Failed to rank groups by semantic search: %v", err)
+		}
+		semantic = results
+	}
+
+	ranked := rankRelevantGroups(keywordHits, semantic, topK)
+	if len(ranked) == 0 {
+		return nil
+	}
+	chatIDs := make([]string, 0, len(ranked))
+	for _, g := range ranked {
+		chatIDs = append(chatIDs, g.chatID)
+	}
+	log.Printf("Cross-group QA restricted to %d relevant groups: %v", len(chatIDs), chatIDs)
+	return chatIDs
+}
@@ -0,0 +1,110 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"team-assistant/internal/model"
+	"team-assistant/pkg/llm"
+)
+
+// ======================== 最火消息（按表情回复数排行，不经过 LLM） ========================
+
+// 最火消息展示限制
+const (
+	hotMessagesLimit   = 10
+	hotMessagesSnippet = 80
+)
+
+// hotMessageKeywords 询问热门消息的关键词
+var hotMessageKeywords = []string{"最火", "最热", "最受欢迎", "点赞最多", "表情最多", "回应最多"}
+
+// hotMessageTargets 热门内容的指代词，避免把 "最火的项目" 之类的问题误判为消息排行
+var hotMessageTargets = []string{"消息", "发言", "帖子", "内容"}
+
+// hotMessagesQuery 解析后的最火消息查询
+type hotMessagesQuery struct {
+	timeRange llm.TimeRange // 为空表示最近 7 天
+	label     string
+}
+
+// parseHotMessagesQuery 识别 "这周群里最火的消息" 之类的问题
+func parseHotMessagesQuery(query string) (hotMessagesQuery, bool) {
+	if !containsAny(query, hotMessageKeywords) || !containsAny(query, hotMessageTargets) {
+		return hotMessagesQuery{}, false
+	}
+	tr, label := parseStatsTimeRange(query)
+	return hotMessagesQuery{timeRange: tr, label: label}, true
+}
+
+// containsAny 字符串是否包含任一关键词
+func containsAny(s string, keywords []string) bool {
+	for _, kw := range keywords {
+		if strings.Contains(s, kw) {
+			return true
+		}
+	}
+	return false
+}
+
+// tryHotMessages 尝试按表情回复数回答最火消息问题，无法回答时返回 false 交给常规流程
+// 群聊限定当前群，私聊统计所有群
+func (hp *HybridProcessor) tryHotMessages(ctx context.Context, chatID, query string) (string, bool) {
+	if hp.svcCtx == nil || hp.svcCtx.MessageModel == nil {
+		return "", false
+	}
+	q, ok := parseHotMessagesQuery(query)
+	if !ok {
+		return "", false
+	}
+
+	searchChatID := ""
+	if !isPrivateChat(ctx, chatID) {
+		searchChatID = chatID
+	}
+	start, _ := hp.statsRange(q.timeRange, time.Now())
+	messages, err := hp.svcCtx.MessageModel.TopReactedSince(ctx, searchChatID, start, hotMessagesLimit)
+	if err != nil {
+		log.Printf("Failed to get top reacted messages: %v, falling back to normal processing", err)
+		return "", false
+	}
+
+	var chatNames map[string]string
+	if searchChatID == "" && hp.svcCtx.GroupModel != nil {
+		if groups, err := hp.svcCtx.GroupModel.ListAll(ctx); err == nil {
+			chatNames = make(map[string]string, len(groups))
+			for _, g := range groups {
+				chatNames[g.ChatID] = g.ChatName.String
+			}
+		}
+	}
+
+	senders := make([]string, len(messages))
+	for i, msg := range messages {
+		senders[i] = hp.senderName(ctx, msg)
+	}
+	return formatHotMessages(q.label, messages, senders, chatNames), true
+}
+
+// formatHotMessages 格式化最火消息排行，chatNames 不为空时标注消息所在的群
+func formatHotMessages(label string, messages []*model.ChatMessage, senders []string, chatNames map[string]string) string {
+	if len(messages) == 0 {
+		return fmt.Sprintf("📭 %s还没有收到表情回复的消息", label)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("🔥 %s最火的消息（按表情回复数）\n", label))
+	for i, msg := range messages {
+		chat := ""
+		if name := chatNames[msg.ChatID]; name != "" {
+			chat = " @" + name
+		}
+		sb.WriteString(fmt.Sprintf("\n%d. 👍 %d  [%s] %s%s\n   %s\n",
+			i+1, msg.ReactionCount, msg.CreatedAt.Format("01-02 15:04"), senders[i], chat,
+			trimSnippet(msg.Content.String, hotMessagesSnippet)))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
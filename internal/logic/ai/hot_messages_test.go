@@ -0,0 +1,57 @@
+package ai
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"team-assistant/internal/model"
+	"team-assistant/pkg/llm"
+)
+
+func TestParseHotMessagesQuery(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		wantOK    bool
+		wantRange llm.TimeRange
+	}{
+		{"本周最火", "这周群里最火的消息", true, llm.TimeRangeThisWeek},
+		{"默认范围", "点赞最多的发言是哪条", true, ""},
+		{"今天最热", "今天最热的消息", true, llm.TimeRangeToday},
+		{"非消息排行", "最火的项目是哪个", false, ""},
+		{"普通问题", "这周的消息总结一下", false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, ok := parseHotMessagesQuery(tt.query)
+			if ok != tt.wantOK {
+				t.Fatalf("parseHotMessagesQuery(%q) ok = %v, want %v", tt.query, ok, tt.wantOK)
+			}
+			if ok && q.timeRange != tt.wantRange {
+				t.Errorf("timeRange = %q, want %q", q.timeRange, tt.wantRange)
+			}
+		})
+	}
+}
+
+func TestFormatHotMessages(t *testing.T) {
+	createdAt := time.Date(2024, 3, 2, 10, 30, 0, 0, time.Local)
+	messages := []*model.ChatMessage{
+		{ChatID: "oc_dev", Content: sql.NullString{String: "新版本已上线 🎉", Valid: true}, CreatedAt: createdAt, ReactionCount: 12},
+		{ChatID: "oc_ops", Content: sql.NullString{String: "机房切换完成", Valid: true}, CreatedAt: createdAt, ReactionCount: 5},
+	}
+
+	got := formatHotMessages("本周", messages, []string{"张三", "李四"}, map[string]string{"oc_dev": "研发群"})
+	for _, want := range []string{"本周最火的消息", "1. 👍 12  [03-02 10:30] 张三 @研发群", "新版本已上线", "2. 👍 5  [03-02 10:30] 李四\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Result should contain %q, got:\n%s", want, got)
+		}
+	}
+
+	if got := formatHotMessages("今天", nil, nil, nil); !strings.Contains(got, "今天还没有收到表情回复的消息") {
+		t.Errorf("Unexpected empty result: %q", got)
+	}
+}
@@ -3,10 +3,10 @@ package ai
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"regexp"
-	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -27,11 +27,11 @@ type ChatTurn struct {
 
 // ConversationContext 对话上下文
 type ConversationContext struct {
-	LastQuery     string           // 上一个问题
-	LastAnswer    string           // 上一次的回答（用于追问时参考）
-	LastParsed    *llm.ParsedQuery // 上一次解析结果
-	LastChatID    string           // 上一次使用的 chatID
-	LastTimestamp time.Time        // 上一次交互时间
+	LastQuery     string           `json:"last_query"`     // 上一个问题
+	LastAnswer    string           `json:"last_answer"`    // 上一次的回答（用于追问时参考）
+	LastParsed    *llm.ParsedQuery `json:"last_parsed"`    // 上一次解析结果
+	LastChatID    string           `json:"last_chat_id"`   // 上一次使用的 chatID
+	LastTimestamp time.Time        `json:"last_timestamp"` // 上一次交互时间
 }
 
 // HybridProcessor 混合 AI 处理器
@@ -42,19 +42,55 @@ type HybridProcessor struct {
 	llmClient       *llm.Client
 	useDify         bool
 	datasetID       string                          // Dify 知识库 ID
-	conversationMap map[string]string               // 用户对话 ID 映射 (userID -> conversationID)
-	contextMap      map[string]*ConversationContext // 用户对话上下文 (userID -> context)
+	conversationMap map[string]string               // 用户对话 ID 映射 (userID -> conversationID)，Redis 不可用时兜底
+	contextMap      map[string]*ConversationContext // 用户对话上下文 (userID -> context)，Redis 不可用时兜底
+	conversations   conversationStore               // 对话 ID 和上下文的持久化存储（可选，重启后恢复追问）
 	mu              sync.RWMutex                    // 保护 conversationMap 和 contextMap 的并发访问
+	senders         *senderResolver                 // 发言人名称解析（处理 sender_name 缺失）
+	reportExporter  ReportExporter                  // 报告导出到知识库（可选）
+	templates       *AnswerTemplates                // 回复模板（nil 时使用内置模板）
+	weeklyStore     weeklySummaryStore              // 周总结缓存（可选）
+	summaries       *summaryCache                   // 已结束时间段的消息总结缓存
+	defaultChats    defaultChatStore                // 用户默认群（可选）
+	priorAnswers    priorSearcher                   // AI 历史回答检索（可选，"有没有人问过X" 时与群消息一起检索）
+	roles           *senderRoles                    // 发言人角色（开启 LLM.SenderRoles 时在上下文中标注）
 }
 
 // NewHybridProcessor 创建混合处理器
 func NewHybridProcessor(svcCtx *svc.ServiceContext) *HybridProcessor {
 	hp := &HybridProcessor{
 		svcCtx:          svcCtx,
-		useDify:         svcCtx.Config.Dify.Enabled,
+		useDify:         svcCtx.Config.Dify.Enabled && !svcCtx.Config.SafeMode,
 		datasetID:       svcCtx.Config.Dify.DatasetID,
 		conversationMap: make(map[string]string),
 		contextMap:      make(map[string]*ConversationContext),
+		summaries:       newSummaryCache(summaryCacheTTL),
+	}
+
+	var fetcher userInfoFetcher
+	if svcCtx.LarkClient != nil {
+		fetcher = svcCtx.LarkClient
+	}
+	hp.senders = newSenderResolver(svcCtx.Config.LLM, fetcher)
+	hp.senders.metrics = svcCtx.Metrics
+
+	templates, err := LoadAnswerTemplates(svcCtx.Config.LLM.AnswerTemplatesFile)
+	if err != nil {
+		log.Printf("Failed to load answer templates, using defaults: %v", err)
+	}
+	hp.templates = templates
+
+	if svcCtx.WeeklySummaryModel != nil {
+		hp.weeklyStore = svcCtx.WeeklySummaryModel
+	}
+	if svcCtx.UserDefaultChatModel != nil {
+		hp.defaultChats = svcCtx.UserDefaultChatModel
+	}
+	if svcCtx.ConversationRepo != nil {
+		hp.conversations = svcCtx.ConversationRepo
+	}
+	if svcCtx.Config.LLM.SenderRoles && svcCtx.MemberModel != nil {
+		hp.roles = newSenderRoles(svcCtx.MemberModel)
 	}
 
 	if hp.useDify && svcCtx.Config.Dify.APIKey != "" {
@@ -62,34 +98,15 @@ func NewHybridProcessor(svcCtx *svc.ServiceContext) *HybridProcessor {
 		log.Println("Using Dify for AI processing")
 	}
 
-	// 始终初始化原生 LLM 作为备用
-	if svcCtx.Config.LLM.APIKey != "" {
-		// 创建代理配置（如果有）
-		var proxyConfig *llm.ProxyConfig
-		if svcCtx.Config.LLM.ProxyHost != "" && svcCtx.Config.LLM.ProxyPort > 0 {
-			proxyConfig = &llm.ProxyConfig{
-				Host:     svcCtx.Config.LLM.ProxyHost,
-				Port:     svcCtx.Config.LLM.ProxyPort,
-				User:     svcCtx.Config.LLM.ProxyUser,
-				Password: svcCtx.Config.LLM.ProxyPassword,
-			}
-			log.Printf("LLM proxy configured: %s:%d", proxyConfig.Host, proxyConfig.Port)
-		}
-		hp.llmClient = llm.NewClientWithProxy(
-			svcCtx.Config.LLM.APIKey,
-			svcCtx.Config.LLM.Endpoint,
-			svcCtx.Config.LLM.Model,
-			proxyConfig,
-		)
-		// 设置视觉模型配置
-		if svcCtx.Config.LLM.VisionModel != "" {
-			hp.llmClient.SetVisionConfig(
-				svcCtx.Config.LLM.VisionModel,
-				svcCtx.Config.LLM.VisionEndpoint,
-				svcCtx.Config.LLM.VisionAPIKey,
-			)
-			log.Printf("Vision model configured: %s", svcCtx.Config.LLM.VisionModel)
-		}
+	// 原生 LLM 始终作为备用，复用服务上下文中的客户端（备选模型、调用统计）；
+	// 安全模式下使用返回固定内容的离线客户端
+	hp.llmClient = svcCtx.LLMClient
+	if hp.llmClient == nil && svcCtx.Config.SafeMode {
+		hp.llmClient = llm.NewOfflineClient(svcCtx.Config.LLM.Model)
+	}
+	if svcCtx.Config.SafeMode {
+		log.Println("Safe mode: using offline LLM client, external AI calls disabled")
+	} else if hp.llmClient != nil {
 		if !hp.useDify {
 			log.Println("Using native LLM for AI processing")
 		} else {
@@ -102,7 +119,31 @@ func NewHybridProcessor(svcCtx *svc.ServiceContext) *HybridProcessor {
 
 // ProcessQuery 处理用户查询
 // chatID 是当前会话所在的群ID（群聊时）或用户ID（私聊时）
-func (hp *HybridProcessor) ProcessQuery(ctx context.Context, chatID, query string, isReplyFollowUp bool) (string, error) {
+// chatType 为事件中的 chat_type（p2p / group / topic_group），用于判断是否私聊
+func (hp *HybridProcessor) ProcessQuery(ctx context.Context, chatID, chatType, query string, isReplyFollowUp bool) (string, error) {
+	ctx = withChatType(ctx, chatType)
+	// 私聊时使用用户设置的默认群作为检索范围
+	ctx = hp.applyDefaultChat(ctx, chatID, query)
+	// "告警最多的三个站点"、"发言最多的五个人" 直接用聚合查询排行
+	if answer, ok := hp.tryTopRanking(ctx, chatID, query); ok {
+		return answer, nil
+	}
+	// 告警趋势/排行直接查告警表，不需要 LLM 扫描消息
+	if answer, ok := hp.tryAlertStats(ctx, query); ok {
+		return answer, nil
+	}
+	// 最火消息按表情回复数排行
+	if answer, ok := hp.tryHotMessages(ctx, chatID, query); ok {
+		return answer, nil
+	}
+	// "有没有人问过X" 直接列出历史讨论，避免重复提问
+	if answer, ok := hp.tryDuplicateQuestion(ctx, chatID, query); ok {
+		return answer, nil
+	}
+	// 最近的决议/里程碑优先读取周总结缓存
+	if answer, ok := hp.tryRecentDecisions(ctx, chatID, query); ok {
+		return answer, nil
+	}
 	if hp.useDify && hp.difyClient != nil {
 		return hp.processWithDify(ctx, chatID, query)
 	}
@@ -126,6 +167,9 @@ func (hp *HybridProcessor) ProcessImageQuery(ctx context.Context, userID, query
 
 	// 调用视觉模型
 	response, err := hp.llmClient.ChatWithImage(ctx, query, imageData)
+	if reply, ok := unsupportedImageReply(err); ok {
+		return reply, nil
+	}
 	if err != nil {
 		log.Printf("Vision model error: %v", err)
 		return "", err
@@ -159,6 +203,9 @@ func (hp *HybridProcessor) ProcessImageQueryWithHistory(ctx context.Context, use
 
 	// 调用视觉模型（带历史）
 	response, err := hp.llmClient.ChatWithImageAndHistory(ctx, query, imageData, llmHistory)
+	if reply, ok := unsupportedImageReply(err); ok {
+		return reply, nil
+	}
 	if err != nil {
 		log.Printf("Vision model error: %v", err)
 		return "", err
@@ -167,6 +214,20 @@ func (hp *HybridProcessor) ProcessImageQueryWithHistory(ctx context.Context, use
 	return response, nil
 }
 
+// unsupportedImageReply 图片格式视觉模型不支持时提示用户转换格式
+func unsupportedImageReply(err error) (string, bool) {
+	var unsupported *llm.UnsupportedImageError
+	if !errors.As(err, &unsupported) {
+		return "", false
+	}
+	log.Printf("Rejected image with unsupported type %s", unsupported.MimeType)
+	format := strings.TrimPrefix(unsupported.MimeType, "image/")
+	if strings.HasPrefix(unsupported.MimeType, "image/hei") {
+		format = "HEIC（iPhone 照片）"
+	}
+	return fmt.Sprintf("⚠️ 暂不支持 %s 格式的图片，请截图或转换为 JPG/PNG 后重新发送", format), true
+}
+
 // processWithDify 使用 Dify 处理
 func (hp *HybridProcessor) processWithDify(ctx context.Context, userID, query string) (string, error) {
 	// 收集上下文数据
@@ -195,9 +256,7 @@ func (hp *HybridProcessor) processWithDify(ctx context.Context, userID, query st
 	}
 
 	// 获取对话 ID（支持多轮对话）
-	hp.mu.RLock()
-	conversationID := hp.conversationMap[userID]
-	hp.mu.RUnlock()
+	conversationID := hp.loadConversationID(ctx, userID)
 
 	// 构建 Dify 请求
 	req := &dify.ChatRequest{
@@ -225,9 +284,7 @@ func (hp *HybridProcessor) processWithDify(ctx context.Context, userID, query st
 
 	// 保存对话 ID 用于多轮对话
 	if resp.ConversationID != "" {
-		hp.mu.Lock()
-		hp.conversationMap[userID] = resp.ConversationID
-		hp.mu.Unlock()
+		hp.storeConversationID(ctx, userID, resp.ConversationID)
 	}
 
 	return resp.Answer, nil
@@ -239,6 +296,7 @@ func (hp *HybridProcessor) ClearConversation(userID string) {
 	delete(hp.conversationMap, userID)
 	delete(hp.contextMap, userID)
 	hp.mu.Unlock()
+	hp.clearStoredConversation(userID)
 }
 
 // isFollowUpQuestion 判断是否是追问（如"再看看"、"你再想想"）
@@ -311,20 +369,10 @@ func (hp *HybridProcessor) isLikelyFollowUp(query string, prevContext *Conversat
 
 // getOrRestoreContext 获取或恢复对话上下文
 // 如果是追问且有上下文，返回合并后的问题
-func (hp *HybridProcessor) getOrRestoreContext(userID, query string) (string, *ConversationContext) {
-	hp.mu.RLock()
-	ctx, exists := hp.contextMap[userID]
-	hp.mu.RUnlock()
-
-	if !exists || ctx == nil {
-		return query, nil
-	}
-
-	// 检查上下文是否过期（5分钟内有效）
-	if time.Since(ctx.LastTimestamp) > 5*time.Minute {
-		hp.mu.Lock()
-		delete(hp.contextMap, userID)
-		hp.mu.Unlock()
+// 上下文 5 分钟内有效，内存中没有时从 Redis 恢复（进程重启后仍可追问）
+func (hp *HybridProcessor) getOrRestoreContext(reqCtx context.Context, userID, query string) (string, *ConversationContext) {
+	ctx := hp.loadContext(reqCtx, userID)
+	if ctx == nil {
 		return query, nil
 	}
 
@@ -376,28 +424,24 @@ func (hp *HybridProcessor) mergeWithContext(followUp string, ctx *ConversationCo
 }
 
 // saveContext 保存对话上下文
-func (hp *HybridProcessor) saveContext(userID string, query string, parsed *llm.ParsedQuery, chatID string) {
-	hp.mu.Lock()
-	hp.contextMap[userID] = &ConversationContext{
+func (hp *HybridProcessor) saveContext(ctx context.Context, userID string, query string, parsed *llm.ParsedQuery, chatID string) {
+	hp.storeContext(ctx, userID, &ConversationContext{
 		LastQuery:     query,
 		LastParsed:    parsed,
 		LastChatID:    chatID,
 		LastTimestamp: time.Now(),
-	}
-	hp.mu.Unlock()
+	})
 }
 
 // saveContextWithAnswer 保存对话上下文（包含回答）
-func (hp *HybridProcessor) saveContextWithAnswer(userID string, query string, answer string, parsed *llm.ParsedQuery, chatID string) {
-	hp.mu.Lock()
-	hp.contextMap[userID] = &ConversationContext{
+func (hp *HybridProcessor) saveContextWithAnswer(ctx context.Context, userID string, query string, answer string, parsed *llm.ParsedQuery, chatID string) {
+	hp.storeContext(ctx, userID, &ConversationContext{
 		LastQuery:     query,
 		LastAnswer:    answer,
 		LastParsed:    parsed,
 		LastChatID:    chatID,
 		LastTimestamp: time.Now(),
-	}
-	hp.mu.Unlock()
+	})
 }
 
 // processWithNativeLLM 使用原生 LLM 处理
@@ -408,7 +452,7 @@ func (hp *HybridProcessor) processWithNativeLLM(ctx context.Context, currentChat
 
 	// 检查是否是追问，尝试恢复上下文
 	originalQuery := query
-	restoredQuery, prevContext := hp.getOrRestoreContext(userID, query)
+	restoredQuery, prevContext := hp.getOrRestoreContext(ctx, userID, query)
 
 	// 判断是否是追问：
 	// 新逻辑：优先使用飞书的回复机制（root_id）来判断是否是追问
@@ -439,7 +483,7 @@ func (hp *HybridProcessor) processWithNativeLLM(ctx context.Context, currentChat
 		answer, err := hp.answerFollowUpFromContext(ctx, originalQuery, prevContext)
 		if err == nil && answer != "" {
 			// 保存本次回答到上下文
-			hp.saveContextWithAnswer(userID, originalQuery, answer, prevContext.LastParsed, prevContext.LastChatID)
+			hp.saveContextWithAnswer(ctx, userID, originalQuery, answer, prevContext.LastParsed, prevContext.LastChatID)
 			return answer, nil
 		}
 		log.Printf("Failed to answer from context: %v, falling back to normal processing", err)
@@ -477,31 +521,55 @@ func (hp *HybridProcessor) processWithNativeLLM(ctx context.Context, currentChat
 		parsed.Intent, parsed.TimeRange, parsed.TargetUsers, parsed.TargetGroup, currentChatID)
 
 	// 根据意图处理，传递当前群ID
-	var answer string
-	switch parsed.Intent {
-	case llm.IntentSiteQuery:
-		answer, err = hp.handleSiteQueryByLLM(ctx, parsed)
-	case llm.IntentGroupTimeline:
-		answer, err = hp.handleGroupTimeline(ctx, parsed, currentChatID)
-	case llm.IntentQueryWorkload, llm.IntentQueryCommits:
-		answer, err = hp.handleWorkloadQuery(ctx, parsed)
-	case llm.IntentSearchMessage:
-		answer, err = hp.handleMessageSearch(ctx, parsed, currentChatID)
-	case llm.IntentSummarize:
-		answer, err = hp.handleSummarize(ctx, parsed, currentChatID)
-	case llm.IntentQA:
-		answer, err = hp.handleQA(ctx, parsed, currentChatID)
-	case llm.IntentHelp:
+	if parsed.Intent == llm.IntentHelp {
 		return hp.getHelpMessage(), nil
-	default:
-		// 对于未知意图，尝试作为问答处理
-		answer, err = hp.handleQA(ctx, parsed, currentChatID)
+	}
+
+	// 未启用的意图改按问答处理（复制一份，避免改动上下文中保存的解析结果）
+	intent, ok := hp.routeIntent(parsed.Intent)
+	if !ok {
+		return intentDisabledMessage, nil
+	}
+	if intent != parsed.Intent {
+		routed := *parsed
+		routed.Intent = intent
+		parsed = &routed
+	}
+
+	// 每个意图处理都有超时上限，避免慢查询（如多周群历程）长时间占用 webhook 协程
+	answer, timedOut, err := runWithTimeout(ctx, hp.intentTimeout(parsed.Intent), func(ctx context.Context) (string, error) {
+		switch parsed.Intent {
+		case llm.IntentSiteQuery:
+			return hp.handleSiteQueryByLLM(ctx, parsed)
+		case llm.IntentGroupTimeline:
+			return hp.handleGroupTimeline(ctx, parsed, currentChatID)
+		case llm.IntentQueryWorkload, llm.IntentQueryCommits:
+			return hp.handleWorkloadQuery(ctx, parsed)
+		case llm.IntentSearchMessage:
+			return hp.handleMessageSearch(ctx, parsed, currentChatID)
+		case llm.IntentSummarize:
+			return hp.handleSummarize(ctx, parsed, currentChatID)
+		case llm.IntentQA:
+			return hp.handleQA(ctx, parsed, currentChatID)
+		case llm.IntentMemberGroups:
+			return hp.handleMemberGroupsQuery(ctx, parsed, currentChatID)
+		case llm.IntentTopRanking:
+			return hp.handleTopRanking(ctx, parsed, currentChatID)
+		default:
+			// 对于未知意图，尝试作为问答处理
+			return hp.handleQA(ctx, parsed, currentChatID)
+		}
+	})
+	if timedOut {
+		hp.svcCtx.Metrics.RecordQueryTimeout()
+		log.Printf("Intent %s timed out for query: %s", parsed.Intent, query)
+		return intentTimeoutMessage, nil
 	}
 
 	// 保存对话上下文（包含回答，用于追问）
 	if err == nil && answer != "" {
 		chatID := hp.getSearchChatID(currentChatID, parsed.TargetGroup, ctx)
-		hp.saveContextWithAnswer(userID, query, answer, parsed, chatID)
+		hp.saveContextWithAnswer(ctx, userID, query, answer, parsed, chatID)
 	}
 
 	return answer, err
@@ -597,35 +665,43 @@ func (hp *HybridProcessor) handleWorkloadQuery(ctx context.Context, parsed *llm.
 		}
 	}
 
+	// 附上 GitHub 数据的采集时间，便于判断统计是否过期
+	freshness := hp.workloadFreshnessNote(ctx)
+
 	if len(stats) == 0 {
 		return fmt.Sprintf("在 %s 到 %s 期间没有找到提交记录。",
 			startTime.Format("2006-01-02"),
-			endTime.Format("2006-01-02")), nil
+			endTime.Format("2006-01-02")) + freshness, nil
 	}
 
+	// 附上最近的提交信息，让 LLM 概括实际做了什么，而不只是数字
+	workloads := hp.attachRecentCommits(ctx, stats, startTime, endTime)
+
 	// 使用LLM生成友好回复
-	response, err := hp.llmClient.GenerateResponse(ctx, parsed.RawQuery, stats)
+	response, err := hp.llmClient.GenerateResponse(ctx, parsed.RawQuery+workloadSummaryInstruction, workloads)
 	if err != nil {
-		return hp.formatWorkloadStats(stats, startTime, endTime), nil
+		return hp.formatWorkloadStats(workloads, startTime, endTime) + freshness, nil
 	}
 
-	return response, nil
+	return response + freshness, nil
 }
 
 // formatWorkloadStats 格式化工作量统计
-func (hp *HybridProcessor) formatWorkloadStats(stats []*model.CommitStats, start, end time.Time) string {
-	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("📊 工作量统计 (%s ~ %s)\n\n",
-		start.Format("01-02"), end.Format("01-02")))
-
-	for _, s := range stats {
-		sb.WriteString(fmt.Sprintf("👤 %s\n", s.AuthorName))
-		sb.WriteString(fmt.Sprintf("   提交: %d 次\n", s.CommitCount))
-		sb.WriteString(fmt.Sprintf("   新增: %d 行 | 删除: %d 行\n", s.Additions, s.Deletions))
-		sb.WriteString(fmt.Sprintf("   涉及仓库: %d 个\n\n", s.RepoCount))
+func (hp *HybridProcessor) formatWorkloadStats(workloads []*memberWorkload, start, end time.Time) string {
+	data := workloadTemplateData{Start: start, End: end}
+	for _, w := range workloads {
+		s := w.Stats
+		data.Workloads = append(data.Workloads, workloadTemplateItem{
+			Name:          s.AuthorName,
+			CommitCount:   s.CommitCount,
+			Additions:     s.Additions,
+			Deletions:     s.Deletions,
+			RepoCount:     s.RepoCount,
+			RecentCommits: formatRecentCommits(w.RecentCommits, 5),
+		})
 	}
 
-	return sb.String()
+	return hp.templates.Render(tmplWorkload, data)
 }
 
 // handleMessageSearch 处理消息搜索（支持语义搜索）
@@ -668,7 +744,7 @@ func (hp *HybridProcessor) handleSemanticSearch(ctx context.Context, parsed *llm
 	log.Printf("Hybrid search time range: %s ~ %s", startTime.Format("2006-01-02 15:04"), endTime.Format("2006-01-02 15:04"))
 
 	// 执行混合搜索（语义 + 关键词融合 + 同义词扩展 + 动态 top-k）
-	results, err := hp.svcCtx.Services.RAG.HybridSearch(ctx, query, parsed.Keywords, 15, hybridOpts)
+	results, err := hp.svcCtx.Services.RAG.HybridSearch(ctx, query, parsed.Keywords, hp.searchFetchLimit(), hybridOpts)
 	if err != nil {
 		log.Printf("Hybrid search failed: %v, falling back to keyword search", err)
 		return hp.handleKeywordSearch(ctx, parsed, currentChatID)
@@ -680,7 +756,7 @@ func (hp *HybridProcessor) handleSemanticSearch(ctx context.Context, parsed *llm
 			log.Printf("No results with filters, trying without time filter")
 			hybridOpts.StartTime = nil
 			hybridOpts.EndTime = nil
-			results, err = hp.svcCtx.Services.RAG.HybridSearch(ctx, query, parsed.Keywords, 15, hybridOpts)
+			results, err = hp.svcCtx.Services.RAG.HybridSearch(ctx, query, parsed.Keywords, hp.searchFetchLimit(), hybridOpts)
 			if err != nil || len(results) == 0 {
 				return "没有找到相关的消息。", nil
 			}
@@ -689,23 +765,29 @@ func (hp *HybridProcessor) handleSemanticSearch(ctx context.Context, parsed *llm
 		}
 	}
 
-	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("🔍 混合搜索找到 %d 条相关消息:\n\n", len(results)))
+	scores := make([]float32, len(results))
+	for i, r := range results {
+		scores[i] = r.Score
+	}
+	scores = calibrateScores(scores, hp.svcCtx.Config.LLM)
 
+	data := searchTemplateData{Total: len(results)}
+	displayLimit := hp.searchDisplayLimit()
 	for i, r := range results {
-		if i >= 10 {
-			sb.WriteString(fmt.Sprintf("...(还有 %d 条消息)\n", len(results)-10))
+		if i >= displayLimit {
+			data.More = len(results) - displayLimit
 			break
 		}
-		sb.WriteString(fmt.Sprintf("[%s] %s 在「%s」:\n%s\n(相关度: %.0f%%)\n\n",
-			r.CreatedAt.Format("01-02 15:04"),
-			r.SenderName,
-			r.ChatName,
-			truncateString(r.Content, 150),
-			r.Score*100))
+		data.Results = append(data.Results, searchTemplateItem{
+			Time:    r.CreatedAt,
+			Sender:  r.SenderName,
+			Chat:    r.ChatName,
+			Content: truncateString(r.Content, 150),
+			Percent: scores[i] * 100,
+		})
 	}
 
-	return sb.String(), nil
+	return hp.templates.Render(tmplSemanticSearch, data), nil
 }
 
 // handleKeywordSearch 传统关键词搜索
@@ -718,10 +800,10 @@ func (hp *HybridProcessor) handleKeywordSearch(ctx context.Context, parsed *llm.
 
 	if len(parsed.Keywords) > 0 {
 		keyword := strings.Join(parsed.Keywords, " ")
-		messages, err = hp.svcCtx.MessageModel.SearchByContent(ctx, chatID, keyword, 20)
+		messages, err = hp.svcCtx.MessageModel.SearchByContent(ctx, chatID, keyword, hp.searchFetchLimit())
 	} else if len(parsed.TargetUsers) > 0 {
 		for _, user := range parsed.TargetUsers {
-			userMsgs, searchErr := hp.svcCtx.MessageModel.SearchBySender(ctx, chatID, user, "", 20)
+			userMsgs, searchErr := hp.svcCtx.MessageModel.SearchBySender(ctx, chatID, user, "", hp.searchFetchLimit())
 			if searchErr == nil {
 				messages = append(messages, userMsgs...)
 			}
@@ -739,12 +821,11 @@ func (hp *HybridProcessor) handleKeywordSearch(ctx context.Context, parsed *llm.
 		return "没有找到匹配的消息。", nil
 	}
 
-	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("🔍 找到 %d 条相关消息:\n\n", len(messages)))
-
+	data := keywordSearchTemplateData{Total: len(messages)}
+	displayLimit := hp.searchDisplayLimit()
 	for i, msg := range messages {
-		if i >= 10 {
-			sb.WriteString(fmt.Sprintf("...(还有 %d 条消息)\n", len(messages)-10))
+		if i >= displayLimit {
+			data.More = len(messages) - displayLimit
 			break
 		}
 		senderName := ""
@@ -755,13 +836,14 @@ func (hp *HybridProcessor) handleKeywordSearch(ctx context.Context, parsed *llm.
 		if msg.Content.Valid {
 			content = msg.Content.String
 		}
-		sb.WriteString(fmt.Sprintf("[%s] %s: %s\n",
-			msg.CreatedAt.Format("01-02 15:04"),
-			senderName,
-			truncateString(content, 100)))
+		data.Messages = append(data.Messages, keywordSearchTemplateItem{
+			Time:    msg.CreatedAt,
+			Sender:  senderName,
+			Content: truncateString(content, 100),
+		})
 	}
 
-	return sb.String(), nil
+	return hp.templates.Render(tmplKeywordSearch, data), nil
 }
 
 // handleSummarize 处理总结请求
@@ -772,7 +854,7 @@ func (hp *HybridProcessor) handleSummarize(ctx context.Context, parsed *llm.Pars
 	var chatID string
 	var groupName string
 
-	if parsed.TargetGroup != "" {
+	if parsed.TargetGroup != "" && !isAllGroupsScope(parsed.TargetGroup) {
 		// 用户指定了目标群
 		log.Printf("Looking for group: %s", parsed.TargetGroup)
 		foundID, foundName := hp.findChatByName(ctx, parsed.TargetGroup)
@@ -784,7 +866,11 @@ func (hp *HybridProcessor) handleSummarize(ctx context.Context, parsed *llm.Pars
 		chatID = foundID
 		groupName = foundName
 		log.Printf("Found group: %s (chat_id: %s)", groupName, chatID)
-	} else if isPrivateChat(currentChatID) {
+	} else if def := defaultChatFrom(ctx); def != nil && parsed.TargetGroup == "" {
+		// 私聊且设置了默认群时，总结默认群
+		chatID = def.ChatID
+		groupName = def.ChatName
+	} else if isPrivateChat(ctx, currentChatID) {
 		// 私聊时，总结所有群（chatID 为空）
 		chatID = ""
 		groupName = "所有群"
@@ -795,6 +881,21 @@ func (hp *HybridProcessor) handleSummarize(ctx context.Context, parsed *llm.Pars
 
 	log.Printf("Summarizing messages from %s to %s, chatID: %s", startTime.Format("2006-01-02 15:04"), endTime.Format("2006-01-02 15:04"), chatID)
 
+	// 已结束的时间段优先使用缓存；"重新总结" 时跳过缓存并用新结果覆盖
+	cacheable := isClosedTimeRange(parsed.TimeRange)
+	cacheKey := summaryCacheKey(chatID, startTime, endTime)
+	if cacheable && !isSummaryRefreshQuery(parsed.RawQuery) {
+		if summary, ok := hp.summaries.get(cacheKey); ok {
+			log.Printf("Using cached summary for %s", cacheKey)
+			return hp.templates.Render(tmplSummary, summaryTemplateData{
+				GroupName: groupName,
+				Start:     startTime,
+				End:       endTime,
+				Summary:   summary,
+			}), nil
+		}
+	}
+
 	messages, err := hp.svcCtx.MessageModel.GetMessagesByDateRange(ctx, chatID, startTime, endTime, 100)
 	if err != nil {
 		log.Printf("Failed to get messages: %v", err)
@@ -811,20 +912,19 @@ func (hp *HybridProcessor) handleSummarize(ctx context.Context, parsed *llm.Pars
 		return "没有找到需要总结的消息。", nil
 	}
 
+	forwardMode := hp.svcCtx.Config.LLM.SummaryForwardMode
 	var msgTexts []string
 	for _, msg := range messages {
-		senderName := ""
-		if msg.SenderName.Valid {
-			senderName = msg.SenderName.String
+		if line, ok := hp.summaryLine(ctx, msg, "15:04", forwardMode); ok {
+			msgTexts = append(msgTexts, line)
 		}
-		content := ""
-		if msg.Content.Valid {
-			content = msg.Content.String
-		}
-		msgTexts = append(msgTexts, fmt.Sprintf("[%s] %s: %s",
-			msg.CreatedAt.Format("15:04"),
-			senderName,
-			content))
+	}
+
+	if len(msgTexts) == 0 {
+		return "没有找到需要总结的消息（仅有转发内容）。", nil
+	}
+	if gc := hp.groupContext(ctx, chatID); gc != "" {
+		msgTexts = append([]string{gc}, msgTexts...)
 	}
 
 	log.Printf("Calling LLM to summarize %d messages", len(msgTexts))
@@ -834,17 +934,18 @@ func (hp *HybridProcessor) handleSummarize(ctx context.Context, parsed *llm.Pars
 		return "总结消息失败，请稍后重试。", err
 	}
 	log.Printf("LLM summary generated successfully")
-
-	title := "消息总结"
-	if groupName != "" {
-		title = fmt.Sprintf("「%s」消息总结", groupName)
+	if cacheable {
+		hp.summaries.put(cacheKey, summary)
 	}
 
-	return fmt.Sprintf("📋 %s (%s ~ %s)\n\n%s",
-		title,
-		startTime.Format("01-02 15:04"),
-		endTime.Format("01-02 15:04"),
-		summary), nil
+	result := hp.templates.Render(tmplSummary, summaryTemplateData{
+		GroupName: groupName,
+		Start:     startTime,
+		End:       endTime,
+		Summary:   summary,
+	})
+	hp.exportReportAsync(reportKindSummary, chatID, groupName, result)
+	return result, nil
 }
 
 // handleQA 处理基于聊天记录的问答
@@ -854,7 +955,7 @@ func (hp *HybridProcessor) handleQA(ctx context.Context, parsed *llm.ParsedQuery
 	// 确定搜索范围：私聊时搜索所有群，群聊时限定当前群
 	chatID := hp.getSearchChatID(currentChatID, parsed.TargetGroup, ctx)
 	log.Printf("handleQA: using chatID=%s (current=%s, target=%s, isPrivate=%v)",
-		chatID, currentChatID, parsed.TargetGroup, isPrivateChat(currentChatID))
+		chatID, currentChatID, parsed.TargetGroup, isPrivateChat(ctx, currentChatID))
 
 	// 获取时间范围（如果用户指定了时间）
 	startTime, endTime := hp.getTimeRange(parsed.TimeRange)
@@ -875,6 +976,19 @@ func (hp *HybridProcessor) handleQA(ctx context.Context, parsed *llm.ParsedQuery
 	keywords := hp.extractSearchKeywords(query, parsed.Keywords)
 	log.Printf("QA search keywords: %v", keywords)
 
+	// 跨群问答：先挑选最可能包含答案的群，缩小检索范围
+	var relevantChatIDs []string
+	var relevantChats map[string]bool
+	if chatID == "" {
+		relevantChatIDs = hp.findRelevantGroups(ctx, query, keywords)
+		if len(relevantChatIDs) > 0 {
+			relevantChats = make(map[string]bool, len(relevantChatIDs))
+			for _, id := range relevantChatIDs {
+				relevantChats[id] = true
+			}
+		}
+	}
+
 	// 根据查询类型决定搜索数量
 	// 统计类查询（如"最多的问题"）需要更多历史数据
 	searchLimit := 100
@@ -884,32 +998,111 @@ func (hp *HybridProcessor) handleQA(ctx context.Context, parsed *llm.ParsedQuery
 	}
 
 	// 使用改进的搜索策略：优先匹配多关键词，按相关度排序
-	type scoredMessage struct {
-		content   string
-		formatted string
-		score     int // 匹配的关键词数量，越多越相关
-		timestamp time.Time
+	var start, end *time.Time
+	if hasTimeFilter {
+		start, end = &startTime, &endTime
+	}
+	messageScores := hp.collectQAMessages(ctx, query, keywords, chatID, relevantChatIDs, relevantChats, start, end, searchLimit)
+
+	// 指定时间范围内没有找到时放宽时间条件重新搜索，回答中注明结果不在该时间范围内
+	widened := false
+	if len(messageScores) == 0 && hasTimeFilter && hp.qaTimeFallbackEnabled() {
+		log.Printf("handleQA: no results in %s ~ %s, retrying without time filter", startTime.Format("2006-01-02"), endTime.Format("2006-01-02"))
+		messageScores = hp.collectQAMessages(ctx, query, keywords, chatID, relevantChatIDs, relevantChats, nil, nil, searchLimit)
+		widened = len(messageScores) > 0
+	}
+
+	// 3. 按相关度排序（关键词匹配数 > 混合检索分数 > 时间）
+	var sortedMessages []*scoredMessage
+	for _, sm := range messageScores {
+		sortedMessages = append(sortedMessages, sm)
+	}
+	sortScoredMessages(sortedMessages)
+
+	// 转换为列表（根据查询类型调整数量，统计类需要更多上下文）
+	statistical := hp.isStatisticalQuery(query)
+	outputLimit := 80
+	if statistical {
+		outputLimit = 200 // 统计类查询需要更多消息来做准确分析
+	}
+	if len(sortedMessages) > outputLimit {
+		sortedMessages = sortedMessages[:outputLimit]
+	}
+	var relevantMessages []string
+	for _, sm := range sortedMessages {
+		relevantMessages = append(relevantMessages, sm.formatted)
+	}
+
+	log.Printf("Total unique messages found: %d (after sorting by relevance)", len(relevantMessages))
+
+	if len(relevantMessages) == 0 {
+		return "抱歉，我在聊天记录中没有找到与您问题相关的信息。您可以尝试：\n• 换个关键词提问\n• 指定具体的群名\n• 使用「搜索 XXX」查找相关消息", nil
+	}
+
+	// "列出..."、精确查找报错码等问题直接返回消息原文，不经 LLM 改写
+	if hp.listAnswerEnabled() && isListAnswerQuery(query) {
+		log.Printf("handleQA: list answer for %q, returning %d raw messages", query, len(relevantMessages))
+		return withWidenedNotice(formatListAnswer(relevantMessages), widened, startTime, endTime), nil
+	}
+
+	// 使用 LLM 根据找到的消息回答问题：按相关度加入完整消息直到长度预算用完，超出时舍弃相关度最低的
+	included, dropped := fillContextBudget(sortedMessages, hp.qaContextLength(statistical))
+	if dropped > 0 {
+		log.Printf("handleQA: context budget reached, %d/%d messages left out", dropped, len(sortedMessages))
+	}
+	context := buildQAContext(included, dropped)
+	var sources []answerSource
+	for _, sm := range included {
+		sources = append(sources, answerSource{sender: sm.sender, content: sm.content, timestamp: sm.timestamp})
 	}
+	if isRoleQuery(query) {
+		if members := hp.activeMembersContext(ctx, chatID); members != "" {
+			context = members + "\n\n" + context
+		}
+	}
+	if widened {
+		context = widenedContextNote(startTime, endTime) + "\n\n" + context
+	}
+	if gc := hp.groupContext(ctx, chatID); gc != "" {
+		context = gc + "\n\n" + context
+	}
+
+	answer, err := hp.answerWithContext(ctx, parsed.RawQuery, context)
+	if err != nil {
+		log.Printf("Failed to generate answer: %v", err)
+		// LLM 失败时，尝试提供一个简单的本地分析
+		return withWidenedNotice(hp.generateLocalAnswer(parsed.RawQuery, relevantMessages), widened, startTime, endTime), nil
+	}
+
+	return withWidenedNotice(hp.appendAnswerSources(answer, sources), widened, startTime, endTime), nil
+}
+
+// collectQAMessages 问答检索：组合关键词搜索 + 混合搜索补充，按内容去重；start/end 为 nil 时不限时间
+func (hp *HybridProcessor) collectQAMessages(ctx context.Context, query string, keywords []string, chatID string,
+	relevantChatIDs []string, relevantChats map[string]bool, start, end *time.Time, searchLimit int) map[string]*scoredMessage {
 	messageScores := make(map[string]*scoredMessage) // content -> scored message
 
-	// 1. 使用组合关键词搜索（优先返回同时匹配多个关键词的消息）
+	// 1. 使用组合关键词搜索（优先返回同时匹配多个关键词的消息），有时间范围时在 SQL 中限定
 	if len(keywords) >= 1 {
-		messages, matchCounts, err := hp.svcCtx.MessageModel.SearchByKeywordCombinations(ctx, chatID, keywords, searchLimit)
+		var messages []*model.ChatMessage
+		var matchCounts map[int64]int
+		var err error
+		if start != nil && end != nil {
+			messages, matchCounts, err = hp.svcCtx.MessageModel.SearchByKeywordCombinationsInRange(ctx, chatID, keywords, *start, *end, searchLimit)
+		} else {
+			messages, matchCounts, err = hp.svcCtx.MessageModel.SearchByKeywordCombinations(ctx, chatID, keywords, searchLimit)
+		}
 		if err == nil {
 			for _, msg := range messages {
 				if msg.Content.Valid {
-					// 如果有时间过滤，检查消息是否在时间范围内
-					if hasTimeFilter && (msg.CreatedAt.Before(startTime) || msg.CreatedAt.After(endTime)) {
+					if relevantChats != nil && !relevantChats[msg.ChatID] {
 						continue
 					}
-					// 获取发送者名称
-					senderName := "系统/机器人"
-					if msg.SenderName.Valid && msg.SenderName.String != "" {
-						senderName = msg.SenderName.String
-					}
+					// 获取发送者名称（缺失时按配置查询或显示标签）
+					senderName := hp.senderName(ctx, msg)
 					formatted := fmt.Sprintf("[%s] %s: %s",
 						msg.CreatedAt.Format("01-02 15:04"),
-						senderName,
+						hp.senderLabel(ctx, msg.SenderID.String, senderName),
 						msg.Content.String)
 
 					score := matchCounts[msg.ID]
@@ -917,6 +1110,7 @@ func (hp *HybridProcessor) handleQA(ctx context.Context, parsed *llm.ParsedQuery
 						messageScores[msg.Content.String] = &scoredMessage{
 							content:   msg.Content.String,
 							formatted: formatted,
+							sender:    senderName,
 							score:     score,
 							timestamp: msg.CreatedAt,
 						}
@@ -931,99 +1125,40 @@ func (hp *HybridProcessor) handleQA(ctx context.Context, parsed *llm.ParsedQuery
 
 	// 2. 使用混合搜索补充（语义 + 关键词融合 + 同义词扩展）
 	if hp.svcCtx.Services.RAG != nil && hp.svcCtx.Services.RAG.IsEnabled() {
-		searchQuery := query
-		if len(keywords) > 0 {
-			searchQuery = strings.Join(keywords, " ")
-		}
-
-		// 构建混合搜索选项
-		hybridOpts := service.DefaultHybridSearchOptions()
-		hybridOpts.ChatID = chatID
-		hybridOpts.Keywords = keywords
-		if hasTimeFilter {
-			hybridOpts.StartTime = &startTime
-			hybridOpts.EndTime = &endTime
-		}
-
-		hybridLimit := searchLimit / 2 // 混合搜索用一半的限制
-		results, err := hp.svcCtx.Services.RAG.HybridSearch(ctx, searchQuery, keywords, hybridLimit, hybridOpts)
+		results, err := hp.qaHybridSearch(ctx, query, keywords, chatID, relevantChatIDs, start, end, searchLimit/2) // 混合搜索用一半的限制
 		if err != nil {
 			log.Printf("Hybrid search failed: %v", err)
 		} else {
 			log.Printf("Hybrid search found %d results", len(results))
 			for _, r := range results {
-				if _, exists := messageScores[r.Content]; !exists {
-					// 计算这条消息匹配了多少个关键词
-					score := 0
-					contentLower := strings.ToLower(r.Content)
-					for _, kw := range keywords {
-						if strings.Contains(contentLower, strings.ToLower(kw)) {
-							score++
-						}
+				if existing, exists := messageScores[r.Content]; exists {
+					// 关键词搜索已找到的消息记录其混合检索分数，用于同分排序
+					if r.Score > existing.relevance {
+						existing.relevance = r.Score
 					}
-					messageScores[r.Content] = &scoredMessage{
-						content:   r.Content,
-						formatted: fmt.Sprintf("[%s] %s: %s", r.CreatedAt.Format("01-02 15:04"), r.SenderName, r.Content),
-						score:     score,
-						timestamp: r.CreatedAt,
+					continue
+				}
+				// 计算这条消息匹配了多少个关键词
+				score := 0
+				contentLower := strings.ToLower(r.Content)
+				for _, kw := range keywords {
+					if strings.Contains(contentLower, strings.ToLower(kw)) {
+						score++
 					}
 				}
+				messageScores[r.Content] = &scoredMessage{
+					content:   r.Content,
+					formatted: fmt.Sprintf("[%s] %s: %s", r.CreatedAt.Format("01-02 15:04"), hp.senderLabel(ctx, r.SenderID, r.SenderName), r.Content),
+					sender:    r.SenderName,
+					score:     score,
+					relevance: r.Score,
+					timestamp: r.CreatedAt,
+				}
 			}
 		}
 	}
 
-	// 3. 按相关度排序（关键词匹配数 > 时间）
-	var sortedMessages []*scoredMessage
-	for _, sm := range messageScores {
-		sortedMessages = append(sortedMessages, sm)
-	}
-	sort.Slice(sortedMessages, func(i, j int) bool {
-		// 首先按匹配的关键词数量降序
-		if sortedMessages[i].score != sortedMessages[j].score {
-			return sortedMessages[i].score > sortedMessages[j].score
-		}
-		// 相同分数按时间降序
-		return sortedMessages[i].timestamp.After(sortedMessages[j].timestamp)
-	})
-
-	// 转换为列表（根据查询类型调整数量，统计类需要更多上下文）
-	outputLimit := 80
-	if hp.isStatisticalQuery(query) {
-		outputLimit = 200 // 统计类查询需要更多消息来做准确分析
-	}
-	var relevantMessages []string
-	for i, sm := range sortedMessages {
-		if i >= outputLimit {
-			break
-		}
-		relevantMessages = append(relevantMessages, sm.formatted)
-	}
-
-	log.Printf("Total unique messages found: %d (after sorting by relevance)", len(relevantMessages))
-
-	if len(relevantMessages) == 0 {
-		return "抱歉，我在聊天记录中没有找到与您问题相关的信息。您可以尝试：\n• 换个关键词提问\n• 指定具体的群名\n• 使用「搜索 XXX」查找相关消息", nil
-	}
-
-	// 使用 LLM 根据找到的消息回答问题
-	context := strings.Join(relevantMessages, "\n")
-	// 统计类查询允许更大的上下文
-	maxContextLen := 8000
-	if hp.isStatisticalQuery(query) {
-		maxContextLen = 15000
-	}
-	if len(context) > maxContextLen {
-		context = context[:maxContextLen] + "...(内容已截断)"
-	}
-
-	answer, err := hp.answerWithContext(ctx, parsed.RawQuery, context)
-	if err != nil {
-		log.Printf("Failed to generate answer: %v", err)
-		// LLM 失败时，尝试提供一个简单的本地分析
-		return hp.generateLocalAnswer(parsed.RawQuery, relevantMessages), nil
-	}
-
-	return answer, nil
+	return messageScores
 }
 
 // generateLocalAnswer 当 LLM 不可用时，生成本地回答
@@ -1035,10 +1170,11 @@ func (hp *HybridProcessor) generateLocalAnswer(query string, messages []string)
 	var sb strings.Builder
 	sb.WriteString(fmt.Sprintf("📝 找到 %d 条相关消息，以下是关键内容：\n\n", len(messages)))
 
-	// 显示最多10条消息
+	// 显示最多 Search.DisplayLimit 条消息
+	displayLimit := hp.searchDisplayLimit()
 	displayCount := len(messages)
-	if displayCount > 10 {
-		displayCount = 10
+	if displayCount > displayLimit {
+		displayCount = displayLimit
 	}
 
 	for i := 0; i < displayCount; i++ {
@@ -1050,8 +1186,8 @@ func (hp *HybridProcessor) generateLocalAnswer(query string, messages []string)
 		sb.WriteString(fmt.Sprintf("%d. %s\n", i+1, msg))
 	}
 
-	if len(messages) > 10 {
-		sb.WriteString(fmt.Sprintf("\n...(还有 %d 条消息未显示)\n", len(messages)-10))
+	if len(messages) > displayLimit {
+		sb.WriteString(fmt.Sprintf("\n...(还有 %d 条消息未显示)\n", len(messages)-displayLimit))
 	}
 
 	sb.WriteString("\n💡 提示：AI 服务暂时繁忙，以上是原始消息记录。请稍后重试获取智能分析。")
@@ -1342,19 +1478,16 @@ func (hp *HybridProcessor) handlePersonActivityQuery(ctx context.Context, parsed
 	// 构建消息上下文
 	var msgTexts []string
 	for _, msg := range messages {
-		if msg.Content.Valid && msg.SenderName.Valid {
+		if msg.Content.Valid {
 			msgTexts = append(msgTexts,
 				fmt.Sprintf("[%s] %s: %s",
 					msg.CreatedAt.Format("01-02 15:04"),
-					msg.SenderName.String,
+					hp.messageSenderLabel(ctx, msg),
 					msg.Content.String))
 		}
 	}
 
-	context := strings.Join(msgTexts, "\n")
-	if len(context) > 8000 {
-		context = context[:8000] + "..."
-	}
+	context := truncateContext(strings.Join(msgTexts, "\n"), hp.contextMaxTokens())
 
 	// 让 LLM 总结这个人做了什么
 	prompt := fmt.Sprintf(`请根据以下聊天记录，总结 %s 做了什么工作。
@@ -1376,6 +1509,7 @@ func (hp *HybridProcessor) answerWithContext(ctx context.Context, question, cont
 	if hp.llmClient == nil {
 		return "", fmt.Errorf("LLM client not available")
 	}
+	context = truncateContext(context, hp.contextMaxTokens())
 
 	prompt := fmt.Sprintf(`根据聊天记录回答问题。
 
@@ -1511,25 +1645,42 @@ func (hp *HybridProcessor) matchGroupWithLLM(ctx context.Context, userQuery stri
 	return ""
 }
 
+// chatTypeKey context 中保存会话类型的 key
+type chatTypeKey struct{}
+
+// withChatType 在 context 中记录会话类型
+func withChatType(ctx context.Context, chatType string) context.Context {
+	if chatType == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, chatTypeKey{}, chatType)
+}
+
 // isPrivateChat 判断是否是私聊
-// 群聊 chat_id 以 "oc_" 开头，私聊时传入的是用户 open_id (以 "ou_" 开头)
-func isPrivateChat(chatID string) bool {
+// 优先使用事件中的 chat_type（只有 p2p 是私聊，话题群等其他类型都按群聊处理）；
+// context 中没有类型时回退到前缀判断：群聊 chat_id 以 "oc_" 开头，私聊时传入的是用户 open_id
+func isPrivateChat(ctx context.Context, chatID string) bool {
+	if chatType, ok := ctx.Value(chatTypeKey{}).(string); ok {
+		return chatType == "p2p"
+	}
 	return !strings.HasPrefix(chatID, "oc_")
 }
 
 // getSearchChatID 获取搜索时使用的 chatID
-// 私聊时返回空字符串（搜索所有群），群聊时返回当前群ID
+// 私聊时返回默认群ID或空字符串（搜索所有群），群聊时返回当前群ID
 func (hp *HybridProcessor) getSearchChatID(currentChatID string, targetGroup string, ctx context.Context) string {
 	// 如果用户指定了目标群，优先使用
-	if targetGroup != "" {
-		if foundID, _ := hp.findChatByName(ctx, targetGroup); foundID != "" {
-			return foundID
-		}
+	var foundID string
+	if targetGroup != "" && !isAllGroupsScope(targetGroup) {
+		foundID, _ = hp.findChatByName(ctx, targetGroup)
 	}
 
-	// 私聊时，搜索所有群（返回空字符串）
-	if isPrivateChat(currentChatID) {
-		return ""
+	// 私聊时，未指定群则使用默认群，否则搜索所有群（返回空字符串）
+	if isPrivateChat(ctx, currentChatID) {
+		return privateScopeChatID(targetGroup, foundID, defaultChatFrom(ctx))
+	}
+	if foundID != "" {
+		return foundID
 	}
 
 	// 群聊时，限定在当前群
@@ -1586,6 +1737,9 @@ func (hp *HybridProcessor) getTimeRange(tr llm.TimeRange) (time.Time, time.Time)
 		thisMonthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
 		lastMonthStart := thisMonthStart.AddDate(0, -1, 0)
 		return lastMonthStart, thisMonthStart
+	case llm.TimeRangeThisQuarter, llm.TimeRangeLastQuarter, llm.TimeRangeThisYear, llm.TimeRangeLastYear:
+		start, end, _ := llm.CoarseTimeRange(tr, now)
+		return start, end
 	case llm.TimeRangeRecentMonth:
 		// 最近30天（不是上个自然月）
 		return today.AddDate(0, 0, -30), now
@@ -1611,6 +1765,7 @@ func (hp *HybridProcessor) getHelpMessage() string {
 📋 **消息总结**
 • "总结一下今天的讨论"
 • "本周群消息摘要"
+• "重新总结昨天的讨论"（忽略缓存，包含新同步的消息）
 
 💡 **提示**
 • 支持自然语言提问
@@ -1631,6 +1786,8 @@ func truncateString(s string, maxLen int) string {
 func (hp *HybridProcessor) handleSiteQueryByLLM(ctx context.Context, parsed *llm.ParsedQuery) (string, error) {
 	sitePrefix := strings.ToLower(parsed.SitePrefix)
 	siteID := strings.TrimSpace(parsed.SiteID)
+	// 只询问某个字段时（如 "by4 的后台地址"）只返回该字段
+	field := strings.TrimSpace(parsed.SiteField)
 
 	// 优先使用站点前缀查询
 	if sitePrefix != "" {
@@ -1638,7 +1795,10 @@ func (hp *HybridProcessor) handleSiteQueryByLLM(ctx context.Context, parsed *llm
 		if !hp.svcCtx.Config.Bitable.Enabled {
 			return "站点信息查询功能未启用。", nil
 		}
-		return hp.handleSiteQuery(ctx, parsed.RawQuery, sitePrefix)
+		if field == "" {
+			field = extractSiteField(parsed.RawQuery, sitePrefix)
+		}
+		return hp.handleSiteQuery(ctx, parsed.RawQuery, sitePrefix, field)
 	}
 
 	// 如果有站点ID，通过ID反查
@@ -1647,7 +1807,10 @@ func (hp *HybridProcessor) handleSiteQueryByLLM(ctx context.Context, parsed *llm
 		if !hp.svcCtx.Config.Bitable.Enabled {
 			return "站点信息查询功能未启用。", nil
 		}
-		return hp.handleSiteQueryByID(ctx, parsed.RawQuery, siteID)
+		if field == "" {
+			field = extractSiteField(parsed.RawQuery, siteID)
+		}
+		return hp.handleSiteQueryByID(ctx, parsed.RawQuery, siteID, field)
 	}
 
 	// 如果都没有，回退到聊天记录搜索
@@ -1655,111 +1818,61 @@ func (hp *HybridProcessor) handleSiteQueryByLLM(ctx context.Context, parsed *llm
 	return hp.handleQA(ctx, parsed, "")
 }
 
-// handleSiteQueryByID 通过站点ID查询站点信息
-func (hp *HybridProcessor) handleSiteQueryByID(ctx context.Context, query, siteID string) (string, error) {
-	appToken := hp.svcCtx.Config.Bitable.AppToken
-	tableID := hp.svcCtx.Config.Bitable.TableID
-
-	if appToken == "" || tableID == "" {
-		log.Printf("Bitable config missing: appToken=%s, tableID=%s", appToken, tableID)
+// handleSiteQueryByID 通过站点ID查询站点信息，field 不为空时只返回该字段
+func (hp *HybridProcessor) handleSiteQueryByID(ctx context.Context, query, siteID, field string) (string, error) {
+	tables := hp.svcCtx.Config.Bitable.SiteTables()
+	if len(tables) == 0 {
+		log.Printf("Bitable config missing: no site tables configured")
 		return "", nil
 	}
 
-	// 通过站点ID查询
-	record, err := hp.svcCtx.LarkClient.GetSiteInfoBySiteID(ctx, appToken, tableID, siteID)
+	// 通过站点ID依次查询各表
+	match, err := hp.getSiteInfoBySiteID(ctx, siteID)
 	if err != nil {
 		log.Printf("Failed to query site info by ID %s: %v", siteID, err)
 		return "", err
 	}
 
-	if record == nil {
+	if match == nil {
 		return fmt.Sprintf("未找到站点ID为「%s」的站点信息。", siteID), nil
 	}
 
 	// 获取站点前缀用于显示
-	prefix := getFieldString(record.Fields, "站点前缀")
+	prefix := getFieldString(match.record.Fields, match.table.PrefixField)
 	if prefix == "" {
 		prefix = siteID
 	}
 
 	// 格式化站点信息
-	return hp.formatSiteInfo(record, prefix), nil
+	return withSiteSource(hp.formatSiteInfo(match.record, prefix, field), match, len(tables)), nil
 }
 
-// handleSiteQuery 处理站点信息查询
-func (hp *HybridProcessor) handleSiteQuery(ctx context.Context, query, sitePrefix string) (string, error) {
-	appToken := hp.svcCtx.Config.Bitable.AppToken
-	tableID := hp.svcCtx.Config.Bitable.TableID
-
-	if appToken == "" || tableID == "" {
-		log.Printf("Bitable config missing: appToken=%s, tableID=%s", appToken, tableID)
+// handleSiteQuery 处理站点信息查询，field 不为空时只返回该字段
+func (hp *HybridProcessor) handleSiteQuery(ctx context.Context, query, sitePrefix, field string) (string, error) {
+	tables := hp.svcCtx.Config.Bitable.SiteTables()
+	if len(tables) == 0 {
+		log.Printf("Bitable config missing: no site tables configured")
 		return "", nil
 	}
 
-	// 查询站点信息
-	record, err := hp.svcCtx.LarkClient.GetSiteInfoByPrefix(ctx, appToken, tableID, sitePrefix)
+	// 查询站点信息（按配置顺序查找各表，返回第一条匹配）
+	match, err := hp.getSiteInfoByPrefix(ctx, sitePrefix)
 	if err != nil {
 		log.Printf("Failed to query site info for %s: %v", sitePrefix, err)
 		return "", err
 	}
 
-	if record == nil {
+	if match == nil {
 		return fmt.Sprintf("未找到站点前缀为「%s」的站点信息。", sitePrefix), nil
 	}
 
 	// 格式化站点信息
-	return hp.formatSiteInfo(record, sitePrefix), nil
+	return withSiteSource(hp.formatSiteInfo(match.record, sitePrefix, field), match, len(tables)), nil
 }
 
 // formatSiteInfo 格式化站点信息
-func (hp *HybridProcessor) formatSiteInfo(record *lark.BitableRecord, prefix string) string {
-	fields := record.Fields
-
-	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("📍 站点「%s」信息：\n\n", strings.ToUpper(prefix)))
-
-	// 站点ID
-	if siteID := getFieldString(fields, "站点ID"); siteID != "" {
-		sb.WriteString(fmt.Sprintf("• 站点ID: %s\n", siteID))
-	}
-
-	// 站点前缀
-	if sitePrefix := getFieldString(fields, "站点前缀"); sitePrefix != "" {
-		sb.WriteString(fmt.Sprintf("• 站点前缀: %s\n", sitePrefix))
-	}
-
-	// 国家
-	if country := getFieldString(fields, "国家"); country != "" {
-		sb.WriteString(fmt.Sprintf("• 国家: %s\n", country))
-	}
-
-	// 状态
-	if status := getFieldString(fields, "状态"); status != "" {
-		sb.WriteString(fmt.Sprintf("• 状态: %s\n", status))
-	}
-
-	// 前台域名（可能有多个，表格中有前台域名1-6）
-	frontDomains := []string{}
-	for i := 1; i <= 6; i++ {
-		fieldName := fmt.Sprintf("前台域名%d", i)
-		if domain := getFieldString(fields, fieldName); domain != "" {
-			frontDomains = append(frontDomains, domain)
-		}
-	}
-	// 还有多域名字段
-	if multiDomain := getFieldString(fields, "多域名"); multiDomain != "" {
-		frontDomains = append(frontDomains, multiDomain)
-	}
-	if len(frontDomains) > 0 {
-		sb.WriteString(fmt.Sprintf("• 前台域名: %s\n", strings.Join(frontDomains, ", ")))
-	}
-
-	// 注意事项
-	if note := getFieldString(fields, "注意"); note != "" {
-		sb.WriteString(fmt.Sprintf("• 注意: %s\n", note))
-	}
-
-	return sb.String()
+func (hp *HybridProcessor) formatSiteInfo(record *lark.BitableRecord, prefix, field string) string {
+	return formatSiteRecord(record.Fields, hp.svcCtx.Config.Bitable.Fields, prefix, field)
 }
 
 // getFieldString 从字段中获取字符串值
@@ -1877,6 +1990,7 @@ func (hp *HybridProcessor) handleGroupTimeline(ctx context.Context, parsed *llm.
 		return hp.formatWeeklySummariesFallback(report), nil
 	}
 
+	hp.exportReportAsync(reportKindTimeline, chatID, groupName, finalReport)
 	return finalReport, nil
 }
 
@@ -1891,7 +2005,7 @@ func (hp *HybridProcessor) resolveTargetGroup(ctx context.Context, currentChatID
 	}
 
 	// 如果是群聊，使用当前群
-	if !isPrivateChat(currentChatID) {
+	if !isPrivateChat(ctx, currentChatID) {
 		// 获取群名
 		group, err := hp.svcCtx.GroupModel.FindByChatID(ctx, currentChatID)
 		if err == nil && group.ChatName.Valid {
@@ -1918,11 +2032,25 @@ func (hp *HybridProcessor) generateWeeklySummaries(ctx context.Context, chatID s
 	maxWeeks := 52 // 最多处理52周
 	processedWeeks := 0
 
+	// 已结束的周直接使用缓存的总结
+	cached := hp.loadCachedWeeks(ctx, chatID, weekStart)
+	now := time.Now()
+
 	for weekStart.Before(endDate) && processedWeeks < maxWeeks {
-		weekEnd := weekStart.AddDate(0, 0, 7)
+		fullWeekEnd := weekStart.AddDate(0, 0, 7)
+		weekEnd := fullWeekEnd
 		if weekEnd.After(endDate) {
 			weekEnd = endDate
 		}
+		// 查询范围覆盖整周时才能使用/写入已结束周的缓存
+		coversWeek := !weekEnd.Before(fullWeekEnd)
+
+		if ws, ok := cached[weekStart.Unix()]; ok && coversWeek {
+			summaries = append(summaries, ws)
+			weekStart = weekEnd
+			processedWeeks++
+			continue
+		}
 
 		// 获取本周消息
 		messages, err := hp.svcCtx.MessageModel.GetMessagesByDateRange(ctx, chatID, weekStart, weekEnd, 200)
@@ -1943,10 +2071,10 @@ func (hp *HybridProcessor) generateWeeklySummaries(ctx context.Context, chatID s
 		// 获取本周参与者
 		participants, _ := hp.svcCtx.MessageModel.GetDistinctSendersByDateRange(ctx, chatID, weekStart, weekEnd)
 
-		// 生成本周总结
-		weeklySummary, err := hp.summarizeWeekMessages(ctx, messages, weekStart, weekEnd)
-		if err != nil {
-			log.Printf("Failed to summarize week %s: %v", weekStart.Format("2006-01-02"), err)
+		// 生成本周总结（失败时减少采样消息数重试一次）
+		weeklySummary, err := hp.summarizeWeekWithRetry(ctx, messages, weekStart, weekEnd)
+		if err != nil || weeklySummary == nil {
+			log.Printf("Week %s uses message-count fallback, summary failed: %v", weekStart.Format("2006-01-02"), err)
 			// 即使LLM失败，也记录基本信息
 			weeklySummary = &WeeklySummary{
 				WeekStart:    weekStart,
@@ -1960,6 +2088,11 @@ func (hp *HybridProcessor) generateWeeklySummaries(ctx context.Context, chatID s
 			weeklySummary.WeekEnd = weekEnd
 			weeklySummary.Participants = participants
 			weeklySummary.MessageCount = len(messages)
+
+			// 已结束的周写入缓存；当前周也写入，下次查询时重新生成覆盖
+			if current := fullWeekEnd.After(now); coversWeek || current {
+				hp.saveWeeklySummary(ctx, chatID, weeklySummary, coversWeek && !current)
+			}
 		}
 
 		summaries = append(summaries, *weeklySummary)
@@ -1972,17 +2105,16 @@ func (hp *HybridProcessor) generateWeeklySummaries(ctx context.Context, chatID s
 	return summaries, nil
 }
 
-// summarizeWeekMessages 总结单周消息
-func (hp *HybridProcessor) summarizeWeekMessages(ctx context.Context, messages []*model.ChatMessage, weekStart, weekEnd time.Time) (*WeeklySummary, error) {
+// summarizeWeekMessages 总结单周消息，最多均匀采样 maxMessages 条
+func (hp *HybridProcessor) summarizeWeekMessages(ctx context.Context, messages []*model.ChatMessage, weekStart, weekEnd time.Time, maxMessages int) (*WeeklySummary, error) {
 	if len(messages) == 0 {
 		return nil, nil
 	}
 
 	// 限制消息数量，避免 Token 超限
-	maxMessages := 100
 	if len(messages) > maxMessages {
-		// 均匀采样
-		step := len(messages) / maxMessages
+		// 均匀采样（向上取整，保证不超过 maxMessages）
+		step := (len(messages) + maxMessages - 1) / maxMessages
 		var sampled []*model.ChatMessage
 		for i := 0; i < len(messages); i += step {
 			sampled = append(sampled, messages[i])
@@ -2015,10 +2147,11 @@ func (hp *HybridProcessor) summarizeWeekMessages(ctx context.Context, messages [
 	}
 
 	// 拼接消息文本，限制长度
-	messageContent := strings.Join(msgTexts, "\n")
-	if len(messageContent) > 6000 {
-		messageContent = messageContent[:6000] + "\n...(内容已截断)"
+	maxTokens := weeklySummaryMaxTokens
+	if configured := hp.contextMaxTokens(); configured < maxTokens {
+		maxTokens = configured
 	}
+	messageContent := truncateContext(strings.Join(msgTexts, "\n"), maxTokens)
 
 	// 调用 LLM 生成周总结
 	return hp.generateWeeklySummaryWithLLM(ctx, messageContent, weekStart, weekEnd)
@@ -2055,12 +2188,8 @@ func (hp *HybridProcessor) generateWeeklySummaryWithLLM(ctx context.Context, mes
 		return nil, err
 	}
 
-	// 解析 JSON 响应
-	resp = strings.TrimSpace(resp)
-	resp = strings.TrimPrefix(resp, "```json")
-	resp = strings.TrimPrefix(resp, "```")
-	resp = strings.TrimSuffix(resp, "```")
-	resp = strings.TrimSpace(resp)
+	// 解析 JSON 响应（兼容代码块包裹和前后附加的说明文字）
+	resp = llm.ExtractJSONObject(resp)
 
 	var result struct {
 		Summary    string   `json:"summary"`
@@ -2112,12 +2241,7 @@ func (hp *HybridProcessor) generateFinalTimelineReport(ctx context.Context, user
 		weekSummaries = append(weekSummaries, weekInfo)
 	}
 
-	summaryContent := strings.Join(weekSummaries, "\n\n")
-
-	// 限制长度
-	if len(summaryContent) > 8000 {
-		summaryContent = summaryContent[:8000] + "\n...(内容已截断)"
-	}
+	summaryContent := truncateContext(strings.Join(weekSummaries, "\n\n"), hp.contextMaxTokens())
 
 	prompt := fmt.Sprintf(`用户问题：%s
 
@@ -0,0 +1,527 @@
+package ai
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"team-assistant/internal/backoff"
+	"team-assistant/internal/config"
+	"team-assistant/internal/model"
+	"team-assistant/internal/service"
+	"team-assistant/internal/svc"
+	"team-assistant/pkg/lark"
+	"team-assistant/pkg/llm"
+)
+
+func TestIsPrivateChat(t *testing.T) {
+	tests := []struct {
+		name     string
+		chatType string
+		chatID   string
+		want     bool
+	}{
+		{"私聊 open_id", "p2p", "ou_353b0f0a3a4fd36dc3fbd7786240b043", true},
+		{"普通群", "group", "oc_0dc23a40dbd0b12a0707abaadeaced28", false},
+		{"话题群", "topic_group", "oc_5ad11d72b830411d72b836c20", false},
+		{"非 oc_ 前缀的群", "group", "om_topic_chat_id", false},
+		{"私聊但 ID 形如群", "p2p", "oc_0dc23a40dbd0b12a0707abaadeaced28", true},
+		{"未知类型回退前缀-私聊", "", "ou_353b0f0a3a4fd36dc3fbd7786240b043", true},
+		{"未知类型回退前缀-群聊", "", "oc_0dc23a40dbd0b12a0707abaadeaced28", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := withChatType(context.Background(), tt.chatType)
+			if got := isPrivateChat(ctx, tt.chatID); got != tt.want {
+				t.Errorf("isPrivateChat(%q, %q) = %v, want %v", tt.chatType, tt.chatID, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDedupeSenderAliases(t *testing.T) {
+	tests := []struct {
+		names []string
+		want  []string
+	}{
+		{[]string{"小王"}, []string{"小王"}},
+		{[]string{"小王", "小王"}, []string{"小王"}},
+		{[]string{"王伟", "王伟(Wayne)"}, []string{"王伟"}},
+		{[]string{"wayne", "王伟", ""}, []string{"wayne", "王伟"}},
+	}
+
+	for _, tt := range tests {
+		got := dedupeSenderAliases(tt.names)
+		if len(got) != len(tt.want) {
+			t.Errorf("dedupeSenderAliases(%v) = %v, want %v", tt.names, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("dedupeSenderAliases(%v) = %v, want %v", tt.names, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestTrimCatchUpMessages(t *testing.T) {
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.Local)
+	var messages []*model.ChatMessage
+	for i := 0; i < 3; i++ {
+		// 按时间倒序，与 GetMessagesByDateRange 一致
+		messages = append(messages, &model.ChatMessage{MessageID: fmt.Sprintf("om_%d", i), CreatedAt: base.Add(-time.Duration(i) * time.Hour)})
+	}
+
+	kept, truncated := trimCatchUpMessages(messages, 3)
+	if truncated || len(kept) != 3 {
+		t.Errorf("Expected all messages kept, got %d truncated=%v", len(kept), truncated)
+	}
+	kept, truncated = trimCatchUpMessages(messages, 2)
+	if !truncated || len(kept) != 2 || kept[0].MessageID != "om_0" || kept[1].MessageID != "om_1" {
+		t.Errorf("Expected the 2 most recent messages with truncated=true, got %d truncated=%v", len(kept), truncated)
+	}
+}
+
+func TestIsCatchUpQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"我不在的时候群里发生了啥", true},
+		{"我错过了什么", true},
+		{"补课", true},
+		{"总结一下今天的讨论", false},
+		{"张三这周干了什么", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsCatchUpQuery(tt.query); got != tt.want {
+			t.Errorf("IsCatchUpQuery(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestFormatSummaryLine(t *testing.T) {
+	createdAt := time.Date(2024, 3, 1, 10, 30, 0, 0, time.Local)
+	native := &model.ChatMessage{
+		SenderName: sql.NullString{String: "张三", Valid: true},
+		Content:    sql.NullString{String: "明天发版", Valid: true},
+		CreatedAt:  createdAt,
+	}
+	forwarded := &model.ChatMessage{
+		SenderName:  sql.NullString{String: "李四", Valid: true},
+		Content:     sql.NullString{String: "其他群的通知", Valid: true},
+		IsForwarded: 1,
+		CreatedAt:   createdAt,
+	}
+
+	tests := []struct {
+		name   string
+		msg    *model.ChatMessage
+		mode   string
+		want   string
+		wantOK bool
+	}{
+		{"本群消息", native, ForwardModeLabel, "[10:30] 张三: 明天发版", true},
+		{"转发消息-默认标注", forwarded, "", "[10:30] 李四: 【转发】其他群的通知", true},
+		{"转发消息-标注", forwarded, ForwardModeLabel, "[10:30] 李四: 【转发】其他群的通知", true},
+		{"转发消息-排除", forwarded, ForwardModeExclude, "", false},
+		{"转发消息-不区分", forwarded, ForwardModeKeep, "[10:30] 李四: 其他群的通知", true},
+		{"本群消息-排除模式不受影响", native, ForwardModeExclude, "[10:30] 张三: 明天发版", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := formatSummaryLine(tt.msg, "15:04", tt.mode)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("formatSummaryLine() = (%q, %v), want (%q, %v)", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestRunWithTimeout(t *testing.T) {
+	t.Run("处理超时", func(t *testing.T) {
+		answer, timedOut, err := runWithTimeout(context.Background(), 20*time.Millisecond, func(ctx context.Context) (string, error) {
+			select {
+			case <-time.After(time.Second):
+				return "太慢了", nil
+			case <-ctx.Done():
+				return "", ctx.Err()
+			}
+		})
+		if !timedOut || err != nil || answer != "" {
+			t.Errorf("Expected timeout, got answer=%q timedOut=%v err=%v", answer, timedOut, err)
+		}
+	})
+
+	t.Run("不响应 ctx 的处理也会超时返回", func(t *testing.T) {
+		start := time.Now()
+		_, timedOut, _ := runWithTimeout(context.Background(), 20*time.Millisecond, func(ctx context.Context) (string, error) {
+			time.Sleep(500 * time.Millisecond)
+			return "太慢了", nil
+		})
+		if !timedOut {
+			t.Error("Expected timeout")
+		}
+		if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
+			t.Errorf("Should return soon after deadline, took %v", elapsed)
+		}
+	})
+
+	t.Run("正常完成", func(t *testing.T) {
+		answer, timedOut, err := runWithTimeout(context.Background(), time.Second, func(ctx context.Context) (string, error) {
+			return "完成", nil
+		})
+		if timedOut || err != nil || answer != "完成" {
+			t.Errorf("Expected answer, got answer=%q timedOut=%v err=%v", answer, timedOut, err)
+		}
+	})
+
+	t.Run("处理 panic 转成错误", func(t *testing.T) {
+		_, timedOut, err := runWithTimeout(context.Background(), time.Second, func(ctx context.Context) (string, error) {
+			panic("boom")
+		})
+		if timedOut || err == nil || !strings.Contains(err.Error(), "boom") {
+			t.Errorf("Expected panic error, got timedOut=%v err=%v", timedOut, err)
+		}
+	})
+
+	t.Run("调用方取消不算超时", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, timedOut, err := runWithTimeout(ctx, time.Second, func(ctx context.Context) (string, error) {
+			<-ctx.Done()
+			time.Sleep(10 * time.Millisecond)
+			return "", nil
+		})
+		if timedOut || err == nil {
+			t.Errorf("Expected cancellation error, got timedOut=%v err=%v", timedOut, err)
+		}
+	})
+}
+
+func TestIntentTimeout(t *testing.T) {
+	hp := &HybridProcessor{svcCtx: &svc.ServiceContext{}}
+	if got := hp.intentTimeout(llm.IntentQA); got != defaultIntentTimeout {
+		t.Errorf("Expected default timeout, got %v", got)
+	}
+	if got := hp.intentTimeout(llm.IntentGroupTimeline); got != defaultTimelineTimeout {
+		t.Errorf("Expected timeline default timeout, got %v", got)
+	}
+
+	hp.svcCtx.Config.LLM.IntentTimeout = 30
+	hp.svcCtx.Config.LLM.IntentTimeouts = map[string]int{"group_timeline": 600, "summarize": 90}
+	tests := []struct {
+		intent llm.Intent
+		want   time.Duration
+	}{
+		{llm.IntentQA, 30 * time.Second},
+		{llm.IntentSummarize, 90 * time.Second},
+		{llm.IntentGroupTimeline, 600 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := hp.intentTimeout(tt.intent); got != tt.want {
+			t.Errorf("intentTimeout(%s) = %v, want %v", tt.intent, got, tt.want)
+		}
+	}
+}
+
+func TestToWorkloadCommits(t *testing.T) {
+	committedAt := time.Date(2024, 3, 5, 10, 0, 0, 0, time.Local)
+	commits := []*model.GitCommit{
+		{RepoName: "api", CommitMessage: sql.NullString{String: "feat: 新增支付回调重试\n\n详细说明", Valid: true},
+			FileList: sql.NullString{String: "pay/callback.go", Valid: true}, CommittedAt: committedAt},
+		{RepoName: "api", CommitMessage: sql.NullString{String: "Merge branch 'main' into dev", Valid: true}, CommittedAt: committedAt},
+		{RepoName: "web", CommitMessage: sql.NullString{Valid: false}, CommittedAt: committedAt},
+		{RepoName: "web", CommitMessage: sql.NullString{String: "  fix: 登录页样式  ", Valid: true}, CommittedAt: committedAt},
+	}
+
+	got := toWorkloadCommits(commits)
+	if len(got) != 2 {
+		t.Fatalf("Expected 2 commits (merge and empty skipped), got %d: %+v", len(got), got)
+	}
+	if got[0].Message != "feat: 新增支付回调重试" || got[0].Files != "pay/callback.go" || got[0].Date != "03-05" {
+		t.Errorf("Unexpected first commit: %+v", got[0])
+	}
+	if got[1].Repo != "web" || got[1].Message != "fix: 登录页样式" || got[1].Files != "" {
+		t.Errorf("Unexpected second commit: %+v", got[1])
+	}
+}
+
+func TestFormatRecentCommits(t *testing.T) {
+	if got := formatRecentCommits(nil, 5); got != "" {
+		t.Errorf("Expected empty output, got %q", got)
+	}
+
+	commits := []workloadCommit{
+		{Repo: "api", Message: "feat: 支付回调", Date: "03-05"},
+		{Repo: "api", Message: "fix: 超时", Date: "03-04"},
+		{Repo: "web", Message: "chore: 升级依赖", Date: "03-03"},
+	}
+	got := formatRecentCommits(commits, 2)
+	if !strings.Contains(got, "[api] feat: 支付回调 (03-05)") || !strings.Contains(got, "[api] fix: 超时 (03-04)") {
+		t.Errorf("Missing commits in output:\n%s", got)
+	}
+	if strings.Contains(got, "升级依赖") || !strings.Contains(got, "还有 1 条") {
+		t.Errorf("Output should be limited:\n%s", got)
+	}
+}
+
+func TestFormatAnswerSources(t *testing.T) {
+	ts := time.Date(2025, 1, 5, 10, 30, 0, 0, time.Local)
+	var sources []answerSource
+	for i := 0; i < 8; i++ {
+		sources = append(sources, answerSource{sender: "张三-后端", content: "支付回调\n超时，已经  重启服务", timestamp: ts})
+	}
+
+	got := formatAnswerSources(sources, 3)
+	lines := strings.Split(got, "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected header + 3 sources, got %d lines:\n%s", len(lines), got)
+	}
+	if lines[0] != "📎 参考消息（3 条）" {
+		t.Errorf("Unexpected header: %q", lines[0])
+	}
+	if lines[1] != "1. [01-05 10:30] 张三-后端: 支付回调 超时，已经 重启服务" {
+		t.Errorf("Unexpected source line: %q", lines[1])
+	}
+
+	if got := formatAnswerSources(sources, 0); !strings.HasPrefix(got, "📎 参考消息（5 条）") {
+		t.Errorf("Expected default limit of %d, got %q", defaultAnswerSourceLimit, got)
+	}
+	if got := formatAnswerSources(nil, 3); got != "" {
+		t.Errorf("Expected empty section for no sources, got %q", got)
+	}
+}
+
+func TestTrimSnippet(t *testing.T) {
+	long := strings.Repeat("支付", 40)
+	got := trimSnippet(long, answerSourceSnippetLen)
+	if want := strings.Repeat("支付", 30) + "..."; got != want {
+		t.Errorf("trimSnippet() = %q, want %q", got, want)
+	}
+}
+
+func TestAppendAnswerSources(t *testing.T) {
+	hp := &HybridProcessor{svcCtx: &svc.ServiceContext{}}
+	sources := []answerSource{{sender: "李四", content: "已上线", timestamp: time.Now()}}
+
+	if got := hp.appendAnswerSources("回答", sources); got != "回答" {
+		t.Errorf("Expected sources hidden when disabled, got %q", got)
+	}
+
+	hp.svcCtx.Config.LLM.ShowAnswerSources = true
+	got := hp.appendAnswerSources("回答", sources)
+	if !strings.HasPrefix(got, "回答\n\n---\n📎 参考消息（1 条）") || !strings.Contains(got, "李四: 已上线") {
+		t.Errorf("Expected sources appended, got %q", got)
+	}
+}
+
+// fakeUserInfoFetcher 测试用飞书用户查询
+type fakeUserInfoFetcher struct {
+	names map[string]string
+	calls int
+}
+
+func (f *fakeUserInfoFetcher) GetUserInfo(ctx context.Context, openID string) (*lark.UserInfo, error) {
+	f.calls++
+	name, ok := f.names[openID]
+	if !ok {
+		return nil, errors.New("user not found")
+	}
+	return &lark.UserInfo{OpenID: openID, Name: name}, nil
+}
+
+func TestSenderResolver(t *testing.T) {
+	named := &model.ChatMessage{
+		SenderID:   sql.NullString{String: "ou_1", Valid: true},
+		SenderName: sql.NullString{String: "张三-后端", Valid: true},
+	}
+	unnamed := &model.ChatMessage{SenderID: sql.NullString{String: "ou_2", Valid: true}}
+	unknown := &model.ChatMessage{SenderID: sql.NullString{String: "ou_3", Valid: true}}
+	bot := &model.ChatMessage{SenderID: sql.NullString{String: "cli_bot", Valid: true}}
+
+	tests := []struct {
+		name string
+		cfg  config.LLMConfig
+		msg  *model.ChatMessage
+		want string
+	}{
+		{"已有名称", config.LLMConfig{UnknownSenderMode: SenderModeResolve}, named, "张三-后端"},
+		{"默认标签", config.LLMConfig{}, unnamed, defaultUnknownSenderLabel},
+		{"自定义标签", config.LLMConfig{UnknownSenderLabel: "未同步成员"}, unnamed, "未同步成员"},
+		{"实时查询", config.LLMConfig{UnknownSenderMode: SenderModeResolve}, unnamed, "李四"},
+		{"查询失败退回标签", config.LLMConfig{UnknownSenderMode: SenderModeResolve}, unknown, defaultUnknownSenderLabel},
+		{"非用户 ID 不查询", config.LLMConfig{UnknownSenderMode: SenderModeResolve}, bot, defaultUnknownSenderLabel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetcher := &fakeUserInfoFetcher{names: map[string]string{"ou_2": "李四"}}
+			r := newSenderResolver(tt.cfg, fetcher)
+			if got := r.name(context.Background(), tt.msg); got != tt.want {
+				t.Errorf("name() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSenderResolverCachesLookups(t *testing.T) {
+	fetcher := &fakeUserInfoFetcher{names: map[string]string{"ou_2": "李四"}}
+	r := newSenderResolver(config.LLMConfig{UnknownSenderMode: SenderModeResolve}, fetcher)
+
+	for _, openID := range []string{"ou_2", "ou_2", "ou_3", "ou_3"} {
+		r.name(context.Background(), &model.ChatMessage{SenderID: sql.NullString{String: openID, Valid: true}})
+	}
+	if fetcher.calls != 2 {
+		t.Errorf("Expected one lookup per open_id (including failures), got %d", fetcher.calls)
+	}
+}
+
+// fakeReportExporter 记录导出的报告
+type fakeReportExporter struct {
+	sourceIDs []string
+	titles    []string
+	contents  []string
+}
+
+func (f *fakeReportExporter) ExportReport(ctx context.Context, sourceID, title, content string) (bool, error) {
+	f.sourceIDs = append(f.sourceIDs, sourceID)
+	f.titles = append(f.titles, title)
+	f.contents = append(f.contents, content)
+	return true, nil
+}
+
+func TestExportReport(t *testing.T) {
+	date := time.Date(2025, 1, 5, 18, 0, 0, 0, time.Local)
+	ctx := context.Background()
+
+	t.Run("未开启时不导出", func(t *testing.T) {
+		exporter := &fakeReportExporter{}
+		hp := &HybridProcessor{svcCtx: &svc.ServiceContext{}}
+		hp.SetReportExporter(exporter)
+		hp.exportReport(ctx, reportKindTimeline, "oc_dev", "研发群", "报告", date)
+		if len(exporter.titles) != 0 {
+			t.Errorf("Expected no export when disabled, got %v", exporter.titles)
+		}
+	})
+
+	t.Run("开启后按群和日期命名", func(t *testing.T) {
+		exporter := &fakeReportExporter{}
+		hp := &HybridProcessor{svcCtx: &svc.ServiceContext{}}
+		hp.svcCtx.Config.Dify.ExportReports = true
+		hp.SetReportExporter(exporter)
+
+		hp.exportReport(ctx, reportKindTimeline, "oc_dev", "研发群", "历程报告", date)
+		hp.exportReport(ctx, reportKindSummary, "oc_pay", "", "总结", date)
+		hp.exportReport(ctx, reportKindSummary, "", "所有群", "跨群总结", date)
+
+		wantTitles := []string{"群历程-研发群-2025-01-05", "群总结-oc_pay-2025-01-05"}
+		if strings.Join(exporter.titles, "|") != strings.Join(wantTitles, "|") {
+			t.Errorf("titles = %v, want %v", exporter.titles, wantTitles)
+		}
+		if exporter.sourceIDs[0] != "report:timeline:oc_dev:2025-01-05" {
+			t.Errorf("Unexpected source id: %s", exporter.sourceIDs[0])
+		}
+		if exporter.contents[0] != "历程报告" {
+			t.Errorf("Expected report content pushed, got %q", exporter.contents[0])
+		}
+	})
+}
+
+func TestRankRelevantGroups(t *testing.T) {
+	chatIDs := func(ranked []groupRelevance) string {
+		var ids []string
+		for _, g := range ranked {
+			ids = append(ids, g.chatID)
+		}
+		return strings.Join(ids, ",")
+	}
+
+	tests := []struct {
+		name     string
+		hits     map[string][]int
+		semantic []service.SearchResult
+		topK     int
+		want     string
+	}{
+		{
+			name: "匹配更多关键词的群优先",
+			hits: map[string][]int{"oc_pay": {5, 3}, "oc_dev": {20, 0}, "oc_ops": {1, 0}},
+			topK: 2,
+			want: "oc_pay,oc_dev",
+		},
+		{
+			name:     "仅语义信号",
+			semantic: []service.SearchResult{{ChatID: "oc_a", Score: 0.9}, {ChatID: "oc_b", Score: 0.5}, {ChatID: "oc_b", Score: 0.6}},
+			topK:     5,
+			want:     "oc_b,oc_a",
+		},
+		{
+			name:     "关键词和语义综合",
+			hits:     map[string][]int{"oc_a": {10}, "oc_b": {2}},
+			semantic: []service.SearchResult{{ChatID: "oc_b", Score: 0.9}, {ChatID: "oc_c", Score: 0.8}},
+			topK:     3,
+			want:     "oc_b,oc_a,oc_c",
+		},
+		{
+			name:     "忽略零命中和空群ID",
+			hits:     map[string][]int{"oc_a": {0, 0}, "": {9}},
+			semantic: []service.SearchResult{{ChatID: "", Score: 0.9}},
+			topK:     3,
+			want:     "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := chatIDs(rankRelevantGroups(tt.hits, tt.semantic, tt.topK)); got != tt.want {
+				t.Errorf("rankRelevantGroups() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCrossGroupTopK(t *testing.T) {
+	hp := &HybridProcessor{svcCtx: &svc.ServiceContext{}}
+	if got := hp.crossGroupTopK(); got != defaultCrossGroupTopK {
+		t.Errorf("Expected default top-k %d, got %d", defaultCrossGroupTopK, got)
+	}
+	hp.svcCtx.Config.LLM.CrossGroupTopK = -1
+	if got := hp.crossGroupTopK(); got != 0 {
+		t.Errorf("Expected ranking disabled, got %d", got)
+	}
+	if got := hp.findRelevantGroups(context.Background(), "支付失败", []string{"支付"}); got != nil {
+		t.Errorf("Expected no restriction when disabled, got %v", got)
+	}
+}
+
+func TestQALLMFailureRecorded(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"error":{"message":"upstream unavailable"}}`))
+	}))
+	defer server.Close()
+
+	metrics := svc.NewMetrics()
+	llmClient := llm.NewClient("test-key", server.URL, "test-model")
+	llmClient.SetRetryBackoff(backoff.New(time.Millisecond, time.Millisecond))
+	llmClient.SetCallObserver(metrics.RecordLLMCall)
+
+	hp := NewHybridProcessor(&svc.ServiceContext{LLMClient: llmClient, Metrics: metrics})
+	if _, err := hp.answerWithContext(context.Background(), "登录超时修好了吗", "[10:00] 张三: 已修复"); err == nil {
+		t.Fatal("Expected QA call to fail")
+	}
+	if snap := metrics.Snapshot(); snap.LLMFailures != 1 {
+		t.Errorf("LLMFailures = %d, want 1 for a failed QA call", snap.LLMFailures)
+	}
+}
@@ -0,0 +1,72 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"team-assistant/pkg/llm"
+)
+
+// ======================== 意图处理超时 ========================
+
+const (
+	// defaultIntentTimeout 意图处理默认超时
+	defaultIntentTimeout = 60 * time.Second
+	// defaultTimelineTimeout 群历程需要逐周调用 LLM，默认超时更长
+	defaultTimelineTimeout = 5 * time.Minute
+)
+
+// intentTimeoutMessage 意图处理超时时的回复
+const intentTimeoutMessage = "⏱️ 处理超时，请缩小范围（如缩短时间范围、指定群或成员）后重试。"
+
+// intentTimeout 获取意图的处理超时
+// 优先级：按意图配置 > 群历程默认值 > 全局配置 > 默认值
+func (hp *HybridProcessor) intentTimeout(intent llm.Intent) time.Duration {
+	cfg := hp.svcCtx.Config.LLM
+	if seconds, ok := cfg.IntentTimeouts[string(intent)]; ok && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if intent == llm.IntentGroupTimeline {
+		return defaultTimelineTimeout
+	}
+	if cfg.IntentTimeout > 0 {
+		return time.Duration(cfg.IntentTimeout) * time.Second
+	}
+	return defaultIntentTimeout
+}
+
+// runWithTimeout 在超时限制内执行 fn，超时返回 timedOut=true
+// fn 收到带截止时间的 ctx，不响应 ctx 的调用会在后台继续执行完，但结果被丢弃
+// fn 在独立的 goroutine 中执行，调用方的 recover 捕获不到，panic 在这里转成错误返回
+func runWithTimeout(ctx context.Context, timeout time.Duration, fn func(ctx context.Context) (string, error)) (answer string, timedOut bool, err error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type result struct {
+		answer string
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("[PANIC] Intent handler panic recovered: %v", r)
+				done <- result{err: fmt.Errorf("intent handler panic: %v", r)}
+			}
+		}()
+		answer, err := fn(ctx)
+		done <- result{answer, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.answer, false, r.err
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			return "", true, nil
+		}
+		return "", false, ctx.Err()
+	}
+}
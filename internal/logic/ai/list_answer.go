@@ -0,0 +1,75 @@
+package ai
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ======================== 直接列出原始消息 ========================
+
+const (
+	listAnswerModeOff = "off"
+	listAnswerLimit   = 20  // 最多列出的消息数
+	listAnswerMaxRune = 500 // 单条消息最多展示的字符数
+)
+
+// 明确要求列出原文的关键词
+var listAnswerKeywords = []string{"列出", "列一下", "列举", "罗列", "原文", "原话", "原始消息", "贴出", "贴一下", "完整消息"}
+
+// 需要解释/分析的问题仍由 LLM 回答，如 "ERR_PAY_TIMEOUT 是什么原因"
+var listAnswerReasonKeywords = []string{"为什么", "原因", "是什么", "什么意思", "怎么", "如何", "总结", "分析"}
+
+// 查找类问题中的填充词，去掉代码和这些词后没有剩余内容的视为精确查找（按长度降序）
+var listAnswerFillers = []string{
+	"在哪里", "有没有", "出现过", "查一下", "搜一下", "找一下",
+	"在哪", "哪里", "出现", "查查", "找找", "搜索", "相关", "消息", "记录", "日志", "报错", "错误", "告警", "群里", "一下",
+	"查", "找", "搜", "有", "的", "过", "了", "吗", "呢",
+}
+
+// codeTokenPattern 报错码/常量名等需要精确匹配的文本：ERR_PAY_TIMEOUT、E10023、ORA-00942、0x1f、99991400
+var codeTokenPattern = regexp.MustCompile(`\b(?:[A-Z][A-Z0-9]*(?:_[A-Z0-9]+)+|[A-Za-z]+-?\d{3,}|0x[0-9a-fA-F]+|\d{5,})\b`)
+
+// listAnswerEnabled 是否允许对特定问题直接列出原始消息
+func (hp *HybridProcessor) listAnswerEnabled() bool {
+	return hp.svcCtx == nil || hp.svcCtx.Config.LLM.ListAnswerMode != listAnswerModeOff
+}
+
+// isListAnswerQuery 问题是否更适合直接列出找到的消息原文，而不是由 LLM 总结
+// 包括明确要求 "列出..." 的问题，以及只包含报错码等精确文本的查找
+func isListAnswerQuery(query string) bool {
+	if containsAny(query, listAnswerKeywords) {
+		return true
+	}
+	if !codeTokenPattern.MatchString(query) || containsAny(query, listAnswerReasonKeywords) {
+		return false
+	}
+
+	rest := codeTokenPattern.ReplaceAllString(query, "")
+	for _, w := range listAnswerFillers {
+		rest = strings.ReplaceAll(rest, w, "")
+	}
+	rest = strings.TrimFunc(rest, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsPunct(r)
+	})
+	return utf8.RuneCountInString(rest) <= 2
+}
+
+// formatListAnswer 按相关度列出找到的消息原文
+func formatListAnswer(messages []string) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📋 找到 %d 条相关消息（原文）：\n\n", len(messages)))
+	for i, msg := range messages {
+		if i >= listAnswerLimit {
+			sb.WriteString(fmt.Sprintf("\n...(还有 %d 条消息未显示)\n", len(messages)-listAnswerLimit))
+			break
+		}
+		if utf8.RuneCountInString(msg) > listAnswerMaxRune {
+			msg = string([]rune(msg)[:listAnswerMaxRune]) + "..."
+		}
+		sb.WriteString(fmt.Sprintf("%d. %s\n", i+1, msg))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
@@ -0,0 +1,79 @@
+package ai
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"team-assistant/internal/config"
+	"team-assistant/internal/svc"
+)
+
+func TestIsListAnswerQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"列出登录超时相关的消息", true},
+		{"把支付回调的原文贴一下", true},
+		{"列出支付失败的原因", true},
+		{"ERR_PAY_TIMEOUT", true},
+		{"查一下 E10023 的报错记录", true},
+		{"99991400 在哪出现过？", true},
+		{"ORA-00942 有没有", true},
+		{"ERR_PAY_TIMEOUT 是什么原因", false},
+		{"E10023 怎么解决", false},
+		{"ERR_PAY_TIMEOUT 谁在处理", false},
+		{"登录超时是谁修的", false},
+		{"2024 年的发布计划", false},
+		{"总结一下今天的讨论", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			if got := isListAnswerQuery(tt.query); got != tt.want {
+				t.Errorf("isListAnswerQuery(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListAnswerEnabled(t *testing.T) {
+	tests := []struct {
+		name string
+		mode string
+		want bool
+	}{
+		{"默认开启", "", true},
+		{"auto", "auto", true},
+		{"关闭", "off", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hp := &HybridProcessor{svcCtx: &svc.ServiceContext{Config: config.Config{LLM: config.LLMConfig{ListAnswerMode: tt.mode}}}}
+			if got := hp.listAnswerEnabled(); got != tt.want {
+				t.Errorf("listAnswerEnabled() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatListAnswer(t *testing.T) {
+	got := formatListAnswer([]string{"[03-04 09:00] 张三: ERR_PAY_TIMEOUT order=123", "[03-04 10:00] 李四: 已重试"})
+	want := "📋 找到 2 条相关消息（原文）：\n\n1. [03-04 09:00] 张三: ERR_PAY_TIMEOUT order=123\n2. [03-04 10:00] 李四: 已重试"
+	if got != want {
+		t.Errorf("formatListAnswer() =\n%q\nwant\n%q", got, want)
+	}
+
+	messages := make([]string, listAnswerLimit+3)
+	for i := range messages {
+		messages[i] = fmt.Sprintf("消息%d", i)
+	}
+	messages[0] = strings.Repeat("长", listAnswerMaxRune+10)
+	got = formatListAnswer(messages)
+	if !strings.Contains(got, "1. "+strings.Repeat("长", listAnswerMaxRune)+"...\n") {
+		t.Error("Long message should be truncated by runes")
+	}
+	if strings.Contains(got, fmt.Sprintf("消息%d", listAnswerLimit)) || !strings.HasSuffix(got, "...(还有 3 条消息未显示)") {
+		t.Errorf("Expected at most %d messages with remainder note, got %q", listAnswerLimit, got)
+	}
+}
@@ -0,0 +1,120 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"team-assistant/internal/model"
+	"team-assistant/pkg/llm"
+)
+
+// ======================== 成员跨群活跃度查询 ========================
+
+// handleMemberGroupsQuery 查询某个成员在哪些群活跃（跨群信息，仅私聊可用）
+func (hp *HybridProcessor) handleMemberGroupsQuery(ctx context.Context, parsed *llm.ParsedQuery, currentChatID string) (string, error) {
+	if !isPrivateChat(ctx, currentChatID) {
+		return "🔒 该查询涉及跨群信息，请私聊我查询。", nil
+	}
+
+	if len(parsed.TargetUsers) == 0 {
+		return "请告诉我要查询哪位成员，例如：小王在哪些群活跃？", nil
+	}
+
+	startTime, _ := hp.getTimeRange(parsed.TimeRange)
+
+	var sb strings.Builder
+	for _, user := range parsed.TargetUsers {
+		aliases := hp.resolveSenderAliases(ctx, user)
+
+		var lists [][]*model.SenderGroupActivity
+		for _, alias := range aliases {
+			groups, err := hp.svcCtx.MessageModel.GroupsBySender(ctx, alias, startTime)
+			if err != nil {
+				log.Printf("Failed to get groups by sender %s: %v", alias, err)
+				continue
+			}
+			lists = append(lists, groups)
+		}
+		groups := model.MergeSenderGroupActivities(lists...)
+
+		sb.WriteString(formatMemberGroups(user, aliases, groups))
+		sb.WriteString("\n")
+	}
+
+	return strings.TrimSpace(sb.String()), nil
+}
+
+// resolveSenderAliases 解析成员的别名（用户输入 + 成员表中匹配到的姓名）
+func (hp *HybridProcessor) resolveSenderAliases(ctx context.Context, name string) []string {
+	names := []string{name}
+
+	members, err := hp.svcCtx.MemberModel.FindByName(ctx, name)
+	if err != nil {
+		log.Printf("Failed to find member %s: %v", name, err)
+	}
+	for _, m := range members {
+		names = append(names, m.Name)
+	}
+
+	return dedupeSenderAliases(names)
+}
+
+// dedupeSenderAliases 别名去重
+// GroupsBySender 使用模糊匹配，如果一个别名包含另一个别名，较长的那个会被重复统计，因此只保留较短的
+func dedupeSenderAliases(names []string) []string {
+	var result []string
+	for i, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		covered := false
+		for j, other := range names {
+			other = strings.TrimSpace(other)
+			if i == j || other == "" {
+				continue
+			}
+			// 被更短的别名覆盖；相同别名只保留第一个
+			if (other != name && strings.Contains(name, other)) || (other == name && j < i) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// formatMemberGroups 格式化成员跨群活跃结果
+func formatMemberGroups(user string, aliases []string, groups []*model.SenderGroupActivity) string {
+	if len(groups) == 0 {
+		return fmt.Sprintf("👤 %s\n没有找到 %s 的发言记录。\n", user, user)
+	}
+
+	total := 0
+	for _, g := range groups {
+		total += g.MessageCount
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("👤 **%s** 在 %d 个群发过言（共 %d 条）", user, len(groups), total))
+	if len(aliases) > 1 {
+		sb.WriteString(fmt.Sprintf("\n（已合并别名：%s）", strings.Join(aliases, "、")))
+	}
+	sb.WriteString("\n\n")
+
+	for i, g := range groups {
+		name := g.ChatName
+		if name == "" {
+			name = g.ChatID
+		}
+		sb.WriteString(fmt.Sprintf("%d. %s - %d 条，最近发言 %s\n",
+			i+1, name, g.MessageCount, g.LastActiveAt.Format("2006-01-02")))
+	}
+	return sb.String()
+}
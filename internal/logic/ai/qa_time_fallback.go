@@ -0,0 +1,40 @@
+package ai
+
+import (
+	"fmt"
+	"time"
+)
+
+// ===== 问答时间范围放宽 =====
+
+const qaTimeFallbackOff = "off"
+
+// qaTimeFallbackEnabled 指定时间范围内没有找到消息时是否放宽时间条件重新搜索（LLM.QATimeFallback 为 off 时关闭）
+func (hp *HybridProcessor) qaTimeFallbackEnabled() bool {
+	return hp.svcCtx == nil || hp.svcCtx.Config.LLM.QATimeFallback != qaTimeFallbackOff
+}
+
+// formatQATimeWindow 格式化用户指定的时间范围，如 "01-06 ~ 01-12"，同一天只显示一个日期
+func formatQATimeWindow(start, end time.Time) string {
+	from, to := start.Format("01-02"), end.Format("01-02")
+	if start.Year() != end.Year() {
+		from, to = start.Format("2006-01-02"), end.Format("2006-01-02")
+	}
+	if from == to {
+		return from
+	}
+	return from + " ~ " + to
+}
+
+// withWidenedNotice 放宽时间范围后找到的结果在回答开头注明不在指定时间范围内
+func withWidenedNotice(answer string, widened bool, start, end time.Time) string {
+	if !widened {
+		return answer
+	}
+	return fmt.Sprintf("⚠️ 指定时间范围（%s）内没有找到相关消息，以下内容来自该范围之外的聊天记录\n\n%s", formatQATimeWindow(start, end), answer)
+}
+
+// widenedContextNote 提示 LLM 聊天记录不在用户询问的时间范围内，避免当作该时间段发生的事回答
+func widenedContextNote(start, end time.Time) string {
+	return fmt.Sprintf("注意：用户询问的时间范围（%s）内没有相关消息，以下聊天记录都不在该时间范围内，回答时请说明消息的实际时间。", formatQATimeWindow(start, end))
+}
@@ -0,0 +1,140 @@
+package ai
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"team-assistant/internal/config"
+	"team-assistant/internal/model"
+	"team-assistant/internal/svc"
+	"team-assistant/pkg/llm"
+)
+
+// olderMessagesDriver 按时间范围查询时没有结果、不限时间时返回一条较早消息的数据库驱动
+type olderMessagesDriver struct{}
+
+func (olderMessagesDriver) Open(string) (driver.Conn, error) { return olderMessagesConn{}, nil }
+
+type olderMessagesConn struct{}
+
+func (olderMessagesConn) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (olderMessagesConn) Close() error                        { return nil }
+func (olderMessagesConn) Begin() (driver.Tx, error)           { return nil, driver.ErrSkip }
+
+func (olderMessagesConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	rows := &olderMessagesRows{}
+	if !strings.Contains(query, "BETWEEN") {
+		rows.values = [][]driver.Value{{
+			int64(1), "om_old", "oc_team", "ou_zhang", "张三", nil, "text",
+			"登录超时是网关配置导致的", nil, []byte("[]"), nil, nil, nil, int64(0), int64(0),
+			time.Date(2024, 1, 3, 10, 0, 0, 0, time.Local), nil, time.Date(2024, 1, 3, 10, 0, 0, 0, time.Local),
+		}}
+	}
+	return rows, nil
+}
+
+type olderMessagesRows struct {
+	values [][]driver.Value
+}
+
+func (r *olderMessagesRows) Columns() []string {
+	return []string{"id", "message_id", "chat_id", "sender_id", "sender_name", "member_id", "msg_type",
+		"content", "raw_content", "mentions", "reply_to_id", "thread_id", "root_id", "is_at_bot", "is_forwarded",
+		"created_at", "created_at_ts", "indexed_at"}
+}
+
+func (r *olderMessagesRows) Close() error { return nil }
+
+func (r *olderMessagesRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+func init() {
+	sql.Register("qa_older_messages", olderMessagesDriver{})
+}
+
+func newTimeFallbackProcessor(t *testing.T, mode string) *HybridProcessor {
+	t.Helper()
+	db, err := sql.Open("qa_older_messages", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return &HybridProcessor{svcCtx: &svc.ServiceContext{
+		Config:       config.Config{LLM: config.LLMConfig{QATimeFallback: mode}},
+		MessageModel: model.NewChatMessageModel(db),
+		Services:     &svc.Services{},
+	}}
+}
+
+func TestHandleQAWidensTimeRange(t *testing.T) {
+	parsed := &llm.ParsedQuery{RawQuery: "登录超时怎么解决", Keywords: []string{"登录超时"}, TimeRange: llm.TimeRangeLastWeek}
+
+	tests := []struct {
+		name        string
+		mode        string
+		wantNotice  bool
+		wantMessage string
+	}{
+		{"默认放宽时间范围并注明", "", true, "登录超时是网关配置导致的"},
+		{"关闭后直接回复没有找到", "off", false, "没有找到与您问题相关的信息"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hp := newTimeFallbackProcessor(t, tt.mode)
+			answer, err := hp.handleQA(context.Background(), parsed, "oc_team")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(answer, tt.wantMessage) {
+				t.Errorf("handleQA() = %q, want it to contain %q", answer, tt.wantMessage)
+			}
+			if got := strings.Contains(answer, "内没有找到相关消息，以下内容来自该范围之外"); got != tt.wantNotice {
+				t.Errorf("handleQA() notice = %v, want %v: %q", got, tt.wantNotice, answer)
+			}
+		})
+	}
+}
+
+func TestHandleQAWithoutTimeFilterNoNotice(t *testing.T) {
+	hp := newTimeFallbackProcessor(t, "")
+	parsed := &llm.ParsedQuery{RawQuery: "登录超时怎么解决", Keywords: []string{"登录超时"}}
+	answer, err := hp.handleQA(context.Background(), parsed, "oc_team")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(answer, "⚠️ 指定时间范围") {
+		t.Errorf("Unfiltered answer should not carry widened notice: %q", answer)
+	}
+}
+
+func TestFormatQATimeWindow(t *testing.T) {
+	day := func(y, m, d int) time.Time { return time.Date(y, time.Month(m), d, 0, 0, 0, 0, time.Local) }
+	tests := []struct {
+		name       string
+		start, end time.Time
+		want       string
+	}{
+		{"同一天", day(2024, 1, 8), day(2024, 1, 8).Add(23 * time.Hour), "01-08"},
+		{"一周", day(2024, 1, 1), day(2024, 1, 7), "01-01 ~ 01-07"},
+		{"跨年显示年份", day(2023, 12, 25), day(2024, 1, 2), "2023-12-25 ~ 2024-01-02"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatQATimeWindow(tt.start, tt.end); got != tt.want {
+				t.Errorf("formatQATimeWindow() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
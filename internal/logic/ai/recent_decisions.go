@@ -0,0 +1,129 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"team-assistant/pkg/llm"
+)
+
+// ======================== 最近的决议/里程碑（读取周总结缓存） ========================
+
+// recentDecisionsWeeks 未指定时间范围时查询的周数
+const recentDecisionsWeeks = 4
+
+// 决议/里程碑关键词
+var (
+	decisionKeywords  = []string{"决议", "做了哪些决定", "有什么决定", "做了什么决定", "定了哪些"}
+	milestoneKeywords = []string{"里程碑"}
+)
+
+// recentDecisionsQuery 解析后的决议/里程碑查询
+type recentDecisionsQuery struct {
+	decisions  bool          // 是否展示决议
+	milestones bool          // 是否展示里程碑
+	timeRange  llm.TimeRange // 为空表示最近 4 周
+	label      string
+}
+
+// parseRecentDecisionsQuery 识别 "这个群最近有什么决议" 之类的问题
+func parseRecentDecisionsQuery(query string) (recentDecisionsQuery, bool) {
+	q := recentDecisionsQuery{
+		decisions:  containsAny(query, decisionKeywords),
+		milestones: containsAny(query, milestoneKeywords),
+	}
+	if !q.decisions && !q.milestones {
+		return recentDecisionsQuery{}, false
+	}
+
+	q.timeRange, q.label = parseStatsTimeRange(query)
+	if q.timeRange == "" {
+		q.label = fmt.Sprintf("最近 %d 周", recentDecisionsWeeks)
+	}
+	return q, true
+}
+
+// tryRecentDecisions 尝试从周总结中回答决议/里程碑问题，无法回答时返回 false 交给常规流程
+// 仅群聊可用；已结束的周读取缓存，当前周重新生成
+func (hp *HybridProcessor) tryRecentDecisions(ctx context.Context, chatID, query string) (string, bool) {
+	if hp.svcCtx == nil || hp.weeklyStore == nil || isPrivateChat(ctx, chatID) {
+		return "", false
+	}
+	q, ok := parseRecentDecisionsQuery(query)
+	if !ok {
+		return "", false
+	}
+
+	now := time.Now()
+	var start, end time.Time
+	if q.timeRange == "" {
+		start, end = now.AddDate(0, 0, -7*recentDecisionsWeeks), now
+	} else {
+		start, end = hp.getTimeRange(q.timeRange)
+	}
+
+	summaries, err := hp.generateWeeklySummaries(ctx, chatID, start, end)
+	if err != nil {
+		log.Printf("Failed to get weekly summaries for decisions: %v, falling back to normal processing", err)
+		return "", false
+	}
+
+	groupName := "当前群"
+	if hp.svcCtx.GroupModel != nil {
+		if group, err := hp.svcCtx.GroupModel.FindByChatID(ctx, chatID); err == nil && group.ChatName.Valid {
+			groupName = group.ChatName.String
+		}
+	}
+	return formatRecentDecisions(groupName, q, summaries), true
+}
+
+// formatRecentDecisions 按周格式化决议/里程碑，最近的周在前，没有记录的周不展示
+func formatRecentDecisions(groupName string, q recentDecisionsQuery, summaries []WeeklySummary) string {
+	title := "决议与里程碑"
+	switch {
+	case !q.milestones:
+		title = "决议"
+	case !q.decisions:
+		title = "里程碑"
+	}
+
+	var sb strings.Builder
+	for i := len(summaries) - 1; i >= 0; i-- {
+		ws := summaries[i]
+		hasDecisions := q.decisions && len(ws.Decisions) > 0
+		hasMilestones := q.milestones && len(ws.Milestones) > 0
+		if !hasDecisions && !hasMilestones {
+			continue
+		}
+
+		sb.WriteString(fmt.Sprintf("\n🗓 %s ~ %s\n", ws.WeekStart.Format("01-02"), weekLastDay(ws).Format("01-02")))
+		if hasDecisions {
+			sb.WriteString("✅ 决议：\n")
+			for _, d := range ws.Decisions {
+				sb.WriteString(fmt.Sprintf("   • %s\n", d))
+			}
+		}
+		if hasMilestones {
+			sb.WriteString("🏁 里程碑：\n")
+			for _, m := range ws.Milestones {
+				sb.WriteString(fmt.Sprintf("   • %s\n", m))
+			}
+		}
+	}
+
+	if sb.Len() == 0 {
+		return fmt.Sprintf("📭 「%s」%s没有记录到%s", groupName, q.label, title)
+	}
+	return fmt.Sprintf("📌 「%s」%s的%s\n", groupName, q.label, title) + strings.TrimRight(sb.String(), "\n")
+}
+
+// weekLastDay 周的最后一天：整周的 WeekEnd 是次周一，展示为周日
+func weekLastDay(ws WeeklySummary) time.Time {
+	if !ws.WeekEnd.Before(ws.WeekStart.AddDate(0, 0, 7)) {
+		return ws.WeekEnd.AddDate(0, 0, -1)
+	}
+	return ws.WeekEnd
+}
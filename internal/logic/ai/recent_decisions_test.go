@@ -0,0 +1,145 @@
+package ai
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"team-assistant/internal/model"
+	"team-assistant/internal/svc"
+	"team-assistant/pkg/llm"
+)
+
+// fakeWeeklyStore 内存中的周总结缓存
+type fakeWeeklyStore struct {
+	records []*model.WeeklySummaryRecord
+	saved   []*model.WeeklySummaryRecord
+}
+
+func (s *fakeWeeklyStore) ListSince(ctx context.Context, chatID string, since time.Time) ([]*model.WeeklySummaryRecord, error) {
+	var result []*model.WeeklySummaryRecord
+	for _, r := range s.records {
+		if r.ChatID == chatID && !r.WeekStart.Before(since) {
+			result = append(result, r)
+		}
+	}
+	return result, nil
+}
+
+func (s *fakeWeeklyStore) Upsert(ctx context.Context, r *model.WeeklySummaryRecord) error {
+	s.saved = append(s.saved, r)
+	return nil
+}
+
+func TestParseRecentDecisionsQuery(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		wantOK         bool
+		wantDecisions  bool
+		wantMilestones bool
+		wantRange      llm.TimeRange
+		wantLabel      string
+	}{
+		{"最近决议", "这个群最近有什么决议", true, true, false, "", "最近 4 周"},
+		{"上周里程碑", "上周有哪些里程碑", true, false, true, llm.TimeRangeLastWeek, "上周"},
+		{"决议和里程碑", "本月的决议和里程碑", true, true, true, llm.TimeRangeThisMonth, "本月"},
+		{"做了哪些决定", "我们最近做了哪些决定", true, true, false, "", "最近 4 周"},
+		{"普通问题", "这周的消息总结一下", false, false, false, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, ok := parseRecentDecisionsQuery(tt.query)
+			if ok != tt.wantOK {
+				t.Fatalf("parseRecentDecisionsQuery(%q) ok = %v, want %v", tt.query, ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if q.decisions != tt.wantDecisions || q.milestones != tt.wantMilestones {
+				t.Errorf("kinds = (%v, %v), want (%v, %v)", q.decisions, q.milestones, tt.wantDecisions, tt.wantMilestones)
+			}
+			if q.timeRange != tt.wantRange || q.label != tt.wantLabel {
+				t.Errorf("range = (%q, %q), want (%q, %q)", q.timeRange, q.label, tt.wantRange, tt.wantLabel)
+			}
+		})
+	}
+}
+
+func TestFormatRecentDecisions(t *testing.T) {
+	week1 := time.Date(2024, 3, 4, 0, 0, 0, 0, time.Local)
+	week2 := week1.AddDate(0, 0, 7)
+	summaries := []WeeklySummary{
+		{WeekStart: week1, WeekEnd: week2, Decisions: []string{"周五发版"}, Milestones: []string{"支付改造完成"}},
+		{WeekStart: week2, WeekEnd: week2.AddDate(0, 0, 3), Decisions: []string{"下线旧接口"}},
+		{WeekStart: week2.AddDate(0, 0, 7), WeekEnd: week2.AddDate(0, 0, 14)},
+	}
+
+	q, _ := parseRecentDecisionsQuery("最近有什么决议和里程碑")
+	got := formatRecentDecisions("研发群", q, summaries)
+	want := "📌 「研发群」最近 4 周的决议与里程碑\n" +
+		"\n🗓 03-11 ~ 03-14\n✅ 决议：\n   • 下线旧接口\n" +
+		"\n🗓 03-04 ~ 03-10\n✅ 决议：\n   • 周五发版\n🏁 里程碑：\n   • 支付改造完成"
+	if got != want {
+		t.Errorf("formatRecentDecisions() =\n%q\nwant\n%q", got, want)
+	}
+
+	q, _ = parseRecentDecisionsQuery("上周有哪些里程碑")
+	if got := formatRecentDecisions("研发群", q, summaries[1:]); got != "📭 「研发群」上周没有记录到里程碑" {
+		t.Errorf("Unexpected empty result: %q", got)
+	}
+}
+
+// mondayOf 返回与 generateWeeklySummaries 一致的周开始时间
+func mondayOf(t time.Time) time.Time {
+	weekStart := t.Truncate(24 * time.Hour)
+	for weekStart.Weekday() != time.Monday {
+		weekStart = weekStart.AddDate(0, 0, -1)
+	}
+	return weekStart
+}
+
+func TestGenerateWeeklySummariesUsesCache(t *testing.T) {
+	weekStart := mondayOf(time.Now().AddDate(0, 0, -21))
+	store := &fakeWeeklyStore{}
+	for i := 0; i < 2; i++ {
+		start := weekStart.AddDate(0, 0, 7*i)
+		store.records = append(store.records, &model.WeeklySummaryRecord{
+			ChatID: "oc_dev", WeekStart: start, WeekEnd: start.AddDate(0, 0, 7),
+			Decisions: []string{"决议" + string(rune('A'+i))}, MessageCount: 10, Complete: true,
+		})
+	}
+	// 未结束的周不从缓存读取
+	store.records = append(store.records, &model.WeeklySummaryRecord{
+		ChatID: "oc_dev", WeekStart: weekStart.AddDate(0, 0, 14), Complete: false,
+	})
+
+	// MessageModel 为空，若访问数据库会 panic
+	hp := &HybridProcessor{svcCtx: &svc.ServiceContext{}, weeklyStore: store}
+	summaries, err := hp.generateWeeklySummaries(context.Background(), "oc_dev", weekStart, weekStart.AddDate(0, 0, 14))
+	if err != nil {
+		t.Fatalf("generateWeeklySummaries() error: %v", err)
+	}
+	if len(summaries) != 2 || summaries[0].Decisions[0] != "决议A" || summaries[1].Decisions[0] != "决议B" {
+		t.Errorf("Expected cached summaries, got %+v", summaries)
+	}
+	if len(store.saved) != 0 {
+		t.Errorf("Cached weeks should not be saved again, saved %d", len(store.saved))
+	}
+}
+
+func TestTryRecentDecisionsFallback(t *testing.T) {
+	hp := &HybridProcessor{svcCtx: &svc.ServiceContext{}, weeklyStore: &fakeWeeklyStore{}}
+	if _, ok := hp.tryRecentDecisions(withChatType(context.Background(), "p2p"), "ou_user", "最近有什么决议"); ok {
+		t.Error("Private chat should fall back to normal processing")
+	}
+	if _, ok := hp.tryRecentDecisions(withChatType(context.Background(), "group"), "oc_dev", "今天讨论了什么"); ok {
+		t.Error("Non-decision query should fall back to normal processing")
+	}
+
+	hp.weeklyStore = nil
+	if _, ok := hp.tryRecentDecisions(withChatType(context.Background(), "group"), "oc_dev", "最近有什么决议"); ok {
+		t.Error("Without weekly summary store should fall back to normal processing")
+	}
+}
@@ -0,0 +1,81 @@
+package ai
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// 导出到知识库的报告类型
+const (
+	reportKindSummary  = "summary"
+	reportKindTimeline = "timeline"
+)
+
+// reportExportTimeout 单次导出的超时时间
+const reportExportTimeout = 30 * time.Second
+
+// ReportExporter 将生成的报告持久化到知识库（由 collector.DifySyncer 实现）
+type ReportExporter interface {
+	ExportReport(ctx context.Context, sourceID, title, content string) (bool, error)
+}
+
+// SetReportExporter 设置报告导出器，配合 Dify.ExportReports 使用
+func (hp *HybridProcessor) SetReportExporter(exporter ReportExporter) {
+	hp.reportExporter = exporter
+}
+
+// reportSourceID 报告的来源标识，同一群同一天的同类报告覆盖更新
+func reportSourceID(kind, chatID string, date time.Time) string {
+	return "report:" + kind + ":" + chatID + ":" + date.Format("2006-01-02")
+}
+
+// reportTitle 报告在知识库中的文档标题，如 "群历程-研发群-2025-01-05"
+func reportTitle(kind, groupName string, date time.Time) string {
+	label := "群总结"
+	if kind == reportKindTimeline {
+		label = "群历程"
+	}
+	return label + "-" + groupName + "-" + date.Format("2006-01-02")
+}
+
+// exportReport 将报告推送到知识库（未开启或未配置导出器时忽略）
+func (hp *HybridProcessor) exportReport(ctx context.Context, kind, chatID, groupName, content string, date time.Time) {
+	if hp.reportExporter == nil || !hp.svcCtx.Config.Dify.ExportReports || chatID == "" || content == "" {
+		return
+	}
+	if groupName == "" {
+		groupName = hp.lookupGroupName(ctx, chatID)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, reportExportTimeout)
+	defer cancel()
+
+	title := reportTitle(kind, groupName, date)
+	written, err := hp.reportExporter.ExportReport(ctx, reportSourceID(kind, chatID, date), title, content)
+	if err != nil {
+		log.Printf("Failed to export report %s to knowledge base: %v", title, err)
+		return
+	}
+	if written {
+		log.Printf("Exported report %s to knowledge base", title)
+	}
+}
+
+// exportReportAsync 后台导出报告，不阻塞回复
+func (hp *HybridProcessor) exportReportAsync(kind, chatID, groupName, content string) {
+	if hp.reportExporter == nil || !hp.svcCtx.Config.Dify.ExportReports {
+		return
+	}
+	go hp.exportReport(context.Background(), kind, chatID, groupName, content, time.Now())
+}
+
+// lookupGroupName 查询群名称，查不到时返回 chatID
+func (hp *HybridProcessor) lookupGroupName(ctx context.Context, chatID string) string {
+	if hp.svcCtx.GroupModel != nil {
+		if group, err := hp.svcCtx.GroupModel.FindByChatID(ctx, chatID); err == nil && group.ChatName.Valid && group.ChatName.String != "" {
+			return group.ChatName.String
+		}
+	}
+	return chatID
+}
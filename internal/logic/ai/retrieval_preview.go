@@ -0,0 +1,104 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"team-assistant/internal/service"
+)
+
+// ======================== 检索预览（调试检索质量，不调用生成模型） ========================
+
+// 检索预览展示限制
+const (
+	retrievalPreviewLimit   = 10 // 最多展示的结果数
+	retrievalPreviewSnippet = 80 // 每条结果展示的内容长度（字符）
+)
+
+// qaHybridSearch 执行问答使用的混合检索（语义 + 关键词融合 + 同义词扩展）
+// 有关键词时用关键词拼接作为检索文本；检索预览复用同一逻辑，保证预览结果与问答一致
+func (hp *HybridProcessor) qaHybridSearch(ctx context.Context, query string, keywords []string, chatID string, chatIDs []string, start, end *time.Time, limit int) ([]service.SearchResult, error) {
+	searchQuery := query
+	if len(keywords) > 0 {
+		searchQuery = strings.Join(keywords, " ")
+	}
+
+	hybridOpts := service.DefaultHybridSearchOptions()
+	hybridOpts.ChatID = chatID
+	hybridOpts.ChatIDs = chatIDs
+	hybridOpts.Keywords = keywords
+	hybridOpts.StartTime = start
+	hybridOpts.EndTime = end
+
+	return hp.svcCtx.Services.RAG.HybridSearch(ctx, searchQuery, keywords, limit, hybridOpts)
+}
+
+// PreviewRetrieval 按问答流程执行混合检索并返回带分数的结果，不调用生成模型
+// chatID 为空时与私聊问答一致：先挑选相关群再检索
+func (hp *HybridProcessor) PreviewRetrieval(ctx context.Context, chatID, query string) (string, error) {
+	if hp.svcCtx.Services == nil || hp.svcCtx.Services.RAG == nil || !hp.svcCtx.Services.RAG.IsEnabled() {
+		return "", fmt.Errorf("向量检索未启用")
+	}
+
+	keywords := hp.extractSearchKeywords(query, nil)
+
+	var relevantChatIDs []string
+	if chatID == "" {
+		relevantChatIDs = hp.findRelevantGroups(ctx, query, keywords)
+	}
+
+	searchLimit := 100
+	if hp.isStatisticalQuery(query) {
+		searchLimit = 500
+	}
+
+	results, err := hp.qaHybridSearch(ctx, query, keywords, chatID, relevantChatIDs, nil, nil, searchLimit/2)
+	if err != nil {
+		return "", fmt.Errorf("hybrid search: %w", err)
+	}
+
+	scope := "所有群"
+	switch {
+	case chatID != "":
+		scope = "当前群"
+	case len(relevantChatIDs) > 0:
+		scope = fmt.Sprintf("最相关的 %d 个群", len(relevantChatIDs))
+	}
+	return formatRetrievalPreview(query, keywords, scope, results), nil
+}
+
+// formatRetrievalPreview 格式化检索预览结果
+func formatRetrievalPreview(query string, keywords []string, scope string, results []service.SearchResult) string {
+	var sb strings.Builder
+	sb.WriteString("🔍 **检索预览**（未调用生成模型）\n\n")
+	sb.WriteString(fmt.Sprintf("问题：%s\n", query))
+	if len(keywords) > 0 {
+		sb.WriteString(fmt.Sprintf("关键词：%s\n", strings.Join(keywords, "、")))
+	} else {
+		sb.WriteString("关键词：无（使用原问题检索）\n")
+	}
+	sb.WriteString(fmt.Sprintf("范围：%s\n", scope))
+
+	if len(results) == 0 {
+		sb.WriteString("\n📭 没有检索到相关消息")
+		return sb.String()
+	}
+
+	shown := len(results)
+	if shown > retrievalPreviewLimit {
+		shown = retrievalPreviewLimit
+	}
+	sb.WriteString(fmt.Sprintf("\n共召回 %d 条，展示前 %d 条：\n", len(results), shown))
+	for i, r := range results[:shown] {
+		chat := ""
+		if r.ChatName != "" {
+			chat = " @" + r.ChatName
+		}
+		sb.WriteString(fmt.Sprintf("\n%d. [%.4f] %s %s%s\n   %s\n",
+			i+1, r.Score, r.CreatedAt.Format("01-02 15:04"), r.SenderName, chat,
+			trimSnippet(r.Content, retrievalPreviewSnippet)))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
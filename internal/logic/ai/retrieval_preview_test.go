@@ -0,0 +1,78 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"team-assistant/internal/service"
+	"team-assistant/internal/svc"
+)
+
+func TestFormatRetrievalPreview(t *testing.T) {
+	createdAt := time.Date(2026, 3, 2, 10, 30, 0, 0, time.Local)
+
+	tests := []struct {
+		name     string
+		keywords []string
+		results  []service.SearchResult
+		want     []string
+		notWant  []string
+	}{
+		{
+			name:     "展示分数和来源",
+			keywords: []string{"登录", "超时"},
+			results: []service.SearchResult{
+				{SenderName: "张三", ChatName: "研发群", Content: "登录接口超时了", CreatedAt: createdAt, Score: 0.91234},
+				{SenderName: "李四", Content: "已经修复登录超时", CreatedAt: createdAt, Score: 0.5},
+			},
+			want:    []string{"关键词：登录、超时", "共召回 2 条，展示前 2 条", "1. [0.9123] 03-02 10:30 张三 @研发群", "登录接口超时了", "2. [0.5000] 03-02 10:30 李四\n"},
+			notWant: []string{"李四 @"},
+		},
+		{
+			name:    "没有结果",
+			want:    []string{"关键词：无", "没有检索到相关消息"},
+			notWant: []string{"共召回"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := formatRetrievalPreview("登录超时", tt.keywords, "所有群", tt.results)
+			for _, w := range tt.want {
+				if !strings.Contains(got, w) {
+					t.Errorf("Preview should contain %q, got:\n%s", w, got)
+				}
+			}
+			for _, w := range tt.notWant {
+				if strings.Contains(got, w) {
+					t.Errorf("Preview should not contain %q, got:\n%s", w, got)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatRetrievalPreviewLimit(t *testing.T) {
+	var results []service.SearchResult
+	for i := 0; i < retrievalPreviewLimit+5; i++ {
+		results = append(results, service.SearchResult{SenderName: "张三", Content: fmt.Sprintf("消息%d", i), Score: 0.5})
+	}
+
+	got := formatRetrievalPreview("问题", nil, "当前群", results)
+	if !strings.Contains(got, fmt.Sprintf("共召回 %d 条，展示前 %d 条", len(results), retrievalPreviewLimit)) {
+		t.Errorf("Unexpected summary line:\n%s", got)
+	}
+	if strings.Contains(got, fmt.Sprintf("消息%d", retrievalPreviewLimit)) {
+		t.Errorf("Results beyond limit should be hidden:\n%s", got)
+	}
+}
+
+func TestPreviewRetrievalRequiresRAG(t *testing.T) {
+	hp := &HybridProcessor{svcCtx: &svc.ServiceContext{}}
+	if _, err := hp.PreviewRetrieval(context.Background(), "", "登录超时"); err == nil {
+		t.Error("Expected error when RAG is not enabled")
+	}
+}
@@ -0,0 +1,73 @@
+package ai
+
+import (
+	"math"
+
+	"team-assistant/internal/config"
+)
+
+// ======================== 相关度展示 ========================
+
+// 相关度展示方式（LLM.ScoreDisplay）
+const (
+	scoreDisplayRaw     = "raw"     // 原始融合分数
+	scoreDisplayMinMax  = "minmax"  // 本次结果内归一化
+	scoreDisplaySigmoid = "sigmoid" // S 型曲线映射
+)
+
+// sigmoid 映射默认参数
+const (
+	defaultScoreSigmoidMidpoint  = 0.5
+	defaultScoreSigmoidSteepness = 10
+)
+
+// calibrateScores 按配置把搜索分数换算为展示用的相关度（0~1）
+// 向量检索的余弦分数随问题变化，原始分数在不同问题之间不可比，展示的百分比容易让人困惑
+func calibrateScores(scores []float32, cfg config.LLMConfig) []float32 {
+	switch cfg.ScoreDisplay {
+	case scoreDisplayMinMax:
+		return minMaxScores(scores)
+	case scoreDisplaySigmoid:
+		midpoint, steepness := cfg.ScoreSigmoidMidpoint, cfg.ScoreSigmoidSteepness
+		if midpoint == 0 {
+			midpoint = defaultScoreSigmoidMidpoint
+		}
+		if steepness == 0 {
+			steepness = defaultScoreSigmoidSteepness
+		}
+		calibrated := make([]float32, len(scores))
+		for i, s := range scores {
+			calibrated[i] = sigmoidScore(s, midpoint, steepness)
+		}
+		return calibrated
+	default:
+		return scores
+	}
+}
+
+// minMaxScores 在本次结果内归一化：最高分为 1，最低分为 0；分数都相同时均为 1
+func minMaxScores(scores []float32) []float32 {
+	if len(scores) == 0 {
+		return scores
+	}
+	lo, hi := scores[0], scores[0]
+	for _, s := range scores[1:] {
+		lo = min(lo, s)
+		hi = max(hi, s)
+	}
+
+	normalized := make([]float32, len(scores))
+	for i, s := range scores {
+		if hi == lo {
+			normalized[i] = 1
+		} else {
+			normalized[i] = (s - lo) / (hi - lo)
+		}
+	}
+	return normalized
+}
+
+// sigmoidScore 把原始分数映射到 0~1，midpoint 处为 0.5
+func sigmoidScore(score float32, midpoint, steepness float64) float32 {
+	return float32(1 / (1 + math.Exp(-steepness*(float64(score)-midpoint))))
+}
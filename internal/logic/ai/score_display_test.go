@@ -0,0 +1,55 @@
+package ai
+
+import (
+	"math"
+	"testing"
+
+	"team-assistant/internal/config"
+)
+
+func approxEqual(a, b float32) bool {
+	return math.Abs(float64(a-b)) < 1e-4
+}
+
+func TestCalibrateScores(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    config.LLMConfig
+		scores []float32
+		want   []float32
+	}{
+		{"默认保持原始分数", config.LLMConfig{}, []float32{0.82, 0.4}, []float32{0.82, 0.4}},
+		{"raw", config.LLMConfig{ScoreDisplay: "raw"}, []float32{0.3}, []float32{0.3}},
+		{"minmax 最高为 1 最低为 0", config.LLMConfig{ScoreDisplay: "minmax"}, []float32{0.6, 0.2, 0.4}, []float32{1, 0, 0.5}},
+		{"minmax 分数相同", config.LLMConfig{ScoreDisplay: "minmax"}, []float32{0.3, 0.3}, []float32{1, 1}},
+		{"minmax 单条结果", config.LLMConfig{ScoreDisplay: "minmax"}, []float32{0.12}, []float32{1}},
+		{"minmax 空结果", config.LLMConfig{ScoreDisplay: "minmax"}, nil, nil},
+		{"sigmoid 默认参数", config.LLMConfig{ScoreDisplay: "sigmoid"}, []float32{0.5, 0.7, 0.3}, []float32{0.5, 0.8808, 0.1192}},
+		{"sigmoid 自定义参数", config.LLMConfig{ScoreDisplay: "sigmoid", ScoreSigmoidMidpoint: 0.4, ScoreSigmoidSteepness: 5}, []float32{0.4, 0.6}, []float32{0.5, 0.7311}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := calibrateScores(tt.scores, tt.cfg)
+			if len(got) != len(tt.want) {
+				t.Fatalf("calibrateScores() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if !approxEqual(got[i], tt.want[i]) {
+					t.Errorf("calibrateScores()[%d] = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSigmoidScoreMonotonic(t *testing.T) {
+	prev := sigmoidScore(0, defaultScoreSigmoidMidpoint, defaultScoreSigmoidSteepness)
+	for s := float32(0.1); s <= 1; s += 0.1 {
+		cur := sigmoidScore(s, defaultScoreSigmoidMidpoint, defaultScoreSigmoidSteepness)
+		if cur <= prev || cur >= 1 {
+			t.Fatalf("sigmoidScore(%v) = %v, previous %v", s, cur, prev)
+		}
+		prev = cur
+	}
+}
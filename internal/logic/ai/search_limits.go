@@ -0,0 +1,25 @@
+package ai
+
+// ======================== 搜索结果条数（Search.FetchLimit / Search.DisplayLimit） ========================
+
+// 搜索结果条数默认值
+const (
+	defaultSearchFetchLimit   = 20 // 从数据库/向量库取回的条数
+	defaultSearchDisplayLimit = 10 // 回复中展示的条数，其余只显示条数
+)
+
+// searchFetchLimit 搜索时取回的消息条数，未配置时使用默认值
+func (hp *HybridProcessor) searchFetchLimit() int {
+	if hp.svcCtx != nil && hp.svcCtx.Config.Search.FetchLimit > 0 {
+		return hp.svcCtx.Config.Search.FetchLimit
+	}
+	return defaultSearchFetchLimit
+}
+
+// searchDisplayLimit 回复中展示的消息条数，未配置时使用默认值
+func (hp *HybridProcessor) searchDisplayLimit() int {
+	if hp.svcCtx != nil && hp.svcCtx.Config.Search.DisplayLimit > 0 {
+		return hp.svcCtx.Config.Search.DisplayLimit
+	}
+	return defaultSearchDisplayLimit
+}
@@ -0,0 +1,128 @@
+package ai
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"team-assistant/internal/config"
+	"team-assistant/internal/model"
+	"team-assistant/internal/svc"
+	"team-assistant/pkg/llm"
+)
+
+// limitRecorder 记录查询的 LIMIT 参数，并按 LIMIT 返回同样条数的消息
+type limitRecorder struct {
+	mu     sync.Mutex
+	limits []int64
+}
+
+func (r *limitRecorder) Open(string) (driver.Conn, error) { return limitConn{r}, nil }
+
+type limitConn struct{ r *limitRecorder }
+
+func (limitConn) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (limitConn) Close() error                        { return nil }
+func (limitConn) Begin() (driver.Tx, error)           { return nil, driver.ErrSkip }
+
+func (c limitConn) QueryContext(_ context.Context, _ string, args []driver.NamedValue) (driver.Rows, error) {
+	limit := args[len(args)-1].Value.(int64)
+	c.r.mu.Lock()
+	c.r.limits = append(c.r.limits, limit)
+	c.r.mu.Unlock()
+
+	rows := &olderMessagesRows{}
+	created := time.Date(2024, 3, 5, 10, 0, 0, 0, time.Local)
+	for i := int64(0); i < limit; i++ {
+		rows.values = append(rows.values, []driver.Value{
+			i + 1, fmt.Sprintf("om_%d", i), "oc_team", "ou_zhang", "张三", nil, "text",
+			fmt.Sprintf("登录超时第 %d 次复现", i+1), nil, []byte("[]"), nil, nil, nil, int64(0), int64(0),
+			created, nil, created,
+		})
+	}
+	return rows, nil
+}
+
+var (
+	searchLimitDriver     = &limitRecorder{}
+	registerSearchLimitDB sync.Once
+)
+
+func newSearchLimitProcessor(t *testing.T, search config.SearchConfig) *HybridProcessor {
+	t.Helper()
+	registerSearchLimitDB.Do(func() { sql.Register("search_limits", searchLimitDriver) })
+	searchLimitDriver.mu.Lock()
+	searchLimitDriver.limits = nil
+	searchLimitDriver.mu.Unlock()
+
+	db, err := sql.Open("search_limits", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return &HybridProcessor{svcCtx: &svc.ServiceContext{
+		Config:       config.Config{Search: search},
+		MessageModel: model.NewChatMessageModel(db),
+		Services:     &svc.Services{},
+	}}
+}
+
+func TestKeywordSearchLimits(t *testing.T) {
+	tests := []struct {
+		name        string
+		search      config.SearchConfig
+		parsed      *llm.ParsedQuery
+		wantFetch   int64
+		wantShown   int
+		wantOmitted int
+	}{
+		{"默认值", config.SearchConfig{}, &llm.ParsedQuery{Keywords: []string{"登录超时"}},
+			defaultSearchFetchLimit, defaultSearchDisplayLimit, defaultSearchFetchLimit - defaultSearchDisplayLimit},
+		{"配置的条数", config.SearchConfig{FetchLimit: 7, DisplayLimit: 3}, &llm.ParsedQuery{Keywords: []string{"登录超时"}},
+			7, 3, 4},
+		{"按发言人搜索同样适用", config.SearchConfig{FetchLimit: 5, DisplayLimit: 5}, &llm.ParsedQuery{TargetUsers: []string{"张三"}},
+			5, 5, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hp := newSearchLimitProcessor(t, tt.search)
+			result, err := hp.handleKeywordSearch(context.Background(), tt.parsed, "oc_team")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(searchLimitDriver.limits) != 1 || searchLimitDriver.limits[0] != tt.wantFetch {
+				t.Errorf("query limits = %v, want [%d]", searchLimitDriver.limits, tt.wantFetch)
+			}
+			if got := strings.Count(result, "登录超时第"); got != tt.wantShown {
+				t.Errorf("shown %d messages, want %d:\n%s", got, tt.wantShown, result)
+			}
+			omitted := fmt.Sprintf("还有 %d 条消息", tt.wantOmitted)
+			if (tt.wantOmitted > 0) != strings.Contains(result, omitted) {
+				t.Errorf("Expected omitted note %q = %v:\n%s", omitted, tt.wantOmitted > 0, result)
+			}
+		})
+	}
+}
+
+func TestLocalAnswerDisplayLimit(t *testing.T) {
+	messages := make([]string, 8)
+	for i := range messages {
+		messages[i] = fmt.Sprintf("[03-05 10:0%d] 张三: 登录超时", i)
+	}
+
+	hp := &HybridProcessor{svcCtx: &svc.ServiceContext{Config: config.Config{Search: config.SearchConfig{DisplayLimit: 3}}}}
+	answer := hp.generateLocalAnswer("登录超时", messages)
+	if got := strings.Count(answer, "张三: 登录超时"); got != 3 {
+		t.Errorf("generateLocalAnswer() shows %d messages, want 3:\n%s", got, answer)
+	}
+	if !strings.Contains(answer, "还有 5 条消息未显示") {
+		t.Errorf("Expected omitted note:\n%s", answer)
+	}
+}
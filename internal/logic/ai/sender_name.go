@@ -0,0 +1,100 @@
+package ai
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+
+	"team-assistant/internal/config"
+	"team-assistant/internal/model"
+	"team-assistant/internal/svc"
+	"team-assistant/pkg/lark"
+)
+
+// 发言人名称缺失时的处理方式
+const (
+	SenderModeLabel   = "label"   // 统一显示为配置的标签（默认）
+	SenderModeResolve = "resolve" // 按 open_id 调用飞书接口实时查询姓名，失败时退回标签
+)
+
+// defaultUnknownSenderLabel 发言人名称缺失时的默认标签
+// 同步缺口导致很多正常成员的消息没有名字，不再统一显示为"系统/机器人"
+const defaultUnknownSenderLabel = "未知成员"
+
+// userInfoFetcher 查询飞书用户信息（便于测试替换）
+type userInfoFetcher interface {
+	GetUserInfo(ctx context.Context, openID string) (*lark.UserInfo, error)
+}
+
+// senderResolver 解析消息发言人名称，resolve 模式下缓存 open_id -> 姓名
+type senderResolver struct {
+	mode    string
+	label   string
+	fetcher userInfoFetcher
+
+	mu      sync.Mutex
+	cache   map[string]string
+	metrics *svc.Metrics // 缓存命中统计（可选）
+}
+
+// newSenderResolver 创建发言人名称解析器
+func newSenderResolver(cfg config.LLMConfig, fetcher userInfoFetcher) *senderResolver {
+	label := strings.TrimSpace(cfg.UnknownSenderLabel)
+	if label == "" {
+		label = defaultUnknownSenderLabel
+	}
+	mode := cfg.UnknownSenderMode
+	if mode != SenderModeResolve || fetcher == nil {
+		mode = SenderModeLabel
+	}
+	return &senderResolver{
+		mode:    mode,
+		label:   label,
+		fetcher: fetcher,
+		cache:   make(map[string]string),
+	}
+}
+
+// name 返回消息的发言人名称，缺失时按配置查询或返回标签
+func (r *senderResolver) name(ctx context.Context, msg *model.ChatMessage) string {
+	if msg.SenderName.Valid && strings.TrimSpace(msg.SenderName.String) != "" {
+		return msg.SenderName.String
+	}
+	if r.mode != SenderModeResolve || !msg.SenderID.Valid || !strings.HasPrefix(msg.SenderID.String, "ou_") {
+		return r.label
+	}
+
+	openID := msg.SenderID.String
+	r.mu.Lock()
+	name, ok := r.cache[openID]
+	r.mu.Unlock()
+	r.metrics.RecordCacheLookup("sender_name", ok)
+	if ok {
+		return name
+	}
+
+	name = r.label
+	if info, err := r.fetcher.GetUserInfo(ctx, openID); err != nil {
+		log.Printf("Failed to resolve sender %s: %v", openID, err)
+	} else if info != nil && info.Name != "" {
+		name = info.Name
+	}
+
+	// 查询失败也缓存，避免每条消息都请求一次接口
+	r.mu.Lock()
+	r.cache[openID] = name
+	r.mu.Unlock()
+	return name
+}
+
+// senderName 返回消息的发言人名称（未初始化解析器时使用默认标签）
+func (hp *HybridProcessor) senderName(ctx context.Context, msg *model.ChatMessage) string {
+	if hp.senders == nil {
+		if msg.SenderName.Valid && strings.TrimSpace(msg.SenderName.String) != "" {
+			return msg.SenderName.String
+		}
+		return defaultUnknownSenderLabel
+	}
+	return hp.senders.name(ctx, msg)
+}
@@ -0,0 +1,123 @@
+package ai
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"team-assistant/internal/model"
+)
+
+// ======================== 发言人角色（上下文中标注成员角色） ========================
+
+// senderRoleTTL 成员档案缓存时间，档案很少变化，定期重新加载即可
+const senderRoleTTL = 10 * time.Minute
+
+// memberLister 列出团队成员档案（便于测试替换）
+type memberLister interface {
+	ListAll(ctx context.Context) ([]*model.TeamMember, error)
+}
+
+// senderRoles 按飞书 open_id/user_id 或姓名查找发言人角色，成员档案整体缓存
+type senderRoles struct {
+	lister memberLister
+	ttl    time.Duration
+
+	mu       sync.Mutex
+	loadedAt time.Time
+	byID     map[string]string // lark_open_id / lark_user_id -> 角色
+	byName   map[string]string // 成员姓名 -> 角色
+}
+
+// newSenderRoles 创建发言人角色查找器
+func newSenderRoles(lister memberLister) *senderRoles {
+	return &senderRoles{lister: lister, ttl: senderRoleTTL}
+}
+
+// memberRoleLabel 成员的角色标签：优先 role，没有时用部门
+func memberRoleLabel(m *model.TeamMember) string {
+	for _, v := range []sql.NullString{m.Role, m.Department} {
+		if v.Valid && strings.TrimSpace(v.String) != "" {
+			return strings.TrimSpace(v.String)
+		}
+	}
+	return ""
+}
+
+// load 缓存过期时重新加载成员档案；加载失败保留旧数据，等下个周期再试
+func (r *senderRoles) load(ctx context.Context) {
+	if !r.loadedAt.IsZero() && time.Since(r.loadedAt) < r.ttl {
+		return
+	}
+	r.loadedAt = time.Now()
+
+	members, err := r.lister.ListAll(ctx)
+	if err != nil {
+		log.Printf("Failed to load member roles: %v", err)
+		return
+	}
+	byID := make(map[string]string)
+	byName := make(map[string]string)
+	for _, m := range members {
+		label := memberRoleLabel(m)
+		if label == "" {
+			continue
+		}
+		for _, id := range []sql.NullString{m.LarkOpenID, m.LarkUserID} {
+			if id.Valid && id.String != "" {
+				byID[id.String] = label
+			}
+		}
+		if name := strings.TrimSpace(m.Name); name != "" {
+			byName[name] = label
+		}
+	}
+	r.byID, r.byName = byID, byName
+}
+
+// role 返回发言人的角色，先按发言人 ID 匹配，再按姓名匹配；没有档案时返回空字符串
+func (r *senderRoles) role(ctx context.Context, senderID, senderName string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.load(ctx)
+	if role, ok := r.byID[senderID]; ok && senderID != "" {
+		return role
+	}
+	return r.byName[strings.TrimSpace(senderName)]
+}
+
+// withSenderRole 在发言人名称前加上角色，如 "[产品经理] 李四"
+// 名称本身已带角色（如 "产品-李四"）时不重复标注
+func withSenderRole(name, role string) string {
+	if role == "" || strings.Contains(name, role) {
+		return name
+	}
+	return fmt.Sprintf("[%s] %s", role, name)
+}
+
+// senderLabel 上下文中使用的发言人名称，开启 LLM.SenderRoles 时带上成员档案中的角色
+func (hp *HybridProcessor) senderLabel(ctx context.Context, senderID, senderName string) string {
+	if hp.roles == nil {
+		return senderName
+	}
+	return withSenderRole(senderName, hp.roles.role(ctx, senderID, senderName))
+}
+
+// messageSenderLabel 消息的发言人名称（处理名称缺失），开启时带上角色
+func (hp *HybridProcessor) messageSenderLabel(ctx context.Context, msg *model.ChatMessage) string {
+	return hp.senderLabel(ctx, msg.SenderID.String, hp.senderName(ctx, msg))
+}
+
+// summaryLine 格式化总结输入行，开启 LLM.SenderRoles 时发言人带上角色
+func (hp *HybridProcessor) summaryLine(ctx context.Context, msg *model.ChatMessage, timeLayout, mode string) (string, bool) {
+	if hp.roles != nil && msg.SenderName.Valid {
+		labeled := *msg
+		labeled.SenderName.String = hp.senderLabel(ctx, msg.SenderID.String, msg.SenderName.String)
+		msg = &labeled
+	}
+	return formatSummaryLine(msg, timeLayout, mode)
+}
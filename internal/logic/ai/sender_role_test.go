@@ -0,0 +1,122 @@
+package ai
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"team-assistant/internal/model"
+)
+
+type fakeMemberLister struct {
+	members []*model.TeamMember
+	err     error
+	calls   int
+}
+
+func (f *fakeMemberLister) ListAll(ctx context.Context) ([]*model.TeamMember, error) {
+	f.calls++
+	return f.members, f.err
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func testMembers() *fakeMemberLister {
+	return &fakeMemberLister{members: []*model.TeamMember{
+		{Name: "李四", LarkOpenID: nullString("ou_li"), Role: nullString("产品经理")},
+		{Name: "王五", LarkUserID: nullString("u_wang"), Department: nullString("测试")},
+		{Name: "赵六", LarkOpenID: nullString("ou_zhao")},
+	}}
+}
+
+func TestWithSenderRole(t *testing.T) {
+	tests := []struct {
+		name   string
+		sender string
+		role   string
+		want   string
+	}{
+		{"加上角色", "李四", "产品经理", "[产品经理] 李四"},
+		{"没有角色", "李四", "", "李四"},
+		{"名称已带角色", "测试-王五", "测试", "测试-王五"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := withSenderRole(tt.sender, tt.role); got != tt.want {
+				t.Errorf("withSenderRole(%q, %q) = %q, want %q", tt.sender, tt.role, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSenderRolesLookup(t *testing.T) {
+	roles := newSenderRoles(testMembers())
+	tests := []struct {
+		name     string
+		senderID string
+		sender   string
+		want     string
+	}{
+		{"按 open_id 匹配", "ou_li", "Li", "产品经理"},
+		{"按 user_id 匹配并用部门兜底", "u_wang", "王五", "测试"},
+		{"按姓名匹配", "ou_unknown", "李四", "产品经理"},
+		{"档案没有角色", "ou_zhao", "赵六", ""},
+		{"不在档案中", "ou_other", "路人", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := roles.role(context.Background(), tt.senderID, tt.sender); got != tt.want {
+				t.Errorf("role(%q, %q) = %q, want %q", tt.senderID, tt.sender, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSenderRolesCache(t *testing.T) {
+	lister := testMembers()
+	roles := newSenderRoles(lister)
+	roles.role(context.Background(), "ou_li", "李四")
+	roles.role(context.Background(), "u_wang", "王五")
+	if lister.calls != 1 {
+		t.Errorf("ListAll called %d times within TTL, want 1", lister.calls)
+	}
+
+	// 过期后重新加载，失败时保留旧数据
+	roles.loadedAt = time.Now().Add(-2 * senderRoleTTL)
+	lister.err = errors.New("db down")
+	if got := roles.role(context.Background(), "ou_li", "李四"); got != "产品经理" {
+		t.Errorf("role() after failed reload = %q, want cached 产品经理", got)
+	}
+	if lister.calls != 2 {
+		t.Errorf("ListAll called %d times after TTL, want 2", lister.calls)
+	}
+}
+
+func TestContextLinesWithSenderRole(t *testing.T) {
+	msg := &model.ChatMessage{
+		SenderID:   nullString("ou_li"),
+		SenderName: nullString("李四"),
+		Content:    nullString("这个需求下周上线"),
+		CreatedAt:  time.Date(2026, 3, 2, 10, 30, 0, 0, time.Local),
+	}
+
+	hp := &HybridProcessor{}
+	if got, _ := hp.summaryLine(context.Background(), msg, "15:04", ForwardModeLabel); got != "[10:30] 李四: 这个需求下周上线" {
+		t.Errorf("summaryLine() without roles = %q", got)
+	}
+
+	hp.roles = newSenderRoles(testMembers())
+	if got, _ := hp.summaryLine(context.Background(), msg, "15:04", ForwardModeLabel); got != "[10:30] [产品经理] 李四: 这个需求下周上线" {
+		t.Errorf("summaryLine() with roles = %q", got)
+	}
+	if msg.SenderName.String != "李四" {
+		t.Errorf("Original message was modified: %q", msg.SenderName.String)
+	}
+	if got := hp.messageSenderLabel(context.Background(), msg); got != "[产品经理] 李四" {
+		t.Errorf("messageSenderLabel() = %q", got)
+	}
+}
@@ -0,0 +1,61 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"team-assistant/internal/config"
+	"team-assistant/pkg/lark"
+)
+
+// ======================== 多表站点查询 ========================
+
+// bitableFinder 按字段查找多维表格记录
+type bitableFinder interface {
+	FindBitableRecord(ctx context.Context, appToken, tableID, fieldName, value string) (*lark.BitableRecord, error)
+}
+
+// siteMatch 查到的站点记录及其来源表
+type siteMatch struct {
+	record *lark.BitableRecord
+	table  config.BitableTableConfig
+}
+
+// findSiteRecord 按顺序在各表中查找 column(表) 等于 value 的记录，返回第一条匹配
+// 某张表查询失败时继续查下一张；都没有查到且有表查询失败时返回最后一个错误
+func findSiteRecord(ctx context.Context, finder bitableFinder, tables []config.BitableTableConfig, value string, column func(config.BitableTableConfig) string) (*siteMatch, error) {
+	var lastErr error
+	for _, table := range tables {
+		record, err := finder.FindBitableRecord(ctx, table.AppToken, table.TableID, column(table), value)
+		if err != nil {
+			log.Printf("Failed to query site %s in bitable table %s: %v", value, table.Name, err)
+			lastErr = err
+			continue
+		}
+		if record != nil {
+			return &siteMatch{record: record, table: table}, nil
+		}
+	}
+	return nil, lastErr
+}
+
+// getSiteInfoByPrefix 按站点前缀依次查询配置的站点表
+func (hp *HybridProcessor) getSiteInfoByPrefix(ctx context.Context, prefix string) (*siteMatch, error) {
+	return findSiteRecord(ctx, hp.svcCtx.LarkClient, hp.svcCtx.Config.Bitable.SiteTables(), prefix,
+		func(t config.BitableTableConfig) string { return t.PrefixField })
+}
+
+// getSiteInfoBySiteID 按站点ID依次查询配置的站点表
+func (hp *HybridProcessor) getSiteInfoBySiteID(ctx context.Context, siteID string) (*siteMatch, error) {
+	return findSiteRecord(ctx, hp.svcCtx.LarkClient, hp.svcCtx.Config.Bitable.SiteTables(), siteID,
+		func(t config.BitableTableConfig) string { return t.IDField })
+}
+
+// withSiteSource 配置了多张表时在回复末尾标注记录来自哪张表
+func withSiteSource(reply string, match *siteMatch, tableCount int) string {
+	if tableCount <= 1 {
+		return reply
+	}
+	return reply + fmt.Sprintf("\n\n📁 来源：%s", match.table.Name)
+}
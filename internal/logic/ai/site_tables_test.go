@@ -0,0 +1,91 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"team-assistant/internal/config"
+	"team-assistant/pkg/lark"
+)
+
+// fakeBitableFinder 按 表ID/列名=值 返回记录，errTables 中的表返回错误
+type fakeBitableFinder struct {
+	records   map[string]*lark.BitableRecord
+	errTables map[string]bool
+	queried   []string
+}
+
+func (f *fakeBitableFinder) FindBitableRecord(ctx context.Context, appToken, tableID, fieldName, value string) (*lark.BitableRecord, error) {
+	f.queried = append(f.queried, tableID)
+	if f.errTables[tableID] {
+		return nil, errors.New("search bitable record failed: permission denied")
+	}
+	return f.records[tableID+"/"+fieldName+"="+value], nil
+}
+
+func TestFindSiteRecord(t *testing.T) {
+	tables := config.BitableConfig{Tables: []config.BitableTableConfig{
+		{Name: "站点表", AppToken: "app", TableID: "tbl_sites"},
+		{Name: "域名表", AppToken: "app", TableID: "tbl_domains", PrefixField: "前缀"},
+		{Name: "商户表", AppToken: "app", TableID: "tbl_merchants", PrefixField: "商户前缀"},
+	}}.SiteTables()
+	byPrefix := func(t config.BitableTableConfig) string { return t.PrefixField }
+
+	domainRecord := &lark.BitableRecord{RecordID: "rec_domain"}
+	merchantRecord := &lark.BitableRecord{RecordID: "rec_merchant"}
+
+	tests := []struct {
+		name        string
+		value       string
+		errTables   map[string]bool
+		wantRecord  string
+		wantTable   string
+		wantQueried int
+		wantErr     bool
+	}{
+		{"第一张表没有时查下一张，查到后不再继续", "by4", nil, "rec_domain", "域名表", 2, false},
+		{"查询失败的表跳过", "m01", map[string]bool{"tbl_sites": true}, "rec_merchant", "商户表", 3, false},
+		{"都没有查到", "zz9", nil, "", "", 3, false},
+		{"没有查到且有表查询失败", "zz9", map[string]bool{"tbl_domains": true}, "", "", 3, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			finder := &fakeBitableFinder{
+				records: map[string]*lark.BitableRecord{
+					"tbl_domains/前缀=by4":     domainRecord,
+					"tbl_merchants/商户前缀=by4": merchantRecord,
+					"tbl_merchants/商户前缀=m01": merchantRecord,
+				},
+				errTables: tt.errTables,
+			}
+			match, err := findSiteRecord(context.Background(), finder, tables, tt.value, byPrefix)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("findSiteRecord() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(finder.queried) != tt.wantQueried {
+				t.Errorf("Expected %d tables queried, got %v", tt.wantQueried, finder.queried)
+			}
+			if tt.wantRecord == "" {
+				if match != nil {
+					t.Errorf("Expected no match, got %+v", match)
+				}
+				return
+			}
+			if match == nil || match.record.RecordID != tt.wantRecord || match.table.Name != tt.wantTable {
+				t.Errorf("findSiteRecord() = %+v, want record %s from %s", match, tt.wantRecord, tt.wantTable)
+			}
+		})
+	}
+}
+
+func TestWithSiteSource(t *testing.T) {
+	match := &siteMatch{table: config.BitableTableConfig{Name: "域名表"}}
+	if got := withSiteSource("📍 站点「BY4」信息：", match, 1); got != "📍 站点「BY4」信息：" {
+		t.Errorf("Expected no source with single table, got %q", got)
+	}
+	if got := withSiteSource("📍 站点「BY4」信息：", match, 2); got != "📍 站点「BY4」信息：\n\n📁 来源：域名表" {
+		t.Errorf("withSiteSource() = %q", got)
+	}
+}
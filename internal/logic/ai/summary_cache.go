@@ -0,0 +1,88 @@
+package ai
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"team-assistant/pkg/llm"
+)
+
+// ======================== 消息总结缓存 ========================
+
+// summaryCacheTTL 总结缓存有效期
+const summaryCacheTTL = 24 * time.Hour
+
+// 强制重新总结的关键词，如 "重新总结昨天"、"刷新一下上周的总结"
+var summaryRefreshKeywords = []string{"重新总结", "重新生成", "重新汇总", "重新整理", "刷新", "不要缓存", "不用缓存"}
+
+// summaryCache 已结束时间段的总结结果缓存（LLM 输出），key 为群 + 时间段
+type summaryCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	now     func() time.Time
+	entries map[string]summaryCacheEntry
+}
+
+type summaryCacheEntry struct {
+	summary   string
+	createdAt time.Time
+}
+
+func newSummaryCache(ttl time.Duration) *summaryCache {
+	return &summaryCache{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[string]summaryCacheEntry),
+	}
+}
+
+// summaryCacheKey 缓存 key：chatID 为空表示所有群
+func summaryCacheKey(chatID string, start, end time.Time) string {
+	return fmt.Sprintf("%s|%d|%d", chatID, start.Unix(), end.Unix())
+}
+
+// get 读取未过期的总结
+func (c *summaryCache) get(key string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || c.now().Sub(entry.createdAt) >= c.ttl {
+		return "", false
+	}
+	return entry.summary, true
+}
+
+// put 保存总结（覆盖旧值），同时清理过期条目
+func (c *summaryCache) put(key, summary string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	for k, entry := range c.entries {
+		if now.Sub(entry.createdAt) >= c.ttl {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = summaryCacheEntry{summary: summary, createdAt: now}
+}
+
+// isSummaryRefreshQuery 用户是否要求忽略缓存重新总结
+func isSummaryRefreshQuery(query string) bool {
+	return containsAny(query, summaryRefreshKeywords)
+}
+
+// isClosedTimeRange 时间段是否已结束（结束后的总结才缓存，"今天"、"本周"等仍在变化）
+func isClosedTimeRange(tr llm.TimeRange) bool {
+	switch tr {
+	case llm.TimeRangeYesterday, llm.TimeRangeLastWeek, llm.TimeRangeLastMonth,
+		llm.TimeRangeLastQuarter, llm.TimeRangeLastYear:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,150 @@
+package ai
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"team-assistant/internal/model"
+	"team-assistant/internal/svc"
+	"team-assistant/pkg/llm"
+)
+
+func TestSummaryCache(t *testing.T) {
+	now := time.Date(2024, 3, 5, 10, 0, 0, 0, time.Local)
+	c := newSummaryCache(time.Hour)
+	c.now = func() time.Time { return now }
+
+	key := summaryCacheKey("oc_1", now.AddDate(0, 0, -1), now)
+	if _, ok := c.get(key); ok {
+		t.Fatal("Empty cache should miss")
+	}
+
+	c.put(key, "旧总结")
+	if got, ok := c.get(key); !ok || got != "旧总结" {
+		t.Errorf("get() = %q, %v", got, ok)
+	}
+	if _, ok := c.get(summaryCacheKey("oc_2", now.AddDate(0, 0, -1), now)); ok {
+		t.Error("Different chat should miss")
+	}
+
+	c.put(key, "新总结")
+	if got, _ := c.get(key); got != "新总结" {
+		t.Errorf("put() should overwrite, got %q", got)
+	}
+
+	now = now.Add(time.Hour)
+	if _, ok := c.get(key); ok {
+		t.Error("Expired entry should miss")
+	}
+	c.put("other", "x")
+	if _, ok := c.entries[key]; ok {
+		t.Error("Expired entry should be pruned on put")
+	}
+
+	var nilCache *summaryCache
+	nilCache.put(key, "x")
+	if _, ok := nilCache.get(key); ok {
+		t.Error("nil cache should always miss")
+	}
+}
+
+func TestIsSummaryRefreshQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"重新总结昨天", true},
+		{"重新生成一下上周的总结", true},
+		{"刷新昨天的总结", true},
+		{"总结一下昨天，不要缓存", true},
+		{"总结昨天的讨论", false},
+		{"总结一下重构的讨论", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			if got := isSummaryRefreshQuery(tt.query); got != tt.want {
+				t.Errorf("isSummaryRefreshQuery(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsClosedTimeRange(t *testing.T) {
+	tests := []struct {
+		tr   llm.TimeRange
+		want bool
+	}{
+		{llm.TimeRangeYesterday, true},
+		{llm.TimeRangeLastWeek, true},
+		{llm.TimeRangeLastMonth, true},
+		{llm.TimeRangeToday, false},
+		{llm.TimeRangeThisWeek, false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := isClosedTimeRange(tt.tr); got != tt.want {
+			t.Errorf("isClosedTimeRange(%q) = %v, want %v", tt.tr, got, tt.want)
+		}
+	}
+}
+
+// unavailableDriver 任何查询都失败的数据库驱动，用于确认是否访问了数据库
+type unavailableDriver struct{}
+
+func (unavailableDriver) Open(string) (driver.Conn, error) {
+	return nil, errors.New("db unavailable")
+}
+
+func init() {
+	sql.Register("summary_cache_unavailable", unavailableDriver{})
+}
+
+func TestHandleSummarizeCache(t *testing.T) {
+	db, err := sql.Open("summary_cache_unavailable", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	hp := &HybridProcessor{
+		svcCtx:    &svc.ServiceContext{MessageModel: model.NewChatMessageModel(db)},
+		summaries: newSummaryCache(summaryCacheTTL),
+	}
+	chatID := "oc_summary_cache"
+	start, end := hp.getTimeRange(llm.TimeRangeYesterday)
+	hp.summaries.put(summaryCacheKey(chatID, start, end), "1. 昨天修复了登录超时")
+
+	ctx := context.Background()
+	t.Run("命中缓存不查询数据库", func(t *testing.T) {
+		parsed := &llm.ParsedQuery{Intent: llm.IntentSummarize, TimeRange: llm.TimeRangeYesterday, RawQuery: "总结昨天"}
+		got, err := hp.handleSummarize(ctx, parsed, chatID)
+		if err != nil {
+			t.Fatalf("handleSummarize() error: %v", err)
+		}
+		if !strings.Contains(got, "1. 昨天修复了登录超时") {
+			t.Errorf("Expected cached summary, got %q", got)
+		}
+	})
+
+	t.Run("重新总结跳过缓存", func(t *testing.T) {
+		parsed := &llm.ParsedQuery{Intent: llm.IntentSummarize, TimeRange: llm.TimeRangeYesterday, RawQuery: "重新总结昨天"}
+		got, err := hp.handleSummarize(ctx, parsed, chatID)
+		if err == nil || got != "获取消息失败，请稍后重试。" {
+			t.Errorf("Forced refresh should query messages again, got %q, %v", got, err)
+		}
+	})
+
+	t.Run("未结束的时间段不使用缓存", func(t *testing.T) {
+		start, end := hp.getTimeRange(llm.TimeRangeToday)
+		hp.summaries.put(summaryCacheKey(chatID, start, end), "今天的旧总结")
+		parsed := &llm.ParsedQuery{Intent: llm.IntentSummarize, TimeRange: llm.TimeRangeToday, RawQuery: "总结今天"}
+		if got, err := hp.handleSummarize(ctx, parsed, chatID); err == nil || strings.Contains(got, "今天的旧总结") {
+			t.Errorf("Open time range should not use cache, got %q, %v", got, err)
+		}
+	})
+}
@@ -0,0 +1,42 @@
+package ai
+
+import (
+	"fmt"
+
+	"team-assistant/internal/model"
+)
+
+// 总结时转发消息的处理方式
+const (
+	ForwardModeLabel   = "label"   // 标注为转发内容（默认）
+	ForwardModeExclude = "exclude" // 不参与总结
+	ForwardModeKeep    = "keep"    // 与本群消息同等对待
+)
+
+// forwardedTag 转发消息在总结输入中的标记，与 SummarizeMessages 提示词保持一致
+const forwardedTag = "【转发】"
+
+// formatSummaryLine 将消息格式化为总结输入行
+// 转发消息按 mode 标注或排除，避免 LLM 把转发内容当作本群讨论/决策；返回 false 表示跳过该消息
+func formatSummaryLine(msg *model.ChatMessage, timeLayout, mode string) (string, bool) {
+	senderName := ""
+	if msg.SenderName.Valid {
+		senderName = msg.SenderName.String
+	}
+	content := ""
+	if msg.Content.Valid {
+		content = msg.Content.String
+	}
+
+	if msg.IsForwarded == 1 {
+		switch mode {
+		case ForwardModeExclude:
+			return "", false
+		case ForwardModeKeep:
+		default:
+			content = forwardedTag + content
+		}
+	}
+
+	return fmt.Sprintf("[%s] %s: %s", msg.CreatedAt.Format(timeLayout), senderName, content), true
+}
@@ -0,0 +1,285 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"team-assistant/internal/model"
+	"team-assistant/pkg/llm"
+)
+
+// ======================== 排行类问题（"告警最多的三个站点"、"发言最多的五个人"） ========================
+
+// 排行维度
+const (
+	rankingBySite    = "site"    // 站点，按告警数排行
+	rankingBySender  = "sender"  // 成员，按发言数排行
+	rankingByKeyword = "keyword" // 关键词，按提到的消息数排行
+)
+
+const (
+	// defaultTopRankingN 没有说个数时（"告警最多的几个站点"）展示的条数
+	defaultTopRankingN = 5
+	// maxTopRankingN 最多展示的条数
+	maxTopRankingN = 20
+)
+
+// topRankingQuery 解析后的排行问题
+type topRankingQuery struct {
+	n           int
+	dimension   string
+	keywords    []string      // 关键词排行的候选词
+	targetGroup string        // 限定的群名（LLM 解析结果）
+	timeRange   llm.TimeRange // 为空表示最近 7 天
+	label       string        // 时间范围描述，如 "本周"
+}
+
+// topNPattern 查询中的个数："前三"、"top 5"、"三个"、"5位"；第三组捕获 "三个月" 这类时间说法，不作为个数
+var topNPattern = regexp.MustCompile(`(?i)(?:前|top\s*)(\d+|[一二两三四五六七八九十]+)|(\d+|[一二两三四五六七八九十]+)\s*(?:个|位|名)(月|星期|小时)?`)
+
+// rankingWords 表示按数量排名的说法，没有这些词时 "三个站点" 只是普通提问
+var rankingWords = []string{"最多", "最活跃", "最频繁", "排行", "排名", "前", "top", "TOP", "Top"}
+
+// chineseDigits 中文数字
+var chineseDigits = map[rune]int{'一': 1, '二': 2, '两': 2, '三': 3, '四': 4, '五': 5, '六': 6, '七': 7, '八': 8, '九': 9}
+
+// parseCount 解析阿拉伯数字或二十以内的中文数字（三、十、十五、二十），无法解析返回 0
+func parseCount(s string) int {
+	if n, err := strconv.Atoi(s); err == nil {
+		return n
+	}
+	runes := []rune(s)
+	switch {
+	case len(runes) == 1 && runes[0] == '十':
+		return 10
+	case len(runes) == 1:
+		return chineseDigits[runes[0]]
+	case len(runes) == 2 && runes[0] == '十':
+		return 10 + chineseDigits[runes[1]]
+	case len(runes) == 2 && runes[1] == '十':
+		return chineseDigits[runes[0]] * 10
+	case len(runes) == 3 && runes[1] == '十':
+		return chineseDigits[runes[0]]*10 + chineseDigits[runes[2]]
+	}
+	return 0
+}
+
+// parseTopN 提取查询中的个数，限制在 1~maxTopRankingN
+func parseTopN(query string) (int, bool) {
+	for _, m := range topNPattern.FindAllStringSubmatch(query, -1) {
+		if m[3] != "" {
+			continue
+		}
+		raw := m[1]
+		if raw == "" {
+			raw = m[2]
+		}
+		n := parseCount(raw)
+		if n <= 0 {
+			continue
+		}
+		if n > maxTopRankingN {
+			n = maxTopRankingN
+		}
+		return n, true
+	}
+	return 0, false
+}
+
+// detectRankingDimension 根据查询中的词判断排行维度，无法判断返回空
+// 提交/代码相关的排行交给工作量查询
+func detectRankingDimension(query string) string {
+	switch {
+	case containsAny(query, []string{"提交", "commit", "代码"}):
+		return ""
+	case strings.Contains(query, "站") && containsAny(query, []string{"告警", "报警"}):
+		return rankingBySite
+	case containsAny(query, []string{"人", "成员", "同事", "发言", "说话"}):
+		return rankingBySender
+	}
+	return ""
+}
+
+// parseTopRankingQuery 识别明确给出个数的排行问题（"告警最多的三个站点"、"本周发言最多的5个人"）
+// 关键词排行需要候选词，由 LLM 解析（top_ranking 意图）处理
+func parseTopRankingQuery(query string) (topRankingQuery, bool) {
+	n, ok := parseTopN(query)
+	if !ok || !containsAny(query, rankingWords) {
+		return topRankingQuery{}, false
+	}
+	dimension := detectRankingDimension(query)
+	if dimension == "" {
+		return topRankingQuery{}, false
+	}
+
+	q := topRankingQuery{n: n, dimension: dimension}
+	q.timeRange, q.label = parseStatsTimeRange(query)
+	return q, true
+}
+
+// topRankingFromParsed 从 LLM 解析结果构建排行问题，params 缺失时从原始问题中识别
+func topRankingFromParsed(parsed *llm.ParsedQuery) topRankingQuery {
+	q := topRankingQuery{
+		n:           defaultTopRankingN,
+		dimension:   strings.ToLower(strings.TrimSpace(parsed.Params["dimension"])),
+		keywords:    parsed.Keywords,
+		targetGroup: parsed.TargetGroup,
+		timeRange:   parsed.TimeRange,
+		label:       statsTimeRangeLabel(parsed.TimeRange),
+	}
+	if n := parseCount(strings.TrimSpace(parsed.Params["top_n"])); n > 0 {
+		q.n = n
+	} else if n, ok := parseTopN(parsed.RawQuery); ok {
+		q.n = n
+	}
+	if q.n > maxTopRankingN {
+		q.n = maxTopRankingN
+	}
+	if q.dimension != rankingBySite && q.dimension != rankingBySender && q.dimension != rankingByKeyword {
+		q.dimension = detectRankingDimension(parsed.RawQuery)
+	}
+	return q
+}
+
+// statsTimeRangeLabel 统计类查询的时间范围描述，不在 statsTimeRanges 中的范围返回空（查询时按日期展示）
+func statsTimeRangeLabel(tr llm.TimeRange) string {
+	if tr == "" {
+		return "最近 7 天"
+	}
+	for _, r := range statsTimeRanges {
+		if r.tr == tr {
+			return r.label
+		}
+	}
+	return ""
+}
+
+// tryTopRanking 尝试直接用聚合查询回答排行问题，无法识别或查询失败时返回 false 交给常规流程
+// 未启用 top_ranking 意图时不走捷径
+func (hp *HybridProcessor) tryTopRanking(ctx context.Context, chatID, query string) (string, bool) {
+	if hp.svcCtx == nil || !hp.intentEnabled(llm.IntentTopRanking) {
+		return "", false
+	}
+	q, ok := parseTopRankingQuery(query)
+	if !ok {
+		return "", false
+	}
+	answer, err := hp.answerTopRanking(ctx, chatID, q)
+	if err != nil {
+		log.Printf("Failed to answer top ranking query: %v, falling back to normal processing", err)
+		return "", false
+	}
+	return answer, true
+}
+
+// handleTopRanking 处理 LLM 识别的排行问题，维度无法识别时按问答处理
+func (hp *HybridProcessor) handleTopRanking(ctx context.Context, parsed *llm.ParsedQuery, currentChatID string) (string, error) {
+	q := topRankingFromParsed(parsed)
+	if q.dimension == "" {
+		return hp.handleQA(ctx, parsed, currentChatID)
+	}
+	return hp.answerTopRanking(ctx, currentChatID, q)
+}
+
+// answerTopRanking 按维度执行聚合查询并返回排行
+func (hp *HybridProcessor) answerTopRanking(ctx context.Context, currentChatID string, q topRankingQuery) (string, error) {
+	start, end := hp.statsRange(q.timeRange, time.Now())
+	if q.label == "" {
+		q.label = fmt.Sprintf("%s ~ %s ", start.Format("2006-01-02"), end.Format("2006-01-02"))
+	}
+
+	switch q.dimension {
+	case rankingBySite:
+		if hp.svcCtx.AlertModel == nil {
+			return "", fmt.Errorf("alert model not configured")
+		}
+		counts, err := hp.svcCtx.AlertModel.CountByTypeAndSite(ctx, start, end)
+		if err != nil {
+			return "", fmt.Errorf("count alerts: %w", err)
+		}
+		return formatAlertRankingTop(counts, q.label, q.n), nil
+
+	case rankingBySender:
+		chatID := hp.getSearchChatID(currentChatID, q.targetGroup, ctx)
+		senders, err := hp.svcCtx.MessageModel.RankSenders(ctx, chatID, start, end, q.n)
+		if err != nil {
+			return "", fmt.Errorf("rank senders: %w", err)
+		}
+		return formatSenderRanking(senders, q.label), nil
+
+	case rankingByKeyword:
+		if len(q.keywords) == 0 {
+			return "请告诉我要比较哪些关键词，例如：支付、登录、提现哪两个讨论最多？", nil
+		}
+		chatID := hp.getSearchChatID(currentChatID, q.targetGroup, ctx)
+		hits, err := hp.svcCtx.MessageModel.CountKeywordHitsByChat(ctx, q.keywords, start)
+		if err != nil {
+			return "", fmt.Errorf("count keyword hits: %w", err)
+		}
+		return formatKeywordRanking(rankKeywords(q.keywords, hits, chatID), q.label, q.n), nil
+	}
+	return "", fmt.Errorf("unknown ranking dimension %q", q.dimension)
+}
+
+// formatSenderRanking 格式化发言排行
+func formatSenderRanking(senders []*model.SenderActivity, label string) string {
+	if len(senders) == 0 {
+		return fmt.Sprintf("📭 %s没有发言记录", label)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📊 %s发言最多的 %d 位成员\n", label, len(senders)))
+	for i, s := range senders {
+		sb.WriteString(fmt.Sprintf("\n%d. 👤%s — %d 条，最近发言 %s", i+1, s.Name, s.MessageCount, s.LastActiveAt.Format("01-02 15:04")))
+	}
+	return sb.String()
+}
+
+// keywordCount 关键词及提到它的消息数
+type keywordCount struct {
+	keyword string
+	count   int
+}
+
+// rankKeywords 汇总各群的关键词命中数，chatID 不为空时只统计该群；按命中数降序（相同时保持提问中的顺序）
+func rankKeywords(keywords []string, hits map[string][]int, chatID string) []keywordCount {
+	counts := make([]keywordCount, len(keywords))
+	for i, kw := range keywords {
+		counts[i].keyword = kw
+	}
+	for chat, perKeyword := range hits {
+		if chatID != "" && chat != chatID {
+			continue
+		}
+		for i, c := range perKeyword {
+			if i < len(counts) {
+				counts[i].count += c
+			}
+		}
+	}
+	sort.SliceStable(counts, func(i, j int) bool { return counts[i].count > counts[j].count })
+	return counts
+}
+
+// formatKeywordRanking 格式化关键词排行，没被提到的关键词不展示
+func formatKeywordRanking(counts []keywordCount, label string, n int) string {
+	var sb strings.Builder
+	shown := 0
+	for _, c := range counts {
+		if shown >= n || c.count == 0 {
+			break
+		}
+		shown++
+		sb.WriteString(fmt.Sprintf("\n%d. 🔑%s — %d 条消息", shown, c.keyword, c.count))
+	}
+	if shown == 0 {
+		return fmt.Sprintf("📭 %s没有消息提到这些关键词", label)
+	}
+	return fmt.Sprintf("📊 %s提到最多的 %d 个关键词\n", label, shown) + sb.String()
+}
@@ -0,0 +1,169 @@
+package ai
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"team-assistant/internal/model"
+	"team-assistant/pkg/llm"
+)
+
+func TestParseTopRankingQuery(t *testing.T) {
+	tests := []struct {
+		name          string
+		query         string
+		wantOK        bool
+		wantN         int
+		wantDimension string
+		wantRange     llm.TimeRange
+	}{
+		{"告警最多的三个站点", "告警最多的三个站点", true, 3, rankingBySite, ""},
+		{"本周告警前五的站", "本周告警前五的站是哪些", true, 5, rankingBySite, llm.TimeRangeThisWeek},
+		{"发言最多的五个人", "发言最多的五个人", true, 5, rankingBySender, ""},
+		{"阿拉伯数字", "上周群里最活跃的10位成员", true, 10, rankingBySender, llm.TimeRangeLastWeek},
+		{"top写法", "说话 top3 的同事", true, 3, rankingBySender, ""},
+		{"十几个", "发言排名前十五的人", true, 15, rankingBySender, ""},
+		{"超过上限", "发言最多的100个人", true, maxTopRankingN, rankingBySender, ""},
+		{"三个月不是个数", "三个月前谁发言最多", false, 0, "", ""},
+		{"没有排名说法", "这三个人负责什么", false, 0, "", ""},
+		{"没说个数交给常规流程", "哪个站点告警最多", false, 0, "", ""},
+		{"提交排行交给工作量", "提交代码最多的三个人", false, 0, "", ""},
+		{"无法识别维度", "最多的三个需求", false, 0, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, ok := parseTopRankingQuery(tt.query)
+			if ok != tt.wantOK {
+				t.Fatalf("parseTopRankingQuery(%q) ok = %v, want %v", tt.query, ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if q.n != tt.wantN || q.dimension != tt.wantDimension || q.timeRange != tt.wantRange {
+				t.Errorf("parseTopRankingQuery(%q) = %+v, want n=%d dimension=%s range=%q",
+					tt.query, q, tt.wantN, tt.wantDimension, tt.wantRange)
+			}
+		})
+	}
+}
+
+func TestParseCount(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"3", 3}, {"三", 3}, {"两", 2}, {"十", 10}, {"十二", 12}, {"二十", 20}, {"二十五", 25}, {"百", 0},
+	}
+	for _, tt := range tests {
+		if got := parseCount(tt.in); got != tt.want {
+			t.Errorf("parseCount(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTopRankingFromParsed(t *testing.T) {
+	tests := []struct {
+		name          string
+		parsed        *llm.ParsedQuery
+		wantN         int
+		wantDimension string
+		wantLabel     string
+	}{
+		{"使用 params", &llm.ParsedQuery{
+			Params: map[string]string{"top_n": "3", "dimension": "Site"}, TimeRange: llm.TimeRangeThisWeek,
+		}, 3, rankingBySite, "本周"},
+		{"params 缺失时从原问题识别", &llm.ParsedQuery{
+			RawQuery: "谁是群里发言最多的前两名",
+		}, 2, rankingBySender, "最近 7 天"},
+		{"没说个数使用默认值", &llm.ParsedQuery{
+			Params: map[string]string{"dimension": "keyword"}, Keywords: []string{"支付", "登录"},
+		}, defaultTopRankingN, rankingByKeyword, "最近 7 天"},
+		{"其他时间范围按日期展示", &llm.ParsedQuery{
+			Params: map[string]string{"top_n": "5", "dimension": "sender"}, TimeRange: llm.TimeRangeThisYear,
+		}, 5, rankingBySender, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := topRankingFromParsed(tt.parsed)
+			if q.n != tt.wantN || q.dimension != tt.wantDimension || q.label != tt.wantLabel {
+				t.Errorf("topRankingFromParsed() = %+v, want n=%d dimension=%s label=%q", q, tt.wantN, tt.wantDimension, tt.wantLabel)
+			}
+		})
+	}
+}
+
+func TestFormatAlertRankingTop(t *testing.T) {
+	counts := []*model.AlertCount{
+		{Site: "by4", AlertType: "慢请求", Count: 5},
+		{Site: "l08", AlertType: "支付失败", Count: 4},
+		{Site: "p03", AlertType: "支付失败", Count: 2},
+	}
+	result := formatAlertRankingTop(counts, "本周", 2)
+	if !strings.Contains(result, "2. 📍l08") || strings.Contains(result, "3. 📍p03") || !strings.Contains(result, "其余 1 个站点未展示") {
+		t.Errorf("formatAlertRankingTop() = %q", result)
+	}
+}
+
+func TestFormatSenderRanking(t *testing.T) {
+	last := time.Date(2024, 3, 8, 18, 30, 0, 0, time.Local)
+	senders := []*model.SenderActivity{
+		{Name: "张三", MessageCount: 42, LastActiveAt: last},
+		{Name: "李四", MessageCount: 17, LastActiveAt: last},
+	}
+	result := formatSenderRanking(senders, "本周")
+	for _, want := range []string{
+		"本周发言最多的 2 位成员",
+		"1. 👤张三 — 42 条，最近发言 03-08 18:30",
+		"2. 👤李四 — 17 条",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("formatSenderRanking() missing %q:\n%s", want, result)
+		}
+	}
+	if got := formatSenderRanking(nil, "今天"); got != "📭 今天没有发言记录" {
+		t.Errorf("formatSenderRanking(nil) = %q", got)
+	}
+}
+
+func TestRankKeywords(t *testing.T) {
+	keywords := []string{"支付", "登录", "提现"}
+	hits := map[string][]int{
+		"oc_a": {3, 5, 0},
+		"oc_b": {4, 0, 0},
+	}
+
+	tests := []struct {
+		name   string
+		chatID string
+		n      int
+		want   []string
+		empty  bool
+	}{
+		{"所有群汇总", "", 2, []string{"1. 🔑支付 — 7 条消息", "2. 🔑登录 — 5 条消息"}, false},
+		{"限定当前群", "oc_a", 3, []string{"提到最多的 2 个关键词", "1. 🔑登录 — 5 条消息", "2. 🔑支付 — 3 条消息"}, false},
+		{"没有命中", "oc_c", 3, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := formatKeywordRanking(rankKeywords(keywords, hits, tt.chatID), "本周", tt.n)
+			if tt.empty {
+				if result != "📭 本周没有消息提到这些关键词" {
+					t.Errorf("formatKeywordRanking() = %q", result)
+				}
+				return
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(result, want) {
+					t.Errorf("formatKeywordRanking() missing %q:\n%s", want, result)
+				}
+			}
+			if strings.Contains(result, "提现") {
+				t.Errorf("Expected keyword without hits omitted:\n%s", result)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package ai
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"team-assistant/internal/model"
+)
+
+// ======================== 周总结缓存 ========================
+
+// weeklySummaryStore 周总结持久化（默认使用 svcCtx.WeeklySummaryModel）
+type weeklySummaryStore interface {
+	ListSince(ctx context.Context, chatID string, since time.Time) ([]*model.WeeklySummaryRecord, error)
+	Upsert(ctx context.Context, s *model.WeeklySummaryRecord) error
+}
+
+// loadCachedWeeks 加载已结束的周总结，key 为周开始时间的 Unix 秒
+// 未结束的周每次重新生成，不从缓存读取
+func (hp *HybridProcessor) loadCachedWeeks(ctx context.Context, chatID string, since time.Time) map[int64]WeeklySummary {
+	if hp.weeklyStore == nil {
+		return nil
+	}
+	records, err := hp.weeklyStore.ListSince(ctx, chatID, since)
+	if err != nil {
+		log.Printf("Failed to load cached weekly summaries for %s: %v", chatID, err)
+		return nil
+	}
+
+	cached := make(map[int64]WeeklySummary, len(records))
+	for _, r := range records {
+		if !r.Complete {
+			continue
+		}
+		cached[r.WeekStart.Unix()] = WeeklySummary{
+			WeekStart:    r.WeekStart,
+			WeekEnd:      r.WeekEnd,
+			Summary:      r.Summary,
+			MainTopics:   r.MainTopics,
+			Decisions:    r.Decisions,
+			Milestones:   r.Milestones,
+			Participants: r.Participants,
+			MessageCount: r.MessageCount,
+		}
+	}
+	return cached
+}
+
+// saveWeeklySummary 保存 LLM 生成的周总结，complete 表示该周已结束
+func (hp *HybridProcessor) saveWeeklySummary(ctx context.Context, chatID string, ws *WeeklySummary, complete bool) {
+	if hp.weeklyStore == nil {
+		return
+	}
+	err := hp.weeklyStore.Upsert(ctx, &model.WeeklySummaryRecord{
+		ChatID:       chatID,
+		WeekStart:    ws.WeekStart,
+		WeekEnd:      ws.WeekEnd,
+		Summary:      ws.Summary,
+		MainTopics:   ws.MainTopics,
+		Decisions:    ws.Decisions,
+		Milestones:   ws.Milestones,
+		Participants: ws.Participants,
+		MessageCount: ws.MessageCount,
+		Complete:     complete,
+	})
+	if err != nil {
+		log.Printf("Failed to save weekly summary %s for %s: %v", ws.WeekStart.Format("2006-01-02"), chatID, err)
+	}
+}
@@ -0,0 +1,29 @@
+package ai
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"team-assistant/internal/model"
+)
+
+// ======================== 周总结重试 ========================
+
+const (
+	weeklySummaryMaxMessages   = 100 // 单周总结采样的消息数
+	weeklySummaryRetryMessages = 50  // 重试时采样的消息数，减少 Token 超限的可能
+)
+
+// summarizeWeekWithRetry 生成单周总结，LLM 调用失败时减少采样消息数重试一次
+// 重试仍失败时返回错误，由调用方退回只记录消息数
+func (hp *HybridProcessor) summarizeWeekWithRetry(ctx context.Context, messages []*model.ChatMessage, weekStart, weekEnd time.Time) (*WeeklySummary, error) {
+	ws, err := hp.summarizeWeekMessages(ctx, messages, weekStart, weekEnd, weeklySummaryMaxMessages)
+	if err == nil || ctx.Err() != nil {
+		return ws, err
+	}
+
+	log.Printf("Failed to summarize week %s: %v, retrying with %d sampled messages",
+		weekStart.Format("2006-01-02"), err, weeklySummaryRetryMessages)
+	return hp.summarizeWeekMessages(ctx, messages, weekStart, weekEnd, weeklySummaryRetryMessages)
+}
@@ -0,0 +1,110 @@
+package ai
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"team-assistant/internal/backoff"
+	"team-assistant/internal/model"
+	"team-assistant/pkg/llm"
+)
+
+// newWeeklySummaryServer 模拟 LLM：前 failures 次请求返回 400，并记录每次 prompt 中的消息条数
+func newWeeklySummaryServer(t *testing.T, failures int) (*HybridProcessor, *[]int) {
+	t.Helper()
+	var sampled []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		prompt := ""
+		for _, m := range req.Messages {
+			prompt += m.Content
+		}
+		sampled = append(sampled, strings.Count(prompt, "] 张三: "))
+
+		if len(sampled) <= failures {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": {"message": "context length exceeded"}}`))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{
+				{"message": map[string]string{"role": "assistant", "content": `{"summary": "完成支付改造", "decisions": ["周五发版"]}`}},
+			},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	client := llm.NewClient("test-key", server.URL, "test-model")
+	client.SetRetryBackoff(backoff.New(time.Millisecond, time.Millisecond))
+	return &HybridProcessor{llmClient: client}, &sampled
+}
+
+func weekMessages(n int, start time.Time) []*model.ChatMessage {
+	messages := make([]*model.ChatMessage, n)
+	for i := range messages {
+		messages[i] = &model.ChatMessage{
+			SenderName: sql.NullString{String: "张三", Valid: true},
+			Content:    sql.NullString{String: fmt.Sprintf("进度 %d", i), Valid: true},
+			CreatedAt:  start.Add(time.Duration(i) * time.Minute),
+		}
+	}
+	return messages
+}
+
+func TestSummarizeWeekWithRetry(t *testing.T) {
+	weekStart := time.Date(2024, 3, 4, 0, 0, 0, 0, time.Local)
+	weekEnd := weekStart.AddDate(0, 0, 7)
+
+	tests := []struct {
+		name        string
+		failures    int
+		wantErr     bool
+		wantSampled []int
+	}{
+		{"首次成功不重试", 0, false, []int{100}},
+		{"失败后减少采样重试成功", 1, false, []int{100, 50}},
+		{"重试仍失败返回错误", 2, true, []int{100, 50}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hp, sampled := newWeeklySummaryServer(t, tt.failures)
+			ws, err := hp.summarizeWeekWithRetry(context.Background(), weekMessages(200, weekStart), weekStart, weekEnd)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("summarizeWeekWithRetry() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (ws == nil || ws.Summary != "完成支付改造") {
+				t.Errorf("Unexpected summary: %+v", ws)
+			}
+			if fmt.Sprint(*sampled) != fmt.Sprint(tt.wantSampled) {
+				t.Errorf("sampled messages per attempt = %v, want %v", *sampled, tt.wantSampled)
+			}
+		})
+	}
+}
+
+func TestSummarizeWeekWithRetrySkipsCanceled(t *testing.T) {
+	hp, sampled := newWeeklySummaryServer(t, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	weekStart := time.Date(2024, 3, 4, 0, 0, 0, 0, time.Local)
+	if _, err := hp.summarizeWeekWithRetry(ctx, weekMessages(10, weekStart), weekStart, weekStart.AddDate(0, 0, 7)); err == nil {
+		t.Fatal("Expected error for canceled context")
+	}
+	if len(*sampled) != 0 {
+		t.Errorf("Canceled context should not retry, got %d requests", len(*sampled))
+	}
+}
@@ -0,0 +1,111 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"team-assistant/internal/model"
+)
+
+// ======================== 工作量查询：提交内容 ========================
+
+const (
+	// workloadCommitsPerMember 每人附带的最近提交数
+	workloadCommitsPerMember = 20
+	// workloadMaxMembers 查询全员时最多附带提交内容的人数（按提交数排序）
+	workloadMaxMembers = 5
+)
+
+// workloadSummaryInstruction 工作量回答的额外要求
+const workloadSummaryInstruction = `
+
+请先给出提交统计数字，再根据 recent_commits 中的提交信息概括每个人主要做了什么（按功能/模块归纳，不要逐条罗列提交）。没有提交信息的成员只给出数字。`
+
+// workloadCommit 提供给 LLM 的提交摘要
+type workloadCommit struct {
+	Repo    string `json:"repo"`
+	Message string `json:"message"`
+	Files   string `json:"files,omitempty"`
+	Date    string `json:"date"`
+}
+
+// memberWorkload 成员工作量（统计 + 最近提交）
+type memberWorkload struct {
+	Stats         *model.CommitStats `json:"stats"`
+	RecentCommits []workloadCommit   `json:"recent_commits,omitempty"`
+}
+
+// attachRecentCommits 为统计结果附上时间范围内最近的提交信息
+func (hp *HybridProcessor) attachRecentCommits(ctx context.Context, stats []*model.CommitStats, start, end time.Time) []*memberWorkload {
+	workloads := make([]*memberWorkload, 0, len(stats))
+	for i, s := range stats {
+		w := &memberWorkload{Stats: s}
+		workloads = append(workloads, w)
+		if i >= workloadMaxMembers {
+			continue
+		}
+
+		var commits []*model.GitCommit
+		var err error
+		if s.MemberID > 0 {
+			commits, err = hp.svcCtx.CommitModel.GetRecentCommits(ctx, s.MemberID, start, end, workloadCommitsPerMember)
+		} else {
+			commits, err = hp.svcCtx.CommitModel.GetCommitsByDateRange(ctx, s.AuthorName, start, end, workloadCommitsPerMember)
+		}
+		if err != nil {
+			log.Printf("Failed to get recent commits for %s: %v", s.AuthorName, err)
+			continue
+		}
+		w.RecentCommits = toWorkloadCommits(commits)
+	}
+	return workloads
+}
+
+// toWorkloadCommits 转换提交记录，跳过没有提交信息的和合并提交
+func toWorkloadCommits(commits []*model.GitCommit) []workloadCommit {
+	var result []workloadCommit
+	for _, c := range commits {
+		if !c.CommitMessage.Valid {
+			continue
+		}
+		msg := strings.TrimSpace(c.CommitMessage.String)
+		if msg == "" || strings.HasPrefix(msg, "Merge ") {
+			continue
+		}
+		if idx := strings.Index(msg, "\n"); idx != -1 {
+			msg = msg[:idx]
+		}
+
+		wc := workloadCommit{
+			Repo:    c.RepoName,
+			Message: msg,
+			Date:    c.CommittedAt.Format("01-02"),
+		}
+		if c.FileList.Valid {
+			wc.Files = c.FileList.String
+		}
+		result = append(result, wc)
+	}
+	return result
+}
+
+// formatRecentCommits 格式化最近提交（LLM 不可用时的降级展示）
+func formatRecentCommits(commits []workloadCommit, limit int) string {
+	if len(commits) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("   最近提交:\n")
+	for i, c := range commits {
+		if i >= limit {
+			sb.WriteString(fmt.Sprintf("   ...还有 %d 条\n", len(commits)-limit))
+			break
+		}
+		sb.WriteString(fmt.Sprintf("   • [%s] %s (%s)\n", c.Repo, c.Message, c.Date))
+	}
+	return sb.String()
+}
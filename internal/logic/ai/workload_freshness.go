@@ -0,0 +1,45 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"team-assistant/internal/model"
+)
+
+// ======================== 工作量数据新鲜度 ========================
+
+// workloadStaleAfter GitHub 采集超过这个时间没有成功时提示统计可能不完整
+const workloadStaleAfter = 48 * time.Hour
+
+// workloadFreshnessNote 工作量回答末尾的 "数据截至" 提示，没有采集记录时返回空
+func (hp *HybridProcessor) workloadFreshnessNote(ctx context.Context) string {
+	if hp.svcCtx == nil || hp.svcCtx.GitHubCollectStateModel == nil {
+		return ""
+	}
+	states, err := hp.svcCtx.GitHubCollectStateModel.ListAll(ctx)
+	if err != nil {
+		log.Printf("Failed to load GitHub collect state: %v", err)
+		return ""
+	}
+	return formatDataFreshness(model.LatestGitHubCollect(states), time.Now())
+}
+
+// formatDataFreshness 格式化数据截至时间，采集过期时附带提示
+func formatDataFreshness(last, now time.Time) string {
+	if last.IsZero() {
+		return ""
+	}
+
+	layout := "2006-01-02 15:04"
+	if last.Year() == now.Year() {
+		layout = "01-02 15:04"
+	}
+	note := "\n\n🕒 数据截至 " + last.Format(layout)
+	if age := now.Sub(last); age > workloadStaleAfter {
+		note += fmt.Sprintf("（GitHub 数据已 %d 天未更新，统计可能不完整）", int(age.Hours()/24))
+	}
+	return note
+}
@@ -0,0 +1,30 @@
+package ai
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatDataFreshness(t *testing.T) {
+	now := time.Date(2024, 3, 8, 18, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name string
+		last time.Time
+		want string
+	}{
+		{"没有采集记录", time.Time{}, ""},
+		{"一小时前", now.Add(-time.Hour), "\n\n🕒 数据截至 03-08 17:00"},
+		{"刚好两天", now.Add(-workloadStaleAfter), "\n\n🕒 数据截至 03-06 18:00"},
+		{"超过两天提示过期", now.AddDate(0, 0, -5), "\n\n🕒 数据截至 03-03 18:00（GitHub 数据已 5 天未更新，统计可能不完整）"},
+		{"跨年显示年份", time.Date(2023, 12, 31, 23, 0, 0, 0, time.Local), "\n\n🕒 数据截至 2023-12-31 23:00（GitHub 数据已 67 天未更新，统计可能不完整）"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatDataFreshness(tt.last, now); got != tt.want {
+				t.Errorf("formatDataFreshness() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
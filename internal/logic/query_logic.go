@@ -248,6 +248,9 @@ func (l *QueryLogic) getTimeRange(tr llm.TimeRange) (time.Time, time.Time) {
 		thisMonthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
 		lastMonthStart := thisMonthStart.AddDate(0, -1, 0)
 		return lastMonthStart, thisMonthStart
+	case llm.TimeRangeThisQuarter, llm.TimeRangeLastQuarter, llm.TimeRangeThisYear, llm.TimeRangeLastYear:
+		start, end, _ := llm.CoarseTimeRange(tr, now)
+		return start, end
 	default:
 		// 默认查询最近3年的消息
 		return today.AddDate(-3, 0, 0), now
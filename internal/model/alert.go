@@ -0,0 +1,196 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Alert 从告警消息中解析出的结构化告警
+type Alert struct {
+	ID        int64     `db:"id"`
+	MessageID string    `db:"message_id"`
+	ChatID    string    `db:"chat_id"`
+	Site      string    `db:"site"`       // 站点前缀或站点ID，解析不到时为空
+	AlertType string    `db:"alert_type"` // 告警类型，如 慢请求、支付失败
+	AlertTime time.Time `db:"alert_time"` // 告警消息发送时间
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// AlertCount 按站点和类型统计的告警数
+type AlertCount struct {
+	Site      string
+	AlertType string
+	Count     int
+}
+
+// AlertDayCount 按天统计的告警数
+type AlertDayCount struct {
+	Day   string // 2006-01-02
+	Count int
+}
+
+type AlertModel struct {
+	db *sql.DB
+}
+
+func NewAlertModel(db *sql.DB) *AlertModel {
+	return &AlertModel{db: db}
+}
+
+// Insert 插入告警（同一条消息只记录一次）
+func (m *AlertModel) Insert(ctx context.Context, alert *Alert) error {
+	query := `INSERT IGNORE INTO chat_alerts (message_id, chat_id, site, alert_type, alert_time)
+              VALUES (?, ?, ?, ?, ?)`
+	_, err := m.db.ExecContext(ctx, query, alert.MessageID, alert.ChatID, alert.Site, alert.AlertType, alert.AlertTime)
+	return err
+}
+
+// RecordMessage 解析消息，是告警则写入告警表，返回是否识别为告警
+func (m *AlertModel) RecordMessage(ctx context.Context, msg *ChatMessage) (bool, error) {
+	alert, ok := NewAlertFromMessage(msg)
+	if !ok {
+		return false, nil
+	}
+	return true, m.Insert(ctx, alert)
+}
+
+// CountByTypeAndSite 统计时间范围内各站点、各类型的告警数，按数量倒序
+func (m *AlertModel) CountByTypeAndSite(ctx context.Context, start, end time.Time) ([]*AlertCount, error) {
+	rows, err := m.db.QueryContext(ctx, alertCountQuery, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []*AlertCount
+	for rows.Next() {
+		var c AlertCount
+		if err := rows.Scan(&c.Site, &c.AlertType, &c.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, &c)
+	}
+	return counts, rows.Err()
+}
+
+// TrendByDay 统计站点在时间范围内每天的告警数，site 为空时统计所有站点
+func (m *AlertModel) TrendByDay(ctx context.Context, site string, start, end time.Time) ([]*AlertDayCount, error) {
+	query, args := alertTrendQuery(site, start, end)
+	rows, err := m.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var days []*AlertDayCount
+	for rows.Next() {
+		var d AlertDayCount
+		if err := rows.Scan(&d.Day, &d.Count); err != nil {
+			return nil, err
+		}
+		days = append(days, &d)
+	}
+	return days, rows.Err()
+}
+
+// alertCountQuery 按站点和类型统计告警数
+const alertCountQuery = `SELECT site, alert_type, COUNT(*) AS cnt FROM chat_alerts
+              WHERE alert_time >= ? AND alert_time < ?
+              GROUP BY site, alert_type ORDER BY cnt DESC, site`
+
+// alertTrendQuery 构建按天统计告警数的查询
+func alertTrendQuery(site string, start, end time.Time) (string, []interface{}) {
+	query := "SELECT DATE_FORMAT(alert_time, '%Y-%m-%d') AS day, COUNT(*) FROM chat_alerts" +
+		" WHERE alert_time >= ? AND alert_time < ?"
+	args := []interface{}{start, end}
+	if site != "" {
+		query += " AND site = ?"
+		args = append(args, strings.ToLower(site))
+	}
+	query += " GROUP BY day ORDER BY day"
+	return query, args
+}
+
+// ======================== 告警解析 ========================
+
+// alertTypes 已知告警类型及其关键词（按优先级排列，越具体越靠前）
+var alertTypes = []struct {
+	name     string
+	keywords []string
+}{
+	{"慢请求", []string{"慢请求"}},
+	{"代付失败", []string{"代付失败"}},
+	{"支付失败", []string{"支付失败"}},
+	{"余额不足", []string{"余额不足"}},
+	{"场馆操作失败", []string{"场馆操作失败"}},
+	{"场馆错误", []string{"场馆错误"}},
+}
+
+// otherAlertType 带告警标记但无法归类的告警
+const otherAlertType = "其他告警"
+
+var (
+	// labeledSitePattern 带标签的站点，如 "站点: by4"、"site=3031"
+	labeledSitePattern = regexp.MustCompile(`(?i)(?:站点|site)\s*(?:ID|前缀)?\s*[:：=]?\s*([a-z]{1,3}\d{1,3}|\d{3,5})\b`)
+	// bareSitePattern 未带标签的站点前缀，如 "by4"、"l08"
+	bareSitePattern = regexp.MustCompile(`(?i)\b[a-z]{1,3}\d{1,3}\b`)
+)
+
+// ParseAlert 解析告警消息，返回站点和告警类型；不是告警消息时 ok 为 false
+func ParseAlert(content string) (site, alertType string, ok bool) {
+	for _, t := range alertTypes {
+		for _, kw := range t.keywords {
+			if strings.Contains(content, kw) {
+				alertType = t.name
+				break
+			}
+		}
+		if alertType != "" {
+			break
+		}
+	}
+	if alertType == "" {
+		if !hasAlertMarker(content) {
+			return "", "", false
+		}
+		alertType = otherAlertType
+	}
+
+	if m := labeledSitePattern.FindStringSubmatch(content); m != nil {
+		site = m[1]
+	} else if m := bareSitePattern.FindString(content); m != "" {
+		site = m
+	}
+	return strings.ToLower(site), alertType, true
+}
+
+// hasAlertMarker 内容中是否带有告警标记
+func hasAlertMarker(content string) bool {
+	return strings.Contains(content, "告警") || strings.Contains(content, "报警") ||
+		strings.Contains(strings.ToLower(content), "alert")
+}
+
+// NewAlertFromMessage 从聊天消息构建告警，不是告警消息时返回 false
+// 只识别卡片消息和带告警标记的消息，避免把"用户反馈支付失败"之类的讨论当成告警
+func NewAlertFromMessage(msg *ChatMessage) (*Alert, bool) {
+	if msg == nil || !msg.Content.Valid || msg.Content.String == "" {
+		return nil, false
+	}
+	if msg.MsgType.String != "interactive" && !hasAlertMarker(msg.Content.String) {
+		return nil, false
+	}
+	site, alertType, ok := ParseAlert(msg.Content.String)
+	if !ok {
+		return nil, false
+	}
+	return &Alert{
+		MessageID: msg.MessageID,
+		ChatID:    msg.ChatID,
+		Site:      site,
+		AlertType: alertType,
+		AlertTime: msg.CreatedAt,
+	}, true
+}
@@ -0,0 +1,89 @@
+package model
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseAlert(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		wantSite string
+		wantType string
+		wantOK   bool
+	}{
+		{"带标签站点", "【支付慢请求告警】站点: BY4 接口 /pay 耗时 5.2s", "by4", "慢请求", true},
+		{"站点ID", "代付失败告警\n站点ID：3031\n原因：余额不足", "3031", "代付失败", true},
+		{"无标签站点前缀", "l08 场馆操作失败，请及时处理", "l08", "场馆操作失败", true},
+		{"未知类型", "⚠️ 告警：by4 CPU 使用率 95%", "by4", otherAlertType, true},
+		{"无站点", "支付失败告警：渠道超时", "", "支付失败", true},
+		{"普通消息", "今天下午三点开会", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			site, alertType, ok := ParseAlert(tt.content)
+			if ok != tt.wantOK || site != tt.wantSite || alertType != tt.wantType {
+				t.Errorf("ParseAlert() = (%q, %q, %v), want (%q, %q, %v)",
+					site, alertType, ok, tt.wantSite, tt.wantType, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestNewAlertFromMessage(t *testing.T) {
+	created := time.Date(2024, 3, 1, 10, 0, 0, 0, time.Local)
+	newMsg := func(msgType, content string) *ChatMessage {
+		return &ChatMessage{
+			MessageID: "om_1",
+			ChatID:    "oc_alert",
+			MsgType:   sql.NullString{String: msgType, Valid: true},
+			Content:   sql.NullString{String: content, Valid: content != ""},
+			CreatedAt: created,
+		}
+	}
+
+	tests := []struct {
+		name   string
+		msg    *ChatMessage
+		wantOK bool
+	}{
+		{"卡片消息", newMsg("interactive", "支付失败\n站点: by4"), true},
+		{"带告警标记的文本", newMsg("text", "by4 慢请求告警"), true},
+		{"讨论支付失败的文本", newMsg("text", "用户反馈 by4 支付失败了"), false},
+		{"空内容", newMsg("interactive", ""), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alert, ok := NewAlertFromMessage(tt.msg)
+			if ok != tt.wantOK {
+				t.Fatalf("NewAlertFromMessage() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && (alert.MessageID != "om_1" || alert.ChatID != "oc_alert" || !alert.AlertTime.Equal(created)) {
+				t.Errorf("Unexpected alert: %+v", alert)
+			}
+		})
+	}
+}
+
+func TestAlertTrendQuery(t *testing.T) {
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local)
+	end := start.AddDate(0, 0, 7)
+
+	query, args := alertTrendQuery("BY4", start, end)
+	if !strings.Contains(query, "AND site = ?") || len(args) != 3 || args[2] != "by4" {
+		t.Errorf("Expected lowercased site filter, got %q %v", query, args)
+	}
+
+	query, args = alertTrendQuery("", start, end)
+	if strings.Contains(query, "site = ?") || len(args) != 2 {
+		t.Errorf("Expected no site filter, got %q %v", query, args)
+	}
+	if !strings.HasSuffix(query, "GROUP BY day ORDER BY day") {
+		t.Errorf("Expected grouping by day, got %q", query)
+	}
+}
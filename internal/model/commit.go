@@ -3,6 +3,8 @@ package model
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"strings"
 	"time"
 )
 
@@ -16,6 +18,7 @@ type GitCommit struct {
 	Branch        sql.NullString `db:"branch"`
 	CommitSHA     string         `db:"commit_sha"`
 	CommitMessage sql.NullString `db:"commit_message"`
+	FileList      sql.NullString `db:"file_list"` // 变更文件列表（逗号分隔）
 	FilesChanged  int            `db:"files_changed"`
 	Additions     int            `db:"additions"`
 	Deletions     int            `db:"deletions"`
@@ -43,15 +46,28 @@ func NewGitCommitModel(db *sql.DB) *GitCommitModel {
 
 func (m *GitCommitModel) Insert(ctx context.Context, commit *GitCommit) error {
 	query := `INSERT INTO git_commits (member_id, author_name, author_email, repo_name, repo_full_name, branch,
-              commit_sha, commit_message, files_changed, additions, deletions, committed_at)
-              VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-              ON DUPLICATE KEY UPDATE files_changed = VALUES(files_changed), additions = VALUES(additions), deletions = VALUES(deletions)`
+              commit_sha, commit_message, file_list, files_changed, additions, deletions, committed_at)
+              VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+              ON DUPLICATE KEY UPDATE files_changed = VALUES(files_changed), additions = VALUES(additions), deletions = VALUES(deletions),
+              file_list = COALESCE(VALUES(file_list), file_list)`
 	_, err := m.db.ExecContext(ctx, query, commit.MemberID, commit.AuthorName, commit.AuthorEmail,
-		commit.RepoName, commit.RepoFullName, commit.Branch, commit.CommitSHA, commit.CommitMessage,
+		commit.RepoName, commit.RepoFullName, commit.Branch, commit.CommitSHA, commit.CommitMessage, commit.FileList,
 		commit.FilesChanged, commit.Additions, commit.Deletions, commit.CommittedAt)
 	return err
 }
 
+// maxCommitFiles 每个提交最多记录的文件数
+const maxCommitFiles = 20
+
+// JoinCommitFiles 拼接变更文件列表，超出上限的部分以 "...(+N)" 表示
+func JoinCommitFiles(files []string) string {
+	if len(files) > maxCommitFiles {
+		extra := len(files) - maxCommitFiles
+		files = append(files[:maxCommitFiles:maxCommitFiles], fmt.Sprintf("...(+%d)", extra))
+	}
+	return strings.Join(files, ",")
+}
+
 func (m *GitCommitModel) BatchInsert(ctx context.Context, commits []*GitCommit) error {
 	for _, commit := range commits {
 		if err := m.Insert(ctx, commit); err != nil {
@@ -130,14 +146,14 @@ func (m *GitCommitModel) GetAllStats(ctx context.Context, start, end time.Time)
 	return statsList, nil
 }
 
-// GetRecentCommits 获取最近的提交
-func (m *GitCommitModel) GetRecentCommits(ctx context.Context, memberID int64, limit int) ([]*GitCommit, error) {
+// GetRecentCommits 获取成员在指定时间范围内最近的提交
+func (m *GitCommitModel) GetRecentCommits(ctx context.Context, memberID int64, start, end time.Time, limit int) ([]*GitCommit, error) {
 	query := `SELECT id, member_id, author_name, author_email, repo_name, repo_full_name, branch,
-              commit_sha, commit_message, files_changed, additions, deletions, committed_at, created_at
+              commit_sha, commit_message, file_list, files_changed, additions, deletions, committed_at, created_at
               FROM git_commits
-              WHERE member_id = ?
+              WHERE member_id = ? AND committed_at BETWEEN ? AND ?
               ORDER BY committed_at DESC LIMIT ?`
-	rows, err := m.db.QueryContext(ctx, query, memberID, limit)
+	rows, err := m.db.QueryContext(ctx, query, memberID, start, end, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -147,7 +163,7 @@ func (m *GitCommitModel) GetRecentCommits(ctx context.Context, memberID int64, l
 	for rows.Next() {
 		var commit GitCommit
 		err := rows.Scan(&commit.ID, &commit.MemberID, &commit.AuthorName, &commit.AuthorEmail,
-			&commit.RepoName, &commit.RepoFullName, &commit.Branch, &commit.CommitSHA, &commit.CommitMessage,
+			&commit.RepoName, &commit.RepoFullName, &commit.Branch, &commit.CommitSHA, &commit.CommitMessage, &commit.FileList,
 			&commit.FilesChanged, &commit.Additions, &commit.Deletions, &commit.CommittedAt, &commit.CreatedAt)
 		if err != nil {
 			return nil, err
@@ -160,7 +176,7 @@ func (m *GitCommitModel) GetRecentCommits(ctx context.Context, memberID int64, l
 // GetCommitsByDateRange 按日期范围查询提交
 func (m *GitCommitModel) GetCommitsByDateRange(ctx context.Context, authorName string, start, end time.Time, limit int) ([]*GitCommit, error) {
 	query := `SELECT id, member_id, author_name, author_email, repo_name, repo_full_name, branch,
-              commit_sha, commit_message, files_changed, additions, deletions, committed_at, created_at
+              commit_sha, commit_message, file_list, files_changed, additions, deletions, committed_at, created_at
               FROM git_commits
               WHERE author_name LIKE ? AND committed_at BETWEEN ? AND ?
               ORDER BY committed_at DESC LIMIT ?`
@@ -174,7 +190,7 @@ func (m *GitCommitModel) GetCommitsByDateRange(ctx context.Context, authorName s
 	for rows.Next() {
 		var commit GitCommit
 		err := rows.Scan(&commit.ID, &commit.MemberID, &commit.AuthorName, &commit.AuthorEmail,
-			&commit.RepoName, &commit.RepoFullName, &commit.Branch, &commit.CommitSHA, &commit.CommitMessage,
+			&commit.RepoName, &commit.RepoFullName, &commit.Branch, &commit.CommitSHA, &commit.CommitMessage, &commit.FileList,
 			&commit.FilesChanged, &commit.Additions, &commit.Deletions, &commit.CommittedAt, &commit.CreatedAt)
 		if err != nil {
 			return nil, err
@@ -0,0 +1,33 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestJoinCommitFiles(t *testing.T) {
+	if got := JoinCommitFiles(nil); got != "" {
+		t.Errorf("Expected empty string, got %q", got)
+	}
+
+	if got := JoinCommitFiles([]string{"a.go", "b.go"}); got != "a.go,b.go" {
+		t.Errorf("Unexpected result: %q", got)
+	}
+
+	var files []string
+	for i := 0; i < maxCommitFiles+5; i++ {
+		files = append(files, fmt.Sprintf("f%d.go", i))
+	}
+	got := JoinCommitFiles(files)
+	parts := strings.Split(got, ",")
+	if len(parts) != maxCommitFiles+1 {
+		t.Fatalf("Expected %d entries, got %d", maxCommitFiles+1, len(parts))
+	}
+	if parts[len(parts)-1] != "...(+5)" {
+		t.Errorf("Expected overflow marker, got %q", parts[len(parts)-1])
+	}
+	if len(files) != maxCommitFiles+5 || files[maxCommitFiles] != fmt.Sprintf("f%d.go", maxCommitFiles) {
+		t.Errorf("Input slice should not be modified")
+	}
+}
@@ -0,0 +1,47 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// DifySyncState 已推送到 Dify 的文档状态
+type DifySyncState struct {
+	ID          int64     `db:"id"`
+	SourceID    string    `db:"source_id"`
+	DocumentID  string    `db:"document_id"`
+	ContentHash string    `db:"content_hash"`
+	CreatedAt   time.Time `db:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at"`
+}
+
+type DifySyncStateModel struct {
+	db *sql.DB
+}
+
+func NewDifySyncStateModel(db *sql.DB) *DifySyncStateModel {
+	return &DifySyncStateModel{db: db}
+}
+
+// Get 获取来源对应的同步状态，不存在时返回 sql.ErrNoRows
+func (m *DifySyncStateModel) Get(ctx context.Context, sourceID string) (*DifySyncState, error) {
+	query := `SELECT id, source_id, document_id, content_hash, created_at, updated_at
+              FROM dify_sync_state WHERE source_id = ?`
+	var state DifySyncState
+	err := m.db.QueryRowContext(ctx, query, sourceID).Scan(
+		&state.ID, &state.SourceID, &state.DocumentID, &state.ContentHash, &state.CreatedAt, &state.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// Upsert 记录来源对应的 Dify 文档和内容哈希
+func (m *DifySyncStateModel) Upsert(ctx context.Context, sourceID, documentID, contentHash string) error {
+	query := `INSERT INTO dify_sync_state (source_id, document_id, content_hash)
+              VALUES (?, ?, ?)
+              ON DUPLICATE KEY UPDATE document_id = VALUES(document_id), content_hash = VALUES(content_hash)`
+	_, err := m.db.ExecContext(ctx, query, sourceID, documentID, contentHash)
+	return err
+}
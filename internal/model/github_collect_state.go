@@ -0,0 +1,64 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// GitHubCollectState 仓库的 GitHub 采集状态
+type GitHubCollectState struct {
+	ID            int64     `db:"id"`
+	RepoFullName  string    `db:"repo_full_name"`
+	LastSuccessAt time.Time `db:"last_success_at"` // 最后一次采集成功时覆盖到的时间
+	UpdatedAt     time.Time `db:"updated_at"`
+}
+
+type GitHubCollectStateModel struct {
+	db *sql.DB
+}
+
+func NewGitHubCollectStateModel(db *sql.DB) *GitHubCollectStateModel {
+	return &GitHubCollectStateModel{db: db}
+}
+
+// MarkSuccess 记录仓库采集成功，数据覆盖到 at
+func (m *GitHubCollectStateModel) MarkSuccess(ctx context.Context, repoFullName string, at time.Time) error {
+	query := `INSERT INTO github_collect_state (repo_full_name, last_success_at)
+              VALUES (?, ?)
+              ON DUPLICATE KEY UPDATE last_success_at = VALUES(last_success_at)`
+	_, err := m.db.ExecContext(ctx, query, repoFullName, at)
+	return err
+}
+
+// ListAll 查询所有仓库的采集状态，按仓库名排序
+func (m *GitHubCollectStateModel) ListAll(ctx context.Context) ([]*GitHubCollectState, error) {
+	query := `SELECT id, repo_full_name, last_success_at, updated_at
+              FROM github_collect_state ORDER BY repo_full_name`
+	rows, err := m.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var states []*GitHubCollectState
+	for rows.Next() {
+		var s GitHubCollectState
+		if err := rows.Scan(&s.ID, &s.RepoFullName, &s.LastSuccessAt, &s.UpdatedAt); err != nil {
+			return nil, err
+		}
+		states = append(states, &s)
+	}
+	return states, rows.Err()
+}
+
+// LatestGitHubCollect 所有仓库中最近一次采集成功的时间，没有记录时返回零值
+func LatestGitHubCollect(states []*GitHubCollectState) time.Time {
+	var latest time.Time
+	for _, s := range states {
+		if s.LastSuccessAt.After(latest) {
+			latest = s.LastSuccessAt
+		}
+	}
+	return latest
+}
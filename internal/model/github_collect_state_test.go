@@ -0,0 +1,29 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatestGitHubCollect(t *testing.T) {
+	t1 := time.Date(2024, 3, 8, 9, 0, 0, 0, time.Local)
+	t2 := t1.Add(2 * time.Hour)
+
+	tests := []struct {
+		name   string
+		states []*GitHubCollectState
+		want   time.Time
+	}{
+		{"没有记录", nil, time.Time{}},
+		{"取最近一次", []*GitHubCollectState{{RepoFullName: "org/api", LastSuccessAt: t1}, {RepoFullName: "org/web", LastSuccessAt: t2}}, t2},
+		{"顺序无关", []*GitHubCollectState{{RepoFullName: "org/web", LastSuccessAt: t2}, {RepoFullName: "org/api", LastSuccessAt: t1}}, t2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LatestGitHubCollect(tt.states); !got.Equal(tt.want) {
+				t.Errorf("LatestGitHubCollect() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
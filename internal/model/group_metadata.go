@@ -0,0 +1,68 @@
+package model
+
+import (
+	"database/sql"
+	"strings"
+)
+
+// maxGroupKeywords 单个群最多保存的关键词数量
+const maxGroupKeywords = 20
+
+// NormalizeGroupKeywords 规范化关键词输入：支持中英文逗号、顿号、空格分隔，去重后以逗号拼接
+func NormalizeGroupKeywords(raw string) string {
+	fields := strings.FieldsFunc(raw, func(r rune) bool {
+		switch r {
+		case ',', '，', '、', ';', '；', ' ', '\t', '\n':
+			return true
+		}
+		return false
+	})
+
+	seen := make(map[string]bool)
+	var keywords []string
+	for _, f := range fields {
+		key := strings.ToLower(f)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		keywords = append(keywords, f)
+		if len(keywords) >= maxGroupKeywords {
+			break
+		}
+	}
+	return strings.Join(keywords, ",")
+}
+
+// KeywordList 返回群的关键词列表
+func (g *ChatGroup) KeywordList() []string {
+	if !g.Keywords.Valid || g.Keywords.String == "" {
+		return nil
+	}
+	return strings.Split(NormalizeGroupKeywords(g.Keywords.String), ",")
+}
+
+// MetadataContext 生成群的项目背景描述，供总结/问答作为上下文，未设置时返回空字符串
+func (g *ChatGroup) MetadataContext() string {
+	project := ""
+	if g.Project.Valid {
+		project = strings.TrimSpace(g.Project.String)
+	}
+	keywords := g.KeywordList()
+
+	switch {
+	case project != "" && len(keywords) > 0:
+		return "这是「" + project + "」项目群，相关关键词：" + strings.Join(keywords, "、")
+	case project != "":
+		return "这是「" + project + "」项目群"
+	case len(keywords) > 0:
+		return "本群相关关键词：" + strings.Join(keywords, "、")
+	}
+	return ""
+}
+
+// nullIfEmpty 空字符串转为 NULL
+func nullIfEmpty(s string) sql.NullString {
+	s = strings.TrimSpace(s)
+	return sql.NullString{String: s, Valid: s != ""}
+}
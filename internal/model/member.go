@@ -3,6 +3,7 @@ package model
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"time"
 )
 
@@ -109,3 +110,115 @@ func (m *TeamMemberModel) Upsert(ctx context.Context, member *TeamMember) error
 		member.LarkOpenID, member.Email, member.Role, member.Department)
 	return err
 }
+
+func (m *TeamMemberModel) FindByID(ctx context.Context, id int64) (*TeamMember, error) {
+	query := `SELECT id, name, github_username, lark_user_id, lark_open_id, email, role, department, status, created_at, updated_at
+              FROM team_members WHERE id = ?`
+	row := m.db.QueryRowContext(ctx, query, id)
+
+	var member TeamMember
+	err := row.Scan(&member.ID, &member.Name, &member.GitHubUsername, &member.LarkUserID, &member.LarkOpenID,
+		&member.Email, &member.Role, &member.Department, &member.Status, &member.CreatedAt, &member.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// MemberMergeResult 合并成员的结果
+type MemberMergeResult struct {
+	Primary   *TeamMember // 合并后的主成员
+	Duplicate *TeamMember // 已删除的重复成员
+	Commits   int64       // 改为关联主成员的提交数
+	Messages  int64       // 改为关联主成员的消息数
+}
+
+// sqlStatement 待执行的 SQL 及参数
+type sqlStatement struct {
+	query string
+	args  []interface{}
+}
+
+// Merge 把重复成员合并到主成员：提交和消息改为关联主成员，主成员缺失的身份信息
+// （GitHub 用户名、飞书 ID、邮箱等）从重复成员补齐，然后删除重复成员；在一个事务中完成
+func (m *TeamMemberModel) Merge(ctx context.Context, primaryID, duplicateID int64) (*MemberMergeResult, error) {
+	if primaryID == duplicateID {
+		return nil, fmt.Errorf("cannot merge member %d into itself", primaryID)
+	}
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	primary, err := findMemberForUpdate(ctx, tx, primaryID)
+	if err != nil {
+		return nil, fmt.Errorf("find primary member %d: %w", primaryID, err)
+	}
+	duplicate, err := findMemberForUpdate(ctx, tx, duplicateID)
+	if err != nil {
+		return nil, fmt.Errorf("find duplicate member %d: %w", duplicateID, err)
+	}
+
+	result := &MemberMergeResult{Primary: mergeMemberFields(primary, duplicate), Duplicate: duplicate}
+	affected := []*int64{&result.Commits, &result.Messages}
+	for i, stmt := range memberMergeStatements(result.Primary, duplicate) {
+		res, err := tx.ExecContext(ctx, stmt.query, stmt.args...)
+		if err != nil {
+			return nil, fmt.Errorf("merge member %d into %d: %w", duplicateID, primaryID, err)
+		}
+		if i < len(affected) {
+			*affected[i], _ = res.RowsAffected()
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// findMemberForUpdate 在事务中查询并锁定成员（不区分启用状态）
+func findMemberForUpdate(ctx context.Context, tx *sql.Tx, id int64) (*TeamMember, error) {
+	query := `SELECT id, name, github_username, lark_user_id, lark_open_id, email, role, department, status, created_at, updated_at
+              FROM team_members WHERE id = ? FOR UPDATE`
+	var member TeamMember
+	err := tx.QueryRowContext(ctx, query, id).Scan(&member.ID, &member.Name, &member.GitHubUsername, &member.LarkUserID,
+		&member.LarkOpenID, &member.Email, &member.Role, &member.Department, &member.Status, &member.CreatedAt, &member.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// mergeMemberFields 合并身份信息：保留主成员已有的值，缺失的从重复成员补齐
+func mergeMemberFields(primary, duplicate *TeamMember) *TeamMember {
+	merged := *primary
+	for _, f := range []struct{ dst, src *sql.NullString }{
+		{&merged.GitHubUsername, &duplicate.GitHubUsername},
+		{&merged.LarkUserID, &duplicate.LarkUserID},
+		{&merged.LarkOpenID, &duplicate.LarkOpenID},
+		{&merged.Email, &duplicate.Email},
+		{&merged.Role, &duplicate.Role},
+		{&merged.Department, &duplicate.Department},
+	} {
+		if (!f.dst.Valid || f.dst.String == "") && f.src.Valid && f.src.String != "" {
+			*f.dst = *f.src
+		}
+	}
+	return &merged
+}
+
+// memberMergeStatements 合并成员要执行的语句（按顺序）：
+// 先迁移提交和消息，再删除重复成员（释放 github_username 等唯一键），最后更新主成员的身份信息
+func memberMergeStatements(merged, duplicate *TeamMember) []sqlStatement {
+	return []sqlStatement{
+		{`UPDATE git_commits SET member_id = ? WHERE member_id = ?`, []interface{}{merged.ID, duplicate.ID}},
+		{`UPDATE chat_messages SET member_id = ? WHERE member_id = ?`, []interface{}{merged.ID, duplicate.ID}},
+		{`DELETE FROM team_members WHERE id = ?`, []interface{}{duplicate.ID}},
+		{`UPDATE team_members SET github_username = ?, lark_user_id = ?, lark_open_id = ?, email = ?, role = ?, department = ?
+              WHERE id = ?`, []interface{}{merged.GitHubUsername, merged.LarkUserID, merged.LarkOpenID,
+			merged.Email, merged.Role, merged.Department, merged.ID}},
+	}
+}
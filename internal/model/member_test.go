@@ -0,0 +1,75 @@
+package model
+
+import (
+	"database/sql"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func TestMergeMemberFields(t *testing.T) {
+	primary := &TeamMember{ID: 1, Name: "张三", GitHubUsername: nullString("zhangsan"), Role: nullString("backend")}
+	duplicate := &TeamMember{ID: 2, Name: "张叁", GitHubUsername: nullString("zs-old"), LarkOpenID: nullString("ou_zs"),
+		Email: nullString("zs@example.com"), Role: nullString("frontend"), Department: sql.NullString{Valid: true}}
+
+	merged := mergeMemberFields(primary, duplicate)
+
+	tests := []struct {
+		name string
+		got  sql.NullString
+		want string
+	}{
+		{"主成员已有的 GitHub 保留", merged.GitHubUsername, "zhangsan"},
+		{"主成员已有的角色保留", merged.Role, "backend"},
+		{"缺失的 open_id 补齐", merged.LarkOpenID, "ou_zs"},
+		{"缺失的邮箱补齐", merged.Email, "zs@example.com"},
+		{"重复成员为空字符串不补齐", merged.Department, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got.String != tt.want {
+				t.Errorf("got %q, want %q", tt.got.String, tt.want)
+			}
+		})
+	}
+
+	if merged.ID != 1 || merged.Name != "张三" {
+		t.Errorf("Merged member should keep primary ID and name, got %d %s", merged.ID, merged.Name)
+	}
+	if primary.LarkOpenID.Valid {
+		t.Error("Primary member should not be modified")
+	}
+}
+
+func TestMemberMergeStatements(t *testing.T) {
+	merged := &TeamMember{ID: 1, Name: "张三", GitHubUsername: nullString("zhangsan"), LarkOpenID: nullString("ou_zs")}
+	duplicate := &TeamMember{ID: 2, Name: "张叁"}
+
+	stmts := memberMergeStatements(merged, duplicate)
+	want := []struct {
+		prefix string
+		args   []interface{}
+	}{
+		{"UPDATE git_commits SET member_id = ? WHERE member_id = ?", []interface{}{int64(1), int64(2)}},
+		{"UPDATE chat_messages SET member_id = ? WHERE member_id = ?", []interface{}{int64(1), int64(2)}},
+		{"DELETE FROM team_members WHERE id = ?", []interface{}{int64(2)}},
+		{"UPDATE team_members SET github_username = ?", []interface{}{merged.GitHubUsername, merged.LarkUserID,
+			merged.LarkOpenID, merged.Email, merged.Role, merged.Department, int64(1)}},
+	}
+
+	if len(stmts) != len(want) {
+		t.Fatalf("Expected %d statements, got %d", len(want), len(stmts))
+	}
+	for i, w := range want {
+		if !strings.HasPrefix(stmts[i].query, w.prefix) {
+			t.Errorf("Statement %d = %q, want prefix %q", i, stmts[i].query, w.prefix)
+		}
+		if !reflect.DeepEqual(stmts[i].args, w.args) {
+			t.Errorf("Statement %d args = %v, want %v", i, stmts[i].args, w.args)
+		}
+	}
+}
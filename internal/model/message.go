@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 )
@@ -24,9 +25,12 @@ type ChatMessage struct {
 	ThreadID    sql.NullString  `db:"thread_id"`
 	RootID      sql.NullString  `db:"root_id"`
 	IsAtBot     int             `db:"is_at_bot"`
+	IsForwarded int             `db:"is_forwarded"` // 是否是转发消息（合并转发/转发的子消息）
 	CreatedAt   time.Time       `db:"created_at"`
 	CreatedAtTs sql.NullInt64   `db:"created_at_ts"` // 毫秒时间戳，用于准确排序
 	IndexedAt   time.Time       `db:"indexed_at"`
+	// 表情回复数，仅 TopReactedSince 返回
+	ReactionCount int `db:"reaction_count"`
 }
 
 type ChatGroup struct {
@@ -37,35 +41,116 @@ type ChatGroup struct {
 	OwnerID     sql.NullString `db:"owner_id"`
 	MemberCount int            `db:"member_count"`
 	Status      int            `db:"status"`
+	Project     sql.NullString `db:"project"`  // 所属项目名称
+	Keywords    sql.NullString `db:"keywords"` // 群主题关键词，逗号分隔
 	CreatedAt   time.Time      `db:"created_at"`
 	UpdatedAt   time.Time      `db:"updated_at"`
 }
 
 type ChatMessageModel struct {
-	db *sql.DB
+	db             *sql.DB
+	keepRawContent bool // 写入时是否保存 raw_content
+	fullText       bool // SearchByContent 是否优先使用全文索引
 }
 
 func NewChatMessageModel(db *sql.DB) *ChatMessageModel {
-	return &ChatMessageModel{db: db}
+	return &ChatMessageModel{db: db, keepRawContent: true}
+}
+
+// SetKeepRawContent 设置写入时是否保存原始消息 JSON（raw_content），关闭后新消息的 raw_content 为 NULL
+// 读取和检索只使用解析后的 content，不依赖 raw_content
+func (m *ChatMessageModel) SetKeepRawContent(keep bool) {
+	m.keepRawContent = keep
+}
+
+// UpsertResult 写入消息的结果
+type UpsertResult int
+
+const (
+	UpsertUnchanged UpsertResult = iota // 消息已存在，合并后没有字段变化
+	UpsertInserted                      // 新消息
+	UpsertUpdated                       // 消息已存在，合并后有字段更新
+)
+
+// messageMergeRules 同一条消息被实时回调（storeMessage）和历史同步（syncMessages）重复写入时各字段的合并规则。
+// 两条路径解析发言人和内容的质量不同，按规则保留更好的一份，与写入先后无关：
+//   - sender_name：新值已解析（非空且不是 sender_id）才覆盖原值
+//   - content：保留更长（更完整）的内容，长度相同时使用新值
+//   - 其余字段新值为 NULL 时保留原值
+var messageMergeRules = []struct {
+	column string
+	expr   string
+}{
+	{"sender_name", "IF(VALUES(sender_name) IS NULL OR VALUES(sender_name) = '' OR VALUES(sender_name) = sender_id, sender_name, VALUES(sender_name))"},
+	{"content", "IF(CHAR_LENGTH(COALESCE(VALUES(content), '')) >= CHAR_LENGTH(COALESCE(content, '')), VALUES(content), content)"},
+	{"member_id", "COALESCE(VALUES(member_id), member_id)"},
+	{"raw_content", "COALESCE(VALUES(raw_content), raw_content)"},
+	{"thread_id", "COALESCE(VALUES(thread_id), thread_id)"},
+	{"root_id", "COALESCE(VALUES(root_id), root_id)"},
+	{"created_at_ts", "COALESCE(VALUES(created_at_ts), created_at_ts)"},
+}
+
+// messageUpsertQuery 写入消息的 SQL，重复的 message_id 按 messageMergeRules 合并
+func messageUpsertQuery() string {
+	assignments := make([]string, 0, len(messageMergeRules))
+	for _, rule := range messageMergeRules {
+		assignments = append(assignments, rule.column+" = "+rule.expr)
+	}
+	return `INSERT INTO chat_messages (message_id, chat_id, sender_id, sender_name, member_id, msg_type,
+              content, raw_content, mentions, reply_to_id, thread_id, root_id, is_at_bot, is_forwarded, created_at, created_at_ts)
+              VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+              ON DUPLICATE KEY UPDATE ` + strings.Join(assignments, ", ")
+}
+
+// upsertResultFromRows 根据 ON DUPLICATE KEY UPDATE 的影响行数判断写入结果：
+// 1 表示新插入，2 表示已存在并更新，0 表示已存在且没有变化
+func upsertResultFromRows(affected int64) UpsertResult {
+	switch {
+	case affected == 0:
+		return UpsertUnchanged
+	case affected == 1:
+		return UpsertInserted
+	default:
+		return UpsertUpdated
+	}
 }
 
 func (m *ChatMessageModel) Insert(ctx context.Context, msg *ChatMessage) error {
-	query := `INSERT INTO chat_messages (message_id, chat_id, sender_id, sender_name, member_id, msg_type,
-              content, raw_content, mentions, reply_to_id, thread_id, root_id, is_at_bot, created_at, created_at_ts)
-              VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-              ON DUPLICATE KEY UPDATE content = VALUES(content), sender_name = COALESCE(VALUES(sender_name), sender_name),
-              thread_id = COALESCE(VALUES(thread_id), thread_id), root_id = COALESCE(VALUES(root_id), root_id),
-              created_at_ts = COALESCE(VALUES(created_at_ts), created_at_ts)`
-	_, err := m.db.ExecContext(ctx, query, msg.MessageID, msg.ChatID, msg.SenderID, msg.SenderName,
-		msg.MemberID, msg.MsgType, msg.Content, msg.RawContent, msg.Mentions, msg.ReplyToID,
-		msg.ThreadID, msg.RootID, msg.IsAtBot, msg.CreatedAt, msg.CreatedAtTs)
+	_, err := m.Upsert(ctx, msg)
 	return err
 }
 
+// Upsert 写入消息并返回写入结果，调用方据此跳过重复消息的向量索引
+func (m *ChatMessageModel) Upsert(ctx context.Context, msg *ChatMessage) (UpsertResult, error) {
+	result, err := m.db.ExecContext(ctx, messageUpsertQuery(), m.insertArgs(msg)...)
+	if err != nil {
+		return UpsertUnchanged, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		// 无法判断时按新消息处理，宁可重复索引也不漏索引
+		return UpsertInserted, nil
+	}
+	return upsertResultFromRows(affected), nil
+}
+
+// insertArgs Insert 的参数，不保存原始内容时 raw_content 写入 NULL
+func (m *ChatMessageModel) insertArgs(msg *ChatMessage) []interface{} {
+	rawContent := msg.RawContent
+	if !m.keepRawContent {
+		rawContent = sql.NullString{}
+	}
+	return []interface{}{
+		msg.MessageID, msg.ChatID, msg.SenderID, msg.SenderName,
+		msg.MemberID, msg.MsgType, msg.Content, rawContent, msg.Mentions, msg.ReplyToID,
+		msg.ThreadID, msg.RootID, msg.IsAtBot, msg.IsForwarded, msg.CreatedAt, msg.CreatedAtTs,
+	}
+}
+
 // GetRecentMessages 获取群最近的消息
 func (m *ChatMessageModel) GetRecentMessages(ctx context.Context, chatID string, limit int) ([]*ChatMessage, error) {
 	query := `SELECT id, message_id, chat_id, sender_id, sender_name, member_id, msg_type,
-              content, raw_content, mentions, reply_to_id, thread_id, root_id, is_at_bot, created_at, created_at_ts, indexed_at
+              content, raw_content, mentions, reply_to_id, thread_id, root_id, is_at_bot, is_forwarded, created_at, created_at_ts, indexed_at
               FROM chat_messages
               WHERE chat_id = ?
               ORDER BY COALESCE(created_at_ts, UNIX_TIMESTAMP(created_at)*1000) DESC LIMIT ?`
@@ -80,7 +165,7 @@ func (m *ChatMessageModel) GetRecentMessages(ctx context.Context, chatID string,
 		var msg ChatMessage
 		err := rows.Scan(&msg.ID, &msg.MessageID, &msg.ChatID, &msg.SenderID, &msg.SenderName,
 			&msg.MemberID, &msg.MsgType, &msg.Content, &msg.RawContent, &msg.Mentions,
-			&msg.ReplyToID, &msg.ThreadID, &msg.RootID, &msg.IsAtBot, &msg.CreatedAt, &msg.CreatedAtTs, &msg.IndexedAt)
+			&msg.ReplyToID, &msg.ThreadID, &msg.RootID, &msg.IsAtBot, &msg.IsForwarded, &msg.CreatedAt, &msg.CreatedAtTs, &msg.IndexedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -101,14 +186,14 @@ func (m *ChatMessageModel) GetMessagesByDateRange(ctx context.Context, chatID st
 
 	if chatID != "" {
 		query = `SELECT id, message_id, chat_id, sender_id, sender_name, member_id, msg_type,
-              content, raw_content, mentions, reply_to_id, thread_id, root_id, is_at_bot, created_at, created_at_ts, indexed_at
+              content, raw_content, mentions, reply_to_id, thread_id, root_id, is_at_bot, is_forwarded, created_at, created_at_ts, indexed_at
               FROM chat_messages
               WHERE chat_id = ? AND COALESCE(created_at_ts, UNIX_TIMESTAMP(created_at)*1000) BETWEEN ? AND ?
               ORDER BY COALESCE(created_at_ts, UNIX_TIMESTAMP(created_at)*1000) DESC LIMIT ?`
 		rows, err = m.db.QueryContext(ctx, query, chatID, startTs, endTs, limit)
 	} else {
 		query = `SELECT id, message_id, chat_id, sender_id, sender_name, member_id, msg_type,
-              content, raw_content, mentions, reply_to_id, thread_id, root_id, is_at_bot, created_at, created_at_ts, indexed_at
+              content, raw_content, mentions, reply_to_id, thread_id, root_id, is_at_bot, is_forwarded, created_at, created_at_ts, indexed_at
               FROM chat_messages
               WHERE COALESCE(created_at_ts, UNIX_TIMESTAMP(created_at)*1000) BETWEEN ? AND ?
               ORDER BY COALESCE(created_at_ts, UNIX_TIMESTAMP(created_at)*1000) DESC LIMIT ?`
@@ -124,7 +209,7 @@ func (m *ChatMessageModel) GetMessagesByDateRange(ctx context.Context, chatID st
 		var msg ChatMessage
 		err := rows.Scan(&msg.ID, &msg.MessageID, &msg.ChatID, &msg.SenderID, &msg.SenderName,
 			&msg.MemberID, &msg.MsgType, &msg.Content, &msg.RawContent, &msg.Mentions,
-			&msg.ReplyToID, &msg.ThreadID, &msg.RootID, &msg.IsAtBot, &msg.CreatedAt, &msg.CreatedAtTs, &msg.IndexedAt)
+			&msg.ReplyToID, &msg.ThreadID, &msg.RootID, &msg.IsAtBot, &msg.IsForwarded, &msg.CreatedAt, &msg.CreatedAtTs, &msg.IndexedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -135,7 +220,10 @@ func (m *ChatMessageModel) GetMessagesByDateRange(ctx context.Context, chatID st
 
 // SearchByContent 按内容搜索消息
 func (m *ChatMessageModel) SearchByContent(ctx context.Context, chatID, keyword string, limit int) ([]*ChatMessage, error) {
-	// 直接使用 LIKE 搜索，因为全文索引可能未配置
+	// 全文索引可能未配置，开启 Storage.FullTextSearch 后才使用 MATCH 查询
+	if m.fullText {
+		return m.SearchByFullText(ctx, chatID, keyword, limit)
+	}
 	return m.searchByLike(ctx, chatID, keyword, limit)
 }
 
@@ -146,14 +234,14 @@ func (m *ChatMessageModel) searchByLike(ctx context.Context, chatID, keyword str
 
 	if chatID != "" {
 		query = `SELECT id, message_id, chat_id, sender_id, sender_name, member_id, msg_type,
-              content, raw_content, mentions, reply_to_id, thread_id, root_id, is_at_bot, created_at, created_at_ts, indexed_at
+              content, raw_content, mentions, reply_to_id, thread_id, root_id, is_at_bot, is_forwarded, created_at, created_at_ts, indexed_at
               FROM chat_messages
               WHERE chat_id = ? AND content LIKE ?
               ORDER BY COALESCE(created_at_ts, UNIX_TIMESTAMP(created_at)*1000) DESC LIMIT ?`
 		rows, err = m.db.QueryContext(ctx, query, chatID, "%"+keyword+"%", limit)
 	} else {
 		query = `SELECT id, message_id, chat_id, sender_id, sender_name, member_id, msg_type,
-              content, raw_content, mentions, reply_to_id, thread_id, root_id, is_at_bot, created_at, created_at_ts, indexed_at
+              content, raw_content, mentions, reply_to_id, thread_id, root_id, is_at_bot, is_forwarded, created_at, created_at_ts, indexed_at
               FROM chat_messages
               WHERE content LIKE ?
               ORDER BY COALESCE(created_at_ts, UNIX_TIMESTAMP(created_at)*1000) DESC LIMIT ?`
@@ -169,7 +257,7 @@ func (m *ChatMessageModel) searchByLike(ctx context.Context, chatID, keyword str
 		var msg ChatMessage
 		err := rows.Scan(&msg.ID, &msg.MessageID, &msg.ChatID, &msg.SenderID, &msg.SenderName,
 			&msg.MemberID, &msg.MsgType, &msg.Content, &msg.RawContent, &msg.Mentions,
-			&msg.ReplyToID, &msg.ThreadID, &msg.RootID, &msg.IsAtBot, &msg.CreatedAt, &msg.CreatedAtTs, &msg.IndexedAt)
+			&msg.ReplyToID, &msg.ThreadID, &msg.RootID, &msg.IsAtBot, &msg.IsForwarded, &msg.CreatedAt, &msg.CreatedAtTs, &msg.IndexedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -205,7 +293,7 @@ func (m *ChatMessageModel) SearchByMultipleKeywords(ctx context.Context, chatID
 	}
 
 	query := fmt.Sprintf(`SELECT id, message_id, chat_id, sender_id, sender_name, member_id, msg_type,
-              content, raw_content, mentions, reply_to_id, thread_id, root_id, is_at_bot, created_at, created_at_ts, indexed_at
+              content, raw_content, mentions, reply_to_id, thread_id, root_id, is_at_bot, is_forwarded, created_at, created_at_ts, indexed_at
               FROM chat_messages
               WHERE %s
               ORDER BY COALESCE(created_at_ts, UNIX_TIMESTAMP(created_at)*1000) DESC LIMIT ?`,
@@ -224,7 +312,7 @@ func (m *ChatMessageModel) SearchByMultipleKeywords(ctx context.Context, chatID
 		var msg ChatMessage
 		err := rows.Scan(&msg.ID, &msg.MessageID, &msg.ChatID, &msg.SenderID, &msg.SenderName,
 			&msg.MemberID, &msg.MsgType, &msg.Content, &msg.RawContent, &msg.Mentions,
-			&msg.ReplyToID, &msg.ThreadID, &msg.RootID, &msg.IsAtBot, &msg.CreatedAt, &msg.CreatedAtTs, &msg.IndexedAt)
+			&msg.ReplyToID, &msg.ThreadID, &msg.RootID, &msg.IsAtBot, &msg.IsForwarded, &msg.CreatedAt, &msg.CreatedAtTs, &msg.IndexedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -233,9 +321,100 @@ func (m *ChatMessageModel) SearchByMultipleKeywords(ctx context.Context, chatID
 	return messages, nil
 }
 
+// SearchByContentAndDateRange 按内容搜索指定时间范围内的消息，时间条件在 SQL 中过滤
+func (m *ChatMessageModel) SearchByContentAndDateRange(ctx context.Context, chatID, keyword string, start, end time.Time, limit int) ([]*ChatMessage, error) {
+	query, args := searchInRangeQuery(chatID, []string{keyword}, start, end, limit)
+	return m.queryMessages(ctx, query, args...)
+}
+
+// searchByMultipleKeywordsInRange 多关键词 AND 搜索指定时间范围内的消息（忽略过短的关键词）
+func (m *ChatMessageModel) searchByMultipleKeywordsInRange(ctx context.Context, chatID string, keywords []string, start, end time.Time, limit int) ([]*ChatMessage, error) {
+	var valid []string
+	for _, kw := range keywords {
+		if len(kw) >= 2 {
+			valid = append(valid, kw)
+		}
+	}
+	if len(valid) == 0 {
+		return nil, nil
+	}
+	query, args := searchInRangeQuery(chatID, valid, start, end, limit)
+	return m.queryMessages(ctx, query, args...)
+}
+
+// searchInRangeQuery 构建按群、关键词（AND）和时间范围搜索消息的查询
+func searchInRangeQuery(chatID string, keywords []string, start, end time.Time, limit int) (string, []interface{}) {
+	var conds []string
+	var args []interface{}
+	if chatID != "" {
+		conds = append(conds, "chat_id = ?")
+		args = append(args, chatID)
+	}
+	conds = append(conds, "COALESCE(created_at_ts, UNIX_TIMESTAMP(created_at)*1000) BETWEEN ? AND ?")
+	args = append(args, start.UnixMilli(), end.UnixMilli())
+	for _, kw := range keywords {
+		conds = append(conds, "content LIKE ?")
+		args = append(args, "%"+kw+"%")
+	}
+
+	query := `SELECT id, message_id, chat_id, sender_id, sender_name, member_id, msg_type,
+              content, raw_content, mentions, reply_to_id, thread_id, root_id, is_at_bot, is_forwarded, created_at, created_at_ts, indexed_at
+              FROM chat_messages
+              WHERE ` + strings.Join(conds, " AND ") + `
+              ORDER BY COALESCE(created_at_ts, UNIX_TIMESTAMP(created_at)*1000) DESC LIMIT ?`
+	return query, append(args, limit)
+}
+
+// queryMessages 执行查询并扫描为消息列表
+func (m *ChatMessageModel) queryMessages(ctx context.Context, query string, args ...interface{}) ([]*ChatMessage, error) {
+	rows, err := m.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []*ChatMessage
+	for rows.Next() {
+		var msg ChatMessage
+		err := rows.Scan(&msg.ID, &msg.MessageID, &msg.ChatID, &msg.SenderID, &msg.SenderName,
+			&msg.MemberID, &msg.MsgType, &msg.Content, &msg.RawContent, &msg.Mentions,
+			&msg.ReplyToID, &msg.ThreadID, &msg.RootID, &msg.IsAtBot, &msg.IsForwarded, &msg.CreatedAt, &msg.CreatedAtTs, &msg.IndexedAt)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, &msg)
+	}
+	return messages, rows.Err()
+}
+
 // SearchByKeywordCombinations 组合关键词搜索（先尝试全部匹配，再逐步放宽）
 // 返回消息和每条消息匹配的关键词数量
 func (m *ChatMessageModel) SearchByKeywordCombinations(ctx context.Context, chatID string, keywords []string, limit int) ([]*ChatMessage, map[int64]int, error) {
+	return searchKeywordCombinations(keywords, limit,
+		func(kws []string, n int) ([]*ChatMessage, error) {
+			return m.SearchByMultipleKeywords(ctx, chatID, kws, n)
+		},
+		func(kw string, n int) ([]*ChatMessage, error) {
+			return m.SearchByContent(ctx, chatID, kw, n)
+		})
+}
+
+// SearchByKeywordCombinationsInRange 与 SearchByKeywordCombinations 相同，但只搜索 start ~ end 之间的消息
+// 时间条件下推到每次 SQL 查询，避免先取出不在范围内的消息再过滤
+func (m *ChatMessageModel) SearchByKeywordCombinationsInRange(ctx context.Context, chatID string, keywords []string, start, end time.Time, limit int) ([]*ChatMessage, map[int64]int, error) {
+	return searchKeywordCombinations(keywords, limit,
+		func(kws []string, n int) ([]*ChatMessage, error) {
+			return m.searchByMultipleKeywordsInRange(ctx, chatID, kws, start, end, n)
+		},
+		func(kw string, n int) ([]*ChatMessage, error) {
+			return m.SearchByContentAndDateRange(ctx, chatID, kw, start, end, n)
+		})
+}
+
+// searchKeywordCombinations 组合关键词搜索策略：全部关键词 AND → 两两组合 → 单关键词补充
+func searchKeywordCombinations(keywords []string, limit int,
+	searchAll func(keywords []string, limit int) ([]*ChatMessage, error),
+	searchOne func(keyword string, limit int) ([]*ChatMessage, error)) ([]*ChatMessage, map[int64]int, error) {
 	if len(keywords) == 0 {
 		return nil, nil, nil
 	}
@@ -245,7 +424,7 @@ func (m *ChatMessageModel) SearchByKeywordCombinations(ctx context.Context, chat
 
 	// 策略1：先尝试所有关键词 AND 搜索（最精确）
 	if len(keywords) >= 2 {
-		msgs, err := m.SearchByMultipleKeywords(ctx, chatID, keywords, limit)
+		msgs, err := searchAll(keywords, limit)
 		if err == nil {
 			for _, msg := range msgs {
 				messageMap[msg.ID] = msg
@@ -262,7 +441,7 @@ func (m *ChatMessageModel) SearchByKeywordCombinations(ctx context.Context, chat
 					break
 				}
 				pair := []string{keywords[i], keywords[j]}
-				msgs, err := m.SearchByMultipleKeywords(ctx, chatID, pair, limit-len(messageMap))
+				msgs, err := searchAll(pair, limit-len(messageMap))
 				if err == nil {
 					for _, msg := range msgs {
 						if _, exists := messageMap[msg.ID]; !exists {
@@ -281,7 +460,7 @@ func (m *ChatMessageModel) SearchByKeywordCombinations(ctx context.Context, chat
 			if len(messageMap) >= limit {
 				break
 			}
-			msgs, err := m.SearchByContent(ctx, chatID, kw, limit-len(messageMap))
+			msgs, err := searchOne(kw, limit-len(messageMap))
 			if err == nil {
 				for _, msg := range msgs {
 					if _, exists := messageMap[msg.ID]; !exists {
@@ -310,14 +489,14 @@ func (m *ChatMessageModel) SearchBySender(ctx context.Context, chatID, senderNam
 
 	if chatID != "" {
 		query = `SELECT id, message_id, chat_id, sender_id, sender_name, member_id, msg_type,
-              content, raw_content, mentions, reply_to_id, thread_id, root_id, is_at_bot, created_at, created_at_ts, indexed_at
+              content, raw_content, mentions, reply_to_id, thread_id, root_id, is_at_bot, is_forwarded, created_at, created_at_ts, indexed_at
               FROM chat_messages
               WHERE chat_id = ? AND sender_name LIKE ? AND content LIKE ?
               ORDER BY COALESCE(created_at_ts, UNIX_TIMESTAMP(created_at)*1000) DESC LIMIT ?`
 		rows, err = m.db.QueryContext(ctx, query, chatID, "%"+senderName+"%", "%"+keyword+"%", limit)
 	} else {
 		query = `SELECT id, message_id, chat_id, sender_id, sender_name, member_id, msg_type,
-              content, raw_content, mentions, reply_to_id, thread_id, root_id, is_at_bot, created_at, created_at_ts, indexed_at
+              content, raw_content, mentions, reply_to_id, thread_id, root_id, is_at_bot, is_forwarded, created_at, created_at_ts, indexed_at
               FROM chat_messages
               WHERE sender_name LIKE ? AND content LIKE ?
               ORDER BY COALESCE(created_at_ts, UNIX_TIMESTAMP(created_at)*1000) DESC LIMIT ?`
@@ -333,7 +512,7 @@ func (m *ChatMessageModel) SearchBySender(ctx context.Context, chatID, senderNam
 		var msg ChatMessage
 		err := rows.Scan(&msg.ID, &msg.MessageID, &msg.ChatID, &msg.SenderID, &msg.SenderName,
 			&msg.MemberID, &msg.MsgType, &msg.Content, &msg.RawContent, &msg.Mentions,
-			&msg.ReplyToID, &msg.ThreadID, &msg.RootID, &msg.IsAtBot, &msg.CreatedAt, &msg.CreatedAtTs, &msg.IndexedAt)
+			&msg.ReplyToID, &msg.ThreadID, &msg.RootID, &msg.IsAtBot, &msg.IsForwarded, &msg.CreatedAt, &msg.CreatedAtTs, &msg.IndexedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -345,7 +524,7 @@ func (m *ChatMessageModel) SearchBySender(ctx context.Context, chatID, senderNam
 // GetAtBotMessages 获取@机器人的消息
 func (m *ChatMessageModel) GetAtBotMessages(ctx context.Context, limit int) ([]*ChatMessage, error) {
 	query := `SELECT id, message_id, chat_id, sender_id, sender_name, member_id, msg_type,
-              content, raw_content, mentions, reply_to_id, thread_id, root_id, is_at_bot, created_at, created_at_ts, indexed_at
+              content, raw_content, mentions, reply_to_id, thread_id, root_id, is_at_bot, is_forwarded, created_at, created_at_ts, indexed_at
               FROM chat_messages
               WHERE is_at_bot = 1
               ORDER BY COALESCE(created_at_ts, UNIX_TIMESTAMP(created_at)*1000) DESC LIMIT ?`
@@ -360,7 +539,7 @@ func (m *ChatMessageModel) GetAtBotMessages(ctx context.Context, limit int) ([]*
 		var msg ChatMessage
 		err := rows.Scan(&msg.ID, &msg.MessageID, &msg.ChatID, &msg.SenderID, &msg.SenderName,
 			&msg.MemberID, &msg.MsgType, &msg.Content, &msg.RawContent, &msg.Mentions,
-			&msg.ReplyToID, &msg.ThreadID, &msg.RootID, &msg.IsAtBot, &msg.CreatedAt, &msg.CreatedAtTs, &msg.IndexedAt)
+			&msg.ReplyToID, &msg.ThreadID, &msg.RootID, &msg.IsAtBot, &msg.IsForwarded, &msg.CreatedAt, &msg.CreatedAtTs, &msg.IndexedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -369,23 +548,95 @@ func (m *ChatMessageModel) GetAtBotMessages(ctx context.Context, limit int) ([]*
 	return messages, nil
 }
 
-// GetDistinctSenders 获取不重复的发送者名称列表
-func (m *ChatMessageModel) GetDistinctSenders(ctx context.Context, chatID string) ([]string, error) {
-	var query string
-	var rows *sql.Rows
-	var err error
+// CountKeywordHitsByChat 统计各群在 since 之后包含每个关键词的消息数
+// 返回 chat_id -> 与 keywords 一一对应的命中数，用于跨群问答时挑选最相关的群
+func (m *ChatMessageModel) CountKeywordHitsByChat(ctx context.Context, keywords []string, since time.Time) (map[string][]int, error) {
+	if len(keywords) == 0 {
+		return nil, nil
+	}
+	query, args := keywordHitsQuery(keywords, since)
+	rows, err := m.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-	if chatID != "" {
-		query = `SELECT DISTINCT sender_name FROM chat_messages
-                 WHERE chat_id = ? AND sender_name IS NOT NULL AND sender_name != ''
-                 ORDER BY sender_name`
-		rows, err = m.db.QueryContext(ctx, query, chatID)
-	} else {
-		query = `SELECT DISTINCT sender_name FROM chat_messages
-                 WHERE sender_name IS NOT NULL AND sender_name != ''
-                 ORDER BY sender_name`
-		rows, err = m.db.QueryContext(ctx, query)
+	hits := make(map[string][]int)
+	for rows.Next() {
+		var chatID string
+		counts := make([]int, len(keywords))
+		dest := []interface{}{&chatID}
+		for i := range counts {
+			dest = append(dest, &counts[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		hits[chatID] = counts
+	}
+	return hits, rows.Err()
+}
+
+// keywordHitsQuery 构建按群统计关键词命中数的查询
+func keywordHitsQuery(keywords []string, since time.Time) (string, []interface{}) {
+	var sums, likes []string
+	var sumArgs, likeArgs []interface{}
+	for _, kw := range keywords {
+		pattern := "%" + kw + "%"
+		sums = append(sums, "COALESCE(SUM(content LIKE ?), 0)")
+		sumArgs = append(sumArgs, pattern)
+		likes = append(likes, "content LIKE ?")
+		likeArgs = append(likeArgs, pattern)
+	}
+
+	query := "SELECT chat_id, " + strings.Join(sums, ", ") + " FROM chat_messages" +
+		" WHERE created_at >= ? AND (" + strings.Join(likes, " OR ") + ")" +
+		" GROUP BY chat_id"
+	args := append(sumArgs, since)
+	args = append(args, likeArgs...)
+	return query, args
+}
+
+// indexableCondition 可索引消息的条件（与 reindex 一致：内容非空）
+const indexableCondition = "content IS NOT NULL AND content != ''"
+
+// CountIndexableByChat 按群统计可索引（内容非空）的消息数，返回 chat_id -> 消息数
+// 用于与向量库的数据点数对账，排除的发言人和只有@提及的消息也会计入
+func (m *ChatMessageModel) CountIndexableByChat(ctx context.Context) (map[string]int64, error) {
+	query := "SELECT chat_id, COUNT(*) FROM chat_messages WHERE " + indexableCondition + " GROUP BY chat_id"
+	rows, err := m.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int64)
+	for rows.Next() {
+		var chatID string
+		var n int64
+		if err := rows.Scan(&chatID, &n); err != nil {
+			return nil, err
+		}
+		counts[chatID] = n
 	}
+	return counts, rows.Err()
+}
+
+// ListIndexableByChat 获取群内最近的可索引消息（用于补齐缺失的向量索引）
+func (m *ChatMessageModel) ListIndexableByChat(ctx context.Context, chatID string, limit int) ([]*ChatMessage, error) {
+	query := `SELECT id, message_id, chat_id, sender_id, sender_name, member_id, msg_type,
+              content, raw_content, mentions, reply_to_id, thread_id, root_id, is_at_bot, is_forwarded, created_at, created_at_ts, indexed_at
+              FROM chat_messages
+              WHERE chat_id = ? AND ` + indexableCondition + `
+              ORDER BY COALESCE(created_at_ts, UNIX_TIMESTAMP(created_at)*1000) DESC LIMIT ?`
+	return m.queryMessages(ctx, query, chatID, limit)
+}
+
+// GetDistinctSenders 获取不重复的发送者名称列表
+// excludeEmpty 为 false 时，名称缺失（同步缺口）的消息以空字符串返回，调用方可归为"未知成员"
+func (m *ChatMessageModel) GetDistinctSenders(ctx context.Context, chatID string, excludeEmpty bool) ([]string, error) {
+	query, args := distinctSendersQuery(chatID, excludeEmpty)
+	rows, err := m.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -402,6 +653,31 @@ func (m *ChatMessageModel) GetDistinctSenders(ctx context.Context, chatID string
 	return senders, nil
 }
 
+// distinctSendersQuery 构建不重复发送者查询
+func distinctSendersQuery(chatID string, excludeEmpty bool) (string, []interface{}) {
+	var conds []string
+	var args []interface{}
+	if chatID != "" {
+		conds = append(conds, "chat_id = ?")
+		args = append(args, chatID)
+	}
+	if excludeEmpty {
+		conds = append(conds, "sender_name IS NOT NULL AND sender_name != ''")
+	}
+
+	query := "SELECT DISTINCT COALESCE(sender_name, '') AS name FROM chat_messages"
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	return query + " ORDER BY name", args
+}
+
+// 群聊状态（chat_groups.status）
+const (
+	ChatGroupStatusInactive = 0 // 群已解散或机器人已被移出，停止同步
+	ChatGroupStatusActive   = 1
+)
+
 // ChatGroupModel 群聊模型
 type ChatGroupModel struct {
 	db *sql.DB
@@ -424,20 +700,40 @@ func (m *ChatGroupModel) Upsert(ctx context.Context, group *ChatGroup) error {
 
 // FindByChatID 根据chat_id查找群聊
 func (m *ChatGroupModel) FindByChatID(ctx context.Context, chatID string) (*ChatGroup, error) {
-	query := `SELECT id, chat_id, chat_name, chat_type, owner_id, member_count, status, created_at, updated_at
+	query := `SELECT id, chat_id, chat_name, chat_type, owner_id, member_count, status, project, keywords, created_at, updated_at
               FROM chat_groups WHERE chat_id = ?`
 	var group ChatGroup
 	err := m.db.QueryRowContext(ctx, query, chatID).Scan(&group.ID, &group.ChatID, &group.ChatName,
-		&group.ChatType, &group.OwnerID, &group.MemberCount, &group.Status, &group.CreatedAt, &group.UpdatedAt)
+		&group.ChatType, &group.OwnerID, &group.MemberCount, &group.Status, &group.Project, &group.Keywords,
+		&group.CreatedAt, &group.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
 	return &group, nil
 }
 
+// UpdateMetadata 设置群的项目名称和关键词（空字符串表示清除）
+// 群记录不存在时会先插入，避免机器人刚入群尚未同步时设置失败
+func (m *ChatGroupModel) UpdateMetadata(ctx context.Context, chatID, project, keywords string) error {
+	query := `INSERT INTO chat_groups (chat_id, project, keywords)
+              VALUES (?, ?, ?)
+              ON DUPLICATE KEY UPDATE project = VALUES(project), keywords = VALUES(keywords)`
+	_, err := m.db.ExecContext(ctx, query, chatID, nullIfEmpty(project), nullIfEmpty(keywords))
+	return err
+}
+
+// SetStatus 设置群的状态，群记录不存在时会先插入
+func (m *ChatGroupModel) SetStatus(ctx context.Context, chatID string, status int) error {
+	query := `INSERT INTO chat_groups (chat_id, status)
+              VALUES (?, ?)
+              ON DUPLICATE KEY UPDATE status = VALUES(status)`
+	_, err := m.db.ExecContext(ctx, query, chatID, status)
+	return err
+}
+
 // ListAll 列出所有群聊
 func (m *ChatGroupModel) ListAll(ctx context.Context) ([]*ChatGroup, error) {
-	query := `SELECT id, chat_id, chat_name, chat_type, owner_id, member_count, status, created_at, updated_at
+	query := `SELECT id, chat_id, chat_name, chat_type, owner_id, member_count, status, project, keywords, created_at, updated_at
               FROM chat_groups WHERE status = 1 ORDER BY updated_at DESC`
 	rows, err := m.db.QueryContext(ctx, query)
 	if err != nil {
@@ -449,7 +745,8 @@ func (m *ChatGroupModel) ListAll(ctx context.Context) ([]*ChatGroup, error) {
 	for rows.Next() {
 		var group ChatGroup
 		err := rows.Scan(&group.ID, &group.ChatID, &group.ChatName, &group.ChatType,
-			&group.OwnerID, &group.MemberCount, &group.Status, &group.CreatedAt, &group.UpdatedAt)
+			&group.OwnerID, &group.MemberCount, &group.Status, &group.Project, &group.Keywords,
+			&group.CreatedAt, &group.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -467,6 +764,7 @@ type MessageSyncTask struct {
 	TotalMessages  int            `db:"total_messages"`
 	SyncedMessages int            `db:"synced_messages"`
 	PageToken      sql.NullString `db:"page_token"`
+	ResumeTime     sql.NullString `db:"resume_time"` // 已同步的最后一条消息时间（秒），分页 Token 失效时从这里续传
 	StartTime      sql.NullString `db:"start_time"`
 	EndTime        sql.NullString `db:"end_time"`
 	ErrorMsg       sql.NullString `db:"error_msg"`
@@ -502,13 +800,13 @@ func (m *MessageSyncTaskModel) Create(ctx context.Context, task *MessageSyncTask
 // GetByID 根据ID获取任务
 func (m *MessageSyncTaskModel) GetByID(ctx context.Context, id int64) (*MessageSyncTask, error) {
 	query := `SELECT id, chat_id, chat_name, status, total_messages, synced_messages,
-              page_token, start_time, end_time, error_msg, requested_by,
+              page_token, resume_time, start_time, end_time, error_msg, requested_by,
               started_at, finished_at, created_at, updated_at
               FROM message_sync_tasks WHERE id = ?`
 	var task MessageSyncTask
 	err := m.db.QueryRowContext(ctx, query, id).Scan(
 		&task.ID, &task.ChatID, &task.ChatName, &task.Status, &task.TotalMessages, &task.SyncedMessages,
-		&task.PageToken, &task.StartTime, &task.EndTime, &task.ErrorMsg, &task.RequestedBy,
+		&task.PageToken, &task.ResumeTime, &task.StartTime, &task.EndTime, &task.ErrorMsg, &task.RequestedBy,
 		&task.StartedAt, &task.FinishedAt, &task.CreatedAt, &task.UpdatedAt)
 	if err != nil {
 		return nil, err
@@ -519,14 +817,14 @@ func (m *MessageSyncTaskModel) GetByID(ctx context.Context, id int64) (*MessageS
 // GetPendingTask 获取待处理的任务
 func (m *MessageSyncTaskModel) GetPendingTask(ctx context.Context) (*MessageSyncTask, error) {
 	query := `SELECT id, chat_id, chat_name, status, total_messages, synced_messages,
-              page_token, start_time, end_time, error_msg, requested_by,
+              page_token, resume_time, start_time, end_time, error_msg, requested_by,
               started_at, finished_at, created_at, updated_at
               FROM message_sync_tasks WHERE status IN ('pending', 'running')
               ORDER BY created_at ASC LIMIT 1`
 	var task MessageSyncTask
 	err := m.db.QueryRowContext(ctx, query).Scan(
 		&task.ID, &task.ChatID, &task.ChatName, &task.Status, &task.TotalMessages, &task.SyncedMessages,
-		&task.PageToken, &task.StartTime, &task.EndTime, &task.ErrorMsg, &task.RequestedBy,
+		&task.PageToken, &task.ResumeTime, &task.StartTime, &task.EndTime, &task.ErrorMsg, &task.RequestedBy,
 		&task.StartedAt, &task.FinishedAt, &task.CreatedAt, &task.UpdatedAt)
 	if err != nil {
 		return nil, err
@@ -541,10 +839,17 @@ func (m *MessageSyncTaskModel) UpdateStatus(ctx context.Context, id int64, statu
 	return err
 }
 
-// UpdateProgress 更新同步进度
-func (m *MessageSyncTaskModel) UpdateProgress(ctx context.Context, id int64, syncedMessages int, pageToken string) error {
-	query := `UPDATE message_sync_tasks SET synced_messages = ?, page_token = ? WHERE id = ?`
-	_, err := m.db.ExecContext(ctx, query, syncedMessages, pageToken, id)
+// UpdateProgress 更新同步进度，resumeTime 为空时保留原值
+func (m *MessageSyncTaskModel) UpdateProgress(ctx context.Context, id int64, syncedMessages int, pageToken, resumeTime string) error {
+	query := `UPDATE message_sync_tasks SET synced_messages = ?, page_token = ?, resume_time = COALESCE(NULLIF(?, ''), resume_time) WHERE id = ?`
+	_, err := m.db.ExecContext(ctx, query, syncedMessages, pageToken, resumeTime, id)
+	return err
+}
+
+// RestartFrom 分页 Token 失效后从 startTime 开始重新分页（清空 page_token），已同步数量保留
+func (m *MessageSyncTaskModel) RestartFrom(ctx context.Context, id int64, startTime string) error {
+	query := `UPDATE message_sync_tasks SET start_time = ?, page_token = NULL WHERE id = ?`
+	_, err := m.db.ExecContext(ctx, query, startTime, id)
 	return err
 }
 
@@ -571,11 +876,65 @@ func (m *MessageSyncTaskModel) MarkFailed(ctx context.Context, id int64, errMsg
 
 // GetRecentTasks 获取最近的任务列表
 func (m *MessageSyncTaskModel) GetRecentTasks(ctx context.Context, limit int) ([]*MessageSyncTask, error) {
+	query, args := syncTaskListQuery("", "", limit)
+	return m.listTasks(ctx, query, args)
+}
+
+// ListByStatus 按状态（pending/running/completed/failed）获取最近的任务
+func (m *MessageSyncTaskModel) ListByStatus(ctx context.Context, status string, limit int) ([]*MessageSyncTask, error) {
+	query, args := syncTaskListQuery("status", status, limit)
+	return m.listTasks(ctx, query, args)
+}
+
+// ListByChat 获取某个群最近的任务
+func (m *MessageSyncTaskModel) ListByChat(ctx context.Context, chatID string, limit int) ([]*MessageSyncTask, error) {
+	query, args := syncTaskListQuery("chat_id", chatID, limit)
+	return m.listTasks(ctx, query, args)
+}
+
+// ChatSyncStats 某个群的同步任务统计
+type ChatSyncStats struct {
+	TotalTasks      int          // 任务总数
+	CompletedTasks  int          // 已完成的任务数
+	FailedTasks     int          // 失败的任务数
+	ActiveTasks     int          // 等待中或同步中的任务数
+	LastCompletedAt sql.NullTime // 最近一次同步完成的时间
+}
+
+// GetChatStats 统计某个群的同步任务
+func (m *MessageSyncTaskModel) GetChatStats(ctx context.Context, chatID string) (*ChatSyncStats, error) {
+	query := `SELECT COUNT(*),
+              COALESCE(SUM(status = 'completed'), 0),
+              COALESCE(SUM(status = 'failed'), 0),
+              COALESCE(SUM(status IN ('pending', 'running')), 0),
+              MAX(CASE WHEN status = 'completed' THEN finished_at END)
+              FROM message_sync_tasks WHERE chat_id = ?`
+	var stats ChatSyncStats
+	err := m.db.QueryRowContext(ctx, query, chatID).Scan(&stats.TotalTasks, &stats.CompletedTasks,
+		&stats.FailedTasks, &stats.ActiveTasks, &stats.LastCompletedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// syncTaskListQuery 构建任务列表查询，column 为空时不过滤，按创建时间倒序
+func syncTaskListQuery(column, value string, limit int) (string, []interface{}) {
 	query := `SELECT id, chat_id, chat_name, status, total_messages, synced_messages,
-              page_token, start_time, end_time, error_msg, requested_by,
+              page_token, resume_time, start_time, end_time, error_msg, requested_by,
               started_at, finished_at, created_at, updated_at
-              FROM message_sync_tasks ORDER BY created_at DESC LIMIT ?`
-	rows, err := m.db.QueryContext(ctx, query, limit)
+              FROM message_sync_tasks`
+	var args []interface{}
+	if column != "" {
+		query += " WHERE " + column + " = ?"
+		args = append(args, value)
+	}
+	return query + " ORDER BY created_at DESC LIMIT ?", append(args, limit)
+}
+
+// listTasks 执行任务列表查询
+func (m *MessageSyncTaskModel) listTasks(ctx context.Context, query string, args []interface{}) ([]*MessageSyncTask, error) {
+	rows, err := m.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -585,7 +944,7 @@ func (m *MessageSyncTaskModel) GetRecentTasks(ctx context.Context, limit int) ([
 	for rows.Next() {
 		var task MessageSyncTask
 		err := rows.Scan(&task.ID, &task.ChatID, &task.ChatName, &task.Status, &task.TotalMessages, &task.SyncedMessages,
-			&task.PageToken, &task.StartTime, &task.EndTime, &task.ErrorMsg, &task.RequestedBy,
+			&task.PageToken, &task.ResumeTime, &task.StartTime, &task.EndTime, &task.ErrorMsg, &task.RequestedBy,
 			&task.StartedAt, &task.FinishedAt, &task.CreatedAt, &task.UpdatedAt)
 		if err != nil {
 			return nil, err
@@ -595,10 +954,37 @@ func (m *MessageSyncTaskModel) GetRecentTasks(ctx context.Context, limit int) ([
 	return tasks, nil
 }
 
+// GetContentByMessageID 按消息ID查询消息内容，不存在时返回 sql.ErrNoRows
+func (m *ChatMessageModel) GetContentByMessageID(ctx context.Context, messageID string) (string, error) {
+	var content sql.NullString
+	err := m.db.QueryRowContext(ctx, "SELECT content FROM chat_messages WHERE message_id = ? LIMIT 1", messageID).Scan(&content)
+	if err != nil {
+		return "", err
+	}
+	return content.String, nil
+}
+
+// GetByMessageID 按消息ID查询消息，不存在时返回 sql.ErrNoRows
+func (m *ChatMessageModel) GetByMessageID(ctx context.Context, messageID string) (*ChatMessage, error) {
+	query := `SELECT id, message_id, chat_id, sender_id, sender_name, member_id, msg_type,
+              content, raw_content, mentions, reply_to_id, thread_id, root_id, is_at_bot, is_forwarded, created_at, created_at_ts, indexed_at
+              FROM chat_messages
+              WHERE message_id = ? LIMIT 1`
+	var msg ChatMessage
+	err := m.db.QueryRowContext(ctx, query, messageID).Scan(
+		&msg.ID, &msg.MessageID, &msg.ChatID, &msg.SenderID, &msg.SenderName,
+		&msg.MemberID, &msg.MsgType, &msg.Content, &msg.RawContent, &msg.Mentions,
+		&msg.ReplyToID, &msg.ThreadID, &msg.RootID, &msg.IsAtBot, &msg.IsForwarded, &msg.CreatedAt, &msg.CreatedAtTs, &msg.IndexedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
 // GetGroupFirstMessage 获取群的第一条消息（用于确定群的起始时间）
 func (m *ChatMessageModel) GetGroupFirstMessage(ctx context.Context, chatID string) (*ChatMessage, error) {
 	query := `SELECT id, message_id, chat_id, sender_id, sender_name, member_id, msg_type,
-              content, raw_content, mentions, reply_to_id, thread_id, root_id, is_at_bot, created_at, created_at_ts, indexed_at
+              content, raw_content, mentions, reply_to_id, thread_id, root_id, is_at_bot, is_forwarded, created_at, created_at_ts, indexed_at
               FROM chat_messages
               WHERE chat_id = ?
               ORDER BY COALESCE(created_at_ts, UNIX_TIMESTAMP(created_at)*1000) ASC LIMIT 1`
@@ -606,7 +992,7 @@ func (m *ChatMessageModel) GetGroupFirstMessage(ctx context.Context, chatID stri
 	err := m.db.QueryRowContext(ctx, query, chatID).Scan(
 		&msg.ID, &msg.MessageID, &msg.ChatID, &msg.SenderID, &msg.SenderName,
 		&msg.MemberID, &msg.MsgType, &msg.Content, &msg.RawContent, &msg.Mentions,
-		&msg.ReplyToID, &msg.ThreadID, &msg.RootID, &msg.IsAtBot, &msg.CreatedAt, &msg.CreatedAtTs, &msg.IndexedAt)
+		&msg.ReplyToID, &msg.ThreadID, &msg.RootID, &msg.IsAtBot, &msg.IsForwarded, &msg.CreatedAt, &msg.CreatedAtTs, &msg.IndexedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -635,3 +1021,173 @@ func (m *ChatMessageModel) GetDistinctSendersByDateRange(ctx context.Context, ch
 	}
 	return senders, nil
 }
+
+// SenderActivity 发言人的发言统计
+type SenderActivity struct {
+	Name         string
+	MessageCount int
+	LastActiveAt time.Time
+}
+
+// GetActiveSenders 查询 since 之后发过言的成员，按发言数降序（相同时最近发言的在前），最多 limit 个
+// 大群中 GetDistinctSenders 会返回数百人，角色/人员类问题只需要活跃成员
+func (m *ChatMessageModel) GetActiveSenders(ctx context.Context, chatID string, since time.Time, limit int) ([]*SenderActivity, error) {
+	query, args := activeSendersQuery(chatID, since, limit)
+	rows, err := m.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var senders []*SenderActivity
+	for rows.Next() {
+		var s SenderActivity
+		if err := rows.Scan(&s.Name, &s.MessageCount, &s.LastActiveAt); err != nil {
+			return nil, err
+		}
+		senders = append(senders, &s)
+	}
+	return senders, rows.Err()
+}
+
+// activeSendersQuery 构建活跃发言人查询，chatID 为空时统计所有群
+func activeSendersQuery(chatID string, since time.Time, limit int) (string, []interface{}) {
+	conds := []string{"created_at >= ?", "sender_name IS NOT NULL AND sender_name != ''"}
+	args := []interface{}{since}
+	if chatID != "" {
+		conds = append([]string{"chat_id = ?"}, conds...)
+		args = append([]interface{}{chatID}, args...)
+	}
+
+	query := "SELECT sender_name, COUNT(*) AS cnt, MAX(created_at) AS last_at FROM chat_messages" +
+		" WHERE " + strings.Join(conds, " AND ") +
+		" GROUP BY sender_name ORDER BY cnt DESC, last_at DESC LIMIT ?"
+	return query, append(args, limit)
+}
+
+// RankSenders 统计时间范围内各成员的发言数，按发言数降序返回前 limit 个（用于 "发言最多的五个人"）
+// chatID 为空时统计所有群
+func (m *ChatMessageModel) RankSenders(ctx context.Context, chatID string, start, end time.Time, limit int) ([]*SenderActivity, error) {
+	query, args := rankSendersQuery(chatID, start, end, limit)
+	rows, err := m.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var senders []*SenderActivity
+	for rows.Next() {
+		var s SenderActivity
+		if err := rows.Scan(&s.Name, &s.MessageCount, &s.LastActiveAt); err != nil {
+			return nil, err
+		}
+		senders = append(senders, &s)
+	}
+	return senders, rows.Err()
+}
+
+// rankSendersQuery 构建发言排行查询，与 activeSendersQuery 相同但限定了结束时间
+func rankSendersQuery(chatID string, start, end time.Time, limit int) (string, []interface{}) {
+	conds := []string{"created_at BETWEEN ? AND ?", "sender_name IS NOT NULL AND sender_name != ''"}
+	args := []interface{}{start, end}
+	if chatID != "" {
+		conds = append([]string{"chat_id = ?"}, conds...)
+		args = append([]interface{}{chatID}, args...)
+	}
+
+	query := "SELECT sender_name, COUNT(*) AS cnt, MAX(created_at) AS last_at FROM chat_messages" +
+		" WHERE " + strings.Join(conds, " AND ") +
+		" GROUP BY sender_name ORDER BY cnt DESC, last_at DESC LIMIT ?"
+	return query, append(args, limit)
+}
+
+// CountBySender 统计时间范围内每个发言人的消息数（所有群），没有发言人名称的消息不计入
+func (m *ChatMessageModel) CountBySender(ctx context.Context, start, end time.Time) (map[string]int, error) {
+	query := `SELECT sender_name, COUNT(*) AS cnt FROM chat_messages
+              WHERE created_at BETWEEN ? AND ? AND sender_name IS NOT NULL AND sender_name != ''
+              GROUP BY sender_name`
+	rows, err := m.db.QueryContext(ctx, query, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var name string
+		var count int
+		if err := rows.Scan(&name, &count); err != nil {
+			return nil, err
+		}
+		counts[name] = count
+	}
+	return counts, rows.Err()
+}
+
+// SenderGroupActivity 成员在某个群的发言统计
+type SenderGroupActivity struct {
+	ChatID       string
+	ChatName     string
+	MessageCount int
+	LastActiveAt time.Time
+}
+
+// GroupsBySender 查询某人发过言的群及发言数（按发言数降序）
+// senderName 为模糊匹配，since 为零值时不限制时间
+func (m *ChatMessageModel) GroupsBySender(ctx context.Context, senderName string, since time.Time) ([]*SenderGroupActivity, error) {
+	query := `SELECT m.chat_id, COALESCE(MAX(g.chat_name), '') AS chat_name, COUNT(*) AS cnt, MAX(m.created_at) AS last_at
+              FROM chat_messages m
+              LEFT JOIN chat_groups g ON m.chat_id = g.chat_id
+              WHERE m.sender_name LIKE ? AND m.created_at >= ?
+              GROUP BY m.chat_id
+              ORDER BY cnt DESC`
+	rows, err := m.db.QueryContext(ctx, query, "%"+senderName+"%", since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var groups []*SenderGroupActivity
+	for rows.Next() {
+		var g SenderGroupActivity
+		if err := rows.Scan(&g.ChatID, &g.ChatName, &g.MessageCount, &g.LastActiveAt); err != nil {
+			return nil, err
+		}
+		groups = append(groups, &g)
+	}
+	return groups, nil
+}
+
+// MergeSenderGroupActivities 合并多个别名的群发言统计（按 chat_id 累加，按发言数降序）
+func MergeSenderGroupActivities(lists ...[]*SenderGroupActivity) []*SenderGroupActivity {
+	merged := make(map[string]*SenderGroupActivity)
+	var order []string
+
+	for _, list := range lists {
+		for _, g := range list {
+			existing, ok := merged[g.ChatID]
+			if !ok {
+				copied := *g
+				merged[g.ChatID] = &copied
+				order = append(order, g.ChatID)
+				continue
+			}
+			existing.MessageCount += g.MessageCount
+			if g.LastActiveAt.After(existing.LastActiveAt) {
+				existing.LastActiveAt = g.LastActiveAt
+			}
+			if existing.ChatName == "" {
+				existing.ChatName = g.ChatName
+			}
+		}
+	}
+
+	result := make([]*SenderGroupActivity, 0, len(order))
+	for _, chatID := range order {
+		result = append(result, merged[chatID])
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].MessageCount > result[j].MessageCount
+	})
+	return result
+}
@@ -0,0 +1,132 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// fullTextIndexName chat_messages.content 的全文索引名，与 deploy/sql/init.sql 一致
+	fullTextIndexName = "ft_content"
+	// ngramTokenSize MySQL ngram 分词的默认长度（ngram_token_size），更短的关键词无法命中全文索引
+	ngramTokenSize = 2
+)
+
+// fullTextOperators 布尔模式的操作符，关键词中出现时去掉，避免改变查询语义
+const fullTextOperators = `+-<>()~*"@`
+
+// SetFullTextSearch 设置 SearchByContent 是否优先使用全文索引（MATCH ... AGAINST）
+func (m *ChatMessageModel) SetFullTextSearch(enabled bool) {
+	m.fullText = enabled
+}
+
+// fullTextBooleanQuery 把关键词转为布尔模式查询：按空白拆分，每个词作为必须包含的短语（+"词"）
+// 有词短于 ngram 分词长度时返回 false，这类关键词只能用 LIKE 匹配
+func fullTextBooleanQuery(keyword string) (string, bool) {
+	var terms []string
+	for _, field := range strings.Fields(keyword) {
+		term := strings.Map(func(r rune) rune {
+			if strings.ContainsRune(fullTextOperators, r) {
+				return -1
+			}
+			return r
+		}, field)
+		if term == "" {
+			continue
+		}
+		if utf8.RuneCountInString(term) < ngramTokenSize {
+			return "", false
+		}
+		terms = append(terms, `+"`+term+`"`)
+	}
+	if len(terms) == 0 {
+		return "", false
+	}
+	return strings.Join(terms, " "), true
+}
+
+// SearchByFullText 通过全文索引按内容搜索消息，结果按时间倒序（与 searchByLike 一致）
+// 关键词不适合全文检索或 MATCH 查询出错（如未创建全文索引）时自动回退到 LIKE 搜索
+func (m *ChatMessageModel) SearchByFullText(ctx context.Context, chatID, keyword string, limit int) ([]*ChatMessage, error) {
+	against, ok := fullTextBooleanQuery(keyword)
+	if !ok {
+		return m.searchByLike(ctx, chatID, keyword, limit)
+	}
+
+	messages, err := m.searchByMatch(ctx, chatID, against, limit)
+	if err != nil {
+		log.Printf("Full-text search failed, falling back to LIKE: %v", err)
+		return m.searchByLike(ctx, chatID, keyword, limit)
+	}
+	return messages, nil
+}
+
+func (m *ChatMessageModel) searchByMatch(ctx context.Context, chatID, against string, limit int) ([]*ChatMessage, error) {
+	var query string
+	var rows *sql.Rows
+	var err error
+
+	if chatID != "" {
+		query = `SELECT id, message_id, chat_id, sender_id, sender_name, member_id, msg_type,
+              content, raw_content, mentions, reply_to_id, thread_id, root_id, is_at_bot, is_forwarded, created_at, created_at_ts, indexed_at
+              FROM chat_messages
+              WHERE chat_id = ? AND MATCH(content) AGAINST(? IN BOOLEAN MODE)
+              ORDER BY COALESCE(created_at_ts, UNIX_TIMESTAMP(created_at)*1000) DESC LIMIT ?`
+		rows, err = m.db.QueryContext(ctx, query, chatID, against, limit)
+	} else {
+		query = `SELECT id, message_id, chat_id, sender_id, sender_name, member_id, msg_type,
+              content, raw_content, mentions, reply_to_id, thread_id, root_id, is_at_bot, is_forwarded, created_at, created_at_ts, indexed_at
+              FROM chat_messages
+              WHERE MATCH(content) AGAINST(? IN BOOLEAN MODE)
+              ORDER BY COALESCE(created_at_ts, UNIX_TIMESTAMP(created_at)*1000) DESC LIMIT ?`
+		rows, err = m.db.QueryContext(ctx, query, against, limit)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []*ChatMessage
+	for rows.Next() {
+		var msg ChatMessage
+		err := rows.Scan(&msg.ID, &msg.MessageID, &msg.ChatID, &msg.SenderID, &msg.SenderName,
+			&msg.MemberID, &msg.MsgType, &msg.Content, &msg.RawContent, &msg.Mentions,
+			&msg.ReplyToID, &msg.ThreadID, &msg.RootID, &msg.IsAtBot, &msg.IsForwarded, &msg.CreatedAt, &msg.CreatedAtTs, &msg.IndexedAt)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, &msg)
+	}
+	return messages, rows.Err()
+}
+
+// HasFullTextIndex chat_messages.content 上是否已有全文索引
+func (m *ChatMessageModel) HasFullTextIndex(ctx context.Context) (bool, error) {
+	query := `SELECT COUNT(*) FROM information_schema.STATISTICS
+              WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'chat_messages'
+              AND COLUMN_NAME = 'content' AND INDEX_TYPE = 'FULLTEXT'`
+	var count int
+	if err := m.db.QueryRowContext(ctx, query).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// EnsureFullTextIndex 迁移：没有全文索引时为 content 创建 ngram 分词的全文索引，返回是否新建
+// 大表上建索引需要较长时间，应在低峰期执行（见 deploy/sql/migrations/010_chat_message_fulltext.sql）
+func (m *ChatMessageModel) EnsureFullTextIndex(ctx context.Context) (bool, error) {
+	exists, err := m.HasFullTextIndex(ctx)
+	if err != nil {
+		return false, err
+	}
+	if exists {
+		return false, nil
+	}
+	if _, err := m.db.ExecContext(ctx, "ALTER TABLE chat_messages ADD FULLTEXT INDEX "+fullTextIndexName+" (content) WITH PARSER ngram"); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,30 @@
+package model
+
+import "testing"
+
+func TestFullTextBooleanQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		keyword string
+		want    string
+		wantOK  bool
+	}{
+		{"单个词", "登录超时", `+"登录超时"`, true},
+		{"多个词都必须包含", "支付 回调", `+"支付" +"回调"`, true},
+		{"去掉布尔操作符", `-退款 "对账*"`, `+"退款" +"对账"`, true},
+		{"英文关键词", "nginx", `+"nginx"`, true},
+		{"单字无法命中 ngram 索引", "钱", "", false},
+		{"含单字的组合回退", "支付 单", "", false},
+		{"只有操作符", `+- *`, "", false},
+		{"空关键词", "  ", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := fullTextBooleanQuery(tt.keyword)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("fullTextBooleanQuery(%q) = %q, %v, want %q, %v", tt.keyword, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
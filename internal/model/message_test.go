@@ -0,0 +1,421 @@
+package model
+
+import (
+	"database/sql"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMergeSenderGroupActivities(t *testing.T) {
+	day1 := time.Date(2025, 1, 1, 10, 0, 0, 0, time.Local)
+	day2 := time.Date(2025, 1, 5, 10, 0, 0, 0, time.Local)
+
+	byName := []*SenderGroupActivity{
+		{ChatID: "oc_a", ChatName: "研发群", MessageCount: 10, LastActiveAt: day1},
+		{ChatID: "oc_b", ChatName: "", MessageCount: 3, LastActiveAt: day1},
+	}
+	byAlias := []*SenderGroupActivity{
+		{ChatID: "oc_b", ChatName: "支付群", MessageCount: 12, LastActiveAt: day2},
+		{ChatID: "oc_c", ChatName: "产品群", MessageCount: 1, LastActiveAt: day2},
+	}
+
+	result := MergeSenderGroupActivities(byName, byAlias)
+
+	if len(result) != 3 {
+		t.Fatalf("Expected 3 groups, got %d", len(result))
+	}
+
+	// 按发言数降序：oc_b(15) > oc_a(10) > oc_c(1)
+	if result[0].ChatID != "oc_b" || result[0].MessageCount != 15 {
+		t.Errorf("Expected oc_b with 15 messages first, got %s with %d", result[0].ChatID, result[0].MessageCount)
+	}
+	if result[0].ChatName != "支付群" {
+		t.Errorf("Expected missing chat name to be filled, got %q", result[0].ChatName)
+	}
+	if !result[0].LastActiveAt.Equal(day2) {
+		t.Errorf("Expected latest active time to be kept, got %v", result[0].LastActiveAt)
+	}
+	if result[1].ChatID != "oc_a" || result[2].ChatID != "oc_c" {
+		t.Errorf("Unexpected order: %s, %s", result[1].ChatID, result[2].ChatID)
+	}
+
+	// 不修改输入
+	if byName[1].MessageCount != 3 {
+		t.Errorf("Input should not be modified, got %d", byName[1].MessageCount)
+	}
+}
+
+func TestMergeSenderGroupActivitiesEmpty(t *testing.T) {
+	if result := MergeSenderGroupActivities(); len(result) != 0 {
+		t.Errorf("Expected empty result, got %d", len(result))
+	}
+	if result := MergeSenderGroupActivities(nil, nil); len(result) != 0 {
+		t.Errorf("Expected empty result, got %d", len(result))
+	}
+}
+
+func TestNormalizeGroupKeywords(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{"英文逗号", "支付,退款", "支付,退款"},
+		{"混合分隔符", "支付，退款、对账; 风控", "支付,退款,对账,风控"},
+		{"去重忽略大小写", "API, api ,Api", "API"},
+		{"空输入", "  ，、 ", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeGroupKeywords(tt.raw); got != tt.want {
+				t.Errorf("NormalizeGroupKeywords(%q) = %q, want %q", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChatGroupMetadataContext(t *testing.T) {
+	tests := []struct {
+		name     string
+		project  string
+		keywords string
+		want     string
+	}{
+		{"项目和关键词", "支付中台", "支付,退款", "这是「支付中台」项目群，相关关键词：支付、退款"},
+		{"仅项目", "支付中台", "", "这是「支付中台」项目群"},
+		{"仅关键词", "", "支付", "本群相关关键词：支付"},
+		{"未设置", "", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &ChatGroup{Project: nullIfEmpty(tt.project), Keywords: nullIfEmpty(tt.keywords)}
+			if got := g.MetadataContext(); got != tt.want {
+				t.Errorf("MetadataContext() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDistinctSendersQuery(t *testing.T) {
+	tests := []struct {
+		name         string
+		chatID       string
+		excludeEmpty bool
+		wantWhere    string
+		wantArgs     int
+	}{
+		{"指定群并排除空名", "oc_a", true, " WHERE chat_id = ? AND sender_name IS NOT NULL AND sender_name != ''", 1},
+		{"指定群保留空名", "oc_a", false, " WHERE chat_id = ?", 1},
+		{"所有群排除空名", "", true, " WHERE sender_name IS NOT NULL AND sender_name != ''", 0},
+		{"所有群保留空名", "", false, "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args := distinctSendersQuery(tt.chatID, tt.excludeEmpty)
+			want := "SELECT DISTINCT COALESCE(sender_name, '') AS name FROM chat_messages" + tt.wantWhere + " ORDER BY name"
+			if query != want {
+				t.Errorf("query = %q, want %q", query, want)
+			}
+			if len(args) != tt.wantArgs {
+				t.Errorf("Expected %d args, got %d", tt.wantArgs, len(args))
+			}
+		})
+	}
+}
+
+func TestKeywordHitsQuery(t *testing.T) {
+	since := time.Date(2025, 1, 1, 0, 0, 0, 0, time.Local)
+	query, args := keywordHitsQuery([]string{"支付", "退款"}, since)
+
+	want := "SELECT chat_id, COALESCE(SUM(content LIKE ?), 0), COALESCE(SUM(content LIKE ?), 0) FROM chat_messages" +
+		" WHERE created_at >= ? AND (content LIKE ? OR content LIKE ?) GROUP BY chat_id"
+	if query != want {
+		t.Errorf("query = %q, want %q", query, want)
+	}
+	wantArgs := []interface{}{"%支付%", "%退款%", since, "%支付%", "%退款%"}
+	if len(args) != len(wantArgs) {
+		t.Fatalf("Expected %d args, got %d", len(wantArgs), len(args))
+	}
+	for i := range args {
+		if args[i] != wantArgs[i] {
+			t.Errorf("args[%d] = %v, want %v", i, args[i], wantArgs[i])
+		}
+	}
+}
+
+func TestSearchInRangeQuery(t *testing.T) {
+	start := time.Date(2025, 3, 3, 0, 0, 0, 0, time.Local)
+	end := time.Date(2025, 3, 9, 23, 59, 59, 0, time.Local)
+	rangeCond := "COALESCE(created_at_ts, UNIX_TIMESTAMP(created_at)*1000) BETWEEN ? AND ?"
+
+	tests := []struct {
+		name      string
+		chatID    string
+		keywords  []string
+		wantWhere string
+		wantArgs  []interface{}
+	}{
+		{"指定群单关键词", "oc_a", []string{"支付"},
+			"chat_id = ? AND " + rangeCond + " AND content LIKE ?",
+			[]interface{}{"oc_a", start.UnixMilli(), end.UnixMilli(), "%支付%", 20}},
+		{"所有群多关键词", "", []string{"支付", "退款"},
+			rangeCond + " AND content LIKE ? AND content LIKE ?",
+			[]interface{}{start.UnixMilli(), end.UnixMilli(), "%支付%", "%退款%", 20}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args := searchInRangeQuery(tt.chatID, tt.keywords, start, end, 20)
+			if !strings.Contains(query, "WHERE "+tt.wantWhere+"\n") {
+				t.Errorf("query = %q, want WHERE %q", query, tt.wantWhere)
+			}
+			if !strings.HasSuffix(query, "DESC LIMIT ?") {
+				t.Errorf("query should order by time and limit: %q", query)
+			}
+			if len(args) != len(tt.wantArgs) {
+				t.Fatalf("args = %v, want %v", args, tt.wantArgs)
+			}
+			for i := range args {
+				if args[i] != tt.wantArgs[i] {
+					t.Errorf("args[%d] = %v, want %v", i, args[i], tt.wantArgs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSearchKeywordCombinations(t *testing.T) {
+	msg := func(id int64) *ChatMessage { return &ChatMessage{ID: id} }
+	var allCalls [][]string
+	var oneCalls []string
+	searchAll := func(kws []string, limit int) ([]*ChatMessage, error) {
+		allCalls = append(allCalls, kws)
+		if len(kws) == 3 {
+			return []*ChatMessage{msg(1)}, nil
+		}
+		if kws[0] == "支付" && kws[1] == "退款" {
+			return []*ChatMessage{msg(1), msg(2)}, nil
+		}
+		return nil, nil
+	}
+	searchOne := func(kw string, limit int) ([]*ChatMessage, error) {
+		oneCalls = append(oneCalls, kw)
+		if kw == "对账" {
+			return []*ChatMessage{msg(2), msg(3)}, nil
+		}
+		return nil, nil
+	}
+
+	messages, counts, err := searchKeywordCombinations([]string{"支付", "退款", "对账"}, 10, searchAll, searchOne)
+	if err != nil {
+		t.Fatalf("searchKeywordCombinations() error: %v", err)
+	}
+	if len(messages) != 3 {
+		t.Fatalf("Expected 3 messages, got %d", len(messages))
+	}
+	wantCounts := map[int64]int{1: 3, 2: 2, 3: 1}
+	for id, want := range wantCounts {
+		if counts[id] != want {
+			t.Errorf("matchCount[%d] = %d, want %d", id, counts[id], want)
+		}
+	}
+	if len(allCalls) != 4 || len(oneCalls) != 3 {
+		t.Errorf("Expected 4 AND searches and 3 single searches, got %v and %v", allCalls, oneCalls)
+	}
+
+	if messages, _, _ := searchKeywordCombinations(nil, 10, searchAll, searchOne); messages != nil {
+		t.Errorf("Expected no messages without keywords, got %d", len(messages))
+	}
+}
+
+func TestActiveSendersQuery(t *testing.T) {
+	since := time.Date(2025, 1, 1, 0, 0, 0, 0, time.Local)
+	tests := []struct {
+		name      string
+		chatID    string
+		wantWhere string
+		wantArgs  []interface{}
+	}{
+		{"指定群", "oc_a", "chat_id = ? AND created_at >= ? AND sender_name IS NOT NULL AND sender_name != ''", []interface{}{"oc_a", since, 30}},
+		{"所有群", "", "created_at >= ? AND sender_name IS NOT NULL AND sender_name != ''", []interface{}{since, 30}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args := activeSendersQuery(tt.chatID, since, 30)
+			want := "SELECT sender_name, COUNT(*) AS cnt, MAX(created_at) AS last_at FROM chat_messages" +
+				" WHERE " + tt.wantWhere + " GROUP BY sender_name ORDER BY cnt DESC, last_at DESC LIMIT ?"
+			if query != want {
+				t.Errorf("query = %q, want %q", query, want)
+			}
+			if len(args) != len(tt.wantArgs) {
+				t.Fatalf("args = %v, want %v", args, tt.wantArgs)
+			}
+			for i := range args {
+				if args[i] != tt.wantArgs[i] {
+					t.Errorf("args[%d] = %v, want %v", i, args[i], tt.wantArgs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRankSendersQuery(t *testing.T) {
+	start := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 7)
+
+	query, args := rankSendersQuery("oc_a", start, end, 3)
+	want := "SELECT sender_name, COUNT(*) AS cnt, MAX(created_at) AS last_at FROM chat_messages" +
+		" WHERE chat_id = ? AND created_at BETWEEN ? AND ? AND sender_name IS NOT NULL AND sender_name != ''" +
+		" GROUP BY sender_name ORDER BY cnt DESC, last_at DESC LIMIT ?"
+	if query != want {
+		t.Errorf("query = %q, want %q", query, want)
+	}
+	wantArgs := []interface{}{"oc_a", start, end, 3}
+	if len(args) != len(wantArgs) {
+		t.Fatalf("args = %v, want %v", args, wantArgs)
+	}
+	for i := range args {
+		if args[i] != wantArgs[i] {
+			t.Errorf("args[%d] = %v, want %v", i, args[i], wantArgs[i])
+		}
+	}
+
+	if query, _ := rankSendersQuery("", start, end, 3); strings.Contains(query, "chat_id") {
+		t.Errorf("Expected no chat filter for all groups, got %q", query)
+	}
+}
+
+func TestInsertArgsRawContent(t *testing.T) {
+	msg := &ChatMessage{
+		MessageID:  "om_1",
+		ChatID:     "oc_1",
+		Content:    sql.NullString{String: "登录超时已修复", Valid: true},
+		RawContent: sql.NullString{String: `{"text":"登录超时已修复"}`, Valid: true},
+	}
+
+	tests := []struct {
+		name string
+		keep bool
+		want sql.NullString
+	}{
+		{"默认保存原始内容", true, msg.RawContent},
+		{"关闭后写入 NULL", false, sql.NullString{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewChatMessageModel(nil)
+			if !tt.keep {
+				m.SetKeepRawContent(false)
+			}
+			args := m.insertArgs(msg)
+			if len(args) != 16 {
+				t.Fatalf("insertArgs() returned %d args, want 16", len(args))
+			}
+			if got := args[7]; got != tt.want {
+				t.Errorf("raw_content arg = %v, want %v", got, tt.want)
+			}
+			if got := args[6]; got != msg.Content {
+				t.Errorf("content arg = %v, want %v", got, msg.Content)
+			}
+		})
+	}
+}
+
+func TestSyncTaskListQuery(t *testing.T) {
+	tests := []struct {
+		name      string
+		column    string
+		value     string
+		wantWhere string
+		wantArgs  []interface{}
+	}{
+		{"不过滤", "", "", "", []interface{}{10}},
+		{"按状态", "status", "failed", " WHERE status = ?", []interface{}{"failed", 10}},
+		{"按群", "chat_id", "oc_a", " WHERE chat_id = ?", []interface{}{"oc_a", 10}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args := syncTaskListQuery(tt.column, tt.value, 10)
+			if !strings.HasSuffix(query, "FROM message_sync_tasks"+tt.wantWhere+" ORDER BY created_at DESC LIMIT ?") {
+				t.Errorf("Unexpected query: %q", query)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestMessageUpsertMergePrecedence(t *testing.T) {
+	query := messageUpsertQuery()
+	idx := strings.Index(query, "ON DUPLICATE KEY UPDATE ")
+	if idx == -1 {
+		t.Fatalf("Expected ON DUPLICATE KEY UPDATE in query: %q", query)
+	}
+	update := query[idx:]
+
+	tests := []struct {
+		name   string
+		column string
+		want   string
+	}{
+		{"新发言人为空保留原值", "sender_name", "VALUES(sender_name) IS NULL OR VALUES(sender_name) = ''"},
+		{"新发言人只是ID保留原值", "sender_name", "VALUES(sender_name) = sender_id, sender_name, VALUES(sender_name)"},
+		{"保留更长的内容", "content", "CHAR_LENGTH(COALESCE(VALUES(content), '')) >= CHAR_LENGTH(COALESCE(content, '')), VALUES(content), content"},
+		{"成员ID为空保留原值", "member_id", "COALESCE(VALUES(member_id), member_id)"},
+		{"原始内容为空保留原值", "raw_content", "COALESCE(VALUES(raw_content), raw_content)"},
+		{"话题ID为空保留原值", "thread_id", "COALESCE(VALUES(thread_id), thread_id)"},
+		{"毫秒时间戳为空保留原值", "created_at_ts", "COALESCE(VALUES(created_at_ts), created_at_ts)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := ""
+			for _, r := range messageMergeRules {
+				if r.column == tt.column {
+					rule = r.expr
+				}
+			}
+			if !strings.Contains(rule, tt.want) {
+				t.Errorf("merge rule for %s = %q, want it to contain %q", tt.column, rule, tt.want)
+			}
+			if !strings.Contains(update, tt.column+" = "+rule) {
+				t.Errorf("Expected %s rule in update clause: %q", tt.column, update)
+			}
+		})
+	}
+
+	// 消息身份和发送时间不参与合并
+	for _, column := range []string{"message_id", "chat_id", "sender_id", "created_at"} {
+		if strings.Contains(update, " "+column+" = ") {
+			t.Errorf("Column %s should not be updated on duplicate: %q", column, update)
+		}
+	}
+}
+
+func TestUpsertResultFromRows(t *testing.T) {
+	tests := []struct {
+		name     string
+		affected int64
+		want     UpsertResult
+	}{
+		{"新消息", 1, UpsertInserted},
+		{"已存在并合并更新", 2, UpsertUpdated},
+		{"已存在且没有变化", 0, UpsertUnchanged},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := upsertResultFromRows(tt.affected); got != tt.want {
+				t.Errorf("upsertResultFromRows(%d) = %v, want %v", tt.affected, got, tt.want)
+			}
+		})
+	}
+}
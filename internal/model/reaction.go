@@ -0,0 +1,79 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ======================== 表情回复 ========================
+
+// AddReaction 记录一次表情回复并刷新消息的回复数（同一用户同一表情只记一次，事件重推不会重复计数）
+func (m *ChatMessageModel) AddReaction(ctx context.Context, messageID, operatorID, emojiType string) error {
+	query := `INSERT IGNORE INTO chat_message_reactions (message_id, operator_id, emoji_type) VALUES (?, ?, ?)`
+	if _, err := m.db.ExecContext(ctx, query, messageID, operatorID, emojiType); err != nil {
+		return fmt.Errorf("insert reaction: %w", err)
+	}
+	return m.refreshReactionCount(ctx, messageID)
+}
+
+// RemoveReaction 删除一次表情回复并刷新消息的回复数
+func (m *ChatMessageModel) RemoveReaction(ctx context.Context, messageID, operatorID, emojiType string) error {
+	query := `DELETE FROM chat_message_reactions WHERE message_id = ? AND operator_id = ? AND emoji_type = ?`
+	if _, err := m.db.ExecContext(ctx, query, messageID, operatorID, emojiType); err != nil {
+		return fmt.Errorf("delete reaction: %w", err)
+	}
+	return m.refreshReactionCount(ctx, messageID)
+}
+
+// refreshReactionCount 按回复明细重新计算消息的表情回复数（消息尚未入库时不更新，同步入库后由下一次回复事件补齐）
+func (m *ChatMessageModel) refreshReactionCount(ctx context.Context, messageID string) error {
+	query := `UPDATE chat_messages SET reaction_count =
+              (SELECT COUNT(*) FROM chat_message_reactions WHERE message_id = ?)
+              WHERE message_id = ?`
+	if _, err := m.db.ExecContext(ctx, query, messageID, messageID); err != nil {
+		return fmt.Errorf("update reaction count: %w", err)
+	}
+	return nil
+}
+
+// TopReactedSince 查询 since 之后表情回复最多的消息（回复数相同按时间倒序），chatID 为空时查询所有群
+func (m *ChatMessageModel) TopReactedSince(ctx context.Context, chatID string, since time.Time, limit int) ([]*ChatMessage, error) {
+	query, args := topReactedQuery(chatID, since, limit)
+	rows, err := m.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []*ChatMessage
+	for rows.Next() {
+		var msg ChatMessage
+		err := rows.Scan(&msg.ID, &msg.MessageID, &msg.ChatID, &msg.SenderID, &msg.SenderName,
+			&msg.MemberID, &msg.MsgType, &msg.Content, &msg.RawContent, &msg.Mentions,
+			&msg.ReplyToID, &msg.ThreadID, &msg.RootID, &msg.IsAtBot, &msg.IsForwarded, &msg.CreatedAt, &msg.CreatedAtTs, &msg.IndexedAt,
+			&msg.ReactionCount)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, &msg)
+	}
+	return messages, rows.Err()
+}
+
+// topReactedQuery 构建表情回复排行查询
+func topReactedQuery(chatID string, since time.Time, limit int) (string, []interface{}) {
+	query := `SELECT id, message_id, chat_id, sender_id, sender_name, member_id, msg_type,
+              content, raw_content, mentions, reply_to_id, thread_id, root_id, is_at_bot, is_forwarded, created_at, created_at_ts, indexed_at,
+              reaction_count
+              FROM chat_messages
+              WHERE reaction_count > 0 AND created_at >= ?`
+	args := []interface{}{since}
+	if chatID != "" {
+		query += " AND chat_id = ?"
+		args = append(args, chatID)
+	}
+	query += " ORDER BY reaction_count DESC, created_at DESC LIMIT ?"
+	args = append(args, limit)
+	return query, args
+}
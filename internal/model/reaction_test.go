@@ -0,0 +1,36 @@
+package model
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTopReactedQuery(t *testing.T) {
+	since := time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name       string
+		chatID     string
+		wantFilter bool
+		wantArgs   int
+	}{
+		{"限定群", "oc_dev", true, 3},
+		{"所有群", "", false, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args := topReactedQuery(tt.chatID, since, 10)
+			if strings.Contains(query, "chat_id = ?") != tt.wantFilter || len(args) != tt.wantArgs {
+				t.Errorf("Unexpected query %q with args %v", query, args)
+			}
+			if args[0] != since || args[len(args)-1] != 10 {
+				t.Errorf("Expected since first and limit last, got %v", args)
+			}
+			if !strings.Contains(query, "reaction_count > 0") || !strings.Contains(query, "ORDER BY reaction_count DESC") {
+				t.Errorf("Expected ordering by reaction count, got %q", query)
+			}
+		})
+	}
+}
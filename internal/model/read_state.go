@@ -0,0 +1,47 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// UserReadState 用户在某个群的已读状态
+type UserReadState struct {
+	ID         int64     `db:"id"`
+	UserID     string    `db:"user_id"`
+	ChatID     string    `db:"chat_id"`
+	LastReadAt time.Time `db:"last_read_at"`
+	CreatedAt  time.Time `db:"created_at"`
+	UpdatedAt  time.Time `db:"updated_at"`
+}
+
+type UserReadStateModel struct {
+	db *sql.DB
+}
+
+func NewUserReadStateModel(db *sql.DB) *UserReadStateModel {
+	return &UserReadStateModel{db: db}
+}
+
+// Get 获取用户在群内的已读状态，不存在时返回 sql.ErrNoRows
+func (m *UserReadStateModel) Get(ctx context.Context, userID, chatID string) (*UserReadState, error) {
+	query := `SELECT id, user_id, chat_id, last_read_at, created_at, updated_at
+              FROM user_read_state WHERE user_id = ? AND chat_id = ?`
+	var state UserReadState
+	err := m.db.QueryRowContext(ctx, query, userID, chatID).Scan(
+		&state.ID, &state.UserID, &state.ChatID, &state.LastReadAt, &state.CreatedAt, &state.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// Upsert 更新用户在群内的已读时间
+func (m *UserReadStateModel) Upsert(ctx context.Context, userID, chatID string, lastReadAt time.Time) error {
+	query := `INSERT INTO user_read_state (user_id, chat_id, last_read_at)
+              VALUES (?, ?, ?)
+              ON DUPLICATE KEY UPDATE last_read_at = VALUES(last_read_at)`
+	_, err := m.db.ExecContext(ctx, query, userID, chatID, lastReadAt)
+	return err
+}
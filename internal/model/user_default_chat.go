@@ -0,0 +1,60 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// UserDefaultChat 用户的默认群，私聊提问时默认只检索该群
+type UserDefaultChat struct {
+	ID        int64     `db:"id"`
+	UserID    string    `db:"user_id"`
+	ChatID    string    `db:"chat_id"`
+	ChatName  string    `db:"chat_name"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+type UserDefaultChatModel struct {
+	db *sql.DB
+}
+
+func NewUserDefaultChatModel(db *sql.DB) *UserDefaultChatModel {
+	return &UserDefaultChatModel{db: db}
+}
+
+// Get 获取用户的默认群，未设置时返回 sql.ErrNoRows
+func (m *UserDefaultChatModel) Get(ctx context.Context, userID string) (*UserDefaultChat, error) {
+	query := `SELECT id, user_id, chat_id, COALESCE(chat_name, ''), created_at, updated_at
+              FROM user_default_chat WHERE user_id = ?`
+	var c UserDefaultChat
+	err := m.db.QueryRowContext(ctx, query, userID).Scan(
+		&c.ID, &c.UserID, &c.ChatID, &c.ChatName, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Set 设置用户的默认群，已设置时覆盖
+func (m *UserDefaultChatModel) Set(ctx context.Context, userID, chatID, chatName string) error {
+	query := `INSERT INTO user_default_chat (user_id, chat_id, chat_name)
+              VALUES (?, ?, ?)
+              ON DUPLICATE KEY UPDATE chat_id = VALUES(chat_id), chat_name = VALUES(chat_name)`
+	_, err := m.db.ExecContext(ctx, query, userID, chatID, chatName)
+	return err
+}
+
+// Clear 清除用户的默认群，返回是否删除了记录
+func (m *UserDefaultChatModel) Clear(ctx context.Context, userID string) (bool, error) {
+	result, err := m.db.ExecContext(ctx, `DELETE FROM user_default_chat WHERE user_id = ?`, userID)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
@@ -0,0 +1,102 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// WeeklySummaryRecord 持久化的群周总结
+type WeeklySummaryRecord struct {
+	ID           int64     `db:"id"`
+	ChatID       string    `db:"chat_id"`
+	WeekStart    time.Time `db:"week_start"`
+	WeekEnd      time.Time `db:"week_end"`
+	Summary      string    `db:"summary"`
+	MainTopics   []string  `db:"main_topics"`
+	Decisions    []string  `db:"decisions"`
+	Milestones   []string  `db:"milestones"`
+	Participants []string  `db:"participants"`
+	MessageCount int       `db:"message_count"`
+	Complete     bool      `db:"complete"` // 周已结束，之后不再重新生成
+	UpdatedAt    time.Time `db:"updated_at"`
+}
+
+type WeeklySummaryModel struct {
+	db *sql.DB
+}
+
+func NewWeeklySummaryModel(db *sql.DB) *WeeklySummaryModel {
+	return &WeeklySummaryModel{db: db}
+}
+
+// Upsert 保存周总结（同一群同一周覆盖，用于未结束的周在下次查询时更新）
+func (m *WeeklySummaryModel) Upsert(ctx context.Context, s *WeeklySummaryRecord) error {
+	query := `INSERT INTO chat_weekly_summaries (chat_id, week_start, week_end, summary, main_topics, decisions,
+              milestones, participants, message_count, complete)
+              VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+              ON DUPLICATE KEY UPDATE week_end = VALUES(week_end), summary = VALUES(summary),
+              main_topics = VALUES(main_topics), decisions = VALUES(decisions), milestones = VALUES(milestones),
+              participants = VALUES(participants), message_count = VALUES(message_count), complete = VALUES(complete)`
+	_, err := m.db.ExecContext(ctx, query, weeklySummaryArgs(s)...)
+	return err
+}
+
+// weeklySummaryArgs Upsert 的参数（字符串列表存为 JSON 数组）
+func weeklySummaryArgs(s *WeeklySummaryRecord) []interface{} {
+	return []interface{}{
+		s.ChatID, s.WeekStart, s.WeekEnd, s.Summary,
+		encodeStringList(s.MainTopics), encodeStringList(s.Decisions),
+		encodeStringList(s.Milestones), encodeStringList(s.Participants),
+		s.MessageCount, s.Complete,
+	}
+}
+
+// ListSince 查询群 since 之后开始的周总结，按周开始时间正序
+func (m *WeeklySummaryModel) ListSince(ctx context.Context, chatID string, since time.Time) ([]*WeeklySummaryRecord, error) {
+	query := `SELECT id, chat_id, week_start, week_end, COALESCE(summary, ''), main_topics, decisions, milestones,
+              participants, message_count, complete, updated_at
+              FROM chat_weekly_summaries
+              WHERE chat_id = ? AND week_start >= ?
+              ORDER BY week_start ASC`
+	rows, err := m.db.QueryContext(ctx, query, chatID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []*WeeklySummaryRecord
+	for rows.Next() {
+		var r WeeklySummaryRecord
+		var topics, decisions, milestones, participants []byte
+		if err := rows.Scan(&r.ID, &r.ChatID, &r.WeekStart, &r.WeekEnd, &r.Summary, &topics, &decisions,
+			&milestones, &participants, &r.MessageCount, &r.Complete, &r.UpdatedAt); err != nil {
+			return nil, err
+		}
+		r.MainTopics = decodeStringList(topics)
+		r.Decisions = decodeStringList(decisions)
+		r.Milestones = decodeStringList(milestones)
+		r.Participants = decodeStringList(participants)
+		records = append(records, &r)
+	}
+	return records, rows.Err()
+}
+
+// encodeStringList 字符串列表转为 JSON 数组（nil 存为 []）
+func encodeStringList(list []string) string {
+	if list == nil {
+		list = []string{}
+	}
+	data, _ := json.Marshal(list)
+	return string(data)
+}
+
+// decodeStringList 解析 JSON 数组，为空或格式错误时返回 nil
+func decodeStringList(data []byte) []string {
+	var list []string
+	if len(data) == 0 || json.Unmarshal(data, &list) != nil || len(list) == 0 {
+		return nil
+	}
+	return list
+}
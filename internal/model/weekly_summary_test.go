@@ -0,0 +1,60 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStringListRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		list    []string
+		encoded string
+	}{
+		{"普通列表", []string{"上线支付回调", "切换机房"}, `["上线支付回调","切换机房"]`},
+		{"空列表", nil, `[]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded := encodeStringList(tt.list)
+			if encoded != tt.encoded {
+				t.Errorf("encodeStringList() = %s, want %s", encoded, tt.encoded)
+			}
+			if got := decodeStringList([]byte(encoded)); !reflect.DeepEqual(got, tt.list) {
+				t.Errorf("decodeStringList() = %v, want %v", got, tt.list)
+			}
+		})
+	}
+}
+
+func TestDecodeStringListInvalid(t *testing.T) {
+	for _, data := range [][]byte{nil, []byte("null"), []byte("not json")} {
+		if got := decodeStringList(data); got != nil {
+			t.Errorf("decodeStringList(%q) = %v, want nil", data, got)
+		}
+	}
+}
+
+func TestWeeklySummaryArgs(t *testing.T) {
+	weekStart := time.Date(2024, 3, 4, 0, 0, 0, 0, time.Local)
+	args := weeklySummaryArgs(&WeeklySummaryRecord{
+		ChatID:       "oc_dev",
+		WeekStart:    weekStart,
+		WeekEnd:      weekStart.AddDate(0, 0, 7),
+		Summary:      "完成支付改造",
+		Decisions:    []string{"周五发版"},
+		Participants: []string{"张三"},
+		MessageCount: 42,
+		Complete:     true,
+	})
+
+	want := []interface{}{
+		"oc_dev", weekStart, weekStart.AddDate(0, 0, 7), "完成支付改造",
+		`[]`, `["周五发版"]`, `[]`, `["张三"]`, 42, true,
+	}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("weeklySummaryArgs() = %v, want %v", args, want)
+	}
+}
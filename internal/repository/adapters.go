@@ -65,8 +65,8 @@ func (a *CommitRepositoryAdapter) GetAllStats(ctx context.Context, start, end ti
 	return a.model.GetAllStats(ctx, start, end)
 }
 
-func (a *CommitRepositoryAdapter) GetRecentCommits(ctx context.Context, memberID int64, limit int) ([]*model.GitCommit, error) {
-	return a.model.GetRecentCommits(ctx, memberID, limit)
+func (a *CommitRepositoryAdapter) GetRecentCommits(ctx context.Context, memberID int64, start, end time.Time, limit int) ([]*model.GitCommit, error) {
+	return a.model.GetRecentCommits(ctx, memberID, start, end, limit)
 }
 
 func (a *CommitRepositoryAdapter) GetCommitsByDateRange(ctx context.Context, authorName string, start, end time.Time, limit int) ([]*model.GitCommit, error) {
@@ -127,6 +127,10 @@ func (a *GroupRepositoryAdapter) ListAll(ctx context.Context) ([]*model.ChatGrou
 	return a.model.ListAll(ctx)
 }
 
+func (a *GroupRepositoryAdapter) UpdateMetadata(ctx context.Context, chatID, project, keywords string) error {
+	return a.model.UpdateMetadata(ctx, chatID, project, keywords)
+}
+
 // SyncTaskRepositoryAdapter 同步任务仓库适配器
 type SyncTaskRepositoryAdapter struct {
 	model *model.MessageSyncTaskModel
@@ -152,12 +156,20 @@ func (a *SyncTaskRepositoryAdapter) GetRecentTasks(ctx context.Context, limit in
 	return a.model.GetRecentTasks(ctx, limit)
 }
 
+func (a *SyncTaskRepositoryAdapter) ListByStatus(ctx context.Context, status string, limit int) ([]*model.MessageSyncTask, error) {
+	return a.model.ListByStatus(ctx, status, limit)
+}
+
+func (a *SyncTaskRepositoryAdapter) ListByChat(ctx context.Context, chatID string, limit int) ([]*model.MessageSyncTask, error) {
+	return a.model.ListByChat(ctx, chatID, limit)
+}
+
 func (a *SyncTaskRepositoryAdapter) UpdateStatus(ctx context.Context, id int64, status string) error {
 	return a.model.UpdateStatus(ctx, id, status)
 }
 
-func (a *SyncTaskRepositoryAdapter) UpdateProgress(ctx context.Context, id int64, syncedMessages int, pageToken string) error {
-	return a.model.UpdateProgress(ctx, id, syncedMessages, pageToken)
+func (a *SyncTaskRepositoryAdapter) UpdateProgress(ctx context.Context, id int64, syncedMessages int, pageToken, resumeTime string) error {
+	return a.model.UpdateProgress(ctx, id, syncedMessages, pageToken, resumeTime)
 }
 
 func (a *SyncTaskRepositoryAdapter) MarkStarted(ctx context.Context, id int64) error {
@@ -171,3 +183,20 @@ func (a *SyncTaskRepositoryAdapter) MarkCompleted(ctx context.Context, id int64,
 func (a *SyncTaskRepositoryAdapter) MarkFailed(ctx context.Context, id int64, errMsg string) error {
 	return a.model.MarkFailed(ctx, id, errMsg)
 }
+
+// ReadStateRepositoryAdapter 已读状态仓库适配器
+type ReadStateRepositoryAdapter struct {
+	model *model.UserReadStateModel
+}
+
+func NewReadStateRepositoryAdapter(m *model.UserReadStateModel) *ReadStateRepositoryAdapter {
+	return &ReadStateRepositoryAdapter{model: m}
+}
+
+func (a *ReadStateRepositoryAdapter) Get(ctx context.Context, userID, chatID string) (*model.UserReadState, error) {
+	return a.model.Get(ctx, userID, chatID)
+}
+
+func (a *ReadStateRepositoryAdapter) Upsert(ctx context.Context, userID, chatID string, lastReadAt time.Time) error {
+	return a.model.Upsert(ctx, userID, chatID, lastReadAt)
+}
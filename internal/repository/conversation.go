@@ -10,6 +10,7 @@ import (
 const (
 	conversationKeyPrefix = "conversation:"
 	defaultConversationTTL = 24 * time.Hour // 对话默认保留24小时
+	conversationContextKeyPrefix = "conversation_context:" // 追问上下文（上一轮问题和回答）
 )
 
 // ConversationRepository 对话存储（Redis 实现）
@@ -55,3 +56,25 @@ func (r *ConversationRepository) ExtendConversationTTL(ctx context.Context, user
 	key := conversationKeyPrefix + userID
 	return r.redis.Expire(ctx, key, ttl).Err()
 }
+
+// GetContext 获取用户的追问上下文（JSON），不存在时返回 nil
+func (r *ConversationRepository) GetContext(ctx context.Context, userID string) ([]byte, error) {
+	key := conversationContextKeyPrefix + userID
+	val, err := r.redis.Get(ctx, key).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	return val, err
+}
+
+// SaveContext 保存用户的追问上下文（JSON），过期后自动删除
+func (r *ConversationRepository) SaveContext(ctx context.Context, userID string, data []byte, ttl time.Duration) error {
+	key := conversationContextKeyPrefix + userID
+	return r.redis.Set(ctx, key, data, ttl).Err()
+}
+
+// DeleteContext 删除用户的追问上下文
+func (r *ConversationRepository) DeleteContext(ctx context.Context, userID string) error {
+	key := conversationContextKeyPrefix + userID
+	return r.redis.Del(ctx, key).Err()
+}
@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// indexVersionKey 向量索引版本号，重建索引后递增
+const indexVersionKey = "rag:index_version"
+
+// IndexVersionRepository 向量索引版本（Redis 实现）
+// reindex 等独立进程重建索引后递增版本，服务进程读取到新版本时刷新集合统计缓存
+type IndexVersionRepository struct {
+	redis *redis.Client
+}
+
+// NewIndexVersionRepository 创建索引版本存储
+func NewIndexVersionRepository(rdb *redis.Client) *IndexVersionRepository {
+	return &IndexVersionRepository{redis: rdb}
+}
+
+// Version 获取当前索引版本，从未重建过时返回空
+func (r *IndexVersionRepository) Version(ctx context.Context) (string, error) {
+	val, err := r.redis.Get(ctx, indexVersionKey).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return val, err
+}
+
+// Bump 递增索引版本
+func (r *IndexVersionRepository) Bump(ctx context.Context) error {
+	return r.redis.Incr(ctx, indexVersionKey).Err()
+}
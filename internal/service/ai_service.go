@@ -30,6 +30,7 @@ type AIService struct {
 	useDify       bool
 	datasetID     string
 	memoryManager *memory.MemoryManager // 永久记忆管理器
+	maxHistoryLen int                   // 传给 Dify 的对话历史最大字符数
 }
 
 // NewAIService 创建 AI 服务
@@ -44,14 +45,15 @@ func NewAIService(
 	datasetID string,
 ) *AIService {
 	return &AIService{
-		commitRepo:  commitRepo,
-		messageRepo: messageRepo,
-		memberRepo:  memberRepo,
-		convRepo:    convRepo,
-		llmClient:   llmClient,
-		difyClient:  difyClient,
-		useDify:     useDify,
-		datasetID:   datasetID,
+		commitRepo:    commitRepo,
+		messageRepo:   messageRepo,
+		memberRepo:    memberRepo,
+		convRepo:      convRepo,
+		llmClient:     llmClient,
+		difyClient:    difyClient,
+		useDify:       useDify,
+		datasetID:     datasetID,
+		maxHistoryLen: defaultMaxHistoryLength,
 	}
 }
 
@@ -369,7 +371,7 @@ func (s *AIService) processWithDify(ctx context.Context, userID, query, conversa
 			"git_stats":            contextData.GitStats,
 			"recent_messages":      contextData.RecentMessages,
 			"knowledge_context":    knowledgeContext,
-			"conversation_history": conversationHistory, // 添加永久记忆上下文
+			"conversation_history": truncateHistory(conversationHistory, s.maxHistoryLen), // 添加永久记忆上下文（只保留最近的对话）
 			"current_time":         time.Now().Format("2006-01-02 15:04:05"),
 		},
 	}
@@ -692,6 +694,9 @@ func (s *AIService) getTimeRange(tr llm.TimeRange) (time.Time, time.Time) {
 		thisMonthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
 		lastMonthStart := thisMonthStart.AddDate(0, -1, 0)
 		return lastMonthStart, thisMonthStart
+	case llm.TimeRangeThisQuarter, llm.TimeRangeLastQuarter, llm.TimeRangeThisYear, llm.TimeRangeLastYear:
+		start, end, _ := llm.CoarseTimeRange(tr, now)
+		return start, end
 	default:
 		// 默认查询最近3年的消息
 		return today.AddDate(-3, 0, 0), now
@@ -2,11 +2,14 @@ package service
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
 
 	"team-assistant/internal/interfaces"
+	"team-assistant/internal/model"
 	"team-assistant/pkg/lark"
 )
 
@@ -71,6 +74,51 @@ func (s *ChatService) FormatChatList(chats []*lark.ChatInfo) string {
 	return sb.String()
 }
 
+// GetGroupMetadata 获取群的项目/关键词信息，群记录不存在时返回空信息
+func (s *ChatService) GetGroupMetadata(ctx context.Context, chatID string) (*model.ChatGroup, error) {
+	group, err := s.groupRepo.FindByChatID(ctx, chatID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &model.ChatGroup{ChatID: chatID}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group: %w", err)
+	}
+	return group, nil
+}
+
+// SetGroupProject 设置群所属项目，保留已有关键词
+func (s *ChatService) SetGroupProject(ctx context.Context, chatID, project string) error {
+	group, err := s.GetGroupMetadata(ctx, chatID)
+	if err != nil {
+		return err
+	}
+	if err := s.groupRepo.UpdateMetadata(ctx, chatID, project, group.Keywords.String); err != nil {
+		return fmt.Errorf("failed to update group project: %w", err)
+	}
+	return nil
+}
+
+// SetGroupKeywords 设置群关键词，保留已有项目，返回规范化后的关键词
+func (s *ChatService) SetGroupKeywords(ctx context.Context, chatID, raw string) (string, error) {
+	group, err := s.GetGroupMetadata(ctx, chatID)
+	if err != nil {
+		return "", err
+	}
+	keywords := model.NormalizeGroupKeywords(raw)
+	if err := s.groupRepo.UpdateMetadata(ctx, chatID, group.Project.String, keywords); err != nil {
+		return "", fmt.Errorf("failed to update group keywords: %w", err)
+	}
+	return keywords, nil
+}
+
+// ClearGroupMetadata 清除群的项目和关键词
+func (s *ChatService) ClearGroupMetadata(ctx context.Context, chatID string) error {
+	if err := s.groupRepo.UpdateMetadata(ctx, chatID, "", ""); err != nil {
+		return fmt.Errorf("failed to clear group metadata: %w", err)
+	}
+	return nil
+}
+
 // ReplyMessage 回复消息
 func (s *ChatService) ReplyMessage(ctx context.Context, messageID, msgType, content string) error {
 	if err := s.larkClient.ReplyMessage(ctx, messageID, msgType, content); err != nil {
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"team-assistant/internal/model"
+)
+
+// fakeGroupRepo 内存版群聊仓库
+type fakeGroupRepo struct {
+	groups map[string]*model.ChatGroup
+}
+
+func newFakeGroupRepo() *fakeGroupRepo {
+	return &fakeGroupRepo{groups: make(map[string]*model.ChatGroup)}
+}
+
+func (r *fakeGroupRepo) Upsert(ctx context.Context, group *model.ChatGroup) error {
+	r.groups[group.ChatID] = group
+	return nil
+}
+
+func (r *fakeGroupRepo) FindByChatID(ctx context.Context, chatID string) (*model.ChatGroup, error) {
+	group, ok := r.groups[chatID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	copied := *group
+	return &copied, nil
+}
+
+func (r *fakeGroupRepo) ListAll(ctx context.Context) ([]*model.ChatGroup, error) {
+	var groups []*model.ChatGroup
+	for _, g := range r.groups {
+		groups = append(groups, g)
+	}
+	return groups, nil
+}
+
+func (r *fakeGroupRepo) UpdateMetadata(ctx context.Context, chatID, project, keywords string) error {
+	group, ok := r.groups[chatID]
+	if !ok {
+		group = &model.ChatGroup{ChatID: chatID}
+		r.groups[chatID] = group
+	}
+	group.Project = sql.NullString{String: project, Valid: project != ""}
+	group.Keywords = sql.NullString{String: keywords, Valid: keywords != ""}
+	return nil
+}
+
+func TestGroupMetadataCRUD(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeGroupRepo()
+	svc := NewChatService(repo, nil)
+
+	// 未设置时返回空信息
+	group, err := svc.GetGroupMetadata(ctx, "oc_pay")
+	if err != nil {
+		t.Fatalf("GetGroupMetadata failed: %v", err)
+	}
+	if group.MetadataContext() != "" {
+		t.Errorf("Expected empty metadata, got %q", group.MetadataContext())
+	}
+
+	if err := svc.SetGroupProject(ctx, "oc_pay", "支付中台"); err != nil {
+		t.Fatalf("SetGroupProject failed: %v", err)
+	}
+	keywords, err := svc.SetGroupKeywords(ctx, "oc_pay", "支付，退款、对账 支付")
+	if err != nil {
+		t.Fatalf("SetGroupKeywords failed: %v", err)
+	}
+	if keywords != "支付,退款,对账" {
+		t.Errorf("Expected normalized keywords, got %q", keywords)
+	}
+
+	group, _ = svc.GetGroupMetadata(ctx, "oc_pay")
+	if group.Project.String != "支付中台" {
+		t.Errorf("Expected project kept after setting keywords, got %q", group.Project.String)
+	}
+	want := "这是「支付中台」项目群，相关关键词：支付、退款、对账"
+	if got := group.MetadataContext(); got != want {
+		t.Errorf("MetadataContext() = %q, want %q", got, want)
+	}
+
+	// 修改项目时保留关键词
+	if err := svc.SetGroupProject(ctx, "oc_pay", "支付2.0"); err != nil {
+		t.Fatalf("SetGroupProject failed: %v", err)
+	}
+	group, _ = svc.GetGroupMetadata(ctx, "oc_pay")
+	if group.Keywords.String != "支付,退款,对账" {
+		t.Errorf("Expected keywords kept after setting project, got %q", group.Keywords.String)
+	}
+
+	if err := svc.ClearGroupMetadata(ctx, "oc_pay"); err != nil {
+		t.Fatalf("ClearGroupMetadata failed: %v", err)
+	}
+	group, _ = svc.GetGroupMetadata(ctx, "oc_pay")
+	if group.Project.Valid || group.Keywords.Valid {
+		t.Errorf("Expected metadata cleared, got project=%v keywords=%v", group.Project, group.Keywords)
+	}
+}
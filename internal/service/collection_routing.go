@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"team-assistant/pkg/vectordb"
+)
+
+// 向量集合划分策略
+const (
+	CollectionStrategySingle  = "single"   // 所有群共用一个集合（默认）
+	CollectionStrategyPerChat = "per_chat" // 每个群一个集合，便于按群删除、配额隔离
+)
+
+// PerChatCollectionName 群专属集合名：<base>_<chat_id>，chat_id 中集合名不支持的字符替换为 _
+func PerChatCollectionName(base, chatID string) string {
+	var sb strings.Builder
+	sb.WriteString(base)
+	sb.WriteByte('_')
+	for _, r := range chatID {
+		if r == '-' || r == '_' || (r >= '0' && r <= '9') || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') {
+			sb.WriteRune(r)
+		} else {
+			sb.WriteByte('_')
+		}
+	}
+	return sb.String()
+}
+
+// SetCollectionStrategy 设置集合划分策略（single / per_chat，其他值按 single 处理）
+// per_chat 时写入按 chat_id 路由到群专属集合（首次写入时创建），
+// 群聊检索只查本群集合，私聊跨群检索查询所有群集合后按分数合并
+func (s *RAGService) SetCollectionStrategy(strategy string) {
+	s.perChat = strategy == CollectionStrategyPerChat
+}
+
+// collectionFor 消息所在群对应的集合
+func (s *RAGService) collectionFor(chatID string) string {
+	if !s.perChat {
+		return s.collectionName
+	}
+	return PerChatCollectionName(s.collectionName, chatID)
+}
+
+// ensureCollection 确保群专属集合存在，创建过的集合不再重复请求
+func (s *RAGService) ensureCollection(ctx context.Context, name string) error {
+	s.collectionsMu.Lock()
+	defer s.collectionsMu.Unlock()
+	if s.ensuredCollections[name] {
+		return nil
+	}
+	if err := s.vectorDB.CreateCollection(ctx, name, s.embeddingClient.GetDimension()); err != nil {
+		return fmt.Errorf("create collection %s: %w", name, err)
+	}
+	if s.ensuredCollections == nil {
+		s.ensuredCollections = make(map[string]bool)
+	}
+	s.ensuredCollections[name] = true
+	return nil
+}
+
+// upsertPoints 写入数据点，per_chat 时按 payload 中的 chat_id 分组写入各群集合
+func (s *RAGService) upsertPoints(ctx context.Context, points []vectordb.Point) error {
+	if !s.perChat {
+		return s.vectorDB.Upsert(ctx, s.collectionName, points)
+	}
+
+	var order []string
+	groups := make(map[string][]vectordb.Point)
+	for _, p := range points {
+		name := s.collectionFor(getString(p.Payload, "chat_id"))
+		if _, ok := groups[name]; !ok {
+			order = append(order, name)
+		}
+		groups[name] = append(groups[name], p)
+	}
+
+	for _, name := range order {
+		if err := s.ensureCollection(ctx, name); err != nil {
+			return err
+		}
+		if err := s.vectorDB.Upsert(ctx, name, groups[name]); err != nil {
+			return fmt.Errorf("upsert to %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// searchCollections 检索需要查询的集合
+// per_chat 时指定群只查对应集合，未指定群（私聊跨群）查询所有群集合；还没有集合的群跳过
+func (s *RAGService) searchCollections(ctx context.Context, opts SearchOptions) ([]string, error) {
+	if !s.perChat {
+		return []string{s.collectionName}, nil
+	}
+
+	existing, err := s.vectorDB.ListCollections(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list collections: %w", err)
+	}
+	exists := make(map[string]bool, len(existing))
+	for _, name := range existing {
+		exists[name] = true
+	}
+
+	chatIDs := opts.ChatIDs
+	if opts.ChatID != "" {
+		chatIDs = []string{opts.ChatID}
+	}
+
+	var names []string
+	if len(chatIDs) > 0 {
+		for _, id := range chatIDs {
+			if name := s.collectionFor(id); exists[name] {
+				names = append(names, name)
+			}
+		}
+		return names, nil
+	}
+
+	prefix := s.collectionName + "_"
+	for _, name := range existing {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// mergeSearchResults 合并多个集合的检索结果，按分数降序取前 limit 条
+func mergeSearchResults(results []vectordb.SearchResult, limit int) []vectordb.SearchResult {
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"team-assistant/pkg/vectordb"
+)
+
+func TestPerChatCollectionName(t *testing.T) {
+	tests := []struct {
+		name   string
+		chatID string
+		want   string
+	}{
+		{"普通群ID", "oc_0dc23a40dbd0b12a", "test_oc_0dc23a40dbd0b12a"},
+		{"特殊字符替换", "oc/a.b c", "test_oc_a_b_c"},
+		{"空群ID", "", "test_"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PerChatCollectionName("test", tt.chatID); got != tt.want {
+				t.Errorf("PerChatCollectionName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIndexMessagesRoutesByChat(t *testing.T) {
+	messages := []MessageVector{
+		{MessageID: "om_1", ChatID: "oc_a", Content: "登录超时已修复", CreatedAt: time.Now()},
+		{MessageID: "om_2", ChatID: "oc_b", Content: "支付回调已上线", CreatedAt: time.Now()},
+		{MessageID: "om_3", ChatID: "oc_a", Content: "明天发布", CreatedAt: time.Now()},
+	}
+
+	t.Run("共用集合", func(t *testing.T) {
+		svc, backend := newTestRAGService(t)
+		if err := svc.IndexMessages(context.Background(), messages); err != nil {
+			t.Fatalf("IndexMessages() error: %v", err)
+		}
+		if len(backend.upserted) != 1 || backend.upserted["test"] != 3 {
+			t.Errorf("upserted = %v, want all points in test", backend.upserted)
+		}
+	})
+
+	t.Run("每群一个集合", func(t *testing.T) {
+		svc, backend := newTestRAGService(t)
+		svc.SetCollectionStrategy(CollectionStrategyPerChat)
+		if err := svc.IndexMessages(context.Background(), messages); err != nil {
+			t.Fatalf("IndexMessages() error: %v", err)
+		}
+		if backend.upserted["test_oc_a"] != 2 || backend.upserted["test_oc_b"] != 1 || backend.upserted["test"] != 0 {
+			t.Errorf("upserted = %v", backend.upserted)
+		}
+		if !backend.collections["test_oc_a"] || !backend.collections["test_oc_b"] {
+			t.Errorf("Per-chat collections should be created, got %v", backend.collections)
+		}
+
+		// 单条索引同样路由到群集合
+		msg := MessageVector{MessageID: "om_4", ChatID: "oc_b", Content: "回滚完成", CreatedAt: time.Now()}
+		if err := svc.IndexMessage(context.Background(), msg); err != nil {
+			t.Fatalf("IndexMessage() error: %v", err)
+		}
+		if backend.upserted["test_oc_b"] != 2 {
+			t.Errorf("IndexMessage should upsert to test_oc_b, got %v", backend.upserted)
+		}
+	})
+}
+
+func TestSearchPerChatCollections(t *testing.T) {
+	svc, backend := newTestRAGService(t)
+	svc.SetCollectionStrategy(CollectionStrategyPerChat)
+	backend.collections = map[string]bool{"test": true, "test_oc_a": true, "test_oc_b": true, "other_oc_c": true}
+	backend.hits = map[string][]vectordb.SearchResult{
+		"test_oc_a": {
+			{ID: "1", Score: 0.6, Payload: map[string]interface{}{"message_id": "om_a1", "chat_id": "oc_a"}},
+			{ID: "2", Score: 0.3, Payload: map[string]interface{}{"message_id": "om_a2", "chat_id": "oc_a"}},
+		},
+		"test_oc_b": {
+			{ID: "3", Score: 0.9, Payload: map[string]interface{}{"message_id": "om_b1", "chat_id": "oc_b"}},
+		},
+	}
+	ctx := context.Background()
+
+	tests := []struct {
+		name         string
+		opts         SearchOptions
+		wantSearched string
+		wantIDs      string
+	}{
+		{"群聊只查本群集合", SearchOptions{ChatID: "oc_a"}, "test_oc_a", "om_a1,om_a2"},
+		{"私聊跨群合并排序", SearchOptions{}, "test_oc_a,test_oc_b", "om_b1,om_a1"},
+		{"限定多个群", SearchOptions{ChatIDs: []string{"oc_b", "oc_missing"}}, "test_oc_b", "om_b1"},
+		{"群还没有集合", SearchOptions{ChatID: "oc_missing"}, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend.searched = nil
+			results, err := svc.SearchWithOptions(ctx, "登录", 2, tt.opts)
+			if err != nil {
+				t.Fatalf("SearchWithOptions() error: %v", err)
+			}
+			if got := strings.Join(backend.searched, ","); got != tt.wantSearched {
+				t.Errorf("searched = %q, want %q", got, tt.wantSearched)
+			}
+			ids := make([]string, len(results))
+			for i, r := range results {
+				ids[i] = r.MessageID
+			}
+			if got := strings.Join(ids, ","); got != tt.wantIDs {
+				t.Errorf("results = %q, want %q", got, tt.wantIDs)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// CollectionStats 向量集合统计信息
+type CollectionStats struct {
+	PointsCount int64     `json:"points_count"` // 向量点数量
+	Dimension   int       `json:"dimension"`    // 向量维度
+	Distance    string    `json:"distance"`     // 距离度量（Cosine/Dot 等）
+	Status      string    `json:"status"`       // 集合状态（green/yellow/red）
+	UpdatedAt   time.Time `json:"updated_at"`   // 缓存更新时间
+}
+
+// CollectionInfoFetcher 获取原始集合信息（Qdrant GET /collections/{name} 的响应）
+type CollectionInfoFetcher func(ctx context.Context) (map[string]interface{}, error)
+
+// IndexVersionSource 跨进程的索引版本（reindex 等独立进程重建索引后递增）
+type IndexVersionSource interface {
+	Version(ctx context.Context) (string, error)
+}
+
+// CollectionStatsCache 集合统计缓存
+// 启动时预热，由 Run 定期刷新，状态查询直接读取缓存，避免每次都请求 Qdrant；
+// 设置了索引版本时，读取缓存前检查版本，其他进程重建索引后立即失效
+type CollectionStatsCache struct {
+	fetch    CollectionInfoFetcher
+	interval time.Duration
+	version  IndexVersionSource
+
+	mu          sync.RWMutex
+	stats       *CollectionStats
+	seenVersion string
+}
+
+// NewCollectionStatsCache 创建集合统计缓存
+func NewCollectionStatsCache(fetch CollectionInfoFetcher, interval time.Duration) *CollectionStatsCache {
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+	return &CollectionStatsCache{
+		fetch:    fetch,
+		interval: interval,
+	}
+}
+
+// SetVersionSource 设置跨进程的索引版本来源，版本变化时缓存失效
+func (c *CollectionStatsCache) SetVersionSource(src IndexVersionSource) {
+	c.version = src
+}
+
+// Run 定期刷新缓存，阻塞直到 ctx 取消
+func (c *CollectionStatsCache) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			refreshCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+			if err := c.Refresh(refreshCtx); err != nil {
+				log.Printf("[RAG] Failed to refresh collection stats: %v", err)
+			}
+			cancel()
+		}
+	}
+}
+
+// Refresh 立即从 Qdrant 拉取并更新缓存
+func (c *CollectionStatsCache) Refresh(ctx context.Context) error {
+	info, err := c.fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	stats := parseCollectionInfo(info)
+	stats.UpdatedAt = time.Now()
+
+	c.mu.Lock()
+	c.stats = stats
+	c.mu.Unlock()
+	return nil
+}
+
+// Get 获取缓存的统计信息，缓存为空（未预热或已失效）时同步刷新一次
+func (c *CollectionStatsCache) Get(ctx context.Context) (*CollectionStats, error) {
+	c.checkVersion(ctx)
+
+	c.mu.RLock()
+	stats := c.stats
+	c.mu.RUnlock()
+
+	if stats != nil {
+		return stats, nil
+	}
+
+	if err := c.Refresh(ctx); err != nil {
+		return nil, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.stats, nil
+}
+
+// Invalidate 使缓存失效（如重建集合、重新索引后），下次读取时重新拉取
+func (c *CollectionStatsCache) Invalidate() {
+	c.mu.Lock()
+	c.stats = nil
+	c.mu.Unlock()
+}
+
+// checkVersion 索引版本变化时使缓存失效，读取版本失败时继续使用缓存
+func (c *CollectionStatsCache) checkVersion(ctx context.Context) {
+	if c.version == nil {
+		return
+	}
+	version, err := c.version.Version(ctx)
+	if err != nil {
+		log.Printf("[RAG] Failed to read index version: %v", err)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if version != c.seenVersion {
+		c.seenVersion = version
+		c.stats = nil
+	}
+}
+
+// parseCollectionInfo 解析 Qdrant 集合信息
+// 响应格式: {"result": {"status": "green", "points_count": 100, "config": {"params": {"vectors": {"size": 768, "distance": "Cosine"}}}}}
+func parseCollectionInfo(info map[string]interface{}) *CollectionStats {
+	stats := &CollectionStats{}
+
+	result, ok := info["result"].(map[string]interface{})
+	if !ok {
+		return stats
+	}
+
+	if status, ok := result["status"].(string); ok {
+		stats.Status = status
+	}
+	if count, ok := result["points_count"].(float64); ok {
+		stats.PointsCount = int64(count)
+	}
+
+	config, _ := result["config"].(map[string]interface{})
+	params, _ := config["params"].(map[string]interface{})
+	vectors, _ := params["vectors"].(map[string]interface{})
+	if size, ok := vectors["size"].(float64); ok {
+		stats.Dimension = int(size)
+	}
+	if distance, ok := vectors["distance"].(string); ok {
+		stats.Distance = distance
+	}
+
+	return stats
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeCollectionInfo 构造 Qdrant 集合信息响应
+func fakeCollectionInfo(count int, dimension int) map[string]interface{} {
+	return map[string]interface{}{
+		"result": map[string]interface{}{
+			"status":       "green",
+			"points_count": float64(count),
+			"config": map[string]interface{}{
+				"params": map[string]interface{}{
+					"vectors": map[string]interface{}{
+						"size":     float64(dimension),
+						"distance": "Cosine",
+					},
+				},
+			},
+		},
+		"status": "ok",
+	}
+}
+
+func TestParseCollectionInfo(t *testing.T) {
+	stats := parseCollectionInfo(fakeCollectionInfo(120, 768))
+
+	if stats.PointsCount != 120 {
+		t.Errorf("Expected PointsCount=120, got %d", stats.PointsCount)
+	}
+	if stats.Dimension != 768 {
+		t.Errorf("Expected Dimension=768, got %d", stats.Dimension)
+	}
+	if stats.Status != "green" || stats.Distance != "Cosine" {
+		t.Errorf("Unexpected status/distance: %+v", stats)
+	}
+
+	// 异常响应不应 panic
+	empty := parseCollectionInfo(map[string]interface{}{"status": "error"})
+	if empty.PointsCount != 0 || empty.Dimension != 0 {
+		t.Errorf("Expected empty stats, got %+v", empty)
+	}
+}
+
+func TestCollectionStatsCacheRefresh(t *testing.T) {
+	calls := 0
+	count := 10
+	cache := NewCollectionStatsCache(func(ctx context.Context) (map[string]interface{}, error) {
+		calls++
+		return fakeCollectionInfo(count, 768), nil
+	}, time.Minute)
+
+	ctx := context.Background()
+
+	// 首次读取时同步拉取
+	stats, err := cache.Get(ctx)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if stats.PointsCount != 10 || calls != 1 {
+		t.Errorf("Expected count=10 calls=1, got count=%d calls=%d", stats.PointsCount, calls)
+	}
+
+	// 命中缓存，不再拉取
+	count = 20
+	stats, _ = cache.Get(ctx)
+	if stats.PointsCount != 10 || calls != 1 {
+		t.Errorf("Expected cached count=10 calls=1, got count=%d calls=%d", stats.PointsCount, calls)
+	}
+
+	// 主动刷新后读取新值
+	if err := cache.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	stats, _ = cache.Get(ctx)
+	if stats.PointsCount != 20 || calls != 2 {
+		t.Errorf("Expected count=20 calls=2, got count=%d calls=%d", stats.PointsCount, calls)
+	}
+
+	// 失效后重新拉取
+	count = 30
+	cache.Invalidate()
+	stats, _ = cache.Get(ctx)
+	if stats.PointsCount != 30 || calls != 3 {
+		t.Errorf("Expected count=30 calls=3 after invalidate, got count=%d calls=%d", stats.PointsCount, calls)
+	}
+}
+
+// fakeIndexVersion 可修改的索引版本
+type fakeIndexVersion struct {
+	version string
+	err     error
+}
+
+func (f *fakeIndexVersion) Version(ctx context.Context) (string, error) { return f.version, f.err }
+
+func TestCollectionStatsCacheIndexVersion(t *testing.T) {
+	calls := 0
+	count := 10
+	cache := NewCollectionStatsCache(func(ctx context.Context) (map[string]interface{}, error) {
+		calls++
+		return fakeCollectionInfo(count, 768), nil
+	}, time.Minute)
+	version := &fakeIndexVersion{}
+	cache.SetVersionSource(version)
+
+	ctx := context.Background()
+	cache.Get(ctx)
+
+	// 版本不变时使用缓存
+	count = 20
+	if stats, _ := cache.Get(ctx); stats.PointsCount != 10 || calls != 1 {
+		t.Errorf("Expected cached count=10 calls=1, got count=%d calls=%d", stats.PointsCount, calls)
+	}
+
+	// 其他进程重建索引后重新拉取
+	version.version = "1"
+	if stats, _ := cache.Get(ctx); stats.PointsCount != 20 || calls != 2 {
+		t.Errorf("Expected count=20 calls=2 after version bump, got count=%d calls=%d", stats.PointsCount, calls)
+	}
+
+	// 读取版本失败时继续使用缓存
+	count = 30
+	version.err = errors.New("redis: connection refused")
+	if stats, _ := cache.Get(ctx); stats.PointsCount != 20 || calls != 2 {
+		t.Errorf("Expected cached count=20 calls=2 when version unavailable, got count=%d calls=%d", stats.PointsCount, calls)
+	}
+}
+
+func TestCollectionStatsCacheRefreshError(t *testing.T) {
+	fail := false
+	cache := NewCollectionStatsCache(func(ctx context.Context) (map[string]interface{}, error) {
+		if fail {
+			return nil, errors.New("qdrant unavailable")
+		}
+		return fakeCollectionInfo(5, 384), nil
+	}, time.Minute)
+
+	ctx := context.Background()
+	if err := cache.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+
+	// 刷新失败时保留旧缓存
+	fail = true
+	if err := cache.Refresh(ctx); err == nil {
+		t.Error("Expected refresh error")
+	}
+	stats, err := cache.Get(ctx)
+	if err != nil || stats.PointsCount != 5 || stats.Dimension != 384 {
+		t.Errorf("Expected stale stats to be kept, got %+v, err=%v", stats, err)
+	}
+
+	// 缓存为空且拉取失败时返回错误
+	cache.Invalidate()
+	if _, err := cache.Get(ctx); err == nil {
+		t.Error("Expected error when cache empty and fetch fails")
+	}
+}
+
+func TestCollectionStatsCacheRun(t *testing.T) {
+	var calls atomic.Int32
+	cache := NewCollectionStatsCache(func(ctx context.Context) (map[string]interface{}, error) {
+		calls.Add(1)
+		return fakeCollectionInfo(10, 768), nil
+	}, 5*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		cache.Run(ctx)
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for calls.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if calls.Load() < 2 {
+		t.Errorf("Expected periodic refreshes, got %d", calls.Load())
+	}
+
+	// 取消后退出，不再泄漏协程
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after ctx was canceled")
+	}
+}
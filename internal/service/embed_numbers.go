@@ -0,0 +1,62 @@
+package service
+
+import (
+	"regexp"
+	"strings"
+)
+
+// embedding 文本中数字/时间戳的处理方式
+const (
+	EmbedNumbersKeep  = "keep"  // 保留原文（默认）
+	EmbedNumbersMask  = "mask"  // 替换为占位符（<时间>、<数字> 等），保留"这里有个数值"的语义
+	EmbedNumbersStrip = "strip" // 直接去掉
+)
+
+// volatileTokenPatterns 易变的数值，按顺序替换（先替换时间戳、IP 等整体，再替换剩余的数字）
+// 字母和数字连写的内容（如站点前缀 by4、l08）不是独立的数字，保持不变
+var volatileTokenPatterns = []struct {
+	pattern     *regexp.Regexp
+	placeholder string
+}{
+	// 2024-03-08 18:30:05、2024/3/8T18:30:05.123+08:00
+	{regexp.MustCompile(`\b\d{4}[-/]\d{1,2}[-/]\d{1,2}(?:[ T]\d{1,2}:\d{2}(?::\d{2})?(?:\.\d+)?(?:Z|[+-]\d{2}:?\d{2})?)?`), "<时间>"},
+	// IPv4（可带端口）
+	{regexp.MustCompile(`\b\d{1,3}(?:\.\d{1,3}){3}(?::\d+)?\b`), "<IP>"},
+	// 18:30、18:30:05
+	{regexp.MustCompile(`\b\d{1,2}:\d{2}(?::\d{2})?\b`), "<时间>"},
+	// UUID、trace id 等 16 位以上的十六进制串
+	{regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b|\b[0-9a-f]{16,}\b`), "<ID>"},
+	// 秒/毫秒级 Unix 时间戳
+	{regexp.MustCompile(`\b\d{10}(?:\d{3})?\b`), "<时间>"},
+	// 其余独立的数字（可带小数和常见单位），如 3021ms、95.5%、500
+	{regexp.MustCompile(`(?i)\b\d+(?:\.\d+)?(?:ms|s|kb|mb|gb)?\b%?`), "<数字>"},
+}
+
+// multiSpacePattern 去掉数字后多余的空白
+var multiSpacePattern = regexp.MustCompile(`[ \t]{2,}`)
+
+// NormalizeEmbedText 按 mode 处理 embedding 文本中的时间戳和数字，使只有数值不同的告警（耗时、时间、请求ID）向量相近
+// 只影响生成 embedding 的文本，payload 和数据库中的原文不变；mode 为空或 keep 时原样返回
+func NormalizeEmbedText(text, mode string) string {
+	if mode != EmbedNumbersMask && mode != EmbedNumbersStrip {
+		return text
+	}
+
+	for _, p := range volatileTokenPatterns {
+		replacement := p.placeholder
+		if mode == EmbedNumbersStrip {
+			replacement = ""
+		}
+		text = p.pattern.ReplaceAllLiteralString(text, replacement)
+	}
+
+	if mode == EmbedNumbersStrip {
+		text = multiSpacePattern.ReplaceAllString(text, " ")
+		lines := strings.Split(text, "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimSpace(line)
+		}
+		text = strings.Join(lines, "\n")
+	}
+	return text
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"math"
+	"sort"
+	"testing"
+	"unicode"
+)
+
+func TestNormalizeEmbedText(t *testing.T) {
+	alert := "【告警】by4 支付回调超时 耗时 3021ms 时间 2024-03-08 18:30:05 trace 9f8e7d6c5b4a3928"
+
+	tests := []struct {
+		name string
+		text string
+		mode string
+		want string
+	}{
+		{"默认保留原文", alert, "", alert},
+		{"keep 保留原文", alert, EmbedNumbersKeep, alert},
+		{"mask 替换为占位符", alert, EmbedNumbersMask, "【告警】by4 支付回调超时 耗时 <数字> 时间 <时间> trace <ID>"},
+		{"strip 去掉数值", alert, EmbedNumbersStrip, "【告警】by4 支付回调超时 耗时 时间 trace"},
+		{"站点前缀和版本号中的字母数字不变", "l08 升级到 v2 后 CPU 95.5%", EmbedNumbersMask, "l08 升级到 v2 后 CPU <数字>"},
+		{"IP 和端口", "连接 10.0.3.17:6379 失败", EmbedNumbersMask, "连接 <IP> 失败"},
+		{"Unix 时间戳和 UUID", "ts=1709893805123 id=3f2b8c1e-9d4a-4e5b-8c7d-1a2b3c4d5e6f", EmbedNumbersMask, "ts=<时间> id=<ID>"},
+		{"中文紧挨数字", "今天报错500次", EmbedNumbersMask, "今天报错<数字>次"},
+		{"strip 保留换行", "慢查询 1200ms\n接口 /api/pay", EmbedNumbersStrip, "慢查询\n接口 /api/pay"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeEmbedText(tt.text, tt.mode); got != tt.want {
+				t.Errorf("NormalizeEmbedText(%q, %q) = %q, want %q", tt.text, tt.mode, got, tt.want)
+			}
+		})
+	}
+}
+
+// bagOfTokensEmbedding 测试用 embedding：ASCII 字母数字串、占位符和每个汉字各算一个词，按词频计数
+// 与真实模型一样，文本中大量数字会稀释语义相关的词
+func bagOfTokensEmbedding(text string) map[string]float64 {
+	vec := make(map[string]float64)
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			vec[string(word)]++
+			word = nil
+		}
+	}
+	for _, r := range text {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) || r == '<' || r == '>':
+			word = append(word, r)
+		case unicode.Is(unicode.Han, r) && len(word) > 0 && word[0] == '<':
+			word = append(word, r) // 占位符中的汉字，如 <时间>
+		case unicode.Is(unicode.Han, r):
+			flush()
+			vec[string(r)]++
+		default:
+			flush()
+		}
+	}
+	flush()
+	return vec
+}
+
+func bagCosine(a, b map[string]float64) float64 {
+	var dot, na, nb float64
+	for k, v := range a {
+		dot += v * b[k]
+		na += v * v
+	}
+	for _, v := range b {
+		nb += v * v
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// rankByEmbedding 按与 query 的相似度对文档排序，返回文档下标
+func rankByEmbedding(query string, docs []string, mode string) []int {
+	q := bagOfTokensEmbedding(NormalizeEmbedText(query, mode))
+	scores := make([]float64, len(docs))
+	order := make([]int, len(docs))
+	for i, doc := range docs {
+		scores[i] = bagCosine(q, bagOfTokensEmbedding(NormalizeEmbedText(doc, mode)))
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return scores[order[i]] > scores[order[j]] })
+	return order
+}
+
+func TestEmbedNumbersRetrieval(t *testing.T) {
+	docs := []string{
+		// 0: 与查询同类的告警，数值全都不同
+		"【告警】支付回调超时 耗时 3021ms 时间 2024-03-08 18:30:05 host 10.0.5.2 pid 1022 订单 88231 trace 9f8e7d6c5b4a3928",
+		// 1: 不同类的告警，恰好与查询有相同的时间和数值
+		"【告警】数据库连接池耗尽 耗时 5120ms 时间 2024-03-09 09:12:44 host 10.0.3.17 pid 23817 订单 10086 trace 0a1b2c3d4e5f6789",
+		// 2: 普通讨论
+		"支付回调那边今天还有问题吗",
+	}
+	query := "【告警】支付回调超时 耗时 5120ms 时间 2024-03-09 09:12:44 host 10.0.3.17 pid 23817 订单 10086 trace 0a1b2c3d4e5f6789"
+
+	tests := []struct {
+		name    string
+		mode    string
+		wantTop int
+	}{
+		{"不处理时数值相同的其他告警排第一", EmbedNumbersKeep, 1},
+		{"mask 后同类告警排第一", EmbedNumbersMask, 0},
+		{"strip 后同类告警排第一", EmbedNumbersStrip, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rankByEmbedding(query, docs, tt.mode); got[0] != tt.wantTop {
+				t.Errorf("rankByEmbedding(%s) = %v, want top %d", tt.mode, got, tt.wantTop)
+			}
+		})
+	}
+}
+
+func TestGetEmbeddingNormalizesNumbers(t *testing.T) {
+	rag, backend := newTestRAGService(t)
+	rag.SetEmbedNumbers(EmbedNumbersMask)
+
+	if _, err := rag.Search(t.Context(), "by4 耗时 3021ms 的告警", 5, ""); err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	msg := MessageVector{MessageID: "om_1", ChatID: "oc_1", Content: "by4 耗时 5120ms"}
+	if err := rag.IndexMessage(t.Context(), msg); err != nil {
+		t.Fatalf("IndexMessage failed: %v", err)
+	}
+
+	want := []string{"by4 耗时 <数字> 的告警", "by4 耗时 <数字>"}
+	if len(backend.prompts) != len(want) {
+		t.Fatalf("Expected %d embedding requests, got %v", len(want), backend.prompts)
+	}
+	for i := range want {
+		if backend.prompts[i] != want[i] {
+			t.Errorf("prompts[%d] = %q, want %q", i, backend.prompts[i], want[i])
+		}
+	}
+	if got := backend.payloads[0]["content"]; got != "by4 耗时 5120ms" {
+		t.Errorf("Expected original content kept in payload, got %v", got)
+	}
+}
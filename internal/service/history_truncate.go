@@ -0,0 +1,50 @@
+package service
+
+import "strings"
+
+// defaultMaxHistoryLength 传给 Dify 的对话历史默认最大字符数
+const defaultMaxHistoryLength = 2000
+
+// historyOmittedMarker 截断对话历史时放在开头的提示
+const historyOmittedMarker = "...(更早的对话已省略)\n"
+
+// SetMaxHistoryLength 设置传给 Dify 的对话历史最大字符数，<=0 使用默认值
+func (s *AIService) SetMaxHistoryLength(n int) {
+	if n <= 0 {
+		n = defaultMaxHistoryLength
+	}
+	s.maxHistoryLen = n
+}
+
+// truncateHistory 将对话历史截断到 maxLen 个字符以内，按行保留最近的对话
+// 最近一行本身超长时只保留其结尾部分；maxLen<=0 不截断
+func truncateHistory(history string, maxLen int) string {
+	if maxLen <= 0 || len([]rune(history)) <= maxLen {
+		return history
+	}
+
+	budget := maxLen - len([]rune(historyOmittedMarker))
+	if budget <= 0 {
+		runes := []rune(history)
+		return string(runes[len(runes)-maxLen:])
+	}
+
+	lines := strings.SplitAfter(strings.TrimRight(history, "\n"), "\n")
+	kept := 0
+	start := len(lines)
+	for start > 0 {
+		n := len([]rune(lines[start-1]))
+		if kept+n > budget {
+			break
+		}
+		kept += n
+		start--
+	}
+
+	if start == len(lines) {
+		// 最近一行也放不下，保留结尾
+		runes := []rune(lines[len(lines)-1])
+		return historyOmittedMarker + string(runes[len(runes)-budget:])
+	}
+	return historyOmittedMarker + strings.Join(lines[start:], "")
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateHistory(t *testing.T) {
+	history := "Human: 登录超时怎么处理\nAI: 先检查网关配置\nHuman: 改完了还是超时\nAI: 看一下 nginx 日志\n"
+	markerLen := utf8.RuneCountInString(historyOmittedMarker)
+	lastTwo := "Human: 改完了还是超时\nAI: 看一下 nginx 日志"
+	lastOne := "AI: 看一下 nginx 日志"
+
+	tests := []struct {
+		name   string
+		maxLen int
+		want   string
+	}{
+		{"不限制", 0, history},
+		{"刚好等于上限", utf8.RuneCountInString(history), history},
+		{"保留最近两行", markerLen + utf8.RuneCountInString(lastTwo), historyOmittedMarker + lastTwo},
+		{"差一个字符只保留最近一行", markerLen + utf8.RuneCountInString(lastTwo) - 1, historyOmittedMarker + lastOne},
+		{"最近一行超长保留结尾", markerLen + 8, historyOmittedMarker + "nginx 日志"},
+		{"上限小于提示长度", 5, "x 日志\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncateHistory(history, tt.maxLen)
+			if got != tt.want {
+				t.Errorf("truncateHistory(%d) = %q, want %q", tt.maxLen, got, tt.want)
+			}
+			if tt.maxLen > 0 && utf8.RuneCountInString(got) > tt.maxLen {
+				t.Errorf("truncateHistory(%d) returned %d runes", tt.maxLen, utf8.RuneCountInString(got))
+			}
+		})
+	}
+}
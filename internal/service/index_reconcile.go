@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"team-assistant/internal/model"
+)
+
+const (
+	defaultReindexLimit  = 5000 // 补齐索引时每个群最多检查的消息数
+	pointLookupBatchSize = 100  // 按 ID 查询数据点时每批的 ID 数
+)
+
+// IndexGap 单个群 MySQL 可索引消息数与向量库数据点数的差异
+type IndexGap struct {
+	ChatID      string
+	MySQLCount  int64 // 内容非空的消息数
+	VectorCount int64 // 向量库中的消息数（分块消息只计第一块）
+}
+
+// Missing 向量库缺少的消息数，为负时向量库多出数据（如 MySQL 中已删除的消息）
+func (g IndexGap) Missing() int64 {
+	return g.MySQLCount - g.VectorCount
+}
+
+// computeIndexGaps 按群对比 MySQL 消息数和向量库消息数，只返回有差异的群
+// 缺失最多的群排在前面，缺失数相同时按 chat_id 排序
+func computeIndexGaps(mysqlCounts, vectorCounts map[string]int64) []IndexGap {
+	var gaps []IndexGap
+	for chatID, n := range mysqlCounts {
+		if n != vectorCounts[chatID] {
+			gaps = append(gaps, IndexGap{ChatID: chatID, MySQLCount: n, VectorCount: vectorCounts[chatID]})
+		}
+	}
+	for chatID, n := range vectorCounts {
+		if _, ok := mysqlCounts[chatID]; !ok && n > 0 {
+			gaps = append(gaps, IndexGap{ChatID: chatID, VectorCount: n})
+		}
+	}
+	sort.Slice(gaps, func(i, j int) bool {
+		if gaps[i].Missing() != gaps[j].Missing() {
+			return gaps[i].Missing() > gaps[j].Missing()
+		}
+		return gaps[i].ChatID < gaps[j].ChatID
+	})
+	return gaps
+}
+
+// indexableMessageStore 可索引消息的查询接口
+type indexableMessageStore interface {
+	CountIndexableByChat(ctx context.Context) (map[string]int64, error)
+	ListIndexableByChat(ctx context.Context, chatID string, limit int) ([]*model.ChatMessage, error)
+}
+
+// IndexReconciler 对账 MySQL 消息和向量库数据点，并补齐缺失的索引
+type IndexReconciler struct {
+	messages indexableMessageStore
+	rag      *RAGService
+	indexer  *MessageIndexer
+}
+
+// NewIndexReconciler 创建索引对账器
+func NewIndexReconciler(messages *model.ChatMessageModel, rag *RAGService) *IndexReconciler {
+	return &IndexReconciler{messages: messages, rag: rag, indexer: NewMessageIndexer(rag)}
+}
+
+// Reconcile 按群统计 MySQL 可索引消息数和向量库消息数，返回有差异的群和检查的群数
+func (r *IndexReconciler) Reconcile(ctx context.Context) (gaps []IndexGap, checked int, err error) {
+	mysqlCounts, err := r.messages.CountIndexableByChat(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("count indexable messages: %w", err)
+	}
+
+	vectorCounts := make(map[string]int64, len(mysqlCounts))
+	for chatID := range mysqlCounts {
+		n, err := r.rag.CountMessagePoints(ctx, chatID)
+		if err != nil {
+			return nil, 0, fmt.Errorf("count points for chat %s: %w", chatID, err)
+		}
+		vectorCounts[chatID] = n
+	}
+	return computeIndexGaps(mysqlCounts, vectorCounts), len(mysqlCounts), nil
+}
+
+// ReindexMissing 补齐群内最近 limit 条可索引消息中缺失的向量索引，返回重新索引的消息数
+// 排除的发言人和只有@提及的消息仍会按配置跳过；limit<=0 使用默认值
+func (r *IndexReconciler) ReindexMissing(ctx context.Context, chatID, chatName string, limit int) (int, error) {
+	if limit <= 0 {
+		limit = defaultReindexLimit
+	}
+	msgs, err := r.messages.ListIndexableByChat(ctx, chatID, limit)
+	if err != nil {
+		return 0, fmt.Errorf("list messages: %w", err)
+	}
+
+	ids := make([]string, len(msgs))
+	for i, msg := range msgs {
+		ids[i] = msg.MessageID
+	}
+	missingIDs, err := r.rag.MissingMessageIDs(ctx, chatID, ids)
+	if err != nil {
+		return 0, err
+	}
+	missing := make(map[string]bool, len(missingIDs))
+	for _, id := range missingIDs {
+		missing[id] = true
+	}
+
+	var toIndex []*model.ChatMessage
+	for _, msg := range msgs {
+		if missing[msg.MessageID] {
+			toIndex = append(toIndex, msg)
+		}
+	}
+	if len(toIndex) == 0 {
+		return 0, nil
+	}
+	if err := r.indexer.IndexMessages(ctx, toIndex, chatName); err != nil {
+		return 0, fmt.Errorf("index messages: %w", err)
+	}
+	r.rag.InvalidateStats()
+	return len(toIndex), nil
+}
+
+// CountMessagePoints 统计群在向量库中的消息数，分块消息只计第一块
+// per_chat 时群集合不存在返回 0
+func (s *RAGService) CountMessagePoints(ctx context.Context, chatID string) (int64, error) {
+	if !s.enabled {
+		return 0, fmt.Errorf("RAG service not enabled")
+	}
+
+	collection := s.collectionFor(chatID)
+	if s.perChat {
+		exists, err := s.vectorDB.CollectionExists(ctx, collection)
+		if err != nil {
+			return 0, fmt.Errorf("check collection: %w", err)
+		}
+		if !exists {
+			return 0, nil
+		}
+	}
+
+	filter := map[string]interface{}{
+		"must": []map[string]interface{}{
+			{"key": "chat_id", "match": map[string]interface{}{"value": chatID}},
+		},
+		"should": []map[string]interface{}{
+			{"key": "is_chunk", "match": map[string]interface{}{"value": false}},
+			{"key": "chunk_index", "match": map[string]interface{}{"value": 0}},
+		},
+	}
+	return s.vectorDB.Count(ctx, collection, filter)
+}
+
+// MissingMessageIDs 返回在向量库中没有数据点的消息ID（整条索引和分块索引的第一块都不存在）
+// per_chat 时群集合不存在则全部视为缺失
+func (s *RAGService) MissingMessageIDs(ctx context.Context, chatID string, messageIDs []string) ([]string, error) {
+	if !s.enabled {
+		return nil, fmt.Errorf("RAG service not enabled")
+	}
+
+	collection := s.collectionFor(chatID)
+	if s.perChat {
+		exists, err := s.vectorDB.CollectionExists(ctx, collection)
+		if err != nil {
+			return nil, fmt.Errorf("check collection: %w", err)
+		}
+		if !exists {
+			return messageIDs, nil
+		}
+	}
+
+	var missing []string
+	for start := 0; start < len(messageIDs); start += pointLookupBatchSize {
+		end := min(start+pointLookupBatchSize, len(messageIDs))
+		batch := messageIDs[start:end]
+
+		owner := make(map[string]string, 2*len(batch)) // 数据点 ID -> 消息ID
+		ids := make([]string, 0, 2*len(batch))
+		for _, id := range batch {
+			for _, pointID := range []string{messageIDToUUID(id), messageIDToUUID(id + "_chunk_0")} {
+				owner[pointID] = id
+				ids = append(ids, pointID)
+			}
+		}
+
+		points, err := s.vectorDB.GetPoints(ctx, collection, ids)
+		if err != nil {
+			return nil, fmt.Errorf("get points: %w", err)
+		}
+		found := make(map[string]bool, len(points))
+		for _, p := range points {
+			found[owner[p.ID]] = true
+		}
+		for _, id := range batch {
+			if !found[id] {
+				missing = append(missing, id)
+			}
+		}
+	}
+	return missing, nil
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	"team-assistant/internal/model"
+)
+
+func TestComputeIndexGaps(t *testing.T) {
+	tests := []struct {
+		name   string
+		mysql  map[string]int64
+		vector map[string]int64
+		want   []IndexGap
+	}{
+		{"全部一致", map[string]int64{"oc_a": 10, "oc_b": 5}, map[string]int64{"oc_a": 10, "oc_b": 5}, nil},
+		{"向量库缺少", map[string]int64{"oc_a": 10}, map[string]int64{"oc_a": 7},
+			[]IndexGap{{ChatID: "oc_a", MySQLCount: 10, VectorCount: 7}}},
+		{"向量库没有该群", map[string]int64{"oc_a": 3}, map[string]int64{},
+			[]IndexGap{{ChatID: "oc_a", MySQLCount: 3}}},
+		{"向量库多出已删除的群", map[string]int64{}, map[string]int64{"oc_gone": 4},
+			[]IndexGap{{ChatID: "oc_gone", VectorCount: 4}}},
+		{"缺失多的排在前面", map[string]int64{"oc_a": 10, "oc_b": 50, "oc_c": 8, "oc_d": 5}, map[string]int64{"oc_a": 9, "oc_b": 20, "oc_c": 10, "oc_d": 4},
+			[]IndexGap{
+				{ChatID: "oc_b", MySQLCount: 50, VectorCount: 20},
+				{ChatID: "oc_a", MySQLCount: 10, VectorCount: 9},
+				{ChatID: "oc_d", MySQLCount: 5, VectorCount: 4},
+				{ChatID: "oc_c", MySQLCount: 8, VectorCount: 10},
+			}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := computeIndexGaps(tt.mysql, tt.vector)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("computeIndexGaps() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIndexGapMissing(t *testing.T) {
+	if got := (IndexGap{MySQLCount: 10, VectorCount: 7}).Missing(); got != 3 {
+		t.Errorf("Missing() = %d, want 3", got)
+	}
+	if got := (IndexGap{MySQLCount: 2, VectorCount: 5}).Missing(); got != -3 {
+		t.Errorf("Missing() = %d, want -3", got)
+	}
+}
+
+// fakeIndexableStore 返回固定的可索引消息
+type fakeIndexableStore struct {
+	messages []*model.ChatMessage
+}
+
+func (f *fakeIndexableStore) CountIndexableByChat(ctx context.Context) (map[string]int64, error) {
+	counts := make(map[string]int64)
+	for _, msg := range f.messages {
+		counts[msg.ChatID]++
+	}
+	return counts, nil
+}
+
+func (f *fakeIndexableStore) ListIndexableByChat(ctx context.Context, chatID string, limit int) ([]*model.ChatMessage, error) {
+	var msgs []*model.ChatMessage
+	for _, msg := range f.messages {
+		if msg.ChatID == chatID && len(msgs) < limit {
+			msgs = append(msgs, msg)
+		}
+	}
+	return msgs, nil
+}
+
+func TestReindexMissing(t *testing.T) {
+	rag, backend := newTestRAGService(t)
+	ctx := context.Background()
+
+	newMsg := func(id, content string) *model.ChatMessage {
+		return &model.ChatMessage{MessageID: id, ChatID: "oc_dev",
+			Content: sql.NullString{String: content, Valid: true}, CreatedAt: time.Now()}
+	}
+	indexed := newMsg("om_1", "已经索引过的消息")
+	if err := rag.IndexMessage(ctx, MessageVector{MessageID: indexed.MessageID, ChatID: "oc_dev", Content: indexed.Content.String}); err != nil {
+		t.Fatal(err)
+	}
+
+	store := &fakeIndexableStore{messages: []*model.ChatMessage{indexed, newMsg("om_2", "漏掉的消息"), newMsg("om_3", "也漏掉了")}}
+	reconciler := &IndexReconciler{messages: store, rag: rag, indexer: NewMessageIndexer(rag)}
+
+	missing, err := rag.MissingMessageIDs(ctx, "oc_dev", []string{"om_1", "om_2", "om_3"})
+	if err != nil || !reflect.DeepEqual(missing, []string{"om_2", "om_3"}) {
+		t.Fatalf("MissingMessageIDs() = %v, %v, want [om_2 om_3]", missing, err)
+	}
+
+	n, err := reconciler.ReindexMissing(ctx, "oc_dev", "研发群", 0)
+	if err != nil || n != 2 {
+		t.Fatalf("ReindexMissing() = %d, %v, want 2", n, err)
+	}
+	if len(backend.payloads) != 3 {
+		t.Errorf("Expected only missing messages reindexed, got %d payloads", len(backend.payloads))
+	}
+
+	n, err = reconciler.ReindexMissing(ctx, "oc_dev", "研发群", 0)
+	if err != nil || n != 0 {
+		t.Errorf("ReindexMissing() after fix = %d, %v, want 0", n, err)
+	}
+}
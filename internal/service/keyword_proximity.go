@@ -0,0 +1,142 @@
+package service
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// 邻近度/部分匹配评分参数
+const (
+	proximityWindow      = 20  // 关键词之间间隔的字符数在此范围内视为相邻，超出后按距离衰减
+	partialMatchCredit   = 0.5 // 关键词未完整出现、只出现部分片段时最多给的分数
+	proximityBlendWeight = 0.3 // 覆盖度和邻近度在关键词分数中的占比
+)
+
+// blendKeywordScore 将基础关键词分数与覆盖度、邻近度融合，结果仍在 0-1，外层继续按融合权重加权
+// 多词 CJK 查询（如 "支付 回调 超时"）中关键词集中出现的消息比分散出现的消息排名更高
+func blendKeywordScore(base float32, content string, keywords []string) float32 {
+	if len(keywords) == 0 {
+		return base
+	}
+	lower := strings.ToLower(content)
+	extra := (keywordCoverage(lower, keywords) + keywordProximity(lower, keywords)) / 2
+	return base*(1-proximityBlendWeight) + extra*proximityBlendWeight
+}
+
+// keywordCoverage 关键词覆盖度（content 需已转小写）
+// 完整出现记 1；未完整出现时，含空格的关键词按子词、多字关键词按二元片段的出现比例给部分分
+func keywordCoverage(content string, keywords []string) float32 {
+	var total float32
+	for _, kw := range keywords {
+		kw = strings.ToLower(strings.TrimSpace(kw))
+		if kw == "" {
+			continue
+		}
+		if strings.Contains(content, kw) {
+			total++
+			continue
+		}
+		total += partialMatchCredit * partialOverlap(content, kw)
+	}
+	return total / float32(len(keywords))
+}
+
+// partialOverlap 关键词片段在内容中出现的比例
+func partialOverlap(content, kw string) float32 {
+	var parts []string
+	if fields := strings.Fields(kw); len(fields) > 1 {
+		parts = fields
+	} else {
+		runes := []rune(kw)
+		if len(runes) < 3 {
+			return 0 // 两个字以内的关键词没有可比较的片段
+		}
+		for i := 0; i+1 < len(runes); i++ {
+			parts = append(parts, string(runes[i:i+2]))
+		}
+	}
+
+	found := 0
+	for _, p := range parts {
+		if strings.Contains(content, p) {
+			found++
+		}
+	}
+	return float32(found) / float32(len(parts))
+}
+
+// keywordOccurrence 关键词在内容中的一次出现（按字符位置）
+type keywordOccurrence struct {
+	pos    int
+	length int
+	kw     int // 关键词下标
+}
+
+// keywordProximity 邻近度（content 需已转小写）：覆盖所有已出现关键词的最短片段越紧凑分数越高
+// 按出现的关键词比例折算；只有一个关键词时等价于是否出现
+func keywordProximity(content string, keywords []string) float32 {
+	var occs []keywordOccurrence
+	matched := make(map[int]bool)
+	for i, kw := range keywords {
+		kw = strings.ToLower(strings.TrimSpace(kw))
+		if kw == "" {
+			continue
+		}
+		kwLen := utf8.RuneCountInString(kw)
+		for offset := 0; ; {
+			idx := strings.Index(content[offset:], kw)
+			if idx < 0 {
+				break
+			}
+			bytePos := offset + idx
+			occs = append(occs, keywordOccurrence{pos: utf8.RuneCountInString(content[:bytePos]), length: kwLen, kw: i})
+			matched[i] = true
+			offset = bytePos + len(kw)
+		}
+	}
+
+	ratio := float32(len(matched)) / float32(len(keywords))
+	if len(matched) < 2 {
+		if len(keywords) == 1 {
+			return ratio
+		}
+		return 0
+	}
+
+	gap := minKeywordGap(occs, len(matched))
+	return ratio / (1 + float32(gap)/proximityWindow)
+}
+
+// minKeywordGap 覆盖全部已出现关键词的最短窗口中，关键词之外的字符数
+func minKeywordGap(occs []keywordOccurrence, distinct int) int {
+	sort.Slice(occs, func(i, j int) bool { return occs[i].pos < occs[j].pos })
+
+	counts := make(map[int]int)
+	best := -1
+	left := 0
+	for right := range occs {
+		counts[occs[right].kw]++
+		for len(counts) == distinct {
+			end := occs[right].pos + occs[right].length
+			covered := 0
+			for i := left; i <= right; i++ {
+				covered += occs[i].length
+			}
+			gap := end - occs[left].pos - covered
+			if gap < 0 {
+				gap = 0 // 关键词相互重叠
+			}
+			if best < 0 || gap < best {
+				best = gap
+			}
+
+			counts[occs[left].kw]--
+			if counts[occs[left].kw] == 0 {
+				delete(counts, occs[left].kw)
+			}
+			left++
+		}
+	}
+	return best
+}
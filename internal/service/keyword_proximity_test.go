@@ -0,0 +1,104 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestKeywordProximityCloseVsScattered(t *testing.T) {
+	keywords := []string{"支付", "回调", "超时"}
+	close := "昨晚支付回调超时了，已经在排查"
+	scattered := "支付渠道今天切换了。另外运维说网关升级要到周五，请各位留意。回调地址不用改。接口超时的问题等下周再看"
+
+	closeScore := keywordProximity(close, keywords)
+	scatteredScore := keywordProximity(scattered, keywords)
+	if closeScore <= scatteredScore {
+		t.Errorf("Close occurrences should score higher: close=%.3f scattered=%.3f", closeScore, scatteredScore)
+	}
+	if closeScore != 1 {
+		t.Errorf("Adjacent keywords should score 1, got %.3f", closeScore)
+	}
+
+	// 邻近度只影响关键词分数：两条消息的覆盖度相同，融合后仍是集中出现的更高
+	if keywordCoverage(close, keywords) != keywordCoverage(scattered, keywords) {
+		t.Error("Both messages contain all keywords, coverage should be equal")
+	}
+	if blendKeywordScore(1, close, keywords) <= blendKeywordScore(1, scattered, keywords) {
+		t.Error("Blended score should prefer close occurrences")
+	}
+}
+
+func TestKeywordProximity(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		keywords []string
+		want     float32
+	}{
+		{"单个关键词出现", "登录报错", []string{"登录"}, 1},
+		{"单个关键词未出现", "支付报错", []string{"登录"}, 0},
+		{"只出现一个关键词", "登录报错", []string{"登录", "超时"}, 0},
+		{"相邻出现", "登录超时", []string{"登录", "超时"}, 1},
+		{"部分关键词相邻", "登录超时", []string{"登录", "超时", "支付"}, 2.0 / 3},
+		{"间隔 20 字", "登录" + strings.Repeat("啊", 20) + "超时", []string{"登录", "超时"}, 0.5},
+		{"取最近的一组", "登录。" + strings.Repeat("啊", 30) + "超时，又登录超时", []string{"登录", "超时"}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := keywordProximity(tt.content, tt.keywords)
+			if diff := got - tt.want; diff > 0.001 || diff < -0.001 {
+				t.Errorf("keywordProximity() = %.3f, want %.3f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeywordCoveragePartialMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		keywords []string
+		want     float32
+	}{
+		{"完整出现", "支付回调失败", []string{"支付回调"}, 1},
+		{"CJK 拆开出现", "支付的回调失败", []string{"支付回调"}, partialMatchCredit * 2 / 3},
+		{"多词关键词拆开出现", "登录页面超时", []string{"登录 超时"}, partialMatchCredit},
+		{"两字关键词不做部分匹配", "登出", []string{"登录"}, 0},
+		{"都未出现", "今天开会", []string{"支付回调"}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := keywordCoverage(tt.content, tt.keywords)
+			if diff := got - tt.want; diff > 0.001 || diff < -0.001 {
+				t.Errorf("keywordCoverage() = %.3f, want %.3f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFuseResultsProximity(t *testing.T) {
+	s := &RAGService{}
+	results := []SearchResult{
+		{MessageID: "scattered", Content: "支付渠道今天切换了，运维说网关升级要到周五。回调也报超时", Score: 0.5},
+		{MessageID: "close", Content: "支付回调超时", Score: 0.5},
+	}
+	keywords := []string{"支付", "回调", "超时"}
+
+	// 不开启邻近度时分数相同，保持原顺序
+	plain := s.fuseResults(results, keywords, nil, 0.6, 0.4)
+	if plain[0].Score != plain[1].Score {
+		t.Fatalf("Without proximity both should score the same: %+v", plain)
+	}
+
+	fused := s.fuseResults(results, keywords, keywords, 0.6, 0.4)
+	if fused[0].MessageID != "close" {
+		t.Errorf("Close occurrences should rank first, got %+v", fused)
+	}
+	for _, r := range fused {
+		if r.Score > 1 {
+			t.Errorf("Fused score should stay within fusion weights, got %.3f", r.Score)
+		}
+	}
+}
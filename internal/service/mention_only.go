@@ -0,0 +1,26 @@
+package service
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// mentionTokenPattern @提及（@张三、@_user_1、@所有人）
+var mentionTokenPattern = regexp.MustCompile(`@[^\s@]+`)
+
+// IsMentionOnly 内容是否只有 @提及（如 "@张三"），没有其他可检索的文字
+// 提及之外只剩空白或标点时也视为只有提及
+func IsMentionOnly(content string) bool {
+	content = strings.TrimSpace(content)
+	if !strings.HasPrefix(content, "@") {
+		return false
+	}
+	rest := mentionTokenPattern.ReplaceAllString(content, "")
+	for _, r := range rest {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			return false
+		}
+	}
+	return true
+}
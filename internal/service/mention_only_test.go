@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestIsMentionOnly(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    bool
+	}{
+		{"单个提及", "@张三", true},
+		{"多个提及", "@张三 @李四", true},
+		{"提及占位符", "@_user_1", true},
+		{"提及加标点", "@张三 ？", true},
+		{"首尾空白", "  @所有人\n", true},
+		{"提及加文字", "@张三 登录超时看一下", false},
+		{"文字在前", "请 @张三 看一下", false},
+		{"提及加数字", "@张三 3031", false},
+		{"普通消息", "今天下午三点开会", false},
+		{"空内容", "", false},
+		{"邮箱", "test@example.com", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsMentionOnly(tt.content); got != tt.want {
+				t.Errorf("IsMentionOnly(%q) = %v, want %v", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRAGServiceSkipsMentionOnly(t *testing.T) {
+	messages := []MessageVector{
+		{MessageID: "om_mention", Content: "@张三", CreatedAt: time.Now()},
+		{MessageID: "om_text", Content: "@张三 登录超时看一下", CreatedAt: time.Now()},
+	}
+
+	tests := []struct {
+		name             string
+		indexMentionOnly bool
+		wantMention      bool
+	}{
+		{"默认跳过", false, false},
+		{"配置为索引", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, backend := newTestRAGService(t)
+			svc.SetIndexMentionOnly(tt.indexMentionOnly)
+			if err := svc.IndexMessages(context.Background(), messages); err != nil {
+				t.Fatalf("IndexMessages() error: %v", err)
+			}
+			if !backend.pointIDs[messageIDToUUID("om_text")] {
+				t.Error("Message with text should be indexed")
+			}
+			if got := backend.pointIDs[messageIDToUUID("om_mention")]; got != tt.wantMention {
+				t.Errorf("Mention-only message indexed = %v, want %v", got, tt.wantMention)
+			}
+		})
+	}
+}
@@ -49,5 +49,7 @@ func FromMessageItem(item *lark.MessageItem) RawMessage {
 		ThreadID:   item.ThreadID,
 		SenderID:   item.Sender.ID,
 		Mentions:   mentions,
+
+		UpperMessageID: item.UpperMessageID,
 	}
 }
@@ -25,7 +25,8 @@ type RawMessage struct {
 	SenderID   string // 发送者 OpenID
 
 	// 可选字段（仅 API 拉取有）
-	ThreadID string // 话题ID
+	ThreadID       string // 话题ID
+	UpperMessageID string // 合并转发消息ID（合并转发的子消息才有）
 
 	// Mentions 统一格式
 	Mentions []MentionInfo
@@ -122,7 +123,13 @@ func (c *MessageConverter) Convert(ctx context.Context, raw RawMessage, opts ...
 		isAtBot = 1
 	}
 
-	// 6. 获取发送者名称
+	// 6. 标记转发消息
+	isForwarded := 0
+	if IsForwardedMessage(raw) {
+		isForwarded = 1
+	}
+
+	// 7. 获取发送者名称
 	senderName := ""
 	if options.UserNameFetcher != nil {
 		senderName = options.UserNameFetcher.GetUserName(ctx, raw.ChatID, raw.SenderID)
@@ -141,11 +148,25 @@ func (c *MessageConverter) Convert(ctx context.Context, raw RawMessage, opts ...
 		ThreadID:    sql.NullString{String: raw.ThreadID, Valid: raw.ThreadID != ""},
 		RootID:      sql.NullString{String: raw.RootID, Valid: raw.RootID != ""},
 		IsAtBot:     isAtBot,
+		IsForwarded: isForwarded,
 		CreatedAt:   createTime,
 		CreatedAtTs: sql.NullInt64{Int64: createTimeTs, Valid: true},
 	}
 }
 
+// forwardedMsgTypes 转发类消息类型（合并转发、分享群名片、分享个人名片）
+var forwardedMsgTypes = map[string]bool{
+	"merge_forward": true,
+	"share_chat":    true,
+	"share_user":    true,
+}
+
+// IsForwardedMessage 判断是否是转发消息
+// 合并转发本身及其子消息（带 upper_message_id）都视为转发内容，而不是本群成员的原创发言
+func IsForwardedMessage(raw RawMessage) bool {
+	return forwardedMsgTypes[raw.MsgType] || raw.UpperMessageID != ""
+}
+
 // ParseTimestamp 解析时间戳（统一逻辑）
 func (c *MessageConverter) ParseTimestamp(createTimeStr string) (time.Time, int64) {
 	if ts, err := strconv.ParseInt(createTimeStr, 10, 64); err == nil {
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"testing"
+)
+
+func TestIsForwardedMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  RawMessage
+		want bool
+	}{
+		{"普通文本", RawMessage{MsgType: "text"}, false},
+		{"富文本", RawMessage{MsgType: "post"}, false},
+		{"合并转发", RawMessage{MsgType: "merge_forward"}, true},
+		{"合并转发的子消息", RawMessage{MsgType: "text", UpperMessageID: "om_parent"}, true},
+		{"分享群名片", RawMessage{MsgType: "share_chat"}, true},
+		{"分享个人名片", RawMessage{MsgType: "share_user"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsForwardedMessage(tt.raw); got != tt.want {
+				t.Errorf("IsForwardedMessage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertTagsForwarded(t *testing.T) {
+	converter := NewMessageConverter()
+
+	native := converter.Convert(context.Background(), RawMessage{
+		MessageID:  "om_1",
+		MsgType:    "text",
+		RawContent: `{"text":"今天发版"}`,
+		CreateTime: "1700000000000",
+	})
+	if native.IsForwarded != 0 {
+		t.Errorf("Native message should not be tagged as forwarded")
+	}
+
+	forwarded := converter.Convert(context.Background(), RawMessage{
+		MessageID:      "om_2",
+		MsgType:        "text",
+		RawContent:     `{"text":"其他群的通知"}`,
+		CreateTime:     "1700000000000",
+		UpperMessageID: "om_merge",
+	})
+	if forwarded.IsForwarded != 1 {
+		t.Errorf("Forwarded message should be tagged as forwarded")
+	}
+	if forwarded.Content.String != "其他群的通知" {
+		t.Errorf("Content should be kept, got %q", forwarded.Content.String)
+	}
+}
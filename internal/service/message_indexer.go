@@ -40,6 +40,7 @@ func (i *MessageIndexer) IndexMessage(ctx context.Context, msg *model.ChatMessag
 		SenderID:   msg.SenderID.String,
 		SenderName: msg.SenderName.String,
 		Content:    msg.Content.String,
+		ReplyToID:  msg.ReplyToID.String,
 		CreatedAt:  msg.CreatedAt,
 	}
 
@@ -68,6 +69,7 @@ func (i *MessageIndexer) IndexMessages(ctx context.Context, msgs []*model.ChatMe
 				SenderID:   msg.SenderID.String,
 				SenderName: msg.SenderName.String,
 				Content:    msg.Content.String,
+				ReplyToID:  msg.ReplyToID.String,
 				CreatedAt:  msg.CreatedAt,
 			})
 		}
@@ -85,3 +87,11 @@ func (i *MessageIndexer) IndexMessages(ctx context.Context, msgs []*model.ChatMe
 	log.Printf("Indexed %d messages to vector DB", len(vectorMsgs))
 	return nil
 }
+
+// DeleteMessage 从向量库删除已删除的消息
+func (i *MessageIndexer) DeleteMessage(ctx context.Context, chatID, messageID string) error {
+	if !i.IsEnabled() {
+		return nil
+	}
+	return i.rag.DeleteMessage(ctx, chatID, messageID)
+}
@@ -69,6 +69,9 @@ func (s *MessageService) StoreMessage(ctx context.Context, event *lark.MessageRe
 		IsAtBot:    isAtBot,
 		CreatedAt:  sendTime,
 	}
+	if IsForwardedMessage(FromWebhookEvent(event)) {
+		msg.IsForwarded = 1
+	}
 
 	if err := s.messageRepo.Insert(ctx, msg); err != nil {
 		log.Printf("Failed to store message: %v", err)
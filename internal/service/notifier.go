@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// notifySendTimeout 补发单条通知的超时
+const notifySendTimeout = 30 * time.Second
+
+// userMessageSender 发送私聊消息（*lark.Client）
+type userMessageSender interface {
+	SendMessageToUser(ctx context.Context, openID, msgType, content string) error
+}
+
+// pendingNotification 免打扰时段内暂存的通知
+type pendingNotification struct {
+	openID  string
+	msgType string
+	content string
+}
+
+// Notifier 主动通知（同步完成等），免打扰时段内暂存，时段结束后补发
+// 暂存的通知只保存在内存中，进程重启会丢失；回复用户的消息不经过这里
+type Notifier struct {
+	sender     userMessageSender
+	quietUntil func(time.Time) time.Time // 返回免打扰时段的结束时间，不在时段内时返回原时间
+	now        func() time.Time
+
+	mu      sync.Mutex
+	pending []pendingNotification
+	timer   *time.Timer
+}
+
+// NewNotifier 创建通知器，quietUntil 为 nil 时不启用免打扰
+func NewNotifier(sender userMessageSender, quietUntil func(time.Time) time.Time) *Notifier {
+	return &Notifier{sender: sender, quietUntil: quietUntil, now: time.Now}
+}
+
+// SendToUser 私聊通知用户，免打扰时段内暂存到时段结束后发送
+func (n *Notifier) SendToUser(ctx context.Context, openID, msgType, content string) error {
+	if n.quietUntil != nil {
+		now := n.now()
+		if end := n.quietUntil(now); end.After(now) {
+			n.enqueue(pendingNotification{openID: openID, msgType: msgType, content: content}, end.Sub(now))
+			log.Printf("Quiet hours: notification to %s deferred until %s", openID, end.Format("01-02 15:04"))
+			return nil
+		}
+	}
+	return n.sender.SendMessageToUser(ctx, openID, msgType, content)
+}
+
+// enqueue 暂存通知，并在 delay 后补发
+func (n *Notifier) enqueue(p pendingNotification, delay time.Duration) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.pending = append(n.pending, p)
+	if n.timer == nil {
+		n.timer = time.AfterFunc(delay, n.Flush)
+	}
+}
+
+// Flush 发送所有暂存的通知
+func (n *Notifier) Flush() {
+	n.mu.Lock()
+	pending := n.pending
+	n.pending = nil
+	n.timer = nil
+	n.mu.Unlock()
+
+	if len(pending) > 0 {
+		log.Printf("Quiet hours ended, sending %d deferred notifications", len(pending))
+	}
+	for _, p := range pending {
+		ctx, cancel := context.WithTimeout(context.Background(), notifySendTimeout)
+		if err := n.sender.SendMessageToUser(ctx, p.openID, p.msgType, p.content); err != nil {
+			log.Printf("Failed to send deferred notification to %s: %v", p.openID, err)
+		}
+		cancel()
+	}
+}
+
+// Pending 暂存中的通知数量
+func (n *Notifier) Pending() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return len(n.pending)
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingSender 记录发送的私聊消息
+type recordingSender struct {
+	mu   sync.Mutex
+	sent []string
+}
+
+func (s *recordingSender) SendMessageToUser(ctx context.Context, openID, msgType, content string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent = append(s.sent, openID+":"+content)
+	return nil
+}
+
+func (s *recordingSender) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.sent)
+}
+
+func TestNotifierSendsOutsideQuietHours(t *testing.T) {
+	sender := &recordingSender{}
+	n := NewNotifier(sender, func(t time.Time) time.Time { return t })
+	if err := n.SendToUser(context.Background(), "ou_1", "text", "同步完成"); err != nil {
+		t.Fatalf("SendToUser() error: %v", err)
+	}
+	if sender.count() != 1 || n.Pending() != 0 {
+		t.Errorf("Expected immediate send, sent=%d pending=%d", sender.count(), n.Pending())
+	}
+
+	// 未配置免打扰
+	n = NewNotifier(sender, nil)
+	n.SendToUser(context.Background(), "ou_1", "text", "同步完成")
+	if sender.count() != 2 {
+		t.Errorf("Expected immediate send without quiet hours, sent=%d", sender.count())
+	}
+}
+
+func TestNotifierDefersDuringQuietHours(t *testing.T) {
+	sender := &recordingSender{}
+	now := time.Date(2024, 3, 4, 23, 0, 0, 0, time.Local)
+	n := NewNotifier(sender, func(t time.Time) time.Time { return t.Add(time.Hour) })
+	n.now = func() time.Time { return now }
+
+	for _, user := range []string{"ou_1", "ou_2"} {
+		if err := n.SendToUser(context.Background(), user, "text", "同步完成"); err != nil {
+			t.Fatalf("SendToUser() error: %v", err)
+		}
+	}
+	if sender.count() != 0 || n.Pending() != 2 {
+		t.Fatalf("Expected deferred notifications, sent=%d pending=%d", sender.count(), n.Pending())
+	}
+
+	n.Flush()
+	if sender.count() != 2 || n.Pending() != 0 {
+		t.Errorf("Expected flushed notifications, sent=%d pending=%d", sender.count(), n.Pending())
+	}
+	if sender.sent[0] != "ou_1:同步完成" || sender.sent[1] != "ou_2:同步完成" {
+		t.Errorf("Flushed in wrong order: %v", sender.sent)
+	}
+}
+
+func TestNotifierFlushesWhenQuietHoursEnd(t *testing.T) {
+	sender := &recordingSender{}
+	n := NewNotifier(sender, func(t time.Time) time.Time { return t.Add(20 * time.Millisecond) })
+	n.SendToUser(context.Background(), "ou_1", "text", "同步完成")
+
+	deadline := time.Now().Add(2 * time.Second)
+	for sender.count() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if sender.count() != 1 {
+		t.Errorf("Deferred notification should be sent after quiet hours, sent=%d", sender.count())
+	}
+}
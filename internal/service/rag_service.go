@@ -8,6 +8,7 @@ import (
 	"log"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 	"unicode/utf8"
 
@@ -24,17 +25,38 @@ func messageIDToUUID(messageID string) string {
 
 // RAGService RAG 服务（检索增强生成）
 type RAGService struct {
-	embeddingClient *embedding.OllamaClient
-	vectorDB        *vectordb.QdrantClient
-	collectionName  string
-	enabled         bool
-	bm25Scorer      *BM25Scorer  // BM25 评分器
-	chunker         *TextChunker // 文本分块器
-	enableChunking  bool         // 是否启用分块
-	reranker        *Reranker    // 重排序器
-	enableRerank    bool         // 是否启用重排序
+	embeddingClient  *embedding.OllamaClient
+	vectorDB         *vectordb.QdrantClient
+	collectionName   string
+	enabled          bool
+	bm25Scorer       *BM25Scorer           // BM25 评分器
+	chunker          *TextChunker          // 文本分块器
+	enableChunking   bool                  // 是否启用分块
+	reranker         *Reranker             // 重排序器
+	enableRerank     bool                  // 是否启用重排序
+	normalize        bool                  // 写入和查询前是否对向量做 L2 归一化
+	fitDimension     bool                  // 维度不一致时补零/截断到集合维度
+	statsCache       *CollectionStatsCache // 集合统计缓存
+	translator       Translator            // 索引前翻译（可选）
+	translateChats   map[string]bool       // 需要翻译的群ID
+	parentFinder     ParentContentFinder   // 回复消息补充父消息上下文（可选）
+	senderFilter     *SenderFilter         // 不参与索引的发言人（可选）
+	indexMentionOnly bool                  // 只有@提及的消息也参与索引
+	embedNumbers     string                // embedding 文本中数字/时间戳的处理方式（EmbedNumbers*）
+
+	perChat            bool            // 每个群一个集合（CollectionStrategyPerChat）
+	collectionsMu      sync.Mutex      // 保护 ensuredCollections
+	ensuredCollections map[string]bool // 已确认存在的群专属集合
 }
 
+// Translator 文本翻译接口（用于跨语言检索）
+type Translator interface {
+	Translate(ctx context.Context, text, targetLang string) (string, error)
+}
+
+// translateTargetLang 索引翻译的目标语言（查询大多为中文）
+const translateTargetLang = "中文"
+
 // MessageVector 消息向量数据
 type MessageVector struct {
 	MessageID  string    `json:"message_id"`
@@ -43,6 +65,7 @@ type MessageVector struct {
 	SenderID   string    `json:"sender_id"`
 	SenderName string    `json:"sender_name"`
 	Content    string    `json:"content"`
+	ReplyToID  string    `json:"reply_to_id"` // 被回复消息ID（开启回复上下文时用于查询父消息）
 	CreatedAt  time.Time `json:"created_at"`
 }
 
@@ -78,6 +101,14 @@ func NewRAGService(qdrantEndpoint, ollamaEndpoint, embeddingModel, collectionNam
 		log.Printf("RAG service initialized, collection: %s", collectionName)
 	}
 
+	// 预热集合统计缓存，之后由 RefreshStatsPeriodically 每 5 分钟刷新一次
+	svc.statsCache = NewCollectionStatsCache(func(ctx context.Context) (map[string]interface{}, error) {
+		return vectorClient.GetCollectionInfo(ctx, collectionName)
+	}, 5*time.Minute)
+	if err := svc.statsCache.Refresh(ctx); err != nil {
+		log.Printf("Failed to warm RAG collection stats: %v", err)
+	}
+
 	return svc
 }
 
@@ -98,14 +129,113 @@ func (s *RAGService) initCollection(ctx context.Context) error {
 			return fmt.Errorf("create collection: %w", err)
 		}
 		log.Printf("Created vector collection: %s (dimension: %d)", s.collectionName, dimension)
+		s.InvalidateStats()
 	}
 
 	return nil
 }
 
+// SetNormalizeEmbeddings 设置是否对向量做 L2 归一化
+// 集合使用 Cosine 距离时 Qdrant 会自行归一化，开启与否不影响结果；
+// 使用 Dot 距离且模型输出未归一化时必须开启，否则分数失真
+func (s *RAGService) SetNormalizeEmbeddings(enabled bool) {
+	s.normalize = enabled
+}
+
+// SetEmbedNumbers 设置生成 embedding 前如何处理数字和时间戳（keep/mask/strip），写入和查询使用同一方式
+// 修改后需要重建索引（go run ./cmd/reindex），否则新旧数据点的向量不可比
+func (s *RAGService) SetEmbedNumbers(mode string) {
+	s.embedNumbers = mode
+}
+
+// SetFitDimension 设置维度不一致时是否补零/截断到集合维度
+// 默认关闭，维度不一致的向量直接报错；迁移模型时个别服务会多返回或少返回几维，开启后记录警告并继续
+func (s *RAGService) SetFitDimension(enabled bool) {
+	s.fitDimension = enabled
+}
+
+// PointExists 检查消息在向量库中是否有数据点（整条索引或分块索引的第一块）
+func (s *RAGService) PointExists(ctx context.Context, chatID, messageID string) (bool, error) {
+	if !s.enabled {
+		return false, fmt.Errorf("RAG service not enabled")
+	}
+
+	ids := []string{messageIDToUUID(messageID), messageIDToUUID(messageID + "_chunk_0")}
+	points, err := s.vectorDB.GetPoints(ctx, s.collectionFor(chatID), ids)
+	if err != nil {
+		return false, fmt.Errorf("get points: %w", err)
+	}
+	return len(points) > 0, nil
+}
+
+// SetSenderFilter 设置不参与索引的发言人（监控机器人等）
+func (s *RAGService) SetSenderFilter(f *SenderFilter) {
+	s.senderFilter = f
+}
+
+// SetIndexMentionOnly 设置只有@提及的消息（如 "@张三"）是否参与索引，默认跳过
+func (s *RAGService) SetIndexMentionOnly(enabled bool) {
+	s.indexMentionOnly = enabled
+}
+
+// skipIndex 消息是否不参与索引：排除的发言人，或只有@提及的内容
+func (s *RAGService) skipIndex(msg MessageVector) bool {
+	if s.senderFilter.Excludes(msg.SenderID, msg.SenderName) {
+		return true
+	}
+	return !s.indexMentionOnly && IsMentionOnly(msg.Content)
+}
+
+// SetTranslator 设置索引前翻译
+// 指定群的消息会先翻译成中文，payload 中同时保存原文 content 和译文 content_zh，
+// 并使用译文生成 embedding，便于用中文检索其他语言的消息；翻译失败时回退到原文
+func (s *RAGService) SetTranslator(t Translator, chatIDs []string) {
+	s.translator = t
+	s.translateChats = make(map[string]bool, len(chatIDs))
+	for _, id := range chatIDs {
+		s.translateChats[id] = true
+	}
+}
+
+// prepareEmbedText 获取用于生成 embedding 的文本
+// 返回 embedding 文本和译文（未翻译时译文为空）
+func (s *RAGService) prepareEmbedText(ctx context.Context, chatID, content string) (embedText, translated string) {
+	if s.translator == nil || !s.translateChats[chatID] {
+		return content, ""
+	}
+
+	translated, err := s.translator.Translate(ctx, content, translateTargetLang)
+	if err != nil || strings.TrimSpace(translated) == "" {
+		if err != nil {
+			log.Printf("[RAG] Failed to translate message in chat %s, using original: %v", chatID, err)
+		}
+		return content, ""
+	}
+	return translated, translated
+}
+
+// getEmbedding 生成 embedding，按配置处理数字并做归一化（写入和查询共用，保证两侧一致）
+func (s *RAGService) getEmbedding(ctx context.Context, text string) ([]float32, error) {
+	vector, err := s.embeddingClient.GetEmbedding(ctx, NormalizeEmbedText(text, s.embedNumbers))
+	if err != nil {
+		return nil, err
+	}
+	if dim := s.embeddingClient.GetDimension(); len(vector) != dim {
+		if !s.fitDimension {
+			return nil, fmt.Errorf("embedding dimension mismatch: got %d, want %d", len(vector), dim)
+		}
+		log.Printf("[RAG] Warning: embedding dimension mismatch (got %d, want %d), fitting to collection dimension", len(vector), dim)
+		vector = embedding.FitDimension(vector, dim)
+	}
+	if s.normalize {
+		vector = embedding.NormalizeL2(vector)
+	}
+	return vector, nil
+}
+
 // IndexMessage 索引单条消息
 func (s *RAGService) IndexMessage(ctx context.Context, msg MessageVector) error {
-	if !s.enabled {
+	if !s.enabled || s.skipIndex(msg) {
 		return nil
 	}
 
@@ -120,8 +250,10 @@ func (s *RAGService) IndexMessage(ctx context.Context, msg MessageVector) error
 
 // indexMessageDirect 直接索引整条消息（不分块）
 func (s *RAGService) indexMessageDirect(ctx context.Context, msg MessageVector) error {
-	// 生成 embedding
-	vector, err := s.embeddingClient.GetEmbedding(ctx, msg.Content)
+	// 生成 embedding（需要翻译的群使用译文，回复消息拼接父消息）
+	embedText, translated := s.prepareEmbedText(ctx, msg.ChatID, msg.Content)
+	embedText = EmbedTextWithReplyContext(embedText, s.parentContent(ctx, msg, nil))
+	vector, err := s.getEmbedding(ctx, embedText)
 	if err != nil {
 		return fmt.Errorf("get embedding: %w", err)
 	}
@@ -141,8 +273,11 @@ func (s *RAGService) indexMessageDirect(ctx context.Context, msg MessageVector)
 			"is_chunk":    false,
 		},
 	}
+	if translated != "" {
+		point.Payload["content_zh"] = translated
+	}
 
-	if err := s.vectorDB.Upsert(ctx, s.collectionName, []vectordb.Point{point}); err != nil {
+	if err := s.upsertPoints(ctx, []vectordb.Point{point}); err != nil {
 		return fmt.Errorf("upsert point: %w", err)
 	}
 
@@ -166,13 +301,14 @@ func (s *RAGService) indexMessageWithChunks(ctx context.Context, msg MessageVect
 
 	points := make([]vectordb.Point, 0, len(chunks))
 	for _, chunk := range chunks {
-		vector, err := s.embeddingClient.GetEmbedding(ctx, chunk.Content)
+		embedText, translated := s.prepareEmbedText(ctx, msg.ChatID, chunk.Content)
+		vector, err := s.getEmbedding(ctx, embedText)
 		if err != nil {
 			log.Printf("[RAG] Failed to get embedding for chunk %s: %v", chunk.ID, err)
 			continue
 		}
 
-		points = append(points, vectordb.Point{
+		point := vectordb.Point{
 			ID:     messageIDToUUID(chunk.ID),
 			Vector: vector,
 			Payload: map[string]interface{}{
@@ -188,20 +324,41 @@ func (s *RAGService) indexMessageWithChunks(ctx context.Context, msg MessageVect
 				"created_at":   msg.CreatedAt.Format(time.RFC3339),
 				"is_chunk":     true,
 			},
-		})
+		}
+		if translated != "" {
+			point.Payload["content_zh"] = translated
+		}
+		points = append(points, point)
 	}
 
 	if len(points) == 0 {
 		return nil
 	}
 
-	if err := s.vectorDB.Upsert(ctx, s.collectionName, points); err != nil {
+	if err := s.upsertPoints(ctx, points); err != nil {
 		return fmt.Errorf("upsert chunks: %w", err)
 	}
 
 	return nil
 }
 
+// DeleteMessage 从向量库删除消息（包括分块），用于飞书中已删除的消息；消息未索引过时不报错
+func (s *RAGService) DeleteMessage(ctx context.Context, chatID, messageID string) error {
+	if !s.enabled || messageID == "" {
+		return nil
+	}
+
+	filter := map[string]interface{}{
+		"must": []map[string]interface{}{
+			{"key": "message_id", "match": map[string]interface{}{"value": messageID}},
+		},
+	}
+	if err := s.vectorDB.DeletePoints(ctx, s.collectionFor(chatID), []string{messageIDToUUID(messageID)}, filter); err != nil {
+		return fmt.Errorf("delete message %s: %w", messageID, err)
+	}
+	return nil
+}
+
 // IndexMessages 批量索引消息
 func (s *RAGService) IndexMessages(ctx context.Context, messages []MessageVector) error {
 	if !s.enabled || len(messages) == 0 {
@@ -213,9 +370,18 @@ func (s *RAGService) IndexMessages(ctx context.Context, messages []MessageVector
 	points := make([]vectordb.Point, 0, len(messages)*2) // 预留分块空间
 	totalChunks := 0
 
+	// 同一批次内的消息内容，回复消息优先从这里取父消息
+	var batch map[string]string
+	if s.parentFinder != nil {
+		batch = make(map[string]string, len(messages))
+		for _, msg := range messages {
+			batch[msg.MessageID] = msg.Content
+		}
+	}
+
 	for _, msg := range messages {
-		// 跳过空内容
-		if strings.TrimSpace(msg.Content) == "" {
+		// 跳过空内容、排除的发言人和只有@提及的消息
+		if strings.TrimSpace(msg.Content) == "" || s.skipIndex(msg) {
 			continue
 		}
 
@@ -225,12 +391,13 @@ func (s *RAGService) IndexMessages(ctx context.Context, messages []MessageVector
 			if len(chunks) > 1 {
 				totalChunks += len(chunks)
 				for _, chunk := range chunks {
-					vector, err := s.embeddingClient.GetEmbedding(ctx, chunk.Content)
+					embedText, translated := s.prepareEmbedText(ctx, msg.ChatID, chunk.Content)
+					vector, err := s.getEmbedding(ctx, embedText)
 					if err != nil {
 						log.Printf("Failed to get embedding for chunk %s: %v", chunk.ID, err)
 						continue
 					}
-					points = append(points, vectordb.Point{
+					point := vectordb.Point{
 						ID:     messageIDToUUID(chunk.ID),
 						Vector: vector,
 						Payload: map[string]interface{}{
@@ -246,20 +413,26 @@ func (s *RAGService) IndexMessages(ctx context.Context, messages []MessageVector
 							"created_at":   msg.CreatedAt.Format(time.RFC3339),
 							"is_chunk":     true,
 						},
-					})
+					}
+					if translated != "" {
+						point.Payload["content_zh"] = translated
+					}
+					points = append(points, point)
 				}
 				continue
 			}
 		}
 
 		// 不需要分块，直接索引
-		vector, err := s.embeddingClient.GetEmbedding(ctx, msg.Content)
+		embedText, translated := s.prepareEmbedText(ctx, msg.ChatID, msg.Content)
+		embedText = EmbedTextWithReplyContext(embedText, s.parentContent(ctx, msg, batch))
+		vector, err := s.getEmbedding(ctx, embedText)
 		if err != nil {
 			log.Printf("Failed to get embedding for message %s: %v", msg.MessageID, err)
 			continue
 		}
 
-		points = append(points, vectordb.Point{
+		point := vectordb.Point{
 			ID:     messageIDToUUID(msg.MessageID),
 			Vector: vector,
 			Payload: map[string]interface{}{
@@ -272,14 +445,18 @@ func (s *RAGService) IndexMessages(ctx context.Context, messages []MessageVector
 				"created_at":  msg.CreatedAt.Format(time.RFC3339),
 				"is_chunk":    false,
 			},
-		})
+		}
+		if translated != "" {
+			point.Payload["content_zh"] = translated
+		}
+		points = append(points, point)
 	}
 
 	if len(points) == 0 {
 		return nil
 	}
 
-	if err := s.vectorDB.Upsert(ctx, s.collectionName, points); err != nil {
+	if err := s.upsertPoints(ctx, points); err != nil {
 		return fmt.Errorf("batch upsert: %w", err)
 	}
 
@@ -299,6 +476,7 @@ type SearchResult struct {
 	SenderID   string    `json:"sender_id"`
 	SenderName string    `json:"sender_name"`
 	Content    string    `json:"content"`
+	ContentZh  string    `json:"content_zh,omitempty"` // 译文（仅开启翻译的群有）
 	CreatedAt  time.Time `json:"created_at"`
 	Score      float32   `json:"score"`
 }
@@ -306,6 +484,7 @@ type SearchResult struct {
 // SearchOptions 搜索选项
 type SearchOptions struct {
 	ChatID     string     // 群ID过滤
+	ChatIDs    []string   // 多群过滤（ChatID 为空时生效，用于跨群问答限定在最相关的群）
 	SenderName string     // 发送者名称过滤
 	StartTime  *time.Time // 开始时间
 	EndTime    *time.Time // 结束时间
@@ -323,7 +502,7 @@ func (s *RAGService) SearchWithOptions(ctx context.Context, query string, limit
 	}
 
 	// 生成查询的 embedding
-	queryVector, err := s.embeddingClient.GetEmbedding(ctx, query)
+	queryVector, err := s.getEmbedding(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("get query embedding: %w", err)
 	}
@@ -337,6 +516,11 @@ func (s *RAGService) SearchWithOptions(ctx context.Context, query string, limit
 			"key":   "chat_id",
 			"match": map[string]interface{}{"value": opts.ChatID},
 		})
+	} else if len(opts.ChatIDs) > 0 {
+		mustFilters = append(mustFilters, map[string]interface{}{
+			"key":   "chat_id",
+			"match": map[string]interface{}{"any": opts.ChatIDs},
+		})
 	}
 
 	// 发送者名称过滤（模糊匹配）
@@ -372,10 +556,21 @@ func (s *RAGService) SearchWithOptions(ctx context.Context, query string, limit
 		}
 	}
 
-	// 向量搜索
-	results, err := s.vectorDB.Search(ctx, s.collectionName, queryVector, limit, filter)
+	// 向量搜索（per_chat 时可能跨多个群集合）
+	collections, err := s.searchCollections(ctx, opts)
 	if err != nil {
-		return nil, fmt.Errorf("vector search: %w", err)
+		return nil, err
+	}
+	var results []vectordb.SearchResult
+	for _, name := range collections {
+		found, err := s.vectorDB.Search(ctx, name, queryVector, limit, filter)
+		if err != nil {
+			return nil, fmt.Errorf("vector search: %w", err)
+		}
+		results = append(results, found...)
+	}
+	if len(collections) > 1 {
+		results = mergeSearchResults(results, limit)
 	}
 
 	// 转换结果
@@ -389,6 +584,7 @@ func (s *RAGService) SearchWithOptions(ctx context.Context, query string, limit
 			SenderID:   getString(r.Payload, "sender_id"),
 			SenderName: getString(r.Payload, "sender_name"),
 			Content:    getString(r.Payload, "content"),
+			ContentZh:  getString(r.Payload, "content_zh"),
 			CreatedAt:  createdAt,
 			Score:      r.Score,
 		})
@@ -429,13 +625,29 @@ func (s *RAGService) IsEnabled() bool {
 	return s.enabled
 }
 
-// GetStats 获取统计信息
+// KeepEmbeddingAlive 定期发送保活请求让 embedding 模型常驻内存，阻塞直到 ctx 取消；未启用时直接返回
+func (s *RAGService) KeepEmbeddingAlive(ctx context.Context, interval time.Duration) {
+	if !s.enabled {
+		return
+	}
+	s.embeddingClient.KeepAlive(ctx, interval)
+}
+
+// RefreshStatsPeriodically 定期刷新集合统计缓存，阻塞直到 ctx 取消；未启用时直接返回
+func (s *RAGService) RefreshStatsPeriodically(ctx context.Context) {
+	if !s.enabled || s.statsCache == nil {
+		return
+	}
+	s.statsCache.Run(ctx)
+}
+
+// GetStats 获取统计信息（读取缓存）
 func (s *RAGService) GetStats(ctx context.Context) map[string]interface{} {
 	if !s.enabled {
 		return map[string]interface{}{"enabled": false}
 	}
 
-	info, err := s.vectorDB.GetCollectionInfo(ctx, s.collectionName)
+	stats, err := s.GetCollectionStats(ctx)
 	if err != nil {
 		return map[string]interface{}{
 			"enabled": true,
@@ -446,7 +658,29 @@ func (s *RAGService) GetStats(ctx context.Context) map[string]interface{} {
 	return map[string]interface{}{
 		"enabled":    true,
 		"collection": s.collectionName,
-		"info":       info,
+		"info":       stats,
+	}
+}
+
+// GetCollectionStats 获取缓存的集合统计信息（数量、维度、状态）
+func (s *RAGService) GetCollectionStats(ctx context.Context) (*CollectionStats, error) {
+	if !s.enabled || s.statsCache == nil {
+		return nil, fmt.Errorf("RAG service disabled")
+	}
+	return s.statsCache.Get(ctx)
+}
+
+// SetIndexVersion 设置跨进程的索引版本来源，reindex 进程重建索引后统计缓存随之失效
+func (s *RAGService) SetIndexVersion(src IndexVersionSource) {
+	if s.statsCache != nil {
+		s.statsCache.SetVersionSource(src)
+	}
+}
+
+// InvalidateStats 使集合统计缓存失效（重建集合或重新索引后调用）
+func (s *RAGService) InvalidateStats() {
+	if s.statsCache != nil {
+		s.statsCache.Invalidate()
 	}
 }
 
@@ -467,15 +701,18 @@ type HybridSearchOptions struct {
 	SemanticWeight float32  // 语义搜索权重（0-1），默认 0.6
 	KeywordWeight  float32  // 关键词匹配权重（0-1），默认 0.4
 	DynamicLimit   bool     // 是否启用动态 limit 调整
+	// 关键词分数同时考虑邻近度和部分匹配（多个关键词集中出现的消息排名更高），仍受 KeywordWeight 约束
+	ProximityScoring bool
 }
 
 // DefaultHybridSearchOptions 默认混合搜索选项
 func DefaultHybridSearchOptions() HybridSearchOptions {
 	return HybridSearchOptions{
-		ExpandSynonyms: true,
-		SemanticWeight: 0.6,
-		KeywordWeight:  0.4,
-		DynamicLimit:   true,
+		ExpandSynonyms:   true,
+		SemanticWeight:   0.6,
+		KeywordWeight:    0.4,
+		DynamicLimit:     true,
+		ProximityScoring: true,
 	}
 }
 
@@ -525,7 +762,12 @@ func (s *RAGService) HybridSearch(ctx context.Context, query string, keywords []
 	}
 
 	// 5. 对结果进行关键词加权融合
-	fusedResults := s.fuseResults(semanticResults, expandedKeywords, opts.SemanticWeight, opts.KeywordWeight)
+	// 邻近度按原始关键词计算，避免同义词扩展稀释覆盖率
+	var proximityKeywords []string
+	if opts.ProximityScoring {
+		proximityKeywords = keywords
+	}
+	fusedResults := s.fuseResults(semanticResults, expandedKeywords, proximityKeywords, opts.SemanticWeight, opts.KeywordWeight)
 
 	// 6. 重排序（如果启用）
 	if s.enableRerank && s.reranker != nil && len(fusedResults) > 1 {
@@ -590,7 +832,8 @@ func (s *RAGService) calculateDynamicLimit(query string, keywords []string, base
 }
 
 // fuseResults 融合语义搜索结果和关键词匹配
-func (s *RAGService) fuseResults(semanticResults []SearchResult, keywords []string, semanticWeight, keywordWeight float32) []SearchResult {
+// proximityKeywords 不为空时关键词分数额外考虑邻近度和部分匹配
+func (s *RAGService) fuseResults(semanticResults []SearchResult, keywords, proximityKeywords []string, semanticWeight, keywordWeight float32) []SearchResult {
 	if len(semanticResults) == 0 {
 		return semanticResults
 	}
@@ -620,8 +863,15 @@ func (s *RAGService) fuseResults(semanticResults []SearchResult, keywords []stri
 		// 语义分数（已归一化到 0-1）
 		semanticScore := r.Score
 
-		// 关键词匹配分数
-		keywordScore := s.calculateKeywordScore(r.Content, lowerKeywords)
+		// 关键词匹配分数（有译文时同时匹配原文和译文）
+		matchContent := r.Content
+		if r.ContentZh != "" {
+			matchContent += "\n" + r.ContentZh
+		}
+		keywordScore := s.calculateKeywordScore(matchContent, lowerKeywords)
+		if len(proximityKeywords) > 0 {
+			keywordScore = blendKeywordScore(keywordScore, matchContent, proximityKeywords)
+		}
 
 		// 融合分数
 		fusedScore := semanticScore*semWeight + keywordScore*kwWeight
@@ -0,0 +1,453 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"team-assistant/pkg/vectordb"
+)
+
+// fakeTranslator 测试用翻译器
+type fakeTranslator struct {
+	result string
+	err    error
+	calls  int
+}
+
+func (f *fakeTranslator) Translate(ctx context.Context, text, targetLang string) (string, error) {
+	f.calls++
+	return f.result, f.err
+}
+
+// fakeVectorBackend 模拟 Ollama 和 Qdrant 的 HTTP 接口，记录 embedding 文本和写入的 payload
+type fakeVectorBackend struct {
+	mu       sync.Mutex
+	prompts  []string
+	payloads []map[string]interface{}
+	filters  []map[string]interface{}
+	pointIDs map[string]bool // 已写入的数据点 ID
+
+	collections map[string]bool                    // 创建过的集合
+	upserted    map[string]int                     // 集合 -> 写入的数据点数
+	searched    []string                           // 依次检索的集合
+	hits        map[string][]vectordb.SearchResult // 集合 -> 检索返回的结果
+}
+
+func (b *fakeVectorBackend) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	collection, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/collections/"), "/")
+
+	switch {
+	case r.URL.Path == "/collections" && r.Method == http.MethodGet:
+		b.mu.Lock()
+		names := make([]map[string]string, 0, len(b.collections))
+		for name := range b.collections {
+			names = append(names, map[string]string{"name": name})
+		}
+		b.mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]interface{}{"result": map[string]interface{}{"collections": names}, "status": "ok"})
+	case r.URL.Path == "/collections/"+collection && r.Method == http.MethodPut:
+		b.mu.Lock()
+		if b.collections == nil {
+			b.collections = make(map[string]bool)
+		}
+		b.collections[collection] = true
+		b.mu.Unlock()
+		w.Write([]byte(`{"result": true, "status": "ok"}`))
+	case r.URL.Path == "/api/embeddings":
+		var req struct {
+			Prompt string `json:"prompt"`
+		}
+		json.Unmarshal(body, &req)
+		b.mu.Lock()
+		b.prompts = append(b.prompts, req.Prompt)
+		b.mu.Unlock()
+		w.Write([]byte(`{"embedding": [0.1, 0.2, 0.3]}`))
+	case strings.HasSuffix(r.URL.Path, "/points/search"):
+		var req struct {
+			Filter map[string]interface{} `json:"filter"`
+		}
+		json.Unmarshal(body, &req)
+		b.mu.Lock()
+		b.filters = append(b.filters, req.Filter)
+		b.searched = append(b.searched, collection)
+		hits := b.hits[collection]
+		b.mu.Unlock()
+		if hits == nil {
+			hits = []vectordb.SearchResult{}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"result": hits, "status": "ok"})
+	case strings.HasSuffix(r.URL.Path, "/points") && r.Method == http.MethodPut:
+		var req struct {
+			Points []struct {
+				ID      string                 `json:"id"`
+				Payload map[string]interface{} `json:"payload"`
+			} `json:"points"`
+		}
+		json.Unmarshal(body, &req)
+		b.mu.Lock()
+		if b.pointIDs == nil {
+			b.pointIDs = make(map[string]bool)
+			b.upserted = make(map[string]int)
+		}
+		b.upserted[collection] += len(req.Points)
+		for _, p := range req.Points {
+			b.payloads = append(b.payloads, p.Payload)
+			b.pointIDs[p.ID] = true
+		}
+		b.mu.Unlock()
+		w.Write([]byte(`{"status": "ok"}`))
+	case strings.HasSuffix(r.URL.Path, "/points") && r.Method == http.MethodPost:
+		var req struct {
+			IDs []string `json:"ids"`
+		}
+		json.Unmarshal(body, &req)
+		var found []map[string]interface{}
+		b.mu.Lock()
+		for _, id := range req.IDs {
+			if b.pointIDs[id] {
+				found = append(found, map[string]interface{}{"id": id, "payload": map[string]interface{}{}})
+			}
+		}
+		b.mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]interface{}{"result": found, "status": "ok"})
+	default:
+		// 集合存在检查、集合信息
+		w.Write([]byte(`{"result": {"status": "green", "points_count": 0}, "status": "ok"}`))
+	}
+}
+
+func newTestRAGService(t *testing.T) (*RAGService, *fakeVectorBackend) {
+	backend := &fakeVectorBackend{}
+	server := httptest.NewServer(backend)
+	t.Cleanup(server.Close)
+
+	svc := NewRAGService(server.URL, server.URL, "test-model", "test", 3, true)
+	svc.enableChunking = false
+	return svc, backend
+}
+
+func TestIndexMessageWithTranslation(t *testing.T) {
+	svc, backend := newTestRAGService(t)
+	translator := &fakeTranslator{result: "支付失败，请检查余额"}
+	svc.SetTranslator(translator, []string{"oc_indonesia"})
+
+	msg := MessageVector{
+		MessageID: "om_1",
+		ChatID:    "oc_indonesia",
+		Content:   "Pembayaran gagal, silakan cek saldo",
+		CreatedAt: time.Now(),
+	}
+	if err := svc.IndexMessage(context.Background(), msg); err != nil {
+		t.Fatalf("IndexMessage failed: %v", err)
+	}
+
+	if len(backend.prompts) != 1 || backend.prompts[0] != "支付失败，请检查余额" {
+		t.Errorf("Expected embedding on translated text, got %v", backend.prompts)
+	}
+	if len(backend.payloads) != 1 {
+		t.Fatalf("Expected 1 payload, got %d", len(backend.payloads))
+	}
+	payload := backend.payloads[0]
+	if payload["content"] != msg.Content {
+		t.Errorf("Expected original content kept, got %v", payload["content"])
+	}
+	if payload["content_zh"] != "支付失败，请检查余额" {
+		t.Errorf("Expected content_zh translated, got %v", payload["content_zh"])
+	}
+}
+
+func TestIndexMessagesTranslationScopedToChats(t *testing.T) {
+	svc, backend := newTestRAGService(t)
+	translator := &fakeTranslator{result: "译文"}
+	svc.SetTranslator(translator, []string{"oc_indonesia"})
+
+	messages := []MessageVector{
+		{MessageID: "om_1", ChatID: "oc_indonesia", Content: "halo", CreatedAt: time.Now()},
+		{MessageID: "om_2", ChatID: "oc_dev", Content: "你好", CreatedAt: time.Now()},
+	}
+	if err := svc.IndexMessages(context.Background(), messages); err != nil {
+		t.Fatalf("IndexMessages failed: %v", err)
+	}
+
+	if translator.calls != 1 {
+		t.Errorf("Expected only configured chat translated, got %d calls", translator.calls)
+	}
+	if len(backend.payloads) != 2 {
+		t.Fatalf("Expected 2 payloads, got %d", len(backend.payloads))
+	}
+	if backend.payloads[0]["content_zh"] != "译文" {
+		t.Errorf("Expected content_zh for translated chat, got %v", backend.payloads[0]["content_zh"])
+	}
+	if _, ok := backend.payloads[1]["content_zh"]; ok {
+		t.Errorf("Expected no content_zh for untranslated chat, got %v", backend.payloads[1]["content_zh"])
+	}
+	if backend.prompts[1] != "你好" {
+		t.Errorf("Expected original text embedded for untranslated chat, got %q", backend.prompts[1])
+	}
+}
+
+func TestIndexMessageTranslationFallback(t *testing.T) {
+	svc, backend := newTestRAGService(t)
+	svc.SetTranslator(&fakeTranslator{err: errors.New("llm unavailable")}, []string{"oc_indonesia"})
+
+	msg := MessageVector{MessageID: "om_1", ChatID: "oc_indonesia", Content: "halo semua", CreatedAt: time.Now()}
+	if err := svc.IndexMessage(context.Background(), msg); err != nil {
+		t.Fatalf("IndexMessage failed: %v", err)
+	}
+
+	if len(backend.prompts) != 1 || backend.prompts[0] != "halo semua" {
+		t.Errorf("Expected fallback to original text, got %v", backend.prompts)
+	}
+	if _, ok := backend.payloads[0]["content_zh"]; ok {
+		t.Errorf("Expected no content_zh when translation fails")
+	}
+}
+
+func TestGetEmbeddingDimensionMismatch(t *testing.T) {
+	server := httptest.NewServer(&fakeVectorBackend{})
+	t.Cleanup(server.Close)
+
+	// 后端固定返回 3 维向量
+	tests := []struct {
+		name    string
+		dim     int
+		fit     bool
+		want    []float32
+		wantErr bool
+	}{
+		{"维度一致", 3, false, []float32{0.1, 0.2, 0.3}, false},
+		{"默认关闭时报错", 4, false, nil, true},
+		{"补零", 5, true, []float32{0.1, 0.2, 0.3, 0, 0}, false},
+		{"截断", 2, true, []float32{0.1, 0.2}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewRAGService(server.URL, server.URL, "test-model", "test", tt.dim, true)
+			svc.SetFitDimension(tt.fit)
+
+			vec, err := svc.getEmbedding(context.Background(), "支付失败")
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected dimension mismatch error, got %v", vec)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("getEmbedding failed: %v", err)
+			}
+			if len(vec) != len(tt.want) {
+				t.Fatalf("Expected %d dimensions, got %d", len(tt.want), len(vec))
+			}
+			for i := range vec {
+				if vec[i] != tt.want[i] {
+					t.Errorf("Expected %v, got %v", tt.want, vec)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestSearchWithOptionsChatFilter(t *testing.T) {
+	tests := []struct {
+		name string
+		opts SearchOptions
+		want string
+	}{
+		{"单群", SearchOptions{ChatID: "oc_a", ChatIDs: []string{"oc_b"}}, `{"must":[{"key":"chat_id","match":{"value":"oc_a"}}]}`},
+		{"多群", SearchOptions{ChatIDs: []string{"oc_a", "oc_b"}}, `{"must":[{"key":"chat_id","match":{"any":["oc_a","oc_b"]}}]}`},
+		{"不过滤", SearchOptions{}, `null`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, backend := newTestRAGService(t)
+			if _, err := svc.SearchWithOptions(context.Background(), "支付失败", 10, tt.opts); err != nil {
+				t.Fatalf("SearchWithOptions failed: %v", err)
+			}
+			if len(backend.filters) != 1 {
+				t.Fatalf("Expected 1 search request, got %d", len(backend.filters))
+			}
+			got, _ := json.Marshal(backend.filters[0])
+			if string(got) != tt.want {
+				t.Errorf("filter = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeParentFinder 内存版父消息查询
+type fakeParentFinder struct {
+	contents map[string]string
+	lookups  []string
+}
+
+func (f *fakeParentFinder) GetContentByMessageID(ctx context.Context, messageID string) (string, error) {
+	f.lookups = append(f.lookups, messageID)
+	content, ok := f.contents[messageID]
+	if !ok {
+		return "", sql.ErrNoRows
+	}
+	return content, nil
+}
+
+func TestIndexMessageWithReplyContext(t *testing.T) {
+	tests := []struct {
+		name       string
+		finder     *fakeParentFinder
+		replyTo    string
+		wantPrompt string
+	}{
+		{"拼接父消息", &fakeParentFinder{contents: map[string]string{"om_parent": "登录页的验证码修了吗"}}, "om_parent", "回复「登录页的验证码修了吗」：是的，改好了"},
+		{"父消息不存在", &fakeParentFinder{}, "om_missing", "是的，改好了"},
+		{"不是回复", &fakeParentFinder{}, "", "是的，改好了"},
+		{"未开启", nil, "om_parent", "是的，改好了"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, backend := newTestRAGService(t)
+			if tt.finder != nil {
+				svc.SetReplyContext(tt.finder)
+			}
+
+			msg := MessageVector{MessageID: "om_1", ChatID: "oc_dev", Content: "是的，改好了", ReplyToID: tt.replyTo, CreatedAt: time.Now()}
+			if err := svc.IndexMessage(context.Background(), msg); err != nil {
+				t.Fatalf("IndexMessage failed: %v", err)
+			}
+
+			if len(backend.prompts) != 1 || backend.prompts[0] != tt.wantPrompt {
+				t.Errorf("Expected embedding text %q, got %v", tt.wantPrompt, backend.prompts)
+			}
+			if backend.payloads[0]["content"] != "是的，改好了" {
+				t.Errorf("Expected original content kept for display, got %v", backend.payloads[0]["content"])
+			}
+		})
+	}
+}
+
+func TestIndexMessagesReplyContextFromBatch(t *testing.T) {
+	svc, backend := newTestRAGService(t)
+	finder := &fakeParentFinder{}
+	svc.SetReplyContext(finder)
+
+	messages := []MessageVector{
+		{MessageID: "om_1", ChatID: "oc_dev", Content: "支付回调超时了", CreatedAt: time.Now()},
+		{MessageID: "om_2", ChatID: "oc_dev", Content: "已修复", ReplyToID: "om_1", CreatedAt: time.Now()},
+	}
+	if err := svc.IndexMessages(context.Background(), messages); err != nil {
+		t.Fatalf("IndexMessages failed: %v", err)
+	}
+
+	if len(finder.lookups) != 0 {
+		t.Errorf("Expected parent taken from the same batch, got lookups %v", finder.lookups)
+	}
+	if backend.prompts[1] != "回复「支付回调超时了」：已修复" {
+		t.Errorf("Unexpected embedding text: %q", backend.prompts[1])
+	}
+	if backend.payloads[1]["content"] != "已修复" {
+		t.Errorf("Expected original content kept, got %v", backend.payloads[1]["content"])
+	}
+}
+
+func TestEmbedTextWithReplyContextTruncates(t *testing.T) {
+	parent := strings.Repeat("长", replyContextMaxRunes+10)
+	got := EmbedTextWithReplyContext("好的", parent)
+	want := "回复「" + strings.Repeat("长", replyContextMaxRunes) + "…」：好的"
+	if got != want {
+		t.Errorf("EmbedTextWithReplyContext() = %q, want %q", got, want)
+	}
+	if got := EmbedTextWithReplyContext("好的", "  "); got != "好的" {
+		t.Errorf("Expected content unchanged for empty parent, got %q", got)
+	}
+}
+
+func TestPointExists(t *testing.T) {
+	svc, backend := newTestRAGService(t)
+	ctx := context.Background()
+
+	msg := MessageVector{MessageID: "om_indexed", ChatID: "oc_dev", Content: "登录超时已修复", CreatedAt: time.Now()}
+	if err := svc.IndexMessage(ctx, msg); err != nil {
+		t.Fatalf("IndexMessage failed: %v", err)
+	}
+	// 分块索引的消息只有 <message_id>_chunk_N 数据点
+	backend.pointIDs[messageIDToUUID("om_chunked_chunk_0")] = true
+
+	tests := []struct {
+		name      string
+		messageID string
+		want      bool
+	}{
+		{"整条索引", "om_indexed", true},
+		{"分块索引", "om_chunked", true},
+		{"未索引", "om_missing", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := svc.PointExists(ctx, "oc_dev", tt.messageID)
+			if err != nil {
+				t.Fatalf("PointExists() error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("PointExists(%s) = %v, want %v", tt.messageID, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPointExistsDisabled(t *testing.T) {
+	svc := &RAGService{}
+	if _, err := svc.PointExists(context.Background(), "oc_dev", "om_1"); err == nil {
+		t.Error("Expected error when RAG is disabled")
+	}
+}
+
+func TestDeleteMessage(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if strings.HasSuffix(r.URL.Path, "/points/delete") {
+			mu.Lock()
+			paths = append(paths, r.URL.Path)
+			bodies = append(bodies, string(body))
+			mu.Unlock()
+		}
+		w.Write([]byte(`{"result": {"status": "green", "points_count": 0}, "status": "ok"}`))
+	}))
+	defer server.Close()
+
+	svc := NewRAGService(server.URL, server.URL, "test-model", "test", 3, true)
+	svc.SetCollectionStrategy(CollectionStrategyPerChat)
+
+	if err := svc.DeleteMessage(context.Background(), "oc_a", "om_1"); err != nil {
+		t.Fatalf("DeleteMessage() error: %v", err)
+	}
+	if len(paths) != 2 || paths[0] != "/collections/test_oc_a/points/delete" {
+		t.Fatalf("DeleteMessage() requests = %v, want deletes in chat collection", paths)
+	}
+	if !strings.Contains(bodies[0], messageIDToUUID("om_1")) {
+		t.Errorf("Expected point UUID in delete request, got %s", bodies[0])
+	}
+	if !strings.Contains(bodies[1], `"message_id"`) || !strings.Contains(bodies[1], `"om_1"`) {
+		t.Errorf("Expected chunk filter on message_id, got %s", bodies[1])
+	}
+
+	disabled := &RAGService{}
+	if err := disabled.DeleteMessage(context.Background(), "oc_a", "om_1"); err != nil {
+		t.Errorf("Disabled service should ignore delete, got %v", err)
+	}
+}
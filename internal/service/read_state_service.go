@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"team-assistant/internal/interfaces"
+)
+
+const (
+	// defaultDigestLookback 没有已读记录时，默认回看的时间
+	defaultDigestLookback = 7 * 24 * time.Hour
+	// maxDigestLookback 最多回看的时间（避免休假很久后一次总结过多消息）
+	maxDigestLookback = 30 * 24 * time.Hour
+)
+
+// ReadStateService 用户已读状态服务
+// 记录用户在每个群最后一次查看/提问的时间，用于"我不在的时候发生了什么"
+type ReadStateService struct {
+	repo interfaces.ReadStateRepository
+}
+
+// NewReadStateService 创建已读状态服务
+func NewReadStateService(repo interfaces.ReadStateRepository) *ReadStateService {
+	return &ReadStateService{repo: repo}
+}
+
+// DigestRange 计算用户在群内需要补看的时间范围
+// hasState 为 false 表示没有已读记录，使用默认回看时间
+func (s *ReadStateService) DigestRange(ctx context.Context, userID, chatID string, now time.Time) (start, end time.Time, hasState bool, err error) {
+	state, err := s.repo.Get(ctx, userID, chatID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, time.Time{}, false, err
+	}
+
+	var lastRead *time.Time
+	if state != nil {
+		lastRead = &state.LastReadAt
+	}
+
+	start, end = computeDigestRange(lastRead, now)
+	return start, end, lastRead != nil, nil
+}
+
+// MarkRead 更新用户在群内的已读时间
+func (s *ReadStateService) MarkRead(ctx context.Context, userID, chatID string, t time.Time) error {
+	if userID == "" || chatID == "" {
+		return nil
+	}
+	return s.repo.Upsert(ctx, userID, chatID, t)
+}
+
+// computeDigestRange 根据上次已读时间计算补看范围
+// 没有记录时回看 defaultDigestLookback，超过 maxDigestLookback 时截断
+func computeDigestRange(lastRead *time.Time, now time.Time) (start, end time.Time) {
+	end = now
+	if lastRead == nil || lastRead.IsZero() {
+		return now.Add(-defaultDigestLookback), end
+	}
+	if !lastRead.Before(now) {
+		// 已读时间不早于现在（时钟偏差），没有需要补看的内容
+		return now, end
+	}
+
+	start = *lastRead
+	if now.Sub(start) > maxDigestLookback {
+		start = now.Add(-maxDigestLookback)
+	}
+	return start, end
+}
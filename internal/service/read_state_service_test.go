@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"team-assistant/internal/model"
+)
+
+// fakeReadStateRepo 内存版已读状态仓库
+type fakeReadStateRepo struct {
+	states map[string]time.Time
+}
+
+func newFakeReadStateRepo() *fakeReadStateRepo {
+	return &fakeReadStateRepo{states: make(map[string]time.Time)}
+}
+
+func (r *fakeReadStateRepo) Get(ctx context.Context, userID, chatID string) (*model.UserReadState, error) {
+	t, ok := r.states[userID+"|"+chatID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &model.UserReadState{UserID: userID, ChatID: chatID, LastReadAt: t}, nil
+}
+
+func (r *fakeReadStateRepo) Upsert(ctx context.Context, userID, chatID string, lastReadAt time.Time) error {
+	r.states[userID+"|"+chatID] = lastReadAt
+	return nil
+}
+
+func TestComputeDigestRange(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.Local)
+	threeDaysAgo := now.Add(-72 * time.Hour)
+	longAgo := now.AddDate(0, -3, 0)
+	future := now.Add(time.Hour)
+
+	tests := []struct {
+		name      string
+		lastRead  *time.Time
+		wantStart time.Time
+	}{
+		{"没有记录", nil, now.Add(-defaultDigestLookback)},
+		{"三天前看过", &threeDaysAgo, threeDaysAgo},
+		{"超过最大回看时间", &longAgo, now.Add(-maxDigestLookback)},
+		{"已读时间晚于现在", &future, now},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := computeDigestRange(tt.lastRead, now)
+			if !start.Equal(tt.wantStart) {
+				t.Errorf("start = %v, want %v", start, tt.wantStart)
+			}
+			if !end.Equal(now) {
+				t.Errorf("end = %v, want %v", end, now)
+			}
+		})
+	}
+}
+
+func TestReadStateServiceTracking(t *testing.T) {
+	repo := newFakeReadStateRepo()
+	svc := NewReadStateService(repo)
+	ctx := context.Background()
+
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.Local)
+
+	// 首次查询：没有记录
+	start, _, hasState, err := svc.DigestRange(ctx, "ou_a", "oc_1", now)
+	if err != nil {
+		t.Fatalf("DigestRange failed: %v", err)
+	}
+	if hasState || !start.Equal(now.Add(-defaultDigestLookback)) {
+		t.Errorf("Expected default lookback without state, got start=%v hasState=%v", start, hasState)
+	}
+
+	// 标记已读后，下次从已读时间开始
+	if err := svc.MarkRead(ctx, "ou_a", "oc_1", now); err != nil {
+		t.Fatalf("MarkRead failed: %v", err)
+	}
+	later := now.Add(5 * time.Hour)
+	start, end, hasState, _ := svc.DigestRange(ctx, "ou_a", "oc_1", later)
+	if !hasState || !start.Equal(now) || !end.Equal(later) {
+		t.Errorf("Expected range [%v, %v], got [%v, %v] hasState=%v", now, later, start, end, hasState)
+	}
+
+	// 不同群、不同用户互不影响
+	if _, _, hasState, _ := svc.DigestRange(ctx, "ou_a", "oc_2", later); hasState {
+		t.Error("Read state should be per chat")
+	}
+	if _, _, hasState, _ := svc.DigestRange(ctx, "ou_b", "oc_1", later); hasState {
+		t.Error("Read state should be per user")
+	}
+
+	// 空用户/群不记录
+	svc.MarkRead(ctx, "", "oc_1", later)
+	if len(repo.states) != 1 {
+		t.Errorf("Expected 1 state, got %d", len(repo.states))
+	}
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+)
+
+// ParentContentFinder 按消息ID查询消息内容（索引回复消息时补充被回复的内容）
+type ParentContentFinder interface {
+	GetContentByMessageID(ctx context.Context, messageID string) (string, error)
+}
+
+// replyContextMaxRunes 拼接到 embedding 文本中的父消息最大长度
+const replyContextMaxRunes = 100
+
+// SetReplyContext 设置索引回复消息时补充父消息内容
+// "是的，改好了" 这类短回复本身没有语义，embedding 文本前拼接被回复的消息，
+// payload 中的 content 仍保存原文，展示不受影响；finder 为 nil 时关闭
+func (s *RAGService) SetReplyContext(finder ParentContentFinder) {
+	s.parentFinder = finder
+}
+
+// ReplyContextEnabled 回复消息是否会补充父消息上下文
+func (s *RAGService) ReplyContextEnabled() bool {
+	return s.parentFinder != nil
+}
+
+// EmbedTextWithReplyContext 在 embedding 文本前拼接父消息内容（过长时截断）
+func EmbedTextWithReplyContext(content, parent string) string {
+	parent = strings.TrimSpace(parent)
+	if parent == "" {
+		return content
+	}
+	if runes := []rune(parent); len(runes) > replyContextMaxRunes {
+		parent = string(runes[:replyContextMaxRunes]) + "…"
+	}
+	return "回复「" + parent + "」：" + content
+}
+
+// parentContent 查询回复消息的父消息内容，优先使用同一批次中的消息，未开启或查不到时返回空
+func (s *RAGService) parentContent(ctx context.Context, msg MessageVector, batch map[string]string) string {
+	if s.parentFinder == nil || msg.ReplyToID == "" {
+		return ""
+	}
+	if content, ok := batch[msg.ReplyToID]; ok {
+		return content
+	}
+
+	content, err := s.parentFinder.GetContentByMessageID(ctx, msg.ReplyToID)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("[RAG] Failed to get parent message %s: %v", msg.ReplyToID, err)
+		}
+		return ""
+	}
+	return content
+}
@@ -0,0 +1,43 @@
+package service
+
+import "strings"
+
+// SenderFilter 按发言人排除消息（监控机器人等高频噪音），让检索语料以人工讨论为主
+// 可为 nil，nil 表示不排除任何发言人
+type SenderFilter struct {
+	senders   map[string]bool // 小写的 open_id 或名称
+	skipStore bool
+}
+
+// NewSenderFilter 创建发言人过滤器，senders 为 open_id 或名称；列表为空时返回 nil
+// skipStore 为 true 时排除的消息也不存储，否则只跳过向量索引
+func NewSenderFilter(senders []string, skipStore bool) *SenderFilter {
+	set := make(map[string]bool, len(senders))
+	for _, s := range senders {
+		if s = strings.ToLower(strings.TrimSpace(s)); s != "" {
+			set[s] = true
+		}
+	}
+	if len(set) == 0 {
+		return nil
+	}
+	return &SenderFilter{senders: set, skipStore: skipStore}
+}
+
+// Excludes 发言人是否被排除（不参与向量索引）
+func (f *SenderFilter) Excludes(senderID, senderName string) bool {
+	if f == nil {
+		return false
+	}
+	for _, s := range []string{senderID, senderName} {
+		if s = strings.ToLower(strings.TrimSpace(s)); s != "" && f.senders[s] {
+			return true
+		}
+	}
+	return false
+}
+
+// SkipStore 发言人的消息是否不存储
+func (f *SenderFilter) SkipStore(senderID, senderName string) bool {
+	return f != nil && f.skipStore && f.Excludes(senderID, senderName)
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSenderFilter(t *testing.T) {
+	f := NewSenderFilter([]string{"ou_monitor", " Grafana告警 ", ""}, false)
+
+	tests := []struct {
+		name       string
+		senderID   string
+		senderName string
+		want       bool
+	}{
+		{"按 open_id 排除", "ou_monitor", "监控机器人", true},
+		{"按名称排除", "ou_other", "grafana告警", true},
+		{"名称忽略大小写和空格", "", " GRAFANA告警", true},
+		{"普通成员", "ou_user", "张三", false},
+		{"发言人为空", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := f.Excludes(tt.senderID, tt.senderName); got != tt.want {
+				t.Errorf("Excludes() = %v, want %v", got, tt.want)
+			}
+			if f.SkipStore(tt.senderID, tt.senderName) {
+				t.Error("SkipStore() should be false when skipStore is off")
+			}
+		})
+	}
+}
+
+func TestSenderFilterSkipStore(t *testing.T) {
+	f := NewSenderFilter([]string{"ou_monitor"}, true)
+	if !f.SkipStore("ou_monitor", "") {
+		t.Error("Excluded sender should not be stored")
+	}
+	if f.SkipStore("ou_user", "张三") {
+		t.Error("Other senders should still be stored")
+	}
+}
+
+func TestSenderFilterEmpty(t *testing.T) {
+	f := NewSenderFilter([]string{" ", ""}, true)
+	if f != nil {
+		t.Fatalf("Empty sender list should return nil filter, got %+v", f)
+	}
+	if f.Excludes("ou_monitor", "监控") || f.SkipStore("ou_monitor", "监控") {
+		t.Error("Nil filter should exclude nothing")
+	}
+}
+
+func TestRAGServiceSkipsExcludedSenders(t *testing.T) {
+	// 未配置向量库和 embedding 客户端：被排除的消息必须在访问它们之前返回
+	s := &RAGService{enabled: true}
+	s.SetSenderFilter(NewSenderFilter([]string{"ou_monitor"}, false))
+	msg := MessageVector{MessageID: "om_1", SenderID: "ou_monitor", Content: "CPU 使用率 95%", CreatedAt: time.Now()}
+
+	if err := s.IndexMessage(context.Background(), msg); err != nil {
+		t.Errorf("IndexMessage() error: %v", err)
+	}
+	if err := s.IndexMessages(context.Background(), []MessageVector{msg, msg}); err != nil {
+		t.Errorf("IndexMessages() error: %v", err)
+	}
+}
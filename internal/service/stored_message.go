@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"log"
+
+	"team-assistant/internal/model"
+)
+
+// StoredMessageReader 按消息ID读取数据库中的消息（*model.ChatMessageModel 实现）
+type StoredMessageReader interface {
+	GetByMessageID(ctx context.Context, messageID string) (*model.ChatMessage, error)
+}
+
+// IndexedPointChecker 查询消息是否已写入向量库（*RAGService 实现）
+type IndexedPointChecker interface {
+	PointExists(ctx context.Context, chatID, messageID string) (bool, error)
+}
+
+// MessageForIndex 按写入结果决定向量索引使用的消息，返回 nil 表示不需要索引
+//   - 新插入：直接索引写入的消息
+//   - 已存在：以数据库中合并后的行为准，重新读取后索引，避免较差的字段（如未解析的发言人）覆盖向量库中的 payload
+//   - 合并后没有变化：只有确认向量库中已有数据点时才跳过，之前索引失败的消息借此补上
+func MessageForIndex(ctx context.Context, store StoredMessageReader, points IndexedPointChecker, msg *model.ChatMessage, result model.UpsertResult) *model.ChatMessage {
+	if result == model.UpsertInserted {
+		return msg
+	}
+
+	if result == model.UpsertUnchanged {
+		exists, err := points.PointExists(ctx, msg.ChatID, msg.MessageID)
+		if err != nil {
+			log.Printf("[RAG] Failed to check point for message %s, reindexing: %v", msg.MessageID, err)
+		} else if exists {
+			return nil
+		}
+	}
+
+	merged, err := store.GetByMessageID(ctx, msg.MessageID)
+	if err != nil {
+		// 读不到合并后的行时不索引，避免用本次写入的字段覆盖数据库中更好的值
+		log.Printf("[RAG] Failed to reload merged message %s, skip indexing: %v", msg.MessageID, err)
+		return nil
+	}
+	return merged
+}
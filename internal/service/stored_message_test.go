@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"team-assistant/internal/model"
+)
+
+// fakeStoredMessages 数据库中合并后的消息
+type fakeStoredMessages map[string]*model.ChatMessage
+
+func (f fakeStoredMessages) GetByMessageID(ctx context.Context, messageID string) (*model.ChatMessage, error) {
+	if msg, ok := f[messageID]; ok {
+		return msg, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+// fakePointChecker 向量库中已有的消息
+type fakePointChecker struct {
+	indexed map[string]bool
+	err     error
+}
+
+func (f *fakePointChecker) PointExists(ctx context.Context, chatID, messageID string) (bool, error) {
+	return f.indexed[messageID], f.err
+}
+
+func TestMessageForIndex(t *testing.T) {
+	incoming := &model.ChatMessage{
+		MessageID:  "om_1",
+		ChatID:     "oc_dev",
+		SenderID:   sql.NullString{String: "ou_zhangsan", Valid: true},
+		SenderName: sql.NullString{String: "ou_zhangsan", Valid: true},
+		Content:    sql.NullString{String: "登录超时已修复，今晚发版", Valid: true},
+	}
+	merged := &model.ChatMessage{
+		MessageID:  "om_1",
+		ChatID:     "oc_dev",
+		SenderID:   sql.NullString{String: "ou_zhangsan", Valid: true},
+		SenderName: sql.NullString{String: "张三", Valid: true},
+		Content:    sql.NullString{String: "登录超时已修复，今晚发版", Valid: true},
+	}
+	store := fakeStoredMessages{"om_1": merged}
+
+	tests := []struct {
+		name   string
+		points *fakePointChecker
+		result model.UpsertResult
+		want   *model.ChatMessage
+	}{
+		{"新消息直接索引", &fakePointChecker{}, model.UpsertInserted, incoming},
+		{"合并后索引数据库中的行", &fakePointChecker{indexed: map[string]bool{"om_1": true}}, model.UpsertUpdated, merged},
+		{"没有变化且已索引时跳过", &fakePointChecker{indexed: map[string]bool{"om_1": true}}, model.UpsertUnchanged, nil},
+		{"没有变化但未索引时补上", &fakePointChecker{}, model.UpsertUnchanged, merged},
+		{"查询向量库失败时重新索引", &fakePointChecker{err: errors.New("connection refused")}, model.UpsertUnchanged, merged},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MessageForIndex(context.Background(), store, tt.points, incoming, tt.result); got != tt.want {
+				t.Errorf("MessageForIndex() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMessageForIndexReloadFails(t *testing.T) {
+	incoming := &model.ChatMessage{MessageID: "om_missing", ChatID: "oc_dev"}
+	if got := MessageForIndex(context.Background(), fakeStoredMessages{}, &fakePointChecker{}, incoming, model.UpsertUpdated); got != nil {
+		t.Errorf("Expected no indexing when merged row cannot be read, got %+v", got)
+	}
+}
@@ -52,6 +52,16 @@ func (s *SyncService) GetRecentTasks(ctx context.Context, limit int) ([]*model.M
 	return s.syncTaskRepo.GetRecentTasks(ctx, limit)
 }
 
+// ListTasksByStatus 按状态获取最近的任务
+func (s *SyncService) ListTasksByStatus(ctx context.Context, status string, limit int) ([]*model.MessageSyncTask, error) {
+	return s.syncTaskRepo.ListByStatus(ctx, status, limit)
+}
+
+// ListTasksByChat 获取某个群最近的任务
+func (s *SyncService) ListTasksByChat(ctx context.Context, chatID string, limit int) ([]*model.MessageSyncTask, error) {
+	return s.syncTaskRepo.ListByChat(ctx, chatID, limit)
+}
+
 // MarkTaskStarted 标记任务开始
 func (s *SyncService) MarkTaskStarted(ctx context.Context, taskID int64) error {
 	return s.syncTaskRepo.MarkStarted(ctx, taskID)
@@ -68,8 +78,8 @@ func (s *SyncService) MarkTaskFailed(ctx context.Context, taskID int64, errMsg s
 }
 
 // UpdateProgress 更新同步进度
-func (s *SyncService) UpdateProgress(ctx context.Context, taskID int64, syncedMessages int, pageToken string) error {
-	return s.syncTaskRepo.UpdateProgress(ctx, taskID, syncedMessages, pageToken)
+func (s *SyncService) UpdateProgress(ctx context.Context, taskID int64, syncedMessages int, pageToken, resumeTime string) error {
+	return s.syncTaskRepo.UpdateProgress(ctx, taskID, syncedMessages, pageToken, resumeTime)
 }
 
 // FormatTaskStatus 格式化任务状态
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+
+	"team-assistant/internal/model"
+)
+
+// utf8BOM 写在 CSV 开头，Excel 据此按 UTF-8 打开
+const utf8BOM = "\ufeff"
+
+// workloadCSVHeader 工作量导出的列，messages 列仅在包含消息数时输出
+var workloadCSVHeader = []string{"author", "commits", "additions", "deletions", "repos"}
+
+// WriteWorkloadCSV 以 CSV 输出工作量统计（每个提交作者一行），开头写入 UTF-8 BOM 以便 Excel 正确显示中文
+// messageCounts 不为 nil 时增加 messages 列，按名称匹配提交作者；只发言没有提交的成员追加在后面（按消息数降序）
+// 没有数据时只输出表头
+func WriteWorkloadCSV(w io.Writer, stats []*model.CommitStats, messageCounts map[string]int) error {
+	if _, err := io.WriteString(w, utf8BOM); err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	header := workloadCSVHeader
+	if messageCounts != nil {
+		header = append(append([]string{}, header...), "messages")
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool, len(stats))
+	for _, s := range stats {
+		row := []string{s.AuthorName, strconv.Itoa(s.CommitCount), strconv.Itoa(s.Additions),
+			strconv.Itoa(s.Deletions), strconv.Itoa(s.RepoCount)}
+		if messageCounts != nil {
+			row = append(row, strconv.Itoa(messageCounts[s.AuthorName]))
+			seen[s.AuthorName] = true
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	// 只发言没有提交的成员
+	var senders []string
+	for name := range messageCounts {
+		if !seen[name] {
+			senders = append(senders, name)
+		}
+	}
+	sort.Slice(senders, func(i, j int) bool {
+		if messageCounts[senders[i]] != messageCounts[senders[j]] {
+			return messageCounts[senders[i]] > messageCounts[senders[j]]
+		}
+		return senders[i] < senders[j]
+	})
+	for _, name := range senders {
+		if err := cw.Write([]string{name, "0", "0", "0", "0", strconv.Itoa(messageCounts[name])}); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"team-assistant/internal/model"
+)
+
+func TestWriteWorkloadCSV(t *testing.T) {
+	stats := []*model.CommitStats{
+		{AuthorName: "张三", CommitCount: 12, Additions: 340, Deletions: 120, RepoCount: 2},
+		{AuthorName: "Li, Si", CommitCount: 3, Additions: 10, Deletions: 0, RepoCount: 1},
+	}
+
+	tests := []struct {
+		name          string
+		stats         []*model.CommitStats
+		messageCounts map[string]int
+		want          string
+	}{
+		{"只有提交统计", stats, nil,
+			"author,commits,additions,deletions,repos\n张三,12,340,120,2\n\"Li, Si\",3,10,0,1\n"},
+		{"附带发言数", stats, map[string]int{"张三": 30, "王五": 8, "赵六": 15},
+			"author,commits,additions,deletions,repos,messages\n张三,12,340,120,2,30\n\"Li, Si\",3,10,0,1,0\n赵六,0,0,0,0,15\n王五,0,0,0,0,8\n"},
+		{"时间范围内没有数据", nil, nil, "author,commits,additions,deletions,repos\n"},
+		{"没有数据附带发言数", nil, map[string]int{}, "author,commits,additions,deletions,repos,messages\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sb strings.Builder
+			if err := WriteWorkloadCSV(&sb, tt.stats, tt.messageCounts); err != nil {
+				t.Fatal(err)
+			}
+			got := sb.String()
+			if !strings.HasPrefix(got, utf8BOM) {
+				t.Fatalf("Expected UTF-8 BOM prefix, got %q", got)
+			}
+			if got = strings.TrimPrefix(got, utf8BOM); got != tt.want {
+				t.Errorf("WriteWorkloadCSV() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
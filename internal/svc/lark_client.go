@@ -0,0 +1,27 @@
+package svc
+
+import (
+	"time"
+
+	"team-assistant/internal/config"
+	"team-assistant/pkg/lark"
+)
+
+// NewLarkClient 按配置创建飞书客户端（超时、代理、资源下载重试、限流），主服务和同步 worker 共用
+func NewLarkClient(c config.LarkConfig) *lark.Client {
+	opts := lark.ClientOptions{Timeout: time.Duration(c.Timeout) * time.Second}
+	if c.ProxyHost != "" && c.ProxyPort > 0 {
+		opts.Proxy = &lark.ProxyConfig{
+			Host:     c.ProxyHost,
+			Port:     c.ProxyPort,
+			User:     c.ProxyUser,
+			Password: c.ProxyPassword,
+		}
+	}
+
+	client := lark.NewClientWithOptions(c.Domain, c.AppID, c.AppSecret, opts)
+	client.SetResourceRetry(c.ResourceRetries, nil)
+	client.SetRateLimit(c.QPS)
+	client.SetSendRate(c.SendPerChatQPS)
+	return client
+}
@@ -0,0 +1,46 @@
+package svc
+
+import (
+	"team-assistant/internal/config"
+	"team-assistant/pkg/llm"
+)
+
+// NewLLMClient 按配置创建 LLM 客户端（代理、视觉模型、备选模型、按场景的调用参数），主服务和同步 worker 共用
+// 未配置 API Key 时返回 nil；安全模式由调用方处理，这里总是创建真实客户端
+func NewLLMClient(c config.LLMConfig) *llm.Client {
+	if c.APIKey == "" {
+		return nil
+	}
+
+	// 如果配置了代理，使用代理
+	var proxyConfig *llm.ProxyConfig
+	if c.ProxyHost != "" && c.ProxyPort > 0 {
+		proxyConfig = &llm.ProxyConfig{
+			Host:     c.ProxyHost,
+			Port:     c.ProxyPort,
+			User:     c.ProxyUser,
+			Password: c.ProxyPassword,
+		}
+	}
+	client := llm.NewClientWithProxy(c.APIKey, c.Endpoint, c.Model, proxyConfig)
+	client.SetCallMaxTokens(c.CallMaxTokens)
+	client.SetCallStop(c.CallStop)
+	// 设置视觉模型配置（如果配置了）
+	if c.VisionModel != "" {
+		client.SetVisionConfig(c.VisionModel, c.VisionEndpoint, c.VisionAPIKey)
+	}
+	// 设置备选模型（智能切换）
+	if len(c.FallbackModels) > 0 {
+		var fallbacks []llm.ModelConfig
+		for _, fb := range c.FallbackModels {
+			fallbacks = append(fallbacks, llm.ModelConfig{
+				Provider: fb.Provider,
+				APIKey:   fb.APIKey,
+				Endpoint: fb.Endpoint,
+				Model:    fb.Model,
+			})
+		}
+		client.SetFallbackModels(fallbacks)
+	}
+	return client
+}
@@ -0,0 +1,154 @@
+package svc
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Metrics 运行时计数器（并发安全），通过 /api/stats 返回快照
+// 不依赖 Prometheus，适合不做指标抓取的部署；所有方法都允许 nil 接收者，便于测试和未初始化场景
+type Metrics struct {
+	startedAt time.Time
+
+	queriesServed    atomic.Int64 // 处理的用户查询数
+	queryErrors      atomic.Int64 // 处理出错的查询数
+	queryTimeouts    atomic.Int64 // 超时的查询数
+	llmCalls         atomic.Int64 // LLM 调用次数（含备选模型切换后的最终结果）
+	llmFailures      atomic.Int64 // LLM 调用失败次数
+	messagesStored   atomic.Int64 // 实时存储的群消息数
+	messagesIngested atomic.Int64 // 外部系统推送的消息数
+
+	caches sync.Map // 缓存名 -> *cacheCounter
+}
+
+// cacheCounter 缓存命中计数
+type cacheCounter struct {
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// MetricsSnapshot 运行时计数快照
+type MetricsSnapshot struct {
+	StartedAt        string                   `json:"started_at"`
+	UptimeSeconds    int64                    `json:"uptime_seconds"`
+	QueriesServed    int64                    `json:"queries_served"`
+	QueryErrors      int64                    `json:"query_errors"`
+	QueryTimeouts    int64                    `json:"query_timeouts"`
+	LLMCalls         int64                    `json:"llm_calls"`
+	LLMFailures      int64                    `json:"llm_failures"`
+	LLMFailureRate   float64                  `json:"llm_failure_rate"`
+	MessagesStored   int64                    `json:"messages_stored"`
+	MessagesIngested int64                    `json:"messages_ingested"`
+	Caches           map[string]CacheSnapshot `json:"caches"`
+}
+
+// CacheSnapshot 缓存命中快照
+type CacheSnapshot struct {
+	Hits    int64   `json:"hits"`
+	Misses  int64   `json:"misses"`
+	HitRate float64 `json:"hit_rate"`
+}
+
+// NewMetrics 创建运行时计数器
+func NewMetrics() *Metrics {
+	return &Metrics{startedAt: time.Now()}
+}
+
+// RecordQuery 记录一次用户查询
+func (m *Metrics) RecordQuery(err error) {
+	if m == nil {
+		return
+	}
+	m.queriesServed.Add(1)
+	if err != nil {
+		m.queryErrors.Add(1)
+	}
+}
+
+// RecordQueryTimeout 记录一次查询超时
+func (m *Metrics) RecordQueryTimeout() {
+	if m == nil {
+		return
+	}
+	m.queryTimeouts.Add(1)
+}
+
+// RecordLLMCall 记录一次 LLM 调用结果
+func (m *Metrics) RecordLLMCall(err error) {
+	if m == nil {
+		return
+	}
+	m.llmCalls.Add(1)
+	if err != nil {
+		m.llmFailures.Add(1)
+	}
+}
+
+// RecordMessageStored 记录一条实时存储的消息
+func (m *Metrics) RecordMessageStored() {
+	if m == nil {
+		return
+	}
+	m.messagesStored.Add(1)
+}
+
+// RecordMessageIngested 记录一条外部推送的消息
+func (m *Metrics) RecordMessageIngested() {
+	if m == nil {
+		return
+	}
+	m.messagesIngested.Add(1)
+}
+
+// RecordCacheLookup 记录一次缓存查询，name 为缓存名（如 user_name）
+func (m *Metrics) RecordCacheLookup(name string, hit bool) {
+	if m == nil {
+		return
+	}
+	v, ok := m.caches.Load(name)
+	if !ok {
+		v, _ = m.caches.LoadOrStore(name, &cacheCounter{})
+	}
+	c := v.(*cacheCounter)
+	if hit {
+		c.hits.Add(1)
+	} else {
+		c.misses.Add(1)
+	}
+}
+
+// Snapshot 返回当前计数快照（可直接 JSON 序列化）
+func (m *Metrics) Snapshot() MetricsSnapshot {
+	snap := MetricsSnapshot{Caches: make(map[string]CacheSnapshot)}
+	if m == nil {
+		return snap
+	}
+
+	snap.StartedAt = m.startedAt.Format(time.RFC3339)
+	snap.UptimeSeconds = int64(time.Since(m.startedAt).Seconds())
+	snap.QueriesServed = m.queriesServed.Load()
+	snap.QueryErrors = m.queryErrors.Load()
+	snap.QueryTimeouts = m.queryTimeouts.Load()
+	snap.LLMCalls = m.llmCalls.Load()
+	snap.LLMFailures = m.llmFailures.Load()
+	snap.LLMFailureRate = ratio(snap.LLMFailures, snap.LLMCalls)
+	snap.MessagesStored = m.messagesStored.Load()
+	snap.MessagesIngested = m.messagesIngested.Load()
+
+	m.caches.Range(func(key, value interface{}) bool {
+		c := value.(*cacheCounter)
+		hits, misses := c.hits.Load(), c.misses.Load()
+		snap.Caches[key.(string)] = CacheSnapshot{Hits: hits, Misses: misses, HitRate: ratio(hits, hits+misses)}
+		return true
+	})
+	return snap
+}
+
+// ratio 计算比例（保留 4 位小数），分母为 0 时返回 0
+func ratio(n, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n*10000/total) / 10000
+}
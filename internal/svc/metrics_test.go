@@ -0,0 +1,99 @@
+package svc
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestMetricsConcurrentCounters(t *testing.T) {
+	m := NewMetrics()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var err error
+			if i%5 == 0 {
+				err = errors.New("failed")
+			}
+			m.RecordQuery(err)
+			m.RecordLLMCall(err)
+			m.RecordCacheLookup("user_name", i%2 == 0)
+			m.RecordMessageStored()
+		}(i)
+	}
+	wg.Wait()
+	m.RecordQueryTimeout()
+	m.RecordMessageIngested()
+
+	snap := m.Snapshot()
+	if snap.QueriesServed != 50 || snap.QueryErrors != 10 || snap.QueryTimeouts != 1 {
+		t.Errorf("Unexpected query counters: %+v", snap)
+	}
+	if snap.LLMCalls != 50 || snap.LLMFailures != 10 || snap.LLMFailureRate != 0.2 {
+		t.Errorf("Unexpected LLM counters: %+v", snap)
+	}
+	if snap.MessagesStored != 50 || snap.MessagesIngested != 1 {
+		t.Errorf("Unexpected message counters: %+v", snap)
+	}
+	cache := snap.Caches["user_name"]
+	if cache.Hits != 25 || cache.Misses != 25 || cache.HitRate != 0.5 {
+		t.Errorf("Unexpected cache counters: %+v", cache)
+	}
+}
+
+func TestMetricsNilSafe(t *testing.T) {
+	var m *Metrics
+	m.RecordQuery(nil)
+	m.RecordQueryTimeout()
+	m.RecordLLMCall(errors.New("failed"))
+	m.RecordCacheLookup("user_name", true)
+	m.RecordMessageStored()
+	m.RecordMessageIngested()
+
+	snap := m.Snapshot()
+	if snap.QueriesServed != 0 || snap.Caches == nil {
+		t.Errorf("Expected empty snapshot, got %+v", snap)
+	}
+}
+
+func TestMetricsSnapshotJSON(t *testing.T) {
+	m := NewMetrics()
+	m.RecordCacheLookup("sender_name", false)
+
+	data, err := json.Marshal(m.Snapshot())
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	var decoded map[string]interface{}
+	json.Unmarshal(data, &decoded)
+	for _, key := range []string{"started_at", "uptime_seconds", "queries_served", "llm_failure_rate", "caches"} {
+		if _, ok := decoded[key]; !ok {
+			t.Errorf("Snapshot JSON should contain %q: %s", key, data)
+		}
+	}
+	caches, _ := decoded["caches"].(map[string]interface{})
+	if _, ok := caches["sender_name"]; !ok {
+		t.Errorf("Snapshot JSON should contain sender_name cache: %s", data)
+	}
+}
+
+func TestRatio(t *testing.T) {
+	tests := []struct {
+		n, total int64
+		want     float64
+	}{
+		{0, 0, 0},
+		{1, 3, 0.3333},
+		{3, 3, 1},
+	}
+	for _, tt := range tests {
+		if got := ratio(tt.n, tt.total); got != tt.want {
+			t.Errorf("ratio(%d, %d) = %v, want %v", tt.n, tt.total, got, tt.want)
+		}
+	}
+}
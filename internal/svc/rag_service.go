@@ -0,0 +1,40 @@
+package svc
+
+import (
+	"github.com/redis/go-redis/v9"
+
+	"team-assistant/internal/config"
+	"team-assistant/internal/model"
+	"team-assistant/internal/repository"
+	"team-assistant/internal/service"
+	"team-assistant/pkg/llm"
+)
+
+// NewRAGService 按配置创建 RAG 服务（翻译、回复上下文、发言人过滤、索引版本），主服务和同步 worker 共用
+// 安全模式下不连接 Qdrant/Ollama，检索退回数据库关键词搜索；rdb 为 nil 时不检查跨进程的索引版本
+func NewRAGService(c config.Config, llmClient *llm.Client, messageModel *model.ChatMessageModel, senderFilter *service.SenderFilter, rdb *redis.Client) *service.RAGService {
+	ragService := service.NewRAGService(
+		c.VectorDB.QdrantEndpoint,
+		c.VectorDB.OllamaEndpoint,
+		c.VectorDB.EmbeddingModel,
+		c.VectorDB.CollectionName,
+		c.VectorDB.EmbeddingDimension,
+		c.VectorDB.Enabled && !c.SafeMode,
+	)
+	ragService.SetNormalizeEmbeddings(c.VectorDB.NormalizeEmbeddings)
+	ragService.SetFitDimension(c.VectorDB.FitDimension)
+	ragService.SetEmbedNumbers(c.VectorDB.EmbedNumbers)
+	ragService.SetCollectionStrategy(c.VectorDB.CollectionStrategy)
+	if len(c.VectorDB.TranslateChats) > 0 && llmClient != nil {
+		ragService.SetTranslator(llmClient, c.VectorDB.TranslateChats)
+	}
+	if c.VectorDB.ReplyContext {
+		ragService.SetReplyContext(messageModel)
+	}
+	ragService.SetSenderFilter(senderFilter)
+	ragService.SetIndexMentionOnly(c.Index.IndexMentionOnly)
+	if rdb != nil {
+		ragService.SetIndexVersion(repository.NewIndexVersionRepository(rdb))
+	}
+	return ragService
+}
@@ -10,6 +10,7 @@ import (
 	"team-assistant/internal/service"
 	"team-assistant/pkg/dify"
 	"team-assistant/pkg/lark"
+	"team-assistant/pkg/lifecycle"
 	"team-assistant/pkg/llm"
 
 	_ "github.com/go-sql-driver/mysql"
@@ -29,11 +30,18 @@ type ServiceContext struct {
 	LarkClient *lark.Client
 
 	// Models（原有字段保持兼容）
-	MemberModel   *model.TeamMemberModel
-	CommitModel   *model.GitCommitModel
-	MessageModel  *model.ChatMessageModel
-	GroupModel    *model.ChatGroupModel
-	SyncTaskModel *model.MessageSyncTaskModel
+	MemberModel        *model.TeamMemberModel
+	CommitModel        *model.GitCommitModel
+	MessageModel       *model.ChatMessageModel
+	GroupModel         *model.ChatGroupModel
+	SyncTaskModel      *model.MessageSyncTaskModel
+	DifySyncStateModel *model.DifySyncStateModel
+	AlertModel         *model.AlertModel
+	WeeklySummaryModel *model.WeeklySummaryModel
+	// GitHub 各仓库最后采集成功时间（工作量回答的 "数据截至"）
+	GitHubCollectStateModel *model.GitHubCollectStateModel
+	// 用户默认群（私聊提问默认检索的群）
+	UserDefaultChatModel *model.UserDefaultChatModel
 
 	// ============================================================
 	// 新架构组件
@@ -48,25 +56,36 @@ type ServiceContext struct {
 
 	// Service 层
 	Services *Services
+
+	// 排除的发言人（监控机器人等），为 nil 时不排除
+	SenderFilter *service.SenderFilter
+
+	// 主动通知（同步完成等），免打扰时段内暂存到时段结束后发送
+	Notifier *service.Notifier
+
+	// 运行时计数（/api/stats 返回快照）
+	Metrics *Metrics
+
+	// 后台协程生命周期管理（/readyz 返回状态，关闭时统一停止）
+	Lifecycle *lifecycle.Manager
 }
 
 // Services 服务集合
 type Services struct {
-	Message *service.MessageService
-	Chat    *service.ChatService
-	Sync    *service.SyncService
-	AI      *service.AIService
-	RAG     *service.RAGService
+	Message   *service.MessageService
+	Chat      *service.ChatService
+	Sync      *service.SyncService
+	AI        *service.AIService
+	RAG       *service.RAGService
+	ReadState *service.ReadStateService
 }
 
 // NewServiceContext 创建服务上下文
 func NewServiceContext(c config.Config) (*ServiceContext, error) {
 	// 初始化 MySQL
-	dsn := fmt.Sprintf("%s:%s@tcp(%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		c.MySQL.User, c.MySQL.Password, c.MySQL.Host, c.MySQL.Database)
-
-	if c.MySQL.SkipSSL {
-		dsn += "&tls=skip-verify"
+	dsn, err := c.MySQL.DSN()
+	if err != nil {
+		return nil, fmt.Errorf("invalid MySQL config: %w", err)
 	}
 
 	db, err := sql.Open("mysql", dsn)
@@ -93,46 +112,40 @@ func NewServiceContext(c config.Config) (*ServiceContext, error) {
 	memberModel := model.NewTeamMemberModel(db)
 	commitModel := model.NewGitCommitModel(db)
 	messageModel := model.NewChatMessageModel(db)
+	messageModel.SetKeepRawContent(c.Storage.KeepsRawContent())
+	messageModel.SetFullTextSearch(c.Storage.FullTextSearch)
 	groupModel := model.NewChatGroupModel(db)
 	syncTaskModel := model.NewMessageSyncTaskModel(db)
+	readStateModel := model.NewUserReadStateModel(db)
+	difySyncStateModel := model.NewDifySyncStateModel(db)
+	alertModel := model.NewAlertModel(db)
+	weeklySummaryModel := model.NewWeeklySummaryModel(db)
+	githubCollectStateModel := model.NewGitHubCollectStateModel(db)
+	userDefaultChatModel := model.NewUserDefaultChatModel(db)
+
+	metrics := NewMetrics()
 
 	// 初始化外部客户端
-	larkClient := lark.NewClient(c.Lark.Domain, c.Lark.AppID, c.Lark.AppSecret)
+	larkClient := NewLarkClient(c.Lark)
+	notifier, err := NewNotifier(c.QuietHours, larkClient)
+	if err != nil {
+		return nil, err
+	}
 
 	var llmClient *llm.Client
-	if c.LLM.APIKey != "" {
-		// 如果配置了代理，使用代理
-		var proxyConfig *llm.ProxyConfig
-		if c.LLM.ProxyHost != "" && c.LLM.ProxyPort > 0 {
-			proxyConfig = &llm.ProxyConfig{
-				Host:     c.LLM.ProxyHost,
-				Port:     c.LLM.ProxyPort,
-				User:     c.LLM.ProxyUser,
-				Password: c.LLM.ProxyPassword,
-			}
-		}
-		llmClient = llm.NewClientWithProxy(c.LLM.APIKey, c.LLM.Endpoint, c.LLM.Model, proxyConfig)
-		// 设置视觉模型配置（如果配置了）
-		if c.LLM.VisionModel != "" {
-			llmClient.SetVisionConfig(c.LLM.VisionModel, c.LLM.VisionEndpoint, c.LLM.VisionAPIKey)
-		}
-		// 设置备选模型（智能切换）
-		if len(c.LLM.FallbackModels) > 0 {
-			var fallbacks []llm.ModelConfig
-			for _, fb := range c.LLM.FallbackModels {
-				fallbacks = append(fallbacks, llm.ModelConfig{
-					Provider: fb.Provider,
-					APIKey:   fb.APIKey,
-					Endpoint: fb.Endpoint,
-					Model:    fb.Model,
-				})
-			}
-			llmClient.SetFallbackModels(fallbacks)
-		}
+	if c.SafeMode {
+		llmClient = llm.NewOfflineClient(c.LLM.Model)
+		llmClient.SetCallMaxTokens(c.LLM.CallMaxTokens)
+		llmClient.SetCallStop(c.LLM.CallStop)
+	} else {
+		llmClient = NewLLMClient(c.LLM)
+	}
+	if llmClient != nil {
+		llmClient.SetCallObserver(metrics.RecordLLMCall)
 	}
 
 	var difyClient *dify.Client
-	if c.Dify.Enabled && c.Dify.APIKey != "" {
+	if c.Dify.Enabled && c.Dify.APIKey != "" && !c.SafeMode {
 		difyClient = dify.NewClient(c.Dify.BaseURL, c.Dify.APIKey)
 	}
 
@@ -143,11 +156,13 @@ func NewServiceContext(c config.Config) (*ServiceContext, error) {
 	messageRepoAdapter := repository.NewMessageRepositoryAdapter(messageModel)
 	groupRepoAdapter := repository.NewGroupRepositoryAdapter(groupModel)
 	syncTaskRepoAdapter := repository.NewSyncTaskRepositoryAdapter(syncTaskModel)
+	readStateRepoAdapter := repository.NewReadStateRepositoryAdapter(readStateModel)
 
 	// 初始化 Service
 	messageService := service.NewMessageService(messageRepoAdapter, groupRepoAdapter, larkClient)
 	chatService := service.NewChatService(groupRepoAdapter, larkClient)
 	syncService := service.NewSyncService(syncTaskRepoAdapter)
+	readStateService := service.NewReadStateService(readStateRepoAdapter)
 	aiService := service.NewAIService(
 		commitRepoAdapter,
 		messageRepoAdapter,
@@ -155,22 +170,18 @@ func NewServiceContext(c config.Config) (*ServiceContext, error) {
 		conversationRepo,
 		llmClient,
 		difyClient,
-		c.Dify.Enabled,
+		c.Dify.Enabled && !c.SafeMode,
 		c.Dify.DatasetID,
 	)
 
+	aiService.SetMaxHistoryLength(c.Dify.MaxHistoryLength)
+
 	// 初始化永久记忆管理器
 	aiService.InitMemoryManager(db, rdb)
 
 	// 初始化 RAG 服务
-	ragService := service.NewRAGService(
-		c.VectorDB.QdrantEndpoint,
-		c.VectorDB.OllamaEndpoint,
-		c.VectorDB.EmbeddingModel,
-		c.VectorDB.CollectionName,
-		c.VectorDB.EmbeddingDimension,
-		c.VectorDB.Enabled,
-	)
+	senderFilter := service.NewSenderFilter(c.Index.ExcludedSenders, c.Index.SkipStoreExcluded)
+	ragService := NewRAGService(c, llmClient, messageModel, senderFilter, rdb)
 
 	return &ServiceContext{
 		Config: c,
@@ -183,11 +194,17 @@ func NewServiceContext(c config.Config) (*ServiceContext, error) {
 		LarkClient: larkClient,
 
 		// 原有 Models
-		MemberModel:   memberModel,
-		CommitModel:   commitModel,
-		MessageModel:  messageModel,
-		GroupModel:    groupModel,
-		SyncTaskModel: syncTaskModel,
+		MemberModel:        memberModel,
+		CommitModel:        commitModel,
+		MessageModel:       messageModel,
+		GroupModel:         groupModel,
+		SyncTaskModel:      syncTaskModel,
+		DifySyncStateModel: difySyncStateModel,
+		AlertModel:         alertModel,
+		WeeklySummaryModel: weeklySummaryModel,
+
+		GitHubCollectStateModel: githubCollectStateModel,
+		UserDefaultChatModel:    userDefaultChatModel,
 
 		// 新客户端
 		LLMClient:  llmClient,
@@ -198,12 +215,18 @@ func NewServiceContext(c config.Config) (*ServiceContext, error) {
 
 		// Services
 		Services: &Services{
-			Message: messageService,
-			Chat:    chatService,
-			Sync:    syncService,
-			AI:      aiService,
-			RAG:     ragService,
+			Message:   messageService,
+			Chat:      chatService,
+			Sync:      syncService,
+			AI:        aiService,
+			RAG:       ragService,
+			ReadState: readStateService,
 		},
+		SenderFilter: senderFilter,
+		Notifier:     notifier,
+
+		Metrics:   metrics,
+		Lifecycle: lifecycle.NewManager(),
 	}, nil
 }
 
@@ -216,3 +239,15 @@ func (s *ServiceContext) Close() {
 		s.Redis.Close()
 	}
 }
+
+// NewNotifier 创建主动通知器，配置了免打扰时段时在时段内暂存通知
+func NewNotifier(c config.QuietHoursConfig, larkClient *lark.Client) (*service.Notifier, error) {
+	quiet, err := c.Window()
+	if err != nil {
+		return nil, err
+	}
+	if quiet == nil {
+		return service.NewNotifier(larkClient, nil), nil
+	}
+	return service.NewNotifier(larkClient, quiet.End), nil
+}
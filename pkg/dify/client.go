@@ -4,17 +4,25 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"time"
+
+	"team-assistant/internal/backoff"
 )
 
+// ErrDocumentNotFound 文档不存在（如已在 Dify 控制台中被删除）
+var ErrDocumentNotFound = errors.New("dify document not found")
+
 // Client Dify API 客户端
 type Client struct {
-	baseURL    string
-	apiKey     string
-	httpClient *http.Client
+	baseURL      string
+	apiKey       string
+	httpClient   *http.Client
+	retryBackoff *backoff.Backoff // 重试等待策略
+	maxAttempts  int              // 幂等请求的最大尝试次数
 }
 
 // NewClient 创建 Dify 客户端
@@ -25,9 +33,59 @@ func NewClient(baseURL, apiKey string) *Client {
 		httpClient: &http.Client{
 			Timeout: 60 * time.Second,
 		},
+		retryBackoff: backoff.New(500*time.Millisecond, 5*time.Second),
+		maxAttempts:  3,
 	}
 }
 
+// doJSON 发送 JSON 请求，返回状态码和响应体
+// idempotent 为 true 时（如知识库检索）网络错误、429 和 5xx 按退避策略重试；
+// 非幂等的调用（如发送对话消息）只发送一次，避免超时后重试让 Dify 把同一轮对话记录两次
+func (c *Client) doJSON(ctx context.Context, method, url string, body []byte, idempotent bool) (int, []byte, error) {
+	maxAttempts := 1
+	if idempotent {
+		maxAttempts = c.maxAttempts
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if attempt > 1 {
+			if err := c.retryBackoff.Sleep(ctx, attempt-1); err != nil {
+				return 0, nil, err
+			}
+		}
+
+		httpReq, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+		if err != nil {
+			return 0, nil, fmt.Errorf("create request: %w", err)
+		}
+		httpReq.Header.Set("Content-Type", "application/json")
+		httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+		resp, err := c.httpClient.Do(httpReq)
+		if err != nil {
+			lastErr = fmt.Errorf("do request: %w", err)
+			continue
+		}
+
+		respBody, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			lastErr = fmt.Errorf("read response: %w", err)
+			continue
+		}
+
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			lastErr = fmt.Errorf("dify api error: %s, body: %s", resp.Status, string(respBody))
+			continue
+		}
+
+		return resp.StatusCode, respBody, nil
+	}
+
+	return 0, nil, lastErr
+}
+
 // ChatRequest 对话请求
 type ChatRequest struct {
 	Query          string                 `json:"query"`
@@ -63,27 +121,13 @@ func (c *Client) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, err
 		return nil, fmt.Errorf("marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/v1/chat-messages", bytes.NewReader(body))
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
-	}
-
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
-
-	resp, err := c.httpClient.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("do request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
+	statusCode, respBody, err := c.doJSON(ctx, "POST", c.baseURL+"/v1/chat-messages", body, false)
 	if err != nil {
-		return nil, fmt.Errorf("read response: %w", err)
+		return nil, err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("dify api error: %s, body: %s", resp.Status, string(respBody))
+	if statusCode != http.StatusOK {
+		return nil, fmt.Errorf("dify api error: %d, body: %s", statusCode, string(respBody))
 	}
 
 	var chatResp ChatResponse
@@ -184,27 +228,13 @@ func (c *Client) SearchKnowledge(ctx context.Context, datasetID string, req *Kno
 	}
 
 	url := fmt.Sprintf("%s/v1/datasets/%s/retrieve", c.baseURL, datasetID)
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
-	}
-
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
-
-	resp, err := c.httpClient.Do(httpReq)
+	statusCode, respBody, err := c.doJSON(ctx, "POST", url, body, true)
 	if err != nil {
-		return nil, fmt.Errorf("do request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("read response: %w", err)
+		return nil, err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("dify api error: %s, body: %s", resp.Status, string(respBody))
+	if statusCode != http.StatusOK {
+		return nil, fmt.Errorf("dify api error: %d, body: %s", statusCode, string(respBody))
 	}
 
 	var searchResult KnowledgeSearchResult
@@ -293,7 +323,7 @@ func (c *Client) CreateDocumentByText(ctx context.Context, datasetID, name, text
 	return &docResp, nil
 }
 
-// UpdateDocumentByText 更新文档
+// UpdateDocumentByText 更新文档，文档不存在时返回 ErrDocumentNotFound
 func (c *Client) UpdateDocumentByText(ctx context.Context, datasetID, documentID, name, text string) error {
 	req := map[string]interface{}{
 		"name": name,
@@ -323,6 +353,9 @@ func (c *Client) UpdateDocumentByText(ctx context.Context, datasetID, documentID
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("update document %s: %w", documentID, ErrDocumentNotFound)
+	}
 	if resp.StatusCode != http.StatusOK {
 		respBody, _ := io.ReadAll(resp.Body)
 		return fmt.Errorf("dify api error: %s, body: %s", resp.Status, string(respBody))
@@ -0,0 +1,13 @@
+package embedding
+
+// FitDimension 将向量调整到指定维度：不足补零，超出截断
+// 返回新的向量；维度一致或 dim <= 0 时原样返回
+func FitDimension(vec []float32, dim int) []float32 {
+	if dim <= 0 || len(vec) == dim {
+		return vec
+	}
+
+	result := make([]float32, dim)
+	copy(result, vec)
+	return result
+}
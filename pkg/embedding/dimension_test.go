@@ -0,0 +1,40 @@
+package embedding
+
+import "testing"
+
+func TestFitDimension(t *testing.T) {
+	tests := []struct {
+		name string
+		vec  []float32
+		dim  int
+		want []float32
+	}{
+		{"维度一致", []float32{1, 2, 3}, 3, []float32{1, 2, 3}},
+		{"不足补零", []float32{1, 2}, 4, []float32{1, 2, 0, 0}},
+		{"超出截断", []float32{1, 2, 3, 4, 5}, 3, []float32{1, 2, 3}},
+		{"未指定维度", []float32{1, 2}, 0, []float32{1, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FitDimension(tt.vec, tt.dim)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected length %d, got %d", len(tt.want), len(got))
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Expected %v, got %v", tt.want, got)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestFitDimensionDoesNotModifyInput(t *testing.T) {
+	vec := []float32{1, 2, 3, 4}
+	FitDimension(vec[:2], 3)
+	if vec[2] != 3 {
+		t.Errorf("Input backing array should not be modified, got %v", vec)
+	}
+}
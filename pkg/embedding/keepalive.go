@@ -0,0 +1,41 @@
+package embedding
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+const (
+	keepAliveText    = "ping"           // 保活请求的文本，尽量短以减少开销
+	keepAliveTimeout = 30 * time.Second // 单次保活请求超时（模型重新加载可能较慢）
+)
+
+// KeepAlive 立即发送一次极短的 embedding 请求预热模型，之后每隔 interval 发送一次，让模型常驻内存
+// Ollama 默认空闲 5 分钟后卸载模型，之后的第一次请求需要重新加载；阻塞直到 ctx 取消，interval<=0 时直接返回
+func (c *OllamaClient) KeepAlive(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	c.ping(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.ping(ctx)
+		}
+	}
+}
+
+// ping 发送一次保活请求，失败只记录日志
+func (c *OllamaClient) ping(ctx context.Context) {
+	reqCtx, cancel := context.WithTimeout(ctx, keepAliveTimeout)
+	defer cancel()
+	if _, err := c.GetEmbedding(reqCtx, keepAliveText); err != nil && ctx.Err() == nil {
+		log.Printf("Ollama keep-alive request failed: %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+package embedding
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestKeepAliveFires(t *testing.T) {
+	var pings atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req EmbeddingRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if r.URL.Path == "/api/embeddings" && req.Prompt == keepAliveText && req.Model == "nomic-embed-text" {
+			pings.Add(1)
+		}
+		w.Write([]byte(`{"embedding": [0.1, 0.2]}`))
+	}))
+	defer server.Close()
+
+	client := NewOllamaClient(server.URL, "")
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		client.KeepAlive(ctx, 10*time.Millisecond)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for pings.Load() < 3 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("KeepAlive did not return after context cancel")
+	}
+
+	if n := pings.Load(); n < 3 {
+		t.Errorf("Expected warm-up plus periodic keep-alive requests, got %d", n)
+	}
+}
+
+func TestKeepAliveDisabled(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+	}))
+	defer server.Close()
+
+	// interval<=0 时直接返回，不发送请求
+	NewOllamaClient(server.URL, "").KeepAlive(context.Background(), 0)
+	if hits.Load() != 0 {
+		t.Errorf("Expected no requests when disabled, got %d", hits.Load())
+	}
+}
@@ -0,0 +1,22 @@
+package embedding
+
+import "math"
+
+// NormalizeL2 对向量做 L2 归一化，返回新的向量（模长为 1）
+// 零向量原样返回，避免除零
+func NormalizeL2(vec []float32) []float32 {
+	var sum float64
+	for _, v := range vec {
+		sum += float64(v) * float64(v)
+	}
+	if sum == 0 {
+		return vec
+	}
+
+	norm := math.Sqrt(sum)
+	result := make([]float32, len(vec))
+	for i, v := range vec {
+		result[i] = float32(float64(v) / norm)
+	}
+	return result
+}
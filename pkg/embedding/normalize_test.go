@@ -0,0 +1,55 @@
+package embedding
+
+import (
+	"math"
+	"testing"
+)
+
+func magnitude(vec []float32) float64 {
+	var sum float64
+	for _, v := range vec {
+		sum += float64(v) * float64(v)
+	}
+	return math.Sqrt(sum)
+}
+
+func TestNormalizeL2(t *testing.T) {
+	tests := []struct {
+		name string
+		vec  []float32
+	}{
+		{"简单向量", []float32{3, 4}},
+		{"负数分量", []float32{-1, 2, -3, 4}},
+		{"已归一化", []float32{1, 0, 0}},
+		{"很小的分量", []float32{1e-4, 2e-4, 3e-4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NormalizeL2(tt.vec)
+			if len(result) != len(tt.vec) {
+				t.Fatalf("Expected length %d, got %d", len(tt.vec), len(result))
+			}
+			if m := magnitude(result); math.Abs(m-1) > 1e-5 {
+				t.Errorf("Expected magnitude 1, got %f", m)
+			}
+		})
+	}
+}
+
+func TestNormalizeL2KeepsDirection(t *testing.T) {
+	result := NormalizeL2([]float32{3, 4})
+	if math.Abs(float64(result[0])-0.6) > 1e-6 || math.Abs(float64(result[1])-0.8) > 1e-6 {
+		t.Errorf("Expected [0.6 0.8], got %v", result)
+	}
+}
+
+func TestNormalizeL2ZeroVector(t *testing.T) {
+	result := NormalizeL2([]float32{0, 0, 0})
+	for _, v := range result {
+		if v != 0 {
+			t.Errorf("Zero vector should stay zero, got %v", result)
+			break
+		}
+	}
+}
@@ -4,9 +4,12 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
+	"sync/atomic"
 	"time"
 )
 
@@ -16,6 +19,9 @@ type OllamaClient struct {
 	model     string
 	dimension int
 	client    *http.Client
+
+	concurrency      int         // 不支持批量接口时逐条请求的并发数，<=0 使用默认值
+	batchUnsupported atomic.Bool // 已确认服务端没有 /api/embed，之后直接逐条请求
 }
 
 // NewOllamaClient 创建 Ollama 客户端
@@ -93,17 +99,30 @@ func (c *OllamaClient) GetEmbedding(ctx context.Context, text string) ([]float32
 	return embResp.Embedding, nil
 }
 
-// GetEmbeddings 批量获取 embedding
+// GetEmbeddings 批量获取 embedding，返回顺序与 texts 一致
+// 优先使用 /api/embed 一次请求；旧版本 Ollama 不支持时改为有并发上限的逐条请求
 func (c *OllamaClient) GetEmbeddings(ctx context.Context, texts []string) ([][]float32, error) {
-	embeddings := make([][]float32, len(texts))
-	for i, text := range texts {
-		emb, err := c.GetEmbedding(ctx, text)
-		if err != nil {
-			return nil, fmt.Errorf("get embedding for text %d: %w", i, err)
+	if len(texts) == 0 {
+		return [][]float32{}, nil
+	}
+
+	if !c.batchUnsupported.Load() {
+		embeddings, err := c.getEmbeddingsBatch(ctx, texts)
+		if err == nil {
+			return embeddings, nil
+		}
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("batch embed: %w", err)
+		}
+		if errors.Is(err, errBatchUnsupported) {
+			c.batchUnsupported.Store(true)
+			log.Printf("Ollama at %s does not support /api/embed, using per-text requests", c.endpoint)
+		} else {
+			log.Printf("Ollama batch embed failed for %d texts, falling back to per-text requests: %v", len(texts), err)
 		}
-		embeddings[i] = emb
 	}
-	return embeddings, nil
+
+	return c.getEmbeddingsParallel(ctx, texts)
 }
 
 // GetDimension 获取 embedding 维度
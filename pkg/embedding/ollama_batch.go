@@ -0,0 +1,147 @@
+package embedding
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// defaultEmbedConcurrency 服务端不支持批量接口时逐条请求的并发数
+const defaultEmbedConcurrency = 4
+
+// errBatchUnsupported 服务端没有 /api/embed（Ollama 0.3 之前的版本）
+var errBatchUnsupported = errors.New("ollama batch embed not supported")
+
+// BatchEmbeddingRequest Ollama /api/embed 请求（input 为文本数组）
+type BatchEmbeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+// BatchEmbeddingResponse Ollama /api/embed 响应，顺序与 input 一致
+type BatchEmbeddingResponse struct {
+	Embeddings [][]float32 `json:"embeddings"`
+}
+
+// getEmbeddingsBatch 调用 /api/embed 一次获取所有文本的 embedding
+// 服务端返回 404/405 时返回 errBatchUnsupported
+func (c *OllamaClient) getEmbeddingsBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	body, err := json.Marshal(BatchEmbeddingRequest{Model: c.model, Input: texts})
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.endpoint+"/api/embed", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed {
+		return nil, errBatchUnsupported
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ollama error: HTTP %d - %s", resp.StatusCode, string(respBody))
+	}
+
+	var embResp BatchEmbeddingResponse
+	if err := json.Unmarshal(respBody, &embResp); err != nil {
+		return nil, fmt.Errorf("unmarshal response: %w", err)
+	}
+	if len(embResp.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("ollama returned %d embeddings for %d texts", len(embResp.Embeddings), len(texts))
+	}
+	return embResp.Embeddings, nil
+}
+
+// getEmbeddingsParallel 逐条调用 GetEmbedding，最多 concurrency 个请求并发，结果按输入顺序返回
+// 任一文本失败时取消其余请求，返回下标最小的失败
+func (c *OllamaClient) getEmbeddingsParallel(ctx context.Context, texts []string) ([][]float32, error) {
+	concurrency := c.concurrency
+	if concurrency <= 0 {
+		concurrency = defaultEmbedConcurrency
+	}
+	if concurrency > len(texts) {
+		concurrency = len(texts)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	embeddings := make([][]float32, len(texts))
+	errs := make([]error, len(texts))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				emb, err := c.GetEmbedding(ctx, texts[i])
+				if err != nil {
+					errs[i] = err
+					cancel()
+					continue
+				}
+				embeddings[i] = emb
+			}
+		}()
+	}
+
+	for i := range texts {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	// 优先报告真正的失败，而不是失败后被取消的请求
+	var canceled error
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		wrapped := fmt.Errorf("get embedding for text %d: %w", i, err)
+		if !errors.Is(err, context.Canceled) {
+			return nil, wrapped
+		}
+		if canceled == nil {
+			canceled = wrapped
+		}
+	}
+	if canceled != nil {
+		return nil, canceled
+	}
+	if err := ctx.Err(); err != nil && embeddingsIncomplete(embeddings) {
+		return nil, fmt.Errorf("get embeddings: %w", err)
+	}
+	return embeddings, nil
+}
+
+// embeddingsIncomplete 是否有文本未获取到 embedding（调用方 ctx 被取消时可能未全部派发）
+func embeddingsIncomplete(embeddings [][]float32) bool {
+	for _, emb := range embeddings {
+		if emb == nil {
+			return true
+		}
+	}
+	return false
+}
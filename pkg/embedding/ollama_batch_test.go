@@ -0,0 +1,168 @@
+package embedding
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeOllama 模拟 Ollama：embedding 为 [文本中的数字]，batch 为 false 时 /api/embed 返回 404
+type fakeOllama struct {
+	batch       bool
+	fail        string // 逐条请求遇到该文本时返回 500
+	batchCalls  atomic.Int32
+	singleCalls atomic.Int32
+	inFlight    atomic.Int32
+	maxInFlight atomic.Int32
+}
+
+func textVector(text string) []float32 {
+	n, _ := strconv.Atoi(strings.TrimPrefix(text, "text-"))
+	return []float32{float32(n)}
+}
+
+func (f *fakeOllama) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/api/embed":
+		f.batchCalls.Add(1)
+		if !f.batch {
+			http.NotFound(w, r)
+			return
+		}
+		var req BatchEmbeddingRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		resp := BatchEmbeddingResponse{}
+		for _, text := range req.Input {
+			resp.Embeddings = append(resp.Embeddings, textVector(text))
+		}
+		json.NewEncoder(w).Encode(resp)
+	case "/api/embeddings":
+		f.singleCalls.Add(1)
+		n := f.inFlight.Add(1)
+		defer f.inFlight.Add(-1)
+		for {
+			max := f.maxInFlight.Load()
+			if n <= max || f.maxInFlight.CompareAndSwap(max, n) {
+				break
+			}
+		}
+
+		var req EmbeddingRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Prompt == f.fail {
+			http.Error(w, "model crashed", http.StatusInternalServerError)
+			return
+		}
+		// 靠前的文本响应更慢，打乱完成顺序
+		vec := textVector(req.Prompt)
+		time.Sleep(time.Duration(10-int(vec[0])%10) * time.Millisecond)
+		json.NewEncoder(w).Encode(EmbeddingResponse{Embedding: vec})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func sampleTexts(n int) []string {
+	texts := make([]string, n)
+	for i := range texts {
+		texts[i] = "text-" + strconv.Itoa(i)
+	}
+	return texts
+}
+
+func assertInOrder(t *testing.T, embeddings [][]float32, n int) {
+	t.Helper()
+	if len(embeddings) != n {
+		t.Fatalf("Expected %d embeddings, got %d", n, len(embeddings))
+	}
+	for i, emb := range embeddings {
+		if len(emb) != 1 || emb[0] != float32(i) {
+			t.Errorf("embeddings[%d] = %v, want [%d]", i, emb, i)
+		}
+	}
+}
+
+func TestGetEmbeddingsPreservesOrder(t *testing.T) {
+	tests := []struct {
+		name        string
+		batch       bool
+		wantBatch   int32
+		wantSingles int32
+	}{
+		{"支持批量接口", true, 1, 0},
+		{"旧版本逐条请求", false, 1, 12},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeOllama{batch: tt.batch}
+			server := httptest.NewServer(fake)
+			defer server.Close()
+
+			client := NewOllamaClient(server.URL, "")
+			embeddings, err := client.GetEmbeddings(context.Background(), sampleTexts(12))
+			if err != nil {
+				t.Fatalf("GetEmbeddings() error: %v", err)
+			}
+			assertInOrder(t, embeddings, 12)
+
+			if got := fake.batchCalls.Load(); got != tt.wantBatch {
+				t.Errorf("batch calls = %d, want %d", got, tt.wantBatch)
+			}
+			if got := fake.singleCalls.Load(); got != tt.wantSingles {
+				t.Errorf("single calls = %d, want %d", got, tt.wantSingles)
+			}
+			if got := fake.maxInFlight.Load(); got > defaultEmbedConcurrency {
+				t.Errorf("max concurrent requests = %d, want <= %d", got, defaultEmbedConcurrency)
+			}
+		})
+	}
+}
+
+func TestGetEmbeddingsRemembersBatchUnsupported(t *testing.T) {
+	fake := &fakeOllama{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	client := NewOllamaClient(server.URL, "")
+	for i := 0; i < 3; i++ {
+		if _, err := client.GetEmbeddings(context.Background(), sampleTexts(2)); err != nil {
+			t.Fatalf("GetEmbeddings() error: %v", err)
+		}
+	}
+	if got := fake.batchCalls.Load(); got != 1 {
+		t.Errorf("Expected /api/embed to be probed once, got %d calls", got)
+	}
+}
+
+func TestGetEmbeddingsPartialFailure(t *testing.T) {
+	fake := &fakeOllama{fail: "text-5"}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	client := NewOllamaClient(server.URL, "")
+	embeddings, err := client.GetEmbeddings(context.Background(), sampleTexts(12))
+	if err == nil {
+		t.Fatal("Expected error when one text fails")
+	}
+	if embeddings != nil {
+		t.Errorf("Expected no partial result, got %d embeddings", len(embeddings))
+	}
+	if !strings.Contains(err.Error(), "text 5") || !strings.Contains(err.Error(), "HTTP 500") {
+		t.Errorf("Error should name the failing text and cause: %v", err)
+	}
+}
+
+func TestGetEmbeddingsEmpty(t *testing.T) {
+	client := NewOllamaClient("http://127.0.0.1:0", "")
+	embeddings, err := client.GetEmbeddings(context.Background(), nil)
+	if err != nil || len(embeddings) != 0 {
+		t.Errorf("GetEmbeddings(nil) = %v, %v", embeddings, err)
+	}
+}
@@ -9,6 +9,9 @@ import (
 	"net/http"
 	"sync"
 	"time"
+
+	"team-assistant/internal/backoff"
+	"team-assistant/pkg/ratelimit"
 )
 
 type Client struct {
@@ -16,16 +19,31 @@ type Client struct {
 	appID     string
 	appSecret string
 
+	httpClient *http.Client // 所有请求共用，带超时和可选代理（见 NewClientWithOptions）
+
 	token     string
 	tokenLock sync.RWMutex
 	expireAt  time.Time
+
+	resourceRetries int              // 下载消息资源遇到临时错误时的重试次数
+	resourceBackoff *backoff.Backoff // 下载重试等待策略
+
+	limiter          *ratelimit.Limiter // 所有接口共享的令牌桶（nil 不限流），见 SetRateLimit
+	rateLimitBackoff *backoff.Backoff   // 触发频率限制且响应头没有指明等待时间时的等待策略
+	sends            *sendQueue         // 按接收方排队发送消息（nil 不限制），见 SetSendRate
 }
 
 func NewClient(domain, appID, appSecret string) *Client {
 	return &Client{
-		domain:    domain,
-		appID:     appID,
-		appSecret: appSecret,
+		domain:           domain,
+		appID:            appID,
+		appSecret:        appSecret,
+		httpClient:       newHTTPClient(ClientOptions{}),
+		resourceRetries:  defaultResourceRetries,
+		resourceBackoff:  backoff.New(500*time.Millisecond, 5*time.Second),
+		limiter:          newQPSLimiter(defaultQPS),
+		rateLimitBackoff: backoff.New(1*time.Second, 10*time.Second),
+		sends:            newSendQueue(defaultSendPerRecipientQPS),
 	}
 }
 
@@ -60,7 +78,7 @@ func (c *Client) GetTenantAccessToken(ctx context.Context) (string, error) {
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return "", err
 	}
@@ -90,6 +108,10 @@ func (c *Client) GetTenantAccessToken(ctx context.Context) (string, error) {
 
 // SendMessage 发送消息到群
 func (c *Client) SendMessage(ctx context.Context, chatID, msgType, content string) error {
+	if err := c.sends.wait(ctx, chatID); err != nil {
+		return err
+	}
+
 	token, err := c.GetTenantAccessToken(ctx)
 	if err != nil {
 		return err
@@ -112,7 +134,7 @@ func (c *Client) SendMessage(ctx context.Context, chatID, msgType, content strin
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+token)
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return err
 	}
@@ -135,8 +157,12 @@ func (c *Client) SendMessage(ctx context.Context, chatID, msgType, content strin
 	return nil
 }
 
-// ReplyMessage 回复消息
+// ReplyMessage 回复消息，按 WithReplyChat 记录的会话排队发送
 func (c *Client) ReplyMessage(ctx context.Context, messageID, msgType, content string) error {
+	if err := c.sends.wait(ctx, replyRecipient(ctx, messageID)); err != nil {
+		return err
+	}
+
 	token, err := c.GetTenantAccessToken(ctx)
 	if err != nil {
 		return err
@@ -158,7 +184,7 @@ func (c *Client) ReplyMessage(ctx context.Context, messageID, msgType, content s
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+token)
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return err
 	}
@@ -200,7 +226,7 @@ func (c *Client) GetChatMessages(ctx context.Context, chatID string, pageSize in
 	}
 	req.Header.Set("Authorization", "Bearer "+token)
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -220,45 +246,24 @@ func (c *Client) GetChatMessages(ctx context.Context, chatID string, pageSize in
 	return &result, nil
 }
 
-// GetChats 获取机器人加入的群列表
+// GetChats 获取机器人加入的群列表（自动翻页）
 func (c *Client) GetChats(ctx context.Context) ([]*ChatInfo, error) {
-	token, err := c.GetTenantAccessToken(ctx)
-	if err != nil {
-		return nil, err
-	}
-
-	url := fmt.Sprintf("%s/open-apis/im/v1/chats?page_size=100", c.domain)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Authorization", "Bearer "+token)
-
-	resp, err := http.DefaultClient.Do(req)
+	var chats []*ChatInfo
+	err := c.listAll(ctx, func(pageToken string) string {
+		return withPageToken(fmt.Sprintf("%s/open-apis/im/v1/chats?page_size=100", c.domain), pageToken)
+	}, func(items json.RawMessage) error {
+		var page []*ChatInfo
+		if err := json.Unmarshal(items, &page); err != nil {
+			return err
+		}
+		chats = append(chats, page...)
+		return nil
+	})
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("get chats failed: %w", err)
 	}
-	defer resp.Body.Close()
 
-	respBody, _ := io.ReadAll(resp.Body)
-
-	var result struct {
-		Code int    `json:"code"`
-		Msg  string `json:"msg"`
-		Data struct {
-			Items []*ChatInfo `json:"items"`
-		} `json:"data"`
-	}
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return nil, err
-	}
-
-	if result.Code != 0 {
-		return nil, fmt.Errorf("get chats failed: %s", result.Msg)
-	}
-
-	return result.Data.Items, nil
+	return chats, nil
 }
 
 type GetMessagesResponse struct {
@@ -272,16 +277,17 @@ type GetMessagesResponse struct {
 }
 
 type MessageItem struct {
-	MessageID   string `json:"message_id"`
-	ThreadID    string `json:"thread_id"`
-	RootID      string `json:"root_id"`
-	ParentID    string `json:"parent_id"`
-	MsgType     string `json:"msg_type"`
-	CreateTime  string `json:"create_time"`
-	UpdateTime  string `json:"update_time"`
-	Deleted     bool   `json:"deleted"`
-	ChatID      string `json:"chat_id"`
-	Sender      struct {
+	MessageID      string `json:"message_id"`
+	ThreadID       string `json:"thread_id"`
+	RootID         string `json:"root_id"`
+	ParentID       string `json:"parent_id"`
+	UpperMessageID string `json:"upper_message_id"` // 合并转发的子消息才有，指向合并转发消息
+	MsgType        string `json:"msg_type"`
+	CreateTime     string `json:"create_time"`
+	UpdateTime     string `json:"update_time"`
+	Deleted        bool   `json:"deleted"`
+	ChatID         string `json:"chat_id"`
+	Sender         struct {
 		ID         string `json:"id"`
 		IDType     string `json:"id_type"`
 		SenderType string `json:"sender_type"`
@@ -337,7 +343,7 @@ func (c *Client) GetUserInfo(ctx context.Context, openID string) (*UserInfo, err
 	}
 	req.Header.Set("Authorization", "Bearer "+token)
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -365,6 +371,10 @@ func (c *Client) GetUserInfo(ctx context.Context, openID string) (*UserInfo, err
 
 // SendMessageToUser 发送消息给用户（私聊）
 func (c *Client) SendMessageToUser(ctx context.Context, openID, msgType, content string) error {
+	if err := c.sends.wait(ctx, openID); err != nil {
+		return err
+	}
+
 	token, err := c.GetTenantAccessToken(ctx)
 	if err != nil {
 		return err
@@ -387,7 +397,7 @@ func (c *Client) SendMessageToUser(ctx context.Context, openID, msgType, content
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+token)
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return err
 	}
@@ -413,6 +423,13 @@ func (c *Client) SendMessageToUser(ctx context.Context, openID, msgType, content
 // DownloadMessageResource 下载消息中的资源文件（图片、文件等）
 // 使用 /im/v1/messages/{message_id}/resources/{file_key} 接口
 func (c *Client) DownloadMessageResource(ctx context.Context, messageID, fileKey, resourceType string) ([]byte, error) {
+	return c.withResourceRetry(ctx, func() ([]byte, error) {
+		return c.downloadMessageResourceOnce(ctx, messageID, fileKey, resourceType, 0)
+	})
+}
+
+// downloadMessageResourceOnce 下载一次消息资源（不重试），maxBytes>0 时超过该大小返回 ResourceTooLargeError
+func (c *Client) downloadMessageResourceOnce(ctx context.Context, messageID, fileKey, resourceType string, maxBytes int64) ([]byte, error) {
 	token, err := c.GetTenantAccessToken(ctx)
 	if err != nil {
 		return nil, err
@@ -429,7 +446,7 @@ func (c *Client) DownloadMessageResource(ctx context.Context, messageID, fileKey
 	}
 	req.Header.Set("Authorization", "Bearer "+token)
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -437,9 +454,12 @@ func (c *Client) DownloadMessageResource(ctx context.Context, messageID, fileKey
 
 	if resp.StatusCode != 200 {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("download resource failed: status=%d, body=%s", resp.StatusCode, string(body))
+		return nil, &ResourceStatusError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
 
+	if maxBytes > 0 {
+		return readLimited(resp.Body, maxBytes)
+	}
 	data, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("read resource data failed: %w", err)
@@ -463,60 +483,22 @@ type ChatMember struct {
 
 // GetChatMembers 获取群成员列表（用于获取用户名称）
 func (c *Client) GetChatMembers(ctx context.Context, chatID string) (map[string]string, error) {
-	token, err := c.GetTenantAccessToken(ctx)
-	if err != nil {
-		return nil, err
-	}
-
 	members := make(map[string]string) // open_id -> name
-	pageToken := ""
-
-	for {
-		url := fmt.Sprintf("%s/open-apis/im/v1/chats/%s/members?member_id_type=open_id&page_size=100",
-			c.domain, chatID)
-		if pageToken != "" {
-			url += "&page_token=" + pageToken
-		}
-
-		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-		if err != nil {
-			return nil, err
-		}
-		req.Header.Set("Authorization", "Bearer "+token)
-
-		resp, err := http.DefaultClient.Do(req)
-		if err != nil {
-			return nil, err
-		}
-
-		respBody, _ := io.ReadAll(resp.Body)
-		resp.Body.Close()
-
-		var result struct {
-			Code int    `json:"code"`
-			Msg  string `json:"msg"`
-			Data struct {
-				Items     []ChatMember `json:"items"`
-				HasMore   bool         `json:"has_more"`
-				PageToken string       `json:"page_token"`
-			} `json:"data"`
+	err := c.listAll(ctx, func(pageToken string) string {
+		return withPageToken(fmt.Sprintf("%s/open-apis/im/v1/chats/%s/members?member_id_type=open_id&page_size=100",
+			c.domain, chatID), pageToken)
+	}, func(items json.RawMessage) error {
+		var page []ChatMember
+		if err := json.Unmarshal(items, &page); err != nil {
+			return err
 		}
-		if err := json.Unmarshal(respBody, &result); err != nil {
-			return nil, err
-		}
-
-		if result.Code != 0 {
-			return nil, fmt.Errorf("get chat members failed: %s", result.Msg)
-		}
-
-		for _, m := range result.Data.Items {
+		for _, m := range page {
 			members[m.MemberID] = m.Name
 		}
-
-		if !result.Data.HasMore {
-			break
-		}
-		pageToken = result.Data.PageToken
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get chat members failed: %w", err)
 	}
 
 	return members, nil
@@ -537,7 +519,7 @@ func (c *Client) GetChatInfo(ctx context.Context, chatID string) (*ChatInfo, err
 	}
 	req.Header.Set("Authorization", "Bearer "+token)
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -577,56 +559,26 @@ type BitableSearchResult struct {
 	PageToken string          `json:"page_token"`
 }
 
-// GetBitableRecords 获取多维表格记录
+// GetBitableRecords 获取多维表格记录（单页，pageToken 为上一页返回的 PageToken）
 func (c *Client) GetBitableRecords(ctx context.Context, appToken, tableID string, pageSize int, pageToken string) (*BitableSearchResult, error) {
-	token, err := c.GetTenantAccessToken(ctx)
-	if err != nil {
-		return nil, err
-	}
-
-	url := fmt.Sprintf("%s/open-apis/bitable/v1/apps/%s/tables/%s/records?page_size=%d",
-		c.domain, appToken, tableID, pageSize)
-	if pageToken != "" {
-		url += "&page_token=" + pageToken
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	page, err := c.getListPage(ctx, withPageToken(fmt.Sprintf("%s/open-apis/bitable/v1/apps/%s/tables/%s/records?page_size=%d",
+		c.domain, appToken, tableID, pageSize), pageToken))
 	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Authorization", "Bearer "+token)
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("get bitable records failed: %w", err)
 	}
-	defer resp.Body.Close()
-
-	respBody, _ := io.ReadAll(resp.Body)
 
-	var result struct {
-		Code int    `json:"code"`
-		Msg  string `json:"msg"`
-		Data struct {
-			Total     int              `json:"total"`
-			HasMore   bool             `json:"has_more"`
-			Items     []*BitableRecord `json:"items"`
-			PageToken string           `json:"page_token"`
-		} `json:"data"`
-	}
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return nil, err
-	}
-
-	if result.Code != 0 {
-		return nil, fmt.Errorf("get bitable records failed: %s", result.Msg)
+	var records []*BitableRecord
+	if len(page.Items) > 0 {
+		if err := json.Unmarshal(page.Items, &records); err != nil {
+			return nil, err
+		}
 	}
 
 	return &BitableSearchResult{
-		Total:     result.Data.Total,
-		HasMore:   result.Data.HasMore,
-		Records:   result.Data.Items,
-		PageToken: result.Data.PageToken,
+		Total:     page.Total,
+		HasMore:   page.HasMore,
+		Records:   records,
+		PageToken: page.PageToken,
 	}, nil
 }
 
@@ -664,7 +616,7 @@ func (c *Client) SearchBitableRecords(ctx context.Context, appToken, tableID str
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+token)
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -699,6 +651,11 @@ func (c *Client) SearchBitableRecords(ctx context.Context, appToken, tableID str
 
 // GetSiteInfoByPrefix 根据站点前缀查询站点信息
 func (c *Client) GetSiteInfoByPrefix(ctx context.Context, appToken, tableID, prefix string) (*BitableRecord, error) {
+	return c.FindBitableRecord(ctx, appToken, tableID, "站点前缀", prefix)
+}
+
+// FindBitableRecord 查询指定字段等于 value 的第一条记录，没有时返回 nil
+func (c *Client) FindBitableRecord(ctx context.Context, appToken, tableID, fieldName, value string) (*BitableRecord, error) {
 	token, err := c.GetTenantAccessToken(ctx)
 	if err != nil {
 		return nil, err
@@ -713,9 +670,9 @@ func (c *Client) GetSiteInfoByPrefix(ctx context.Context, appToken, tableID, pre
 			"conjunction": "and",
 			"conditions": []map[string]interface{}{
 				{
-					"field_name": "站点前缀",
+					"field_name": fieldName,
 					"operator":   "is",
-					"value":      []string{prefix},
+					"value":      []string{value},
 				},
 			},
 		},
@@ -729,7 +686,7 @@ func (c *Client) GetSiteInfoByPrefix(ctx context.Context, appToken, tableID, pre
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+token)
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -749,7 +706,7 @@ func (c *Client) GetSiteInfoByPrefix(ctx context.Context, appToken, tableID, pre
 	}
 
 	if result.Code != 0 {
-		return nil, fmt.Errorf("search site info failed: %s", result.Msg)
+		return nil, fmt.Errorf("search bitable record by %s failed: %s", fieldName, result.Msg)
 	}
 
 	if len(result.Data.Items) == 0 {
@@ -766,108 +723,30 @@ type BitableField struct {
 	Type      int    `json:"type"`
 }
 
-// GetBitableFields 获取多维表格字段列表
+// GetBitableFields 获取多维表格字段列表（自动翻页）
 func (c *Client) GetBitableFields(ctx context.Context, appToken, tableID string) ([]*BitableField, error) {
-	token, err := c.GetTenantAccessToken(ctx)
-	if err != nil {
-		return nil, err
-	}
-
-	url := fmt.Sprintf("%s/open-apis/bitable/v1/apps/%s/tables/%s/fields",
-		c.domain, appToken, tableID)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Authorization", "Bearer "+token)
-
-	resp, err := http.DefaultClient.Do(req)
+	var fields []*BitableField
+	err := c.listAll(ctx, func(pageToken string) string {
+		return withPageToken(fmt.Sprintf("%s/open-apis/bitable/v1/apps/%s/tables/%s/fields?page_size=100",
+			c.domain, appToken, tableID), pageToken)
+	}, func(items json.RawMessage) error {
+		var page []*BitableField
+		if err := json.Unmarshal(items, &page); err != nil {
+			return err
+		}
+		fields = append(fields, page...)
+		return nil
+	})
 	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	respBody, _ := io.ReadAll(resp.Body)
-
-	var result struct {
-		Code int    `json:"code"`
-		Msg  string `json:"msg"`
-		Data struct {
-			Items []*BitableField `json:"items"`
-		} `json:"data"`
-	}
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return nil, err
-	}
-
-	if result.Code != 0 {
-		return nil, fmt.Errorf("get bitable fields failed: %s", result.Msg)
+		return nil, fmt.Errorf("get bitable fields failed: %w", err)
 	}
 
-	return result.Data.Items, nil
+	return fields, nil
 }
 
 // GetSiteInfoBySiteID 根据站点ID查询站点信息
 func (c *Client) GetSiteInfoBySiteID(ctx context.Context, appToken, tableID, siteID string) (*BitableRecord, error) {
-	token, err := c.GetTenantAccessToken(ctx)
-	if err != nil {
-		return nil, err
-	}
-
-	url := fmt.Sprintf("%s/open-apis/bitable/v1/apps/%s/tables/%s/records/search",
-		c.domain, appToken, tableID)
-
-	body := map[string]interface{}{
-		"page_size": 1,
-		"filter": map[string]interface{}{
-			"conjunction": "and",
-			"conditions": []map[string]interface{}{
-				{
-					"field_name": "站点ID",
-					"operator":   "is",
-					"value":      []string{siteID},
-				},
-			},
-		},
-	}
-	jsonBody, _ := json.Marshal(body)
-
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(jsonBody))
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+token)
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	respBody, _ := io.ReadAll(resp.Body)
-
-	var result struct {
-		Code int    `json:"code"`
-		Msg  string `json:"msg"`
-		Data struct {
-			Items []*BitableRecord `json:"items"`
-		} `json:"data"`
-	}
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return nil, err
-	}
-
-	if result.Code != 0 {
-		return nil, fmt.Errorf("search site info by ID failed: %s", result.Msg)
-	}
-
-	if len(result.Data.Items) == 0 {
-		return nil, nil
-	}
-
-	return result.Data.Items[0], nil
+	return c.FindBitableRecord(ctx, appToken, tableID, "站点ID", siteID)
 }
 
 // GetChatHistory 获取群聊历史消息（支持时间范围）
@@ -895,7 +774,7 @@ func (c *Client) GetChatHistory(ctx context.Context, chatID string, startTime, e
 	}
 	req.Header.Set("Authorization", "Bearer "+token)
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -909,7 +788,7 @@ func (c *Client) GetChatHistory(ctx context.Context, chatID string, startTime, e
 	}
 
 	if result.Code != 0 {
-		return nil, fmt.Errorf("get chat history failed: %s", result.Msg)
+		return nil, &APIError{Op: "get chat history", Code: result.Code, Msg: result.Msg}
 	}
 
 	return &result, nil
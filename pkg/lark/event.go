@@ -63,6 +63,35 @@ type MessageReceiveEvent struct {
 	} `json:"message"`
 }
 
+// ChatLifecycleEvent 群解散、机器人进群/被移出群事件
+// （im.chat.disbanded_v1 / im.chat.member.bot.added_v1 / im.chat.member.bot.deleted_v1）
+type ChatLifecycleEvent struct {
+	ChatID     string `json:"chat_id"`
+	Name       string `json:"name"` // 群名称（机器人进出群事件）
+	OperatorID struct {
+		OpenID  string `json:"open_id"`
+		UserID  string `json:"user_id"`
+		UnionID string `json:"union_id"`
+	} `json:"operator_id"`
+	External bool `json:"external"`
+}
+
+// MessageReactionEvent 消息表情回复事件（im.message.reaction.created_v1 / deleted_v1）
+type MessageReactionEvent struct {
+	MessageID    string `json:"message_id"`
+	ReactionType struct {
+		EmojiType string `json:"emoji_type"`
+	} `json:"reaction_type"`
+	OperatorType string `json:"operator_type"` // user / app
+	UserID       struct {
+		OpenID  string `json:"open_id"`
+		UserID  string `json:"user_id"`
+		UnionID string `json:"union_id"`
+	} `json:"user_id"`
+	AppID      string `json:"app_id"`
+	ActionTime string `json:"action_time"`
+}
+
 // ParseMessageContent 解析消息内容
 func ParseMessageContent(msgType, content string) string {
 	switch msgType {
@@ -0,0 +1,63 @@
+package lark
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// defaultHTTPTimeout 调用飞书接口的默认超时，避免飞书响应慢时协程一直挂起
+const defaultHTTPTimeout = 30 * time.Second
+
+// ProxyConfig HTTP 代理配置
+type ProxyConfig struct {
+	Host     string
+	Port     int
+	User     string
+	Password string
+}
+
+// ClientOptions 创建客户端的可选配置
+type ClientOptions struct {
+	Timeout time.Duration // 单次请求超时（含读取响应体），0 使用默认值 30 秒
+	Proxy   *ProxyConfig  // HTTP 代理（可选）
+}
+
+// NewClientWithOptions 创建指定超时和代理的客户端
+func NewClientWithOptions(domain, appID, appSecret string, opts ClientOptions) *Client {
+	c := NewClient(domain, appID, appSecret)
+	c.httpClient = newHTTPClient(opts)
+	return c
+}
+
+// newHTTPClient 按配置创建 HTTP 客户端
+func newHTTPClient(opts ClientOptions) *http.Client {
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = defaultHTTPTimeout
+	}
+	client := &http.Client{Timeout: timeout}
+
+	if proxyURL := opts.Proxy.url(); proxyURL != nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.Proxy = http.ProxyURL(proxyURL)
+		client.Transport = transport
+	}
+	return client
+}
+
+// url 代理地址，未配置时返回 nil
+func (p *ProxyConfig) url() *url.URL {
+	if p == nil || p.Host == "" || p.Port <= 0 {
+		return nil
+	}
+	u := &url.URL{Scheme: "http", Host: fmt.Sprintf("%s:%d", p.Host, p.Port)}
+	switch {
+	case p.User != "" && p.Password != "":
+		u.User = url.UserPassword(p.User, p.Password)
+	case p.User != "":
+		u.User = url.User(p.User)
+	}
+	return u
+}
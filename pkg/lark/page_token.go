@@ -0,0 +1,27 @@
+package lark
+
+import (
+	"errors"
+	"fmt"
+)
+
+// pageTokenExpiredCode 分页 Token 无效/过期时飞书返回的错误码
+const pageTokenExpiredCode = 230001
+
+// APIError 飞书接口返回的业务错误（code 不为 0）
+type APIError struct {
+	Op   string // 调用的接口，如 "get chat history"
+	Code int
+	Msg  string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s failed: %s", e.Op, e.Msg)
+}
+
+// IsPageTokenExpired 是否是分页 Token 失效/过期的错误
+// 长时间同步时 page_token 可能过期，按飞书返回的错误码判断，此时需要去掉 Token 从已同步的位置重新拉取
+func IsPageTokenExpired(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Code == pageTokenExpiredCode
+}
@@ -0,0 +1,81 @@
+package lark
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// expiringHistoryServer 带 page_token 的请求返回 Token 过期，不带 Token 的请求返回一页消息
+type expiringHistoryServer struct {
+	tokens []string // 依次收到的 page_token
+}
+
+func (s *expiringHistoryServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(r.URL.Path, "/tenant_access_token/internal") {
+		fmt.Fprint(w, `{"code":0,"tenant_access_token":"t-test","expire":7200}`)
+		return
+	}
+	token := r.URL.Query().Get("page_token")
+	s.tokens = append(s.tokens, token)
+	if token != "" {
+		fmt.Fprint(w, `{"code":230001,"msg":"invalid page_token, it may have expired"}`)
+		return
+	}
+	fmt.Fprint(w, `{"code":0,"data":{"has_more":false,"items":[{"message_id":"om_1","create_time":"1700000000123"}]}}`)
+}
+
+func TestGetChatHistoryExpiredPageToken(t *testing.T) {
+	s := &expiringHistoryServer{}
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+	c := NewClient(server.URL, "app", "secret")
+	ctx := context.Background()
+
+	_, err := c.GetChatHistory(ctx, "oc_1", "1699990000", "", 50, "stale-token")
+	if !IsPageTokenExpired(err) {
+		t.Fatalf("Expected page token expired error, got %v", err)
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != 230001 {
+		t.Errorf("Expected APIError with code, got %#v", err)
+	}
+
+	// 去掉 Token 从续传时间重新拉取
+	resp, err := c.GetChatHistory(ctx, "oc_1", "1700000000", "", 50, "")
+	if err != nil {
+		t.Fatalf("Restart without page token failed: %v", err)
+	}
+	if len(resp.Data.Items) != 1 || resp.Data.Items[0].MessageID != "om_1" {
+		t.Errorf("Unexpected items after restart: %+v", resp.Data.Items)
+	}
+	if strings.Join(s.tokens, ",") != "stale-token," {
+		t.Errorf("page tokens = %q", s.tokens)
+	}
+}
+
+func TestIsPageTokenExpired(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"Token 失效", &APIError{Op: "get chat history", Code: 230001, Msg: "invalid page_token"}, true},
+		{"包装后的错误", fmt.Errorf("sync: %w", &APIError{Code: 230001, Msg: "page token expired"}), true},
+		{"其他错误码提到 page_token", &APIError{Code: 99992402, Msg: "field validation failed: page_token"}, false},
+		{"限流", &APIError{Code: 99991400, Msg: "request trigger frequency limit"}, false},
+		{"网络错误", errors.New("page_token: connection reset"), false},
+		{"nil", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsPageTokenExpired(tt.err); got != tt.want {
+				t.Errorf("IsPageTokenExpired(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
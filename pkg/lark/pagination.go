@@ -0,0 +1,97 @@
+package lark
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// listPage 分页列表接口的单页数据
+type listPage struct {
+	Items     json.RawMessage // data.items，由调用方解析
+	HasMore   bool
+	PageToken string
+	Total     int
+}
+
+// withPageToken 在请求地址后追加 page_token（首页为空时不追加）
+func withPageToken(rawURL, pageToken string) string {
+	if pageToken == "" {
+		return rawURL
+	}
+	return rawURL + "&page_token=" + url.QueryEscape(pageToken)
+}
+
+// getListPage 请求分页列表接口的一页，code 不为 0 时返回 msg 作为错误
+func (c *Client) getListPage(ctx context.Context, pageURL string) (*listPage, error) {
+	token, err := c.GetTenantAccessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+
+	var result struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+		Data struct {
+			Items     json.RawMessage `json:"items"`
+			HasMore   bool            `json:"has_more"`
+			PageToken string          `json:"page_token"`
+			Total     int             `json:"total"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, err
+	}
+
+	if result.Code != 0 {
+		return nil, errors.New(result.Msg)
+	}
+
+	return &listPage{
+		Items:     result.Data.Items,
+		HasMore:   result.Data.HasMore,
+		PageToken: result.Data.PageToken,
+		Total:     result.Data.Total,
+	}, nil
+}
+
+// listAll 按 has_more/page_token 循环请求分页列表接口，直到最后一页
+// urlBuilder 根据 page_token 生成请求地址（首页为空），decodeItems 解析每页的 data.items
+func (c *Client) listAll(ctx context.Context, urlBuilder func(pageToken string) string, decodeItems func(items json.RawMessage) error) error {
+	pageToken := ""
+	for {
+		page, err := c.getListPage(ctx, urlBuilder(pageToken))
+		if err != nil {
+			return err
+		}
+
+		if len(page.Items) > 0 {
+			if err := decodeItems(page.Items); err != nil {
+				return err
+			}
+		}
+
+		// page_token 为空或没有变化时停止，避免接口异常导致死循环
+		if !page.HasMore || page.PageToken == "" || page.PageToken == pageToken {
+			return nil
+		}
+		pageToken = page.PageToken
+	}
+}
@@ -0,0 +1,144 @@
+package lark
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakePagedServer 按 page_token 返回分页数据的飞书接口
+type fakePagedServer struct {
+	pages      map[string]string // page_token -> data JSON
+	requests   []string          // 收到的列表请求 page_token
+	errorToken string            // 请求此 page_token 时返回 code 非 0
+}
+
+func (s *fakePagedServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(r.URL.Path, "/tenant_access_token/internal") {
+		fmt.Fprint(w, `{"code":0,"tenant_access_token":"t-test","expire":7200}`)
+		return
+	}
+	if r.Header.Get("Authorization") != "Bearer t-test" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	token := r.URL.Query().Get("page_token")
+	s.requests = append(s.requests, token)
+	if token != "" && token == s.errorToken {
+		fmt.Fprint(w, `{"code":99991400,"msg":"request trigger frequency limit"}`)
+		return
+	}
+	fmt.Fprintf(w, `{"code":0,"msg":"success","data":%s}`, s.pages[token])
+}
+
+func newPagedClient(t *testing.T, s *fakePagedServer) *Client {
+	t.Helper()
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+	return NewClient(server.URL, "app", "secret")
+}
+
+// chatPage 生成一页群列表数据
+func chatPage(nextToken string, ids ...string) string {
+	items := make([]map[string]string, len(ids))
+	for i, id := range ids {
+		items[i] = map[string]string{"chat_id": id, "name": "群" + id}
+	}
+	data, _ := json.Marshal(map[string]interface{}{
+		"items": items, "has_more": nextToken != "", "page_token": nextToken,
+	})
+	return string(data)
+}
+
+func TestGetChatsPaginates(t *testing.T) {
+	server := &fakePagedServer{pages: map[string]string{
+		"":   chatPage("p2", "oc_1", "oc_2"),
+		"p2": chatPage("p3", "oc_3"),
+		"p3": chatPage("", "oc_4"),
+	}}
+	chats, err := newPagedClient(t, server).GetChats(context.Background())
+	if err != nil {
+		t.Fatalf("GetChats() error: %v", err)
+	}
+
+	var ids []string
+	for _, c := range chats {
+		ids = append(ids, c.ChatID)
+	}
+	if got := strings.Join(ids, ","); got != "oc_1,oc_2,oc_3,oc_4" {
+		t.Errorf("GetChats() ids = %s, want all pages", got)
+	}
+	if got := strings.Join(server.requests, ","); got != ",p2,p3" {
+		t.Errorf("Requested page tokens = %q", got)
+	}
+}
+
+func TestGetChatMembersPaginates(t *testing.T) {
+	server := &fakePagedServer{pages: map[string]string{
+		"":   `{"items":[{"member_id":"ou_1","name":"张三"}],"has_more":true,"page_token":"p2"}`,
+		"p2": `{"items":[{"member_id":"ou_2","name":"李四"}],"has_more":false}`,
+	}}
+	members, err := newPagedClient(t, server).GetChatMembers(context.Background(), "oc_dev")
+	if err != nil {
+		t.Fatalf("GetChatMembers() error: %v", err)
+	}
+	if len(members) != 2 || members["ou_1"] != "张三" || members["ou_2"] != "李四" {
+		t.Errorf("GetChatMembers() = %v", members)
+	}
+}
+
+func TestListAllStopsOnInvalidPageToken(t *testing.T) {
+	tests := []struct {
+		name  string
+		pages map[string]string
+		want  int
+	}{
+		{"has_more 但没有 page_token", map[string]string{"": `{"items":[{"chat_id":"oc_1"}],"has_more":true}`}, 1},
+		{"page_token 不变", map[string]string{"": chatPage("p2", "oc_1"), "p2": chatPage("p2", "oc_2")}, 2},
+		{"空页", map[string]string{"": `{"has_more":false}`}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &fakePagedServer{pages: tt.pages}
+			if _, err := newPagedClient(t, server).GetChats(context.Background()); err != nil {
+				t.Fatalf("GetChats() error: %v", err)
+			}
+			if len(server.requests) != tt.want {
+				t.Errorf("Requests = %d, want %d", len(server.requests), tt.want)
+			}
+		})
+	}
+}
+
+func TestListAllReturnsPageError(t *testing.T) {
+	server := &fakePagedServer{
+		pages:      map[string]string{"": chatPage("p2", "oc_1")},
+		errorToken: "p2",
+	}
+	_, err := newPagedClient(t, server).GetChats(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "get chats failed: request trigger frequency limit") {
+		t.Errorf("Expected page error, got %v", err)
+	}
+}
+
+func TestGetBitableRecordsSinglePage(t *testing.T) {
+	server := &fakePagedServer{pages: map[string]string{
+		"p2": `{"items":[{"record_id":"rec_1","fields":{"站点":"by4"}}],"has_more":true,"page_token":"p3","total":3}`,
+	}}
+	result, err := newPagedClient(t, server).GetBitableRecords(context.Background(), "app", "tbl", 1, "p2")
+	if err != nil {
+		t.Fatalf("GetBitableRecords() error: %v", err)
+	}
+	if len(result.Records) != 1 || result.Records[0].RecordID != "rec_1" || !result.HasMore || result.PageToken != "p3" || result.Total != 3 {
+		t.Errorf("GetBitableRecords() = %+v", result)
+	}
+	if len(server.requests) != 1 {
+		t.Errorf("GetBitableRecords should request a single page, got %d", len(server.requests))
+	}
+}
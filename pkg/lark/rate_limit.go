@@ -0,0 +1,115 @@
+package lark
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"team-assistant/internal/backoff"
+	"team-assistant/pkg/ratelimit"
+)
+
+const (
+	rateLimitCode        = 99991400 // 飞书频率限制的错误码
+	defaultQPS           = 20       // 默认每秒请求数上限
+	rateLimitRetries     = 3        // 触发频率限制后的最多重试次数
+	maxRateLimitWait     = 60 * time.Second
+	rateLimitResetHeader = "X-Ogw-Ratelimit-Reset"
+)
+
+// SetRateLimit 设置调用飞书接口的每秒请求数上限（所有接口共享一个令牌桶）
+// qps 为 0 使用默认值 20，负数不限流
+func (c *Client) SetRateLimit(qps float64) {
+	switch {
+	case qps < 0:
+		c.limiter = nil
+	case qps == 0:
+		c.limiter = newQPSLimiter(defaultQPS)
+	default:
+		c.limiter = newQPSLimiter(qps)
+	}
+}
+
+// newQPSLimiter 创建每秒 qps 个请求、允许一秒突发量的令牌桶
+func newQPSLimiter(qps float64) *ratelimit.Limiter {
+	return ratelimit.NewLimiter(qps, int(math.Max(1, math.Ceil(qps))))
+}
+
+// do 发送请求：先从令牌桶取令牌，触发飞书频率限制时按响应头等待后重试，最多 3 次
+// 重试用完仍被限流时原样返回最后一次响应，由调用方按原有逻辑处理错误
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	for attempt := 1; ; attempt++ {
+		if c.limiter != nil {
+			if err := c.limiter.Wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if attempt > rateLimitRetries || !isRateLimited(resp) || (req.Body != nil && req.GetBody == nil) {
+			return resp, nil
+		}
+
+		wait := rateLimitWait(resp.Header, c.rateLimitBackoff, attempt)
+		resp.Body.Close()
+		log.Printf("[Lark] Rate limited on %s, retrying in %v (attempt %d/%d)", req.URL.Path, wait, attempt, rateLimitRetries)
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(ctx)
+			req.Body = body
+		}
+	}
+}
+
+// isRateLimited 判断响应是否是频率限制：HTTP 429 或 JSON 响应的 code 为 99991400
+// 会读取 JSON 响应体判断，读取后重新放回，不影响调用方解析
+func isRateLimited(resp *http.Response) bool {
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	if resp.StatusCode < http.StatusBadRequest && !strings.Contains(resp.Header.Get("Content-Type"), "json") {
+		return false
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return false
+	}
+	var result struct {
+		Code int `json:"code"`
+	}
+	return json.Unmarshal(body, &result) == nil && result.Code == rateLimitCode
+}
+
+// rateLimitWait 被限流后的等待时间：优先使用 Retry-After / X-Ogw-Ratelimit-Reset（秒），都没有时按退避策略
+func rateLimitWait(header http.Header, b *backoff.Backoff, attempt int) time.Duration {
+	for _, name := range []string{"Retry-After", rateLimitResetHeader} {
+		if seconds, err := strconv.Atoi(strings.TrimSpace(header.Get(name))); err == nil && seconds > 0 {
+			return min(time.Duration(seconds)*time.Second, maxRateLimitWait)
+		}
+	}
+	return b.Duration(attempt)
+}
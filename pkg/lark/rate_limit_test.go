@@ -0,0 +1,162 @@
+package lark
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"team-assistant/internal/backoff"
+)
+
+// rateLimitedServer 前 limited 次接口请求返回频率限制，之后正常返回
+type rateLimitedServer struct {
+	limited  int
+	attempts int
+	bodies   []string // 收到的请求体
+	response string
+}
+
+func (s *rateLimitedServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(r.URL.Path, "/tenant_access_token/internal") {
+		fmt.Fprint(w, `{"code":0,"tenant_access_token":"t-test","expire":7200}`)
+		return
+	}
+	s.attempts++
+	body, _ := io.ReadAll(r.Body)
+	s.bodies = append(s.bodies, string(body))
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if s.attempts <= s.limited {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"code":99991400,"msg":"request trigger frequency limit"}`)
+		return
+	}
+	fmt.Fprint(w, s.response)
+}
+
+func newRateLimitedClient(t *testing.T, s *rateLimitedServer) *Client {
+	t.Helper()
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+	c := NewClient(server.URL, "app", "secret")
+	c.rateLimitBackoff = backoff.New(time.Millisecond, time.Millisecond)
+	return c
+}
+
+func TestRateLimitRetryTransparent(t *testing.T) {
+	s := &rateLimitedServer{limited: 2, response: `{"code":0,"data":{"items":[{"member_id":"ou_1","name":"张三"}],"has_more":false}}`}
+	members, err := newRateLimitedClient(t, s).GetChatMembers(context.Background(), "oc_1")
+	if err != nil {
+		t.Fatalf("GetChatMembers failed: %v", err)
+	}
+	if members["ou_1"] != "张三" {
+		t.Errorf("GetChatMembers() = %v", members)
+	}
+	if s.attempts != 3 {
+		t.Errorf("Expected 2 retries, got %d attempts", s.attempts)
+	}
+}
+
+func TestRateLimitRetryReplaysBody(t *testing.T) {
+	s := &rateLimitedServer{limited: 1, response: `{"code":0}`}
+	if err := newRateLimitedClient(t, s).ReplyMessage(context.Background(), "om_1", "text", `{"text":"你好"}`); err != nil {
+		t.Fatalf("ReplyMessage failed: %v", err)
+	}
+	if len(s.bodies) != 2 || s.bodies[0] == "" || s.bodies[0] != s.bodies[1] {
+		t.Errorf("Expected the same body on retry, got %q", s.bodies)
+	}
+}
+
+func TestRateLimitRetriesExhausted(t *testing.T) {
+	s := &rateLimitedServer{limited: 10}
+	_, err := newRateLimitedClient(t, s).GetChatMembers(context.Background(), "oc_1")
+	if err == nil || !strings.Contains(err.Error(), "request trigger frequency limit") {
+		t.Errorf("Expected rate limit error after retries, got %v", err)
+	}
+	if s.attempts != rateLimitRetries+1 {
+		t.Errorf("Expected %d attempts, got %d", rateLimitRetries+1, s.attempts)
+	}
+}
+
+func TestIsRateLimited(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		contentType string
+		body        string
+		want        bool
+	}{
+		{"HTTP 429", http.StatusTooManyRequests, "text/plain", "slow down", true},
+		{"400 限流码", http.StatusBadRequest, "application/json", `{"code":99991400,"msg":"limit"}`, true},
+		{"200 限流码", http.StatusOK, "application/json; charset=utf-8", `{"code":99991400}`, true},
+		{"其他业务错误", http.StatusBadRequest, "application/json", `{"code":230002,"msg":"bot not in chat"}`, false},
+		{"成功", http.StatusOK, "application/json", `{"code":0}`, false},
+		{"图片数据不读取", http.StatusOK, "image/png", `{"code":99991400}`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: tt.status,
+				Header:     http.Header{"Content-Type": {tt.contentType}},
+				Body:       io.NopCloser(strings.NewReader(tt.body)),
+			}
+			if got := isRateLimited(resp); got != tt.want {
+				t.Errorf("isRateLimited() = %v, want %v", got, tt.want)
+			}
+			// 判断后响应体仍可被调用方读取
+			if body, _ := io.ReadAll(resp.Body); string(body) != tt.body {
+				t.Errorf("Response body after check = %q, want %q", body, tt.body)
+			}
+		})
+	}
+}
+
+func TestRateLimitWait(t *testing.T) {
+	b := backoff.New(time.Second, 10*time.Second)
+	b.Jitter = 0
+	tests := []struct {
+		name   string
+		header http.Header
+		want   time.Duration
+	}{
+		{"Retry-After", http.Header{"Retry-After": {"3"}}, 3 * time.Second},
+		{"X-Ogw-Ratelimit-Reset", http.Header{"X-Ogw-Ratelimit-Reset": {"2"}}, 2 * time.Second},
+		{"超过上限", http.Header{"Retry-After": {"600"}}, maxRateLimitWait},
+		{"没有响应头按退避", http.Header{}, 2 * time.Second},
+		{"无效值按退避", http.Header{"Retry-After": {"soon"}}, 2 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rateLimitWait(tt.header, b, 2); got != tt.want {
+				t.Errorf("rateLimitWait() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetRateLimit(t *testing.T) {
+	c := NewClient("http://localhost", "app", "secret")
+	if c.limiter == nil {
+		t.Fatal("Expected default limiter")
+	}
+	c.SetRateLimit(-1)
+	if c.limiter != nil {
+		t.Error("Expected negative QPS to disable the limiter")
+	}
+
+	c.SetRateLimit(2)
+	allowed := 0
+	for i := 0; i < 5; i++ {
+		if c.limiter.Allow() {
+			allowed++
+		}
+	}
+	if allowed != 2 {
+		t.Errorf("Expected burst of 2 with QPS 2, got %d", allowed)
+	}
+}
@@ -0,0 +1,36 @@
+package lark
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// ResourceTooLargeError 下载的消息资源超过大小限制
+type ResourceTooLargeError struct {
+	Limit int64 // 允许的最大字节数
+}
+
+func (e *ResourceTooLargeError) Error() string {
+	return fmt.Sprintf("resource exceeds %d bytes", e.Limit)
+}
+
+// DownloadImageWithLimit 下载图片，超过 maxBytes 时停止读取并返回 ResourceTooLargeError，避免超大图片占满内存
+// maxBytes<=0 不限制
+func (c *Client) DownloadImageWithLimit(ctx context.Context, messageID, imageKey string, maxBytes int64) ([]byte, error) {
+	return c.withResourceRetry(ctx, func() ([]byte, error) {
+		return c.downloadMessageResourceOnce(ctx, messageID, imageKey, "image", maxBytes)
+	})
+}
+
+// readLimited 最多读取 maxBytes+1 字节，多读的一个字节用于判断是否超限
+func readLimited(r io.Reader, maxBytes int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("read resource data failed: %w", err)
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, &ResourceTooLargeError{Limit: maxBytes}
+	}
+	return data, nil
+}
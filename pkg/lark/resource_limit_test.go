@@ -0,0 +1,44 @@
+package lark
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestDownloadImageWithLimit(t *testing.T) {
+	tests := []struct {
+		name     string
+		maxBytes int64
+		tooLarge bool
+	}{
+		{"不限制", 0, false},
+		{"刚好等于上限", int64(len("image-bytes")), false},
+		{"超过上限", int64(len("image-bytes")) - 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &flakyResourceServer{}
+			c := newFlakyResourceClient(t, s, 2)
+			data, err := c.DownloadImageWithLimit(context.Background(), "om_1", "img_1", tt.maxBytes)
+
+			var tooLarge *ResourceTooLargeError
+			if errors.As(err, &tooLarge) != tt.tooLarge {
+				t.Fatalf("DownloadImageWithLimit() error = %v, want too large %v", err, tt.tooLarge)
+			}
+			if tt.tooLarge {
+				if tooLarge.Limit != tt.maxBytes {
+					t.Errorf("ResourceTooLargeError.Limit = %d, want %d", tooLarge.Limit, tt.maxBytes)
+				}
+				if s.attempts != 1 {
+					t.Errorf("Expected no retry for oversized image, got %d attempts", s.attempts)
+				}
+				return
+			}
+			if string(data) != "image-bytes" {
+				t.Errorf("DownloadImageWithLimit() data = %q", data)
+			}
+		})
+	}
+}
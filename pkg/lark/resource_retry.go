@@ -0,0 +1,71 @@
+package lark
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+
+	"team-assistant/internal/backoff"
+)
+
+// defaultResourceRetries 下载消息资源的默认重试次数
+const defaultResourceRetries = 3
+
+// ResourceStatusError 下载消息资源时飞书返回非 200 状态码
+type ResourceStatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *ResourceStatusError) Error() string {
+	return fmt.Sprintf("download resource failed: status=%d, body=%s", e.StatusCode, e.Body)
+}
+
+// SetResourceRetry 设置下载消息资源（图片/文件）遇到 5xx 或超时时的重试次数和等待策略
+// retries 为 0 时保持默认值，负数不重试；b 为 nil 时保持默认等待策略
+func (c *Client) SetResourceRetry(retries int, b *backoff.Backoff) {
+	switch {
+	case retries < 0:
+		c.resourceRetries = 0
+	case retries > 0:
+		c.resourceRetries = retries
+	}
+	if b != nil {
+		c.resourceBackoff = b
+	}
+}
+
+// withResourceRetry 执行下载，临时错误按退避策略重试，4xx 等永久错误直接返回
+func (c *Client) withResourceRetry(ctx context.Context, download func() ([]byte, error)) ([]byte, error) {
+	for attempt := 1; ; attempt++ {
+		data, err := download()
+		if err == nil {
+			return data, nil
+		}
+		if attempt > c.resourceRetries || ctx.Err() != nil || !isTransientResourceError(err) {
+			if attempt > 1 {
+				return nil, fmt.Errorf("after %d attempts: %w", attempt, err)
+			}
+			return nil, err
+		}
+		if sleepErr := c.resourceBackoff.Sleep(ctx, attempt); sleepErr != nil {
+			return nil, err
+		}
+	}
+}
+
+// isTransientResourceError 是否是重试可能成功的错误：5xx、网络超时、响应体读到一半中断
+func isTransientResourceError(err error) bool {
+	var statusErr *ResourceStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= http.StatusInternalServerError
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,102 @@
+package lark
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"team-assistant/internal/backoff"
+)
+
+// flakyResourceServer 前 failures 次下载返回 status，之后返回图片数据
+type flakyResourceServer struct {
+	status   int
+	failures int
+	attempts int
+}
+
+func (s *flakyResourceServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(r.URL.Path, "/tenant_access_token/internal") {
+		fmt.Fprint(w, `{"code":0,"tenant_access_token":"t-test","expire":7200}`)
+		return
+	}
+	s.attempts++
+	if s.attempts <= s.failures {
+		http.Error(w, "upstream error", s.status)
+		return
+	}
+	fmt.Fprint(w, "image-bytes")
+}
+
+func newFlakyResourceClient(t *testing.T, s *flakyResourceServer, retries int) *Client {
+	t.Helper()
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+	c := NewClient(server.URL, "app", "secret")
+	c.SetResourceRetry(retries, backoff.New(time.Millisecond, time.Millisecond))
+	return c
+}
+
+func TestDownloadMessageResourceRetry(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		failures     int
+		retries      int
+		wantErr      bool
+		wantAttempts int
+	}{
+		{"一次成功", http.StatusOK, 0, 3, false, 1},
+		{"503 后重试成功", http.StatusServiceUnavailable, 2, 3, false, 3},
+		{"502 超过重试次数", http.StatusBadGateway, 5, 2, true, 3},
+		{"404 不重试", http.StatusNotFound, 1, 3, true, 1},
+		{"403 不重试", http.StatusForbidden, 1, 3, true, 1},
+		{"关闭重试", http.StatusInternalServerError, 1, -1, true, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &flakyResourceServer{status: tt.status, failures: tt.failures}
+			c := newFlakyResourceClient(t, s, tt.retries)
+
+			data, err := c.DownloadImage(context.Background(), "om_1", "img_1")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DownloadImage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && string(data) != "image-bytes" {
+				t.Errorf("DownloadImage() data = %q", data)
+			}
+			if s.attempts != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", s.attempts, tt.wantAttempts)
+			}
+			if tt.wantErr {
+				var statusErr *ResourceStatusError
+				if !errors.As(err, &statusErr) || statusErr.StatusCode != tt.status {
+					t.Errorf("Expected ResourceStatusError with status %d, got %v", tt.status, err)
+				}
+			}
+		})
+	}
+}
+
+func TestDownloadMessageResourceStopsOnCancel(t *testing.T) {
+	s := &flakyResourceServer{status: http.StatusServiceUnavailable, failures: 10}
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+	c := NewClient(server.URL, "app", "secret")
+	c.SetResourceRetry(5, backoff.New(time.Hour, time.Hour))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := c.DownloadImage(ctx, "om_1", "img_1"); err == nil {
+		t.Fatal("Expected error")
+	}
+	if s.attempts != 1 {
+		t.Errorf("attempts = %d, want 1 (should stop waiting when context is done)", s.attempts)
+	}
+}
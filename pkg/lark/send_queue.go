@@ -0,0 +1,117 @@
+package lark
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	defaultSendPerRecipientQPS = 5           // 飞书限制向同一用户/群发消息每秒 5 条
+	sendQueueIdleTimeout       = time.Minute // 超过该时间没有发送的接收方从队列中移除
+	sendQueuePruneSize         = 1000        // 接收方数量超过该值时清理空闲的接收方
+)
+
+// sendQueue 按接收方排队发送消息：同一群/用户的消息依次发出，间隔不小于 interval
+// 不同接收方互不影响，总速率仍受 Client 的全局令牌桶限制
+type sendQueue struct {
+	interval time.Duration
+
+	mu    sync.Mutex
+	slots map[string]*sendSlot
+}
+
+// sendSlot 单个接收方的发送队列
+type sendSlot struct {
+	mu   sync.Mutex // 同一接收方的发送者在此排队
+	next time.Time  // 下一条消息最早的发送时间
+}
+
+// newSendQueue 创建每个接收方每秒最多 qps 条的发送队列，qps<=0 返回 nil（不限制）
+func newSendQueue(qps float64) *sendQueue {
+	if qps <= 0 {
+		return nil
+	}
+	return &sendQueue{
+		interval: time.Duration(float64(time.Second) / qps),
+		slots:    make(map[string]*sendSlot),
+	}
+}
+
+// SetSendRate 设置向同一群/用户发送消息的每秒上限，0 使用默认值 5，负数不限制
+func (c *Client) SetSendRate(perRecipientQPS float64) {
+	switch {
+	case perRecipientQPS < 0:
+		c.sends = nil
+	case perRecipientQPS == 0:
+		c.sends = newSendQueue(defaultSendPerRecipientQPS)
+	default:
+		c.sends = newSendQueue(perRecipientQPS)
+	}
+}
+
+// replyChatKey context 中被回复消息所在会话 ID 的 key
+type replyChatKey struct{}
+
+// WithReplyChat 在 ctx 中记录被回复消息所在的群/私聊会话 ID（来自消息事件的 chat_id）
+// ReplyMessage 按该 ID 排队，连续回复同一群里的不同消息时也受每秒上限约束
+func WithReplyChat(ctx context.Context, chatID string) context.Context {
+	if chatID == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, replyChatKey{}, chatID)
+}
+
+// replyRecipient 回复消息的排队 key：优先使用 ctx 中的会话 ID，没有时退回到消息 ID
+func replyRecipient(ctx context.Context, messageID string) string {
+	if chatID, ok := ctx.Value(replyChatKey{}).(string); ok {
+		return chatID
+	}
+	return messageID
+}
+
+// wait 排队等待向 recipient 发送的时机，ctx 取消时返回错误；q 为 nil 时不等待
+func (q *sendQueue) wait(ctx context.Context, recipient string) error {
+	if q == nil {
+		return nil
+	}
+	slot := q.slot(recipient)
+
+	slot.mu.Lock()
+	defer slot.mu.Unlock()
+	if delay := time.Until(slot.next); delay > 0 {
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+	slot.next = time.Now().Add(q.interval)
+	return nil
+}
+
+// slot 获取接收方的队列，接收方过多时顺带清理空闲的队列
+func (q *sendQueue) slot(recipient string) *sendSlot {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if s, ok := q.slots[recipient]; ok {
+		return s
+	}
+	if len(q.slots) >= sendQueuePruneSize {
+		cutoff := time.Now().Add(-sendQueueIdleTimeout)
+		for key, s := range q.slots {
+			if s.mu.TryLock() {
+				if s.next.Before(cutoff) {
+					delete(q.slots, key)
+				}
+				s.mu.Unlock()
+			}
+		}
+	}
+	s := &sendSlot{}
+	q.slots[recipient] = s
+	return s
+}
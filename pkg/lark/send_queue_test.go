@@ -0,0 +1,143 @@
+package lark
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// sendRecordingServer 记录每个接收方收到发送请求的时间
+type sendRecordingServer struct {
+	mu    sync.Mutex
+	times map[string][]time.Time
+}
+
+func (s *sendRecordingServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(r.URL.Path, "/tenant_access_token/internal") {
+		fmt.Fprint(w, `{"code":0,"tenant_access_token":"t-test","expire":7200}`)
+		return
+	}
+	recipient := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/open-apis/im/v1/messages/"), "/reply")
+	s.mu.Lock()
+	s.times[recipient] = append(s.times[recipient], time.Now())
+	s.mu.Unlock()
+	fmt.Fprint(w, `{"code":0}`)
+}
+
+func TestSendRateBoundedPerRecipient(t *testing.T) {
+	s := &sendRecordingServer{times: make(map[string][]time.Time)}
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	c := NewClient(server.URL, "app", "secret")
+	c.SetRateLimit(-1)
+	c.SetSendRate(20) // 每个接收方间隔 50ms
+
+	const perRecipient = 5
+	var wg sync.WaitGroup
+	start := time.Now()
+	for _, messageID := range []string{"om_a", "om_b"} {
+		for i := 0; i < perRecipient; i++ {
+			wg.Add(1)
+			go func(messageID string, i int) {
+				defer wg.Done()
+				if err := c.ReplyMessage(context.Background(), messageID, "text", fmt.Sprintf("第%d段", i)); err != nil {
+					t.Errorf("ReplyMessage failed: %v", err)
+				}
+			}(messageID, i)
+		}
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	interval := 50 * time.Millisecond
+	for messageID, times := range s.times {
+		if len(times) != perRecipient {
+			t.Errorf("%s received %d replies, want %d", messageID, len(times), perRecipient)
+		}
+		for i := 1; i < len(times); i++ {
+			// 请求在排队放行后才发出，允许少量调度误差
+			if gap := times[i].Sub(times[i-1]); gap < interval-5*time.Millisecond {
+				t.Errorf("%s replies %d and %d only %v apart, want >= %v", messageID, i-1, i, gap, interval)
+			}
+		}
+	}
+	// 两个接收方并行排队，总耗时约为单个接收方的耗时
+	if max := time.Duration(2*perRecipient) * interval; elapsed >= max {
+		t.Errorf("Recipients should not block each other, took %v", elapsed)
+	}
+}
+
+func TestSendQueueCanceled(t *testing.T) {
+	q := newSendQueue(1)
+	if err := q.wait(context.Background(), "oc_1"); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := q.wait(ctx, "oc_1"); err == nil {
+		t.Error("Expected context error while waiting in the queue")
+	}
+}
+
+func TestSetSendRate(t *testing.T) {
+	c := NewClient("http://localhost", "app", "secret")
+	if c.sends == nil || c.sends.interval != time.Second/defaultSendPerRecipientQPS {
+		t.Fatalf("Expected default send queue, got %+v", c.sends)
+	}
+	c.SetSendRate(-1)
+	if c.sends != nil {
+		t.Error("Expected negative rate to disable the send queue")
+	}
+	// nil 队列不等待
+	if err := c.sends.wait(context.Background(), "oc_1"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestReplyQueuedPerChat(t *testing.T) {
+	s := &sendRecordingServer{times: make(map[string][]time.Time)}
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	c := NewClient(server.URL, "app", "secret")
+	c.SetRateLimit(-1)
+	c.SetSendRate(20) // 每个会话间隔 50ms
+
+	// 同一群里回复不同消息，共用该群的队列
+	ctx := WithReplyChat(context.Background(), "oc_1")
+	const replies = 4
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := 0; i < replies; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := c.ReplyMessage(ctx, fmt.Sprintf("om_%d", i), "text", "收到"); err != nil {
+				t.Errorf("ReplyMessage failed: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if min := time.Duration(replies-1)*50*time.Millisecond - 5*time.Millisecond; time.Since(start) < min {
+		t.Errorf("Replies in the same chat should be throttled, took %v, want >= %v", time.Since(start), min)
+	}
+}
+
+func TestReplyRecipient(t *testing.T) {
+	if got := replyRecipient(context.Background(), "om_1"); got != "om_1" {
+		t.Errorf("replyRecipient() = %q, want om_1 without chat", got)
+	}
+	if got := replyRecipient(WithReplyChat(context.Background(), "oc_1"), "om_1"); got != "oc_1" {
+		t.Errorf("replyRecipient() = %q, want oc_1", got)
+	}
+	if got := replyRecipient(WithReplyChat(context.Background(), ""), "om_1"); got != "om_1" {
+		t.Errorf("replyRecipient() = %q, want om_1 with empty chat", got)
+	}
+}
@@ -0,0 +1,182 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 任务状态
+const (
+	StateRunning = "running" // 运行中
+	StateStopped = "stopped" // 已正常退出
+	StateFailed  = "failed"  // panic 退出，或在停止前意外退出
+)
+
+// TaskStatus 后台任务状态
+type TaskStatus struct {
+	Name      string     `json:"name"`
+	State     string     `json:"state"`
+	StartedAt time.Time  `json:"started_at"`
+	StoppedAt *time.Time `json:"stopped_at,omitempty"`
+	Error     string     `json:"error,omitempty"`
+}
+
+// Manager 后台协程的统一生命周期管理：登记运行中的任务、统一停止并等待退出
+// 所有方法都允许 nil 接收者（此时 Go 退化为普通 goroutine），便于测试和未初始化场景
+type Manager struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu       sync.Mutex
+	tasks    map[string]*TaskStatus
+	stopping bool
+}
+
+// NewManager 创建生命周期管理器
+func NewManager() *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{ctx: ctx, cancel: cancel, tasks: make(map[string]*TaskStatus)}
+}
+
+// Go 启动一个受管理的后台任务，fn 应在 ctx 取消后尽快返回
+// 同名任务仍在运行时不会重复启动
+func (m *Manager) Go(name string, fn func(ctx context.Context)) {
+	if m == nil {
+		go fn(context.Background())
+		return
+	}
+
+	m.mu.Lock()
+	if m.stopping {
+		m.mu.Unlock()
+		log.Printf("[Lifecycle] Manager is stopping, task %s not started", name)
+		return
+	}
+	if t, ok := m.tasks[name]; ok && t.State == StateRunning {
+		m.mu.Unlock()
+		log.Printf("[Lifecycle] Task %s is already running", name)
+		return
+	}
+	status := &TaskStatus{Name: name, State: StateRunning, StartedAt: time.Now()}
+	m.tasks[name] = status
+	m.wg.Add(1)
+	m.mu.Unlock()
+
+	go func() {
+		defer m.wg.Done()
+		var panicErr string
+		defer func() {
+			if r := recover(); r != nil {
+				panicErr = fmt.Sprintf("panic: %v", r)
+				log.Printf("[Lifecycle] Task %s panic recovered: %v", name, r)
+			}
+			m.finish(status, panicErr)
+		}()
+		fn(m.ctx)
+	}()
+}
+
+// GoService 托管 Start/Stop 风格的组件：启动时调用 start（阻塞或非阻塞均可），停止时调用 stop 并等待 start 返回
+func (m *Manager) GoService(name string, start, stop func()) {
+	m.Go(name, func(ctx context.Context) {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("[Lifecycle] Service %s start panic recovered: %v", name, r)
+				}
+			}()
+			start()
+		}()
+		<-ctx.Done()
+		stop()
+		<-done
+	})
+}
+
+// finish 记录任务退出
+func (m *Manager) finish(status *TaskStatus, errMsg string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	status.StoppedAt = &now
+	switch {
+	case errMsg != "":
+		status.State, status.Error = StateFailed, errMsg
+	case !m.stopping:
+		// 未收到停止信号就退出，说明任务异常结束
+		status.State, status.Error = StateFailed, "exited before stop"
+	default:
+		status.State = StateStopped
+	}
+}
+
+// Stop 通知所有任务停止，最多等待 timeout；超时返回仍在运行的任务名
+func (m *Manager) Stop(timeout time.Duration) error {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	m.stopping = true
+	m.mu.Unlock()
+	m.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-time.After(timeout):
+		var running []string
+		for _, t := range m.Status() {
+			if t.State == StateRunning {
+				running = append(running, t.Name)
+			}
+		}
+		return fmt.Errorf("timed out after %v waiting for tasks: %s", timeout, strings.Join(running, ", "))
+	}
+}
+
+// Status 返回所有任务的状态快照（按名称排序）
+func (m *Manager) Status() []TaskStatus {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	list := make([]TaskStatus, 0, len(m.tasks))
+	for _, t := range m.tasks {
+		list = append(list, *t)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Ready 所有任务都在运行且未进入停止流程时返回 true
+func (m *Manager) Ready() bool {
+	if m == nil {
+		return true
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.stopping {
+		return false
+	}
+	for _, t := range m.tasks {
+		if t.State != StateRunning {
+			return false
+		}
+	}
+	return true
+}
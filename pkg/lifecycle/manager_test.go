@@ -0,0 +1,169 @@
+package lifecycle
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waitFor 等待条件成立（最多 1 秒）
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met within 1s")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestManagerStartStop(t *testing.T) {
+	m := NewManager()
+	var stopped atomic.Int32
+	for _, name := range []string{"b_task", "a_task"} {
+		m.Go(name, func(ctx context.Context) {
+			<-ctx.Done()
+			stopped.Add(1)
+		})
+	}
+
+	status := m.Status()
+	if len(status) != 2 || status[0].Name != "a_task" || status[0].State != StateRunning {
+		t.Fatalf("Unexpected status: %+v", status)
+	}
+	if !m.Ready() {
+		t.Error("Manager should be ready while all tasks are running")
+	}
+
+	if err := m.Stop(time.Second); err != nil {
+		t.Fatalf("Stop() error: %v", err)
+	}
+	if stopped.Load() != 2 {
+		t.Errorf("Expected 2 tasks stopped, got %d", stopped.Load())
+	}
+	for _, s := range m.Status() {
+		if s.State != StateStopped || s.StoppedAt == nil {
+			t.Errorf("Task %s should be stopped: %+v", s.Name, s)
+		}
+	}
+	if m.Ready() {
+		t.Error("Manager should not be ready after stop")
+	}
+
+	// 停止后不再启动新任务
+	m.Go("late", func(ctx context.Context) {})
+	if len(m.Status()) != 2 {
+		t.Error("Tasks should not start after stop")
+	}
+}
+
+func TestManagerStopTimeout(t *testing.T) {
+	m := NewManager()
+	release := make(chan struct{})
+	defer close(release)
+	m.Go("stuck", func(ctx context.Context) { <-release })
+	m.Go("fine", func(ctx context.Context) { <-ctx.Done() })
+
+	err := m.Stop(50 * time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "stuck") || strings.Contains(err.Error(), "fine") {
+		t.Errorf("Expected timeout error naming only the stuck task, got %v", err)
+	}
+}
+
+func TestManagerTaskFailures(t *testing.T) {
+	tests := []struct {
+		name    string
+		fn      func(ctx context.Context)
+		wantErr string
+	}{
+		{"panic", func(ctx context.Context) { panic("boom") }, "panic: boom"},
+		{"提前退出", func(ctx context.Context) {}, "exited before stop"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewManager()
+			m.Go("task", tt.fn)
+			waitFor(t, func() bool { return m.Status()[0].State != StateRunning })
+
+			s := m.Status()[0]
+			if s.State != StateFailed || s.Error != tt.wantErr {
+				t.Errorf("Unexpected status: %+v", s)
+			}
+			if m.Ready() {
+				t.Error("Manager should not be ready with a failed task")
+			}
+
+			// 失败的任务可以重新启动
+			m.Go("task", func(ctx context.Context) { <-ctx.Done() })
+			if s := m.Status()[0]; s.State != StateRunning {
+				t.Errorf("Task should restart, got %+v", s)
+			}
+			m.Stop(time.Second)
+		})
+	}
+}
+
+func TestManagerSkipsDuplicateTask(t *testing.T) {
+	m := NewManager()
+	var runs atomic.Int32
+	fn := func(ctx context.Context) {
+		runs.Add(1)
+		<-ctx.Done()
+	}
+	m.Go("task", fn)
+	m.Go("task", fn)
+	m.Stop(time.Second)
+
+	if runs.Load() != 1 {
+		t.Errorf("Expected task to run once, got %d", runs.Load())
+	}
+}
+
+func TestManagerGoService(t *testing.T) {
+	t.Run("阻塞式 Start", func(t *testing.T) {
+		m := NewManager()
+		stopChan := make(chan struct{})
+		m.GoService("blocking", func() { <-stopChan }, func() { close(stopChan) })
+
+		if err := m.Stop(time.Second); err != nil {
+			t.Fatalf("Stop() error: %v", err)
+		}
+		if s := m.Status()[0]; s.State != StateStopped {
+			t.Errorf("Unexpected status: %+v", s)
+		}
+	})
+
+	t.Run("非阻塞 Start", func(t *testing.T) {
+		m := NewManager()
+		var started, stopped atomic.Bool
+		m.GoService("async", func() { started.Store(true) }, func() { stopped.Store(true) })
+
+		waitFor(t, started.Load)
+		time.Sleep(10 * time.Millisecond)
+		if s := m.Status()[0]; s.State != StateRunning {
+			t.Errorf("Service should stay running after Start returns: %+v", s)
+		}
+		if err := m.Stop(time.Second); err != nil || !stopped.Load() {
+			t.Errorf("Stop() should call service stop, err = %v", err)
+		}
+	})
+}
+
+func TestNilManager(t *testing.T) {
+	var m *Manager
+	done := make(chan struct{})
+	m.Go("task", func(ctx context.Context) { close(done) })
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Nil manager should still run the task")
+	}
+	if !m.Ready() || m.Status() != nil || m.Stop(time.Second) != nil {
+		t.Error("Nil manager should be ready with no status")
+	}
+}
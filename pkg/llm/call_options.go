@@ -0,0 +1,45 @@
+package llm
+
+// 调用场景（用于按场景配置 max_tokens 和 stop 序列）
+const (
+	CallParse        = "parse"         // 意图解析
+	CallGenerate     = "generate"      // 通用回答生成（问答、周总结、群历程等）
+	CallSummarize    = "summarize"     // 消息总结
+	CallTranslate    = "translate"     // 翻译
+	CallAnalyzeImage = "analyze_image" // 同步时的图片内容分析
+	CallVision       = "vision"        // 图片问答
+)
+
+// defaultCallMaxTokens 各调用场景默认的 max_tokens
+var defaultCallMaxTokens = map[string]int{
+	CallParse:        500,
+	CallGenerate:     1024,
+	CallSummarize:    1500,
+	CallTranslate:    1024,
+	CallAnalyzeImage: 1024,
+	CallVision:       2048,
+}
+
+// SetCallMaxTokens 按调用场景覆盖 max_tokens，未配置或 <=0 的场景使用默认值
+func (c *Client) SetCallMaxTokens(maxTokens map[string]int) {
+	c.callMaxTokens = maxTokens
+}
+
+// SetCallStop 按调用场景设置 stop 序列，未配置的场景不设置
+func (c *Client) SetCallStop(stop map[string][]string) {
+	c.callStop = stop
+}
+
+// newCallRequest 构建指定调用场景的请求，填入该场景的 max_tokens 和 stop 序列
+func (c *Client) newCallRequest(call, model string, messages []ChatMessage) ChatRequest {
+	maxTokens := defaultCallMaxTokens[call]
+	if n := c.callMaxTokens[call]; n > 0 {
+		maxTokens = n
+	}
+	return ChatRequest{
+		Model:     model,
+		Messages:  messages,
+		MaxTokens: maxTokens,
+		Stop:      c.callStop[call],
+	}
+}
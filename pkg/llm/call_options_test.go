@@ -0,0 +1,110 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// newRecordingServer 记录收到的请求体，返回固定回复
+func newRecordingServer(t *testing.T, reply string) (*httptest.Server, *[]map[string]interface{}) {
+	t.Helper()
+	var requests []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		requests = append(requests, body)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{
+				{"message": map[string]string{"role": "assistant", "content": reply}},
+			},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestCallOptionsReachRequest(t *testing.T) {
+	tests := []struct {
+		name      string
+		maxTokens map[string]int
+		stop      map[string][]string
+		wantMax   float64
+		wantStop  interface{}
+	}{
+		{"默认值", nil, nil, 1500, nil},
+		{"覆盖 max_tokens", map[string]int{CallSummarize: 3000}, nil, 3000, nil},
+		{"其他场景的配置不影响", map[string]int{CallParse: 200}, map[string][]string{CallParse: {"\n\n"}}, 1500, nil},
+		{"无效值使用默认", map[string]int{CallSummarize: 0}, nil, 1500, nil},
+		{"stop 序列", nil, map[string][]string{CallSummarize: {"</summary>"}}, 1500, []interface{}{"</summary>"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, requests := newRecordingServer(t, "总结内容")
+			client := NewClient("test-key", server.URL, "test-model")
+			client.SetCallMaxTokens(tt.maxTokens)
+			client.SetCallStop(tt.stop)
+
+			if _, err := client.SummarizeMessages(context.Background(), []string{"消息"}); err != nil {
+				t.Fatalf("SummarizeMessages() error: %v", err)
+			}
+			if len(*requests) != 1 {
+				t.Fatalf("Expected 1 request, got %d", len(*requests))
+			}
+			req := (*requests)[0]
+			if req["max_tokens"] != tt.wantMax {
+				t.Errorf("max_tokens = %v, want %v", req["max_tokens"], tt.wantMax)
+			}
+			if !reflect.DeepEqual(req["stop"], tt.wantStop) {
+				t.Errorf("stop = %v, want %v", req["stop"], tt.wantStop)
+			}
+		})
+	}
+}
+
+func TestCallOptionsPerCallSite(t *testing.T) {
+	server, requests := newRecordingServer(t, `{"intent": "qa"}`)
+	client := NewClient("test-key", server.URL, "test-model")
+	client.SetCallMaxTokens(map[string]int{CallParse: 256, CallGenerate: 4096})
+
+	ctx := context.Background()
+	client.ParseUserQuery(ctx, "登录超时修好了吗")
+	client.GenerateResponse(ctx, "问题", nil)
+	client.Translate(ctx, "hello", "中文")
+
+	want := []float64{256, 4096, 1024}
+	if len(*requests) != len(want) {
+		t.Fatalf("Expected %d requests, got %d", len(want), len(*requests))
+	}
+	for i, req := range *requests {
+		if req["max_tokens"] != want[i] {
+			t.Errorf("request %d max_tokens = %v, want %v", i, req["max_tokens"], want[i])
+		}
+	}
+}
+
+func TestAnthropicStopSequences(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"content": []map[string]string{{"type": "text", "text": "总结内容"}},
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", server.URL, "claude-test")
+	client.provider = "anthropic"
+	client.SetCallMaxTokens(map[string]int{CallSummarize: 800})
+	client.SetCallStop(map[string][]string{CallSummarize: {"</summary>"}})
+	if _, err := client.SummarizeMessages(context.Background(), []string{"消息"}); err != nil {
+		t.Fatalf("SummarizeMessages() error: %v", err)
+	}
+	if body["max_tokens"] != float64(800) || !reflect.DeepEqual(body["stop_sequences"], []interface{}{"</summary>"}) {
+		t.Errorf("Anthropic request = max_tokens %v, stop_sequences %v", body["max_tokens"], body["stop_sequences"])
+	}
+}
@@ -13,6 +13,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"team-assistant/internal/backoff"
 )
 
 // Intent 用户意图类型
@@ -27,6 +29,8 @@ const (
 	IntentQA               Intent = "qa"                // 基于聊天记录的问答
 	IntentSiteQuery        Intent = "site_query"        // 查询站点信息
 	IntentGroupTimeline    Intent = "group_timeline"    // 群历程查询
+	IntentMemberGroups     Intent = "member_groups"     // 查询成员活跃的群
+	IntentTopRanking       Intent = "top_ranking"       // 排行类问题（最多的前 N 个站点/人/关键词）
 	IntentHelp             Intent = "help"              // 帮助
 	IntentUnknown          Intent = "unknown"           // 未知意图
 )
@@ -42,6 +46,10 @@ const (
 	TimeRangeThisMonth   TimeRange = "this_month"
 	TimeRangeLastMonth   TimeRange = "last_month"
 	TimeRangeRecentMonth TimeRange = "recent_month" // 最近30天（不是上个自然月）
+	TimeRangeThisQuarter TimeRange = "this_quarter"
+	TimeRangeLastQuarter TimeRange = "last_quarter"
+	TimeRangeThisYear    TimeRange = "this_year"
+	TimeRangeLastYear    TimeRange = "last_year"
 	TimeRangeCustom      TimeRange = "custom"
 	TimeRangeAll         TimeRange = "all" // 全部历史（用于群历程查询）
 )
@@ -56,6 +64,7 @@ type ParsedQuery struct {
 	Repository  string            `json:"repository"`   // 仓库名
 	SitePrefix  string            `json:"site_prefix"`  // 站点前缀（如：l08, b01）
 	SiteID      string            `json:"site_id"`      // 站点ID（纯数字，如：3040）
+	SiteField   string            `json:"site_field"`   // 只询问站点的某个字段时的字段名（如：后台地址），为空返回完整信息
 	Params      map[string]string `json:"params"`       // 其他参数
 	RawQuery    string            `json:"raw_query"`    // 原始查询
 }
@@ -110,6 +119,15 @@ type Client struct {
 	modelHealth    map[string]*ModelHealth // 模型健康状态 (key: endpoint+model)
 	healthMu       sync.RWMutex           // 保护 modelHealth 的锁
 	currentModel   int                    // 当前使用的模型索引 (-1 表示主模型)
+
+	retryBackoff *backoff.Backoff // 重试等待策略
+
+	callObserver func(err error) // 每次调用结束后回调（用于运行时统计）
+
+	callMaxTokens map[string]int      // 按调用场景覆盖 max_tokens（见 call_options.go）
+	callStop      map[string][]string // 按调用场景设置 stop 序列
+
+	offline bool // 离线模式：不请求外部接口，返回固定内容（见 NewOfflineClient）
 }
 
 // NewClient 创建LLM客户端
@@ -172,9 +190,22 @@ func NewClientWithProxy(apiKey, endpoint, model string, proxyConfig *ProxyConfig
 		fallbackModels: nil,
 		modelHealth:    make(map[string]*ModelHealth),
 		currentModel:   -1, // -1 表示使用主模型
+		retryBackoff:   backoff.New(1*time.Second, 10*time.Second),
 	}
 }
 
+// SetRetryBackoff 设置重试等待策略
+func (c *Client) SetRetryBackoff(b *backoff.Backoff) {
+	if b != nil {
+		c.retryBackoff = b
+	}
+}
+
+// SetCallObserver 设置调用结果回调，每次对话请求（含备选模型切换）结束后调用一次
+func (c *Client) SetCallObserver(fn func(err error)) {
+	c.callObserver = fn
+}
+
 // SetFallbackModels 设置备选模型列表
 func (c *Client) SetFallbackModels(models []ModelConfig) {
 	c.fallbackModels = models
@@ -299,6 +330,8 @@ type ChatRequest struct {
 	Model     string        `json:"model"`
 	Messages  []ChatMessage `json:"messages"`
 	MaxTokens int           `json:"max_tokens,omitempty"`
+	Stop      []string      `json:"stop,omitempty"`
+	Stream    bool          `json:"stream,omitempty"` // 流式输出（见 ChatStream）
 }
 
 // ChatMessage 聊天消息
@@ -329,6 +362,14 @@ type ChatResponse struct {
 	Error *struct {
 		Message string `json:"message"`
 	} `json:"error,omitempty"`
+	Usage *Usage `json:"usage,omitempty"` // token 用量（接口未返回时为 nil）
+}
+
+// Usage token 用量
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
 }
 
 // AnthropicRequest Anthropic API 请求格式
@@ -337,6 +378,9 @@ type AnthropicRequest struct {
 	MaxTokens int                `json:"max_tokens"`
 	System    string             `json:"system,omitempty"`
 	Messages  []AnthropicMessage `json:"messages"`
+	// stop 序列（对应 OpenAI 格式的 stop）
+	StopSequences []string `json:"stop_sequences,omitempty"`
+	Stream        bool     `json:"stream,omitempty"`
 }
 
 // AnthropicMessage Anthropic 消息格式
@@ -369,6 +413,10 @@ type AnthropicResponse struct {
 		Text string `json:"text"`
 	} `json:"content"`
 	StopReason string `json:"stop_reason"`
+	Usage      *struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage,omitempty"`
 	Error      *struct {
 		Type    string `json:"type"`
 		Message string `json:"message"`
@@ -377,6 +425,10 @@ type AnthropicResponse struct {
 
 // ParseUserQuery 解析用户查询意图
 func (c *Client) ParseUserQuery(ctx context.Context, query string) (*ParsedQuery, error) {
+	if c.offline {
+		return offlineParsedQuery(query), nil
+	}
+
 	systemPrompt := `你是一个智能助手，负责解析用户的查询意图。
 请分析用户的问题，返回JSON格式的解析结果。
 
@@ -391,6 +443,7 @@ func (c *Client) ParseUserQuery(ctx context.Context, query string) (*ParsedQuery
      例如："3040的站点前缀是？"、"3040是哪个站点？"、"站点ID 2888对应什么前缀？"
   站点前缀通常是1-3个字母加2-3个数字
   站点ID是纯数字（通常3-4位）
+  如果只询问站点的某个字段（如"by4的后台地址"、"l08的状态"），把字段名填到 site_field
 - qa: 基于聊天记录的问答和主题分析（如：虚拟币需求的产品经理是谁？谁负责支付功能？这个bug是什么原因？支付错误有哪些？）
   这是最常用的意图，当用户询问任何需要从聊天记录中查找答案的问题时使用
   **重要**：如果用户问某个特定主题（如"支付错误"、"登录问题"、"Bug情况"）的总结/汇总/分析，应该使用 qa 而不是 summarize
@@ -400,7 +453,12 @@ func (c *Client) ParseUserQuery(ctx context.Context, query string) (*ParsedQuery
 - search_message: 搜索聊天消息，用于查找特定内容（如：张三说过什么关于登录的？搜索关于支付的消息）
 - summarize: 总结**整个群聊**的讨论内容（如：总结一下今天群里的讨论、总结印尼群的消息、今天大家聊了什么）
   **注意**：summarize 只用于总结群聊的整体内容，不带特定主题。如果用户要总结特定主题（如支付、错误、某功能），应该用 qa
+  "重新总结昨天"、"刷新一下上周的总结" 也是 summarize（"重新"/"刷新" 只表示不使用缓存）
 - query_requirement: 查询需求进度（如：用户登录功能做到哪了？）
+- member_groups: 查询某个成员在哪些群活跃、在哪些群发过言（如：小王在哪些群活跃？张三都在哪些群说过话？），需要把成员名填到 target_users
+- top_ranking: 求"最多的前 N 个"的排行问题，需要统计计数而不是从聊天记录里找答案（如：告警最多的三个站点？发言最多的五个人？支付、登录、提现哪两个讨论最多？）
+  在 params 中填写 top_n（个数，写成字符串，如 "3"，没说个数时不填）和 dimension：site（站点，按告警数排行）、sender（成员，按发言数排行）、keyword（关键词，按提到次数排行，候选关键词填到 keywords）
+  注意：只问"谁负责"、"有哪些"而不是按数量排名的问题仍然用 qa；提交代码最多的人用 query_workload
 - help: 帮助信息
 - unknown: 完全无法理解的问题
 
@@ -411,7 +469,8 @@ func (c *Client) ParseUserQuery(ctx context.Context, query string) (*ParsedQuery
 4. 如果用户问"谁"、"什么"、"为什么"、"怎么"等问题（但不涉及站点或历程），优先使用 qa 意图
 5. 如果问题涉及项目、需求、功能、Bug、错误、支付、人员等具体主题，优先使用 qa 意图
 6. 只有明确要求"搜索"或"查找消息"时才用 search_message
-7. **关键**：summarize 只用于"总结群聊整体内容"，不带特定主题。例如：
+7. 如果用户要"最多的三个"、"前五名"、"排名前几"这类按数量排名的结果，使用 top_ranking 意图，不要用 qa
+8. **关键**：summarize 只用于"总结群聊整体内容"，不带特定主题。例如：
    - "总结今天群里的讨论" -> summarize（没有特定主题）
    - "今天的支付错误总结" -> qa（有特定主题：支付错误）
    - "登录问题汇总" -> qa（有特定主题：登录问题）
@@ -424,6 +483,10 @@ func (c *Client) ParseUserQuery(ctx context.Context, query string) (*ParsedQuery
 - this_month: 本月（**仅当**用户明确说"本月"、"这个月"时使用）
 - last_month: 上个月（**仅当**用户明确说"上个月"、"上月"时使用，指上一个自然月）
 - recent_month: 最近一个月/最近30天（当用户说"最近一个月"、"近一个月"、"过去一个月"时使用）
+- this_quarter: 本季度（当用户说"这个季度"、"本季度"时使用）
+- last_quarter: 上季度（当用户说"上个季度"、"上季度"时使用，指上一个自然季度）
+- this_year: 今年（当用户说"今年"、"本年度"时使用）
+- last_year: 去年（当用户说"去年"、"上一年"时使用）
 - custom: 只有用户明确指定了具体日期范围时才使用
 - all: 全部历史（当用户询问"历程"、"从一开始"、"所有历史"、"发展过程"时使用）
 - 空字符串: **默认值**，当用户没有明确指定时间范围时使用，系统会搜索全部历史
@@ -447,17 +510,14 @@ func (c *Client) ParseUserQuery(ctx context.Context, query string) (*ParsedQuery
   "repository": "仓库名（如有）",
   "site_prefix": "站点前缀（如：l08、b01、by4，没有则为空字符串）",
   "site_id": "站点ID（纯数字，如：3040、2888，没有则为空字符串）",
+  "site_field": "只询问站点某个字段时的字段名（如：后台地址、状态），询问整体信息时为空字符串",
   "params": {}
 }`
 
-	req := ChatRequest{
-		Model: c.model,
-		Messages: []ChatMessage{
-			{Role: "system", Content: systemPrompt},
-			{Role: "user", Content: query},
-		},
-		MaxTokens: 500,
-	}
+	req := c.newCallRequest(CallParse, c.model, []ChatMessage{
+		{Role: "system", Content: systemPrompt},
+		{Role: "user", Content: query},
+	})
 
 	resp, err := c.chat(ctx, req)
 	if err != nil {
@@ -468,11 +528,7 @@ func (c *Client) ParseUserQuery(ctx context.Context, query string) (*ParsedQuery
 		return nil, fmt.Errorf("no response from LLM")
 	}
 
-	content := resp.Choices[0].Message.Content
-	content = strings.TrimPrefix(content, "```json")
-	content = strings.TrimPrefix(content, "```")
-	content = strings.TrimSuffix(content, "```")
-	content = strings.TrimSpace(content)
+	content := ExtractJSONObject(resp.Choices[0].Message.Content)
 
 	var parsed ParsedQuery
 	if err := json.Unmarshal([]byte(content), &parsed); err != nil {
@@ -489,6 +545,12 @@ func (c *Client) ParseUserQuery(ctx context.Context, query string) (*ParsedQuery
 
 // GenerateResponse 生成回复
 func (c *Client) GenerateResponse(ctx context.Context, prompt string, data interface{}) (string, error) {
+	content, _, err := c.GenerateResponseWithUsage(ctx, prompt, data)
+	return content, err
+}
+
+// GenerateResponseWithUsage 生成回复，同时返回 token 用量（接口未返回用量时为 nil）
+func (c *Client) GenerateResponseWithUsage(ctx context.Context, prompt string, data interface{}) (string, *Usage, error) {
 	dataJSON, _ := json.MarshalIndent(data, "", "  ")
 
 	systemPrompt := `你是一个专业的团队助手，负责分析告警群消息。
@@ -513,25 +575,21 @@ func (c *Client) GenerateResponse(ctx context.Context, prompt string, data inter
 
 请直接回答：`, prompt, string(dataJSON))
 
-	req := ChatRequest{
-		Model: c.model,
-		Messages: []ChatMessage{
-			{Role: "system", Content: systemPrompt},
-			{Role: "user", Content: userPrompt},
-		},
-		MaxTokens: 1024,
-	}
+	req := c.newCallRequest(CallGenerate, c.model, []ChatMessage{
+		{Role: "system", Content: systemPrompt},
+		{Role: "user", Content: userPrompt},
+	})
 
 	resp, err := c.chat(ctx, req)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
 	if len(resp.Choices) == 0 {
-		return "", fmt.Errorf("no response from LLM")
+		return "", resp.Usage, fmt.Errorf("no response from LLM")
 	}
 
-	return resp.Choices[0].Message.Content, nil
+	return resp.Choices[0].Message.Content, resp.Usage, nil
 }
 
 // SummarizeMessages 总结消息
@@ -541,8 +599,8 @@ func (c *Client) SummarizeMessages(ctx context.Context, messages []string) (stri
 	}
 
 	content := strings.Join(messages, "\n")
-	if len(content) > 8000 {
-		content = content[:8000] + "...(内容已截断)"
+	if truncated := TruncateToTokens(content, summarizeMaxTokens); len(truncated) < len(content) {
+		content = truncated + "...(内容已截断)"
 	}
 
 	systemPrompt := `你是专业的群消息总结助手。请对群聊消息进行精准总结。
@@ -561,21 +619,21 @@ func (c *Client) SummarizeMessages(ctx context.Context, messages []string) (stri
 📋 **待办事项**（如有）
 • [事项内容] - 👤负责人
 
+🔁 **转发内容**（如有）
+• [转发内容概述] - 转发人
+
 【总结要求】
 1. 引用具体站点名、人名、时间
 2. 问题类需标注处理状态（已解决/待处理/进行中）
 3. 按重要性排序，最重要的放前面
 4. 省略闲聊、表情等无实质内容
-5. 每个分类如无内容则省略整个分类`
+5. 每个分类如无内容则省略整个分类
+6. 带有【转发】标记的消息是从其他群或会话转发来的内容，只归入「转发内容」，不要当作本群讨论、决策或待办`
 
-	req := ChatRequest{
-		Model: c.model,
-		Messages: []ChatMessage{
-			{Role: "system", Content: systemPrompt},
-			{Role: "user", Content: fmt.Sprintf("请总结以下群聊消息：\n\n%s", content)},
-		},
-		MaxTokens: 1500,
-	}
+	req := c.newCallRequest(CallSummarize, c.model, []ChatMessage{
+		{Role: "system", Content: systemPrompt},
+		{Role: "user", Content: fmt.Sprintf("请总结以下群聊消息：\n\n%s", content)},
+	})
 
 	resp, err := c.chat(ctx, req)
 	if err != nil {
@@ -589,11 +647,48 @@ func (c *Client) SummarizeMessages(ctx context.Context, messages []string) (stri
 	return resp.Choices[0].Message.Content, nil
 }
 
-// AnalyzeImage 分析图片内容（使用 Vision 模型）
+// Translate 将文本翻译为目标语言（如 "中文"），只返回译文
+func (c *Client) Translate(ctx context.Context, text, targetLang string) (string, error) {
+	if strings.TrimSpace(text) == "" {
+		return "", nil
+	}
+
+	systemPrompt := fmt.Sprintf(`你是专业的翻译助手。请把用户发送的文本翻译成%s。
+要求：
+1. 只输出译文，不要解释、不要加引号
+2. 保留站点代号、数字、链接、人名和专有名词原样
+3. 如果原文已经是%s，原样输出`, targetLang, targetLang)
+
+	req := c.newCallRequest(CallTranslate, c.model, []ChatMessage{
+		{Role: "system", Content: systemPrompt},
+		{Role: "user", Content: text},
+	})
+
+	resp, err := c.chat(ctx, req)
+	if err != nil {
+		return "", err
+	}
+
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("no response from LLM")
+	}
+
+	return strings.TrimSpace(resp.Choices[0].Message.Content), nil
+}
+
+// AnalyzeImage 分析图片内容（使用 Vision 模型），mimeType 为空时根据图片内容检测（见 DetectImageMIME）
 func (c *Client) AnalyzeImage(ctx context.Context, imageBase64 string, mimeType string) (string, error) {
 	// 使用支持 Vision 的模型
 	visionModel := "meta-llama/llama-4-scout-17b-16e-instruct"
 
+	// 未指定类型时根据图片内容检测
+	if mimeType == "" {
+		mimeType = defaultImageMIME
+		if imageData, err := base64.StdEncoding.DecodeString(imageBase64); err == nil {
+			mimeType = DetectImageMIME(imageData)
+		}
+	}
+
 	// 构建 data URI
 	dataURI := fmt.Sprintf("data:%s;base64,%s", mimeType, imageBase64)
 
@@ -622,13 +717,9 @@ func (c *Client) AnalyzeImage(ctx context.Context, imageBase64 string, mimeType
 		},
 	}
 
-	req := ChatRequest{
-		Model: visionModel,
-		Messages: []ChatMessage{
-			{Role: "user", Content: contentParts},
-		},
-		MaxTokens: 1024,
-	}
+	req := c.newCallRequest(CallAnalyzeImage, visionModel, []ChatMessage{
+		{Role: "user", Content: contentParts},
+	})
 
 	resp, err := c.chat(ctx, req)
 	if err != nil {
@@ -649,7 +740,10 @@ func (c *Client) ChatWithImage(ctx context.Context, query string, imageData []by
 	}
 
 	// 检测图片类型
-	mimeType := detectImageMimeType(imageData)
+	mimeType, err := DetectImageMimeType(imageData)
+	if err != nil {
+		return "", err
+	}
 
 	// 转换为 base64
 	imageBase64 := base64.StdEncoding.EncodeToString(imageData)
@@ -670,13 +764,9 @@ func (c *Client) ChatWithImage(ctx context.Context, query string, imageData []by
 		},
 	}
 
-	req := ChatRequest{
-		Model: c.visionConfig.Model,
-		Messages: []ChatMessage{
-			{Role: "user", Content: contentParts},
-		},
-		MaxTokens: 2048,
-	}
+	req := c.newCallRequest(CallVision, c.visionConfig.Model, []ChatMessage{
+		{Role: "user", Content: contentParts},
+	})
 
 	resp, err := c.chat(ctx, req)
 	if err != nil {
@@ -697,7 +787,10 @@ func (c *Client) ChatWithImageAndHistory(ctx context.Context, query string, imag
 	}
 
 	// 检测图片类型
-	mimeType := detectImageMimeType(imageData)
+	mimeType, err := DetectImageMimeType(imageData)
+	if err != nil {
+		return "", err
+	}
 
 	// 转换为 base64
 	imageBase64 := base64.StdEncoding.EncodeToString(imageData)
@@ -748,11 +841,7 @@ func (c *Client) ChatWithImageAndHistory(ctx context.Context, query string, imag
 		messages = append(messages, ChatMessage{Role: "user", Content: contentParts})
 	}
 
-	req := ChatRequest{
-		Model:     c.visionConfig.Model,
-		Messages:  messages,
-		MaxTokens: 2048,
-	}
+	req := c.newCallRequest(CallVision, c.visionConfig.Model, messages)
 
 	resp, err := c.chat(ctx, req)
 	if err != nil {
@@ -766,37 +855,43 @@ func (c *Client) ChatWithImageAndHistory(ctx context.Context, query string, imag
 	return resp.Choices[0].Message.Content, nil
 }
 
-// detectImageMimeType 检测图片的 MIME 类型
-func detectImageMimeType(imageData []byte) string {
-	mimeType := "image/jpeg"
-	if len(imageData) > 8 {
-		// PNG 文件头: 89 50 4E 47
-		if imageData[0] == 0x89 && imageData[1] == 0x50 && imageData[2] == 0x4E && imageData[3] == 0x47 {
-			mimeType = "image/png"
-		}
-		// GIF 文件头: 47 49 46
-		if imageData[0] == 0x47 && imageData[1] == 0x49 && imageData[2] == 0x46 {
-			mimeType = "image/gif"
-		}
-		// WebP 文件头: 52 49 46 46 ... 57 45 42 50
-		if imageData[0] == 0x52 && imageData[1] == 0x49 && imageData[2] == 0x46 && imageData[3] == 0x46 {
-			mimeType = "image/webp"
-		}
+func (c *Client) chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	if c.offline {
+		return offlineChatResponse(), nil
 	}
-	return mimeType
-}
 
-func (c *Client) chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
 	// 根据 provider 选择不同的 API 格式
+	var resp *ChatResponse
+	var err error
 	if c.provider == "anthropic" {
-		return c.chatAnthropic(ctx, req)
+		resp, err = c.chatAnthropic(ctx, req)
+	} else {
+		resp, err = c.chatOpenAI(ctx, req)
+	}
+	if c.callObserver != nil {
+		c.callObserver(err)
 	}
-	return c.chatOpenAI(ctx, req)
+	return resp, err
 }
 
 // chatOpenAI 使用 OpenAI 兼容格式 (OpenAI, Groq, NVIDIA NIM 等)
 // 包含智能切换机制：主模型失败时自动切换到备选模型
 func (c *Client) chatOpenAI(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	var resp *ChatResponse
+	err := c.tryModels(ctx, req, func(r ChatRequest, apiKey, endpoint string) error {
+		var err error
+		resp, err = c.doOpenAIRequestWithConfig(ctx, r, apiKey, endpoint)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// tryModels 依次尝试主模型和备选模型，每个模型可重试错误最多尝试 2 次
+// do 返回 streamInterruptedError 时（流式输出已开始）不再重试或切换模型，直接返回
+func (c *Client) tryModels(ctx context.Context, req ChatRequest, do func(r ChatRequest, apiKey, endpoint string) error) error {
 	// 构建模型列表：主模型 + 备选模型
 	type modelInfo struct {
 		provider string
@@ -834,7 +929,7 @@ func (c *Client) chatOpenAI(ctx context.Context, req ChatRequest) (*ChatResponse
 		for attempt := 1; attempt <= 2; attempt++ {
 			log.Printf("[LLM] Trying model %s (attempt %d/2)", m.model, attempt)
 
-			resp, err := c.doOpenAIRequestWithConfig(ctx, reqCopy, m.apiKey, m.endpoint)
+			err := do(reqCopy, m.apiKey, m.endpoint)
 			if err == nil {
 				// 成功
 				c.markModelSuccess(m.endpoint, m.model)
@@ -842,7 +937,13 @@ func (c *Client) chatOpenAI(ctx context.Context, req ChatRequest) (*ChatResponse
 					log.Printf("[LLM] Successfully used fallback model: %s", m.model)
 				}
 				c.currentModel = idx - 1 // 更新当前模型索引
-				return resp, nil
+				return nil
+			}
+
+			// 已经输出了部分内容，重试会让调用方收到重复内容
+			if isStreamInterrupted(err) {
+				log.Printf("[LLM] Model %s stream interrupted: %v", m.model, err)
+				return err
 			}
 
 			lastErr = err
@@ -854,10 +955,12 @@ func (c *Client) chatOpenAI(ctx context.Context, req ChatRequest) (*ChatResponse
 				break
 			}
 
-			// 第一次失败后短暂等待再重试
+			// 第一次失败后按退避策略等待再重试
 			if attempt == 1 {
 				log.Printf("[LLM] Model %s failed (attempt 1): %v, retrying...", m.model, err)
-				time.Sleep(1 * time.Second)
+				if sleepErr := c.retryBackoff.Sleep(ctx, attempt); sleepErr != nil {
+					return fmt.Errorf("retry canceled: %w", sleepErr)
+				}
 			} else {
 				// 第二次也失败，标记模型失败，尝试下一个
 				log.Printf("[LLM] Model %s failed after 2 attempts: %v", m.model, err)
@@ -866,7 +969,7 @@ func (c *Client) chatOpenAI(ctx context.Context, req ChatRequest) (*ChatResponse
 		}
 	}
 
-	return nil, fmt.Errorf("all LLM models failed, last error: %w", lastErr)
+	return fmt.Errorf("all LLM models failed, last error: %w", lastErr)
 }
 
 // isRetryableError 判断是否是可重试的错误
@@ -941,6 +1044,10 @@ func (c *Client) doOpenAIRequestWithConfig(ctx context.Context, req ChatRequest,
 
 	var chatResp ChatResponse
 	if err := json.Unmarshal(respBody, &chatResp); err != nil {
+		// 端点地址错误时常返回 HTML 错误页，优先报告状态码
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("LLM error: HTTP %d - %s", resp.StatusCode, string(respBody))
+		}
 		return nil, err
 	}
 
@@ -973,12 +1080,14 @@ func stripThinkingTags(content string) string {
 	return content
 }
 
-// chatAnthropic 使用 Anthropic API 格式
-func (c *Client) chatAnthropic(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+// buildAnthropicRequest 将 OpenAI 格式的请求转换为 Anthropic 格式
+func (c *Client) buildAnthropicRequest(req ChatRequest) AnthropicRequest {
 	// 转换请求格式
 	anthropicReq := AnthropicRequest{
-		Model:     c.model,
-		MaxTokens: req.MaxTokens,
+		Model:         c.model,
+		MaxTokens:     req.MaxTokens,
+		StopSequences: req.Stop,
+		Stream:        req.Stream,
 	}
 	if anthropicReq.MaxTokens == 0 {
 		anthropicReq.MaxTokens = 1024
@@ -1045,6 +1154,13 @@ func (c *Client) chatAnthropic(ctx context.Context, req ChatRequest) (*ChatRespo
 		}
 	}
 
+	return anthropicReq
+}
+
+// chatAnthropic 使用 Anthropic API 格式
+func (c *Client) chatAnthropic(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	anthropicReq := c.buildAnthropicRequest(req)
+
 	body, err := json.Marshal(anthropicReq)
 	if err != nil {
 		return nil, err
@@ -1091,7 +1207,7 @@ func (c *Client) chatAnthropic(ctx context.Context, req ChatRequest) (*ChatRespo
 		}
 	}
 
-	return &ChatResponse{
+	chatResp := &ChatResponse{
 		Choices: []struct {
 			Message struct {
 				Content string `json:"content"`
@@ -1101,5 +1217,9 @@ func (c *Client) chatAnthropic(ctx context.Context, req ChatRequest) (*ChatRespo
 				Content string `json:"content"`
 			}{Content: content}},
 		},
-	}, nil
+	}
+	if u := anthropicResp.Usage; u != nil {
+		chatResp.Usage = &Usage{PromptTokens: u.InputTokens, CompletionTokens: u.OutputTokens, TotalTokens: u.InputTokens + u.OutputTokens}
+	}
+	return chatResp, nil
 }
@@ -0,0 +1,61 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGenerateResponseWithUsage(t *testing.T) {
+	tests := []struct {
+		name      string
+		provider  string
+		status    int
+		body      string
+		wantReply string
+		wantUsage *Usage
+		wantErr   string
+	}{
+		{"OpenAI 返回用量", "openai", http.StatusOK,
+			`{"choices":[{"message":{"content":"OK"}}],"usage":{"prompt_tokens":120,"completion_tokens":2,"total_tokens":122}}`,
+			"OK", &Usage{PromptTokens: 120, CompletionTokens: 2, TotalTokens: 122}, ""},
+		{"未返回用量", "openai", http.StatusOK, `{"choices":[{"message":{"content":"OK"}}]}`, "OK", nil, ""},
+		{"Anthropic 用量转换", "anthropic", http.StatusOK,
+			`{"content":[{"type":"text","text":"OK"}],"usage":{"input_tokens":100,"output_tokens":3}}`,
+			"OK", &Usage{PromptTokens: 100, CompletionTokens: 3, TotalTokens: 103}, ""},
+		{"认证失败", "openai", http.StatusUnauthorized, `{"error":{"message":"Invalid API key"}}`, "", nil, "HTTP 401"},
+		{"端点返回 HTML 时报告状态码", "openai", http.StatusNotFound, `<html>404 page not found</html>`, "", nil, "HTTP 404"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			client := NewClient("test-key", server.URL, "test-model")
+			client.provider = tt.provider
+
+			reply, usage, err := client.GenerateResponseWithUsage(context.Background(), "测试", nil)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GenerateResponseWithUsage() error: %v", err)
+			}
+			if reply != tt.wantReply {
+				t.Errorf("reply = %q, want %q", reply, tt.wantReply)
+			}
+			if (usage == nil) != (tt.wantUsage == nil) || (usage != nil && *usage != *tt.wantUsage) {
+				t.Errorf("usage = %+v, want %+v", usage, tt.wantUsage)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package llm
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// defaultImageMIME 无法识别图片格式时使用的类型（飞书图片绝大多数是 JPEG）
+const defaultImageMIME = "image/jpeg"
+
+// visionImageTypes 视觉模型接口接受的图片类型
+var visionImageTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// heifBrands ISO BMFF ftyp 中表示 HEIC/HEIF 的 brand（iPhone 拍照默认格式）
+var heifBrands = map[string]string{
+	"heic": "image/heic", "heix": "image/heic", "heim": "image/heic", "heis": "image/heic",
+	"hevc": "image/heic-sequence", "hevx": "image/heic-sequence",
+	"mif1": "image/heif", "msf1": "image/heif-sequence",
+	"avif": "image/avif", "avis": "image/avif",
+}
+
+// UnsupportedImageError 图片格式视觉模型不支持（如 HEIC），不发送错误标注的 data URI
+type UnsupportedImageError struct {
+	MimeType string
+}
+
+func (e *UnsupportedImageError) Error() string {
+	return fmt.Sprintf("unsupported image type: %s", e.MimeType)
+}
+
+// DetectImageMIME 根据文件头检测图片 MIME 类型：PNG、JPEG、GIF、WebP、BMP、TIFF 和 HEIC/HEIF/AVIF
+// 文件头无法识别时返回 image/jpeg；能识别的非图片内容（如视频、HTML 错误页）返回其实际类型
+// 不判断视觉模型是否支持，发送前使用 DetectImageMimeType
+func DetectImageMIME(imageData []byte) string {
+	if mimeType := detectHEIF(imageData); mimeType != "" {
+		return mimeType
+	}
+	if mimeType := detectTIFF(imageData); mimeType != "" {
+		return mimeType
+	}
+	// http.DetectContentType 识别 PNG、JPEG、GIF、WebP、BMP、ICO，无法识别时返回 octet-stream 或 text/plain
+	mimeType := http.DetectContentType(imageData)
+	if mimeType == "application/octet-stream" || strings.HasPrefix(mimeType, "text/plain") {
+		return defaultImageMIME
+	}
+	return mimeType
+}
+
+// DetectImageMimeType 检测图片 MIME 类型（见 DetectImageMIME），视觉模型不支持的类型返回 UnsupportedImageError
+func DetectImageMimeType(imageData []byte) (string, error) {
+	mimeType := DetectImageMIME(imageData)
+	if !visionImageTypes[mimeType] {
+		return mimeType, &UnsupportedImageError{MimeType: mimeType}
+	}
+	return mimeType, nil
+}
+
+// detectTIFF 识别 TIFF 文件头（小端 "II*\x00" 或大端 "MM\x00*"），不是 TIFF 时返回空字符串
+func detectTIFF(data []byte) string {
+	if bytes.HasPrefix(data, []byte("II*\x00")) || bytes.HasPrefix(data, []byte("MM\x00*")) {
+		return "image/tiff"
+	}
+	return ""
+}
+
+// detectHEIF 识别 ISO BMFF 容器的图片：4 字节 box 长度 + "ftyp" + major brand + 版本 + compatible brands
+// 不是 HEIF 系列时返回空字符串
+func detectHEIF(data []byte) string {
+	if len(data) < 12 || !bytes.Equal(data[4:8], []byte("ftyp")) {
+		return ""
+	}
+	if mimeType, ok := heifBrands[string(data[8:12])]; ok {
+		return mimeType
+	}
+
+	// major brand 未知时检查 compatible brands（不超出 ftyp box）
+	boxLen := int(data[0])<<24 | int(data[1])<<16 | int(data[2])<<8 | int(data[3])
+	if boxLen > len(data) {
+		boxLen = len(data)
+	}
+	for i := 16; i+4 <= boxLen; i += 4 {
+		if mimeType, ok := heifBrands[string(data[i:i+4])]; ok {
+			return mimeType
+		}
+	}
+	return ""
+}
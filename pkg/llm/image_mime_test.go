@@ -0,0 +1,123 @@
+package llm
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// ftypBox 构造 ISO BMFF 文件头：box 长度 + "ftyp" + major brand + 版本 + compatible brands
+func ftypBox(major string, compatible ...string) []byte {
+	data := []byte{0, 0, 0, byte(16 + 4*len(compatible))}
+	data = append(data, "ftyp"+major+"\x00\x00\x00\x00"...)
+	for _, brand := range compatible {
+		data = append(data, brand...)
+	}
+	return append(data, make([]byte, 16)...)
+}
+
+func TestDetectImageMIME(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"PNG", []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), "image/png"},
+		{"JPEG", []byte("\xff\xd8\xff\xe1\x00\x18Exif\x00\x00"), "image/jpeg"},
+		{"GIF87a", []byte("GIF87a\x01\x00\x01\x00"), "image/gif"},
+		{"GIF89a", []byte("GIF89a\x01\x00\x01\x00"), "image/gif"},
+		{"WebP", []byte("RIFF\x24\x00\x00\x00WEBPVP8L"), "image/webp"},
+		{"BMP", []byte("BM\x36\x00\x0c\x00\x00\x00\x00\x00\x36\x00\x00\x00"), "image/bmp"},
+		{"TIFF 小端", []byte("II*\x00\x08\x00\x00\x00"), "image/tiff"},
+		{"TIFF 大端", []byte("MM\x00*\x00\x00\x00\x08"), "image/tiff"},
+		{"HEIC", ftypBox("heic", "mif1"), "image/heic"},
+		{"截断的数据", []byte("\x00\x01"), "image/jpeg"},
+		{"空数据", nil, "image/jpeg"},
+		{"HTML 错误页不当作图片", []byte("<html><body>error</body></html>"), "text/html; charset=utf-8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectImageMIME(tt.data); got != tt.want {
+				t.Errorf("DetectImageMIME() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectImageMimeType(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        []byte
+		want        string
+		unsupported bool
+	}{
+		{"PNG", []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), "image/png", false},
+		{"JPEG", []byte("\xff\xd8\xff\xe0\x00\x10JFIF\x00"), "image/jpeg", false},
+		{"GIF", []byte("GIF89a\x01\x00\x01\x00"), "image/gif", false},
+		{"WebP", []byte("RIFF\x24\x00\x00\x00WEBPVP8 "), "image/webp", false},
+		{"WAV 不是 WebP", []byte("RIFF\x24\x00\x00\x00WAVEfmt "), "audio/wave", true},
+		{"iPhone HEIC", ftypBox("heic", "mif1", "heic"), "image/heic", true},
+		{"HEIF 兼容 brand", ftypBox("isom", "mif1"), "image/heif", true},
+		{"AVIF", ftypBox("avif", "mif1"), "image/avif", true},
+		{"MP4 视频", ftypBox("isom", "iso2", "mp41"), "video/mp4", true},
+		{"BMP", []byte("BM\x36\x00\x00\x00\x00\x00\x00\x00"), "image/bmp", true},
+		{"TIFF", []byte("II*\x00\x08\x00\x00\x00"), "image/tiff", true},
+		{"无法识别时按 JPEG 处理", []byte("hello world"), "image/jpeg", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DetectImageMimeType(tt.data)
+			if got != tt.want {
+				t.Errorf("DetectImageMimeType() = %q, want %q", got, tt.want)
+			}
+			var unsupported *UnsupportedImageError
+			if errors.As(err, &unsupported) != tt.unsupported {
+				t.Errorf("DetectImageMimeType() error = %v, want unsupported=%v", err, tt.unsupported)
+			}
+			if tt.unsupported && unsupported.MimeType != tt.want {
+				t.Errorf("UnsupportedImageError.MimeType = %q, want %q", unsupported.MimeType, tt.want)
+			}
+		})
+	}
+}
+
+func TestChatWithImageRejectsUnsupported(t *testing.T) {
+	c := &Client{visionConfig: &VisionConfig{Model: "vision"}}
+	_, err := c.ChatWithImage(t.Context(), "这是什么", ftypBox("heic"))
+	var unsupported *UnsupportedImageError
+	if !errors.As(err, &unsupported) {
+		t.Fatalf("Expected UnsupportedImageError before calling the API, got %v", err)
+	}
+}
+
+func TestAnalyzeImageDetectsMimeType(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	tests := []struct {
+		name     string
+		mimeType string
+		want     string
+	}{
+		{"未指定时检测", "", "data:image/png;base64,"},
+		{"使用调用方指定的类型", "image/gif", "data:image/gif;base64,"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, requests := newRecordingServer(t, "后台截图")
+			client := NewClient("test-key", server.URL, "test-model")
+			if _, err := client.AnalyzeImage(t.Context(), base64.StdEncoding.EncodeToString(png), tt.mimeType); err != nil {
+				t.Fatalf("AnalyzeImage() error: %v", err)
+			}
+			if len(*requests) != 1 {
+				t.Fatalf("Expected 1 request, got %d", len(*requests))
+			}
+			if body := fmt.Sprint((*requests)[0]["messages"]); !strings.Contains(body, tt.want) {
+				t.Errorf("Expected data URI %q in request, got %s", tt.want, body)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package llm
+
+import "strings"
+
+// ExtractJSONObject 从模型输出中提取第一个完整的 {...} 块
+// 模型经常在 JSON 前后附加说明文字（如 "好的，这是结果："）或包裹 ```json 代码块，
+// 这里按括号配对扫描（忽略字符串内的括号和转义），找不到完整块时返回去除首尾空白的原文
+func ExtractJSONObject(content string) string {
+	start := strings.IndexByte(content, '{')
+	for start != -1 {
+		if end := matchBrace(content, start); end != -1 {
+			return content[start : end+1]
+		}
+		// 当前 { 没有配对（如说明文字中的孤立括号），尝试下一个
+		next := strings.IndexByte(content[start+1:], '{')
+		if next == -1 {
+			break
+		}
+		start += next + 1
+	}
+	return strings.TrimSpace(content)
+}
+
+// matchBrace 返回与 content[start] 处 { 配对的 } 下标，未配对返回 -1
+func matchBrace(content string, start int) int {
+	depth := 0
+	inString := false
+	escaped := false
+	for i := start; i < len(content); i++ {
+		c := content[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
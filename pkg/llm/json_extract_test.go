@@ -0,0 +1,101 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"team-assistant/internal/backoff"
+)
+
+func TestExtractJSONObject(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"纯 JSON", `{"intent": "summarize"}`, `{"intent": "summarize"}`},
+		{"代码块包裹", "```json\n{\"intent\": \"summarize\"}\n```", `{"intent": "summarize"}`},
+		{"前置说明", `好的，这是结果：{"intent": "summarize"}`, `{"intent": "summarize"}`},
+		{"前后说明", "好的，这是结果：\n```json\n{\"intent\": \"qa\"}\n```\n希望对你有帮助！", `{"intent": "qa"}`},
+		{"嵌套对象", `结果：{"a": {"b": 1}, "c": [1, 2]} 以上`, `{"a": {"b": 1}, "c": [1, 2]}`},
+		{"字符串中的括号", `{"summary": "修复 } 和 { 的问题", "x": "\"}"}`, `{"summary": "修复 } 和 { 的问题", "x": "\"}"}`},
+		{"多个对象取第一个", `{"a": 1} {"b": 2}`, `{"a": 1}`},
+		{"孤立括号后的对象", `注意 { 这里 "未闭合` + "\n" + `{"a": 1}`, `{"a": 1}`},
+		{"没有 JSON", "  抱歉，我无法理解  ", "抱歉，我无法理解"},
+		{"未闭合", `{"intent": "qa"`, `{"intent": "qa"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExtractJSONObject(tt.content); got != tt.want {
+				t.Errorf("ExtractJSONObject(%q) = %q, want %q", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseUserQueryWithProse(t *testing.T) {
+	reply := "好的，这是结果：\n```json\n{\"intent\": \"summarize\", \"time_range\": \"today\"}\n```\n如有问题请告诉我。"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{
+				{"message": map[string]string{"role": "assistant", "content": reply}},
+			},
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", server.URL, "test-model")
+	parsed, err := client.ParseUserQuery(context.Background(), "总结一下今天的讨论")
+	if err != nil {
+		t.Fatalf("ParseUserQuery failed: %v", err)
+	}
+	if parsed.Intent != IntentSummarize {
+		t.Errorf("Expected intent %q, got %q", IntentSummarize, parsed.Intent)
+	}
+	if parsed.TimeRange != TimeRangeToday {
+		t.Errorf("Expected time range %q, got %q", TimeRangeToday, parsed.TimeRange)
+	}
+	if parsed.RawQuery != "总结一下今天的讨论" {
+		t.Errorf("Expected raw query kept, got %q", parsed.RawQuery)
+	}
+}
+
+func TestCallObserver(t *testing.T) {
+	fail := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": {"message": "bad request"}}`))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{
+				{"message": map[string]string{"role": "assistant", "content": "总结内容"}},
+			},
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", server.URL, "test-model")
+	client.SetRetryBackoff(backoff.New(time.Millisecond, time.Millisecond))
+	var results []error
+	client.SetCallObserver(func(err error) { results = append(results, err) })
+
+	if _, err := client.SummarizeMessages(context.Background(), []string{"消息"}); err != nil {
+		t.Fatalf("SummarizeMessages failed: %v", err)
+	}
+	fail = true
+	client.SummarizeMessages(context.Background(), []string{"消息"})
+
+	if len(results) != 2 {
+		t.Fatalf("Expected one observation per call, got %d", len(results))
+	}
+	if results[0] != nil || results[1] == nil {
+		t.Errorf("Expected success then failure, got %v", results)
+	}
+}
@@ -0,0 +1,44 @@
+package llm
+
+import "log"
+
+// OfflineResponse 离线模式下对话请求的固定回复
+const OfflineResponse = "[离线模式] 未调用外部模型，这是固定回复。"
+
+// NewOfflineClient 创建离线客户端：不发起任何网络请求，对话返回 OfflineResponse，意图解析固定为 qa
+// 用于预发环境或 CI 在没有 API Key 时走通完整请求流程
+func NewOfflineClient(model string) *Client {
+	if model == "" {
+		model = "offline"
+	}
+	c := NewClient("", "", model)
+	c.offline = true
+	c.SetVisionConfig(model, "", "") // 图片问题同样返回固定回复
+	log.Printf("LLM client running in offline mode, model: %s", model)
+	return c
+}
+
+// IsOffline 是否为离线客户端
+func (c *Client) IsOffline() bool {
+	return c.offline
+}
+
+// offlineParsedQuery 离线模式的意图解析结果：统一按聊天记录问答处理，关键词由调用方本地提取
+func offlineParsedQuery(query string) *ParsedQuery {
+	return &ParsedQuery{
+		Intent:   IntentQA,
+		RawQuery: query,
+	}
+}
+
+// offlineChatResponse 离线模式的固定对话响应
+func offlineChatResponse() *ChatResponse {
+	resp := &ChatResponse{}
+	resp.Choices = make([]struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	}, 1)
+	resp.Choices[0].Message.Content = OfflineResponse
+	return resp
+}
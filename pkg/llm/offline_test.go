@@ -0,0 +1,58 @@
+package llm
+
+import (
+	"context"
+	"testing"
+)
+
+func TestOfflineClient(t *testing.T) {
+	c := NewOfflineClient("test-model")
+	ctx := context.Background()
+
+	if !c.IsOffline() {
+		t.Fatal("Expected offline client")
+	}
+	if !c.HasVisionSupport() {
+		t.Error("Offline client should accept image queries")
+	}
+
+	parsed, err := c.ParseUserQuery(ctx, "登录超时修好了吗")
+	if err != nil {
+		t.Fatalf("ParseUserQuery() error: %v", err)
+	}
+	if parsed.Intent != IntentQA || parsed.RawQuery != "登录超时修好了吗" {
+		t.Errorf("ParseUserQuery() = %+v, want qa intent with raw query", parsed)
+	}
+
+	calls := []struct {
+		name string
+		call func() (string, error)
+	}{
+		{"GenerateResponse", func() (string, error) { return c.GenerateResponse(ctx, "问题", nil) }},
+		{"SummarizeMessages", func() (string, error) { return c.SummarizeMessages(ctx, []string{"消息"}) }},
+		{"ChatWithImage", func() (string, error) { return c.ChatWithImage(ctx, "图里是什么", []byte("\x89PNG\r\n\x1a\n")) }},
+	}
+	for _, tt := range calls {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.call()
+			if err != nil {
+				t.Fatalf("%s() error: %v", tt.name, err)
+			}
+			if got != OfflineResponse {
+				t.Errorf("%s() = %q, want %q", tt.name, got, OfflineResponse)
+			}
+		})
+	}
+}
+
+func TestOfflineClientReportsCalls(t *testing.T) {
+	c := NewOfflineClient("test-model")
+	calls := 0
+	c.SetCallObserver(func(err error) { calls++ })
+	if _, err := c.GenerateResponse(context.Background(), "问题", nil); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 0 {
+		t.Errorf("Offline calls should not be recorded as LLM calls, got %d", calls)
+	}
+}
@@ -0,0 +1,305 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxSSELineSize 单行 SSE 数据的最大长度
+const maxSSELineSize = 1 << 20
+
+// streamInterruptedError 流式输出开始后出错（已回调部分内容），不能再重试或切换模型
+type streamInterruptedError struct {
+	err error
+}
+
+func (e *streamInterruptedError) Error() string {
+	return fmt.Sprintf("stream interrupted: %v", e.err)
+}
+
+func (e *streamInterruptedError) Unwrap() error {
+	return e.err
+}
+
+// isStreamInterrupted 是否是流式输出中途出错
+func isStreamInterrupted(err error) bool {
+	var interrupted *streamInterruptedError
+	return errors.As(err, &interrupted)
+}
+
+// ChatStream 以流式方式发送对话请求，每收到一段内容回调一次 onDelta，返回完整回答
+// 建立连接阶段（收到第一段内容前）的失败按 chat 的规则重试并切换备选模型；
+// 开始输出后出错或 onDelta 返回错误时直接返回，避免重复输出
+func (c *Client) ChatStream(ctx context.Context, req ChatRequest, onDelta func(delta string) error) (string, error) {
+	if c.offline {
+		if err := onDelta(OfflineResponse); err != nil {
+			return "", err
+		}
+		return OfflineResponse, nil
+	}
+
+	req.Stream = true
+	var content string
+	var err error
+	if c.provider == "anthropic" {
+		content, err = c.streamAnthropic(ctx, req, onDelta)
+	} else {
+		err = c.tryModels(ctx, req, func(r ChatRequest, apiKey, endpoint string) error {
+			var streamErr error
+			content, streamErr = c.streamOpenAIWithConfig(ctx, r, apiKey, endpoint, onDelta)
+			return streamErr
+		})
+	}
+	if c.callObserver != nil {
+		c.callObserver(err)
+	}
+	return content, err
+}
+
+// streamOpenAIWithConfig 执行单次 OpenAI 兼容格式的流式请求
+func (c *Client) streamOpenAIWithConfig(ctx context.Context, req ChatRequest, apiKey, endpoint string, onDelta func(string) error) (string, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+apiKey)
+	httpReq.Header.Set("Accept", "text/event-stream")
+
+	resp, err := c.doStreamRequest(httpReq)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("LLM error: HTTP %d - %s", resp.StatusCode, string(respBody))
+	}
+
+	out := newStreamOutput(onDelta)
+	err = readSSE(resp.Body, func(_, data string) (bool, error) {
+		if data == "[DONE]" {
+			return true, nil
+		}
+		var chunk struct {
+			Choices []struct {
+				Delta struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+			} `json:"choices"`
+			Error *struct {
+				Message string `json:"message"`
+			} `json:"error,omitempty"`
+		}
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return false, fmt.Errorf("parse stream chunk failed: %w", err)
+		}
+		if chunk.Error != nil {
+			return false, fmt.Errorf("LLM error: %s", chunk.Error.Message)
+		}
+		for _, choice := range chunk.Choices {
+			if err := out.write(choice.Delta.Content); err != nil {
+				return false, err
+			}
+		}
+		return false, nil
+	})
+	return out.finish(err)
+}
+
+// streamAnthropic 执行 Anthropic 格式的流式请求，内容在 content_block_delta 事件中
+func (c *Client) streamAnthropic(ctx context.Context, req ChatRequest, onDelta func(string) error) (string, error) {
+	body, err := json.Marshal(c.buildAnthropicRequest(req))
+	if err != nil {
+		return "", err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-api-key", c.apiKey)
+	httpReq.Header.Set("anthropic-version", "2023-06-01")
+	httpReq.Header.Set("Accept", "text/event-stream")
+
+	resp, err := c.doStreamRequest(httpReq)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("Anthropic error: HTTP %d - %s", resp.StatusCode, string(respBody))
+	}
+
+	out := newStreamOutput(onDelta)
+	err = readSSE(resp.Body, func(event, data string) (bool, error) {
+		var ev struct {
+			Type  string `json:"type"`
+			Delta struct {
+				Type string `json:"type"`
+				Text string `json:"text"`
+			} `json:"delta"`
+			Error *struct {
+				Type    string `json:"type"`
+				Message string `json:"message"`
+			} `json:"error,omitempty"`
+		}
+		if err := json.Unmarshal([]byte(data), &ev); err != nil {
+			return false, fmt.Errorf("parse Anthropic stream event failed: %w", err)
+		}
+		if ev.Type == "" {
+			ev.Type = event
+		}
+		switch ev.Type {
+		case "content_block_delta":
+			if ev.Delta.Type == "text_delta" {
+				return false, out.write(ev.Delta.Text)
+			}
+		case "message_stop":
+			return true, nil
+		case "error":
+			if ev.Error != nil {
+				return false, fmt.Errorf("Anthropic error: %s", ev.Error.Message)
+			}
+			return false, errors.New("Anthropic error: unknown stream error")
+		}
+		return false, nil
+	})
+	return out.finish(err)
+}
+
+// doStreamRequest 发送流式请求；流式回答可能超过 client 的整体超时，因此不使用 client.Timeout，由 ctx 控制
+func (c *Client) doStreamRequest(req *http.Request) (*http.Response, error) {
+	streamClient := *c.client
+	streamClient.Timeout = 0
+	return streamClient.Do(req)
+}
+
+// readSSE 逐条解析 SSE 事件，onEvent 返回 true 表示流结束
+func readSSE(r io.Reader, onEvent func(event, data string) (bool, error)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxSSELineSize)
+
+	var event string
+	var data []string
+	dispatch := func() (bool, error) {
+		if len(data) == 0 {
+			event = ""
+			return false, nil
+		}
+		done, err := onEvent(event, strings.Join(data, "\n"))
+		event, data = "", nil
+		return done, err
+	}
+
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		switch {
+		case line == "":
+			if done, err := dispatch(); done || err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, ":"):
+			// 注释行（心跳）
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read stream failed: %w", err)
+	}
+	// 最后一个事件后没有空行
+	_, err := dispatch()
+	return err
+}
+
+// streamOutput 汇总流式内容并回调调用方，过滤开头的 <think>...</think> 思考过程
+type streamOutput struct {
+	onDelta  func(string) error
+	content  strings.Builder
+	pending  strings.Builder // 可能属于思考过程、尚未输出的内容
+	thinking bool
+	started  bool // 已回调过内容
+	decided  bool // 已确定开头是否是思考过程
+}
+
+func newStreamOutput(onDelta func(string) error) *streamOutput {
+	return &streamOutput{onDelta: onDelta}
+}
+
+// write 处理一段增量内容
+func (o *streamOutput) write(delta string) error {
+	if delta == "" {
+		return nil
+	}
+	if !o.decided {
+		o.pending.WriteString(delta)
+		head := strings.TrimLeft(o.pending.String(), " \n\t")
+		if len(head) < len("<think>") && strings.HasPrefix("<think>", head) {
+			return nil // 还不能确定
+		}
+		o.decided = true
+		o.thinking = strings.HasPrefix(head, "<think>")
+		delta = o.pending.String()
+		o.pending.Reset()
+	}
+	if o.thinking {
+		o.pending.WriteString(delta)
+		buffered := o.pending.String()
+		idx := strings.Index(buffered, "</think>")
+		if idx == -1 {
+			return nil
+		}
+		o.thinking = false
+		o.pending.Reset()
+		delta = strings.TrimLeft(buffered[idx+len("</think>"):], " \n\t")
+		if delta == "" {
+			return nil
+		}
+	}
+	return o.emit(delta)
+}
+
+// emit 回调一段内容
+func (o *streamOutput) emit(delta string) error {
+	o.content.WriteString(delta)
+	o.started = true
+	if err := o.onDelta(delta); err != nil {
+		return &streamInterruptedError{err: err}
+	}
+	return nil
+}
+
+// finish 结束输出：输出缓存的剩余内容（思考标签没有闭合时与非流式一样保留原文）；已输出内容后出错时标记为中途中断
+func (o *streamOutput) finish(err error) (string, error) {
+	if err == nil && o.pending.Len() > 0 {
+		rest := o.pending.String()
+		o.pending.Reset()
+		err = o.emit(rest)
+	}
+	if err != nil {
+		if o.started && !isStreamInterrupted(err) {
+			err = &streamInterruptedError{err: err}
+		}
+		return o.content.String(), err
+	}
+	return o.content.String(), nil
+}
@@ -0,0 +1,207 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"team-assistant/internal/backoff"
+)
+
+// openAIChunk 构造 OpenAI 流式响应的一行 data
+func openAIChunk(content string) string {
+	b, _ := json.Marshal(map[string]interface{}{
+		"choices": []map[string]interface{}{{"delta": map[string]string{"content": content}}},
+	})
+	return "data: " + string(b) + "\n\n"
+}
+
+// collectDeltas 返回收集回调内容的 onDelta
+func collectDeltas(deltas *[]string) func(string) error {
+	return func(delta string) error {
+		*deltas = append(*deltas, delta)
+		return nil
+	}
+}
+
+func newStreamClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	client := NewClient("test-key", server.URL, "test-model")
+	client.SetRetryBackoff(backoff.New(time.Millisecond, time.Millisecond))
+	return client
+}
+
+func TestChatStreamOpenAI(t *testing.T) {
+	var gotStream interface{}
+	client := newStreamClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		gotStream = body["stream"]
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, ": keep-alive\n\n")
+		fmt.Fprint(w, openAIChunk("登录"))
+		fmt.Fprint(w, openAIChunk(""))
+		fmt.Fprint(w, openAIChunk("超时已修复"))
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	})
+
+	var deltas []string
+	content, err := client.ChatStream(context.Background(), ChatRequest{Messages: []ChatMessage{{Role: "user", Content: "问题"}}}, collectDeltas(&deltas))
+	if err != nil {
+		t.Fatalf("ChatStream failed: %v", err)
+	}
+	if gotStream != true {
+		t.Errorf("Expected stream=true in payload, got %v", gotStream)
+	}
+	if content != "登录超时已修复" {
+		t.Errorf("ChatStream() content = %q", content)
+	}
+	if strings.Join(deltas, "|") != "登录|超时已修复" {
+		t.Errorf("ChatStream() deltas = %v", deltas)
+	}
+}
+
+func TestChatStreamAnthropic(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["stream"] != true {
+			t.Errorf("Expected stream=true in Anthropic payload, got %v", body["stream"])
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: message_start\ndata: {\"type\":\"message_start\"}\n\n")
+		fmt.Fprint(w, "event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":0}\n\n")
+		fmt.Fprint(w, "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"周五\"}}\n\n")
+		fmt.Fprint(w, "event: ping\ndata: {\"type\":\"ping\"}\n\n")
+		fmt.Fprint(w, "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"上线\"}}\n\n")
+		fmt.Fprint(w, "event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n")
+	}))
+	defer server.Close()
+
+	// endpoint 包含 anthropic.com 才会识别为 Anthropic
+	client := NewClient("test-key", server.URL+"/api.anthropic.com", "claude")
+	var deltas []string
+	content, err := client.ChatStream(context.Background(), ChatRequest{Messages: []ChatMessage{{Role: "user", Content: "什么时候上线"}}}, collectDeltas(&deltas))
+	if err != nil {
+		t.Fatalf("ChatStream failed: %v", err)
+	}
+	if content != "周五上线" || strings.Join(deltas, "|") != "周五|上线" {
+		t.Errorf("ChatStream() = %q, deltas %v", content, deltas)
+	}
+}
+
+func TestChatStreamRetriesBeforeFirstDelta(t *testing.T) {
+	attempts := 0
+	client := newStreamClient(t, func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprint(w, "overloaded")
+			return
+		}
+		fmt.Fprint(w, openAIChunk("好的"))
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	})
+
+	var deltas []string
+	content, err := client.ChatStream(context.Background(), ChatRequest{}, collectDeltas(&deltas))
+	if err != nil {
+		t.Fatalf("ChatStream failed: %v", err)
+	}
+	if attempts != 2 || content != "好的" {
+		t.Errorf("Expected retry on initial 503, got %d attempts, content %q", attempts, content)
+	}
+}
+
+func TestChatStreamNoRetryMidStream(t *testing.T) {
+	attempts := 0
+	client := newStreamClient(t, func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		fmt.Fprint(w, openAIChunk("前半段"))
+		fmt.Fprint(w, "data: {\"error\": {\"message\": \"connection reset\"}}\n\n")
+	})
+
+	var deltas []string
+	content, err := client.ChatStream(context.Background(), ChatRequest{}, collectDeltas(&deltas))
+	if !isStreamInterrupted(err) {
+		t.Fatalf("Expected stream interrupted error, got %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("Expected no retry after output started, got %d attempts", attempts)
+	}
+	if content != "前半段" || len(deltas) != 1 {
+		t.Errorf("Expected partial content kept, got %q, deltas %v", content, deltas)
+	}
+}
+
+func TestChatStreamCallbackError(t *testing.T) {
+	attempts := 0
+	client := newStreamClient(t, func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		fmt.Fprint(w, openAIChunk("第一段"))
+		fmt.Fprint(w, openAIChunk("第二段"))
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	})
+
+	stop := errors.New("message recalled")
+	calls := 0
+	_, err := client.ChatStream(context.Background(), ChatRequest{}, func(string) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) {
+		t.Fatalf("Expected callback error returned, got %v", err)
+	}
+	if calls != 1 || attempts != 1 {
+		t.Errorf("Expected stop after first callback without retry, got %d calls, %d attempts", calls, attempts)
+	}
+}
+
+func TestStreamOutputStripsThinking(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []string
+		want   string
+	}{
+		{"没有思考过程", []string{"直接", "回答"}, "直接回答"},
+		{"思考过程跨多段", []string{"<thi", "nk>先想想", "</th", "ink>\n结论", "是A"}, "结论是A"},
+		{"思考标签未闭合保留原文", []string{"<think>", "没想完"}, "<think>没想完"},
+		{"短内容不是思考标签", []string{"<b"}, "<b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var deltas []string
+			out := newStreamOutput(collectDeltas(&deltas))
+			for _, chunk := range tt.chunks {
+				if err := out.write(chunk); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := out.finish(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want || strings.Join(deltas, "") != tt.want {
+				t.Errorf("streamOutput = %q (deltas %v), want %q", got, deltas, tt.want)
+			}
+		})
+	}
+}
+
+func TestChatStreamOffline(t *testing.T) {
+	client := NewOfflineClient("offline-model")
+	var deltas []string
+	content, err := client.ChatStream(context.Background(), ChatRequest{}, collectDeltas(&deltas))
+	if err != nil || content != OfflineResponse || len(deltas) != 1 {
+		t.Errorf("ChatStream() offline = %q, %v, deltas %v", content, err, deltas)
+	}
+}
@@ -0,0 +1,27 @@
+package llm
+
+import "time"
+
+// quarterStart 返回 t 所在自然季度的第一天零点
+func quarterStart(t time.Time) time.Time {
+	month := time.Month((int(t.Month())-1)/3*3 + 1)
+	return time.Date(t.Year(), month, 1, 0, 0, 0, 0, t.Location())
+}
+
+// CoarseTimeRange 计算季度/年度时间范围，"本季度"/"今年"截止到 now，"上季度"/"去年"为完整的自然区间
+// 非季度/年度的时间范围返回 ok=false
+func CoarseTimeRange(tr TimeRange, now time.Time) (start, end time.Time, ok bool) {
+	switch tr {
+	case TimeRangeThisQuarter:
+		return quarterStart(now), now, true
+	case TimeRangeLastQuarter:
+		thisQuarterStart := quarterStart(now)
+		return thisQuarterStart.AddDate(0, -3, 0), thisQuarterStart, true
+	case TimeRangeThisYear:
+		return time.Date(now.Year(), 1, 1, 0, 0, 0, 0, now.Location()), now, true
+	case TimeRangeLastYear:
+		thisYearStart := time.Date(now.Year(), 1, 1, 0, 0, 0, 0, now.Location())
+		return thisYearStart.AddDate(-1, 0, 0), thisYearStart, true
+	}
+	return time.Time{}, time.Time{}, false
+}
@@ -0,0 +1,52 @@
+package llm
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCoarseTimeRange(t *testing.T) {
+	date := func(y int, m time.Month, d, h int) time.Time {
+		return time.Date(y, m, d, h, 0, 0, 0, time.Local)
+	}
+
+	tests := []struct {
+		name      string
+		tr        TimeRange
+		now       time.Time
+		wantStart time.Time
+		wantEnd   time.Time
+	}{
+		{"本季度-季度中", TimeRangeThisQuarter, date(2025, 5, 20, 10), date(2025, 4, 1, 0), date(2025, 5, 20, 10)},
+		{"本季度-季度首日", TimeRangeThisQuarter, date(2025, 7, 1, 9), date(2025, 7, 1, 0), date(2025, 7, 1, 9)},
+		{"本季度-季度末日", TimeRangeThisQuarter, date(2025, 9, 30, 23), date(2025, 7, 1, 0), date(2025, 9, 30, 23)},
+		{"上季度-季度中", TimeRangeLastQuarter, date(2025, 5, 20, 10), date(2025, 1, 1, 0), date(2025, 4, 1, 0)},
+		{"上季度-季度首日", TimeRangeLastQuarter, date(2025, 10, 1, 8), date(2025, 7, 1, 0), date(2025, 10, 1, 0)},
+		{"上季度-跨年", TimeRangeLastQuarter, date(2025, 1, 1, 8), date(2024, 10, 1, 0), date(2025, 1, 1, 0)},
+		{"今年-元旦", TimeRangeThisYear, date(2025, 1, 1, 8), date(2025, 1, 1, 0), date(2025, 1, 1, 8)},
+		{"今年-年末", TimeRangeThisYear, date(2025, 12, 31, 23), date(2025, 1, 1, 0), date(2025, 12, 31, 23)},
+		{"去年-元旦", TimeRangeLastYear, date(2025, 1, 1, 8), date(2024, 1, 1, 0), date(2025, 1, 1, 0)},
+		{"去年-年中", TimeRangeLastYear, date(2025, 6, 15, 12), date(2024, 1, 1, 0), date(2025, 1, 1, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, ok := CoarseTimeRange(tt.tr, tt.now)
+			if !ok {
+				t.Fatalf("Expected %s to be handled", tt.tr)
+			}
+			if !start.Equal(tt.wantStart) || !end.Equal(tt.wantEnd) {
+				t.Errorf("CoarseTimeRange(%s, %v) = %v ~ %v, want %v ~ %v",
+					tt.tr, tt.now, start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestCoarseTimeRangeIgnoresOtherRanges(t *testing.T) {
+	for _, tr := range []TimeRange{TimeRangeToday, TimeRangeLastMonth, TimeRangeAll, ""} {
+		if _, _, ok := CoarseTimeRange(tr, time.Now()); ok {
+			t.Errorf("Expected %q not handled by CoarseTimeRange", tr)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package llm
+
+import "unicode"
+
+// token 估算：1 token ≈ 1.5 个汉字（CJK 字符和全角标点），其余字符约 4 个 1 token
+// 以 1/12 token 为单位计数，避免浮点误差：汉字 8 个单位，其他字符 3 个单位
+const (
+	tokenUnits     = 12
+	cjkRuneUnits   = 8
+	otherRuneUnits = 3
+)
+
+// summarizeMaxTokens SummarizeMessages 传给 LLM 的消息 token 上限
+const summarizeMaxTokens = 3000
+
+// runeTokenUnits 单个字符的估算 token 数（以 1/12 token 为单位）
+func runeTokenUnits(r rune) int {
+	if isCJKRune(r) {
+		return cjkRuneUnits
+	}
+	return otherRuneUnits
+}
+
+// isCJKRune 是否是中日韩文字或全角标点
+func isCJKRune(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) ||
+		(r >= 0x3000 && r <= 0x303F) || // CJK 标点
+		(r >= 0xFF00 && r <= 0xFFEF) // 全角字符
+}
+
+// EstimateTokens 粗略估算文本的 token 数（见 TruncateToTokens），用于控制上下文长度，不要求与模型的分词器一致
+func EstimateTokens(text string) int {
+	units := 0
+	for _, r := range text {
+		units += runeTokenUnits(r)
+	}
+	return (units + tokenUnits - 1) / tokenUnits
+}
+
+// TruncateToTokens 截取估算 token 数不超过 maxTokens 的前缀，按字符截断不会产生无效的 UTF-8
+// 1 token ≈ 1.5 个汉字或 4 个英文字符；maxTokens <= 0 时不截断
+func TruncateToTokens(text string, maxTokens int) string {
+	if maxTokens <= 0 {
+		return text
+	}
+	budget := maxTokens * tokenUnits
+	units := 0
+	for i, r := range text {
+		units += runeTokenUnits(r)
+		if units > budget {
+			return text[:i]
+		}
+	}
+	return text
+}
@@ -0,0 +1,68 @@
+package llm
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want int
+	}{
+		{"空文本", "", 0},
+		{"三个汉字为两个 token", "登录超", 2},
+		{"英文 4 个字符一个 token", "timeout!", 2},
+		{"全角标点按汉字计", "好，", 2},
+		{"中英混合", "支付回调 timeout", 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EstimateTokens(tt.text); got != tt.want {
+				t.Errorf("EstimateTokens(%q) = %d, want %d", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTruncateToTokens(t *testing.T) {
+	chinese := strings.Repeat("登录超时已修复今晚发布", 100)
+	mixed := strings.Repeat("[03-05 10:30] 张三-后端: 支付回调 timeout 已经修复 ✅\n", 50)
+
+	tests := []struct {
+		name      string
+		text      string
+		maxTokens int
+		want      string
+	}{
+		{"未超出时原样返回", "登录超时", 10, "登录超时"},
+		{"不限制", chinese, 0, chinese},
+		{"按汉字截断", "登录超时已修复", 2, "登录超"},
+		{"英文按字符截断", "payment timeout", 2, "payment "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TruncateToTokens(tt.text, tt.maxTokens); got != tt.want {
+				t.Errorf("TruncateToTokens(%q, %d) = %q, want %q", tt.text, tt.maxTokens, got, tt.want)
+			}
+		})
+	}
+
+	// 任意预算下截断结果都是有效的 UTF-8、是原文前缀且不超出预算
+	for _, text := range []string{chinese, mixed} {
+		for maxTokens := 1; maxTokens <= 300; maxTokens++ {
+			got := TruncateToTokens(text, maxTokens)
+			if !utf8.ValidString(got) {
+				t.Fatalf("TruncateToTokens(%d) produced invalid UTF-8: %q", maxTokens, got)
+			}
+			if !strings.HasPrefix(text, got) {
+				t.Fatalf("TruncateToTokens(%d) is not a prefix of the input", maxTokens)
+			}
+			if EstimateTokens(got) > maxTokens {
+				t.Fatalf("TruncateToTokens(%d) = %d tokens, over budget", maxTokens, EstimateTokens(got))
+			}
+		}
+	}
+}
@@ -131,9 +131,6 @@ func (m *MultiLimiter) Allow(key string, rate float64, burst int) bool {
 
 // 预定义的限流配置
 var (
-	// LarkAPILimiter 飞书 API 限流（每秒 10 次，突发 20）
-	LarkAPILimiter = NewLimiter(10, 20)
-
 	// LLMAPILimiter LLM API 限流（每秒 5 次，突发 10）
 	LLMAPILimiter = NewLimiter(5, 10)
 
@@ -144,11 +141,6 @@ var (
 	UserLimiters = NewMultiLimiter()
 )
 
-// AllowLarkAPI 检查飞书 API 是否允许请求
-func AllowLarkAPI() bool {
-	return LarkAPILimiter.Allow()
-}
-
 // AllowLLMAPI 检查 LLM API 是否允许请求
 func AllowLLMAPI() bool {
 	return LLMAPILimiter.Allow()
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 )
 
@@ -40,8 +41,15 @@ type SearchResult struct {
 	Payload map[string]interface{} `json:"payload"`
 }
 
-// CreateCollection 创建集合
+// CreateCollection 创建集合（幂等：集合已存在视为成功）
+// syncworker 和主服务同时启动时会并发创建同一集合，后到的一方会收到 "already exists"
 func (c *QdrantClient) CreateCollection(ctx context.Context, name string, dimension int) error {
+	_, err := c.createCollection(ctx, name, dimension)
+	return err
+}
+
+// createCollection 创建集合，existed 表示集合已被其他进程创建
+func (c *QdrantClient) createCollection(ctx context.Context, name string, dimension int) (existed bool, err error) {
 	body := map[string]interface{}{
 		"vectors": map[string]interface{}{
 			"size":     dimension,
@@ -52,23 +60,34 @@ func (c *QdrantClient) CreateCollection(ctx context.Context, name string, dimens
 	jsonBody, _ := json.Marshal(body)
 	req, err := http.NewRequestWithContext(ctx, "PUT", fmt.Sprintf("%s/collections/%s", c.endpoint, name), bytes.NewReader(jsonBody))
 	if err != nil {
-		return err
+		return false, err
 	}
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return err
+		return false, err
 	}
 	defer resp.Body.Close()
 
-	// 如果集合已存在，忽略错误
-	if resp.StatusCode == http.StatusConflict || resp.StatusCode == http.StatusOK {
-		return nil
+	if resp.StatusCode == http.StatusOK {
+		return false, nil
 	}
 
 	respBody, _ := io.ReadAll(resp.Body)
-	return fmt.Errorf("create collection failed: %s", string(respBody))
+	if isAlreadyExists(resp.StatusCode, respBody) {
+		return true, nil
+	}
+	return false, fmt.Errorf("create collection failed (status %d): %s", resp.StatusCode, string(respBody))
+}
+
+// isAlreadyExists 判断创建集合的响应是否为"集合已存在"
+// Qdrant 返回 400 {"status":{"error":"Wrong input: Collection `x` already exists!"}}，部分代理/旧版本返回 409
+func isAlreadyExists(statusCode int, body []byte) bool {
+	if statusCode == http.StatusConflict {
+		return true
+	}
+	return statusCode == http.StatusBadRequest && strings.Contains(string(body), "already exists")
 }
 
 // CollectionExists 检查集合是否存在
@@ -158,14 +177,174 @@ func (c *QdrantClient) Search(ctx context.Context, collection string, vector []f
 	return result.Result, nil
 }
 
-// Delete 删除数据点
-func (c *QdrantClient) Delete(ctx context.Context, collection string, ids []string) error {
+// GetPoints 按 ID 获取数据点（不返回向量），不存在的 ID 不出现在结果中
+func (c *QdrantClient) GetPoints(ctx context.Context, collection string, ids []string) ([]SearchResult, error) {
+	body := map[string]interface{}{
+		"ids":          ids,
+		"with_payload": true,
+		"with_vector":  false,
+	}
+
+	jsonBody, _ := json.Marshal(body)
+	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/collections/%s/points", c.endpoint, collection), bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("get points failed (status %d): %s", resp.StatusCode, string(respBody))
+	}
+
+	var result struct {
+		Result []SearchResult `json:"result"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, err
+	}
+
+	return result.Result, nil
+}
+
+// PointsExist 查询哪些 ID 的数据点已存在（不返回 payload 和向量），集合不存在时视为都不存在
+func (c *QdrantClient) PointsExist(ctx context.Context, collection string, ids []string) (map[string]bool, error) {
+	exists := make(map[string]bool, len(ids))
+	if len(ids) == 0 {
+		return exists, nil
+	}
+
+	body := map[string]interface{}{
+		"ids":          ids,
+		"with_payload": false,
+		"with_vector":  false,
+	}
+
+	jsonBody, _ := json.Marshal(body)
+	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/collections/%s/points", c.endpoint, collection), bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return exists, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("retrieve points failed (status %d): %s", resp.StatusCode, string(respBody))
+	}
+
+	var result struct {
+		Result []struct {
+			ID string `json:"id"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, err
+	}
+
+	// Qdrant 返回的 UUID 为小写带连字符格式，按请求中的 ID 回填
+	returned := make(map[string]bool, len(result.Result))
+	for _, p := range result.Result {
+		returned[strings.ToLower(p.ID)] = true
+	}
+	for _, id := range ids {
+		if returned[strings.ToLower(id)] {
+			exists[id] = true
+		}
+	}
+	return exists, nil
+}
+
+// Count 精确统计集合中满足过滤条件的数据点数，filter 为 nil 时统计全部
+func (c *QdrantClient) Count(ctx context.Context, collection string, filter map[string]interface{}) (int64, error) {
 	body := map[string]interface{}{
-		"points": ids,
+		"exact": true,
+	}
+	if filter != nil {
+		body["filter"] = filter
 	}
 
 	jsonBody, _ := json.Marshal(body)
-	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/collections/%s/points/delete", c.endpoint, collection), bytes.NewReader(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/collections/%s/points/count", c.endpoint, collection), bytes.NewReader(jsonBody))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("count failed (status %d): %s", resp.StatusCode, string(respBody))
+	}
+
+	var result struct {
+		Result struct {
+			Count int64 `json:"count"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return 0, err
+	}
+
+	return result.Result.Count, nil
+}
+
+// Delete 删除指定 ID 的数据点
+// 数据点不存在时 Qdrant 同样返回成功；集合不存在时视为没有可删除的数据，返回 nil
+func (c *QdrantClient) Delete(ctx context.Context, collection string, ids []string) error {
+	return c.deletePoints(ctx, collection, map[string]interface{}{"points": ids})
+}
+
+// DeletePoints 删除指定 ID 的数据点以及匹配 filter 的数据点（如同一条消息的所有分块），filter 为 nil 时只按 ID 删除
+func (c *QdrantClient) DeletePoints(ctx context.Context, collection string, ids []string, filter map[string]interface{}) error {
+	if len(ids) > 0 {
+		if err := c.Delete(ctx, collection, ids); err != nil {
+			return err
+		}
+	}
+	if filter != nil {
+		if err := c.deletePoints(ctx, collection, map[string]interface{}{"filter": filter}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deletePoints 按选择器（points 或 filter）删除数据点，wait=true 保证返回时已删除
+func (c *QdrantClient) deletePoints(ctx context.Context, collection string, selector map[string]interface{}) error {
+	jsonBody, _ := json.Marshal(selector)
+	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/collections/%s/points/delete?wait=true", c.endpoint, collection), bytes.NewReader(jsonBody))
 	if err != nil {
 		return err
 	}
@@ -177,11 +356,13 @@ func (c *QdrantClient) Delete(ctx context.Context, collection string, ids []stri
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
 	if resp.StatusCode != http.StatusOK {
 		respBody, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("delete failed: %s", string(respBody))
+		return fmt.Errorf("delete points failed: HTTP %d - %s", resp.StatusCode, string(respBody))
 	}
-
 	return nil
 }
 
@@ -211,6 +392,46 @@ func (c *QdrantClient) GetCollectionInfo(ctx context.Context, name string) (map[
 	return result, nil
 }
 
+// ListCollections 列出所有集合名称
+// 响应格式: {"result": {"collections": [{"name": "messages"}]}}
+func (c *QdrantClient) ListCollections(ctx context.Context) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.endpoint+"/collections", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("list collections failed: %s", string(respBody))
+	}
+
+	var result struct {
+		Result struct {
+			Collections []struct {
+				Name string `json:"name"`
+			} `json:"collections"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(result.Result.Collections))
+	for _, col := range result.Result.Collections {
+		names = append(names, col.Name)
+	}
+	return names, nil
+}
+
 // DeleteCollection 删除集合
 func (c *QdrantClient) DeleteCollection(ctx context.Context, name string) error {
 	req, err := http.NewRequestWithContext(ctx, "DELETE", fmt.Sprintf("%s/collections/%s", c.endpoint, name), nil)
@@ -233,6 +454,7 @@ func (c *QdrantClient) DeleteCollection(ctx context.Context, name string) error
 }
 
 // RecreateCollection 删除并重新创建集合（用于模型升级）
+// 删除后若其他进程抢先创建了集合，维度一致时视为成功，不一致时返回错误
 func (c *QdrantClient) RecreateCollection(ctx context.Context, name string, dimension int) error {
 	// 先删除
 	if err := c.DeleteCollection(ctx, name); err != nil {
@@ -240,9 +462,31 @@ func (c *QdrantClient) RecreateCollection(ctx context.Context, name string, dime
 	}
 
 	// 再创建
-	if err := c.CreateCollection(ctx, name, dimension); err != nil {
+	existed, err := c.createCollection(ctx, name, dimension)
+	if err != nil {
 		return fmt.Errorf("create collection: %w", err)
 	}
+	if !existed {
+		return nil
+	}
 
+	info, err := c.GetCollectionInfo(ctx, name)
+	if err != nil {
+		return fmt.Errorf("get concurrently created collection: %w", err)
+	}
+	if size := collectionVectorSize(info); size != dimension {
+		return fmt.Errorf("collection %s was concurrently recreated with dimension %d, expected %d", name, size, dimension)
+	}
 	return nil
 }
+
+// collectionVectorSize 从集合信息中读取向量维度，读取失败返回 0
+// 响应格式: {"result": {"config": {"params": {"vectors": {"size": 768, "distance": "Cosine"}}}}}
+func collectionVectorSize(info map[string]interface{}) int {
+	result, _ := info["result"].(map[string]interface{})
+	config, _ := result["config"].(map[string]interface{})
+	params, _ := config["params"].(map[string]interface{})
+	vectors, _ := params["vectors"].(map[string]interface{})
+	size, _ := vectors["size"].(float64)
+	return int(size)
+}
@@ -0,0 +1,362 @@
+package vectordb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const alreadyExistsBody = `{"status":{"error":"Wrong input: Collection ` + "`messages`" + ` already exists!"},"time":0.0001}`
+
+// fakeQdrant 模拟 Qdrant 集合接口：集合存在时再次创建返回 400 already exists
+type fakeQdrant struct {
+	mu          sync.Mutex
+	collections map[string]int // 集合名 -> 维度
+	creates     int
+	// onDelete 删除集合后调用，用于模拟其他进程抢先创建
+	onDelete func(name string)
+}
+
+func (f *fakeQdrant) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/collections/")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodPut:
+		f.creates++
+		if _, ok := f.collections[name]; ok {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(alreadyExistsBody))
+			return
+		}
+		f.collections[name] = 768
+		w.Write([]byte(`{"result":true,"status":"ok"}`))
+	case http.MethodDelete:
+		delete(f.collections, name)
+		if f.onDelete != nil {
+			f.onDelete(name)
+		}
+		w.Write([]byte(`{"result":true,"status":"ok"}`))
+	case http.MethodGet:
+		if r.URL.Path == "/collections" {
+			var items []string
+			for n := range f.collections {
+				items = append(items, fmt.Sprintf(`{"name":%q}`, n))
+			}
+			fmt.Fprintf(w, `{"result":{"collections":[%s]},"status":"ok"}`, strings.Join(items, ","))
+			return
+		}
+		size, ok := f.collections[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, `{"result":{"status":"green","config":{"params":{"vectors":{"size":%d,"distance":"Cosine"}}}}}`, size)
+	}
+}
+
+func newFakeQdrant(t *testing.T) (*QdrantClient, *fakeQdrant) {
+	t.Helper()
+	fake := &fakeQdrant{collections: make(map[string]int)}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return NewQdrantClient(server.URL), fake
+}
+
+func TestCreateCollectionIdempotent(t *testing.T) {
+	client, fake := newFakeQdrant(t)
+	ctx := context.Background()
+
+	if err := client.CreateCollection(ctx, "messages", 768); err != nil {
+		t.Fatalf("First create failed: %v", err)
+	}
+	if err := client.CreateCollection(ctx, "messages", 768); err != nil {
+		t.Errorf("Create of existing collection should succeed, got %v", err)
+	}
+	if fake.creates != 2 {
+		t.Errorf("Expected 2 create requests, got %d", fake.creates)
+	}
+}
+
+func TestCreateCollectionConcurrent(t *testing.T) {
+	client, _ := newFakeQdrant(t)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- client.CreateCollection(context.Background(), "messages", 768)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Concurrent create should succeed, got %v", err)
+		}
+	}
+}
+
+func TestCreateCollectionRealFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"status":{"error":"Wrong input: Vector size must be positive"}}`))
+	}))
+	defer server.Close()
+
+	err := NewQdrantClient(server.URL).CreateCollection(context.Background(), "messages", 0)
+	if err == nil || !strings.Contains(err.Error(), "Vector size") {
+		t.Errorf("Expected real failure to be returned, got %v", err)
+	}
+}
+
+func TestIsAlreadyExists(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   bool
+	}{
+		{"Qdrant 已存在", http.StatusBadRequest, alreadyExistsBody, true},
+		{"409 冲突", http.StatusConflict, "", true},
+		{"其他参数错误", http.StatusBadRequest, `{"status":{"error":"Wrong input"}}`, false},
+		{"服务端错误", http.StatusInternalServerError, "already exists", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isAlreadyExists(tt.status, []byte(tt.body)); got != tt.want {
+				t.Errorf("isAlreadyExists() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecreateCollectionRace(t *testing.T) {
+	tests := []struct {
+		name          string
+		concurrentDim int // 删除后其他进程创建的维度，0 表示无并发创建
+		wantErr       bool
+	}{
+		{"无并发", 0, false},
+		{"并发创建维度一致", 768, false},
+		{"并发创建维度不一致", 1024, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, fake := newFakeQdrant(t)
+			fake.collections["messages"] = 384
+			if tt.concurrentDim > 0 {
+				fake.onDelete = func(name string) { fake.collections[name] = tt.concurrentDim }
+			}
+
+			err := client.RecreateCollection(context.Background(), "messages", 768)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("RecreateCollection() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestListCollections(t *testing.T) {
+	client, _ := newFakeQdrant(t)
+	ctx := context.Background()
+
+	names, err := client.ListCollections(ctx)
+	if err != nil || len(names) != 0 {
+		t.Fatalf("ListCollections() = %v, %v, want empty", names, err)
+	}
+
+	for _, name := range []string{"messages_oc_a", "messages_oc_b"} {
+		if err := client.CreateCollection(ctx, name, 768); err != nil {
+			t.Fatal(err)
+		}
+	}
+	names, err = client.ListCollections(ctx)
+	if err != nil {
+		t.Fatalf("ListCollections() error: %v", err)
+	}
+	sort.Strings(names)
+	if strings.Join(names, ",") != "messages_oc_a,messages_oc_b" {
+		t.Errorf("ListCollections() = %v", names)
+	}
+}
+
+func TestCount(t *testing.T) {
+	var gotPath string
+	var gotBody map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		json.NewDecoder(r.Body).Decode(&gotBody)
+		w.Write([]byte(`{"result":{"count":42},"status":"ok"}`))
+	}))
+	defer server.Close()
+
+	filter := map[string]interface{}{
+		"must": []map[string]interface{}{{"key": "chat_id", "match": map[string]interface{}{"value": "oc_a"}}},
+	}
+	n, err := NewQdrantClient(server.URL).Count(context.Background(), "messages", filter)
+	if err != nil || n != 42 {
+		t.Fatalf("Count() = %d, %v, want 42", n, err)
+	}
+	if gotPath != "/collections/messages/points/count" {
+		t.Errorf("Count() path = %s", gotPath)
+	}
+	if gotBody["exact"] != true || gotBody["filter"] == nil {
+		t.Errorf("Count() body = %v, want exact count with filter", gotBody)
+	}
+}
+
+func TestCountFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"status":{"error":"Not found: Collection messages doesn't exist!"}}`))
+	}))
+	defer server.Close()
+
+	if _, err := NewQdrantClient(server.URL).Count(context.Background(), "messages", nil); err == nil {
+		t.Error("Expected error for missing collection")
+	}
+}
+
+func TestDeletePoints(t *testing.T) {
+	var paths []string
+	var bodies []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		paths = append(paths, r.URL.Path)
+		bodies = append(bodies, body)
+		w.Write([]byte(`{"result":{"status":"completed"},"status":"ok"}`))
+	}))
+	defer server.Close()
+
+	filter := map[string]interface{}{
+		"must": []map[string]interface{}{{"key": "message_id", "match": map[string]interface{}{"value": "om_1"}}},
+	}
+	if err := NewQdrantClient(server.URL).DeletePoints(context.Background(), "messages", []string{"id-1"}, filter); err != nil {
+		t.Fatalf("DeletePoints() error: %v", err)
+	}
+	if len(paths) != 2 || paths[0] != "/collections/messages/points/delete" {
+		t.Fatalf("DeletePoints() requests = %v", paths)
+	}
+	if bodies[0]["points"] == nil || bodies[0]["filter"] != nil {
+		t.Errorf("First request should delete by ID, got %v", bodies[0])
+	}
+	if bodies[1]["filter"] == nil || bodies[1]["points"] != nil {
+		t.Errorf("Second request should delete by filter, got %v", bodies[1])
+	}
+}
+
+func TestDeletePointsStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		wantErr bool
+	}{
+		{"集合不存在视为已删除", http.StatusNotFound, false},
+		{"服务端错误", http.StatusInternalServerError, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(`{"status":{"error":"failed"}}`))
+			}))
+			defer server.Close()
+
+			err := NewQdrantClient(server.URL).DeletePoints(context.Background(), "messages", []string{"id-1"}, nil)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DeletePoints() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPointsExist(t *testing.T) {
+	var gotPath string
+	var gotBody map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		json.NewDecoder(r.Body).Decode(&gotBody)
+		w.Write([]byte(`{"result":[{"id":"0f3a6c1e-0000-4000-8000-00000000000a","payload":{}}],"status":"ok"}`))
+	}))
+	defer server.Close()
+
+	ids := []string{"0F3A6C1E-0000-4000-8000-00000000000A", "0f3a6c1e-0000-4000-8000-00000000000b"}
+	got, err := NewQdrantClient(server.URL).PointsExist(context.Background(), "messages", ids)
+	if err != nil {
+		t.Fatalf("PointsExist() error: %v", err)
+	}
+	if len(got) != 1 || !got[ids[0]] {
+		t.Errorf("PointsExist() = %v, want only %s", got, ids[0])
+	}
+	if gotPath != "/collections/messages/points" {
+		t.Errorf("PointsExist() path = %s", gotPath)
+	}
+	if gotBody["with_payload"] != false || gotBody["with_vector"] != false {
+		t.Errorf("PointsExist() should not fetch payload or vector, body = %v", gotBody)
+	}
+}
+
+func TestPointsExistStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		wantErr bool
+	}{
+		{"集合不存在视为都不存在", http.StatusNotFound, false},
+		{"服务端错误", http.StatusInternalServerError, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(`{"status":{"error":"failed"}}`))
+			}))
+			defer server.Close()
+
+			got, err := NewQdrantClient(server.URL).PointsExist(context.Background(), "messages", []string{"id-1"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("PointsExist() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != 0 {
+				t.Errorf("PointsExist() = %v, want none", got)
+			}
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	var gotQuery string
+	var gotBody map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.RawQuery
+		json.NewDecoder(r.Body).Decode(&gotBody)
+		w.Write([]byte(`{"result":{"status":"completed"},"status":"ok"}`))
+	}))
+	defer server.Close()
+
+	if err := NewQdrantClient(server.URL).Delete(context.Background(), "messages", []string{"id-1", "id-2"}); err != nil {
+		t.Fatalf("Delete() error: %v", err)
+	}
+	if gotQuery != "wait=true" {
+		t.Errorf("Delete() query = %q, want wait=true", gotQuery)
+	}
+	if ids, _ := gotBody["points"].([]interface{}); len(ids) != 2 {
+		t.Errorf("Delete() body = %v", gotBody)
+	}
+}